@@ -0,0 +1,289 @@
+// Package accounting sums up the resources, that each meeting and each
+// organization uses, so hosting providers can attribute the costs to their
+// customers.
+//
+// For each meeting, it counts the sent bytes, the time of the open connections
+// and the time of the restrictions. A connection belongs to a meeting, if its
+// first message contains fields of exactly one meeting. Other connections are
+// counted for the meeting 0.
+//
+// The totals are kept since the start of the instance. Each instance counts
+// its own connections.
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envAccounting = environment.NewVariable("AUTOUPDATE_ACCOUNTING", "false", "Sum up the sent bytes, the connection time and the restriction time of each meeting and organization.")
+
+// DefaultOrganization is the name of the organization of the environment.
+const DefaultOrganization = "default"
+
+var state struct {
+	mu      sync.Mutex
+	enabled bool
+	totals  map[string]map[int]*usage
+	open    map[*Connection]struct{}
+}
+
+// usage is the consumption of one meeting.
+type usage struct {
+	bytes      int64
+	connection time.Duration
+	restrict   time.Duration
+}
+
+func (u usage) export() Usage {
+	return Usage{
+		BytesSent:         u.bytes,
+		ConnectionSeconds: int64(u.connection.Seconds()),
+		RestrictMS:        u.restrict.Milliseconds(),
+	}
+}
+
+// Usage is the consumption of a meeting or an organization.
+type Usage struct {
+	BytesSent         int64 `json:"bytes_sent"`
+	ConnectionSeconds int64 `json:"connection_seconds"`
+	RestrictMS        int64 `json:"restrict_ms"`
+}
+
+// Organization is the consumption of an organization and of each of its
+// meetings.
+type Organization struct {
+	Usage
+	Meetings map[int]Usage `json:"meetings"`
+}
+
+// Configure reads the settings from the environment.
+func Configure(lookup environment.Environmenter) error {
+	enabled, err := strconv.ParseBool(envAccounting.Value(lookup))
+	if err != nil {
+		return fmt.Errorf("invalid value for `%s`, expected boolean got %s: %w", envAccounting.Key, envAccounting.Value(lookup), err)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.enabled = enabled
+	return nil
+}
+
+// Enabled tells, if the accounting is enabled.
+func Enabled() bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.enabled
+}
+
+// Connection counts the consumption of one request.
+//
+// A nil Connection counts nothing.
+type Connection struct {
+	organization string
+	meetingID    int
+	meetingKnown bool
+
+	// flushed is the time until the connection time was added to the totals.
+	flushed time.Time
+
+	// The values, that are not added to the totals.
+	bytes    int64
+	restrict time.Duration
+}
+
+type contextKey struct{}
+
+// Start starts to count a request of an organization. Close has to be called,
+// when the request is finished.
+//
+// Returns a nil Connection, if the accounting is disabled.
+func Start(ctx context.Context, organization string) (context.Context, *Connection) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.enabled {
+		return ctx, nil
+	}
+
+	c := &Connection{organization: organization, flushed: time.Now()}
+	if state.open == nil {
+		state.open = make(map[*Connection]struct{})
+	}
+	state.open[c] = struct{}{}
+
+	return context.WithValue(ctx, contextKey{}, c), c
+}
+
+// FromContext returns the Connection of a request or nil.
+func FromContext(ctx context.Context) *Connection {
+	c, _ := ctx.Value(contextKey{}).(*Connection)
+	return c
+}
+
+// AddRestrictTime adds the time of a restriction to the connection of the
+// context.
+func AddRestrictTime(ctx context.Context, duration time.Duration) {
+	c := FromContext(ctx)
+	if c == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	c.restrict += duration
+}
+
+// SetMeeting sets the meeting of the connection. Only the first call is used.
+// A meeting id of 0 means, that the connection belongs to no meeting.
+func (c *Connection) SetMeeting(meetingID int) {
+	if c == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if c.meetingKnown {
+		return
+	}
+	c.meetingID = meetingID
+	c.meetingKnown = true
+}
+
+// Sent counts the bytes of a message.
+func (c *Connection) Sent(bytes int) {
+	if c == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if _, ok := state.open[c]; !ok {
+		return
+	}
+
+	c.bytes += int64(bytes)
+	if c.meetingKnown {
+		c.flush(time.Now())
+	}
+}
+
+// Close adds the values of the connection to the totals. Later values are
+// ignored.
+func (c *Connection) Close() {
+	if c == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if _, ok := state.open[c]; !ok {
+		return
+	}
+
+	c.flush(time.Now())
+	delete(state.open, c)
+}
+
+// flush adds the values to the totals. Has to be called with the lock.
+func (c *Connection) flush(now time.Time) {
+	if state.totals == nil {
+		state.totals = make(map[string]map[int]*usage)
+	}
+
+	meetings := state.totals[c.organization]
+	if meetings == nil {
+		meetings = make(map[int]*usage)
+		state.totals[c.organization] = meetings
+	}
+
+	u := meetings[c.meetingID]
+	if u == nil {
+		u = new(usage)
+		meetings[c.meetingID] = u
+	}
+
+	u.bytes += c.bytes
+	u.restrict += c.restrict
+	u.connection += now.Sub(c.flushed)
+
+	c.bytes = 0
+	c.restrict = 0
+	c.flushed = now
+}
+
+// Totals returns the consumption of each organization including the open
+// connections.
+func Totals() map[string]Organization {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	now := time.Now()
+	for c := range state.open {
+		if c.meetingKnown {
+			c.flush(now)
+		}
+	}
+
+	out := make(map[string]Organization, len(state.totals))
+	for name, meetings := range state.totals {
+		var sum usage
+		org := Organization{Meetings: make(map[int]Usage, len(meetings))}
+		for meetingID, u := range meetings {
+			org.Meetings[meetingID] = u.export()
+			sum.bytes += u.bytes
+			sum.connection += u.connection
+			sum.restrict += u.restrict
+		}
+		org.Usage = sum.export()
+		out[name] = org
+	}
+	return out
+}
+
+// Metric adds the totals of each organization and meeting to the metric.
+//
+// The values of the default organization have the prefix `accounting_`, the
+// values of other organizations the prefix from prefix.
+func Metric(prefix func(organization string) string) func(con metric.Container) {
+	return func(con metric.Container) {
+		for key, value := range metricValues(prefix) {
+			con.Add(key, value)
+		}
+	}
+}
+
+func metricValues(prefix func(organization string) string) map[string]int {
+	values := make(map[string]int)
+	add := func(p string, u Usage) {
+		values[p+"bytes_sent"] = int(u.BytesSent)
+		values[p+"connection_seconds"] = int(u.ConnectionSeconds)
+		values[p+"restrict_ms"] = int(u.RestrictMS)
+	}
+
+	for name, org := range Totals() {
+		p := "accounting_"
+		if name != DefaultOrganization {
+			p = prefix(name) + p
+		}
+
+		add(p, org.Usage)
+		for meetingID, u := range org.Meetings {
+			add(fmt.Sprintf("%smeeting_%d_", p, meetingID), u)
+		}
+	}
+	return values
+}
@@ -0,0 +1,114 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func reset(t *testing.T, enabled string) {
+	t.Helper()
+
+	state.mu.Lock()
+	state.totals = nil
+	state.open = nil
+	state.mu.Unlock()
+
+	if err := Configure(environment.ForTests{"AUTOUPDATE_ACCOUNTING": enabled}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	t.Cleanup(func() {
+		Configure(environment.ForTests{})
+	})
+}
+
+func TestDisabled(t *testing.T) {
+	reset(t, "false")
+
+	ctx, c := Start(context.Background(), DefaultOrganization)
+	if c != nil {
+		t.Fatalf("Start returned a connection, expected nil")
+	}
+
+	AddRestrictTime(ctx, time.Second)
+	c.SetMeeting(5)
+	c.Sent(100)
+	c.Close()
+
+	if got := Totals(); len(got) != 0 {
+		t.Errorf("got totals %v, expected none", got)
+	}
+}
+
+func TestConnection(t *testing.T) {
+	reset(t, "true")
+
+	ctx, c := Start(context.Background(), DefaultOrganization)
+	if FromContext(ctx) != c {
+		t.Fatalf("FromContext returned an other connection")
+	}
+
+	AddRestrictTime(ctx, 3*time.Millisecond)
+	c.Sent(10)
+
+	if got := Totals(); len(got) != 0 {
+		t.Errorf("got totals %v before the meeting was known", got)
+	}
+
+	c.SetMeeting(5)
+	c.SetMeeting(6)
+	c.Sent(100)
+	AddRestrictTime(ctx, 2*time.Millisecond)
+
+	_, other := Start(context.Background(), "example.com")
+	other.SetMeeting(0)
+	other.Sent(7)
+	other.Close()
+
+	c.Close()
+	c.Sent(1000)
+	c.Close()
+
+	totals := Totals()
+	org := totals[DefaultOrganization]
+	if org.BytesSent != 110 || org.RestrictMS != 5 {
+		t.Errorf("got organization %v, expected 110 bytes and 5ms", org.Usage)
+	}
+
+	if len(org.Meetings) != 1 || org.Meetings[5].BytesSent != 110 {
+		t.Errorf("got meetings %v, expected meeting 5 with 110 bytes", org.Meetings)
+	}
+
+	if got := totals["example.com"].Meetings[0].BytesSent; got != 7 {
+		t.Errorf("other organization sent %d bytes, expected 7", got)
+	}
+}
+
+func TestMetric(t *testing.T) {
+	reset(t, "true")
+
+	_, c := Start(context.Background(), DefaultOrganization)
+	c.SetMeeting(5)
+	c.Sent(100)
+	c.Close()
+
+	_, other := Start(context.Background(), "example.com")
+	other.SetMeeting(7)
+	other.Sent(3)
+	other.Close()
+
+	values := metricValues(func(name string) string { return "tenant_" + name + "_" })
+
+	for key, expect := range map[string]int{
+		"accounting_bytes_sent":                              100,
+		"accounting_meeting_5_bytes_sent":                    100,
+		"tenant_example.com_accounting_bytes_sent":           3,
+		"tenant_example.com_accounting_meeting_7_bytes_sent": 3,
+	} {
+		if got, ok := values[key]; !ok || got != expect {
+			t.Errorf("metric %s is %d, expected %d", key, got, expect)
+		}
+	}
+}
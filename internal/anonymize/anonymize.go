@@ -0,0 +1,275 @@
+// Package anonymize pseudonymizes personal data on the fly.
+//
+// Demo and training instances can use a copy of the production data. The
+// anonymizer replaces the personal fields like names and emails with
+// pseudonyms and removes the connection between the votes and the users,
+// before the data is cached. So each response only contains the pseudonyms.
+//
+// The pseudonyms are calculated with HMAC-SHA256 from the value. The same
+// value gets the same pseudonym on all instances with the same key, so for
+// example two users with the same last name still have the same last name.
+package anonymize
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// defaultFields are the personal fields of the models.
+const defaultFields = "user/username:text,user/first_name:first_name,user/last_name:last_name,user/email:email,user/title:null,user/pronoun:null,user/member_number:text,user/saml_id:null,user/default_password:null,meeting_user/number:text,meeting_user/about_me:null,meeting_user/comment:null,personal_note/note:null,vote/user_id:null,vote/delegated_user_id:null,vote/user_token:null,user/vote_ids:null,user/delegated_vote_ids:null,poll/votes_raw:null,poll/entitled_users_at_stop:null"
+
+var (
+	envAnonymize  = environment.NewVariable("AUTOUPDATE_ANONYMIZE", "false", "Replace personal fields like names, emails and votes with pseudonyms in all responses. For demo and training instances with a copy of production data.")
+	envFields     = environment.NewVariable("AUTOUPDATE_ANONYMIZE_FIELDS", defaultFields, "Comma separated list of `collection/field:method` with the fields, that are anonymized. The methods are `first_name`, `last_name`, `email`, `text` and `null`.")
+	envSecretFile = environment.NewVariable("AUTOUPDATE_ANONYMIZE_SECRET_FILE", "/run/secrets/autoupdate_anonymize_secret", "Path of the key for the pseudonyms. With the same key, a value gets the same pseudonym.")
+)
+
+// method tells, how the value of a field is replaced.
+type method string
+
+const (
+	methodFirstName method = "first_name"
+	methodLastName  method = "last_name"
+	methodEmail     method = "email"
+	methodText      method = "text"
+	methodNull      method = "null"
+)
+
+var firstNames = []string{
+	"Alex", "Billie", "Charlie", "Dana", "Eli", "Finley", "Gabriel", "Harper",
+	"Indra", "Jules", "Kai", "Lou", "Mika", "Noa", "Oli", "Pat",
+	"Quinn", "Robin", "Sam", "Toni", "Uli", "Val", "Wren", "Yuki",
+}
+
+var lastNames = []string{
+	"Abel", "Berger", "Castell", "Dorn", "Eck", "Falk", "Graf", "Hahn",
+	"Imhof", "Jansen", "Keller", "Lorenz", "Maurer", "Nowak", "Ott", "Peters",
+	"Quast", "Roth", "Sommer", "Thal", "Ulrich", "Vogt", "Winter", "Zeller",
+}
+
+// transformed is the number of values, that were replaced.
+var transformed atomic.Uint64
+
+// Anonymizer replaces the values of the personal fields.
+//
+// A nil Anonymizer does not change the data.
+type Anonymizer struct {
+	key     []byte
+	methods map[int]method
+}
+
+// New creates an Anonymizer from the environment. Returns nil, if the
+// anonymization is disabled.
+func New(lookup environment.Environmenter) (*Anonymizer, error) {
+	active, err := strconv.ParseBool(envAnonymize.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected boolean got %s: %w", envAnonymize.Key, envAnonymize.Value(lookup), err)
+	}
+
+	if !active {
+		return nil, nil
+	}
+
+	methods, err := parseFields(envFields.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envFields.Key, err)
+	}
+
+	key, err := environment.ReadSecret(lookup, envSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading anonymization key: %w", err)
+	}
+
+	return &Anonymizer{
+		key:     []byte(key),
+		methods: methods,
+	}, nil
+}
+
+// parseFields parses a list like `user/first_name:first_name,user/email:email`.
+func parseFields(value string) (map[int]method, error) {
+	methods := make(map[int]method)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		collectionField, rawMethod, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("`%s` has no method", entry)
+		}
+
+		collection, field, _ := strings.Cut(collectionField, "/")
+		id := dskey.CollectionFieldID(collection, field)
+		if id == -1 {
+			return nil, fmt.Errorf("%s does not exist", collectionField)
+		}
+
+		m := method(rawMethod)
+		switch m {
+		case methodFirstName, methodLastName, methodEmail, methodText, methodNull:
+		default:
+			return nil, fmt.Errorf("%s: unknown method `%s`", collectionField, rawMethod)
+		}
+
+		methods[id] = m
+	}
+	return methods, nil
+}
+
+// Data replaces the values of the personal fields in place.
+func (a *Anonymizer) Data(data map[dskey.Key][]byte) {
+	if a == nil {
+		return
+	}
+
+	for key, value := range data {
+		m, ok := a.methods[key.CollectionFieldID()]
+		if !ok || value == nil {
+			continue
+		}
+
+		data[key] = a.replace(m, key.Field(), value)
+		transformed.Add(1)
+	}
+}
+
+// Object replaces the values of the personal fields of one object in place.
+// The keys of fields are the field names.
+func (a *Anonymizer) Object(collection string, fields map[string]json.RawMessage) {
+	if a == nil {
+		return
+	}
+
+	for field, value := range fields {
+		m, ok := a.methods[dskey.CollectionFieldID(collection, field)]
+		if !ok || value == nil {
+			continue
+		}
+
+		fields[field] = a.replace(m, field, value)
+		transformed.Add(1)
+	}
+}
+
+// replace returns the pseudonym for a value. Values, that are not strings, are
+// removed. Empty strings are kept.
+func (a *Anonymizer) replace(m method, field string, value []byte) []byte {
+	if m == methodNull {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return nil
+	}
+
+	if text == "" {
+		return value
+	}
+
+	encoded, err := json.Marshal(a.pseudonym(m, field, text))
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
+
+// pseudonym calculates the pseudonym of a string.
+func (a *Anonymizer) pseudonym(m method, field string, text string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(m))
+	mac.Write([]byte{0})
+	mac.Write([]byte(text))
+	sum := mac.Sum(nil)
+
+	n := binary.BigEndian.Uint32(sum)
+	switch m {
+	case methodFirstName:
+		return firstNames[n%uint32(len(firstNames))]
+	case methodLastName:
+		return lastNames[n%uint32(len(lastNames))]
+	case methodEmail:
+		return "person-" + hex.EncodeToString(sum[:4]) + "@example.invalid"
+	default:
+		return field + "-" + hex.EncodeToString(sum[:4])
+	}
+}
+
+// CheckRaw returns an Error, if the data is anonymized. It is used for routes,
+// that return the data of the database without the model structure.
+func (a *Anonymizer) CheckRaw() error {
+	if a == nil {
+		return nil
+	}
+	return Error{msg: "The raw data is not available, because the data of this instance is anonymized."}
+}
+
+// Flow anonymizes the data of f. It is used below the cache.
+func (a *Anonymizer) Flow(f flow.Flow) flow.Flow {
+	if a == nil {
+		return f
+	}
+	return anonymizeFlow{flow: f, anonymizer: a}
+}
+
+type anonymizeFlow struct {
+	flow       flow.Flow
+	anonymizer *Anonymizer
+}
+
+func (f anonymizeFlow) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	data, err := f.flow.Get(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	f.anonymizer.Data(data)
+	return data, nil
+}
+
+func (f anonymizeFlow) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
+	f.flow.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		f.anonymizer.Data(data)
+		updateFn(data, err)
+	})
+}
+
+// Metric adds the number of replaced values to the metric.
+func Metric(con metric.Container) {
+	con.Add("anonymize_values", int(transformed.Load()))
+}
+
+// Error is returned for the raw data of an anonymized instance.
+type Error struct {
+	msg string
+}
+
+func (e Error) Error() string {
+	return e.msg
+}
+
+// Type is the type of the error for the client.
+func (e Error) Type() string {
+	return "anonymized"
+}
+
+// StatusCode is the http status of the error.
+func (e Error) StatusCode() int {
+	return http.StatusForbidden
+}
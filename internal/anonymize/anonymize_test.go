@@ -0,0 +1,217 @@
+package anonymize
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestNewDisabled(t *testing.T) {
+	a, err := New(environment.ForTests{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if a != nil {
+		t.Errorf("got an anonymizer, expected nil")
+	}
+
+	data := map[dskey.Key][]byte{dskey.MustKey("user/1/first_name"): []byte(`"Max"`)}
+	a.Data(data)
+	if got := string(data[dskey.MustKey("user/1/first_name")]); got != `"Max"` {
+		t.Errorf("nil anonymizer changed the value to %s", got)
+	}
+
+	if err := a.CheckRaw(); err != nil {
+		t.Errorf("CheckRaw: %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		fields string
+		valid  bool
+	}{
+		{"default", defaultFields, true},
+		{"empty", "", true},
+		{"no method", "user/email", false},
+		{"unknown field", "user/unknown:null", false},
+		{"unknown method", "user/email:hash", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(environment.ForTests{
+				"AUTOUPDATE_ANONYMIZE":        "true",
+				"AUTOUPDATE_ANONYMIZE_FIELDS": tt.fields,
+				"OPENSLIDES_DEVELOPMENT":      "true",
+			})
+
+			if tt.valid && err != nil {
+				t.Errorf("New: %v", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Errorf("New returned no error")
+			}
+		})
+	}
+}
+
+func newTestAnonymizer(t *testing.T) *Anonymizer {
+	t.Helper()
+
+	a, err := New(environment.ForTests{
+		"AUTOUPDATE_ANONYMIZE":   "true",
+		"OPENSLIDES_DEVELOPMENT": "true",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func TestData(t *testing.T) {
+	a := newTestAnonymizer(t)
+
+	data := dsmock.YAMLData(`---
+	user:
+		1:
+			first_name: Max
+			last_name: Mustermann
+			email: max@example.com
+			username: max
+			title: Dr.
+			is_active: true
+		2:
+			last_name: Mustermann
+	vote/3:
+		user_id: 1
+		value: "Y"
+	`)
+	data[dskey.MustKey("user/1/member_number")] = []byte(`""`)
+
+	a.Data(data)
+
+	get := func(key string) string {
+		return string(data[dskey.MustKey(key)])
+	}
+
+	for _, key := range []string{"user/1/first_name", "user/1/last_name", "user/1/email", "user/1/username"} {
+		var value string
+		if err := json.Unmarshal([]byte(get(key)), &value); err != nil || value == "" {
+			t.Errorf("%s is %s, expected a pseudonym", key, get(key))
+		}
+	}
+
+	if strings.Contains(get("user/1/email"), "max") || !strings.HasSuffix(get("user/1/email"), `@example.invalid"`) {
+		t.Errorf("got email %s", get("user/1/email"))
+	}
+
+	if !strings.HasPrefix(get("user/1/username"), `"username-`) {
+		t.Errorf("got username %s", get("user/1/username"))
+	}
+
+	if get("user/1/last_name") != get("user/2/last_name") {
+		t.Errorf("same last name got different pseudonyms: %s and %s", get("user/1/last_name"), get("user/2/last_name"))
+	}
+
+	if v := data[dskey.MustKey("user/1/title")]; v != nil {
+		t.Errorf("title is %s, expected nil", v)
+	}
+
+	if v := data[dskey.MustKey("vote/3/user_id")]; v != nil {
+		t.Errorf("vote/3/user_id is %s, expected nil", v)
+	}
+
+	if get("user/1/member_number") != `""` {
+		t.Errorf("empty member_number was changed to %s", get("user/1/member_number"))
+	}
+
+	if get("user/1/is_active") != `true` || get("vote/3/value") != `"Y"` {
+		t.Errorf("other fields were changed")
+	}
+}
+
+func TestDataConsistent(t *testing.T) {
+	a := newTestAnonymizer(t)
+	key := dskey.MustKey("user/1/email")
+
+	first := map[dskey.Key][]byte{key: []byte(`"max@example.com"`)}
+	second := map[dskey.Key][]byte{key: []byte(`"max@example.com"`)}
+	a.Data(first)
+	a.Data(second)
+
+	if string(first[key]) != string(second[key]) {
+		t.Errorf("same value got the pseudonyms %s and %s", first[key], second[key])
+	}
+
+	other := &Anonymizer{key: []byte("other key"), methods: a.methods}
+	third := map[dskey.Key][]byte{key: []byte(`"max@example.com"`)}
+	other.Data(third)
+
+	if string(first[key]) == string(third[key]) {
+		t.Errorf("other key got the same pseudonym %s", third[key])
+	}
+}
+
+func TestObject(t *testing.T) {
+	a := newTestAnonymizer(t)
+
+	object := map[string]json.RawMessage{
+		"first_name": json.RawMessage(`"Max"`),
+		"id":         json.RawMessage(`1`),
+	}
+	a.Object("user", object)
+
+	if string(object["first_name"]) == `"Max"` {
+		t.Errorf("first_name was not anonymized")
+	}
+
+	if string(object["id"]) != `1` {
+		t.Errorf("id was changed to %s", object["id"])
+	}
+}
+
+func TestFlow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a := newTestAnonymizer(t)
+	ds := dsmock.NewFlow(dsmock.YAMLData("user/1/email: max@example.com"))
+	flow := a.Flow(ds)
+	key := dskey.MustKey("user/1/email")
+
+	data, err := flow.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if strings.Contains(string(data[key]), "max") {
+		t.Errorf("Get returned %s, expected a pseudonym", data[key])
+	}
+
+	updated := make(chan []byte, 1)
+	go flow.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		updated <- data[key]
+	})
+	ds.Send(map[dskey.Key][]byte{key: []byte(`"moritz@example.com"`)})
+
+	select {
+	case value := <-updated:
+		if strings.Contains(string(value), "moritz") {
+			t.Errorf("Update returned %s, expected a pseudonym", value)
+		}
+	case <-ctx.Done():
+		t.Fatalf("no update")
+	}
+
+	if _, ok := a.CheckRaw().(Error); !ok {
+		t.Errorf("CheckRaw returned no Error")
+	}
+}
@@ -0,0 +1,38 @@
+// Package arena allocates the short lived memory of one big request, so it can
+// be freed at once at the end of the request.
+//
+// With GOEXPERIMENT=arenas, the memory comes from an arena of the go runtime.
+// The garbage collector does not have to scan the many small objects and they
+// are freed together with Free. Without the experiment, the memory is
+// allocated as usual and Free does nothing.
+//
+// The memory must not be used after Free. Only use it for values, that are
+// not needed after the request.
+package arena
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a context with a new arena. The caller has to call Free
+// on the arena, when the request is done.
+func NewContext(ctx context.Context) (context.Context, *Arena) {
+	a := New()
+	return context.WithValue(ctx, contextKey{}, a), a
+}
+
+// FromContext returns the arena from the context or nil, if there is none.
+func FromContext(ctx context.Context) *Arena {
+	a, _ := ctx.Value(contextKey{}).(*Arena)
+	return a
+}
+
+// MakeSlice creates a slice like make.
+//
+// If the arena is nil, the slice is allocated as usual.
+func MakeSlice[T any](a *Arena, length, capacity int) []T {
+	if a == nil {
+		return make([]T, length, capacity)
+	}
+	return makeSlice[T](a, length, capacity)
+}
@@ -0,0 +1,24 @@
+//go:build !goexperiment.arenas
+
+package arena
+
+// Enabled tells, if the memory comes from an arena of the go runtime.
+const Enabled = false
+
+// Arena holds the memory of one request.
+//
+// Without the arenas experiment, it is empty and the memory is allocated as
+// usual.
+type Arena struct{}
+
+// New creates an arena.
+func New() *Arena {
+	return &Arena{}
+}
+
+func makeSlice[T any](_ *Arena, length, capacity int) []T {
+	return make([]T, length, capacity)
+}
+
+// Free does nothing without the arenas experiment.
+func (a *Arena) Free() {}
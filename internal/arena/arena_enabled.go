@@ -0,0 +1,57 @@
+//go:build goexperiment.arenas
+
+package arena
+
+import (
+	goarena "arena"
+	"sync"
+)
+
+// Enabled tells, if the memory comes from an arena of the go runtime.
+const Enabled = true
+
+// Arena holds the memory of one request.
+//
+// The runtime arena is created on the first allocation, so a request, that
+// does not use it, costs nothing. An Arena can be used from many goroutines.
+type Arena struct {
+	mu    sync.Mutex
+	arena *goarena.Arena
+	freed bool
+}
+
+// New creates an arena.
+func New() *Arena {
+	return &Arena{}
+}
+
+func makeSlice[T any](a *Arena, length, capacity int) []T {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.freed {
+		return make([]T, length, capacity)
+	}
+
+	if a.arena == nil {
+		a.arena = goarena.NewArena()
+	}
+	return goarena.MakeSlice[T](a.arena, length, capacity)
+}
+
+// Free frees all memory of the arena. Later allocations are allocated as
+// usual. Free on a nil arena does nothing.
+func (a *Arena) Free() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.arena != nil {
+		a.arena.Free()
+		a.arena = nil
+	}
+	a.freed = true
+}
@@ -0,0 +1,57 @@
+package arena_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/arena"
+)
+
+func TestMakeSlice(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		arena *arena.Arena
+	}{
+		{"without arena", nil},
+		{"with arena", arena.New()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.arena.Free()
+
+			s := arena.MakeSlice[int](tt.arena, 2, 10)
+			if len(s) != 2 || cap(s) != 10 {
+				t.Fatalf("got slice with len %d and cap %d, expected 2 and 10", len(s), cap(s))
+			}
+
+			s = append(s, 1, 2, 3)
+			if s[0] != 0 || s[4] != 3 {
+				t.Errorf("got %v, expected [0 0 1 2 3]", s)
+			}
+		})
+	}
+}
+
+func TestAfterFree(t *testing.T) {
+	a := arena.New()
+	arena.MakeSlice[byte](a, 0, 100)
+	a.Free()
+
+	s := arena.MakeSlice[byte](a, 0, 100)
+	s = append(s, "allocated as usual"...)
+	if string(s) != "allocated as usual" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if got := arena.FromContext(context.Background()); got != nil {
+		t.Errorf("got an arena from an empty context")
+	}
+
+	ctx, a := arena.NewContext(context.Background())
+	defer a.Free()
+
+	if got := arena.FromContext(ctx); got != a {
+		t.Errorf("got arena %p, expected %p", got, a)
+	}
+}
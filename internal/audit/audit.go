@@ -0,0 +1,223 @@
+// Package audit writes a tamper-evident log of the usage of the internal
+// routes.
+//
+// Each entry is one json object per line. It contains the hash of the previous
+// entry and its own hash. Changing or removing an entry breaks the chain,
+// which is detected by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envAuditFile = environment.NewVariable("AUTOUPDATE_AUDIT_FILE", "", "Path of a file, where each call of an internal route, a debug route or an export route is written with a hash chain. Empty disables the audit log.")
+
+// Entry is one call of an audited route.
+type Entry struct {
+	Seq        int64     `json:"seq"`
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id"`
+	UserID     int       `json:"user_id"`
+	ClientIP   string    `json:"client_ip"`
+	Route      string    `json:"route"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	Body       string    `json:"body,omitempty"`
+	Status     int       `json:"status"`
+	DurationMS int64     `json:"duration_ms"`
+
+	// PrevHash is the hash of the previous entry. It is empty for the first
+	// entry.
+	PrevHash string `json:"prev_hash"`
+
+	// Hash is the hash of this entry without the field Hash.
+	Hash string `json:"hash"`
+}
+
+// hash returns the hash of the entry.
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	bs, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("encoding entry: %w", err)
+	}
+
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log appends entries to the audit file.
+//
+// Has to be created with New.
+type Log struct {
+	mu       sync.Mutex
+	file     *os.File
+	seq      int64
+	lastHash string
+}
+
+// New opens the audit file from the environment. New entries are appended to
+// the existing chain.
+//
+// Returns nil, if the audit log is disabled.
+func New(lookup environment.Environmenter) (*Log, error) {
+	path := envAuditFile.Value(lookup)
+	if path == "" {
+		return nil, nil
+	}
+
+	return Open(path)
+}
+
+// Open opens an audit file. New entries are appended to the existing chain.
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+
+	last, err := lastEntry(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading audit file %s: %w", path, err)
+	}
+
+	return &Log{
+		file:     file,
+		seq:      last.Seq,
+		lastHash: last.Hash,
+	}, nil
+}
+
+// lastEntry returns the last entry of the file. Returns an empty entry, if the
+// file is empty.
+func lastEntry(r io.Reader) (Entry, error) {
+	var last Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+			return Entry{}, fmt.Errorf("decoding entry: %w", err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Entry{}, fmt.Errorf("reading entries: %w", err)
+	}
+	return last, nil
+}
+
+// Write adds an entry to the chain.
+//
+// The fields Seq, PrevHash and Hash are set by Write. If the Time is zero, the
+// current time is used.
+func (l *Log) Write(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = l.seq + 1
+	e.PrevHash = l.lastHash
+
+	hash, err := e.hash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	bs, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding entry: %w", err)
+	}
+
+	if _, err := l.file.Write(append(bs, '\n')); err != nil {
+		return fmt.Errorf("writing audit entry: %w", err)
+	}
+
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("syncing audit file: %w", err)
+	}
+
+	l.seq = e.Seq
+	l.lastHash = e.Hash
+	return nil
+}
+
+// Close closes the audit file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// Verify checks the hash chain of an audit file. It returns the number of
+// valid entries.
+//
+// The returned error tells the first entry, that is invalid.
+func Verify(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	var prev Entry
+	var count int
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return count, fmt.Errorf("line %d: decoding entry: %w", line, err)
+		}
+
+		if e.Seq != prev.Seq+1 {
+			return count, fmt.Errorf("line %d: got sequence %d, expected %d", line, e.Seq, prev.Seq+1)
+		}
+
+		if e.PrevHash != prev.Hash {
+			return count, fmt.Errorf("line %d: previous hash does not match entry %d", line, prev.Seq)
+		}
+
+		hash, err := e.hash()
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if hash != e.Hash {
+			return count, fmt.Errorf("line %d: %w", line, ErrModified)
+		}
+
+		prev = e
+		count++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("reading entries: %w", err)
+	}
+	return count, nil
+}
+
+// ErrModified is returned by Verify, if the hash of an entry does not match
+// its content.
+var ErrModified = errors.New("entry was modified")
@@ -0,0 +1,84 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/audit"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func writeEntries(t *testing.T, path string, paths ...string) {
+	t.Helper()
+
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer log.Close()
+
+	for _, p := range paths {
+		if err := log.Write(audit.Entry{Method: "GET", Path: p, Status: 200}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	writeEntries(t, path, "/internal/autoupdate", "/debug/runtime")
+	// A second process continues the chain.
+	writeEntries(t, path, "/debug/log_level")
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading file: %v", err)
+	}
+
+	count, err := audit.Verify(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("got %d valid entries, expected 3", count)
+	}
+
+	t.Run("modified entry", func(t *testing.T) {
+		modified := strings.Replace(string(content), "/debug/runtime", "/debug/pprof/", 1)
+
+		count, err := audit.Verify(strings.NewReader(modified))
+		if !errors.Is(err, audit.ErrModified) {
+			t.Errorf("got error %v, expected ErrModified", err)
+		}
+
+		if count != 1 {
+			t.Errorf("got %d valid entries, expected 1", count)
+		}
+	})
+
+	t.Run("removed entry", func(t *testing.T) {
+		lines := strings.Split(string(content), "\n")
+		removed := strings.Join(append(lines[:1:1], lines[2:]...), "\n")
+
+		if _, err := audit.Verify(strings.NewReader(removed)); err == nil {
+			t.Errorf("got no error after removing an entry")
+		}
+	})
+}
+
+func TestDisabled(t *testing.T) {
+	log, err := audit.New(environment.ForTests{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if log != nil {
+		t.Errorf("got an audit log, expected nil")
+	}
+}
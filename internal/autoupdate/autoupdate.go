@@ -7,7 +7,9 @@
 package autoupdate
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -15,11 +17,19 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/capture"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/errorreport"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/lifecycle"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/presenter"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict/collection"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict/perm"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
@@ -38,6 +48,7 @@ const (
 var (
 	envConcurentWorker = environment.NewVariable("CONCURENT_WORKER", "0", "Amount of clients that calculate there values at the same time. Default to GOMAXPROCS.")
 	envCacheReset      = environment.NewVariable("CACHE_RESET", "24h", "Time to reset the cache.")
+	envPublishWindow   = environment.NewVariable("AUTOUPDATE_PUBLISH_WINDOW", "5ms", "Time, how long datastore updates are collected, before the connections are informed. Zero informs them on each update.")
 )
 
 // KeysBuilder holds the keys that are requested by a user.
@@ -57,6 +68,27 @@ type Autoupdate struct {
 	pool       *workPool
 
 	cacheReset time.Duration
+	retention  historyRetention
+
+	publishWindow time.Duration
+	batch         *publishBatch
+	parking       *parking
+
+	search     *searchIndex
+	presenters *presenter.Store
+
+	// tenantRetention is the history retention of tenants with their own
+	// setting.
+	tenantRetention map[string]historyRetention
+
+	// pruneInterval is the time between two runs of pruneHistory.
+	pruneInterval time.Duration
+
+	// published holds the time of each topic id.
+	published struct {
+		mu    sync.Mutex
+		times map[uint64]time.Time
+	}
 }
 
 // New creates a new autoupdate service.
@@ -78,29 +110,72 @@ func New(lookup environment.Environmenter, flow flow.Flow, restricter RestrictMi
 		return nil, nil, fmt.Errorf("invalid value for `CACHE_RESET`, expected duration got %s: %w", envCacheReset.Value(lookup), err)
 	}
 
+	publishWindow, err := environment.ParseDuration(envPublishWindow.Value(lookup))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envPublishWindow.Key, envPublishWindow.Value(lookup), err)
+	}
+
+	retention, err := parseHistoryRetention(lookup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tenantRetention, err := parseTenantRetentions(lookup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pruneInterval, err := parsePruneInterval(lookup)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	a := &Autoupdate{
 		flow:       flow,
 		topic:      topic.New[dskey.Key](),
 		restricter: restricter,
 		pool:       newWorkPool(workers),
 		cacheReset: cacheResetTime,
+		retention:  retention,
+
+		publishWindow: publishWindow,
+		batch:         newPublishBatch(),
+		parking:       newParking(),
+		search:        newSearchIndex(),
+		presenters:    presenter.Presenters(),
+
+		tenantRetention: tenantRetention,
+		pruneInterval:   pruneInterval,
 	}
+	a.published.times = make(map[uint64]time.Time)
 
 	background := func(ctx context.Context, errorHandler func(error)) {
 		go a.pruneOldData(ctx)
 		go a.resetCache(ctx)
+		go a.pruneHistory(ctx, errorHandler)
+		if a.publishWindow > 0 {
+			go a.publishLoop(ctx)
+		}
 		go a.flow.Update(ctx, func(data map[dskey.Key][]byte, err error) {
 			if err != nil {
 				oserror.Handle(err)
+				errorreport.Capture(ctx, err, map[string]string{"kind": "datastore_update"})
+				emitResync(err)
 				// Continue. The update function can return an error and data.
 			}
 
+			capture.Update(data)
+
 			keys := make([]dskey.Key, 0, len(data))
 			for k := range data {
 				keys = append(keys, k)
 			}
 
-			a.topic.Publish(keys...)
+			if a.publishWindow > 0 {
+				a.batch.add(keys)
+				return
+			}
+			a.publish(keys, clock.Now())
 		})
 	}
 
@@ -131,12 +206,12 @@ func (a *Autoupdate) Connect(ctx context.Context, userID int, kb KeysBuilder) (C
 func (a *Autoupdate) SingleData(ctx context.Context, userID int, kb KeysBuilder) (map[dskey.Key][]byte, error) {
 	ctx, restricter := a.restricter(ctx, a.flow, userID)
 
-	keys, err := kb.Update(ctx, restricter)
+	keys, err := updateKeys(ctx, kb, restricter)
 	if err != nil {
 		return nil, fmt.Errorf("create keys for keysbuilder: %w", err)
 	}
 
-	data, err := restricter.Get(ctx, keys...)
+	data, err := restrictedGet(ctx, restricter, keys)
 	if err != nil {
 		return nil, fmt.Errorf("get restricted data: %w", err)
 	}
@@ -153,19 +228,68 @@ func (a *Autoupdate) SingleData(ctx context.Context, userID int, kb KeysBuilder)
 // pruneOldData removes old data from the topic. Blocks until the service is
 // closed.
 func (a *Autoupdate) pruneOldData(ctx context.Context) {
-	tick := time.NewTicker(time.Minute)
+	tick := clock.NewTicker(time.Minute)
 	defer tick.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-tick.C:
-			a.topic.Prune(time.Now().Add(-pruneTime))
+		case <-tick.C():
+			until := clock.Now().Add(-pruneTime)
+			a.topic.Prune(until)
+			a.prunePublished(until)
+			a.search.prune(clock.Now().Add(-searchIdle))
+		}
+	}
+}
+
+// Introspect returns the state of the topic and the work pool.
+func (a *Autoupdate) Introspect() any {
+	a.published.mu.Lock()
+	backlog := len(a.published.times)
+	a.published.mu.Unlock()
+
+	return map[string]int{
+		"topic_last_id":  int(a.topic.LastID()),
+		"topic_backlog":  backlog,
+		"workpool_size":  cap(a.pool.sem),
+		"workpool_inuse": len(a.pool.sem),
+		"parked":         a.parking.parkedCount(),
+	}
+}
+
+func (a *Autoupdate) setPublished(tid uint64, t time.Time) {
+	a.published.mu.Lock()
+	defer a.published.mu.Unlock()
+	a.published.times[tid] = t
+}
+
+// publishedTime returns the time, when the topic id was published. Returns
+// the zero time, if the id is unknown.
+func (a *Autoupdate) publishedTime(tid uint64) time.Time {
+	a.published.mu.Lock()
+	defer a.published.mu.Unlock()
+	return a.published.times[tid]
+}
+
+func (a *Autoupdate) prunePublished(until time.Time) {
+	a.published.mu.Lock()
+	defer a.published.mu.Unlock()
+	for tid, t := range a.published.times {
+		if t.Before(until) {
+			delete(a.published.times, tid)
 		}
 	}
 }
 
+// emitResync sends a resync event, if messages of the message bus were lost.
+func emitResync(err error) {
+	if errors.Is(err, flow.ErrResync) {
+		lifecycle.Emit(lifecycle.Event{Type: lifecycle.Resync, Error: err.Error()})
+	}
+}
+
 // resetCache runs in the background and cleans the cache from time to time.
 // Blocks until the service is closed.
 func (a *Autoupdate) resetCache(ctx context.Context) {
@@ -177,15 +301,16 @@ func (a *Autoupdate) resetCache(ctx context.Context) {
 		return
 	}
 
-	tick := time.NewTicker(a.cacheReset)
+	tick := clock.NewTicker(a.cacheReset)
 	defer tick.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-tick.C:
+		case <-tick.C():
 			reset.ResetCache()
+			a.search.reset()
 		}
 	}
 }
@@ -232,6 +357,18 @@ func (a *Autoupdate) skipWorkpool(ctx context.Context, userID int) (bool, error)
 	return false, nil
 }
 
+// CanProfile returns, if the user is allowed to create profiles of the
+// service. Only superadmins are allowed.
+func (a *Autoupdate) CanProfile(ctx context.Context, userID int) (bool, error) {
+	ds := dsfetch.New(a.flow)
+
+	isSuperadmin, err := perm.HasOrganizationManagementLevel(ctx, ds, userID, perm.OMLSuperadmin)
+	if err != nil {
+		return false, fmt.Errorf("getting organization management level: %w", err)
+	}
+	return isSuperadmin, nil
+}
+
 // CanSeeConnectionCount returns, if the user can see the connection count.
 //
 // If the second value is not empty, the user is only allowed for meetings in that list.
@@ -337,19 +474,407 @@ func (a *Autoupdate) FilterConnectionCount(ctx context.Context, meetingIDs []int
 
 var reValidKeys = regexp.MustCompile(`^([a-z]+|[a-z][a-z_]*[a-z])/[1-9][0-9]*`)
 
-// HistoryInformation returns the histrory information for an fqid.
-func (a *Autoupdate) HistoryInformation(ctx context.Context, uid int, fqid string, w io.Writer) error {
+// HistoryInformation returns the histrory information for an fqid or a
+// meeting.
+//
+// The second return value is the cursor for the next page or 0, if there are
+// no more entries.
+func (a *Autoupdate) HistoryInformation(ctx context.Context, uid int, query datastore.HistoryQuery) (map[string][]datastore.HistoryEntry, int, error) {
 	type History interface {
-		historyInformation(ctx context.Context, fqid string, w io.Writer) error
+		historyInformation(ctx context.Context, query datastore.HistoryQuery) (map[string][]datastore.HistoryEntry, int, error)
 	}
 	hi, ok := a.flow.(History)
+	if !ok {
+		return nil, 0, fmt.Errorf("history not supported")
+	}
+
+	query, err := a.checkHistoryQuery(ctx, uid, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries, next, err := hi.historyInformation(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting history information: %w", err)
+	}
+
+	return entries, next, nil
+}
+
+// HistoryExport writes all changes of an fqid or of all objects in a meeting
+// as newline delimited json.
+func (a *Autoupdate) HistoryExport(ctx context.Context, uid int, query datastore.HistoryQuery, w io.Writer) error {
+	type History interface {
+		historyExport(ctx context.Context, query datastore.HistoryQuery, w io.Writer) error
+	}
+	he, ok := a.flow.(History)
 	if !ok {
 		return fmt.Errorf("history not supported")
 	}
 
+	query, err := a.checkHistoryQuery(ctx, uid, query)
+	if err != nil {
+		return err
+	}
+
+	if err := he.historyExport(ctx, query, w); err != nil {
+		return fmt.Errorf("exporting history: %w", err)
+	}
+
+	return nil
+}
+
+// HistoryPosition returns the last position at or before the timestamp and
+// the time of that position.
+//
+// If meetingID is not 0, only positions that changed the meeting are used and
+// the user needs the permission to see the history of the meeting. Otherwise,
+// the user has to be an organization manager.
+func (a *Autoupdate) HistoryPosition(ctx context.Context, uid int, timestamp time.Time, meetingID int) (int, time.Time, error) {
+	type History interface {
+		positionAt(ctx context.Context, timestamp time.Time, meetingID int) (int, time.Time, error)
+	}
+	h, ok := a.flow.(History)
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("history not supported")
+	}
+
+	fqid := "organization/1"
+	if meetingID != 0 {
+		fqid = fmt.Sprintf("meeting/%d", meetingID)
+	}
+
+	permMeetingID, err := a.checkHistoryPermission(ctx, uid, fqid)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if since := a.retentionFor(ctx).since(permMeetingID); timestamp.Before(since) {
+		return 0, time.Time{}, invalidInputError{fmt.Sprintf("the history before %s is not available", since.Format(time.RFC3339))}
+	}
+
+	position, positionTime, err := h.positionAt(ctx, timestamp, meetingID)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("getting position: %w", err)
+	}
+
+	if position == 0 {
+		return 0, time.Time{}, invalidInputError{fmt.Sprintf("there is no position before %s", timestamp.Format(time.RFC3339))}
+	}
+
+	return position, positionTime, nil
+}
+
+// MeetingSnapshot is the state of a meeting at a position.
+type MeetingSnapshot struct {
+	MeetingID int                                   `json:"meeting_id"`
+	Position  int                                   `json:"position"`
+	Timestamp int64                                 `json:"timestamp"`
+	Data      map[string]map[string]json.RawMessage `json:"data"`
+}
+
+// MeetingExport returns all objects of a meeting as they were at a position.
+// The data is restricted for the user.
+//
+// If position is 0, the latest position is used.
+func (a *Autoupdate) MeetingExport(ctx context.Context, uid int, meetingID int, position int) (MeetingSnapshot, error) {
+	type History interface {
+		historyAt(position int) historyGetter
+		positionTime(ctx context.Context, position int) (time.Time, error)
+		positionAt(ctx context.Context, timestamp time.Time, meetingID int) (int, time.Time, error)
+	}
+	h, ok := a.flow.(History)
+	if !ok {
+		return MeetingSnapshot{}, fmt.Errorf("history not supported")
+	}
+
+	if meetingID < 1 || position < 0 {
+		return MeetingSnapshot{}, invalidInputError{"meeting id and position have to be positive numbers"}
+	}
+
+	if _, err := a.checkHistoryPermission(ctx, uid, fmt.Sprintf("meeting/%d", meetingID)); err != nil {
+		return MeetingSnapshot{}, err
+	}
+
+	var timestamp time.Time
+	var err error
+	if position == 0 {
+		position, timestamp, err = h.positionAt(ctx, clock.Now(), 0)
+		if err != nil {
+			return MeetingSnapshot{}, fmt.Errorf("getting latest position: %w", err)
+		}
+	} else {
+		timestamp, err = h.positionTime(ctx, position)
+		if err != nil {
+			return MeetingSnapshot{}, fmt.Errorf("getting time of position %d: %w", position, err)
+		}
+	}
+
+	if since := a.retentionFor(ctx).since(meetingID); timestamp.Before(since) {
+		return MeetingSnapshot{}, invalidInputError{fmt.Sprintf("the history before %s is not available", since.Format(time.RFC3339))}
+	}
+
+	getter := h.historyAt(position)
+	objects, err := getter.MeetingObjects(ctx, meetingID)
+	if err != nil {
+		return MeetingSnapshot{}, fmt.Errorf("getting objects of meeting %d: %w", meetingID, err)
+	}
+
+	var keys []dskey.Key
+	for fqid, object := range objects {
+		coll, rawID, _ := strings.Cut(fqid, "/")
+		id, _ := strconv.Atoi(rawID)
+		for field := range object {
+			key, err := dskey.FromParts(coll, id, field)
+			if err != nil {
+				// Fields that are not in the models.yml can not be restricted.
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	ctx, restricter := a.restricter(ctx, getter, uid)
+	values, err := restricter.Get(ctx, keys...)
+	if err != nil {
+		return MeetingSnapshot{}, fmt.Errorf("get restricted data: %w", err)
+	}
+
+	data := make(map[string]map[string]json.RawMessage)
+	for key, value := range values {
+		if value == nil {
+			continue
+		}
+
+		if data[key.FQID()] == nil {
+			data[key.FQID()] = make(map[string]json.RawMessage)
+		}
+		data[key.FQID()][key.Field()] = value
+	}
+
+	return MeetingSnapshot{
+		MeetingID: meetingID,
+		Position:  position,
+		Timestamp: timestamp.Unix(),
+		Data:      data,
+	}, nil
+}
+
+// HistorySubscribe returns a function, that returns the new positions, that
+// change the meeting or one of its objects.
+//
+// The returned function blocks until there are new positions or the context
+// is done. The permission of the user is checked again on every update. The
+// positions are only queried, when an update changes the meeting or one of its
+// objects.
+func (a *Autoupdate) HistorySubscribe(ctx context.Context, uid int, meetingID int) (func(context.Context) ([]datastore.HistoryPosition, error), error) {
+	type History interface {
+		positionAt(ctx context.Context, timestamp time.Time, meetingID int) (int, time.Time, error)
+		historyPositions(ctx context.Context, query datastore.HistoryQuery) ([]datastore.HistoryPosition, error)
+	}
+	h, ok := a.flow.(History)
+	if !ok {
+		return nil, fmt.Errorf("history not supported")
+	}
+
+	if meetingID < 1 {
+		return nil, invalidInputError{"meeting id has to be a positive number"}
+	}
+
+	query, err := a.checkHistoryQuery(ctx, uid, datastore.HistoryQuery{MeetingID: meetingID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the topic id before the position, so no update gets lost.
+	tid := a.topic.LastID()
+	query.After, _, err = h.positionAt(ctx, clock.Now(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("getting latest position: %w", err)
+	}
+
+	return func(ctx context.Context) ([]datastore.HistoryPosition, error) {
+		for {
+			newTID, changed, err := a.topic.Receive(ctx, tid)
+			pruned := false
+			if err != nil {
+				var errUnknownID topic.UnknownIDError
+				if !errors.As(err, &errUnknownID) {
+					return nil, fmt.Errorf("waiting for updates: %w", err)
+				}
+
+				// The topic was pruned. The position is the real cursor, so
+				// nothing is lost.
+				newTID = a.topic.LastID()
+				pruned = true
+			}
+			tid = newTID
+
+			if _, err := a.checkHistoryQuery(ctx, uid, datastore.HistoryQuery{MeetingID: meetingID}); err != nil {
+				return nil, err
+			}
+
+			if !pruned {
+				relevant, err := a.changesMeeting(ctx, changed, meetingID)
+				if err != nil {
+					return nil, fmt.Errorf("checking changed keys: %w", err)
+				}
+
+				if !relevant {
+					continue
+				}
+			}
+
+			positions, err := h.historyPositions(ctx, query)
+			if err != nil {
+				return nil, fmt.Errorf("getting new positions: %w", err)
+			}
+
+			if len(positions) > 0 {
+				query.After = positions[len(positions)-1].Position
+				return positions, nil
+			}
+		}
+	}, nil
+}
+
+// changesMeeting tells, if one of the keys belongs to the meeting or to one of
+// its objects.
+//
+// An object, that has a meeting_id field without a value, was deleted. It is
+// counted as a change of the meeting, so no position is missed.
+func (a *Autoupdate) changesMeeting(ctx context.Context, keys []dskey.Key, meetingID int) (bool, error) {
+	meetingKeys := set.New[dskey.Key]()
+	for _, key := range keys {
+		if key.Collection() == "meeting" {
+			if key.ID() == meetingID {
+				return true, nil
+			}
+			continue
+		}
+
+		meetingKey, err := dskey.FromParts(key.Collection(), key.ID(), "meeting_id")
+		if err != nil {
+			// The collection does not belong to a meeting.
+			continue
+		}
+		meetingKeys.Add(meetingKey)
+	}
+
+	if meetingKeys.Len() == 0 {
+		return false, nil
+	}
+
+	values, err := a.flow.Get(ctx, meetingKeys.List()...)
+	if err != nil {
+		return false, fmt.Errorf("getting meeting ids: %w", err)
+	}
+
+	for _, value := range values {
+		if value == nil {
+			return true, nil
+		}
+
+		if id, err := strconv.Atoi(string(value)); err == nil && id == meetingID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ProjectionPreview calculates the content of a projection without creating
+// it in the datastore.
+//
+// The content object has to belong to the meeting of the projection. The user
+// needs the permission to manage the projectors of the meeting.
+func (a *Autoupdate) ProjectionPreview(ctx context.Context, uid int, p7on projector.Projection) (json.RawMessage, error) {
+	type Previewer interface {
+		projectionPreview(ctx context.Context, p7on *projector.Projection) ([]byte, error)
+	}
+	previewer, ok := a.flow.(Previewer)
+	if !ok {
+		return nil, fmt.Errorf("projection preview not supported")
+	}
+
+	if p7on.MeetingID < 1 {
+		return nil, invalidInputError{"meeting_id has to be a positive number"}
+	}
+
+	if !reValidKeys.MatchString(p7on.ContentObjectID) {
+		return nil, invalidInputError{fmt.Sprintf("content_object_id %s is invalid", p7on.ContentObjectID)}
+	}
+
+	ds := dsfetch.New(a.flow)
+
+	coll, rawID, _ := strings.Cut(p7on.ContentObjectID, "/")
+	id, _ := strconv.Atoi(rawID)
+	meetingID, hasMeeting, err := collection.Collection(ctx, coll).MeetingID(ctx, ds, id)
+	if err != nil {
+		var errNotExist dsfetch.DoesNotExistError
+		if errors.As(err, &errNotExist) {
+			return nil, notExistError{dskey.Key(errNotExist)}
+		}
+		return nil, fmt.Errorf("getting meeting id of %s: %w", p7on.ContentObjectID, err)
+	}
+
+	if coll == "meeting" {
+		meetingID, hasMeeting = id, true
+	}
+
+	if !hasMeeting || meetingID != p7on.MeetingID {
+		return nil, invalidInputError{fmt.Sprintf("%s does not belong to meeting %d", p7on.ContentObjectID, p7on.MeetingID)}
+	}
+
+	p, err := perm.New(ctx, ds, uid, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("getting meeting permissions: %w", err)
+	}
+
+	if !p.Has(perm.ProjectorCanManage) {
+		return nil, permissionDeniedError{fmt.Errorf("you are not allowed to manage the projectors of meeting %d", meetingID)}
+	}
+
+	content, err := previewer.projectionPreview(ctx, &p7on)
+	if err != nil {
+		return nil, fmt.Errorf("calculating preview: %w", err)
+	}
+
+	return content, nil
+}
+
+// checkHistoryQuery returns an error, if the user is not allowed to see the
+// history of the query.
+//
+// The returned query hides the entries before the retention window.
+func (a *Autoupdate) checkHistoryQuery(ctx context.Context, uid int, query datastore.HistoryQuery) (datastore.HistoryQuery, error) {
+	fqid := query.FQID
+	if query.MeetingID != 0 {
+		if query.FQID != "" {
+			return query, invalidInputError{"fqid and meeting_id can not be used together"}
+		}
+		fqid = fmt.Sprintf("meeting/%d", query.MeetingID)
+	}
+
+	meetingID, err := a.checkHistoryPermission(ctx, uid, fqid)
+	if err != nil {
+		return query, err
+	}
+
+	if since := a.retentionFor(ctx).since(meetingID); query.Since.Before(since) {
+		query.Since = since
+	}
+
+	return query, nil
+}
+
+// checkHistoryPermission returns an error, if the user is not allowed to see
+// the history of the fqid.
+//
+// Returns the meeting id of the fqid or 0, if it does not belong to a meeting.
+func (a *Autoupdate) checkHistoryPermission(ctx context.Context, uid int, fqid string) (int, error) {
 	if !reValidKeys.MatchString(fqid) {
 		// TODO Client Error
-		return invalidInputError{fmt.Sprintf("fqid %s is invalid", fqid)}
+		return 0, invalidInputError{fmt.Sprintf("fqid %s is invalid", fqid)}
 	}
 
 	coll, rawID, _ := strings.Cut(fqid, "/")
@@ -362,38 +887,139 @@ func (a *Autoupdate) HistoryInformation(ctx context.Context, uid int, fqid strin
 		var errNotExist dsfetch.DoesNotExistError
 		if errors.As(err, &errNotExist) {
 			// TODO Client Error
-			return notExistError{dskey.Key(errNotExist)}
+			return 0, notExistError{dskey.Key(errNotExist)}
 		}
-		return fmt.Errorf("getting meeting id for collection %s id %d: %w", coll, id, err)
+		return 0, fmt.Errorf("getting meeting id for collection %s id %d: %w", coll, id, err)
 	}
 
 	if !hasMeeting {
 		hasOML, err := perm.HasOrganizationManagementLevel(ctx, ds, uid, perm.OMLCanManageOrganization)
 		if err != nil {
-			return fmt.Errorf("getting organization management level: %w", err)
+			return 0, fmt.Errorf("getting organization management level: %w", err)
 		}
 
 		if !hasOML {
 			// TODO Client Error
-			return permissionDeniedError{fmt.Errorf("you are not allowed to use history information on %s", fqid)}
+			return 0, permissionDeniedError{fmt.Errorf("you are not allowed to use history information on %s", fqid)}
 		}
-	} else {
-		p, err := perm.New(ctx, ds, uid, meetingID)
+		return 0, nil
+	}
+
+	p, err := perm.New(ctx, ds, uid, meetingID)
+	if err != nil {
+		return 0, fmt.Errorf("getting meeting permissions: %w", err)
+	}
+
+	if !p.Has(perm.MeetingCanSeeHistory) {
+		// TODO Client Error
+		return 0, permissionDeniedError{fmt.Errorf("you are not allowed to use history information on %s", fqid)}
+	}
+
+	return meetingID, nil
+}
+
+// historyGetter returns the data at a position.
+type historyGetter interface {
+	flow.Getter
+	Object(ctx context.Context, fqid string) (map[string]json.RawMessage, error)
+	MeetingObjects(ctx context.Context, meetingID int) (map[string]map[string]json.RawMessage, error)
+}
+
+// FieldDiff is the change of one field between two positions. A value of nil
+// means, that the field did not exist or the user can not see it.
+type FieldDiff struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+// HistoryDiff returns the fields of the fqids that are different between the
+// two positions.
+//
+// The values are restricted for the user with the data at each position.
+func (a *Autoupdate) HistoryDiff(ctx context.Context, uid int, fqids []string, from, to int) (map[string]map[string]FieldDiff, error) {
+	type History interface {
+		historyAt(position int) historyGetter
+		positionTime(ctx context.Context, position int) (time.Time, error)
+	}
+	h, ok := a.flow.(History)
+	if !ok {
+		return nil, fmt.Errorf("history not supported")
+	}
+
+	if from < 1 || to < 1 {
+		return nil, invalidInputError{"positions have to be positive numbers"}
+	}
+
+	var timestamps [2]time.Time
+	for i, position := range []int{from, to} {
+		timestamp, err := h.positionTime(ctx, position)
 		if err != nil {
-			return fmt.Errorf("getting meeting permissions: %w", err)
+			return nil, fmt.Errorf("getting time of position %d: %w", position, err)
 		}
+		timestamps[i] = timestamp
+	}
 
-		if !p.Has(perm.MeetingCanSeeHistory) {
-			// TODO Client Error
-			return permissionDeniedError{fmt.Errorf("you are not allowed to use history information on %s", fqid)}
+	for _, fqid := range fqids {
+		meetingID, err := a.checkHistoryPermission(ctx, uid, fqid)
+		if err != nil {
+			return nil, err
+		}
+
+		since := a.retentionFor(ctx).since(meetingID)
+		if timestamps[0].Before(since) || timestamps[1].Before(since) {
+			return nil, invalidInputError{fmt.Sprintf("the history of %s before %s is not available", fqid, since.Format(time.RFC3339))}
 		}
 	}
 
-	if err := hi.historyInformation(ctx, fqid, w); err != nil {
-		return fmt.Errorf("getting history information: %w", err)
+	getters := [2]historyGetter{h.historyAt(from), h.historyAt(to)}
+
+	var keys []dskey.Key
+	for _, fqid := range fqids {
+		fields := make(map[string]struct{})
+		for _, getter := range getters {
+			object, err := getter.Object(ctx, fqid)
+			if err != nil {
+				return nil, fmt.Errorf("getting %s: %w", fqid, err)
+			}
+			for field := range object {
+				fields[field] = struct{}{}
+			}
+		}
+
+		coll, rawID, _ := strings.Cut(fqid, "/")
+		id, _ := strconv.Atoi(rawID)
+		for field := range fields {
+			key, err := dskey.FromParts(coll, id, field)
+			if err != nil {
+				// Fields that are not in the models.yml can not be restricted.
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	var values [2]map[dskey.Key][]byte
+	for i, getter := range getters {
+		ctx, restricter := a.restricter(ctx, getter, uid)
+		data, err := restricter.Get(ctx, keys...)
+		if err != nil {
+			return nil, fmt.Errorf("get restricted data: %w", err)
+		}
+		values[i] = data
 	}
 
-	fmt.Fprintln(w)
+	diff := make(map[string]map[string]FieldDiff, len(fqids))
+	for _, key := range keys {
+		before, after := values[0][key], values[1][key]
+		if bytes.Equal(before, after) {
+			continue
+		}
+
+		if diff[key.FQID()] == nil {
+			diff[key.FQID()] = make(map[string]FieldDiff)
+		}
+		diff[key.FQID()][key.Field()] = FieldDiff{Old: before, New: after}
+	}
 
-	return nil
+	return diff, nil
 }
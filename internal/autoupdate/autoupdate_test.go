@@ -6,6 +6,7 @@ import (
 
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 )
@@ -33,3 +34,51 @@ func TestSingleDataEmptyValues(t *testing.T) {
 		t.Errorf("Got %v, expected empty dict", data)
 	}
 }
+
+func TestConnectProjector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := dsmock.NewFlow(dsmock.YAMLData(`---
+		projector/1:
+			scale: 2
+			current_projection_ids: [5]
+			name: projector
+		projection/5:
+			weight: 3
+			meeting_id: 1
+	`))
+	s, _, _ := autoupdate.New(environment.ForTests{}, flow, RestrictAllowed)
+
+	conn, err := s.ConnectProjector(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("ConnectProjector: %v", err)
+	}
+
+	next, _ := conn.Next()
+	data, err := next(ctx)
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+
+	for _, key := range []string{"projector/1/scale", "projector/1/current_projection_ids", "projection/5/weight"} {
+		if data[dskey.MustKey(key)] == nil {
+			t.Errorf("key %s is missing in %v", key, data)
+		}
+	}
+
+	for _, key := range []string{"projector/1/name", "projection/5/meeting_id"} {
+		if _, ok := data[dskey.MustKey(key)]; ok {
+			t.Errorf("got key %s, that was not requested", key)
+		}
+	}
+}
+
+func TestConnectProjectorInvalidID(t *testing.T) {
+	flow := dsmock.NewFlow(nil)
+	s, _, _ := autoupdate.New(environment.ForTests{}, flow, RestrictAllowed)
+
+	if _, err := s.ConnectProjector(context.Background(), 1, 0); err == nil {
+		t.Errorf("ConnectProjector with id 0 did not return an error")
+	}
+}
@@ -0,0 +1,171 @@
+package autoupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// ErrNoCachedData is returned by Benchmark, when there is no data in the
+// cache.
+var ErrNoCachedData = errors.New("no cached data")
+
+// BenchmarkConfig are the settings of Autoupdate.Benchmark.
+type BenchmarkConfig struct {
+	// UserIDs are the users, the data is restricted for.
+	UserIDs []int
+
+	// SampleSize is the maximum number of cached keys, that are used.
+	SampleSize int
+
+	// Duration is the time of each measurement.
+	Duration time.Duration
+
+	// Encode writes the restricted data like it is sent to a client. It
+	// returns the number of bytes.
+	Encode func(data map[dskey.Key][]byte) (int, error)
+}
+
+// BenchmarkResult is the result of Autoupdate.Benchmark.
+type BenchmarkResult struct {
+	CachedKeys int                   `json:"cached_keys"`
+	SampleKeys int                   `json:"sample_keys"`
+	Users      []BenchmarkUserResult `json:"users"`
+}
+
+// BenchmarkUserResult are the measurements for one user.
+type BenchmarkUserResult struct {
+	UserID      int                 `json:"user_id"`
+	VisibleKeys int                 `json:"visible_keys"`
+	Restrict    BenchmarkThroughput `json:"restrict"`
+	Encode      BenchmarkThroughput `json:"encode"`
+}
+
+// BenchmarkThroughput is the throughput of one step.
+type BenchmarkThroughput struct {
+	Runs           int     `json:"runs"`
+	MSPerRun       float64 `json:"ms_per_run"`
+	KeysPerSecond  float64 `json:"keys_per_second"`
+	BytesPerSecond float64 `json:"bytes_per_second,omitempty"`
+}
+
+// Benchmark measures, how fast a sample of the cached data is restricted and
+// encoded for each user. Each step is repeated for the duration in one
+// goroutine, so the result is the throughput of one CPU.
+//
+// The data is not fetched from the datastore. If the flow has no cache,
+// ErrNoCachedData is returned.
+func (a *Autoupdate) Benchmark(ctx context.Context, cfg BenchmarkConfig) (BenchmarkResult, error) {
+	type cacher interface {
+		cachedKeys() []dskey.Key
+	}
+
+	cached, ok := a.flow.(cacher)
+	if !ok {
+		return BenchmarkResult{}, ErrNoCachedData
+	}
+
+	keys := cached.cachedKeys()
+	if len(keys) == 0 {
+		return BenchmarkResult{}, ErrNoCachedData
+	}
+
+	result := BenchmarkResult{CachedKeys: len(keys)}
+	if cfg.SampleSize > 0 && len(keys) > cfg.SampleSize {
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		keys = keys[:cfg.SampleSize]
+	}
+	result.SampleKeys = len(keys)
+
+	for _, uid := range cfg.UserIDs {
+		userResult, err := a.benchmarkUser(ctx, cfg, uid, keys)
+		if err != nil {
+			return BenchmarkResult{}, fmt.Errorf("user %d: %w", uid, err)
+		}
+		result.Users = append(result.Users, userResult)
+	}
+
+	return result, nil
+}
+
+func (a *Autoupdate) benchmarkUser(ctx context.Context, cfg BenchmarkConfig, uid int, keys []dskey.Key) (BenchmarkUserResult, error) {
+	result := BenchmarkUserResult{UserID: uid}
+
+	var restricted map[dskey.Key][]byte
+	throughput, err := measure(ctx, cfg.Duration, func() (int, int, error) {
+		restrictCtx, getter := a.restricter(ctx, a.flow, uid)
+		data, err := getter.Get(restrictCtx, keys...)
+		if err != nil {
+			return 0, 0, fmt.Errorf("restrict: %w", err)
+		}
+		restricted = data
+		return len(keys), 0, nil
+	})
+	if err != nil {
+		return BenchmarkUserResult{}, err
+	}
+	result.Restrict = throughput
+
+	// Only the visible keys are sent to the client.
+	visible := make(map[dskey.Key][]byte, len(restricted))
+	for k, v := range restricted {
+		if v != nil {
+			visible[k] = v
+		}
+	}
+	result.VisibleKeys = len(visible)
+
+	if cfg.Encode == nil {
+		return result, nil
+	}
+
+	throughput, err = measure(ctx, cfg.Duration, func() (int, int, error) {
+		n, err := cfg.Encode(visible)
+		if err != nil {
+			return 0, 0, fmt.Errorf("encode: %w", err)
+		}
+		return len(visible), n, nil
+	})
+	if err != nil {
+		return BenchmarkUserResult{}, err
+	}
+	result.Encode = throughput
+
+	return result, nil
+}
+
+// measure calls f until the duration is over. f returns the number of keys
+// and bytes of one run. It runs at least once.
+func measure(ctx context.Context, duration time.Duration, f func() (int, int, error)) (BenchmarkThroughput, error) {
+	var runs, keys, bytes int
+	start := time.Now()
+	for {
+		k, b, err := f()
+		if err != nil {
+			return BenchmarkThroughput{}, err
+		}
+		runs++
+		keys += k
+		bytes += b
+
+		if time.Since(start) >= duration {
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			return BenchmarkThroughput{}, err
+		}
+	}
+
+	elapsed := time.Since(start)
+	return BenchmarkThroughput{
+		Runs:           runs,
+		MSPerRun:       float64(elapsed.Microseconds()) / 1000 / float64(runs),
+		KeysPerSecond:  float64(keys) / elapsed.Seconds(),
+		BytesPerSecond: float64(bytes) / elapsed.Seconds(),
+	}, nil
+}
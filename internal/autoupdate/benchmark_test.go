@@ -0,0 +1,72 @@
+package autoupdate_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestBenchmark(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "example-data.json")
+	content := `{"user": {"1": {"id": 1, "username": "admin"}, "2": {"id": 2, "username": "other"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing example data: %v", err)
+	}
+
+	flow, err := autoupdate.NewExampleFlow(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewExampleFlow: %v", err)
+	}
+
+	// Fill the cache.
+	if _, err := flow.Get(ctx, dskey.MustKey("user/1/username"), dskey.MustKey("user/2/username"), dskey.MustKey("user/2/id")); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	s, _, _ := autoupdate.New(environment.ForTests{}, flow, RestrictAllowed)
+
+	var encoded int
+	result, err := s.Benchmark(ctx, autoupdate.BenchmarkConfig{
+		UserIDs:    []int{1},
+		SampleSize: 2,
+		Duration:   time.Millisecond,
+		Encode: func(data map[dskey.Key][]byte) (int, error) {
+			encoded = len(data)
+			return 10, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Benchmark: %v", err)
+	}
+
+	if result.CachedKeys != 3 || result.SampleKeys != 2 {
+		t.Errorf("got %d cached and %d sample keys, expected 3 and 2", result.CachedKeys, result.SampleKeys)
+	}
+
+	if len(result.Users) != 1 {
+		t.Fatalf("got %d user results, expected 1", len(result.Users))
+	}
+
+	user := result.Users[0]
+	if user.VisibleKeys != 2 || encoded != 2 || user.Restrict.Runs < 1 || user.Encode.BytesPerSecond <= 0 {
+		t.Errorf("got user result %+v", user)
+	}
+}
+
+func TestBenchmarkWithoutCache(t *testing.T) {
+	s, _, _ := autoupdate.New(environment.ForTests{}, dsmock.NewFlow(nil), RestrictAllowed)
+
+	if _, err := s.Benchmark(context.Background(), autoupdate.BenchmarkConfig{UserIDs: []int{1}}); !errors.Is(err, autoupdate.ErrNoCachedData) {
+		t.Errorf("Benchmark returned %v, expected ErrNoCachedData", err)
+	}
+}
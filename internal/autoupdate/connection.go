@@ -3,9 +3,15 @@ package autoupdate
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/throttle"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsrecorder"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Connection holds the connection to data and has the ability to return the next.
@@ -24,6 +30,14 @@ type connection struct {
 	filter       filter
 	skipWorkpool bool
 	hotkeys      map[dskey.Key]struct{}
+
+	// updateTime is the time of the datastore update, that caused the last
+	// data.
+	updateTime time.Time
+
+	// resumed is true, if the filter was set by Resume and the first data
+	// was not created yet.
+	resumed bool
 }
 
 // Next returns a function to fetch the next data.
@@ -57,10 +71,28 @@ type connection struct {
 //
 // On every other call, it blocks until there is new data. In this case, the map
 // is never empty.
+//
+// A connection, that was resumed, only returns the data, that changed since the
+// state was created. If nothing changed, the first call blocks like the others.
 func (c *connection) Next() (func(context.Context) (map[dskey.Key][]byte, error), bool) {
 	return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+		if c.resumed {
+			c.resumed = false
+			c.tid = c.autoupdate.topic.LastID()
+			c.updateTime = time.Time{}
+			data, err := c.updatedData(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("creating resumed data: %w", err)
+			}
+
+			if len(data) > 0 {
+				return data, nil
+			}
+		}
+
 		if c.filter.empty() {
 			c.tid = c.autoupdate.topic.LastID()
+			c.updateTime = time.Time{}
 			data, err := c.updatedData(ctx)
 			if err != nil {
 				return nil, fmt.Errorf("creating first time data: %w", err)
@@ -70,12 +102,29 @@ func (c *connection) Next() (func(context.Context) (map[dskey.Key][]byte, error)
 		}
 
 		for {
+			// While the service is throttled, wait before receiving, so all
+			// updates in this time are handled together.
+			if delay := throttle.Delay(); delay > 0 {
+				if err := clock.Sleep(ctx, delay); err != nil {
+					return nil, err
+				}
+			}
+
+			// Blocks until one of the hotkeys is published. The topic ids
+			// until then only contain other keys and are skipped.
+			skipped, err := c.autoupdate.parking.wait(ctx, c.tid, c.hotkeys)
+			if err != nil {
+				return nil, fmt.Errorf("waiting for updated keys: %w", err)
+			}
+			c.tid = skipped
+
 			// Blocks until new data or the context is done.
 			tid, changedKeys, err := c.autoupdate.topic.Receive(ctx, c.tid)
 			if err != nil {
 				// TODO EXTERMAL ERROR
 				return nil, fmt.Errorf("get updated keys: %w", err)
 			}
+			firstTID := c.tid + 1
 			c.tid = tid
 
 			foundKey := false
@@ -87,6 +136,7 @@ func (c *connection) Next() (func(context.Context) (map[dskey.Key][]byte, error)
 			}
 
 			if foundKey {
+				c.updateTime = c.autoupdate.publishedTime(firstTID)
 				data, err := c.updatedData(ctx)
 				if err != nil {
 					return nil, fmt.Errorf("creating later data: %w", err)
@@ -100,6 +150,12 @@ func (c *connection) Next() (func(context.Context) (map[dskey.Key][]byte, error)
 	}, true
 }
 
+// UpdateTime returns the time of the oldest datastore update, that caused the
+// data of the last call to Next. It is the zero time for the first data.
+func (c *connection) UpdateTime() time.Time {
+	return c.updateTime
+}
+
 func (c *connection) NextWithFilter(ctx context.Context, filterHashes string) (map[dskey.Key][]byte, string, error) {
 	c.tid = c.autoupdate.topic.LastID()
 
@@ -128,25 +184,50 @@ func (c *connection) NextWithFilter(ctx context.Context, filterHashes string) (m
 	return data, hashes, nil
 }
 
+// ResumeState returns the state of the connection, so it can be continued on
+// another instance with Resume.
+//
+// It must not be called concurrently with Next.
+func (c *connection) ResumeState() (string, error) {
+	if c.filter.empty() {
+		return "", nil
+	}
+	return c.filter.hashState()
+}
+
+// Resume sets the state of a connection, that was returned by ResumeState. It
+// has to be called before the first call to Next.
+func (c *connection) Resume(state string) error {
+	if err := c.filter.setHashState(state); err != nil {
+		return fmt.Errorf("set resume state: %w", err)
+	}
+	c.resumed = !c.filter.empty()
+	return nil
+}
+
 // updatedData returns all values from the datastore.getter.
-func (c *connection) updatedData(ctx context.Context) (map[dskey.Key][]byte, error) {
+func (c *connection) updatedData(ctx context.Context) (data map[dskey.Key][]byte, err error) {
+	ctx, span := tracing.Start(ctx, "autoupdate.updatedData", attribute.Int("user_id", c.uid))
+	defer func() { tracing.End(span, err) }()
+
 	if !c.skipWorkpool {
 		done, err := c.autoupdate.pool.Wait(ctx)
 		if err != nil {
 			return nil, err
 		}
 		defer done()
+		span.AddEvent("workpool")
 	}
 
 	recorder := dsrecorder.New(c.autoupdate.flow)
 	ctx, restricter := c.autoupdate.restricter(ctx, recorder, c.uid)
 
-	keys, err := c.kb.Update(ctx, restricter)
+	keys, err := updateKeys(ctx, c.kb, restricter)
 	if err != nil {
 		return nil, fmt.Errorf("create keys for keysbuilder: %w", err)
 	}
 
-	data, err := restricter.Get(ctx, keys...)
+	data, err = restrictedGet(ctx, restricter, keys)
 	if err != nil {
 		return nil, fmt.Errorf("get restricted data: %w", err)
 	}
@@ -156,3 +237,23 @@ func (c *connection) updatedData(ctx context.Context) (map[dskey.Key][]byte, err
 
 	return data, nil
 }
+
+// updateKeys calls kb.Update inside a span.
+func updateKeys(ctx context.Context, kb KeysBuilder, getter flow.Getter) ([]dskey.Key, error) {
+	ctx, span := tracing.Start(ctx, "keysbuilder.Update")
+	keys, err := kb.Update(ctx, getter)
+	span.SetAttributes(attribute.Int("keys", len(keys)))
+	if r, ok := kb.(interface{ Reused() int }); ok {
+		span.SetAttributes(attribute.Int("keys_reused", r.Reused()))
+	}
+	tracing.End(span, err)
+	return keys, err
+}
+
+// restrictedGet calls restricter.Get inside a span.
+func restrictedGet(ctx context.Context, restricter flow.Getter, keys []dskey.Key) (map[dskey.Key][]byte, error) {
+	ctx, span := tracing.Start(ctx, "restrict.Get", attribute.Int("keys", len(keys)))
+	data, err := restricter.Get(ctx, keys...)
+	tracing.End(span, err)
+	return data, err
+}
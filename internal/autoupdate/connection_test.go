@@ -5,10 +5,12 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/cache"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
@@ -73,6 +75,44 @@ func TestConnectionReadNewData(t *testing.T) {
 	}
 }
 
+func TestConnectionUpdateTime(t *testing.T) {
+	shutdownCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	datastore := dsmock.NewFlow(dsmock.YAMLData(`---
+	user/1/username: Hello World
+	`))
+	s, bg, _ := autoupdate.New(environment.ForTests{}, datastore, RestrictAllowed)
+	go bg(shutdownCtx, oserror.Handle)
+
+	kb, _ := keysbuilder.FromKeys(userNameKey.String())
+	conn, err := s.Connect(context.Background(), 1, kb)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	timer := conn.(interface{ UpdateTime() time.Time })
+
+	next, _ := conn.Next()
+	if _, err := next(context.Background()); err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+
+	if !timer.UpdateTime().IsZero() {
+		t.Errorf("first data has update time %v, expected zero", timer.UpdateTime())
+	}
+
+	before := time.Now()
+	datastore.Send(map[dskey.Key][]byte{userNameKey: []byte(`"new value"`)})
+	next, _ = conn.Next()
+	if _, err := next(context.Background()); err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+
+	if got := timer.UpdateTime(); got.Before(before) || got.After(time.Now()) {
+		t.Errorf("got update time %v, expected a time after %v", got, before)
+	}
+}
+
 func TestConnectionEmptyData(t *testing.T) {
 	var (
 		doesNotExistKey = dskey.MustKey("user/2/username")
@@ -485,3 +525,110 @@ func TestKeyRequestedAgain(t *testing.T) {
 		t.Errorf("Got organization_tag/2/id: %q, expected 2", v)
 	}
 }
+
+func TestConnectionTimeline(t *testing.T) {
+	shutdownCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	datastore := dsmock.NewFlow(dsmock.YAMLData(`---
+	user/1/username: first
+	user/2/username: other
+	`))
+	s, bg, _ := autoupdate.New(environment.ForTests{}, cache.New(datastore), RestrictAllowed)
+	go bg(shutdownCtx, func(error) {})
+
+	kb, _ := keysbuilder.FromKeys(userNameKey.String())
+	conn, err := s.Connect(shutdownCtx, 1, kb)
+	if err != nil {
+		t.Fatalf("creating conection: %v", err)
+	}
+	next, _ := conn.Next()
+
+	if _, err := next(shutdownCtx); err != nil {
+		t.Fatalf("Getting first data: %v", err)
+	}
+
+	played := make(chan error, 1)
+	go func() {
+		played <- datastore.Play(shutdownCtx,
+			dsmock.Write{Position: 2, After: 10 * time.Millisecond, Data: dsmock.YAMLData("user/1/username: second")},
+			dsmock.Write{Position: 3, After: 20 * time.Millisecond, Data: dsmock.YAMLData("user/1/username: third"), Lost: true},
+			dsmock.Write{Position: 4, After: 30 * time.Millisecond, Data: dsmock.YAMLData("user/2/username: changed")},
+			dsmock.Write{Position: 5, After: 40 * time.Millisecond, Data: dsmock.YAMLData("user/1/username: fifth")},
+		)
+	}()
+
+	// The lost write is received after the resync of the cache. The write to
+	// the other user is not sent to the connection.
+	for _, expect := range []string{`"second"`, `"third"`, `"fifth"`} {
+		ctx, cancelNext := context.WithTimeout(shutdownCtx, time.Second)
+		data, err := next(ctx)
+		cancelNext()
+		if err != nil {
+			t.Fatalf("waiting for %s: %v", expect, err)
+		}
+
+		if got := string(data[userNameKey]); len(data) != 1 || got != expect {
+			t.Errorf("next() returned %v, expected only the username %s", data, expect)
+		}
+	}
+
+	if err := <-played; err != nil {
+		t.Errorf("Play: %v", err)
+	}
+}
+
+func TestConnectionResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds := dsmock.NewFlow(dsmock.YAMLData(`---
+		user/1/username: Hello World
+		user/1/first_name: Hello
+	`))
+
+	s, bg, _ := autoupdate.New(environment.ForTests{}, ds, RestrictAllowed)
+	go bg(ctx, oserror.Handle)
+
+	type resumer interface {
+		ResumeState() (string, error)
+		Resume(state string) error
+	}
+
+	kb, _ := keysbuilder.FromKeys("user/1/username", "user/1/first_name")
+	conn, err := s.Connect(ctx, 1, kb)
+	if err != nil {
+		t.Fatalf("creating connection: %v", err)
+	}
+
+	next, _ := conn.Next()
+	if _, err := next(ctx); err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+
+	state, err := conn.(resumer).ResumeState()
+	if err != nil {
+		t.Fatalf("ResumeState: %v", err)
+	}
+
+	ds.Send(map[dskey.Key][]byte{userNameKey: []byte(`"new value"`)})
+
+	resumed, err := s.Connect(ctx, 1, kb)
+	if err != nil {
+		t.Fatalf("creating resumed connection: %v", err)
+	}
+
+	if err := resumed.(resumer).Resume(state); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+
+	next, _ = resumed.Next()
+	data, err := next(ctx)
+	if err != nil {
+		t.Fatalf("next() of resumed connection: %v", err)
+	}
+
+	if len(data) != 1 || string(data[userNameKey]) != `"new value"` {
+		t.Errorf("resumed connection returned %v, expected only the changed username", data)
+	}
+}
@@ -0,0 +1,81 @@
+package autoupdate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector/slide"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/cache"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+)
+
+// ExampleFlow is a flow for local development. It reads the data from an
+// example-data.json file instead of postgres and the vote-service.
+//
+// Each change of the file is a new position. The routes, that need a
+// position, work with them. The other history routes are not supported.
+type ExampleFlow struct {
+	flow.Flow
+
+	cache     *cache.Cache
+	projector *projector.Projector
+	example   *datastore.FlowExample
+}
+
+// NewExampleFlow initializes a flow from the example data file. The file is
+// checked for changes in the interval.
+func NewExampleFlow(path string, interval time.Duration) (*ExampleFlow, error) {
+	example, err := datastore.NewFlowExample(path, interval)
+	if err != nil {
+		return nil, fmt.Errorf("init example data: %w", err)
+	}
+
+	cache := cache.New(example)
+	projector := projector.NewProjector(cache, slide.Slides())
+
+	return &ExampleFlow{
+		Flow:      projector,
+		cache:     cache,
+		projector: projector,
+		example:   example,
+	}, nil
+}
+
+// ResetCache clears the cache.
+func (f *ExampleFlow) ResetCache() {
+	f.cache.Reset()
+	f.projector.Reset()
+}
+
+// Ping checks, that the file is readable.
+func (f *ExampleFlow) Ping(ctx context.Context) error {
+	return f.example.Ping(ctx)
+}
+
+func (f *ExampleFlow) cachedKeys() []dskey.Key {
+	return f.cache.Keys()
+}
+
+// CacheWarm returns an error, if the cache is empty.
+func (f *ExampleFlow) CacheWarm(ctx context.Context) error {
+	if f.cache.Len() == 0 {
+		return fmt.Errorf("cache is empty")
+	}
+	return nil
+}
+
+func (f *ExampleFlow) historyAt(position int) historyGetter {
+	return f.example.AtPosition(position)
+}
+
+func (f *ExampleFlow) positionTime(ctx context.Context, position int) (time.Time, error) {
+	return f.example.PositionTime(ctx, position)
+}
+
+func (f *ExampleFlow) positionAt(ctx context.Context, timestamp time.Time, meetingID int) (int, time.Time, error) {
+	return f.example.PositionAt(ctx, timestamp, meetingID)
+}
@@ -2,15 +2,22 @@ package autoupdate
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/anonymize"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/chaos"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/maintenance"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector/slide"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/cache"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 )
@@ -27,9 +34,10 @@ import (
 type Flow struct {
 	flow.Flow
 
-	cache     *cache.Cache
-	projector *projector.Projector
-	postgres  *datastore.FlowPostgres
+	cache      *cache.Cache
+	projector  *projector.Projector
+	postgres   *datastore.FlowPostgres
+	anonymizer *anonymize.Anonymizer
 }
 
 // NewFlow initializes a flow for the autoupdate service.
@@ -41,17 +49,24 @@ func NewFlow(lookup environment.Environmenter, messageBus flow.Updater, skipVote
 
 	vote := datastore.NewFlowVoteCount(lookup)
 
-	var dataFlow flow.Flow = postgres
+	anonymizer, err := anonymize.New(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init anonymization: %w", err)
+	}
+
+	// The faults are only injected into the datastore and the message bus.
+	// The vote service is not wrapped.
+	var dataFlow flow.Flow = chaos.Flow(postgres)
 	background := func(context.Context, func(error)) {}
 	if !skipVoteService {
 		dataFlow = flow.Combine(
-			postgres,
+			dataFlow,
 			map[string]flow.Flow{"poll/vote_count": vote},
 		)
 
 		eventer := func() (<-chan time.Time, func() bool) {
-			timer := time.NewTimer(time.Second)
-			return timer.C, timer.Stop
+			timer := clock.NewTimer(time.Second)
+			return timer.C(), timer.Stop
 		}
 
 		background = func(ctx context.Context, errorHandler func(error)) {
@@ -59,32 +74,182 @@ func NewFlow(lookup environment.Environmenter, messageBus flow.Updater, skipVote
 		}
 	}
 
-	cache := cache.New(dataFlow)
+	// During maintenance, only the cache is used. The cache only contains
+	// anonymized data.
+	cache := cache.New(anonymizer.Flow(maintenance.Flow(dataFlow)))
 	projector := projector.NewProjector(cache, slide.Slides())
 
 	flow := Flow{
-		Flow:      projector,
-		cache:     cache,
-		projector: projector,
-		postgres:  postgres,
+		Flow:       projector,
+		cache:      cache,
+		projector:  projector,
+		postgres:   postgres,
+		anonymizer: anonymizer,
 	}
 
-	metric.Register(flow.metric)
-
 	return &flow, background, nil
 }
 
 // ResetCache clears the cache.
+//
+// During maintenance, the cache is kept, because the data could not be read
+// again.
 func (f *Flow) ResetCache() {
+	if maintenance.Active() {
+		return
+	}
+
 	f.cache.Reset()
 	f.projector.Reset()
 }
 
-func (f *Flow) metric(values metric.Container) {
+// Ping checks, that the database is reachable.
+func (f *Flow) Ping(ctx context.Context) error {
+	return f.postgres.Ping(ctx)
+}
+
+// CheckRead reads one value from postgres without the cache.
+func (f *Flow) CheckRead(ctx context.Context) error {
+	if _, err := f.postgres.Get(ctx, dskey.MustKey("organization/1/id")); err != nil {
+		return fmt.Errorf("reading organization/1/id: %w", err)
+	}
+	return nil
+}
+
+func (f *Flow) cachedKeys() []dskey.Key {
+	return f.cache.Keys()
+}
+
+// CacheWarm returns an error, if the cache is empty.
+func (f *Flow) CacheWarm(ctx context.Context) error {
+	if f.cache.Len() == 0 {
+		return fmt.Errorf("cache is empty")
+	}
+	return nil
+}
+
+// Metric adds the size of the cache to the metric values.
+func (f *Flow) Metric(values metric.Container) {
 	values.Add("datastore_cache_key_len", f.cache.Len())
 	values.Add("datastore_cache_size", f.cache.Size())
+
+	waits, waitTime := f.cache.LockWaits()
+	values.Add("datastore_cache_lock_waits", int(waits))
+	values.Add("datastore_cache_lock_wait_ms", int(waitTime.Milliseconds()))
+}
+
+// Introspect returns the state of the cache.
+func (f *Flow) Introspect() any {
+	hits, misses := f.cache.Stats()
+	waits, waitTime := f.cache.LockWaits()
+	return map[string]int{
+		"keys":         f.cache.Len(),
+		"size_bytes":   f.cache.Size(),
+		"hits":         int(hits),
+		"misses":       int(misses),
+		"lock_waits":   int(waits),
+		"lock_wait_ms": int(waitTime.Milliseconds()),
+	}
+}
+
+// The history is read from the database without the cache, so it is not
+// available during maintenance. The data of a position is anonymized like the
+// current data. The export contains the raw events, so it is not available with
+// anonymization.
+
+func (f *Flow) historyInformation(ctx context.Context, query datastore.HistoryQuery) (map[string][]datastore.HistoryEntry, int, error) {
+	if err := maintenance.Check(); err != nil {
+		return nil, 0, err
+	}
+	return f.postgres.HistoryInformation(ctx, query)
+}
+
+func (f *Flow) historyExport(ctx context.Context, query datastore.HistoryQuery, w io.Writer) error {
+	if err := maintenance.Check(); err != nil {
+		return err
+	}
+
+	if err := f.anonymizer.CheckRaw(); err != nil {
+		return err
+	}
+	return f.postgres.HistoryExport(ctx, query, w)
 }
 
-func (f *Flow) historyInformation(ctx context.Context, fqid string, w io.Writer) error {
-	return f.postgres.HistoryInformation(ctx, fqid, w)
+func (f *Flow) historyAt(position int) historyGetter {
+	if f.anonymizer != nil {
+		return anonymizedHistory{historyGetter: f.postgres.AtPosition(position), anonymizer: f.anonymizer}
+	}
+	return f.postgres.AtPosition(position)
+}
+
+func (f *Flow) positionTime(ctx context.Context, position int) (time.Time, error) {
+	if err := maintenance.Check(); err != nil {
+		return time.Time{}, err
+	}
+	return f.postgres.PositionTime(ctx, position)
+}
+
+func (f *Flow) positionAt(ctx context.Context, timestamp time.Time, meetingID int) (int, time.Time, error) {
+	if err := maintenance.Check(); err != nil {
+		return 0, time.Time{}, err
+	}
+	return f.postgres.PositionAt(ctx, timestamp, meetingID)
+}
+
+func (f *Flow) historyPositions(ctx context.Context, query datastore.HistoryQuery) ([]datastore.HistoryPosition, error) {
+	if err := maintenance.Check(); err != nil {
+		return nil, err
+	}
+	return f.postgres.HistoryPositions(ctx, query)
+}
+
+func (f *Flow) pruneHistory(ctx context.Context, before time.Time, keepMeetings []int) (int, error) {
+	if err := maintenance.Check(); err != nil {
+		return 0, err
+	}
+	return f.postgres.PruneHistory(ctx, before, keepMeetings)
+}
+
+func (f *Flow) projectionPreview(ctx context.Context, p7on *projector.Projection) ([]byte, error) {
+	return f.projector.Preview(ctx, p7on)
+}
+
+// anonymizedHistory anonymizes the data of a position.
+type anonymizedHistory struct {
+	historyGetter
+	anonymizer *anonymize.Anonymizer
+}
+
+func (h anonymizedHistory) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	data, err := h.historyGetter.Get(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	h.anonymizer.Data(data)
+	return data, nil
+}
+
+func (h anonymizedHistory) Object(ctx context.Context, fqid string) (map[string]json.RawMessage, error) {
+	object, err := h.historyGetter.Object(ctx, fqid)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, _, _ := strings.Cut(fqid, "/")
+	h.anonymizer.Object(collection, object)
+	return object, nil
+}
+
+func (h anonymizedHistory) MeetingObjects(ctx context.Context, meetingID int) (map[string]map[string]json.RawMessage, error) {
+	objects, err := h.historyGetter.MeetingObjects(ctx, meetingID)
+	if err != nil {
+		return nil, err
+	}
+
+	for fqid, object := range objects {
+		collection, _, _ := strings.Cut(fqid, "/")
+		h.anonymizer.Object(collection, object)
+	}
+	return objects, nil
 }
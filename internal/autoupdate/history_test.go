@@ -0,0 +1,47 @@
+package autoupdate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+)
+
+func TestChangesMeeting(t *testing.T) {
+	a := &Autoupdate{flow: dsmock.NewFlow(dsmock.YAMLData(`---
+	motion/1/meeting_id: 1
+	motion/2/meeting_id: 2
+	user/5/username: hugo
+	`))}
+
+	for _, tt := range []struct {
+		name   string
+		keys   []string
+		expect bool
+	}{
+		{"meeting", []string{"meeting/1/name"}, true},
+		{"other meeting", []string{"meeting/2/name"}, false},
+		{"object of the meeting", []string{"motion/1/title"}, true},
+		{"object of other meeting", []string{"motion/2/title"}, false},
+		{"deleted object", []string{"motion/3/title"}, true},
+		{"object without meeting", []string{"user/5/username"}, false},
+		{"no keys", nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := make([]dskey.Key, len(tt.keys))
+			for i, k := range tt.keys {
+				keys[i] = dskey.MustKey(k)
+			}
+
+			got, err := a.changesMeeting(context.Background(), keys, 1)
+			if err != nil {
+				t.Fatalf("changesMeeting: %v", err)
+			}
+
+			if got != tt.expect {
+				t.Errorf("changesMeeting returned %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
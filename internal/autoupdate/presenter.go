@@ -0,0 +1,63 @@
+package autoupdate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/presenter"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
+)
+
+// maxPresenterRequests is the number of presenters, that can be called in one
+// request.
+const maxPresenterRequests = 20
+
+// PresenterRequest is the call of one presenter.
+type PresenterRequest struct {
+	Presenter string          `json:"presenter"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Present calls the presenters and returns their results in the same order.
+//
+// The presenters read the data from the cache. The fetcher of a presenter only
+// returns the data, that the user can see.
+func (a *Autoupdate) Present(ctx context.Context, uid int, requests []PresenterRequest) ([]json.RawMessage, error) {
+	if len(requests) == 0 {
+		return nil, invalidInputError{"no presenter requested"}
+	}
+
+	if len(requests) > maxPresenterRequests {
+		return nil, invalidInputError{fmt.Sprintf("at most %d presenters can be requested at once", maxPresenterRequests)}
+	}
+
+	funcs := make([]presenter.Func, len(requests))
+	for i, req := range requests {
+		funcs[i] = a.presenters.Get(req.Presenter)
+		if funcs[i] == nil {
+			return nil, invalidInputError{fmt.Sprintf("unknown presenter `%s`", req.Presenter)}
+		}
+	}
+
+	ctx, restricter := a.restricter(ctx, a.flow, uid)
+
+	results := make([]json.RawMessage, len(requests))
+	for i, req := range requests {
+		result, err := funcs[i](ctx, dsfetch.New(restricter), presenter.Request{
+			UserID:       uid,
+			Data:         req.Data,
+			Unrestricted: dsfetch.New(a.flow),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("presenter %s: %w", req.Presenter, err)
+		}
+
+		results[i], err = json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("encoding result of presenter %s: %w", req.Presenter, err)
+		}
+	}
+
+	return results, nil
+}
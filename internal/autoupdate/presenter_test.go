@@ -0,0 +1,44 @@
+package autoupdate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestPresent(t *testing.T) {
+	ctx := context.Background()
+	data := dsmock.YAMLData(`---
+	poll/5:
+		meeting_id: 1
+		entitled_group_ids: [10]
+	group/10/meeting_user_ids: [20]
+	meeting_user/20/user_id: 30
+	user/30/is_present_in_meeting_ids: [1]
+	`)
+	requests := []autoupdate.PresenterRequest{{Presenter: "eligible_voters", Data: []byte(`{"poll_id": 5}`)}}
+
+	s, _, _ := autoupdate.New(environment.ForTests{}, dsmock.NewFlow(data), RestrictAllowed)
+	results, err := s.Present(ctx, 1, requests)
+	if err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+
+	if len(results) != 1 || string(results[0]) != `{"entitled_users":1,"present_entitled_users":1}` {
+		t.Errorf("got %s, expected one result", results)
+	}
+
+	var errClient interface{ Type() string }
+	s, _, _ = autoupdate.New(environment.ForTests{}, dsmock.NewFlow(data), RestrictNotAllowed)
+	if _, err := s.Present(ctx, 1, requests); !errors.As(err, &errClient) || errClient.Type() != "permission_denied" {
+		t.Errorf("poll, the user can not see: got %v, expected permission denied", err)
+	}
+
+	if _, err := s.Present(ctx, 1, []autoupdate.PresenterRequest{{Presenter: "unknown"}}); !errors.As(err, &errClient) || errClient.Type() != "invalid_input" {
+		t.Errorf("unknown presenter: got %v, expected invalid input", err)
+	}
+}
@@ -0,0 +1,86 @@
+package autoupdate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// projectorRequest is the keysbuilder body for a projector connection.
+const projectorRequest = `{
+	"ids": [%d],
+	"collection": "projector",
+	"fields": {
+		"scale": null,
+		"scroll": null,
+		"current_projection_ids": {
+			"type": "relation-list",
+			"collection": "projection",
+			"fields": {
+				"content": null,
+				"content_object_id": null,
+				"options": null,
+				"stable": null,
+				"type": null,
+				"weight": null
+			}
+		}
+	}
+}`
+
+// projectorKeysBuilder returns the keysbuilder for the projections of a
+// projector.
+func projectorKeysBuilder(projectorID int) (*keysbuilder.Builder, error) {
+	if projectorID < 1 {
+		return nil, invalidInputError{"projector id has to be a positive number"}
+	}
+
+	kb, err := keysbuilder.FromJSON(strings.NewReader(fmt.Sprintf(projectorRequest, projectorID)))
+	if err != nil {
+		return nil, fmt.Errorf("build projector keys: %w", err)
+	}
+	return kb, nil
+}
+
+// ConnectProjector returns a connection for the current projections of a
+// projector.
+//
+// The connection does not wait for the work pool. A projector only needs a
+// few keys, so its data is calculated before the data of other clients, even
+// if many clients are connected.
+func (a *Autoupdate) ConnectProjector(ctx context.Context, userID int, projectorID int) (Connection, error) {
+	kb, err := projectorKeysBuilder(projectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &connection{
+		autoupdate:   a,
+		uid:          userID,
+		kb:           kb,
+		skipWorkpool: true,
+	}
+
+	return c, nil
+}
+
+// ProjectorSnapshot returns the current data of a projector with its
+// projections.
+//
+// It is the same data as the first message of ConnectProjector, but the
+// connection is not kept open.
+func (a *Autoupdate) ProjectorSnapshot(ctx context.Context, userID int, projectorID int) (map[dskey.Key][]byte, error) {
+	kb, err := projectorKeysBuilder(projectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := a.SingleData(ctx, userID, kb)
+	if err != nil {
+		return nil, fmt.Errorf("projector data: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,234 @@
+package autoupdate
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// parallelRelevanceCheck is the number of parked connections, from which the
+// publisher checks them in more then one goroutine.
+const parallelRelevanceCheck = 256
+
+// publishBatch collects the keys of the datastore updates, so updates in a
+// short window are published together. Each publish wakes connections, so a
+// burst of small updates would wake them many times.
+type publishBatch struct {
+	mu    sync.Mutex
+	keys  map[dskey.Key]struct{}
+	first time.Time
+
+	signal chan struct{}
+}
+
+func newPublishBatch() *publishBatch {
+	return &publishBatch{
+		keys:   make(map[dskey.Key]struct{}),
+		signal: make(chan struct{}, 1),
+	}
+}
+
+// add adds keys to the batch. The time of the first update in the batch is
+// saved as publish time.
+func (b *publishBatch) add(keys []dskey.Key) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.keys) == 0 {
+		b.first = clock.Now()
+	}
+
+	for _, k := range keys {
+		b.keys[k] = struct{}{}
+	}
+
+	select {
+	case b.signal <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the keys of the batch and the time of the first update and
+// starts a new batch.
+func (b *publishBatch) take() ([]dskey.Key, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keys := make([]dskey.Key, 0, len(b.keys))
+	for k := range b.keys {
+		keys = append(keys, k)
+	}
+	clear(b.keys)
+	return keys, b.first
+}
+
+// publishLoop publishes the batched keys. After the first update, it waits for
+// the window, so all updates in this time are published together. Blocks until
+// the context is done.
+func (a *Autoupdate) publishLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.batch.signal:
+		}
+
+		if err := clock.Sleep(ctx, a.publishWindow); err != nil {
+			return
+		}
+
+		keys, first := a.batch.take()
+		if len(keys) == 0 {
+			continue
+		}
+		a.publish(keys, first)
+	}
+}
+
+// publish adds the keys to the topic and wakes the parked connections, that
+// are interested in them.
+func (a *Autoupdate) publish(keys []dskey.Key, published time.Time) {
+	// The time is saved before the keys are published, so a connection always
+	// finds it. There is only one publisher, so the next id is known.
+	a.setPublished(a.topic.LastID()+1, published)
+	tid := a.topic.Publish(keys...)
+	a.parking.wake(tid, keys)
+	a.search.invalidate(keys)
+}
+
+// parking holds the connections, that wait for new data.
+//
+// Instead of waking all connections on each publish, the publisher only wakes
+// the connections, where a published key is one of its hotkeys. The other
+// connections stay parked.
+type parking struct {
+	mu      sync.Mutex
+	lastID  uint64
+	waiting map[*parked]struct{}
+}
+
+// parked is one waiting connection.
+type parked struct {
+	hotkeys map[dskey.Key]struct{}
+	wake    chan struct{}
+
+	// skipped is the last topic id, where the published keys were not
+	// relevant for the connection.
+	skipped uint64
+	woken   bool
+}
+
+func newParking() *parking {
+	return &parking{waiting: make(map[*parked]struct{})}
+}
+
+// wait blocks until a key is published, that is in hotkeys. tid is the last
+// topic id, the connection has seen.
+//
+// Returns the last topic id, that can be skipped, since the keys, that were
+// published until then, are not in hotkeys. The returned id is tid, if nothing
+// was skipped.
+//
+// wait does not block, if something was published after tid. The hotkeys must
+// not be changed while wait is blocking.
+func (p *parking) wait(ctx context.Context, tid uint64, hotkeys map[dskey.Key]struct{}) (uint64, error) {
+	p.mu.Lock()
+	if p.lastID > tid {
+		p.mu.Unlock()
+		return tid, nil
+	}
+
+	w := &parked{
+		hotkeys: hotkeys,
+		wake:    make(chan struct{}),
+		skipped: tid,
+	}
+	p.waiting[w] = struct{}{}
+	p.mu.Unlock()
+
+	select {
+	case <-w.wake:
+		return w.skipped, nil
+
+	case <-ctx.Done():
+		p.mu.Lock()
+		delete(p.waiting, w)
+		p.mu.Unlock()
+		return tid, ctx.Err()
+	}
+}
+
+// wake wakes all connections, that are interested in one of the keys. tid is
+// the topic id of the keys.
+func (p *parking) wake(tid uint64, keys []dskey.Key) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastID = tid
+	if len(p.waiting) == 0 {
+		return
+	}
+
+	waiting := make([]*parked, 0, len(p.waiting))
+	for w := range p.waiting {
+		waiting = append(waiting, w)
+	}
+
+	checkRelevance(waiting, keys)
+
+	for _, w := range waiting {
+		if !w.woken {
+			w.skipped = tid
+			continue
+		}
+
+		delete(p.waiting, w)
+		close(w.wake)
+	}
+}
+
+// checkRelevance sets woken on each parked connection, where one of the keys is
+// a hotkey.
+//
+// With many parked connections, they are checked in parallel.
+func checkRelevance(waiting []*parked, keys []dskey.Key) {
+	check := func(waiting []*parked) {
+		for _, w := range waiting {
+			for _, k := range keys {
+				if _, ok := w.hotkeys[k]; ok {
+					w.woken = true
+					break
+				}
+			}
+		}
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if len(waiting) < parallelRelevanceCheck || workers == 1 {
+		check(waiting)
+		return
+	}
+
+	chunk := (len(waiting) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(waiting); start += chunk {
+		end := min(start+chunk, len(waiting))
+		wg.Add(1)
+		go func(waiting []*parked) {
+			defer wg.Done()
+			check(waiting)
+		}(waiting[start:end])
+	}
+	wg.Wait()
+}
+
+// parkedCount returns the number of parked connections.
+func (p *parking) parkedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.waiting)
+}
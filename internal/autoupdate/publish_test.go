@@ -0,0 +1,158 @@
+package autoupdate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/ostcar/topic"
+)
+
+type waitResult struct {
+	skipped uint64
+	err     error
+}
+
+func parkInBackground(ctx context.Context, p *parking, tid uint64, hotkeys ...dskey.Key) <-chan waitResult {
+	keys := make(map[dskey.Key]struct{}, len(hotkeys))
+	for _, k := range hotkeys {
+		keys[k] = struct{}{}
+	}
+
+	done := make(chan waitResult, 1)
+	go func() {
+		skipped, err := p.wait(ctx, tid, keys)
+		done <- waitResult{skipped, err}
+	}()
+	return done
+}
+
+func waitParked(t *testing.T, p *parking, n int) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for p.parkedCount() != n {
+		select {
+		case <-timeout:
+			t.Fatalf("got %d parked connections, expected %d", p.parkedCount(), n)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestParkingWakesOnlyInterested(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := newParking()
+	done1 := parkInBackground(ctx, p, 0, myKey1)
+	done2 := parkInBackground(ctx, p, 0, myKey2)
+	waitParked(t, p, 2)
+
+	p.wake(1, []dskey.Key{myKey1})
+
+	select {
+	case got := <-done1:
+		if got.err != nil || got.skipped != 0 {
+			t.Errorf("first connection returned (%d, %v), expected (0, nil)", got.skipped, got.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("first connection was not woken")
+	}
+
+	select {
+	case <-done2:
+		t.Fatalf("second connection was woken for a key, it is not interested in")
+	default:
+	}
+
+	p.wake(2, []dskey.Key{myKey2})
+
+	select {
+	case got := <-done2:
+		if got.err != nil || got.skipped != 1 {
+			t.Errorf("second connection returned (%d, %v), expected (1, nil)", got.skipped, got.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("second connection was not woken")
+	}
+}
+
+func TestParkingAfterPublish(t *testing.T) {
+	p := newParking()
+	p.wake(1, []dskey.Key{myKey2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	skipped, err := p.wait(ctx, 0, map[dskey.Key]struct{}{myKey1: {}})
+	if err != nil {
+		t.Fatalf("wait returned: %v", err)
+	}
+
+	if skipped != 0 {
+		t.Errorf("wait skipped until %d, expected 0", skipped)
+	}
+}
+
+func TestParkingContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := newParking()
+	done := parkInBackground(ctx, p, 0, myKey1)
+	waitParked(t, p, 1)
+	cancel()
+
+	if got := <-done; got.err == nil {
+		t.Errorf("wait returned no error")
+	}
+
+	if p.parkedCount() != 0 {
+		t.Errorf("connection is still parked")
+	}
+}
+
+func TestPublishLoopBatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	defer clock.Use(fake)()
+
+	a := &Autoupdate{
+		topic:         topic.New[dskey.Key](),
+		publishWindow: 5 * time.Millisecond,
+		batch:         newPublishBatch(),
+		parking:       newParking(),
+		search:        newSearchIndex(),
+	}
+	a.published.times = make(map[uint64]time.Time)
+
+	go a.publishLoop(ctx)
+
+	a.batch.add([]dskey.Key{myKey1})
+	if err := fake.BlockUntil(ctx, 1); err != nil {
+		t.Fatalf("waiting for the publish window: %v", err)
+	}
+	a.batch.add([]dskey.Key{myKey2})
+	fake.Advance(5 * time.Millisecond)
+
+	receiveCtx, receiveCancel := context.WithTimeout(ctx, time.Second)
+	defer receiveCancel()
+
+	tid, keys, err := a.topic.Receive(receiveCtx, 0)
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+
+	if tid != 1 || len(keys) != 2 {
+		t.Errorf("got topic id %d with keys %v, expected id 1 with both keys", tid, keys)
+	}
+
+	if got := a.publishedTime(1); !got.Equal(start) {
+		t.Errorf("published time is %s, expected the time of the first update %s", got, start)
+	}
+}
@@ -0,0 +1,157 @@
+package autoupdate
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
+)
+
+var (
+	envHistoryRetention     = environment.NewVariable("HISTORY_RETENTION", "0s", "Time, how long history entries are available. Older entries are hidden from all history routes. Zero keeps all entries.")
+	envHistoryLegalHold     = environment.NewVariable("HISTORY_LEGAL_HOLD_MEETINGS", "", "Comma separated list of meeting ids. The history of these meetings is available regardless of `HISTORY_RETENTION`.")
+	envHistoryPruneInterval = environment.NewVariable("HISTORY_PRUNE_INTERVAL", "0s", "Time between two runs, that delete the history older than `HISTORY_RETENTION` from the database. Zero only hides the entries.")
+)
+
+func init() {
+	tenant.Overridable(envHistoryRetention)
+}
+
+// historyRetention decides, which history entries are visible.
+//
+// The entries are hidden as soon as they are older than the window. With
+// HISTORY_PRUNE_INTERVAL, they are also deleted by pruneHistory.
+type historyRetention struct {
+	window    time.Duration
+	legalHold set.Set[int]
+	now       func() time.Time
+}
+
+func parseHistoryRetention(lookup environment.Environmenter) (historyRetention, error) {
+	window, err := environment.ParseDuration(envHistoryRetention.Value(lookup))
+	if err != nil || window < 0 {
+		return historyRetention{}, fmt.Errorf("invalid value for `%s`, expected positive duration got %s", envHistoryRetention.Key, envHistoryRetention.Value(lookup))
+	}
+
+	legalHold := set.New[int]()
+	for _, rawID := range strings.Split(envHistoryLegalHold.Value(lookup), ",") {
+		rawID = strings.TrimSpace(rawID)
+		if rawID == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(rawID)
+		if err != nil {
+			return historyRetention{}, fmt.Errorf("invalid value for `%s`, expected meeting id got %s", envHistoryLegalHold.Key, rawID)
+		}
+		legalHold.Add(id)
+	}
+
+	return historyRetention{
+		window:    window,
+		legalHold: legalHold,
+		now:       clock.Now,
+	}, nil
+}
+
+// parseTenantRetentions returns the history retention of each tenant.
+func parseTenantRetentions(lookup environment.Environmenter) (map[string]historyRetention, error) {
+	retentions := make(map[string]historyRetention)
+	for name, tenantLookup := range tenant.Lookups(lookup) {
+		retention, err := parseHistoryRetention(tenantLookup)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		retentions[name] = retention
+	}
+	return retentions, nil
+}
+
+// retentionFor returns the history retention for the tenant of the request.
+func (a *Autoupdate) retentionFor(ctx context.Context) historyRetention {
+	if retention, ok := a.tenantRetention[tenant.FromContext(ctx)]; ok {
+		return retention
+	}
+	return a.retention
+}
+
+// since returns the time of the oldest visible history entry for a meeting.
+// Use 0 for objects without a meeting.
+//
+// The zero time means, that all entries are visible.
+func (r historyRetention) since(meetingID int) time.Time {
+	if r.window == 0 || (meetingID != 0 && r.legalHold.Has(meetingID)) {
+		return time.Time{}
+	}
+	return r.now().Add(-r.window)
+}
+
+func parsePruneInterval(lookup environment.Environmenter) (time.Duration, error) {
+	interval, err := environment.ParseDuration(envHistoryPruneInterval.Value(lookup))
+	if err != nil || interval < 0 {
+		return 0, fmt.Errorf("invalid value for `%s`, expected positive duration got %s", envHistoryPruneInterval.Key, envHistoryPruneInterval.Value(lookup))
+	}
+	return interval, nil
+}
+
+// pruneLimit returns the time, before which the history can be deleted, and
+// the meetings, whose history is kept.
+//
+// The tenants without isolation use the same database, so the longest window
+// is used. Returns false, if one of them keeps all entries.
+func (a *Autoupdate) pruneLimit() (time.Time, []int, bool) {
+	retentions := append([]historyRetention{a.retention}, slices.Collect(maps.Values(a.tenantRetention))...)
+
+	var window time.Duration
+	keep := set.New[int]()
+	for _, r := range retentions {
+		if r.window == 0 {
+			return time.Time{}, nil, false
+		}
+		window = max(window, r.window)
+		keep.Merge(r.legalHold)
+	}
+
+	keepMeetings := keep.List()
+	slices.Sort(keepMeetings)
+	return clock.Now().Add(-window), keepMeetings, true
+}
+
+// pruneHistory deletes the history, that is older than the retention, after
+// each prune interval.
+func (a *Autoupdate) pruneHistory(ctx context.Context, errorHandler func(error)) {
+	type pruner interface {
+		pruneHistory(ctx context.Context, before time.Time, keepMeetings []int) (int, error)
+	}
+	p, ok := a.flow.(pruner)
+	if !ok || a.pruneInterval == 0 {
+		return
+	}
+
+	tick := clock.NewTicker(a.pruneInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C():
+			before, keepMeetings, ok := a.pruneLimit()
+			if !ok {
+				continue
+			}
+
+			if _, err := p.pruneHistory(ctx, before, keepMeetings); err != nil {
+				errorHandler(fmt.Errorf("pruning history: %w", err))
+			}
+		}
+	}
+}
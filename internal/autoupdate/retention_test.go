@@ -0,0 +1,103 @@
+package autoupdate
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
+)
+
+func TestHistoryRetention(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name      string
+		env       environment.ForTests
+		meetingID int
+		expect    time.Time
+	}{
+		{"default keeps all", nil, 1, time.Time{}},
+		{"window", map[string]string{"HISTORY_RETENTION": "48h"}, 1, now.Add(-48 * time.Hour)},
+		{"window without meeting", map[string]string{"HISTORY_RETENTION": "48h"}, 0, now.Add(-48 * time.Hour)},
+		{"legal hold", map[string]string{"HISTORY_RETENTION": "48h", "HISTORY_LEGAL_HOLD_MEETINGS": "1, 2"}, 2, time.Time{}},
+		{"other meeting", map[string]string{"HISTORY_RETENTION": "48h", "HISTORY_LEGAL_HOLD_MEETINGS": "1,2"}, 3, now.Add(-48 * time.Hour)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			retention, err := parseHistoryRetention(environment.ForTests(tt.env))
+			if err != nil {
+				t.Fatalf("parseHistoryRetention: %v", err)
+			}
+			retention.now = func() time.Time { return now }
+
+			if got := retention.since(tt.meetingID); !got.Equal(tt.expect) {
+				t.Errorf("since(%d) = %s, expected %s", tt.meetingID, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestHistoryRetentionInvalid(t *testing.T) {
+	for _, env := range []environment.ForTests{
+		{"HISTORY_RETENTION": "-1h"},
+		{"HISTORY_RETENTION": "soon"},
+		{"HISTORY_LEGAL_HOLD_MEETINGS": "1,x"},
+	} {
+		_, err := parseHistoryRetention(environment.ForTests(env))
+		if err == nil {
+			t.Errorf("parseHistoryRetention(%v) returned no error", env)
+		}
+	}
+}
+
+func TestHistoryRetentionTenant(t *testing.T) {
+	a := &Autoupdate{
+		retention: historyRetention{},
+		tenantRetention: map[string]historyRetention{
+			"meeting.example.com": {window: time.Hour, now: time.Now},
+		},
+	}
+
+	if got := a.retentionFor(context.Background()); got.window != 0 {
+		t.Errorf("request without tenant has window %s, expected 0", got.window)
+	}
+
+	ctx := tenant.ContextWith(context.Background(), "meeting.example.com")
+	if got := a.retentionFor(ctx); got.window != time.Hour {
+		t.Errorf("request of the tenant has window %s, expected 1h", got.window)
+	}
+}
+
+func TestPruneLimit(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	defer clock.Use(clock.NewFake(now))()
+
+	a := &Autoupdate{
+		retention: historyRetention{window: time.Hour, legalHold: set.New(2)},
+		tenantRetention: map[string]historyRetention{
+			"meeting.example.com": {window: 48 * time.Hour, legalHold: set.New(1, 2)},
+		},
+	}
+
+	before, keepMeetings, ok := a.pruneLimit()
+	if !ok {
+		t.Fatalf("pruneLimit returned false")
+	}
+
+	if expect := now.Add(-48 * time.Hour); !before.Equal(expect) {
+		t.Errorf("got time %s, expected the longest window %s", before, expect)
+	}
+
+	if !reflect.DeepEqual(keepMeetings, []int{1, 2}) {
+		t.Errorf("got kept meetings %v, expected [1 2]", keepMeetings)
+	}
+
+	a.tenantRetention["other.example.com"] = historyRetention{}
+	if _, _, ok := a.pruneLimit(); ok {
+		t.Errorf("pruneLimit returned true, when a tenant keeps all entries")
+	}
+}
@@ -0,0 +1,461 @@
+package autoupdate
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
+)
+
+const (
+	// searchMaxLimit is the maximum number of results of a search.
+	searchMaxLimit = 100
+
+	// searchChunk is the number of candidates, that are checked with the
+	// restricter at once.
+	searchChunk = 100
+
+	// searchIdle is the time after which the index of a meeting is removed,
+	// if it was not used.
+	searchIdle = time.Hour
+)
+
+// searchFields are the text fields, that are indexed for each collection.
+var searchFields = map[string][]string{
+	"motion":      {"number", "title", "text"},
+	"topic":       {"title", "text"},
+	"agenda_item": {"item_number", "comment"},
+	"user":        {"username", "first_name", "last_name"},
+}
+
+// searchMeetingFields are the fields of a meeting with the ids of the indexed
+// objects.
+var searchMeetingFields = map[string]string{
+	"motion_ids":      "motion",
+	"topic_ids":       "topic",
+	"agenda_item_ids": "agenda_item",
+	"user_ids":        "user",
+}
+
+var reHTMLTag = regexp.MustCompile(`<[^>]*>`)
+
+// SearchResult is one object found by a search. Fields contains the indexed
+// fields of the object, that the user can see.
+type SearchResult struct {
+	FQID   string            `json:"fqid"`
+	Fields map[string]string `json:"fields"`
+}
+
+// Search returns the objects of a meeting, where the indexed text fields
+// contain all words of the query. The last word can also be the beginning of
+// a word.
+//
+// The index is built on the first search in a meeting and is updated with
+// each datastore update. The query is matched again on the restricted values,
+// so a result never depends on a field, the user can not see.
+func (a *Autoupdate) Search(ctx context.Context, uid int, meetingID int, query string, limit int) ([]SearchResult, error) {
+	if meetingID < 1 {
+		return nil, invalidInputError{"meeting_id has to be a positive number"}
+	}
+
+	terms := searchTokens(query)
+	if len(terms) == 0 {
+		return nil, invalidInputError{"query has no words"}
+	}
+
+	if limit < 1 || limit > searchMaxLimit {
+		return nil, invalidInputError{fmt.Sprintf("limit has to be between 1 and %d", searchMaxLimit)}
+	}
+
+	ctx, restricter := a.restricter(ctx, a.flow, uid)
+
+	meetingKey := dskey.MustKey(fmt.Sprintf("meeting/%d/id", meetingID))
+	data, err := restrictedGet(ctx, restricter, []dskey.Key{meetingKey})
+	if err != nil {
+		return nil, fmt.Errorf("checking meeting: %w", err)
+	}
+
+	if data[meetingKey] == nil {
+		return nil, permissionDeniedError{fmt.Errorf("you can not see meeting %d", meetingID)}
+	}
+
+	if err := a.search.refresh(ctx, a.flow, meetingID); err != nil {
+		return nil, fmt.Errorf("updating search index: %w", err)
+	}
+
+	candidates := a.search.find(meetingID, terms)
+
+	results := []SearchResult{}
+	for start := 0; start < len(candidates) && len(results) < limit; start += searchChunk {
+		chunk := candidates[start:min(start+searchChunk, len(candidates))]
+
+		data, err := restrictedGet(ctx, restricter, searchKeys(chunk))
+		if err != nil {
+			return nil, fmt.Errorf("getting restricted values: %w", err)
+		}
+
+		for _, fqid := range chunk {
+			fields := searchValues(fqid, data)
+			if !searchMatch(fields, terms) {
+				continue
+			}
+
+			results = append(results, SearchResult{FQID: fqid, Fields: fields})
+			if len(results) == limit {
+				break
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// searchIndex is an inverted index of the text fields of the meetings.
+type searchIndex struct {
+	mu       sync.Mutex
+	meetings map[int]*meetingIndex
+}
+
+// meetingIndex is the index of one meeting.
+type meetingIndex struct {
+	// stale is true, if the ids of the indexed objects have to be read
+	// again.
+	stale bool
+
+	// dirty are the objects, that have to be indexed again.
+	dirty set.Set[string]
+
+	// docs are the tokens of each object.
+	docs map[string][]string
+
+	// tokens are the objects of each token.
+	tokens map[string]set.Set[string]
+
+	lastUsed time.Time
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{meetings: make(map[int]*meetingIndex)}
+}
+
+// invalidate marks the objects of the keys to be indexed again. It is called
+// with the keys of each datastore update.
+func (s *searchIndex) invalidate(keys []dskey.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.meetings) == 0 {
+		return
+	}
+
+	for _, key := range keys {
+		if key.Collection() == "meeting" {
+			if _, ok := searchMeetingFields[key.Field()]; ok {
+				if m, ok := s.meetings[key.ID()]; ok {
+					m.stale = true
+				}
+			}
+			continue
+		}
+
+		if !slices.Contains(searchFields[key.Collection()], key.Field()) {
+			continue
+		}
+
+		fqid := key.FQID()
+		for _, m := range s.meetings {
+			if _, ok := m.docs[fqid]; ok {
+				m.dirty.Add(fqid)
+			}
+		}
+	}
+}
+
+// reset removes all indexes. They are built again on the next search.
+func (s *searchIndex) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.meetings)
+}
+
+// prune removes the indexes, that were not used since the given time.
+func (s *searchIndex) prune(until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, m := range s.meetings {
+		if m.lastUsed.Before(until) {
+			delete(s.meetings, id)
+		}
+	}
+}
+
+// refresh builds the index of the meeting or indexes the changed objects
+// again.
+//
+// The mutex is not hold while the values are fetched, so the datastore
+// updates are not blocked. Updates in this time mark the objects as dirty for
+// the next refresh.
+func (s *searchIndex) refresh(ctx context.Context, getter flow.Getter, meetingID int) error {
+	s.mu.Lock()
+	m, ok := s.meetings[meetingID]
+	if !ok {
+		m = &meetingIndex{
+			stale:  true,
+			dirty:  set.New[string](),
+			docs:   make(map[string][]string),
+			tokens: make(map[string]set.Set[string]),
+		}
+		s.meetings[meetingID] = m
+	}
+	m.lastUsed = clock.Now()
+	stale := m.stale
+	dirty := m.dirty
+	m.stale = false
+	m.dirty = set.New[string]()
+	s.mu.Unlock()
+
+	members, data, err := s.fetch(ctx, getter, m, meetingID, stale, dirty)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		// Try again on the next search.
+		m.stale = m.stale || stale
+		m.dirty.Merge(dirty)
+		return err
+	}
+
+	if stale {
+		for fqid := range m.docs {
+			if !members.Has(fqid) {
+				m.remove(fqid)
+			}
+		}
+	}
+
+	for _, fqid := range dirty.List() {
+		m.remove(fqid)
+		if stale && !members.Has(fqid) {
+			continue
+		}
+		m.add(fqid, searchValues(fqid, data))
+	}
+	return nil
+}
+
+// fetch returns the values of the objects, that have to be indexed. If stale
+// is true, the objects of the meeting are read and the new ones are added to
+// dirty.
+func (s *searchIndex) fetch(ctx context.Context, getter flow.Getter, m *meetingIndex, meetingID int, stale bool, dirty set.Set[string]) (set.Set[string], map[dskey.Key][]byte, error) {
+	var members set.Set[string]
+	if stale {
+		var err error
+		members, err = searchMembers(ctx, getter, meetingID)
+		if err != nil {
+			return members, nil, err
+		}
+
+		s.mu.Lock()
+		for _, fqid := range members.List() {
+			if _, ok := m.docs[fqid]; !ok {
+				// The empty doc makes sure, that an update while fetching
+				// marks the object as dirty.
+				m.docs[fqid] = nil
+				dirty.Add(fqid)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	if dirty.Len() == 0 {
+		return members, nil, nil
+	}
+
+	data, err := getter.Get(ctx, searchKeys(dirty.List())...)
+	if err != nil {
+		return members, nil, fmt.Errorf("getting values: %w", err)
+	}
+	return members, data, nil
+}
+
+// find returns the objects, that contain all terms, sorted by collection and
+// id.
+func (s *searchIndex) find(meetingID int, terms []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.meetings[meetingID]
+	if !ok {
+		return nil
+	}
+
+	var found set.Set[string]
+	for i, term := range terms {
+		prefix := i == len(terms)-1
+		matches := set.New[string]()
+		for token, fqids := range m.tokens {
+			if token != term && !(prefix && strings.HasPrefix(token, term)) {
+				continue
+			}
+
+			for _, fqid := range fqids.List() {
+				if found.IsNotInitialized() || found.Has(fqid) {
+					matches.Add(fqid)
+				}
+			}
+		}
+
+		found = matches
+		if found.Len() == 0 {
+			return nil
+		}
+	}
+
+	result := found.List()
+	slices.SortFunc(result, func(a, b string) int {
+		collectionA, idA, _ := strings.Cut(a, "/")
+		collectionB, idB, _ := strings.Cut(b, "/")
+		if c := strings.Compare(collectionA, collectionB); c != 0 {
+			return c
+		}
+		if len(idA) != len(idB) {
+			return cmp.Compare(len(idA), len(idB))
+		}
+		return strings.Compare(idA, idB)
+	})
+	return result
+}
+
+func (m *meetingIndex) add(fqid string, fields map[string]string) {
+	var tokens []string
+	for _, value := range fields {
+		tokens = append(tokens, searchTokens(value)...)
+	}
+	slices.Sort(tokens)
+	tokens = slices.Compact(tokens)
+
+	m.docs[fqid] = tokens
+	for _, token := range tokens {
+		if _, ok := m.tokens[token]; !ok {
+			m.tokens[token] = set.New[string]()
+		}
+		m.tokens[token].Add(fqid)
+	}
+}
+
+func (m *meetingIndex) remove(fqid string) {
+	for _, token := range m.docs[fqid] {
+		m.tokens[token].Remove(fqid)
+		if m.tokens[token].Len() == 0 {
+			delete(m.tokens, token)
+		}
+	}
+	delete(m.docs, fqid)
+}
+
+// searchMembers returns the fqids of the indexed objects of a meeting.
+func searchMembers(ctx context.Context, getter flow.Getter, meetingID int) (set.Set[string], error) {
+	keys := make([]dskey.Key, 0, len(searchMeetingFields))
+	for field := range searchMeetingFields {
+		keys = append(keys, dskey.MustKey(fmt.Sprintf("meeting/%d/%s", meetingID, field)))
+	}
+
+	data, err := getter.Get(ctx, keys...)
+	if err != nil {
+		return set.Set[string]{}, fmt.Errorf("getting objects of meeting %d: %w", meetingID, err)
+	}
+
+	members := set.New[string]()
+	for _, key := range keys {
+		if data[key] == nil {
+			continue
+		}
+
+		var ids []int
+		if err := json.Unmarshal(data[key], &ids); err != nil {
+			return set.Set[string]{}, fmt.Errorf("decoding %s: %w", key, err)
+		}
+
+		for _, id := range ids {
+			members.Add(fmt.Sprintf("%s/%d", searchMeetingFields[key.Field()], id))
+		}
+	}
+	return members, nil
+}
+
+// searchKeys returns the keys of the indexed fields of the objects.
+func searchKeys(fqids []string) []dskey.Key {
+	var keys []dskey.Key
+	for _, fqid := range fqids {
+		collection, _, _ := strings.Cut(fqid, "/")
+		for _, field := range searchFields[collection] {
+			keys = append(keys, dskey.MustKey(fqid+"/"+field))
+		}
+	}
+	return keys
+}
+
+// searchValues returns the text values of the indexed fields of an object.
+// Fields without a value are skipped.
+func searchValues(fqid string, data map[dskey.Key][]byte) map[string]string {
+	collection, _, _ := strings.Cut(fqid, "/")
+
+	fields := make(map[string]string)
+	for _, field := range searchFields[collection] {
+		raw := data[dskey.MustKey(fqid+"/"+field)]
+		if raw == nil {
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil || value == "" {
+			continue
+		}
+		fields[field] = value
+	}
+	return fields
+}
+
+// searchMatch returns true, if the values contain all terms.
+func searchMatch(fields map[string]string, terms []string) bool {
+	if len(fields) == 0 {
+		return false
+	}
+
+	var tokens []string
+	for _, value := range fields {
+		tokens = append(tokens, searchTokens(value)...)
+	}
+
+	for i, term := range terms {
+		prefix := i == len(terms)-1
+		if !slices.ContainsFunc(tokens, func(token string) bool {
+			return token == term || (prefix && strings.HasPrefix(token, term))
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchTokens splits a text into lower case words. HTML tags are removed.
+func searchTokens(text string) []string {
+	text = reHTMLTag.ReplaceAllString(text, " ")
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
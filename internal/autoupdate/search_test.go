@@ -0,0 +1,199 @@
+package autoupdate_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var searchData = dsmock.YAMLData(`---
+meeting/1:
+	id: 1
+	motion_ids: [1, 2]
+	topic_ids: [3]
+	user_ids: [5]
+
+motion/1:
+	number: A1
+	title: Budget 2024
+	text: <p>The <b>budget</b> for the next year.</p>
+
+motion/2:
+	title: Climate
+	text: Secret budget plans
+
+topic/3/title: Budget discussion
+
+user/5:
+	username: bud
+	first_name: Max
+	last_name: Mustermann
+`)
+
+// restrictHidden is a restricter, that hides the given keys.
+func restrictHidden(hidden ...string) autoupdate.RestrictMiddleware {
+	return func(ctx context.Context, getter flow.Getter, uid int) (context.Context, flow.Getter) {
+		return ctx, hideGetter{getter: getter, hidden: hidden}
+	}
+}
+
+type hideGetter struct {
+	getter flow.Getter
+	hidden []string
+}
+
+func (g hideGetter) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	data, err := g.getter.Get(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range g.hidden {
+		if _, ok := data[dskey.MustKey(key)]; ok {
+			data[dskey.MustKey(key)] = nil
+		}
+	}
+	return data, nil
+}
+
+func searchFQIDs(results []autoupdate.SearchResult) []string {
+	fqids := []string{}
+	for _, r := range results {
+		fqids = append(fqids, r.FQID)
+	}
+	return fqids
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := autoupdate.New(environment.ForTests{}, dsmock.NewFlow(searchData), RestrictAllowed)
+
+	for _, tt := range []struct {
+		query  string
+		expect []string
+	}{
+		{"budget", []string{"motion/1", "motion/2", "topic/3"}},
+		{"BUDGET 2024", []string{"motion/1"}},
+		{"bud", []string{"motion/1", "motion/2", "topic/3", "user/5"}},
+		{"bud plans", []string{}},
+		{"budget pla", []string{"motion/2"}},
+		{"a1", []string{"motion/1"}},
+		{"max", []string{"user/5"}},
+		{"p", []string{"motion/2"}},
+		{"unknown", []string{}},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := s.Search(ctx, 1, 1, tt.query, 10)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+
+			if got := searchFQIDs(results); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSearchRestricted(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := autoupdate.New(environment.ForTests{}, dsmock.NewFlow(searchData), restrictHidden("motion/2/text"))
+
+	results, err := s.Search(ctx, 1, 1, "budget", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	if got := searchFQIDs(results); !reflect.DeepEqual(got, []string{"motion/1", "topic/3"}) {
+		t.Errorf("got %v, expected motion/1 and topic/3", got)
+	}
+
+	results, err = s.Search(ctx, 1, 1, "climate", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	expect := []autoupdate.SearchResult{{FQID: "motion/2", Fields: map[string]string{"title": "Climate"}}}
+	if !reflect.DeepEqual(results, expect) {
+		t.Errorf("got %v, expected %v", results, expect)
+	}
+
+	s, _, _ = autoupdate.New(environment.ForTests{}, dsmock.NewFlow(searchData), restrictHidden("meeting/1/id"))
+	_, err = s.Search(ctx, 1, 1, "budget", 10)
+
+	var errClient interface{ Type() string }
+	if !errors.As(err, &errClient) || errClient.Type() != "permission_denied" {
+		t.Errorf("search in hidden meeting returned %v, expected permission denied", err)
+	}
+}
+
+func TestSearchUpdate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	datastore := dsmock.NewFlow(searchData)
+	s, bg, _ := autoupdate.New(environment.ForTests{"AUTOUPDATE_PUBLISH_WINDOW": "0"}, datastore, RestrictAllowed)
+	go bg(ctx, func(error) {})
+
+	if _, err := s.Search(ctx, 1, 1, "budget", 10); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	datastore.Send(dsmock.YAMLData(`---
+	motion/2/text: Nothing here
+	motion/4/title: Budget 2025
+	meeting/1/motion_ids: [1, 2, 4]
+	`))
+
+	for {
+		results, err := s.Search(ctx, 1, 1, "budget", 10)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+
+		got := searchFQIDs(results)
+		if reflect.DeepEqual(got, []string{"motion/1", "motion/4", "topic/3"}) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("search after update returned %v", got)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestSearchInvalid(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := autoupdate.New(environment.ForTests{}, dsmock.NewFlow(searchData), RestrictAllowed)
+
+	for _, tt := range []struct {
+		name      string
+		meetingID int
+		query     string
+		limit     int
+	}{
+		{"no meeting", 0, "budget", 10},
+		{"empty query", 1, " ,. ", 10},
+		{"limit too high", 1, "budget", 1000},
+		{"no limit", 1, "budget", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Search(ctx, 1, tt.meetingID, tt.query, tt.limit)
+
+			var errClient interface{ Type() string }
+			if !errors.As(err, &errClient) || errClient.Type() != "invalid_input" {
+				t.Errorf("got %v, expected invalid input", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Package buildinfo tells, which build of the service is running.
+//
+// Version, Commit and Date are set at build time with
+//
+//	go build -ldflags "-X github.com/OpenSlides/openslides-autoupdate-service/internal/buildinfo.Version=4.2.0"
+//
+// If they are not set, the commit and the date are taken from the version
+// control information, that go embeds into the binary.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/feature"
+)
+
+// Values set at build time.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string            `json:"version"`
+	Commit    string            `json:"commit"`
+	Date      string            `json:"build_date"`
+	GoVersion string            `json:"go_version"`
+	Modified  bool              `json:"modified,omitempty"`
+	Features  map[string]string `json:"features"`
+}
+
+// Get returns the information about the running build and the state of the
+// feature flags.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Features:  make(map[string]string),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	for name, flag := range feature.Flags() {
+		info.Features[name] = flag.State
+	}
+
+	return info
+}
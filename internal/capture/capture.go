@@ -0,0 +1,214 @@
+// Package capture records the autoupdate requests and the datastore updates
+// for a time window and replays them against another instance.
+//
+// A capture is a file with one json record per line. It contains the request
+// bodies and the changed values, so it has to be handled like a database dump.
+package capture
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envDir         = environment.NewVariable("AUTOUPDATE_CAPTURE_DIR", "", "Directory for traffic captures. The route /debug/capture writes a file into it. Empty disables the captures.")
+	envMaxDuration = environment.NewVariable("AUTOUPDATE_CAPTURE_MAX_DURATION", "1h", "Longest time window of a traffic capture.")
+)
+
+var logger = logging.For(logging.Autoupdate)
+
+// Kinds of a record.
+const (
+	KindRequest = "request"
+	KindUpdate  = "update"
+)
+
+// Record is one line of a capture file.
+type Record struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+
+	// Fields of a request.
+	UserID int    `json:"user_id,omitempty"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+
+	// Data of an update. A deleted key has the value null.
+	Data map[string]json.RawMessage `json:"data,omitempty"`
+}
+
+// ErrDisabled is returned by Start, if no capture directory is set.
+var ErrDisabled = errors.New("captures are disabled")
+
+// ErrRunning is returned by Start, if a capture is already running.
+var ErrRunning = errors.New("a capture is already running")
+
+var state struct {
+	active atomic.Bool
+
+	mu          sync.Mutex
+	dir         string
+	maxDuration time.Duration
+	file        *os.File
+	encoder     *json.Encoder
+	timer       *time.Timer
+	status      Status
+}
+
+// Status describes the current or the last capture.
+type Status struct {
+	Running bool      `json:"running"`
+	Path    string    `json:"path,omitempty"`
+	Until   time.Time `json:"until,omitempty"`
+	Records int       `json:"records"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// New reads the settings.
+func New(lookup environment.Environmenter) error {
+	maxDuration, err := environment.ParseDuration(envMaxDuration.Value(lookup))
+	if err != nil {
+		return fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envMaxDuration.Key, envMaxDuration.Value(lookup), err)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.dir = envDir.Value(lookup)
+	state.maxDuration = maxDuration
+	return nil
+}
+
+// Start starts a capture for the duration. It returns the path of the
+// capture file.
+func Start(duration time.Duration) (string, error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.dir == "" {
+		return "", ErrDisabled
+	}
+
+	if state.file != nil {
+		return "", ErrRunning
+	}
+
+	if duration <= 0 || duration > state.maxDuration {
+		return "", fmt.Errorf("duration has to be between 0 and %s, got %s", state.maxDuration, duration)
+	}
+
+	now := time.Now()
+	path := filepath.Join(state.dir, fmt.Sprintf("capture-%s.ndjson", now.UTC().Format("20060102T150405")))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("creating capture file: %w", err)
+	}
+
+	state.file = file
+	state.encoder = json.NewEncoder(file)
+	state.status = Status{Running: true, Path: path, Until: now.Add(duration)}
+	state.timer = time.AfterFunc(duration, func() { Stop() })
+	state.active.Store(true)
+
+	logger.Info("Capture started", "path", path, "duration", duration)
+	return path, nil
+}
+
+// Stop ends the running capture. It does nothing, if no capture is running.
+func Stop() Status {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	stopLocked(nil)
+	return state.status
+}
+
+// stopLocked closes the capture file. state.mu has to be locked.
+func stopLocked(cause error) {
+	if state.file == nil {
+		return
+	}
+
+	state.active.Store(false)
+	state.timer.Stop()
+
+	err := state.file.Close()
+	if cause != nil {
+		err = cause
+	}
+	if err != nil {
+		state.status.Error = err.Error()
+		logger.Warn("Capture failed", "path", state.status.Path, "error", err)
+	}
+
+	state.file = nil
+	state.encoder = nil
+	state.status.Running = false
+	logger.Info("Capture finished", "path", state.status.Path, "records", state.status.Records)
+}
+
+// CurrentStatus returns the status of the current or the last capture.
+func CurrentStatus() Status {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.status
+}
+
+// Request records an autoupdate request, if a capture is running.
+func Request(userID int, query string, body []byte) {
+	if !state.active.Load() {
+		return
+	}
+
+	write(Record{
+		Time:   time.Now(),
+		Kind:   KindRequest,
+		UserID: userID,
+		Query:  query,
+		Body:   string(body),
+	})
+}
+
+// Update records an update from the datastore, if a capture is running.
+func Update(data map[dskey.Key][]byte) {
+	if !state.active.Load() || len(data) == 0 {
+		return
+	}
+
+	values := make(map[string]json.RawMessage, len(data))
+	for key, value := range data {
+		values[key.String()] = value
+	}
+
+	write(Record{
+		Time: time.Now(),
+		Kind: KindUpdate,
+		Data: values,
+	})
+}
+
+func write(record Record) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.encoder == nil {
+		return
+	}
+
+	if err := state.encoder.Encode(record); err != nil {
+		stopLocked(fmt.Errorf("writing record: %w", err))
+		return
+	}
+	state.status.Records++
+}
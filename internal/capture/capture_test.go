@@ -0,0 +1,166 @@
+package capture_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/capture"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestCapture(t *testing.T) {
+	dir := t.TempDir()
+	if err := capture.New(environment.ForTests(map[string]string{"AUTOUPDATE_CAPTURE_DIR": dir})); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	capture.Request(1, "k=user/1/username", nil)
+
+	path, err := capture.Start(time.Minute)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if _, err := capture.Start(time.Minute); !errors.Is(err, capture.ErrRunning) {
+		t.Errorf("second Start returned %v, expected ErrRunning", err)
+	}
+
+	capture.Request(5, "k=user/1/username", []byte(`[{"collection":"user","ids":[1],"fields":{"username":null}}]`))
+	capture.Update(map[dskey.Key][]byte{
+		dskey.MustKey("user/1/username"): []byte(`"hugo"`),
+		dskey.MustKey("user/2/username"): nil,
+	})
+
+	status := capture.Stop()
+	if status.Running || status.Records != 2 || status.Path != path {
+		t.Errorf("got status %+v, expected two records in %s", status, path)
+	}
+
+	capture.Request(1, "k=user/1/username", nil)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open capture: %v", err)
+	}
+	defer f.Close()
+
+	records, err := capture.Read(f)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("got %d records, expected 2", len(records))
+	}
+
+	if r := records[0]; r.Kind != capture.KindRequest || r.UserID != 5 || r.Query != "k=user/1/username" {
+		t.Errorf("got request %+v", r)
+	}
+
+	data := records[1].Data
+	if records[1].Kind != capture.KindUpdate || string(data["user/1/username"]) != `"hugo"` || string(data["user/2/username"]) != "null" {
+		t.Errorf("got update %+v", records[1])
+	}
+}
+
+func TestCaptureDisabled(t *testing.T) {
+	if err := capture.New(environment.ForTests(nil)); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := capture.Start(time.Minute); !errors.Is(err, capture.ErrDisabled) {
+		t.Errorf("Start returned %v, expected ErrDisabled", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	content := strings.Join([]string{
+		`{"time":"2024-01-01T10:00:02Z","kind":"update","data":{"user/1/username":"\"hugo\""}}`,
+		``,
+		`{"time":"2024-01-01T10:00:01Z","kind":"request","body":"[]"}`,
+	}, "\n")
+
+	records, err := capture.Read(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if len(records) != 2 || records[0].Kind != capture.KindRequest {
+		t.Errorf("got %+v, expected the request first", records)
+	}
+
+	if _, err := capture.Read(strings.NewReader(`{"kind":"other"}`)); err == nil {
+		t.Errorf("Read with an unknown kind did not return an error")
+	}
+}
+
+type publisherStub struct {
+	mu   sync.Mutex
+	data []map[dskey.Key][]byte
+}
+
+func (p *publisherStub) Publish(ctx context.Context, data map[dskey.Key][]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.data = append(p.data, data)
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s|%s", r.URL.RawQuery, body))
+		mu.Unlock()
+
+		fmt.Fprintln(w, `{}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	records := []capture.Record{
+		{Time: start, Kind: capture.KindRequest, Query: "k=user/1/username", UserID: 5},
+		{Time: start.Add(time.Second), Kind: capture.KindUpdate, Data: map[string]json.RawMessage{"user/1/username": json.RawMessage(`"hugo"`)}},
+		{Time: start.Add(2 * time.Second), Kind: capture.KindRequest, Body: `[]`},
+	}
+
+	publisher := new(publisherStub)
+	report, err := capture.Replay(context.Background(), records, capture.ReplayConfig{
+		URL:       ts.URL,
+		Speed:     20,
+		Linger:    50 * time.Millisecond,
+		Publisher: publisher,
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	if report.Connections != 2 || report.Failed != 0 {
+		t.Errorf("got report %+v, expected two successful connections", report)
+	}
+
+	if len(publisher.data) != 1 || string(publisher.data[0][dskey.MustKey("user/1/username")]) != `"hugo"` {
+		t.Errorf("got published data %v", publisher.data)
+	}
+
+	expect := "k=user/1/username|, |[]"
+	if got := strings.Join(requests, ", "); got != expect {
+		t.Errorf("got requests %q, expected %q", got, expect)
+	}
+}
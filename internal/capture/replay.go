@@ -0,0 +1,166 @@
+package capture
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/loadtest"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// Read parses a capture file. The records are sorted by time.
+func Read(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch record.Kind {
+		case KindRequest, KindUpdate:
+		default:
+			return nil, fmt.Errorf("line %d: unknown kind %q", line, record.Kind)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading capture: %w", err)
+	}
+
+	slices.SortStableFunc(records, func(a, b Record) int {
+		return a.Time.Compare(b.Time)
+	})
+	return records, nil
+}
+
+// Publisher writes updates to the message bus of the test instance.
+type Publisher interface {
+	Publish(ctx context.Context, data map[dskey.Key][]byte) error
+}
+
+// ReplayConfig configures a replay.
+type ReplayConfig struct {
+	// URL is the autoupdate route of the test instance.
+	URL string
+
+	// Speed is the factor for the recorded times. 2 replays twice as fast.
+	Speed float64
+
+	// Linger is the time, the connections are kept open after the last
+	// record.
+	Linger time.Duration
+
+	// Header is sent with each request, for example for authentication. The
+	// recorded users can not be used.
+	Header http.Header
+
+	// Publisher writes the updates. If nil, only the requests are replayed.
+	Publisher Publisher
+
+	// Client sends the requests. If nil, a client without timeout is used.
+	Client *http.Client
+}
+
+// Replay sends the recorded requests and updates with the recorded distances,
+// divided by the speed. The report measures the requests like a load test.
+func Replay(ctx context.Context, records []Record, cfg ReplayConfig) (loadtest.Report, error) {
+	if cfg.Speed <= 0 {
+		return loadtest.Report{}, fmt.Errorf("speed has to be positive, got %v", cfg.Speed)
+	}
+
+	if len(records) == 0 {
+		return loadtest.Report{}, fmt.Errorf("capture is empty")
+	}
+
+	start := records[0].Time
+	offset := func(r Record) time.Duration {
+		return time.Duration(float64(r.Time.Sub(start)) / cfg.Speed)
+	}
+
+	ltCfg := loadtest.Config{
+		URL:      cfg.URL,
+		Duration: cfg.Linger,
+		Header:   cfg.Header,
+		Client:   cfg.Client,
+	}
+
+	var updates []Record
+	for _, record := range records {
+		switch record.Kind {
+		case KindRequest:
+			ltCfg.Schedule = append(ltCfg.Schedule, offset(record))
+			ltCfg.Bodies = append(ltCfg.Bodies, record.Body)
+			ltCfg.Queries = append(ltCfg.Queries, record.Query)
+		case KindUpdate:
+			updates = append(updates, record)
+		}
+	}
+	ltCfg.Connections = len(ltCfg.Schedule)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	publishErr := make(chan error, 1)
+	go func() {
+		publishErr <- publish(ctx, cfg.Publisher, updates, offset)
+	}()
+
+	var report loadtest.Report
+	if ltCfg.Connections > 0 {
+		var err error
+		report, err = loadtest.Run(ctx, ltCfg)
+		if err != nil {
+			return loadtest.Report{}, fmt.Errorf("replay requests: %w", err)
+		}
+	}
+
+	if err := <-publishErr; err != nil {
+		return report, fmt.Errorf("replay updates: %w", err)
+	}
+	return report, nil
+}
+
+// publish writes the updates at their offsets.
+func publish(ctx context.Context, publisher Publisher, updates []Record, offset func(Record) time.Duration) error {
+	if publisher == nil {
+		return nil
+	}
+
+	begin := time.Now()
+	for _, record := range updates {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(begin.Add(offset(record)))):
+		}
+
+		data := make(map[dskey.Key][]byte, len(record.Data))
+		for rawKey, value := range record.Data {
+			key, err := dskey.FromString(rawKey)
+			if err != nil {
+				return fmt.Errorf("update at %s: invalid key %s: %w", record.Time, rawKey, err)
+			}
+			data[key] = value
+		}
+
+		if err := publisher.Publish(ctx, data); err != nil {
+			return fmt.Errorf("update at %s: %w", record.Time, err)
+		}
+	}
+	return nil
+}
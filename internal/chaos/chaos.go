@@ -0,0 +1,225 @@
+// Package chaos injects faults into the calls to the datastore and the message
+// bus.
+//
+// It is meant to test the resilience of the service in a staging environment.
+// The fault injection has to be enabled with AUTOUPDATE_CHAOS at startup. The
+// latency and the rates can then be changed with a reload.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/auth"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envEnabled        = environment.NewVariable("AUTOUPDATE_CHAOS", "false", "Injects faults into the calls to the datastore and the message bus. Only for resilience tests. Never enable it in production.")
+	envLatency        = environment.NewVariable("AUTOUPDATE_CHAOS_LATENCY", "0", "Latency, that is added to each call to the datastore and the message bus, when the fault injection is enabled.")
+	envErrorRate      = environment.NewVariable("AUTOUPDATE_CHAOS_ERROR_RATE", "0", "Part of the calls to the datastore and the message bus, between 0 and 1, that return an error, when the fault injection is enabled.")
+	envDisconnectRate = environment.NewVariable("AUTOUPDATE_CHAOS_DISCONNECT_RATE", "0", "Part of the messages of the message bus, between 0 and 1, that are lost like on a disconnect, when the fault injection is enabled. The service has to resync its cache.")
+)
+
+// ErrInjected is the error of an injected fault.
+var ErrInjected = errors.New("injected fault")
+
+var logger = logging.For(logging.Autoupdate)
+
+var state struct {
+	enabled atomic.Bool
+
+	mu             sync.RWMutex
+	latency        time.Duration
+	errorRate      float64
+	disconnectRate float64
+
+	// random returns a number in [0, 1). It is replaced in tests.
+	random func() float64
+
+	errors      atomic.Uint64
+	disconnects atomic.Uint64
+}
+
+func init() {
+	state.random = rand.Float64
+}
+
+// New configures the fault injection from the environment.
+func New(lookup environment.Environmenter) error {
+	enabled, err := strconv.ParseBool(envEnabled.Value(lookup))
+	if err != nil {
+		return fmt.Errorf("invalid value for `%s`, expected boolean got %s: %w", envEnabled.Key, envEnabled.Value(lookup), err)
+	}
+
+	apply, err := Reload(lookup)
+	if err != nil {
+		return err
+	}
+
+	apply()
+	state.enabled.Store(enabled)
+	if enabled {
+		logger.Warn("Fault injection is enabled")
+	}
+	return nil
+}
+
+// Reload reads the latency and the rates from the environment. The returned
+// function sets them.
+//
+// It does not enable or disable the fault injection.
+func Reload(lookup environment.Environmenter) (func(), error) {
+	latency, err := environment.ParseDuration(envLatency.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envLatency.Key, envLatency.Value(lookup), err)
+	}
+
+	errorRate, err := parseRate(envErrorRate, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	disconnectRate, err := parseRate(envDisconnectRate, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	apply := func() {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+
+		state.latency = latency
+		state.errorRate = errorRate
+		state.disconnectRate = disconnectRate
+	}
+	return apply, nil
+}
+
+func parseRate(v environment.Variable, lookup environment.Environmenter) (float64, error) {
+	rate, err := strconv.ParseFloat(v.Value(lookup), 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("invalid value for `%s`, expected number between 0 and 1, got %s", v.Key, v.Value(lookup))
+	}
+	return rate, nil
+}
+
+// Enabled tells, if the fault injection is enabled.
+func Enabled() bool {
+	return state.enabled.Load()
+}
+
+// Metric adds the number of injected faults to the metric.
+func Metric(con metric.Container) {
+	if !Enabled() {
+		return
+	}
+
+	con.Add("chaos_errors", int(state.errors.Load()))
+	con.Add("chaos_disconnects", int(state.disconnects.Load()))
+}
+
+// settings returns the current latency and rates.
+func settings() (time.Duration, float64, float64) {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	return state.latency, state.errorRate, state.disconnectRate
+}
+
+// inject waits for the latency and returns an error with the error rate.
+func inject(ctx context.Context, call string) error {
+	latency, errorRate, _ := settings()
+	if latency > 0 {
+		if err := clock.Sleep(ctx, latency); err != nil {
+			return err
+		}
+	}
+
+	if errorRate > 0 && state.random() < errorRate {
+		state.errors.Add(1)
+		return fmt.Errorf("%s: %w", call, ErrInjected)
+	}
+	return nil
+}
+
+// disconnect tells, if a message of the message bus should be lost.
+func disconnect() bool {
+	_, _, disconnectRate := settings()
+	if disconnectRate > 0 && state.random() < disconnectRate {
+		state.disconnects.Add(1)
+		return true
+	}
+	return false
+}
+
+// Flow adds the faults to a flow. The Get calls are the calls to the
+// datastore and the updates are the messages of the message bus.
+//
+// Returns the flow unchanged, if the fault injection is disabled.
+func Flow(f flow.Flow) flow.Flow {
+	if !Enabled() {
+		return f
+	}
+	return &chaosFlow{flow: f}
+}
+
+type chaosFlow struct {
+	flow flow.Flow
+}
+
+func (f *chaosFlow) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	if err := inject(ctx, "datastore"); err != nil {
+		return nil, err
+	}
+	return f.flow.Get(ctx, keys...)
+}
+
+// Update drops messages with the disconnect rate and reports, that they were
+// lost. With the error rate, the data is given to updateFn together with an
+// error.
+func (f *chaosFlow) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
+	f.flow.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		if err == nil && disconnect() {
+			updateFn(nil, fmt.Errorf("message bus disconnect: %w: %w", ErrInjected, flow.ErrResync))
+			return
+		}
+
+		if injected := inject(ctx, "message bus"); injected != nil && err == nil {
+			err = injected
+		}
+		updateFn(data, err)
+	})
+}
+
+// LogoutEventer adds the faults to the logout events from the message bus.
+//
+// Returns the eventer unchanged, if the fault injection is disabled.
+func LogoutEventer(e auth.LogoutEventer) auth.LogoutEventer {
+	if !Enabled() {
+		return e
+	}
+	return chaosLogoutEventer{e}
+}
+
+type chaosLogoutEventer struct {
+	eventer auth.LogoutEventer
+}
+
+func (e chaosLogoutEventer) LogoutEvent(ctx context.Context) ([]string, error) {
+	if err := inject(ctx, "logout events"); err != nil {
+		return nil, err
+	}
+	return e.eventer.LogoutEvent(ctx)
+}
@@ -0,0 +1,80 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestDisabled(t *testing.T) {
+	if err := New(environment.ForTests{"AUTOUPDATE_CHAOS_ERROR_RATE": "1"}); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ds := dsmock.NewFlow(nil)
+	if got := Flow(ds); got != flow.Flow(ds) {
+		t.Errorf("Flow() wrapped the flow, while the fault injection is disabled")
+	}
+}
+
+func TestFlow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := New(environment.ForTests{
+		"AUTOUPDATE_CHAOS":                 "true",
+		"AUTOUPDATE_CHAOS_ERROR_RATE":      "0.5",
+		"AUTOUPDATE_CHAOS_DISCONNECT_RATE": "0.5",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer state.enabled.Store(false)
+
+	key := dskey.MustKey("user/1/username")
+	ds := dsmock.NewFlow(dsmock.YAMLData("user/1/username: hugo"))
+	chaosFlow := Flow(ds)
+
+	state.random = func() float64 { return 0.7 }
+	if _, err := chaosFlow.Get(ctx, key); err != nil {
+		t.Errorf("Get above the error rate returned: %v", err)
+	}
+
+	state.random = func() float64 { return 0.2 }
+	if _, err := chaosFlow.Get(ctx, key); !errors.Is(err, ErrInjected) {
+		t.Errorf("Get below the error rate returned %v, expected the injected error", err)
+	}
+
+	type update struct {
+		data map[dskey.Key][]byte
+		err  error
+	}
+	updates := make(chan update, 1)
+	go chaosFlow.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		updates <- update{data, err}
+	})
+
+	ds.Send(dsmock.YAMLData("user/1/username: new"))
+	if got := <-updates; !errors.Is(got.err, flow.ErrResync) || got.data != nil {
+		t.Errorf("update below the disconnect rate got %v, %v, expected only a resync error", got.data, got.err)
+	}
+
+	state.random = func() float64 { return 0.7 }
+	ds.Send(dsmock.YAMLData("user/1/username: newer"))
+	if got := <-updates; got.err != nil || string(got.data[key]) != `"newer"` {
+		t.Errorf("update above the rates got %v, %v, expected the data", got.data, got.err)
+	}
+}
+
+func TestReloadInvalidRate(t *testing.T) {
+	for _, rate := range []string{"abc", "-0.1", "2"} {
+		if _, err := Reload(environment.ForTests{"AUTOUPDATE_CHAOS_ERROR_RATE": rate}); err == nil {
+			t.Errorf("rate %s: got no error", rate)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package errorreport sends unexpected errors to Sentry or a compatible
+// service like GlitchTip.
+//
+// Without the environment variable SENTRY_DSN, nothing is reported.
+package errorreport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/getsentry/sentry-go"
+)
+
+var (
+	envDSN         = environment.NewVariable("SENTRY_DSN", "", "DSN of a Sentry or GlitchTip project. Empty disables the error reporting.")
+	envSampleRate  = environment.NewVariable("SENTRY_SAMPLE_RATE", "1", "Fraction of the errors, that are reported. Zero disables the error reporting.")
+	envEnvironment = environment.NewVariable("SENTRY_ENVIRONMENT", "", "Name of the environment in the reports, for example `production`.")
+)
+
+// flushTimeout is the time to send the remaining reports on shutdown.
+const flushTimeout = 5 * time.Second
+
+var enabled atomic.Bool
+
+// New configures the error reporting from the environment.
+//
+// Returns a background task that sends the remaining reports, when the
+// context is done.
+func New(lookup environment.Environmenter) (func(context.Context, func(error)), error) {
+	dsn := envDSN.Value(lookup)
+	sampleRate, err := strconv.ParseFloat(envSampleRate.Value(lookup), 64)
+	if err != nil || sampleRate < 0 || sampleRate > 1 {
+		return nil, fmt.Errorf("invalid value for `%s`, expected a number between 0 and 1, got `%s`", envSampleRate.Key, envSampleRate.Value(lookup))
+	}
+
+	if dsn == "" || sampleRate == 0 {
+		enabled.Store(false)
+		return func(context.Context, func(error)) {}, nil
+	}
+
+	err = sentry.Init(sentry.ClientOptions{
+		Dsn:         dsn,
+		SampleRate:  sampleRate,
+		Environment: envEnvironment.Value(lookup),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("init sentry client: %w", err)
+	}
+	enabled.Store(true)
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		<-ctx.Done()
+		if !sentry.Flush(flushTimeout) {
+			errorHandler(fmt.Errorf("sending the remaining error reports: timeout"))
+		}
+	}
+	return background, nil
+}
+
+// Enabled tells, if errors are reported.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// WithRequest returns a context for the request. Errors, that are captured
+// with this context, contain the method, the url and the headers of the
+// request.
+//
+// The headers Authorization and Cookie are not reported.
+func WithRequest(ctx context.Context, r *http.Request) context.Context {
+	if !Enabled() {
+		return ctx
+	}
+
+	hub := sentry.CurrentHub().Clone()
+	hub.Scope().SetRequest(r)
+	return sentry.SetHubOnContext(ctx, hub)
+}
+
+// Capture reports an error.
+//
+// The tags are added to the report. If the context was created with
+// WithRequest, the request is added to the report. Errors of a closed context
+// are not reported.
+func Capture(ctx context.Context, err error, tags map[string]string) {
+	if !Enabled() || err == nil || oserror.ContextDone(err) {
+		return
+	}
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTags(tags)
+		hub.CaptureException(err)
+	})
+}
@@ -0,0 +1,139 @@
+package errorreport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/errorreport"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// sentryServer collects the bodies of the reports.
+type sentryServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	reports []string
+}
+
+func newSentryServer(t *testing.T) *sentryServer {
+	t.Helper()
+
+	s := new(sentryServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.reports = append(s.reports, string(body))
+		s.mu.Unlock()
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *sentryServer) dsn() string {
+	return strings.Replace(s.URL, "http://", "http://public@", 1) + "/1"
+}
+
+func (s *sentryServer) all() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.reports, "\n")
+}
+
+// start enables the error reporting and returns a function, that sends all
+// reports.
+func start(t *testing.T, lookup environment.ForTests) func() {
+	t.Helper()
+
+	background, err := errorreport.New(lookup)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		background(ctx, func(err error) { t.Errorf("background: %v", err) })
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		errorreport.New(environment.ForTests{})
+	})
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestCapture(t *testing.T) {
+	server := newSentryServer(t)
+	flush := start(t, environment.ForTests{"SENTRY_DSN": server.dsn(), "SENTRY_ENVIRONMENT": "testing"})
+
+	if !errorreport.Enabled() {
+		t.Fatalf("error reporting is not enabled")
+	}
+
+	r := httptest.NewRequest("GET", "/system/autoupdate?k=user/1/username", nil)
+	// The token is not written literally, since the report contains the
+	// source code around the call.
+	token := fmt.Sprintf("token-%d", 6*7)
+	r.Header.Set("Authorization", token)
+	ctx := errorreport.WithRequest(context.Background(), r)
+
+	errorreport.Capture(ctx, errors.New("my error"), map[string]string{"request_id": "my-id"})
+	errorreport.Capture(ctx, fmt.Errorf("wrapped: %w", context.Canceled), nil)
+	flush()
+
+	got := server.all()
+	for _, expect := range []string{"my error", `"request_id":"my-id"`, "/system/autoupdate", `"environment":"testing"`} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("report does not contain `%s`:\n%s", expect, got)
+		}
+	}
+
+	for _, unexpected := range []string{token, "context canceled"} {
+		if strings.Contains(got, unexpected) {
+			t.Errorf("report contains `%s`:\n%s", unexpected, got)
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	server := newSentryServer(t)
+
+	for _, lookup := range []environment.ForTests{
+		{},
+		{"SENTRY_DSN": server.dsn(), "SENTRY_SAMPLE_RATE": "0"},
+	} {
+		flush := start(t, lookup)
+		if errorreport.Enabled() {
+			t.Errorf("%v: error reporting is enabled", lookup)
+		}
+
+		errorreport.Capture(context.Background(), errors.New("my error"), nil)
+		flush()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if got := server.all(); got != "" {
+		t.Errorf("got reports, expected none: %s", got)
+	}
+}
+
+func TestInvalidSampleRate(t *testing.T) {
+	for _, rate := range []string{"abc", "-1", "2"} {
+		if _, err := errorreport.New(environment.ForTests{"SENTRY_SAMPLE_RATE": rate}); err == nil {
+			t.Errorf("sample rate %s: got no error", rate)
+		}
+	}
+}
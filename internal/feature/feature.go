@@ -0,0 +1,281 @@
+// Package feature switches new capabilities of the service on and off at
+// runtime.
+//
+// A package defines a flag with Define and asks it with Enabled or
+// EnabledFor before using the new code path. The flags are configured with
+// the environment, the config file or a redis hash. Operators can roll out a
+// feature to a percentage of the users and roll it back without a redeploy.
+package feature
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envFeatures = environment.NewVariable("AUTOUPDATE_FEATURES", "", "Comma separated list of feature flags, for example `delta,shared_cache=25%`. A flag is `on`, `off` or a percentage of the users. A flag without value is `on`.")
+	envRedisKey = environment.NewVariable("AUTOUPDATE_FEATURES_REDIS_KEY", "", "Name of a redis hash with feature flags. Its values override `AUTOUPDATE_FEATURES`. Empty disables the hash.")
+	envInterval = environment.NewVariable("AUTOUPDATE_FEATURES_INTERVAL", "10s", "Interval, how often the redis hash with the feature flags is read.")
+)
+
+// HashReader reads a redis hash.
+type HashReader interface {
+	HashGetAll(ctx context.Context, key string) (map[string]string, error)
+}
+
+// Flag is a feature, that can be switched on at runtime.
+//
+// Has to be created with Define.
+type Flag struct {
+	name        string
+	description string
+	percent     atomic.Int32
+}
+
+var registry struct {
+	mu     sync.Mutex
+	flags  map[string]*Flag
+	env    map[string]int32
+	remote map[string]int32
+}
+
+// Define creates a flag. It is off, until it is configured.
+//
+// Define has to be called at package level. It panics, if the name is used
+// twice.
+func Define(name, description string) *Flag {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if registry.flags == nil {
+		registry.flags = make(map[string]*Flag)
+	}
+
+	if _, ok := registry.flags[name]; ok {
+		panic(fmt.Sprintf("feature flag %s is defined twice", name))
+	}
+
+	f := &Flag{name: name, description: description}
+	registry.flags[name] = f
+	return f
+}
+
+// Enabled tells, if the feature is on for all users.
+func (f *Flag) Enabled() bool {
+	return f.percent.Load() >= 100
+}
+
+// EnabledFor tells, if the feature is on for a user. A user gets the same
+// answer on every call, as long as the percentage does not change.
+func (f *Flag) EnabledFor(userID int) bool {
+	percent := f.percent.Load()
+	switch {
+	case percent <= 0:
+		return false
+	case percent >= 100:
+		return true
+	}
+
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", f.name, userID)
+	return int32(h.Sum32()%100) < percent
+}
+
+// New configures the flags from the environment.
+//
+// The returned function reads the redis hash. It has to be run in the
+// background.
+func New(lookup environment.Environmenter, store HashReader) (func(context.Context, func(error)), error) {
+	apply, err := Reload(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	key := envRedisKey.Value(lookup)
+	interval, err := environment.ParseDuration(envInterval.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envInterval.Key, envInterval.Value(lookup), err)
+	}
+
+	registry.mu.Lock()
+	registry.remote = nil
+	registry.mu.Unlock()
+	apply()
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		if key == "" || store == nil || interval <= 0 {
+			return
+		}
+
+		for {
+			if err := readRemote(ctx, store, key); err != nil {
+				errorHandler(fmt.Errorf("reading feature flags from redis hash %s: %w", key, err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}
+
+	return background, nil
+}
+
+// Reload reads the flags from the environment. The returned function sets
+// them.
+func Reload(lookup environment.Environmenter) (func(), error) {
+	values, err := parseList(envFeatures.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envFeatures.Key, err)
+	}
+
+	if err := checkNames(values); err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envFeatures.Key, err)
+	}
+
+	apply := func() {
+		registry.mu.Lock()
+		defer registry.mu.Unlock()
+
+		registry.env = values
+		update()
+	}
+	return apply, nil
+}
+
+// Info describes the state of a flag.
+type Info struct {
+	State       string `json:"state"`
+	Description string `json:"description"`
+}
+
+// Flags returns the state of all defined flags.
+func Flags() map[string]Info {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	flags := make(map[string]Info, len(registry.flags))
+	for name, f := range registry.flags {
+		flags[name] = Info{
+			State:       formatPercent(f.percent.Load()),
+			Description: f.description,
+		}
+	}
+	return flags
+}
+
+// readRemote reads the flags from the redis hash. Unknown flags are ignored,
+// so the hash can be shared with other versions of the service.
+func readRemote(ctx context.Context, store HashReader, key string) error {
+	hash, err := store.HashGetAll(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]int32, len(hash))
+	for name, value := range hash {
+		percent, err := parseValue(value)
+		if err != nil {
+			return fmt.Errorf("flag %s: %w", name, err)
+		}
+		values[name] = percent
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.remote = values
+	update()
+	return nil
+}
+
+// update sets the percentage of each flag. registry.mu has to be locked.
+func update() {
+	for name, f := range registry.flags {
+		percent := registry.env[name]
+		if remote, ok := registry.remote[name]; ok {
+			percent = remote
+		}
+		f.percent.Store(percent)
+	}
+}
+
+func checkNames(values map[string]int32) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if _, ok := registry.flags[name]; !ok {
+			return fmt.Errorf("unknown feature flag `%s`", name)
+		}
+	}
+	return nil
+}
+
+// parseList parses a list like `delta,shared_cache=25%,other=off`.
+func parseList(list string) (map[string]int32, error) {
+	values := make(map[string]int32)
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(part, "=")
+		if !found {
+			value = "on"
+		}
+
+		percent, err := parseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("flag %s: %w", name, err)
+		}
+		values[strings.TrimSpace(name)] = percent
+	}
+	return values, nil
+}
+
+// parseValue parses `on`, `off`, `true`, `false` or a percentage like `25%`.
+func parseValue(value string) (int32, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "on", "true":
+		return 100, nil
+	case "off", "false":
+		return 0, nil
+	}
+
+	number, found := strings.CutSuffix(value, "%")
+	if !found {
+		return 0, fmt.Errorf("expected on, off or a percentage, got %s", value)
+	}
+
+	percent, err := strconv.Atoi(number)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("expected a percentage between 0%% and 100%%, got %s", value)
+	}
+	return int32(percent), nil
+}
+
+func formatPercent(percent int32) string {
+	switch percent {
+	case 0:
+		return "off"
+	case 100:
+		return "on"
+	default:
+		return fmt.Sprintf("%d%%", percent)
+	}
+}
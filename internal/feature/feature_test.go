@@ -0,0 +1,95 @@
+package feature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	testFlag    = Define("test", "Flag for the tests.")
+	rolloutFlag = Define("rollout", "Flag for the percentage tests.")
+)
+
+type fakeHash map[string]string
+
+func (h fakeHash) HashGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return h, nil
+}
+
+func TestFeature(t *testing.T) {
+	if _, err := New(environment.ForTests{"AUTOUPDATE_FEATURES": "test"}, nil); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if !testFlag.Enabled() {
+		t.Errorf("flag test is not enabled")
+	}
+
+	if rolloutFlag.Enabled() || rolloutFlag.EnabledFor(1) {
+		t.Errorf("flag rollout is enabled without configuration")
+	}
+}
+
+func TestFeatureUnknown(t *testing.T) {
+	if _, err := New(environment.ForTests{"AUTOUPDATE_FEATURES": "unknown"}, nil); err == nil {
+		t.Errorf("New with an unknown flag did not return an error")
+	}
+
+	if _, err := New(environment.ForTests{"AUTOUPDATE_FEATURES": "test=sometimes"}, nil); err == nil {
+		t.Errorf("New with an invalid value did not return an error")
+	}
+}
+
+func TestFeaturePercentage(t *testing.T) {
+	if _, err := New(environment.ForTests{"AUTOUPDATE_FEATURES": "rollout=30%"}, nil); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if rolloutFlag.Enabled() {
+		t.Errorf("flag is enabled for all users with 30%%")
+	}
+
+	var enabled int
+	for userID := 1; userID <= 1000; userID++ {
+		if rolloutFlag.EnabledFor(userID) {
+			enabled++
+		}
+
+		if rolloutFlag.EnabledFor(userID) != rolloutFlag.EnabledFor(userID) {
+			t.Fatalf("user %d got different answers", userID)
+		}
+	}
+
+	if enabled < 200 || enabled > 400 {
+		t.Errorf("flag is enabled for %d of 1000 users, expected about 300", enabled)
+	}
+}
+
+func TestFeatureRemote(t *testing.T) {
+	if _, err := New(environment.ForTests{"AUTOUPDATE_FEATURES": "test"}, nil); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	hash := fakeHash{"test": "off", "rollout": "on", "other_version": "on"}
+	if err := readRemote(context.Background(), hash, "features"); err != nil {
+		t.Fatalf("readRemote: %v", err)
+	}
+
+	if testFlag.Enabled() {
+		t.Errorf("redis hash did not override the environment")
+	}
+
+	if !rolloutFlag.Enabled() {
+		t.Errorf("flag from the redis hash is not enabled")
+	}
+
+	if got := Flags()["rollout"].State; got != "on" {
+		t.Errorf("Flags() returned state %s for rollout, expected on", got)
+	}
+
+	if err := readRemote(context.Background(), fakeHash{"test": "maybe"}, "features"); err == nil {
+		t.Errorf("readRemote with an invalid value did not return an error")
+	}
+}
@@ -0,0 +1,500 @@
+// Package federation merges the data of remote OpenSlides instances into the
+// autoupdate streams.
+//
+// In a committee federation, some data of a client lives on another instance.
+// The entries of an autoupdate request with the attribute `instance` are sent
+// to the autoupdate service of this instance. The token of the user is
+// exchanged for a token of the remote instance with OAuth 2.0 token exchange
+// (RFC 8693). The keys of the remote data get the name of the instance as
+// prefix, for example `committee_b:user/1/username`.
+//
+// The remote instances are configured in a YAML or JSON file.
+package federation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/goccy/go-yaml"
+)
+
+var envFile = environment.NewVariable("AUTOUPDATE_FEDERATION_FILE", "", "Path of a YAML or JSON file with remote OpenSlides instances, whose data can be requested with the attribute `instance`. Empty disables the federation.")
+
+const (
+	// exchangeTimeout is the time, the token endpoint can take for one token
+	// exchange.
+	exchangeTimeout = 10 * time.Second
+
+	// minBackoff is the delay after the first failed connection to a remote
+	// instance. It is doubled after each try until maxBackoff.
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// The parameters of the token exchange from RFC 8693.
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// authHeader is the header with the access token of a user.
+const authHeader = "Authentication"
+
+var logger = logging.For(logging.Autoupdate)
+
+var counter struct {
+	streams    atomic.Int64
+	messages   atomic.Uint64
+	reconnects atomic.Uint64
+}
+
+// Remote is one remote instance.
+type Remote struct {
+	Name string
+
+	// URL is the autoupdate route of the remote instance.
+	URL string
+
+	// TokenURL is the token endpoint of the identity provider, that exchanges
+	// the tokens of the users.
+	TokenURL string
+	ClientID string
+	Audience string
+
+	clientSecret string
+}
+
+// Federation streams the data of remote instances.
+//
+// Has to be created with New.
+type Federation struct {
+	remotes map[string]Remote
+	client  *http.Client
+}
+
+// New reads the remote instances from the file. If the file is not set, the
+// federation has no remote instances.
+func New(lookup environment.Environmenter) (*Federation, error) {
+	f := Federation{
+		client: &http.Client{},
+	}
+
+	path := envFile.Value(lookup)
+	if path == "" {
+		return &f, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading federation file: %w", err)
+	}
+
+	remotes, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing federation file %s: %w", path, err)
+	}
+
+	f.remotes = remotes
+	return &f, nil
+}
+
+// parse decodes the federation file and reads the client secrets.
+func parse(content []byte) (map[string]Remote, error) {
+	var raw map[string]struct {
+		URL              string `yaml:"url"`
+		TokenURL         string `yaml:"token_url"`
+		ClientID         string `yaml:"client_id"`
+		ClientSecretFile string `yaml:"client_secret_file"`
+		Audience         string `yaml:"audience"`
+	}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("decoding yaml: %w", err)
+	}
+
+	remotes := make(map[string]Remote, len(raw))
+	for name, r := range raw {
+		if name == "" || strings.ContainsAny(name, ":/") {
+			return nil, fmt.Errorf("invalid instance name `%s`", name)
+		}
+
+		if !isHTTPURL(r.URL) {
+			return nil, fmt.Errorf("instance %s: expected an http or https url, got `%s`", name, r.URL)
+		}
+
+		if !isHTTPURL(r.TokenURL) {
+			return nil, fmt.Errorf("instance %s: expected an http or https token_url, got `%s`", name, r.TokenURL)
+		}
+
+		if r.ClientID == "" {
+			return nil, fmt.Errorf("instance %s: client_id is missing", name)
+		}
+
+		secret, err := os.ReadFile(r.ClientSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s: reading client secret: %w", name, err)
+		}
+
+		remotes[name] = Remote{
+			Name:         name,
+			URL:          r.URL,
+			TokenURL:     r.TokenURL,
+			ClientID:     r.ClientID,
+			Audience:     r.Audience,
+			clientSecret: strings.TrimSpace(string(secret)),
+		}
+	}
+	return remotes, nil
+}
+
+func isHTTPURL(u string) bool {
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
+
+// Split separates the entries of an autoupdate request with the attribute
+// `instance` from the local entries. The attribute is removed from the remote
+// entries. Returns the local body and the body for each instance.
+//
+// If the body is not a list of objects, it is returned unchanged, so the
+// keysbuilder can report the error. It must not panic on any input.
+func Split(body []byte) ([]byte, map[string][]byte, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(body, &entries); err != nil || len(entries) == 0 {
+		return body, nil, nil
+	}
+
+	var local []json.RawMessage
+	remoteEntries := make(map[string][]json.RawMessage)
+	for _, entry := range entries {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(entry, &fields); err != nil {
+			local = append(local, entry)
+			continue
+		}
+
+		rawInstance, ok := fields["instance"]
+		if !ok {
+			local = append(local, entry)
+			continue
+		}
+
+		var instance string
+		if err := json.Unmarshal(rawInstance, &instance); err != nil || instance == "" {
+			return nil, nil, invalidRequestError{"attribute instance has to be the name of an instance"}
+		}
+
+		delete(fields, "instance")
+		encoded, err := json.Marshal(fields)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding entry: %w", err)
+		}
+		remoteEntries[instance] = append(remoteEntries[instance], encoded)
+	}
+
+	if len(remoteEntries) == 0 {
+		return body, nil, nil
+	}
+
+	remote := make(map[string][]byte, len(remoteEntries))
+	for instance, entries := range remoteEntries {
+		encoded, err := json.Marshal(entries)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding request of %s: %w", instance, err)
+		}
+		remote[instance] = encoded
+	}
+
+	if len(local) == 0 {
+		return nil, remote, nil
+	}
+
+	encoded, err := json.Marshal(local)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding local request: %w", err)
+	}
+	return encoded, remote, nil
+}
+
+// Known tells, if the instance is configured.
+func (f *Federation) Known(instance string) bool {
+	_, ok := f.remotes[instance]
+	return ok
+}
+
+// Stream sends the body to the remote instance and calls send with each
+// message. The keys of the messages have the name of the instance as prefix.
+//
+// header is the Authentication header of the client. Without a token, the
+// remote data is requested as anonymous.
+//
+// When the connection to the remote instance fails, it is opened again. Stream
+// only returns, when the context is done, when send returns an error or when
+// the remote instance rejects the request.
+func (f *Federation) Stream(ctx context.Context, instance string, header string, body []byte, send func([]byte) error) error {
+	remote, ok := f.remotes[instance]
+	if !ok {
+		return Error{Instance: instance, msg: "unknown instance"}
+	}
+
+	counter.streams.Add(1)
+	defer counter.streams.Add(-1)
+
+	subjectToken := tokenFromHeader(header)
+
+	backoff := minBackoff
+	for {
+		err := f.stream(ctx, remote, subjectToken, body, func(message []byte) error {
+			backoff = minBackoff
+			return send(message)
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var errSend sendError
+		if errors.As(err, &errSend) {
+			return errSend.err
+		}
+
+		var errRemote Error
+		if errors.As(err, &errRemote) {
+			return errRemote
+		}
+
+		counter.reconnects.Add(1)
+		logger.Warn("Remote instance failed", "instance", instance, "error", err, "retry", backoff)
+
+		if err := clock.Sleep(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// stream opens one connection to the remote instance.
+func (f *Federation) stream(ctx context.Context, remote Remote, subjectToken string, body []byte, send func([]byte) error) error {
+	token, err := f.exchange(ctx, remote, subjectToken)
+	if err != nil {
+		return fmt.Errorf("token exchange: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, remote.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	if token != "" {
+		req.Header.Set(authHeader, "bearer "+token)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		content, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		msg := fmt.Sprintf("remote instance returned %s: %s", resp.Status, remoteMessage(content))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return Error{Instance: remote.Name, msg: msg}
+		}
+		return errors.New(msg)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			message, err := prefixKeys(remote.Name, line)
+			if err != nil {
+				return err
+			}
+
+			if message != nil {
+				counter.messages.Add(1)
+				if err := send(message); err != nil {
+					return sendError{err}
+				}
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return errors.New("remote instance closed the stream")
+			}
+			return fmt.Errorf("reading stream: %w", err)
+		}
+	}
+}
+
+// prefixKeys decodes one message of the remote instance and adds the prefix to
+// the keys. Other values like the maintenance notice are skipped. Returns nil,
+// if no keys are left.
+func prefixKeys(instance string, line []byte) ([]byte, error) {
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(line, &data); err != nil {
+		return nil, fmt.Errorf("decoding message: %w", err)
+	}
+
+	if raw, ok := data["error"]; ok && len(raw) > 0 && raw[0] == '{' {
+		return nil, fmt.Errorf("remote instance sent an error: %s", remoteMessage(line))
+	}
+
+	prefixed := make(map[string]json.RawMessage, len(data))
+	for key, value := range data {
+		if !strings.Contains(key, "/") {
+			continue
+		}
+		prefixed[instance+":"+key] = value
+	}
+
+	if len(prefixed) == 0 {
+		return nil, nil
+	}
+
+	message, err := json.Marshal(prefixed)
+	if err != nil {
+		return nil, fmt.Errorf("encoding message: %w", err)
+	}
+	return message, nil
+}
+
+// tokenFromHeader returns the token from an Authentication header or an empty
+// string.
+func tokenFromHeader(header string) string {
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return header[len(prefix):]
+}
+
+// remoteMessage returns the message of an error response of an autoupdate
+// service or the content itself.
+func remoteMessage(content []byte) string {
+	var problem struct {
+		Error struct {
+			Msg string `json:"msg"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(content, &problem); err == nil && problem.Error.Msg != "" {
+		return problem.Error.Msg
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// exchange returns a token of the remote instance for the token of the user.
+// Returns an empty token, if the user has no token.
+func (f *Federation) exchange(ctx context.Context, remote Remote, subjectToken string) (string, error) {
+	if subjectToken == "" {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, exchangeTimeout)
+	defer cancel()
+
+	form := url.Values{
+		"grant_type":           {grantTypeTokenExchange},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {tokenTypeAccessToken},
+		"requested_token_type": {tokenTypeAccessToken},
+	}
+	if remote.Audience != "" {
+		form.Set("audience", remote.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, remote.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(remote.ClientID), url.QueryEscape(remote.clientSecret))
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var content struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&content); err != nil {
+		return "", fmt.Errorf("decoding response with status %s: %w", resp.Status, err)
+	}
+
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return "", Error{Instance: remote.Name, msg: fmt.Sprintf("token exchange rejected: %s %s", content.Error, content.ErrorDescription)}
+	}
+
+	if resp.StatusCode != http.StatusOK || content.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned %s: %s", resp.Status, content.Error)
+	}
+
+	return content.AccessToken, nil
+}
+
+// Metric adds the open remote streams, the received messages and the
+// reconnects to the metric.
+func Metric(con metric.Container) {
+	con.Add("federation_streams", int(counter.streams.Load()))
+	con.Add("federation_messages", int(counter.messages.Load()))
+	con.Add("federation_reconnects", int(counter.reconnects.Load()))
+}
+
+// sendError is an error from the send function.
+type sendError struct {
+	err error
+}
+
+func (e sendError) Error() string {
+	return e.err.Error()
+}
+
+func (e sendError) Unwrap() error {
+	return e.err
+}
+
+// Error is returned, when a remote instance rejects a request.
+type Error struct {
+	Instance string
+	msg      string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("instance %s: %s", e.Instance, e.msg)
+}
+
+// Type is the type of the error for the client.
+func (e Error) Type() string {
+	return "federation"
+}
+
+type invalidRequestError struct {
+	msg string
+}
+
+func (e invalidRequestError) Error() string {
+	return e.msg
+}
+
+func (e invalidRequestError) Type() string {
+	return "invalid_request"
+}
@@ -0,0 +1,276 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	local, remote, err := Split([]byte(`[
+		{"collection":"user","ids":[1],"fields":{"username":null}},
+		{"instance":"committee_b","collection":"meeting","ids":[5],"fields":{"name":null}}
+	]`))
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+
+	if got := string(local); got != `[{"collection":"user","ids":[1],"fields":{"username":null}}]` {
+		t.Errorf("got local body %s", got)
+	}
+
+	if len(remote) != 1 {
+		t.Fatalf("got %d remote bodies, expected 1", len(remote))
+	}
+
+	if got := string(remote["committee_b"]); got != `[{"collection":"meeting","fields":{"name":null},"ids":[5]}]` {
+		t.Errorf("got remote body %s", got)
+	}
+}
+
+func TestSplitOnlyRemote(t *testing.T) {
+	local, remote, err := Split([]byte(`[{"instance":"b","collection":"meeting","ids":[5],"fields":{"name":null}}]`))
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+
+	if local != nil {
+		t.Errorf("got local body %s, expected nil", local)
+	}
+
+	if len(remote) != 1 {
+		t.Errorf("got %d remote bodies, expected 1", len(remote))
+	}
+}
+
+func TestSplitUnchanged(t *testing.T) {
+	for _, body := range []string{
+		``,
+		`[]`,
+		`{"collection":"user"}`,
+		`[{"collection":"user","ids":[1],"fields":{"username":null}}]`,
+		`[1, "foo"]`,
+	} {
+		local, remote, err := Split([]byte(body))
+		if err != nil {
+			t.Errorf("Split(%s): %v", body, err)
+		}
+
+		if string(local) != body || remote != nil {
+			t.Errorf("Split(%s) returned %s and %v, expected the body", body, local, remote)
+		}
+	}
+}
+
+func TestSplitInvalidInstance(t *testing.T) {
+	for _, body := range []string{
+		`[{"instance":"","collection":"user"}]`,
+		`[{"instance":5,"collection":"user"}]`,
+	} {
+		if _, _, err := Split([]byte(body)); err == nil {
+			t.Errorf("Split(%s) returned no error", body)
+		}
+	}
+}
+
+func TestPrefixKeys(t *testing.T) {
+	got, err := prefixKeys("b", []byte(`{"meeting/5/name":"Board","user/1/username":null}`+"\n"))
+	if err != nil {
+		t.Fatalf("prefixKeys: %v", err)
+	}
+
+	if expect := `{"b:meeting/5/name":"Board","b:user/1/username":null}`; string(got) != expect {
+		t.Errorf("got %s, expected %s", got, expect)
+	}
+
+	got, err = prefixKeys("b", []byte(`{"maintenance":{"active":true}}`))
+	if err != nil {
+		t.Fatalf("prefixKeys: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("got %s for a maintenance notice, expected nil", got)
+	}
+
+	if _, err := prefixKeys("b", []byte(`{"type":"x","error":{"type":"invalid","msg":"broken"}}`)); err == nil {
+		t.Errorf("prefixKeys returned no error for an error message")
+	}
+}
+
+func TestParse(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("my-secret\n"), 0o600); err != nil {
+		t.Fatalf("writing secret: %v", err)
+	}
+
+	remotes, err := parse([]byte(fmt.Sprintf(`---
+committee_b:
+  url: https://b.example.com/system/autoupdate
+  token_url: https://keycloak.example.com/token
+  client_id: autoupdate
+  client_secret_file: %s
+  audience: openslides-b
+`, secretFile)))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	remote, ok := remotes["committee_b"]
+	if !ok {
+		t.Fatalf("instance committee_b is missing")
+	}
+
+	if remote.clientSecret != "my-secret" || remote.Audience != "openslides-b" {
+		t.Errorf("got %v", remote)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{"invalid name", fmt.Sprintf(`{"a:b": {url: "https://b.example.com", token_url: "https://k.example.com", client_id: a, client_secret_file: %s}}`, secretFile)},
+		{"invalid url", fmt.Sprintf(`{b: {url: "ftp://b.example.com", token_url: "https://k.example.com", client_id: a, client_secret_file: %s}}`, secretFile)},
+		{"no token url", fmt.Sprintf(`{b: {url: "https://b.example.com", client_id: a, client_secret_file: %s}}`, secretFile)},
+		{"no client id", fmt.Sprintf(`{b: {url: "https://b.example.com", token_url: "https://k.example.com", client_secret_file: %s}}`, secretFile)},
+		{"no secret", `{b: {url: "https://b.example.com", token_url: "https://k.example.com", client_id: a, client_secret_file: /does/not/exist}}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parse([]byte(tt.content)); err == nil {
+				t.Errorf("parse returned no error")
+			}
+		})
+	}
+}
+
+// newTestFederation returns a federation with one instance `b`. The token
+// endpoint exchanges the token `user-token` for `remote-token`.
+func newTestFederation(t *testing.T, autoupdate http.HandlerFunc) *Federation {
+	t.Helper()
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		if clientID != "autoupdate" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, `{"error":"invalid_client"}`)
+			return
+		}
+
+		if r.FormValue("grant_type") != grantTypeTokenExchange || r.FormValue("subject_token") != "user-token" || r.FormValue("audience") != "openslides-b" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"error":"invalid_grant"}`)
+			return
+		}
+
+		fmt.Fprintln(w, `{"access_token":"remote-token","token_type":"Bearer"}`)
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	remoteServer := httptest.NewServer(autoupdate)
+	t.Cleanup(remoteServer.Close)
+
+	return &Federation{
+		remotes: map[string]Remote{
+			"b": {
+				Name:         "b",
+				URL:          remoteServer.URL + "/system/autoupdate",
+				TokenURL:     tokenServer.URL,
+				ClientID:     "autoupdate",
+				Audience:     "openslides-b",
+				clientSecret: "secret",
+			},
+		},
+		client: &http.Client{},
+	}
+}
+
+func TestStream(t *testing.T) {
+	f := newTestFederation(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authentication"); got != "bearer remote-token" {
+			t.Errorf("remote got Authentication header %q", got)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `[{"collection":"meeting"}]` {
+			t.Errorf("remote got body %s", body)
+		}
+
+		fmt.Fprintln(w, `{"meeting/5/name":"Board"}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errDone := errors.New("done")
+	var got string
+	err := f.Stream(ctx, "b", "Bearer user-token", []byte(`[{"collection":"meeting"}]`), func(message []byte) error {
+		got = string(message)
+		return errDone
+	})
+
+	if !errors.Is(err, errDone) {
+		t.Errorf("Stream returned %v, expected the error from send", err)
+	}
+
+	if expect := `{"b:meeting/5/name":"Board"}`; got != expect {
+		t.Errorf("got message %s, expected %s", got, expect)
+	}
+}
+
+func TestStreamRejected(t *testing.T) {
+	f := newTestFederation(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(w, `{"type":"x","error":{"type":"invalid","msg":"meeting/name does not exist"}}`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := f.Stream(ctx, "b", "", []byte(`[]`), func(message []byte) error { return nil })
+
+	var errRemote Error
+	if !errors.As(err, &errRemote) {
+		t.Fatalf("Stream returned %v, expected a federation error", err)
+	}
+
+	if expect := "instance b: remote instance returned 400 Bad Request: meeting/name does not exist"; err.Error() != expect {
+		t.Errorf("got error `%s`, expected `%s`", err, expect)
+	}
+}
+
+func TestStreamTokenRejected(t *testing.T) {
+	f := newTestFederation(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("remote instance was called")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := f.Stream(ctx, "b", "bearer other-token", []byte(`[]`), func(message []byte) error { return nil })
+
+	var errRemote Error
+	if !errors.As(err, &errRemote) {
+		t.Fatalf("Stream returned %v, expected a federation error", err)
+	}
+}
+
+func TestStreamUnknownInstance(t *testing.T) {
+	f := &Federation{}
+
+	if f.Known("b") {
+		t.Errorf("Known returned true for an unknown instance")
+	}
+
+	err := f.Stream(context.Background(), "b", "", nil, func([]byte) error { return nil })
+	if err == nil {
+		t.Errorf("Stream returned no error")
+	}
+}
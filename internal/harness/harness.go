@@ -0,0 +1,132 @@
+// Package harness runs the service with a real postgres and redis for
+// integration tests.
+//
+// Postgres and redis are started as throwaway docker containers. The service
+// itself runs in the same process and uses the fake auth, so every request is
+// from user 1.
+//
+//	h, err := harness.New(ctx)
+//	if errors.Is(err, harness.ErrNoDocker) {
+//		t.Skip(err)
+//	}
+//	defer h.Close()
+//
+//	h.Write(ctx, dsmock.YAMLData(...))
+//	service, err := h.Start(ctx, nil)
+//	data, err := service.Single(ctx, `[{"collection":"user","ids":[1],"fields":{"username":null}}]`)
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/ory/dockertest/v3"
+)
+
+// ErrNoDocker is returned, when docker is not available. Tests should be
+// skipped in this case.
+var ErrNoDocker = errors.New("docker is not available")
+
+// Harness holds a postgres and a redis container.
+type Harness struct {
+	Postgres *Postgres
+	Redis    *Redis
+}
+
+// New starts postgres and redis.
+func New(ctx context.Context) (h *Harness, err error) {
+	pool, err := dockerPool()
+	if err != nil {
+		return nil, err
+	}
+
+	h = new(Harness)
+	defer func() {
+		if err != nil {
+			if err := h.Close(); err != nil {
+				log.Printf("Closing harness: %v", err)
+			}
+		}
+	}()
+
+	h.Postgres, err = newPostgres(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("start postgres: %w", err)
+	}
+
+	h.Redis, err = newRedis(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("start redis: %w", err)
+	}
+
+	return h, nil
+}
+
+// dockerPool connects to docker.
+func dockerPool() (*dockertest.Pool, error) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoDocker, err)
+	}
+
+	if err := pool.Client.Ping(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoDocker, err)
+	}
+	return pool, nil
+}
+
+// Close removes the containers.
+func (h *Harness) Close() error {
+	var errs []error
+	if h.Postgres != nil {
+		errs = append(errs, h.Postgres.Close())
+	}
+	if h.Redis != nil {
+		errs = append(errs, h.Redis.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Env returns the environment variables to connect to postgres and redis.
+func (h *Harness) Env() map[string]string {
+	env := make(map[string]string)
+	for k, v := range h.Postgres.Env {
+		env[k] = v
+	}
+	for k, v := range h.Redis.Env {
+		env[k] = v
+	}
+	return env
+}
+
+// Write writes the data like the datastore writer does. The data is saved in
+// postgres and the keys are published on redis. A nil value deletes a key.
+func (h *Harness) Write(ctx context.Context, data map[dskey.Key][]byte) error {
+	if err := h.Postgres.Write(ctx, data); err != nil {
+		return fmt.Errorf("write to postgres: %w", err)
+	}
+
+	if err := h.Redis.Publish(ctx, data); err != nil {
+		return fmt.Errorf("publish on redis: %w", err)
+	}
+	return nil
+}
+
+// LoadExampleData writes the content of an example-data.json file to
+// postgres. Nothing is published on redis, so it should be called before the
+// service is started.
+func (h *Harness) LoadExampleData(ctx context.Context, content []byte) error {
+	objects, err := datastore.ParseExampleData(content)
+	if err != nil {
+		return fmt.Errorf("parsing example data: %w", err)
+	}
+
+	if err := h.Postgres.writeObjects(ctx, objects); err != nil {
+		return fmt.Errorf("write to postgres: %w", err)
+	}
+	return nil
+}
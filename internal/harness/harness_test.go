@@ -0,0 +1,73 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/harness"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+)
+
+func TestService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Integration Test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	h, err := harness.New(ctx)
+	if errors.Is(err, harness.ErrNoDocker) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("starting harness: %v", err)
+	}
+	defer h.Close()
+
+	err = h.LoadExampleData(ctx, []byte(`{
+		"_migration_index": 50,
+		"organization": {"1": {"id": 1, "name": "test"}},
+		"user": {"1": {"id": 1, "username": "admin", "organization_management_level": "superadmin"}}
+	}`))
+	if err != nil {
+		t.Fatalf("loading example data: %v", err)
+	}
+
+	service, err := h.Start(ctx, nil)
+	if err != nil {
+		t.Fatalf("starting service: %v", err)
+	}
+	defer service.Close()
+
+	body := `[{"collection":"user","ids":[1],"fields":{"username":null}}]`
+	data, err := service.Single(ctx, body)
+	if err != nil {
+		t.Fatalf("Single: %v", err)
+	}
+
+	if got := string(data["user/1/username"]); got != `"admin"` {
+		t.Errorf("got username %s, expected \"admin\"", got)
+	}
+
+	stream, err := service.Stream(ctx, body)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	<-stream
+
+	if err := h.Write(ctx, dsmock.YAMLData("user/1/username: hugo")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	select {
+	case data := <-stream:
+		if got := string(data["user/1/username"]); got != `"hugo"` {
+			t.Errorf("got update %v, expected the new username", data)
+		}
+	case <-ctx.Done():
+		t.Fatalf("no update after the write")
+	}
+}
@@ -0,0 +1,147 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/jackc/pgx/v5"
+	"github.com/ory/dockertest/v3"
+)
+
+// schema is the table of the datastore, that is read by the service.
+const schema = `
+CREATE TABLE IF NOT EXISTS models (
+	fqid VARCHAR(48) PRIMARY KEY,
+	data JSONB NOT NULL,
+	deleted BOOLEAN NOT NULL
+);`
+
+// Postgres is a postgres container with the schema of the datastore.
+type Postgres struct {
+	pool     *dockertest.Pool
+	resource *dockertest.Resource
+	config   *pgx.ConnConfig
+
+	// Env are the environment variables for the service.
+	Env map[string]string
+}
+
+func newPostgres(ctx context.Context, pool *dockertest.Pool) (*Postgres, error) {
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "13",
+		Env: []string{
+			"POSTGRES_USER=postgres",
+			"POSTGRES_PASSWORD=openslides",
+			"POSTGRES_DB=database",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start postgres container: %w", err)
+	}
+
+	port := resource.GetPort("5432/tcp")
+	config, err := pgx.ParseConfig(fmt.Sprintf(`user=postgres password='openslides' host=localhost port=%s dbname=database`, port))
+	if err != nil {
+		pool.Purge(resource)
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	p := &Postgres{
+		pool:     pool,
+		resource: resource,
+		config:   config,
+		Env: map[string]string{
+			"DATABASE_HOST": "localhost",
+			"DATABASE_PORT": port,
+			"DATABASE_NAME": "database",
+			"DATABASE_USER": "postgres",
+		},
+	}
+
+	conn, err := p.conn(ctx)
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, schema); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("add schema: %w", err)
+	}
+
+	return p, nil
+}
+
+// Close removes the container.
+func (p *Postgres) Close() error {
+	if err := p.pool.Purge(p.resource); err != nil {
+		return fmt.Errorf("purge postgres container: %w", err)
+	}
+	return nil
+}
+
+// conn connects to postgres. It retries until the container accepts
+// connections or the context is done.
+func (p *Postgres) conn(ctx context.Context) (*pgx.Conn, error) {
+	for {
+		conn, err := pgx.ConnectConfig(ctx, p.config)
+		if err == nil {
+			return conn, nil
+		}
+
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w, last error: %v", ctx.Err(), err)
+		}
+	}
+}
+
+// Write saves the keys. A nil value deletes the field.
+func (p *Postgres) Write(ctx context.Context, data map[dskey.Key][]byte) error {
+	objects := make(map[string]map[string]json.RawMessage)
+	for k, v := range data {
+		fqid := k.FQID()
+		if _, ok := objects[fqid]; !ok {
+			objects[fqid] = make(map[string]json.RawMessage)
+		}
+
+		if v == nil {
+			v = []byte("null")
+		}
+		objects[fqid][k.Field()] = v
+	}
+
+	return p.writeObjects(ctx, objects)
+}
+
+// writeObjects merges the fields into the objects. Fields with the value null
+// are removed.
+func (p *Postgres) writeObjects(ctx context.Context, objects map[string]map[string]json.RawMessage) error {
+	conn, err := p.conn(ctx)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	for fqid, fields := range objects {
+		encoded, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", fqid, err)
+		}
+
+		if _, err := conn.Exec(ctx, `INSERT INTO models (fqid, data, deleted) VALUES ($1, '{}', false) ON CONFLICT DO NOTHING;`, fqid); err != nil {
+			return fmt.Errorf("create %s: %w", fqid, err)
+		}
+
+		if _, err := conn.Exec(ctx, `UPDATE models SET data = jsonb_strip_nulls(data || $2) WHERE fqid = $1;`, fqid, string(encoded)); err != nil {
+			return fmt.Errorf("write %s: %w", fqid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/redis"
+	"github.com/ory/dockertest/v3"
+)
+
+// Redis is a redis container.
+type Redis struct {
+	pool     *dockertest.Pool
+	resource *dockertest.Resource
+	client   *redis.Redis
+
+	// Env are the environment variables for the service.
+	Env map[string]string
+}
+
+func newRedis(ctx context.Context, pool *dockertest.Pool) (*Redis, error) {
+	resource, err := pool.Run("redis", "6.2", nil)
+	if err != nil {
+		return nil, fmt.Errorf("start redis container: %w", err)
+	}
+
+	env := map[string]string{
+		"MESSAGE_BUS_HOST": "localhost",
+		"MESSAGE_BUS_PORT": resource.GetPort("6379/tcp"),
+	}
+
+	client, err := redis.New(environment.ForTests(env))
+	if err != nil {
+		pool.Purge(resource)
+		return nil, fmt.Errorf("init redis client: %w", err)
+	}
+
+	if err := client.Wait(ctx); err != nil {
+		pool.Purge(resource)
+		return nil, fmt.Errorf("waiting for redis: %w", err)
+	}
+
+	return &Redis{
+		pool:     pool,
+		resource: resource,
+		client:   client,
+		Env:      env,
+	}, nil
+}
+
+// Close removes the container.
+func (r *Redis) Close() error {
+	if err := r.pool.Purge(r.resource); err != nil {
+		return fmt.Errorf("purge redis container: %w", err)
+	}
+	return nil
+}
+
+// Publish writes the keys to the autoupdate stream.
+func (r *Redis) Publish(ctx context.Context, data map[dskey.Key][]byte) error {
+	return r.client.Publish(ctx, data)
+}
@@ -0,0 +1,229 @@
+package harness
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	gohttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/auth"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/redis"
+)
+
+// startTimeout is the time, the service has to answer on the health route.
+const startTimeout = 30 * time.Second
+
+// Service is the autoupdate service running in the test process.
+type Service struct {
+	// URL is the address of the public routes, for example
+	// http://localhost:12345/system/autoupdate.
+	URL string
+
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// Start runs the service with postgres and redis of the harness. The values
+// of env are added to the environment, for example to change a setting.
+//
+// The vote service is not used. Auth is the fake auth, that handles each
+// request as user 1.
+func (h *Harness) Start(ctx context.Context, env map[string]string) (*Service, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, fmt.Errorf("find free port: %w", err)
+	}
+
+	settings := h.Env()
+	settings["AUTH_FAKE"] = "true"
+	settings["AUTOUPDATE_PORT"] = port
+	for k, v := range env {
+		settings[k] = v
+	}
+	lookup := environment.ForTests(settings)
+
+	messageBus, err := redis.New(lookup)
+	if err != nil {
+		return nil, fmt.Errorf("init message bus: %w", err)
+	}
+
+	flow, flowBackground, err := autoupdate.NewFlow(lookup, messageBus, true)
+	if err != nil {
+		return nil, fmt.Errorf("init autoupdate data flow: %w", err)
+	}
+
+	authService, _, err := auth.New(lookup, messageBus)
+	if err != nil {
+		return nil, fmt.Errorf("init auth: %w", err)
+	}
+
+	if err := restrict.Configure(lookup); err != nil {
+		return nil, fmt.Errorf("init restricter: %w", err)
+	}
+
+	auService, auBackground, err := autoupdate.New(lookup, flow, restrict.Middleware)
+	if err != nil {
+		return nil, fmt.Errorf("init autoupdate: %w", err)
+	}
+
+	httpConfig, err := http.NewConfig(lookup)
+	if err != nil {
+		return nil, fmt.Errorf("init http config: %w", err)
+	}
+
+	readinessChecks := map[string]http.ReadinessCheck{
+		"datastore":  flow.Ping,
+		"messagebus": messageBus.Ping,
+		"cache":      flow.CacheWarm,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Service{
+		URL:    "http://localhost:" + port + httpConfig.BasePath,
+		cancel: cancel,
+		done:   make(chan error, 1),
+	}
+
+	ignoreErrors := func(error) {}
+	go flowBackground(ctx, ignoreErrors)
+	go auBackground(ctx, ignoreErrors)
+	go func() {
+		s.done <- http.Run(ctx, httpConfig, ":"+port, authService, auService, nil, 0, readinessChecks)
+	}()
+
+	if err := s.waitHealthy(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// freePort returns a port, that is not used at the moment.
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+// waitHealthy blocks until the health route answers.
+func (s *Service) waitHealthy(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	for {
+		req, err := gohttp.NewRequestWithContext(ctx, "GET", s.URL+"/health", nil)
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+
+		resp, err := gohttp.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == 200 {
+				return nil
+			}
+		}
+
+		select {
+		case err := <-s.done:
+			return fmt.Errorf("service stopped: %w", err)
+		case <-ctx.Done():
+			return fmt.Errorf("service not healthy: %w", ctx.Err())
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	s.cancel()
+	if err := <-s.done; err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("service: %w", err)
+	}
+	return nil
+}
+
+// request sends a body to the autoupdate route.
+func (s *Service) request(ctx context.Context, query string, body string) (*gohttp.Response, error) {
+	req, err := gohttp.NewRequestWithContext(ctx, "POST", s.URL+"?"+query, strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := gohttp.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("got status %s: %s", resp.Status, msg)
+	}
+	return resp, nil
+}
+
+// Single sends a keysrequest to the autoupdate route and returns the first
+// response.
+func (s *Service) Single(ctx context.Context, body string) (map[string]json.RawMessage, error) {
+	resp, err := s.request(ctx, "single=1", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return data, nil
+}
+
+// Stream opens an autoupdate connection. Each response is sent to the
+// returned channel. The channel is closed, when the connection ends, for
+// example when the context is done.
+func (s *Service) Stream(ctx context.Context, body string) (<-chan map[string]json.RawMessage, error) {
+	resp, err := s.request(ctx, "", body)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan map[string]json.RawMessage)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, 10_000_000)
+		for scanner.Scan() {
+			var data map[string]json.RawMessage
+			if err := json.Unmarshal(scanner.Bytes(), &data); err != nil {
+				return
+			}
+
+			select {
+			case ch <- data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/accounting"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+)
+
+// HandleAccounting registers an internal route, that returns the consumption
+// of each organization and its meetings since the start of the instance.
+//
+// {"default":{"bytes_sent":1024,"connection_seconds":360,"restrict_ms":42,"meetings":{"5":{...}}}}
+//
+// The route only exists, if the accounting is enabled.
+//
+// GET /internal/autoupdate/accounting
+func HandleAccounting(mux *http.ServeMux) {
+	if !accounting.Enabled() {
+		return
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			handleErrorInternal(w, invalidRequestError{fmt.Errorf("only GET requests are supported")})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(accounting.Totals()); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding accounting: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(prefixInternal+"/accounting", routeMiddleware(handler, routeInternal))
+}
+
+// startAccounting starts to count the consumption of a request for the
+// organization of its tenant.
+func startAccounting(ctx context.Context) (context.Context, *accounting.Connection) {
+	organization := tenant.FromContext(ctx)
+	if organization == "" {
+		organization = accounting.DefaultOrganization
+	}
+	return accounting.Start(ctx, organization)
+}
+
+// accountedWriter counts the written bytes of a request with a single
+// response.
+type accountedWriter struct {
+	http.ResponseWriter
+	account *accounting.Connection
+}
+
+func (w accountedWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.account.Sent(n)
+	return n, err
+}
+
+func (w accountedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
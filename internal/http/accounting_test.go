@@ -0,0 +1,59 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/accounting"
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestAccounting(t *testing.T) {
+	if err := accounting.Configure(environment.ForTests{"AUTOUPDATE_ACCOUNTING": "true"}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	defer accounting.Configure(environment.ForTests{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := func(ctx context.Context) (map[dskey.Key][]byte, error) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		cancel()
+		return map[dskey.Key][]byte{dskey.MustKey("meeting/5/name"): []byte(`"Board"`)}, nil
+	}
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) { return f, true },
+	}
+
+	mux := http.NewServeMux()
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
+	ahttp.HandleAccounting(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate?k=meeting/5/name", nil).WithContext(ctx))
+	sent := rec.Body.Len()
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/internal/autoupdate/accounting", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %s: %s", http.StatusText(rec.Code), rec.Body.String())
+	}
+
+	var totals map[string]accounting.Organization
+	if err := json.Unmarshal(rec.Body.Bytes(), &totals); err != nil {
+		t.Fatalf("decoding body %s: %v", rec.Body.String(), err)
+	}
+
+	if got := totals[accounting.DefaultOrganization].Meetings[5].BytesSent; got != int64(sent) {
+		t.Errorf("meeting 5 sent %d bytes, expected %d", got, sent)
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/audit"
+)
+
+// maxAuditBody is the number of bytes of the request body, that are written to
+// the audit log.
+const maxAuditBody = 1000
+
+// auditedRoutes are the route groups, that are always written to the audit
+// log. Other routes are marked with auditRoute.
+var auditedRoutes = routeSet{groups: map[string]bool{routeInternal: true, routeProfile: true}}
+
+// auditMiddleware writes the audited requests to the audit log after they are
+// finished.
+//
+// Does nothing, if auditLog is nil. Has to be called inside the
+// requestIDMiddleware.
+func auditMiddleware(next http.Handler, auditLog *audit.Log) http.Handler {
+	if auditLog == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		cw := &countingWriter{ResponseWriter: w, status: http.StatusOK}
+		body := &bodyCapture{ReadCloser: r.Body}
+		if r.Body != nil {
+			r.Body = body
+		}
+
+		next.ServeHTTP(cw, r)
+
+		info, _ := r.Context().Value(requestInfoKey{}).(*requestInfo)
+		if info == nil || !(info.audit || auditedRoutes.has(info.route)) {
+			return
+		}
+
+		err := auditLog.Write(audit.Entry{
+			Time:       start,
+			RequestID:  info.requestID,
+			UserID:     info.userID,
+			ClientIP:   ClientIPFromContext(r.Context()),
+			Route:      info.route,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      r.URL.RawQuery,
+			Body:       body.String(),
+			Status:     cw.status,
+			DurationMS: time.Since(start).Milliseconds(),
+		})
+		if err != nil {
+			logger.Error("Writing audit log", "request_id", info.requestID, "error", err)
+		}
+	})
+}
+
+// auditRoute marks the requests of a route for the audit log.
+func auditRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+			info.audit = true
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyCapture remembers the start of the request body, that was read by the
+// handler.
+type bodyCapture struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	dropped int
+}
+
+func (b *bodyCapture) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	keep := min(n, maxAuditBody-b.buf.Len())
+	b.buf.Write(p[:keep])
+	b.dropped += n - keep
+	return n, err
+}
+
+func (b *bodyCapture) String() string {
+	if b.dropped > 0 {
+		return b.buf.String() + "..."
+	}
+	return b.buf.String()
+}
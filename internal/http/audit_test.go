@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/audit"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.Open(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer auditLog.Close()
+
+	mux := http.NewServeMux()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setUserForLog(r.Context(), 7)
+		buf := make([]byte, 100)
+		r.Body.Read(buf)
+	})
+	mux.Handle("/internal/autoupdate", routeMiddleware(handler, routeInternal))
+	mux.Handle("/system/autoupdate/meeting_export", routeMiddleware(auditRoute(handler), routeHistory))
+	mux.Handle("/system/autoupdate/history_information", routeMiddleware(handler, routeHistory))
+
+	server := requestIDMiddleware(auditMiddleware(mux, auditLog))
+	for _, target := range []string{
+		"/internal/autoupdate?user_id=7",
+		"/system/autoupdate/meeting_export?meeting_id=1",
+		"/system/autoupdate/history_information?fqid=motion/1",
+	} {
+		req := httptest.NewRequest("POST", target, strings.NewReader(`[{"collection":"user"}]`))
+		server.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading audit file: %v", err)
+	}
+
+	count, err := audit.Verify(strings.NewReader(string(content)))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("got %d entries, expected 2:\n%s", count, content)
+	}
+
+	for _, expect := range []string{`"path":"/internal/autoupdate"`, `"query":"user_id=7"`, `"user_id":7`, `"route":"internal"`, `"path":"/system/autoupdate/meeting_export"`, `"body":"[{\"collection\":\"user\"}]"`} {
+		if !strings.Contains(string(content), expect) {
+			t.Errorf("audit file does not contain `%s`:\n%s", expect, content)
+		}
+	}
+
+	if strings.Contains(string(content), "history_information") {
+		t.Errorf("audit file contains a route, that is not audited:\n%s", content)
+	}
+}
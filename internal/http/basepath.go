@@ -0,0 +1,86 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envBasePath = environment.NewVariable("AUTOUPDATE_BASE_PATH", prefixPublic, "URL path under which the public routes are served. Use `/` to serve them at the root.")
+
+// unprefixedPaths are the paths of the routes, that are not public. They are
+// not changed by the base path.
+var unprefixedPaths = []string{prefixInternal, "/debug"}
+
+// parseBasePath reads the base path from the environment.
+//
+// The root path is returned as empty string.
+func parseBasePath(lookup environment.Environmenter) (string, error) {
+	value := envBasePath.Value(lookup)
+	if !strings.HasPrefix(value, "/") {
+		return "", fmt.Errorf("invalid value for `%s`: has to start with a slash, got %s", envBasePath.Key, value)
+	}
+
+	basePath := strings.TrimSuffix(value, "/")
+	if basePath == "" || basePath == prefixPublic {
+		return basePath, nil
+	}
+
+	for _, path := range append([]string{prefixPublic}, unprefixedPaths...) {
+		if hasPathPrefix(basePath, path) || hasPathPrefix(path, basePath) {
+			return "", fmt.Errorf("invalid value for `%s`: %s overlaps with %s", envBasePath.Key, basePath, path)
+		}
+	}
+	return basePath, nil
+}
+
+// basePathMiddleware serves the public routes under another path.
+//
+// The routes are registered with the prefix /system/autoupdate. This
+// middleware rewrites requests from the base path to this prefix. Requests to
+// the original prefix are rejected. Other paths are not changed. The internal
+// and debug routes are never changed, even if the base path is the root.
+func basePathMiddleware(next http.Handler, basePath string) http.Handler {
+	if basePath == prefixPublic {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hasPathPrefix(r.URL.Path, prefixPublic) {
+			handleErrorWithStatus(w, notFoundError{})
+			return
+		}
+
+		if hasPathPrefix(r.URL.Path, basePath) && !isUnprefixed(r.URL.Path) {
+			subPath := strings.TrimPrefix(r.URL.Path, basePath)
+			if subPath == "/" && basePath == "" {
+				subPath = ""
+			}
+
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = prefixPublic + subPath
+			r2.URL.RawPath = ""
+			r = r2
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasPathPrefix returns true, if path is prefix or a sub path of prefix.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// isUnprefixed returns true, if the path belongs to a route, that is not
+// public.
+func isUnprefixed(path string) bool {
+	for _, prefix := range unprefixedPaths {
+		if hasPathPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestBasePath(t *testing.T) {
+	var gotPath string
+	handler := basePathMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+	}), "/openslides/autoupdate")
+
+	for _, tt := range []struct {
+		path       string
+		expectCode int
+		expectPath string
+	}{
+		{"/openslides/autoupdate", 200, "/system/autoupdate"},
+		{"/openslides/autoupdate/health", 200, "/system/autoupdate/health"},
+		{"/openslides/autoupdatefoo", 200, "/openslides/autoupdatefoo"},
+		{"/internal/autoupdate", 200, "/internal/autoupdate"},
+		{"/system/autoupdate", 404, ""},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			gotPath = ""
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if rec.Code != tt.expectCode {
+				t.Errorf("got status %d, expected %d", rec.Code, tt.expectCode)
+			}
+
+			if gotPath != tt.expectPath {
+				t.Errorf("got path %s, expected %s", gotPath, tt.expectPath)
+			}
+		})
+	}
+}
+
+func TestBasePathRoot(t *testing.T) {
+	var gotPath string
+	handler := basePathMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+	}), "")
+
+	for _, tt := range []struct {
+		path       string
+		expectCode int
+		expectPath string
+	}{
+		{"/", 200, "/system/autoupdate"},
+		{"/health", 200, "/system/autoupdate/health"},
+		{"/internal/autoupdate", 200, "/internal/autoupdate"},
+		{"/debug/pprof/", 200, "/debug/pprof/"},
+		{"/system/autoupdate/health", 404, ""},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			gotPath = ""
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if rec.Code != tt.expectCode {
+				t.Errorf("got status %d, expected %d", rec.Code, tt.expectCode)
+			}
+
+			if gotPath != tt.expectPath {
+				t.Errorf("got path %s, expected %s", gotPath, tt.expectPath)
+			}
+		})
+	}
+}
+
+func TestParseBasePath(t *testing.T) {
+	for _, tt := range []struct {
+		value  string
+		expect string
+		err    bool
+	}{
+		{"/system/autoupdate", "/system/autoupdate", false},
+		{"/openslides/autoupdate/", "/openslides/autoupdate", false},
+		{"/", "", false},
+		{"openslides", "", true},
+		{"/system", "", true},
+		{"/system/autoupdate/v2", "", true},
+		{"/internal", "", true},
+		{"/debug/autoupdate", "", true},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseBasePath(environment.ForTests{"AUTOUPDATE_BASE_PATH": tt.value})
+			if tt.err {
+				if err == nil {
+					t.Errorf("got no error for %s", tt.value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseBasePath: %v", err)
+			}
+
+			if got != tt.expect {
+				t.Errorf("got %q, expected %q", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+const (
+	benchmarkMaxUsers    = 10
+	benchmarkMaxDuration = 30 * time.Second
+)
+
+// Benchmarker measures the throughput of the service with the cached data.
+type Benchmarker interface {
+	Profiler
+	Benchmark(ctx context.Context, cfg autoupdate.BenchmarkConfig) (autoupdate.BenchmarkResult, error)
+}
+
+// HandleBenchmark registers a route to measure, how fast the cached data is
+// restricted and encoded.
+//
+// The query parameter `user_id` can be given up to ten times. `sample` is the
+// number of cached keys (default 10000), `duration` the time of each
+// measurement (default 2s, maximum 30s) and `compress` uses the compression of
+// the autoupdate route.
+//
+// The route is protected like the profile routes.
+func HandleBenchmark(mux *http.ServeMux, auth Authenticater, benchmarker Benchmarker) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg, compress, err := parseBenchmarkQuery(r)
+		if err != nil {
+			handleErrorWithStatus(w, invalidRequestError{err})
+			return
+		}
+
+		cfg.Encode = func(data map[dskey.Key][]byte) (int, error) {
+			counter := &byteCounter{Writer: io.Discard}
+			if err := writeData(counter, data, compress); err != nil {
+				return 0, err
+			}
+			return counter.n, nil
+		}
+
+		result, err := benchmarker.Benchmark(r.Context(), cfg)
+		if err != nil {
+			if errors.Is(err, autoupdate.ErrNoCachedData) {
+				err = invalidRequestError{err}
+			}
+			handleErrorWithStatus(w, fmt.Errorf("benchmark: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding benchmark: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/benchmark", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, benchmarker), auth), routeProfile))
+}
+
+func parseBenchmarkQuery(r *http.Request) (autoupdate.BenchmarkConfig, bool, error) {
+	query := r.URL.Query()
+	cfg := autoupdate.BenchmarkConfig{
+		SampleSize: 10_000,
+		Duration:   2 * time.Second,
+	}
+
+	for _, raw := range query["user_id"] {
+		uid, err := strconv.Atoi(raw)
+		if err != nil || uid < 0 {
+			return cfg, false, fmt.Errorf("invalid user_id %q", raw)
+		}
+		cfg.UserIDs = append(cfg.UserIDs, uid)
+	}
+
+	if len(cfg.UserIDs) == 0 || len(cfg.UserIDs) > benchmarkMaxUsers {
+		return cfg, false, fmt.Errorf("expected between 1 and %d user_id parameters", benchmarkMaxUsers)
+	}
+
+	if raw := query.Get("sample"); raw != "" {
+		sample, err := strconv.Atoi(raw)
+		if err != nil || sample < 1 {
+			return cfg, false, fmt.Errorf("invalid sample %q", raw)
+		}
+		cfg.SampleSize = sample
+	}
+
+	if raw := query.Get("duration"); raw != "" {
+		duration, err := time.ParseDuration(raw)
+		if err != nil || duration <= 0 || duration > benchmarkMaxDuration {
+			return cfg, false, fmt.Errorf("invalid duration %q, expected a duration up to %s", raw, benchmarkMaxDuration)
+		}
+		cfg.Duration = duration
+	}
+
+	compress, _ := strconv.ParseBool(query.Get("compress"))
+	return cfg, compress, nil
+}
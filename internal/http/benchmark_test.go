@@ -0,0 +1,80 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+type benchmarkerStub struct {
+	profilerStub
+	cfg autoupdate.BenchmarkConfig
+}
+
+func (b *benchmarkerStub) Benchmark(ctx context.Context, cfg autoupdate.BenchmarkConfig) (autoupdate.BenchmarkResult, error) {
+	b.cfg = cfg
+	n, err := cfg.Encode(map[dskey.Key][]byte{myKey1: []byte(`"bar"`)})
+	if err != nil {
+		return autoupdate.BenchmarkResult{}, err
+	}
+
+	return autoupdate.BenchmarkResult{
+		SampleKeys: cfg.SampleSize,
+		Users: []autoupdate.BenchmarkUserResult{
+			{UserID: cfg.UserIDs[0], Encode: autoupdate.BenchmarkThroughput{Runs: 1, BytesPerSecond: float64(n)}},
+		},
+	}, nil
+}
+
+func TestBenchmark(t *testing.T) {
+	benchmarker := &benchmarkerStub{profilerStub: true}
+	mux := http.NewServeMux()
+	ahttp.HandleBenchmark(mux, fakeAuth(1), benchmarker)
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/benchmark?user_id=5&user_id=0&sample=100&duration=1s", nil))
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d: %s", resp.Code, resp.Body.String())
+	}
+
+	if got := benchmarker.cfg; len(got.UserIDs) != 2 || got.SampleSize != 100 || got.Duration.Seconds() != 1 {
+		t.Errorf("got config %+v", got)
+	}
+
+	var result autoupdate.BenchmarkResult
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+
+	// The encoder writes {"user/1/username":"bar"} with a newline.
+	if len(result.Users) != 1 || result.Users[0].Encode.BytesPerSecond != 26 {
+		t.Errorf("got result %+v, expected 26 encoded bytes", result)
+	}
+}
+
+func TestBenchmarkInvalid(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleBenchmark(mux, fakeAuth(1), &benchmarkerStub{profilerStub: true})
+
+	for _, query := range []string{
+		"",
+		"user_id=abc",
+		"user_id=1&sample=0",
+		"user_id=1&duration=1h",
+		"user_id=1&user_id=2&user_id=3&user_id=4&user_id=5&user_id=6&user_id=7&user_id=8&user_id=9&user_id=10&user_id=11",
+	} {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/benchmark?"+query, nil))
+
+		if resp.Code != 400 {
+			t.Errorf("query %q: got status %d, expected 400", query, resp.Code)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// protocolVersion is the version of the autoupdate protocol. It is increased,
+// when the format of the default profile changes.
+const protocolVersion = 1
+
+const (
+	// headerCapabilities is the request header, with that a client declares
+	// the features it supports.
+	headerCapabilities = "Autoupdate-Capabilities"
+
+	// headerProfile is the response header with the selected features.
+	headerProfile = "Autoupdate-Profile"
+
+	// headerProtocol is the response header with the protocol version.
+	headerProtocol = "Autoupdate-Protocol"
+)
+
+// The features of the protocol, that a client can select.
+const (
+	// featureCompress compresses each line with zstd and encodes it with
+	// base64. It is the same as the query `compress`.
+	featureCompress = "compress"
+
+	// featureControl are the control messages in a stream, like the
+	// maintenance notice, the resume id of a handoff and the reason of a
+	// termination.
+	featureControl = "control"
+)
+
+// protocolProfile are the features, that are used for a request.
+type protocolProfile struct {
+	compress bool
+	control  bool
+}
+
+// negotiateProfile selects the features for a request.
+//
+// A client without the header Autoupdate-Capabilities gets the default
+// profile. It contains the control messages and uses compression, if the
+// request has the query `compress`. A client with the header only gets the
+// declared features. Unknown features are ignored, so clients can declare
+// features of newer versions.
+func negotiateProfile(r *http.Request) protocolProfile {
+	profile := protocolProfile{
+		compress: r.URL.Query().Has("compress"),
+		control:  true,
+	}
+
+	declared := r.Header.Values(headerCapabilities)
+	if len(declared) == 0 {
+		return profile
+	}
+
+	profile.control = false
+	for _, value := range declared {
+		for _, feature := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(feature)) {
+			case featureCompress:
+				profile.compress = true
+			case featureControl:
+				profile.control = true
+			}
+		}
+	}
+	return profile
+}
+
+// features returns the names of the selected features.
+func (p protocolProfile) features() []string {
+	var features []string
+	if p.compress {
+		features = append(features, featureCompress)
+	}
+	if p.control {
+		features = append(features, featureControl)
+	}
+	return features
+}
+
+// writeHeader tells the client the protocol version and the selected
+// features.
+func (p protocolProfile) writeHeader(header http.Header) {
+	header.Set(headerProtocol, strconv.Itoa(protocolVersion))
+	header.Set(headerProfile, strings.Join(p.features(), ", "))
+}
@@ -0,0 +1,112 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/maintenance"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+func TestProfileHeader(t *testing.T) {
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) {
+			return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+				return map[dskey.Key][]byte{myKey1: []byte(`"bar"`)}, nil
+			}, true
+		},
+	}
+
+	mux := http.NewServeMux()
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
+
+	for _, tt := range []struct {
+		name         string
+		query        string
+		capabilities []string
+		expect       string
+	}{
+		{"default", "", nil, "control"},
+		{"default with compress", "&compress=1", nil, "compress, control"},
+		{"declared", "", []string{"control, COMPRESS"}, "compress, control"},
+		{"several headers", "", []string{"control", "compress"}, "compress, control"},
+		{"unknown features", "", []string{"delta, binary, tombstones"}, ""},
+		{"only compress", "", []string{"compress"}, "compress"},
+		{"query and header", "&compress=1", []string{"control"}, "compress, control"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/system/autoupdate?single=1&k=user/1/username"+tt.query, nil)
+			for _, value := range tt.capabilities {
+				req.Header.Add("Autoupdate-Capabilities", value)
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %s: %s", http.StatusText(rec.Code), rec.Body.String())
+			}
+
+			if got := rec.Header().Get("Autoupdate-Protocol"); got != "1" {
+				t.Errorf("got protocol %q, expected 1", got)
+			}
+
+			if got := rec.Header().Get("Autoupdate-Profile"); got != tt.expect {
+				t.Errorf("got profile %q, expected %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestProfileWithoutControl(t *testing.T) {
+	maintenance.Start("Update")
+	defer maintenance.End()
+
+	first := true
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) {
+			return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+				if first {
+					first = false
+					return map[dskey.Key][]byte{myKey1: []byte(`"bar"`)}, nil
+				}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}, true
+		},
+	}
+
+	mux := http.NewServeMux()
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/system/autoupdate?k=user/1/username", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Autoupdate-Capabilities", "delta")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() {
+		t.Fatalf("stream ended: %v", scanner.Err())
+	}
+
+	if got, expect := scanner.Text(), `{"user/1/username":"bar"}`; got != expect {
+		t.Errorf("got line %s, expected the data without the maintenance notice", got)
+	}
+}
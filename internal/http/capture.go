@@ -0,0 +1,54 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/capture"
+)
+
+// HandleCapture registers a route to record the traffic.
+//
+// A POST request with the query parameter `duration` starts a capture, a
+// DELETE request ends it early and a GET request returns the status.
+//
+// The route is protected like the profile routes.
+func HandleCapture(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status capture.Status
+		switch r.Method {
+		case http.MethodGet:
+			status = capture.CurrentStatus()
+
+		case http.MethodPost:
+			duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+			if err != nil {
+				handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("invalid duration: %w", err)})
+				return
+			}
+
+			if _, err := capture.Start(duration); err != nil {
+				handleErrorWithStatus(w, invalidRequestError{err})
+				return
+			}
+			status = capture.CurrentStatus()
+
+		case http.MethodDelete:
+			status = capture.Stop()
+
+		default:
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("only GET, POST and DELETE requests are supported")})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding capture status: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/capture", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envTrustedProxies = environment.NewVariable("AUTOUPDATE_TRUSTED_PROXIES", "", "Comma separated list of CIDRs of reverse proxies. The client ip is read from X-Forwarded-For or Forwarded for requests from these addresses. Use `unix` to trust the requests over the unix socket.")
+
+// TrustedProxies finds the real client ip of a request.
+//
+// Has to be initialized with NewTrustedProxies().
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+
+	// unix is true, if the requests over the unix socket come from a trusted
+	// proxy.
+	unix bool
+}
+
+// NewTrustedProxies reads the trusted proxies from the environment.
+func NewTrustedProxies(lookup environment.Environmenter) (*TrustedProxies, error) {
+	var t TrustedProxies
+	for _, raw := range strings.Split(envTrustedProxies.Value(lookup), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		if raw == "unix" {
+			t.unix = true
+			continue
+		}
+
+		if !strings.Contains(raw, "/") {
+			addr, err := netip.ParseAddr(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for `%s`: %w", envTrustedProxies.Key, err)
+			}
+			t.prefixes = append(t.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for `%s`: %w", envTrustedProxies.Key, err)
+		}
+		t.prefixes = append(t.prefixes, prefix.Masked())
+	}
+
+	return &t, nil
+}
+
+func (t *TrustedProxies) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range t.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the ip address of the client.
+//
+// If the request comes from a trusted proxy, the forwarding headers are read
+// from right to left. The first address that is not a trusted proxy is the
+// client.
+//
+// Requests over the unix socket are only handled as requests from a trusted
+// proxy, if `unix` is in the trusted proxies. Requests with an invalid remote
+// address are never trusted. For them, the remote address is returned.
+func (t *TrustedProxies) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	fallback := r.RemoteAddr
+	if err == nil {
+		fallback = remote.Unmap().String()
+	}
+
+	if err != nil && !(t.unix && isUnixSocket(r)) {
+		return fallback
+	}
+
+	if err == nil && !t.trusted(remote) {
+		return fallback
+	}
+
+	forwarded := forwardedFor(r.Header)
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if !t.trusted(forwarded[i]) {
+			return forwarded[i].Unmap().String()
+		}
+	}
+
+	if len(forwarded) > 0 {
+		return forwarded[0].Unmap().String()
+	}
+
+	return fallback
+}
+
+// isUnixSocket tells, if the request was received on a unix socket.
+func isUnixSocket(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
+}
+
+// forwardedFor returns the addresses from the Forwarded header or if it does
+// not exist from the X-Forwarded-For header. The client is the first element.
+//
+// Nodes that are not ip addresses, like `unknown` or obfuscated identifiers,
+// are dropped together with all nodes left of them, since the chain of
+// proxies can not be followed behind them.
+func forwardedFor(header http.Header) []netip.Addr {
+	var nodes []string
+
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+					if !found || !strings.EqualFold(key, "for") {
+						continue
+					}
+
+					nodes = append(nodes, parseForwardedNode(value))
+				}
+			}
+		}
+	} else {
+		for _, value := range header.Values("X-Forwarded-For") {
+			for _, node := range strings.Split(value, ",") {
+				if node = strings.TrimSpace(node); node != "" {
+					nodes = append(nodes, node)
+				}
+			}
+		}
+	}
+
+	var addrs []netip.Addr
+	for _, node := range nodes {
+		addr, err := netip.ParseAddr(node)
+		if err != nil {
+			addrs = nil
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// parseForwardedNode returns the address from a node of the Forwarded header.
+//
+// See RFC 7239 section 6: `"[2001:db8::1]:4711"` or `192.0.2.43:47011`.
+func parseForwardedNode(node string) string {
+	node = strings.Trim(node, `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return strings.Trim(node, "[]")
+}
+
+// Middleware saves the client ip in the request context.
+//
+// Has to be called inside the requestIDMiddleware.
+func (t *TrustedProxies) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+			info.clientIP = t.ClientIP(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIPFromContext returns the client ip of the request.
+//
+// Returns an empty string, if the context does not belong to a request.
+func ClientIPFromContext(ctx context.Context) string {
+	info, ok := ctx.Value(requestInfoKey{}).(*requestInfo)
+	if !ok {
+		return ""
+	}
+	return info.clientIP
+}
@@ -0,0 +1,150 @@
+package http_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ahttp.NewTrustedProxies(environment.ForTests{
+		"AUTOUPDATE_TRUSTED_PROXIES": "10.0.0.0/8, 192.168.1.1",
+	})
+	if err != nil {
+		t.Fatalf("NewTrustedProxies: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		remoteAddr string
+		header     map[string]string
+		expect     string
+	}{
+		{
+			name:       "No proxy",
+			remoteAddr: "203.0.113.5:1234",
+			expect:     "203.0.113.5",
+		},
+		{
+			name:       "Untrusted remote with header",
+			remoteAddr: "203.0.113.5:1234",
+			header:     map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expect:     "203.0.113.5",
+		},
+		{
+			name:       "Trusted proxy",
+			remoteAddr: "10.1.2.3:1234",
+			header:     map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expect:     "198.51.100.1",
+		},
+		{
+			name:       "Proxy chain",
+			remoteAddr: "10.1.2.3:1234",
+			header:     map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 192.168.1.1"},
+			expect:     "198.51.100.1",
+		},
+		{
+			name:       "Forwarded header",
+			remoteAddr: "10.1.2.3:1234",
+			header:     map[string]string{"Forwarded": `for=198.51.100.1;proto=https, for="[2001:db8::1]:4711"`},
+			expect:     "2001:db8::1",
+		},
+		{
+			name:       "Only trusted addresses",
+			remoteAddr: "10.1.2.3:1234",
+			header:     map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expect:     "10.0.0.1",
+		},
+		{
+			name:       "Unknown node",
+			remoteAddr: "10.1.2.3:1234",
+			header:     map[string]string{"X-Forwarded-For": "198.51.100.1, unknown, 10.0.0.1"},
+			expect:     "10.0.0.1",
+		},
+		{
+			name:       "Obfuscated node",
+			remoteAddr: "10.1.2.3:1234",
+			header:     map[string]string{"Forwarded": `for=_hidden, for=198.51.100.1`},
+			expect:     "198.51.100.1",
+		},
+		{
+			name:       "Only invalid nodes",
+			remoteAddr: "10.1.2.3:1234",
+			header:     map[string]string{"X-Forwarded-For": "unknown"},
+			expect:     "10.1.2.3",
+		},
+		{
+			name:       "Unix socket",
+			remoteAddr: "@",
+			header:     map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expect:     "@",
+		},
+		{
+			name:       "Invalid remote address",
+			remoteAddr: "not-an-ip:1234",
+			header:     map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expect:     "not-an-ip:1234",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+
+			if got := proxies.ClientIP(req); got != tt.expect {
+				t.Errorf("got %s, expected %s", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestClientIPUnixSocket(t *testing.T) {
+	proxies, err := ahttp.NewTrustedProxies(environment.ForTests{
+		"AUTOUPDATE_TRUSTED_PROXIES": "unix, 10.0.0.0/8",
+	})
+	if err != nil {
+		t.Fatalf("NewTrustedProxies: %v", err)
+	}
+
+	socket := context.WithValue(context.Background(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/run/autoupdate.sock", Net: "unix"})
+
+	for _, tt := range []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expect     string
+	}{
+		{"Client", "@", "198.51.100.1", "198.51.100.1"},
+		{"Proxy chain", "@", "198.51.100.1, 10.0.0.1", "198.51.100.1"},
+		{"No header", "@", "", "@"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil).WithContext(socket)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if got := proxies.ClientIP(req); got != tt.expect {
+				t.Errorf("got %s, expected %s", got, tt.expect)
+			}
+		})
+	}
+
+	t.Run("Invalid remote address over tcp", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "@"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+		if got := proxies.ClientIP(req); got != "@" {
+			t.Errorf("got %s, expected @", got)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// acceptsGzip tells, if the client accepts gzip compressed responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) == "gzip" && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter compresses the response, if enabled returns true.
+//
+// enabled is called, when the header is written, so it can use the route
+// group of the request. Responses, that have their own Content-Encoding or
+// no body, are not compressed.
+type gzipWriter struct {
+	http.ResponseWriter
+	enabled func() bool
+
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) decide(status int) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	if !w.enabled() {
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	if w.Header().Get("Content-Encoding") != "" || status == http.StatusNoContent || status == http.StatusNotModified {
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	w.decide(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	w.decide(http.StatusOK)
+	if w.gz == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.gz.Write(p)
+}
+
+// Flush implements the http.Flusher interface. The compressed data is sent to
+// the client, so streams are not delayed.
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap is used by http.ResponseController.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close writes the end of the compressed data.
+func (w *gzipWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
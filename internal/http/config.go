@@ -0,0 +1,206 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/audit"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/throttle"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// Config holds the settings of the http server.
+//
+// It has to be created with NewConfig() at startup time.
+type Config struct {
+	// BasePath is the path of the public routes.
+	BasePath string
+
+	CORS           *CORS
+	AccessLog      bool
+	TrustedProxies *TrustedProxies
+
+	// RateLimiter is nil, if the rate limit is disabled.
+	RateLimiter *RateLimiter
+
+	// ConnectionLimiter is nil, if the number of connections is not limited.
+	ConnectionLimiter *ConnectionLimiter
+
+	// MemoryThrottle tells, if a new connection has to be rejected, because
+	// the heap is too big. Nil means no check.
+	MemoryThrottle func() bool
+
+	// MeetingMetric is nil, if the metric for each meeting is disabled.
+	MeetingMetric *MeetingMetric
+
+	// Routes decides, which route groups use the rate limit, the access log,
+	// the relaxed auth and the compression.
+	Routes RouteMiddleware
+
+	Timeouts Timeouts
+
+	// LongpollingTimeout is the time after which a longpolling request returns
+	// without data.
+	LongpollingTimeout time.Duration
+
+	// SlowRequest is the duration after which the first response of an
+	// autoupdate request is logged. Zero disables the log.
+	SlowRequest time.Duration
+
+	// HandoffTTL is the time, the state of a connection is kept, when it is
+	// handed off to another instance. Zero disables the handoff.
+	HandoffTTL time.Duration
+
+	// ResumeStore saves the state of the handed off connections. The handoff
+	// is disabled, if it is nil. It is not set by NewConfig.
+	ResumeStore ResumeStore
+
+	// ICC handles the inter client communication. The routes are not
+	// registered, if it is nil. It is not set by NewConfig.
+	ICC InterClientCommunicator
+
+	// ForcedLogout closes the connections of a session or a user on all
+	// instances. The route is not registered, if it is nil. It is not set by
+	// NewConfig.
+	ForcedLogout ForcedLogouter
+
+	// Terminate closes the connections of a session or a user on all
+	// instances without a logout. The route is not registered, if it is nil.
+	// It is not set by NewConfig.
+	Terminate Terminator
+
+	// Federation streams the data of remote instances. Requests with remote
+	// entries are rejected, if it is nil. It is not set by NewConfig.
+	Federation Federator
+
+	// Tenants are the organizations with their own backend. If it is not
+	// empty, the data routes are only served for their hosts. It is not set
+	// by NewConfig.
+	Tenants map[string]Tenant
+
+	// TLS is nil, if the service should not use TLS.
+	TLS *CertReloader
+
+	// SocketPath is the path of an additional unix socket. Empty means no
+	// unix socket.
+	SocketPath string
+
+	// InternalAddr is the address for the internal routes. If it is empty,
+	// the internal routes are served with the public routes.
+	InternalAddr string
+
+	// Audit is nil, if the audit log is disabled.
+	Audit *audit.Log
+
+	// ReadinessChecks are the names of the checks used for the readiness
+	// route.
+	ReadinessChecks []string
+
+	// Settings returns the effective configuration with secrets redacted. It
+	// is nil, if the environment can not tell the sources of the values.
+	Settings func() []environment.Setting
+}
+
+// NewConfig reads the settings of the http server from the environment.
+func NewConfig(lookup environment.Environmenter) (Config, error) {
+	basePath, err := parseBasePath(lookup)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cors, err := NewCORS(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init cors: %w", err)
+	}
+
+	accessLog, err := strconv.ParseBool(envAccessLog.Value(lookup))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid value for `%s`: %w", envAccessLog.Key, err)
+	}
+
+	trustedProxies, err := NewTrustedProxies(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init trusted proxies: %w", err)
+	}
+
+	rateLimiter, err := NewRateLimiter(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init rate limit: %w", err)
+	}
+
+	connectionLimiter, err := NewConnectionLimiter(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init connection limit: %w", err)
+	}
+
+	meetingMetric, err := NewMeetingMetric(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init meeting metric: %w", err)
+	}
+
+	routes, err := NewRouteMiddleware(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init route middleware: %w", err)
+	}
+
+	timeouts, err := NewTimeouts(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init timeouts: %w", err)
+	}
+
+	longpollingTimeout, err := environment.ParseDuration(envLongpollingTimeout.Value(lookup))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envLongpollingTimeout.Key, envLongpollingTimeout.Value(lookup), err)
+	}
+
+	slowRequest, err := parseSlowRequest(lookup)
+	if err != nil {
+		return Config{}, err
+	}
+
+	handoffTTL, err := environment.ParseDuration(envHandoffTTL.Value(lookup))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envHandoffTTL.Key, envHandoffTTL.Value(lookup), err)
+	}
+
+	certReloader, err := NewCertReloader(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init tls: %w", err)
+	}
+
+	auditLog, err := audit.New(lookup)
+	if err != nil {
+		return Config{}, fmt.Errorf("init audit log: %w", err)
+	}
+
+	var settings func() []environment.Setting
+	if resolver, ok := lookup.(interface {
+		Resolve([]environment.Variable) []environment.Setting
+	}); ok {
+		settings = func() []environment.Setting { return resolver.Resolve(environment.Defined()) }
+	}
+
+	return Config{
+		BasePath:       basePath,
+		CORS:           cors,
+		AccessLog:      accessLog,
+		TrustedProxies: trustedProxies,
+		RateLimiter:    rateLimiter,
+		Routes:         routes,
+		Timeouts:       timeouts,
+
+		ConnectionLimiter:  connectionLimiter,
+		MemoryThrottle:     throttle.Reject,
+		MeetingMetric:      meetingMetric,
+		LongpollingTimeout: longpollingTimeout,
+		SlowRequest:        slowRequest,
+		HandoffTTL:         handoffTTL,
+		TLS:                certReloader,
+		SocketPath:         envSocket.Value(lookup),
+		InternalAddr:       envInternalAddr.Value(lookup),
+		Audit:              auditLog,
+		ReadinessChecks:    parseReadinessChecks(lookup),
+		Settings:           settings,
+	}, nil
+}
@@ -101,6 +101,21 @@ func (c *ConnectionCount) Done(uid int) {
 	c.increment(uid, -1)
 }
 
+// local returns the number of users and connections of this instance.
+func (c *ConnectionCount) local() (users int, connections int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, count := range c.connections {
+		if count <= 0 {
+			continue
+		}
+		users++
+		connections += count
+	}
+	return users, connections
+}
+
 // Show shows the counter.
 //
 // if a redis connection is set, the data are fetched from redis. In other case,
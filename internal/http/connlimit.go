@@ -0,0 +1,161 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// overloadRetryAfter is the value of the Retry-After header, when a connection
+// is rejected.
+const overloadRetryAfter = "10"
+
+var (
+	envMaxConnections      = environment.NewVariable("AUTOUPDATE_MAX_CONNECTIONS", "0", "Maximum number of open autoupdate connections. Requests with `single` are not counted. Zero means no limit.")
+	envMaxConnectionsPerIP = environment.NewVariable("AUTOUPDATE_MAX_CONNECTIONS_PER_IP", "0", "Maximum number of open autoupdate connections from one client ip. Zero means no limit.")
+)
+
+// ConnectionLimiter limits the number of open connections in total and for
+// each client ip.
+//
+// Has to be initialized with NewConnectionLimiter().
+type ConnectionLimiter struct {
+	maxTotal int
+	maxPerIP int
+
+	mu              sync.Mutex
+	total           int
+	perIP           map[string]int
+	rejectedTotal   int
+	rejectedPerIP   int
+	highestObserved int
+}
+
+// NewConnectionLimiter initializes a ConnectionLimiter from the environment.
+//
+// Returns nil, if there is no limit.
+func NewConnectionLimiter(lookup environment.Environmenter) (*ConnectionLimiter, error) {
+	var limits [2]int
+	for i, env := range []environment.Variable{envMaxConnections, envMaxConnectionsPerIP} {
+		v, err := strconv.Atoi(env.Value(lookup))
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid value for `%s`, expected positive number, got %s", env.Key, env.Value(lookup))
+		}
+		limits[i] = v
+	}
+
+	if limits[0] == 0 && limits[1] == 0 {
+		return nil, nil
+	}
+
+	return newConnectionLimiter(limits[0], limits[1]), nil
+}
+
+func newConnectionLimiter(maxTotal, maxPerIP int) *ConnectionLimiter {
+	return &ConnectionLimiter{
+		maxTotal: maxTotal,
+		maxPerIP: maxPerIP,
+		perIP:    make(map[string]int),
+	}
+}
+
+// acquire reserves a connection for the ip. The returned function has to be
+// called, when the connection is closed.
+func (l *ConnectionLimiter) acquire(ip string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxTotal > 0 && l.total >= l.maxTotal {
+		l.rejectedTotal++
+		return nil, overloadedError{"The server has too many open connections."}
+	}
+
+	if l.maxPerIP > 0 && l.perIP[ip] >= l.maxPerIP {
+		l.rejectedPerIP++
+		return nil, overloadedError{"There are too many open connections from your ip address."}
+	}
+
+	l.total++
+	l.perIP[ip]++
+	if l.total > l.highestObserved {
+		l.highestObserved = l.total
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.total--
+			l.perIP[ip]--
+			if l.perIP[ip] <= 0 {
+				delete(l.perIP, ip)
+			}
+		})
+	}, nil
+}
+
+// Metric adds the values of the limiter to the metric.
+func (l *ConnectionLimiter) Metric(con metric.Container) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	con.Add("connection_limit_current", l.total)
+	con.Add("connection_limit_highest", l.highestObserved)
+	con.Add("connection_limit_ips", len(l.perIP))
+	con.Add("connection_limit_rejected_total", l.rejectedTotal)
+	con.Add("connection_limit_rejected_per_ip", l.rejectedPerIP)
+}
+
+// connectionLimitMiddleware rejects new connections, when a limit is reached.
+//
+// Requests with the query `single` are not limited, since they are closed
+// after the response. If the limiter is nil, next is returned unchanged.
+func connectionLimitMiddleware(next http.Handler, limiter *ConnectionLimiter) http.Handler {
+	if limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("single") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		release, err := limiter.acquire(ClientIPFromContext(r.Context()))
+		if err != nil {
+			w.Header().Set("Retry-After", overloadRetryAfter)
+			handleErrorWithStatus(w, err)
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// memoryThrottleMiddleware rejects new connections, while the service is
+// throttled because of its memory usage.
+//
+// Requests with the query `single` are not rejected. If reject is nil, next is
+// returned unchanged.
+func memoryThrottleMiddleware(next http.Handler, reject func() bool) http.Handler {
+	if reject == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("single") && reject() {
+			w.Header().Set("Retry-After", overloadRetryAfter)
+			handleErrorWithStatus(w, overloadedError{"The server is low on memory."})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnectionLimiter(t *testing.T) {
+	t.Run("total", func(t *testing.T) {
+		l := newConnectionLimiter(2, 0)
+
+		release1, err := l.acquire("1.1.1.1")
+		if err != nil {
+			t.Fatalf("first connection: %v", err)
+		}
+
+		if _, err := l.acquire("2.2.2.2"); err != nil {
+			t.Fatalf("second connection: %v", err)
+		}
+
+		if _, err := l.acquire("3.3.3.3"); err == nil {
+			t.Fatalf("third connection was allowed")
+		}
+
+		release1()
+		release1()
+
+		if _, err := l.acquire("3.3.3.3"); err != nil {
+			t.Errorf("connection after release: %v", err)
+		}
+
+		if _, err := l.acquire("4.4.4.4"); err == nil {
+			t.Errorf("calling release twice freed two connections")
+		}
+	})
+
+	t.Run("per ip", func(t *testing.T) {
+		l := newConnectionLimiter(0, 1)
+
+		if _, err := l.acquire("1.1.1.1"); err != nil {
+			t.Fatalf("first connection: %v", err)
+		}
+
+		if _, err := l.acquire("1.1.1.1"); err == nil {
+			t.Errorf("second connection from same ip was allowed")
+		}
+
+		if _, err := l.acquire("2.2.2.2"); err != nil {
+			t.Errorf("connection from other ip: %v", err)
+		}
+	})
+}
+
+func TestConnectionLimitMiddleware(t *testing.T) {
+	l := newConnectionLimiter(1, 0)
+	if _, err := l.acquire("1.1.1.1"); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	handler := connectionLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), l)
+
+	t.Run("stream", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate?k=user/1/username", nil))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("got status %d, expected 503", rec.Code)
+		}
+
+		if rec.Header().Get("Retry-After") == "" {
+			t.Errorf("no Retry-After header")
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate?k=user/1/username&single=1", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("got status %d, expected 200", rec.Code)
+		}
+	})
+}
+
+func TestMemoryThrottleMiddleware(t *testing.T) {
+	throttled := true
+	handler := memoryThrottleMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), func() bool { return throttled })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate?k=user/1/username", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("throttled: got status %d, expected 503", rec.Code)
+	}
+
+	if rec.Header().Get("Retry-After") == "" {
+		t.Errorf("throttled: no Retry-After header")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate?k=user/1/username&single=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("single: got status %d, expected 200", rec.Code)
+	}
+
+	throttled = false
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate?k=user/1/username", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("not throttled: got status %d, expected 200", rec.Code)
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envCORSAllowedOrigins   = environment.NewVariable("AUTOUPDATE_CORS_ALLOWED_ORIGINS", "", "Comma separated list of origins that are allowed to do cross-origin requests. Use `*` to allow all origins, which can not be used with credentials. Empty disables CORS.")
+	envCORSAllowCredentials = environment.NewVariable("AUTOUPDATE_CORS_ALLOW_CREDENTIALS", "false", "Allow cross-origin requests to send credentials.")
+	envCORSMaxAge           = environment.NewVariable("AUTOUPDATE_CORS_MAX_AGE", "10m", "Time a browser is allowed to cache the result of a preflight request.")
+)
+
+// CORS adds the cross-origin resource sharing headers to responses.
+//
+// Has to be initialized with NewCORS().
+type CORS struct {
+	enabled  bool
+	settings atomic.Pointer[corsSettings]
+}
+
+type corsSettings struct {
+	allowAll         bool
+	allowedOrigins   map[string]struct{}
+	allowCredentials bool
+	maxAge           time.Duration
+}
+
+// NewCORS initializes the CORS settings from the environment.
+func NewCORS(lookup environment.Environmenter) (*CORS, error) {
+	settings, err := parseCORS(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	var c CORS
+	c.enabled = settings.allowAll || len(settings.allowedOrigins) > 0
+	c.settings.Store(settings)
+	return &c, nil
+}
+
+func parseCORS(lookup environment.Environmenter) (*corsSettings, error) {
+	allowCredentials, err := strconv.ParseBool(envCORSAllowCredentials.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envCORSAllowCredentials.Key, err)
+	}
+
+	maxAge, err := environment.ParseDuration(envCORSMaxAge.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envCORSMaxAge.Key, envCORSMaxAge.Value(lookup), err)
+	}
+
+	c := corsSettings{
+		allowedOrigins:   make(map[string]struct{}),
+		allowCredentials: allowCredentials,
+		maxAge:           maxAge,
+	}
+
+	for _, origin := range strings.Split(envCORSAllowedOrigins.Value(lookup), ",") {
+		origin = strings.TrimSpace(origin)
+		switch origin {
+		case "":
+			continue
+		case "*":
+			c.allowAll = true
+		default:
+			c.allowedOrigins[strings.TrimSuffix(origin, "/")] = struct{}{}
+		}
+	}
+
+	if c.allowAll && c.allowCredentials {
+		// A browser does not send credentials to the wildcard. Allowing them
+		// would mean to reflect every origin, so every website could use the
+		// session of a user.
+		return nil, fmt.Errorf("`%s` can not be `*`, when `%s` is true", envCORSAllowedOrigins.Key, envCORSAllowCredentials.Key)
+	}
+
+	return &c, nil
+}
+
+// Reload reads the CORS settings from the environment. The returned function
+// sets them.
+//
+// If CORS was disabled when the middleware was created, it stays disabled.
+func (c *CORS) Reload(lookup environment.Environmenter) (func(), error) {
+	settings, err := parseCORS(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { c.settings.Store(settings) }, nil
+}
+
+// Middleware returns a handler that sets the CORS headers and answers
+// preflight requests.
+//
+// If no origin is configured, next is returned unchanged.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	if !c.enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := c.settings.Load()
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if !c.allowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowOrigin := origin
+		if c.allowAll {
+			allowOrigin = "*"
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+		if c.allowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			// Preflight request.
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Authentication, Content-Type, "+headerCapabilities)
+			if c.maxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.maxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", "Authentication, "+headerProtocol+", "+headerProfile)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *corsSettings) allowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+
+	_, ok := c.allowedOrigins[origin]
+	return ok
+}
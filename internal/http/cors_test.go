@@ -0,0 +1,169 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+
+	for _, tt := range []struct {
+		name        string
+		env         environment.ForTests
+		method      string
+		origin      string
+		preflight   bool
+		expectAllow string
+		expectCred  string
+		expectAge   string
+		expectCode  int
+	}{
+		{
+			name:        "Disabled",
+			env:         environment.ForTests{},
+			method:      "GET",
+			origin:      "https://example.com",
+			expectAllow: "",
+			expectCode:  200,
+		},
+		{
+			name:        "Allowed origin",
+			env:         environment.ForTests{"AUTOUPDATE_CORS_ALLOWED_ORIGINS": "https://example.com, https://other.com"},
+			method:      "GET",
+			origin:      "https://example.com",
+			expectAllow: "https://example.com",
+			expectCode:  200,
+		},
+		{
+			name:        "Unknown origin",
+			env:         environment.ForTests{"AUTOUPDATE_CORS_ALLOWED_ORIGINS": "https://example.com"},
+			method:      "GET",
+			origin:      "https://evil.com",
+			expectAllow: "",
+			expectCode:  200,
+		},
+		{
+			name:        "Wildcard",
+			env:         environment.ForTests{"AUTOUPDATE_CORS_ALLOWED_ORIGINS": "*"},
+			method:      "GET",
+			origin:      "https://example.com",
+			expectAllow: "*",
+			expectCode:  200,
+		},
+		{
+			name: "Credentials",
+			env: environment.ForTests{
+				"AUTOUPDATE_CORS_ALLOWED_ORIGINS":   "https://example.com",
+				"AUTOUPDATE_CORS_ALLOW_CREDENTIALS": "true",
+			},
+			method:      "GET",
+			origin:      "https://example.com",
+			expectAllow: "https://example.com",
+			expectCred:  "true",
+			expectCode:  200,
+		},
+		{
+			name: "Preflight",
+			env: environment.ForTests{
+				"AUTOUPDATE_CORS_ALLOWED_ORIGINS": "https://example.com",
+				"AUTOUPDATE_CORS_MAX_AGE":         "1m",
+			},
+			method:      "OPTIONS",
+			origin:      "https://example.com",
+			preflight:   true,
+			expectAllow: "https://example.com",
+			expectAge:   "60",
+			expectCode:  204,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cors, err := ahttp.NewCORS(tt.env)
+			if err != nil {
+				t.Fatalf("NewCORS: %v", err)
+			}
+
+			req := httptest.NewRequest(tt.method, "/system/autoupdate", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			rec := httptest.NewRecorder()
+
+			cors.Middleware(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectCode {
+				t.Errorf("Got status %d, expected %d", rec.Code, tt.expectCode)
+			}
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.expectAllow {
+				t.Errorf("Got Access-Control-Allow-Origin `%s`, expected `%s`", got, tt.expectAllow)
+			}
+
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.expectCred {
+				t.Errorf("Got Access-Control-Allow-Credentials `%s`, expected `%s`", got, tt.expectCred)
+			}
+
+			if got := rec.Header().Get("Access-Control-Max-Age"); got != tt.expectAge {
+				t.Errorf("Got Access-Control-Max-Age `%s`, expected `%s`", got, tt.expectAge)
+			}
+		})
+	}
+}
+
+func TestCORSWildcardWithCredentials(t *testing.T) {
+	env := environment.ForTests{
+		"AUTOUPDATE_CORS_ALLOWED_ORIGINS":   "*",
+		"AUTOUPDATE_CORS_ALLOW_CREDENTIALS": "true",
+	}
+
+	if _, err := ahttp.NewCORS(env); err == nil {
+		t.Errorf("NewCORS with wildcard and credentials did not return an error")
+	}
+}
+
+func TestCORSReload(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	cors, err := ahttp.NewCORS(environment.ForTests{"AUTOUPDATE_CORS_ALLOWED_ORIGINS": "https://example.com"})
+	if err != nil {
+		t.Fatalf("NewCORS: %v", err)
+	}
+	handler := cors.Middleware(next)
+
+	allowOrigin := func(origin string) string {
+		req := httptest.NewRequest("GET", "/system/autoupdate", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowOrigin("https://other.com"); got != "" {
+		t.Fatalf("other origin was allowed before the reload")
+	}
+
+	apply, err := cors.Reload(environment.ForTests{"AUTOUPDATE_CORS_ALLOWED_ORIGINS": "https://other.com"})
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	apply()
+
+	if got := allowOrigin("https://other.com"); got != "https://other.com" {
+		t.Errorf("Got Access-Control-Allow-Origin `%s` after the reload, expected `https://other.com`", got)
+	}
+
+	if got := allowOrigin("https://example.com"); got != "" {
+		t.Errorf("removed origin is still allowed after the reload")
+	}
+
+	if _, err := cors.Reload(environment.ForTests{"AUTOUPDATE_CORS_MAX_AGE": "forever"}); err == nil {
+		t.Errorf("Reload with invalid max age did not return an error")
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	_ "embed" // Needed for the dashboard page.
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/introspect"
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// HandleDashboard registers a html page, that shows the state of the service.
+//
+// The page shows the connections, the update throughput, the cache hit rate
+// and the recent errors. It fetches its data every few seconds from
+// /debug/dashboard/data.
+//
+// The page itself contains no data and is served without authentication. The
+// data route needs an authenticated superadmin. The page sends the access
+// token, that can be entered on the page.
+//
+// /debug/dashboard
+// /debug/dashboard/data
+func HandleDashboard(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+		w.Write(dashboardPage)
+	})
+
+	data := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+		if err := json.NewEncoder(w).Encode(introspect.Collect()); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding dashboard data: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/dashboard", routeMiddleware(page, routeProfile))
+	mux.Handle("/debug/dashboard/data", routeMiddleware(authMiddleware(profileAuthMiddleware(data, auth, profiler), auth), routeProfile))
+}
+
+// connectionInfo returns the open connections of this instance for the
+// dashboard.
+func connectionInfo(connectionCount [2]*ConnectionCount, meetings *MeetingMetric) map[string]any {
+	streamUsers, streamConnections := connectionCount[0].local()
+	longpollingUsers, longpollingConnections := connectionCount[1].local()
+
+	return map[string]any{
+		"stream": map[string]int{
+			"users":       streamUsers,
+			"connections": streamConnections,
+		},
+		"longpolling": map[string]int{
+			"users":       longpollingUsers,
+			"connections": longpollingConnections,
+		},
+		"meetings": meetings.connections(),
+	}
+}
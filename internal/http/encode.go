@@ -0,0 +1,169 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxPooledBuffer is the capacity of a buffer, that is still put back into the
+// pool. Bigger buffers, for example from the first response of a large
+// meeting, are left to the garbage collector, so the pool does not keep them
+// forever.
+const maxPooledBuffer = 1 << 22
+
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer gives the buffer back to the pool. The buffer can not be used
+// afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// encodeState are the reusable values of one call to writeData.
+type encodeState struct {
+	json    bytes.Buffer
+	scratch bytes.Buffer
+	names   []byte
+	keys    []encodeKey
+}
+
+// encodeKey is a key with the position of its string form in
+// encodeState.names.
+type encodeKey struct {
+	key        dskey.Key
+	start, end int
+}
+
+var encodeStatePool = sync.Pool{
+	New: func() any { return new(encodeState) },
+}
+
+// zstdEncoder compresses the responses. EncodeAll can be called concurrently
+// and reuses the internal state of the encoder.
+var zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil)
+})
+
+// writeData writes the data as one json object to w.
+//
+// The keys are sorted and each value is compacted and html escaped, so the
+// output is the same as from json.Encoder. All buffers come from a pool and
+// the data is written with one call to w.Write.
+func writeData(w io.Writer, data map[dskey.Key][]byte, compress bool) error {
+	state := encodeStatePool.Get().(*encodeState)
+	defer func() {
+		if state.json.Cap() > maxPooledBuffer || state.scratch.Cap() > maxPooledBuffer {
+			return
+		}
+		state.json.Reset()
+		state.scratch.Reset()
+		state.names = state.names[:0]
+		state.keys = state.keys[:0]
+		encodeStatePool.Put(state)
+	}()
+
+	if err := state.encode(data); err != nil {
+		return fmt.Errorf("encode data: %w", err)
+	}
+
+	out := state.json.Bytes()
+	if compress {
+		encoder, err := zstdEncoder()
+		if err != nil {
+			return fmt.Errorf("create encoder: %w", err)
+		}
+
+		state.scratch.Reset()
+		state.scratch.Write(encoder.EncodeAll(out, state.scratch.AvailableBuffer()))
+
+		state.json.Reset()
+		state.json.Write(base64.RawStdEncoding.AppendEncode(state.json.AvailableBuffer(), state.scratch.Bytes()))
+		state.json.WriteByte('\n')
+		out = state.json.Bytes()
+	}
+
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("write data: %w", err)
+	}
+	return nil
+}
+
+// writeLine writes an encoded json object as one line to w. With compress, it
+// is compressed like the output of writeData.
+func writeLine(w io.Writer, message []byte, compress bool) error {
+	out := make([]byte, 0, len(message)+1)
+	out = append(out, message...)
+	out = append(out, '\n')
+
+	if compress {
+		encoder, err := zstdEncoder()
+		if err != nil {
+			return fmt.Errorf("create encoder: %w", err)
+		}
+
+		out = append(base64.RawStdEncoding.AppendEncode(nil, encoder.EncodeAll(out, nil)), '\n')
+	}
+
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("write data: %w", err)
+	}
+	return nil
+}
+
+// encode writes the data as json into s.json.
+func (s *encodeState) encode(data map[dskey.Key][]byte) error {
+	for key := range data {
+		start := len(s.names)
+		s.names = key.AppendString(s.names)
+		s.keys = append(s.keys, encodeKey{key: key, start: start, end: len(s.names)})
+	}
+	slices.SortFunc(s.keys, func(a, b encodeKey) int {
+		return bytes.Compare(s.names[a.start:a.end], s.names[b.start:b.end])
+	})
+
+	s.json.WriteByte('{')
+	for i, k := range s.keys {
+		name := s.names[k.start:k.end]
+		if i > 0 {
+			s.json.WriteByte(',')
+		}
+
+		// The keys only contain letters, numbers, underscores and slashes.
+		s.json.WriteByte('"')
+		s.json.Write(name)
+		s.json.WriteString(`":`)
+
+		value := data[k.key]
+		if value == nil {
+			s.json.WriteString("null")
+			continue
+		}
+
+		s.scratch.Reset()
+		if err := json.Compact(&s.scratch, value); err != nil {
+			return fmt.Errorf("key %s: %w", name, err)
+		}
+		json.HTMLEscape(&s.json, s.scratch.Bytes())
+	}
+	s.json.WriteString("}\n")
+	return nil
+}
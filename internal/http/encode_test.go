@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestWriteDataLikeJSONEncoder(t *testing.T) {
+	data := map[dskey.Key][]byte{
+		dskey.MustKey("motion/1/title"):   []byte(`"<b>Motion & more</b>"`),
+		dskey.MustKey("motion/1/text"):    []byte("{\n  \"a\": [1, 2]\n}"),
+		dskey.MustKey("motion/10/title"):  []byte(`"ten"`),
+		dskey.MustKey("motion/2/title"):   nil,
+		dskey.MustKey("user/1/username"):  []byte(`"admin"`),
+		dskey.MustKey("user/1/is_active"): []byte(`true`),
+	}
+
+	converted := make(map[string]json.RawMessage, len(data))
+	for k, v := range data {
+		converted[k.String()] = v
+	}
+	expect := new(bytes.Buffer)
+	if err := json.NewEncoder(expect).Encode(converted); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	// Run twice to use the pooled buffers.
+	for i := 0; i < 2; i++ {
+		buf := new(bytes.Buffer)
+		if err := writeData(buf, data, false); err != nil {
+			t.Fatalf("writeData: %v", err)
+		}
+
+		if got := buf.String(); got != expect.String() {
+			t.Errorf("run %d: got\n%s\nexpected\n%s", i, got, expect)
+		}
+	}
+}
+
+func TestWriteDataCompressed(t *testing.T) {
+	data := map[dskey.Key][]byte{
+		dskey.MustKey("user/1/username"): []byte(`"admin"`),
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeData(buf, data, true); err != nil {
+		t.Fatalf("writeData: %v", err)
+	}
+
+	line, ok := bytes.CutSuffix(buf.Bytes(), []byte("\n"))
+	if !ok {
+		t.Fatalf("response does not end with a newline: %q", buf)
+	}
+
+	compressed, err := base64.RawStdEncoding.DecodeString(string(line))
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("create decoder: %v", err)
+	}
+	defer decoder.Close()
+
+	got, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+
+	if expect := `{"user/1/username":"admin"}` + "\n"; string(got) != expect {
+		t.Errorf("got %q, expected %q", got, expect)
+	}
+}
+
+func TestWriteDataInvalidJSON(t *testing.T) {
+	data := map[dskey.Key][]byte{
+		dskey.MustKey("user/1/username"): []byte(`"admin`),
+	}
+
+	if err := writeData(new(bytes.Buffer), data, false); err == nil {
+		t.Errorf("writeData returned no error")
+	}
+}
+
+func BenchmarkWriteData(b *testing.B) {
+	data := make(map[dskey.Key][]byte, 1000)
+	for i := 1; i <= 1000; i++ {
+		data[dskey.MustKey(fmt.Sprintf("motion/%d/title", i))] = []byte(fmt.Sprintf(`"Motion %d"`, i))
+	}
+
+	for _, compress := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compress=%t", compress), func(b *testing.B) {
+			buf := new(bytes.Buffer)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := writeData(buf, data, compress); err != nil {
+					b.Fatalf("writeData: %v", err)
+				}
+			}
+		})
+	}
+}
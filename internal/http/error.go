@@ -1,6 +1,9 @@
 package http
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+)
 
 type invalidRequestError struct {
 	err error
@@ -13,3 +16,91 @@ func (e invalidRequestError) Error() string {
 func (e invalidRequestError) Type() string {
 	return "invalid_request"
 }
+
+type tooManyRequestsError struct{}
+
+func (e tooManyRequestsError) Error() string {
+	return "Too many requests. Please try again later."
+}
+
+func (e tooManyRequestsError) Type() string {
+	return "too_many_requests"
+}
+
+func (e tooManyRequestsError) StatusCode() int {
+	return 429
+}
+
+type bodyTooLargeError struct {
+	limit int64
+}
+
+func (e bodyTooLargeError) Error() string {
+	return fmt.Sprintf("The request body is larger then %d bytes.", e.limit)
+}
+
+func (e bodyTooLargeError) Type() string {
+	return "body_too_large"
+}
+
+func (e bodyTooLargeError) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+type notFoundError struct{}
+
+func (e notFoundError) Error() string {
+	return "The requested route does not exist."
+}
+
+func (e notFoundError) Type() string {
+	return "not_found"
+}
+
+func (e notFoundError) StatusCode() int {
+	return http.StatusNotFound
+}
+
+type permissionDeniedError struct {
+	msg string
+}
+
+func (e permissionDeniedError) Error() string {
+	return e.msg
+}
+
+func (e permissionDeniedError) Type() string {
+	return "permission_denied"
+}
+
+type overloadedError struct {
+	msg string
+}
+
+func (e overloadedError) Error() string {
+	return e.msg
+}
+
+func (e overloadedError) Type() string {
+	return "overloaded"
+}
+
+func (e overloadedError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+type unknownOrganizationError struct {
+	host string
+}
+
+func (e unknownOrganizationError) Error() string {
+	return fmt.Sprintf("The host %s is no organization of this service.", e.host)
+}
+
+func (e unknownOrganizationError) Type() string {
+	return "unknown_organization"
+}
+
+func (e unknownOrganizationError) StatusCode() int {
+	return http.StatusMisdirectedRequest
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/errorreport"
+)
+
+// errorReportMiddleware saves the request for the error reports.
+//
+// The error handlers only get the response writer. So the context is saved in
+// the writer. Has to be called inside the requestIDMiddleware.
+func errorReportMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !errorreport.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := errorreport.WithRequest(r.Context(), r)
+		next.ServeHTTP(&reportWriter{ResponseWriter: w, ctx: ctx}, r.WithContext(ctx))
+	})
+}
+
+// reportWriter is a http.ResponseWriter that holds the context of the request.
+type reportWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+// Flush implements the http.Flusher interface.
+func (w *reportWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap is used by http.ResponseController.
+func (w *reportWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// reportError sends an unexpected error to the error reporting.
+//
+// The report contains the request, the request id, the route and the user.
+func reportError(w http.ResponseWriter, err error) {
+	if !errorreport.Enabled() {
+		return
+	}
+
+	ctx := context.Background()
+	for w != nil {
+		if rw, ok := w.(*reportWriter); ok {
+			ctx = rw.ctx
+			break
+		}
+
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = unwrapper.Unwrap()
+	}
+
+	tags := make(map[string]string)
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		tags["request_id"] = info.requestID
+		if info.route != "" {
+			tags["route"] = info.route
+		}
+		if info.hasUser {
+			tags["user_id"] = strconv.Itoa(info.userID)
+		}
+	}
+
+	errorreport.Capture(ctx, err, tags)
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/errorreport"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestErrorReport(t *testing.T) {
+	var mu sync.Mutex
+	var reports []string
+	sentry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		reports = append(reports, string(body))
+		mu.Unlock()
+	}))
+	defer sentry.Close()
+
+	dsn := strings.Replace(sentry.URL, "http://", "http://public@", 1) + "/1"
+	background, err := errorreport.New(environment.ForTests{"SENTRY_DSN": dsn})
+	if err != nil {
+		t.Fatalf("init error reporting: %v", err)
+	}
+	defer errorreport.New(environment.ForTests{})
+
+	handler := requestIDMiddleware(errorReportMiddleware(routeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setUserForLog(r.Context(), 42)
+		if r.URL.Query().Has("client") {
+			handleErrorWithStatus(w, invalidRequestError{errors.New("client error")})
+			return
+		}
+		handleErrorWithStatus(w, errors.New("server error"))
+	}), routeHistory)))
+
+	for _, query := range []string{"", "?client"} {
+		req := httptest.NewRequest("GET", "/system/autoupdate/history_information"+query, nil)
+		req.Header.Set(requestIDHeader, "my-id")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	background(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	mu.Lock()
+	got := strings.Join(reports, "\n")
+	mu.Unlock()
+
+	for _, expect := range []string{`"value":"server error"`, `"request_id":"my-id"`, `"route":"history"`, `"user_id":"42"`} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("report does not contain `%s`:\n%s", expect, got)
+		}
+	}
+
+	// The report contains the source code around the call. So the message of
+	// the error is checked and not only the text.
+	if strings.Contains(got, `"value":"Invalid request: client error"`) {
+		t.Errorf("client error was reported")
+	}
+}
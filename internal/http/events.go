@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/lifecycle"
+)
+
+var (
+	// errServerStopped is the cause of the request contexts, when the server
+	// stops and the drain timeout is over.
+	errServerStopped = errors.New("server stopped")
+
+	// errRestart is the cause of the server context, when a new process took
+	// over the listeners.
+	errRestart = errors.New("restart")
+)
+
+// connectionEvents sends the lifecycle events of one autoupdate connection.
+//
+// All methods can be called on nil. In this case, they do nothing.
+type connectionEvents struct {
+	base       lifecycle.Event
+	start      time.Time
+	subscribed bool
+}
+
+// newConnectionEvents sends the connect event. Returns nil, if the events are
+// disabled.
+func newConnectionEvents(ctx context.Context, uid int) *connectionEvents {
+	if !lifecycle.Enabled() {
+		return nil
+	}
+
+	c := &connectionEvents{
+		base: lifecycle.Event{
+			RequestID: RequestIDFromContext(ctx),
+			UserID:    uid,
+			ClientIP:  ClientIPFromContext(ctx),
+		},
+		start: time.Now(),
+	}
+
+	c.emit(lifecycle.Event{Type: lifecycle.Connect})
+	return c
+}
+
+func (c *connectionEvents) emit(e lifecycle.Event) {
+	e.RequestID = c.base.RequestID
+	e.UserID = c.base.UserID
+	e.ClientIP = c.base.ClientIP
+	if e.MeetingID == 0 {
+		e.MeetingID = c.base.MeetingID
+	}
+	lifecycle.Emit(e)
+}
+
+// subscribe sends the subscribe event after the first data was written. Only
+// the first call does something.
+func (c *connectionEvents) subscribe(keys int, meetingID int) {
+	if c == nil || c.subscribed {
+		return
+	}
+	c.subscribed = true
+
+	c.base.MeetingID = meetingID
+	c.emit(lifecycle.Event{
+		Type:       lifecycle.Subscribe,
+		Keys:       keys,
+		DurationMS: time.Since(c.start).Milliseconds(),
+	})
+}
+
+// written sends the slow consumer event, if writing a message took too long.
+func (c *connectionEvents) written(duration time.Duration) {
+	if c == nil || !lifecycle.IsSlowConsumer(duration) {
+		return
+	}
+
+	c.emit(lifecycle.Event{
+		Type:       lifecycle.SlowConsumer,
+		DurationMS: duration.Milliseconds(),
+	})
+}
+
+// disconnect sends the disconnect event with the reason, why the connection
+// was closed.
+func (c *connectionEvents) disconnect(ctx context.Context, err error) {
+	if c == nil {
+		return
+	}
+
+	reason, errMsg := disconnectReason(ctx, err)
+	c.emit(lifecycle.Event{
+		Type:       lifecycle.Disconnect,
+		DurationMS: time.Since(c.start).Milliseconds(),
+		Reason:     reason,
+		Error:      errMsg,
+	})
+}
+
+// disconnectReason returns the reason, why a connection with the given context
+// and error was closed. If the reason is an error, its message is returned.
+func disconnectReason(ctx context.Context, err error) (string, string) {
+	switch {
+	case errors.Is(err, errHandoff):
+		return lifecycle.ReasonHandoff, ""
+	case errors.Is(context.Cause(ctx), errServerStopped):
+		return lifecycle.ReasonShutdown, ""
+	case ctx.Err() != nil:
+		return lifecycle.ReasonClientClosed, ""
+	case err != nil:
+		return lifecycle.ReasonError, err.Error()
+	default:
+		return lifecycle.ReasonClientClosed, ""
+	}
+}
+
+// emitDrain sends the drain event, when the context is done.
+func emitDrain(ctx context.Context, drain time.Duration) {
+	<-ctx.Done()
+
+	reason := lifecycle.ReasonShutdown
+	if errors.Is(context.Cause(ctx), errRestart) {
+		reason = lifecycle.ReasonRestart
+	}
+
+	lifecycle.Emit(lifecycle.Event{
+		Type:       lifecycle.Drain,
+		DurationMS: drain.Milliseconds(),
+		Reason:     reason,
+	})
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/lifecycle"
+)
+
+func TestDisconnectReason(t *testing.T) {
+	stopped, cancelStopped := context.WithCancelCause(context.Background())
+	cancelStopped(errServerStopped)
+
+	closed, cancelClosed := context.WithCancel(context.Background())
+	cancelClosed()
+
+	for _, tt := range []struct {
+		name   string
+		ctx    context.Context
+		err    error
+		reason string
+		errMsg string
+	}{
+		{"server stopped", stopped, context.Canceled, lifecycle.ReasonShutdown, ""},
+		{"client closed", closed, context.Canceled, lifecycle.ReasonClientClosed, ""},
+		{"error", context.Background(), errors.New("broken"), lifecycle.ReasonError, "broken"},
+		{"no error", context.Background(), nil, lifecycle.ReasonClientClosed, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, errMsg := disconnectReason(tt.ctx, tt.err)
+			if reason != tt.reason || errMsg != tt.errMsg {
+				t.Errorf("got (%q, %q), expected (%q, %q)", reason, errMsg, tt.reason, tt.errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// DataExporter returns the restricted data of a request once.
+type DataExporter interface {
+	SingleData(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (map[dskey.Key][]byte, error)
+}
+
+// HandleExport registers the route to download the data of an autoupdate
+// request. The request is evaluated once with the permissions of the user.
+//
+// With `format=json` (default), the response is a json object with a list of
+// objects for each collection. With `format=csv`, the response is a csv file
+// with one row for each object of a collection. If the data contains more then
+// one collection, the collection has to be selected with `collection`.
+//
+// /system/autoupdate/export?format=csv&collection=user
+func HandleExport(mux *http.ServeMux, auth Authenticater, exporter DataExporter, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid := auth.FromContext(ctx)
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+
+		if format != "json" && format != "csv" {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("format has to be json or csv, not `%s`", format)})
+			return
+		}
+
+		request, err := parseAutoupdateRequest(r)
+		if err != nil {
+			handleErrorWithStatus(w, invalidRequestError{err})
+			return
+		}
+
+		if len(request.remote) > 0 {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("data of remote instances can not be exported")})
+			return
+		}
+
+		data, err := exporter.SingleData(ctx, uid, request.builder)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("getting data: %w", err))
+			return
+		}
+
+		tables := exportTables(data)
+
+		if format == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", `attachment; filename="export.json"`)
+			if err := writeExportJSON(w, tables); err != nil {
+				handleErrorWithoutStatus(w, fmt.Errorf("writing json: %w", err))
+			}
+			return
+		}
+
+		collection := r.URL.Query().Get("collection")
+		if collection == "" {
+			if len(tables) > 1 {
+				collections := make([]string, 0, len(tables))
+				for c := range tables {
+					collections = append(collections, c)
+				}
+				sort.Strings(collections)
+				handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("the data has the collections %s, select one with `collection`", strings.Join(collections, ", "))})
+				return
+			}
+
+			for c := range tables {
+				collection = c
+			}
+		}
+
+		table := tables[collection]
+		if table == nil {
+			table = &exportTable{}
+		}
+
+		filename := "export.csv"
+		if collection != "" {
+			filename = collection + ".csv"
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		if err := writeExportCSV(w, table); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("writing csv: %w", err))
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/export",
+		routeMiddleware(
+			validRequest(authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeAutoupdate)), auth)),
+			routeAutoupdate,
+		),
+	)
+}
+
+// exportTable is the data of one collection.
+type exportTable struct {
+	fields []string
+	rows   map[int]map[string]json.RawMessage
+}
+
+// ids returns the ids of the objects in ascending order.
+func (t *exportTable) ids() []int {
+	ids := make([]int, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// exportTables groups the data by collection. Keys without a value are
+// skipped.
+func exportTables(data map[dskey.Key][]byte) map[string]*exportTable {
+	tables := make(map[string]*exportTable)
+	for key, value := range data {
+		if value == nil {
+			continue
+		}
+
+		table, ok := tables[key.Collection()]
+		if !ok {
+			table = &exportTable{rows: make(map[int]map[string]json.RawMessage)}
+			tables[key.Collection()] = table
+		}
+
+		row, ok := table.rows[key.ID()]
+		if !ok {
+			row = make(map[string]json.RawMessage)
+			table.rows[key.ID()] = row
+		}
+
+		if key.Field() != "id" && !slices.Contains(table.fields, key.Field()) {
+			table.fields = append(table.fields, key.Field())
+		}
+		row[key.Field()] = value
+	}
+
+	for _, table := range tables {
+		sort.Strings(table.fields)
+	}
+	return tables
+}
+
+// writeExportJSON writes an object with a list of objects for each collection.
+// Each object has its id.
+func writeExportJSON(w io.Writer, tables map[string]*exportTable) error {
+	out := make(map[string][]map[string]json.RawMessage, len(tables))
+	for collection, table := range tables {
+		objects := make([]map[string]json.RawMessage, 0, len(table.rows))
+		for _, id := range table.ids() {
+			object := table.rows[id]
+			object["id"] = []byte(strconv.Itoa(id))
+			objects = append(objects, object)
+		}
+		out[collection] = objects
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+// writeExportCSV writes the objects of one collection. The first column is the
+// id, the other columns are the fields in alphabetical order.
+func writeExportCSV(w io.Writer, table *exportTable) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"id"}, table.fields...)); err != nil {
+		return err
+	}
+
+	record := make([]string, len(table.fields)+1)
+	for _, id := range table.ids() {
+		record[0] = strconv.Itoa(id)
+		for i, field := range table.fields {
+			record[i+1] = csvValue(table.rows[id][field])
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvValue converts a json value to the value of a csv cell. Strings are
+// written without quotes, lists and objects as json.
+//
+// Values, that a spreadsheet would run as formula, get the prefix `'`.
+func csvValue(raw json.RawMessage) string {
+	if raw == nil || string(raw) == "null" {
+		return ""
+	}
+
+	value := string(raw)
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		value = str
+	}
+
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			value = "'" + value
+		}
+	}
+	return value
+}
@@ -0,0 +1,110 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+type exportStub struct {
+	data  map[dskey.Key][]byte
+	uid   int
+	calls int
+}
+
+func (e *exportStub) SingleData(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (map[dskey.Key][]byte, error) {
+	e.uid = userID
+	e.calls++
+	return e.data, nil
+}
+
+func TestExport(t *testing.T) {
+	stub := &exportStub{data: map[dskey.Key][]byte{
+		dskey.MustKey("user/2/username"):    []byte(`"max"`),
+		dskey.MustKey("user/2/is_active"):   []byte(`true`),
+		dskey.MustKey("user/1/username"):    []byte(`"admin"`),
+		dskey.MustKey("user/1/meeting_ids"): []byte(`[1,2]`),
+		dskey.MustKey("user/3/username"):    nil,
+		dskey.MustKey("user/2/first_name"):  []byte(`"=HYPERLINK(\"x\")"`),
+		dskey.MustKey("user/2/last_name"):   []byte(`"-5"`),
+	}}
+
+	mux := http.NewServeMux()
+	ahttp.HandleExport(mux, fakeAuth(1), stub, ahttp.Config{})
+
+	t.Run("json", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("POST", "/system/autoupdate/export", strings.NewReader(`[{"collection":"user","ids":[1,2],"fields":{"username":null}}]`)))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200: %s", resp.Code, resp.Body.String())
+		}
+
+		expect := `{"user":[{"id":1,"meeting_ids":[1,2],"username":"admin"},{"first_name":"=HYPERLINK(\"x\")","id":2,"is_active":true,"last_name":"-5","username":"max"}]}`
+		if got := strings.TrimSpace(resp.Body.String()); got != expect {
+			t.Errorf("got body %s, expected %s", got, expect)
+		}
+
+		if got := resp.Header().Get("Content-Disposition"); got != `attachment; filename="export.json"` {
+			t.Errorf("got Content-Disposition %q", got)
+		}
+
+		if stub.uid != 1 || stub.calls != 1 {
+			t.Errorf("SingleData was called %d times with uid %d, expected once with uid 1", stub.calls, stub.uid)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/export?format=csv&k=user/1/username", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200: %s", resp.Code, resp.Body.String())
+		}
+
+		expect := "id,first_name,is_active,last_name,meeting_ids,username\n" +
+			"1,,,,\"[1,2]\",admin\n" +
+			"2,\"'=HYPERLINK(\"\"x\"\")\",true,-5,,max\n"
+		if got := resp.Body.String(); got != expect {
+			t.Errorf("got body:\n%s\nexpected:\n%s", got, expect)
+		}
+
+		if got := resp.Header().Get("Content-Disposition"); got != `attachment; filename="user.csv"` {
+			t.Errorf("got Content-Disposition %q", got)
+		}
+	})
+}
+
+func TestExportCSVCollection(t *testing.T) {
+	stub := &exportStub{data: map[dskey.Key][]byte{
+		dskey.MustKey("user/1/username"): []byte(`"admin"`),
+		dskey.MustKey("motion/5/title"):  []byte(`"Budget"`),
+	}}
+
+	mux := http.NewServeMux()
+	ahttp.HandleExport(mux, fakeAuth(1), stub, ahttp.Config{})
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/export?format=csv&k=user/1/username", nil))
+	if resp.Code != 400 {
+		t.Errorf("csv with two collections: got status %d, expected 400", resp.Code)
+	}
+
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/export?format=csv&collection=motion&k=motion/5/title", nil))
+	if got := resp.Body.String(); got != "id,title\n5,Budget\n" {
+		t.Errorf("got body %q", got)
+	}
+
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/export?format=xml&k=motion/5/title", nil))
+	if resp.Code != 400 {
+		t.Errorf("unknown format: got status %d, expected 400", resp.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Federator streams the data of remote instances.
+type Federator interface {
+	Known(instance string) bool
+	Stream(ctx context.Context, instance string, authHeader string, body []byte, send func([]byte) error) error
+}
+
+// remoteRequest are the entries of an autoupdate request for remote instances.
+type remoteRequest struct {
+	federation Federator
+	authHeader string
+	bodies     map[string][]byte
+}
+
+// newRemoteRequest checks, that all instances of the request are known.
+// Returns nil, if the request has no remote entries.
+func newRemoteRequest(federation Federator, authHeader string, bodies map[string][]byte) (*remoteRequest, error) {
+	if len(bodies) == 0 {
+		return nil, nil
+	}
+
+	for instance := range bodies {
+		if federation == nil || !federation.Known(instance) {
+			return nil, invalidRequestError{fmt.Errorf("unknown instance `%s`", instance)}
+		}
+	}
+
+	return &remoteRequest{
+		federation: federation,
+		authHeader: authHeader,
+		bodies:     bodies,
+	}, nil
+}
+
+// start streams the data of the remote instances into the stream of the
+// notice. The messages are written with the lock of the notice.
+//
+// When a remote instance rejects the request, the returned context is
+// canceled with the error as cause. The returned function stops the streams.
+func (rr *remoteRequest) start(ctx context.Context, notice *maintenanceNotice, compress bool) (context.Context, func()) {
+	if rr == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	streamCtx, stopStreams := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	for instance, body := range rr.bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := rr.federation.Stream(streamCtx, instance, rr.authHeader, body, func(message []byte) error {
+				notice.mu.Lock()
+				defer notice.mu.Unlock()
+
+				if err := writeLine(notice.w, message, compress); err != nil {
+					return err
+				}
+				notice.w.(http.Flusher).Flush()
+				return nil
+			})
+			if err != nil && streamCtx.Err() == nil {
+				cancel(err)
+			}
+		}()
+	}
+
+	return ctx, func() {
+		stopStreams()
+		wg.Wait()
+		cancel(nil)
+	}
+}
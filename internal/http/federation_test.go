@@ -0,0 +1,111 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+type federatorMock struct {
+	body   chan string
+	header chan string
+}
+
+func (f federatorMock) Known(instance string) bool {
+	return instance == "b"
+}
+
+func (f federatorMock) Stream(ctx context.Context, instance string, authHeader string, body []byte, send func([]byte) error) error {
+	f.body <- string(body)
+	f.header <- authHeader
+
+	if err := send([]byte(`{"b:meeting/5/name":"Board"}`)); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestFederation(t *testing.T) {
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) {
+			return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}, true
+		},
+	}
+
+	federator := federatorMock{body: make(chan string, 1), header: make(chan string, 1)}
+
+	mux := http.NewServeMux()
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{Federation: federator})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	body := `[{"instance":"b","collection":"meeting","ids":[5],"fields":{"name":null}}]`
+	req, err := http.NewRequestWithContext(ctx, "POST", srv.URL+"/system/autoupdate", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Authentication", "bearer my-token")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() {
+		t.Fatalf("stream ended: %v", scanner.Err())
+	}
+
+	if got, expect := scanner.Text(), `{"b:meeting/5/name":"Board"}`; got != expect {
+		t.Errorf("got line %s, expected %s", got, expect)
+	}
+
+	if got, expect := <-federator.body, `[{"collection":"meeting","fields":{"name":null},"ids":[5]}]`; got != expect {
+		t.Errorf("remote got body %s, expected %s", got, expect)
+	}
+
+	if got := <-federator.header; got != "bearer my-token" {
+		t.Errorf("remote got header %s, expected the header of the client", got)
+	}
+}
+
+func TestFederationErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		query     string
+		federator ahttp.Federator
+		body      string
+	}{
+		{"unknown instance", "", federatorMock{}, `[{"instance":"c","collection":"meeting","ids":[5],"fields":{"name":null}}]`},
+		{"no federation", "", nil, `[{"instance":"b","collection":"meeting","ids":[5],"fields":{"name":null}}]`},
+		{"single", "?single=1", federatorMock{}, `[{"instance":"b","collection":"meeting","ids":[5],"fields":{"name":null}}]`},
+		{"longpolling", "?longpolling=1", federatorMock{}, `[{"instance":"b","collection":"meeting","ids":[5],"fields":{"name":null}}]`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			ahttp.HandleAutoupdate(mux, fakeAuth(1), &connecterMock{}, [2]*ahttp.ConnectionCount{}, ahttp.Config{Federation: tt.federator})
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("POST", "/system/autoupdate"+tt.query, strings.NewReader(tt.body)))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %s, expected 400: %s", http.StatusText(rec.Code), rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+type nullGetter struct{}
+
+func (nullGetter) Get(_ context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	return make(map[dskey.Key][]byte), nil
+}
+
+func FuzzParseAutoupdateRequest(f *testing.F) {
+	f.Add("", "k=user/1/username", `[{"ids":[1],"collection":"user","fields":{"username":null}}]`)
+	f.Add("application/json", "longpolling", `{"ids":[1],"collection":"user","fields":{"username":null}}`)
+	f.Add("multipart/form-data; boundary=xx", "", "--xx\r\n\r\n[]\r\n--xx\r\n\r\nhash\r\n--xx--\r\n")
+	f.Add("multipart/form-data", "", "")
+	f.Add("text/plain; charset", "k=", "null")
+
+	f.Fuzz(func(t *testing.T, contentType string, rawQuery string, body string) {
+		r := httptest.NewRequest("POST", "/system/autoupdate", strings.NewReader(body))
+		r.URL.RawQuery = rawQuery
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+
+		request, err := parseAutoupdateRequest(r)
+		if err != nil {
+			return
+		}
+
+		// Errors are expected. The test fails on a panic.
+		request.builder.Update(context.Background(), nullGetter{})
+	})
+}
+
+func FuzzParseHistoryQuery(f *testing.F) {
+	f.Add("fqid=motion/42&limit=10&since=2024-01-01T00:00:00Z")
+	f.Add("meeting_id=1&cursor=5&until=1700000000")
+	f.Add("fqid=motion/1,motion/2&from=1&to=20")
+	f.Add("timestamp=1700000000&meeting_id=1")
+	f.Add("meeting_id=-1&limit=99999999999999999999")
+	f.Add("%zz")
+
+	f.Fuzz(func(t *testing.T, rawQuery string) {
+		values, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return
+		}
+
+		if query, err := parseHistoryQuery(values); err == nil {
+			if query.FQID == "" && query.MeetingID == 0 {
+				t.Errorf("parseHistoryQuery(%q) returned a query without fqid and meeting", rawQuery)
+			}
+		}
+
+		if fqids, _, err := parseHistoryDiffQuery(values); err == nil && len(fqids) == 0 {
+			t.Errorf("parseHistoryDiffQuery(%q) returned no fqids", rawQuery)
+		}
+
+		if _, meetingID, err := parseHistoryPositionQuery(values); err == nil && meetingID < 0 {
+			t.Errorf("parseHistoryPositionQuery(%q) returned meeting %d", rawQuery, meetingID)
+		}
+	})
+}
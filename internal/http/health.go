@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// readinessTimeout is the time each readiness check has to finish.
+const readinessTimeout = 5 * time.Second
+
+var envReadinessChecks = environment.NewVariable("AUTOUPDATE_READINESS_CHECKS", "datastore,messagebus,auth", "Comma separated list of checks for the readiness route. Possible values are `datastore`, `messagebus`, `auth` and `cache`.")
+
+// ReadinessCheck returns an error, if a component of the service is not ready.
+type ReadinessCheck func(ctx context.Context) error
+
+// parseReadinessChecks reads the names of the readiness checks from the
+// environment.
+func parseReadinessChecks(lookup environment.Environmenter) []string {
+	var names []string
+	for _, name := range strings.Split(envReadinessChecks.Value(lookup), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// selectReadinessChecks returns the checks with the given names.
+func selectReadinessChecks(names []string, available map[string]ReadinessCheck) (map[string]ReadinessCheck, error) {
+	selected := make(map[string]ReadinessCheck, len(names))
+	for _, name := range names {
+		check, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown readiness check `%s` in `%s`", name, envReadinessChecks.Key)
+		}
+		selected[name] = check
+	}
+	return selected, nil
+}
+
+// HandleLiveness registers a route that tells, if the process is running.
+//
+// It does not check any dependency.
+func HandleLiveness(mux *http.ServeMux) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"alive": true}`)
+	})
+
+	mux.Handle(prefixPublic+"/healthz", routeMiddleware(handler, routeHealth))
+}
+
+// HandleReadiness registers a route that tells, if the service is ready to
+// handle requests.
+//
+// It runs all given checks. If one of them fails, the status 503 is returned.
+func HandleReadiness(mux *http.ServeMux, checks map[string]ReadinessCheck) {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		type result struct {
+			name string
+			err  error
+		}
+
+		results := make(chan result, len(names))
+		for _, name := range names {
+			go func(name string) {
+				results <- result{name: name, err: checks[name](ctx)}
+			}(name)
+		}
+
+		ready := true
+		status := make(map[string]string, len(names))
+		for range names {
+			res := <-results
+			status[res.name] = "ok"
+			if res.err != nil {
+				ready = false
+				status[res.name] = res.err.Error()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		json.NewEncoder(w).Encode(struct {
+			Ready  bool              `json:"ready"`
+			Checks map[string]string `json:"checks"`
+		}{ready, status})
+	})
+
+	mux.Handle(prefixPublic+"/readyz", routeMiddleware(handler, routeHealth))
+}
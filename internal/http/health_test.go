@@ -0,0 +1,87 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+)
+
+func TestLiveness(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleLiveness(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("got status %d, expected 200", rec.Code)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("not connected") }
+
+	for _, tt := range []struct {
+		name         string
+		checks       map[string]ahttp.ReadinessCheck
+		expectStatus int
+		expectChecks map[string]string
+	}{
+		{
+			name:         "No checks",
+			expectStatus: 200,
+			expectChecks: map[string]string{},
+		},
+		{
+			name:         "All ok",
+			checks:       map[string]ahttp.ReadinessCheck{"datastore": ok, "messagebus": ok},
+			expectStatus: 200,
+			expectChecks: map[string]string{"datastore": "ok", "messagebus": "ok"},
+		},
+		{
+			name:         "One fails",
+			checks:       map[string]ahttp.ReadinessCheck{"datastore": ok, "messagebus": fail},
+			expectStatus: 503,
+			expectChecks: map[string]string{"datastore": "ok", "messagebus": "not connected"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			ahttp.HandleReadiness(mux, tt.checks)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate/readyz", nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Errorf("got status %d, expected %d", rec.Code, tt.expectStatus)
+			}
+
+			var body struct {
+				Ready  bool              `json:"ready"`
+				Checks map[string]string `json:"checks"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+
+			if body.Ready != (tt.expectStatus == 200) {
+				t.Errorf("got ready %t", body.Ready)
+			}
+
+			if len(body.Checks) != len(tt.expectChecks) {
+				t.Fatalf("got checks %v, expected %v", body.Checks, tt.expectChecks)
+			}
+			for name, expect := range tt.expectChecks {
+				if body.Checks[name] != expect {
+					t.Errorf("check %s: got %q, expected %q", name, body.Checks[name], expect)
+				}
+			}
+		})
+	}
+}
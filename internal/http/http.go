@@ -3,8 +3,9 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
-	"encoding/base64"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,19 +14,35 @@ import (
 	"mime/multipart"
 	"net"
 	"net/http"
-	"net/http/pprof"
+	"net/url"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/accounting"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/arena"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/capture"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/federation"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/introspect"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logout"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/reload"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/redis"
-	"github.com/klauspost/compress/zstd"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracecontext"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracing"
+	"github.com/zeebo/xxh3"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -34,38 +51,244 @@ const (
 )
 
 // Run starts the http server.
+//
+// If an internal address is configured, the internal routes are served on a
+// second server that only listens on that address.
 func Run(
 	ctx context.Context,
+	cfg Config,
 	addr string,
 	auth Authenticater,
 	autoupdate *autoupdate.Autoupdate,
 	redisConnection *redis.Redis,
 	saveIntercal time.Duration,
+	readinessChecks map[string]ReadinessCheck,
 ) error {
+	readiness, err := selectReadinessChecks(cfg.ReadinessChecks, readinessChecks)
+	if err != nil {
+		return fmt.Errorf("readiness checks: %w", err)
+	}
+
 	var connectionCount [2]*ConnectionCount
 	connectionCount[0] = newConnectionCount(ctx, redisConnection, saveIntercal, "connections_stream")
 	connectionCount[1] = newConnectionCount(ctx, redisConnection, saveIntercal, "connections_longpolling")
 	metric.Register(connectionCount[0].Metric)
 	metric.Register(connectionCount[1].Metric)
+	metric.Register(firstResponseTime.Metric)
+	metric.Register(payloadSize.Metric)
+	if cfg.ConnectionLimiter != nil {
+		metric.Register(cfg.ConnectionLimiter.Metric)
+	}
+	if cfg.MeetingMetric != nil {
+		metric.Register(cfg.MeetingMetric.Metric)
+	}
+	if cfg.RateLimiter != nil {
+		reload.Register("rate_limit", cfg.RateLimiter.Reload)
+	}
+	if cfg.CORS != nil {
+		reload.Register("cors", cfg.CORS.Reload)
+	}
+	introspect.Register("connections", func() any {
+		return connectionInfo(connectionCount, cfg.MeetingMetric)
+	})
 
 	mux := http.NewServeMux()
+	internalMux := mux
+	if cfg.InternalAddr != "" {
+		internalMux = http.NewServeMux()
+	}
+
+	handleShared(mux, readiness)
+
+	var public http.Handler = mux
+	if len(cfg.Tenants) == 0 {
+		handleData(mux, auth, autoupdate, connectionCount, cfg)
+	} else {
+		HandleUnknownOrganization(mux)
+		public = tenantHandler(ctx, mux, cfg, redisConnection != nil, saveIntercal)
+	}
+
+	HandleInternalAutoupdate(internalMux, auth, autoupdate, cfg)
+	HandleForcedLogout(internalMux, cfg.ForcedLogout)
+	HandleTerminate(internalMux, cfg.Terminate)
+	HandleAccounting(internalMux)
+	HandleProfile(internalMux, auth, autoupdate)
+	HandleLogLevel(internalMux, auth, autoupdate)
+	HandleReload(internalMux, auth, autoupdate)
+	HandleMaintenance(internalMux, auth, autoupdate)
+	HandleRuntime(internalMux, auth, autoupdate)
+	HandleConfig(internalMux, auth, autoupdate, cfg)
+	HandleConfigSchema(internalMux, auth, autoupdate)
+	HandleCapture(internalMux, auth, autoupdate)
+	HandleBenchmark(internalMux, auth, autoupdate)
+	HandleDashboard(internalMux, auth, autoupdate)
+
+	handler := cfg.CORS.Middleware(bodyReadTimeoutMiddleware(routeOptionsMiddleware(basePathMiddleware(public, cfg.BasePath), cfg.Routes), cfg.Timeouts.BodyRead, cfg.Timeouts.MaxBodyBytes))
+	handler = auditMiddleware(handler, cfg.Audit)
+	if cfg.AccessLog {
+		handler = accessLogMiddleware(handler, logger, cfg.Routes.accessLog)
+	}
+	handler = requestIDMiddleware(tracecontext.Middleware(tracing.Middleware(errorReportMiddleware(cfg.TrustedProxies.Middleware(tenantMiddleware(handler))))))
+
+	inherited, err := inherit()
+	if err != nil {
+		return fmt.Errorf("using listeners from previous process: %w", err)
+	}
+
+	listeners := inherited.public
+	if len(listeners) == 0 {
+		listeners, err = listen(addr, cfg.SocketPath)
+		if err != nil {
+			return fmt.Errorf("open listeners: %w", err)
+		}
+	}
+
+	internalListener := inherited.internal
+	if cfg.InternalAddr == "" && internalListener != nil {
+		internalListener.Close()
+		internalListener = nil
+	}
+
+	if cfg.InternalAddr != "" && internalListener == nil {
+		internalListener, err = net.Listen("tcp", cfg.InternalAddr)
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return fmt.Errorf("listen on internal address %s: %w", cfg.InternalAddr, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go handleRestartSignal(ctx, func() { cancel(errRestart) }, listeners, internalListener)
+
+	publicListeners := listeners
+	if cfg.TLS != nil {
+		go cfg.TLS.Watch(ctx)
+		publicListeners = make([]net.Listener, len(listeners))
+		for i, listener := range listeners {
+			publicListeners[i] = listener
+			// Unix sockets are only used by local reverse proxies.
+			if listener.Addr().Network() == "tcp" {
+				publicListeners[i] = tls.NewListener(listener, cfg.TLS.TLSConfig())
+			}
+		}
+	}
+
+	if err := inherited.signalReady(); err != nil {
+		return fmt.Errorf("signal ready: %w", err)
+	}
+	notifySystemd(ctx, inherited.ready != nil)
+	go emitDrain(ctx, cfg.Timeouts.Drain)
+
+	if internalListener == nil {
+		return serve(ctx, handler, publicListeners, cfg.Timeouts)
+	}
+
+	internalHandler := bodyReadTimeoutMiddleware(routeOptionsMiddleware(internalMux, cfg.Routes), cfg.Timeouts.BodyRead, cfg.Timeouts.MaxBodyBytes)
+	internalHandler = auditMiddleware(internalHandler, cfg.Audit)
+	if cfg.AccessLog {
+		internalHandler = accessLogMiddleware(internalHandler, logger, cfg.Routes.accessLog)
+	}
+	internalHandler = requestIDMiddleware(tracecontext.Middleware(tracing.Middleware(errorReportMiddleware(cfg.TrustedProxies.Middleware(internalHandler)))))
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		return serve(ctx, handler, publicListeners, cfg.Timeouts)
+	})
+	eg.Go(func() error {
+		if err := serve(ctx, internalHandler, []net.Listener{internalListener}, cfg.Timeouts); err != nil {
+			return fmt.Errorf("internal server: %w", err)
+		}
+		return nil
+	})
+	return eg.Wait()
+}
+
+// handleShared registers the public routes, that do not use the data of an
+// organization.
+func handleShared(mux *http.ServeMux, readiness map[string]ReadinessCheck) {
 	HandleHealth(mux)
-	HandleAutoupdate(mux, auth, autoupdate, connectionCount)
-	HandleInternalAutoupdate(mux, auth, autoupdate)
-	HandleShowConnectionCount(mux, autoupdate, auth, connectionCount)
-	HandleHistoryInformation(mux, auth, autoupdate)
-	HandleProfile(mux)
+	HandleLiveness(mux)
+	HandleServerTime(mux)
+	HandleVersion(mux)
+	HandleReadiness(mux, readiness)
+	HandleOpenAPI(mux)
+}
+
+// handleData registers the public routes, that use the data of an
+// organization.
+func handleData(mux *http.ServeMux, auth Authenticater, autoupdate *autoupdate.Autoupdate, connectionCount [2]*ConnectionCount, cfg Config) {
+	HandleAutoupdate(mux, auth, autoupdate, connectionCount, cfg)
+	HandleShowConnectionCount(mux, autoupdate, auth, connectionCount, cfg)
+	HandleHistoryInformation(mux, auth, autoupdate, cfg)
+	HandleHistoryExport(mux, auth, autoupdate, cfg)
+	HandleHistoryDiff(mux, auth, autoupdate, cfg)
+	HandleHistoryPosition(mux, auth, autoupdate, cfg)
+	HandleMeetingExport(mux, auth, autoupdate, cfg)
+	HandleHistorySubscribe(mux, auth, autoupdate, cfg)
+	HandleProjectionPreview(mux, auth, autoupdate, cfg)
+	HandleProjectorStream(mux, auth, autoupdate, cfg)
+	HandleProjectorSnapshot(mux, auth, autoupdate, cfg)
+	HandleICC(mux, auth, cfg.ICC, cfg)
+	HandleSearch(mux, auth, autoupdate, cfg)
+	HandlePresenter(mux, auth, autoupdate, cfg)
+	HandleExport(mux, auth, autoupdate, cfg)
+}
+
+// handleRestartSignal starts a new process of the service on SIGUSR2. When the
+// new process is ready, cancel is called to stop this process.
+func handleRestartSignal(ctx context.Context, cancel func(), listeners []net.Listener, internalListener net.Listener) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR2)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+		}
+
+		logger.Info("Restart: starting new process")
+		if err := handover(listeners, internalListener); err != nil {
+			oserror.Handle(fmt.Errorf("restart: %w", err))
+			continue
+		}
+
+		logger.Info("Restart: new process is ready, draining connections")
+		cancel()
+		return
+	}
+}
+
+// serve runs a http server on all given listeners until the context is done.
+//
+// Afterwards, running requests can continue for the drain timeout.
+func serve(ctx context.Context, handler http.Handler, listeners []net.Listener, timeouts Timeouts) error {
+	handlerCtx, cancelHandlersCause := context.WithCancelCause(context.WithoutCancel(ctx))
+	cancelHandlers := func() { cancelHandlersCause(errServerStopped) }
+	defer cancelHandlers()
 
 	srv := &http.Server{
-		Addr:        addr,
-		Handler:     mux,
-		BaseContext: func(net.Listener) context.Context { return ctx },
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return withServerContext(handlerCtx, ctx) },
 	}
+	timeouts.apply(srv)
 
 	// Shutdown logic in separate goroutine.
-	wait := make(chan error)
+	wait := make(chan error, 1)
 	go func() {
 		<-ctx.Done()
+
+		if timeouts.Drain > 0 {
+			timer := time.AfterFunc(timeouts.Drain, cancelHandlers)
+			defer timer.Stop()
+		} else {
+			cancelHandlers()
+		}
+
 		if err := srv.Shutdown(context.WithoutCancel(ctx)); err != nil {
 			// TODO EXTERNAL ERROR
 			wait <- fmt.Errorf("HTTP server shutdown: %w", err)
@@ -74,9 +297,18 @@ func Run(
 		wait <- nil
 	}()
 
-	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-		// TODO EXTERNAL ERROR
-		return fmt.Errorf("HTTP Server failed: %v", err)
+	serveErr := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			serveErr <- srv.Serve(listener)
+		}(listener)
+	}
+
+	for range listeners {
+		if err := <-serveErr; err != http.ErrServerClosed {
+			// TODO EXTERNAL ERROR
+			return fmt.Errorf("HTTP Server failed: %v", err)
+		}
 	}
 
 	return <-wait
@@ -88,56 +320,90 @@ type Connecter interface {
 	SingleData(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (map[dskey.Key][]byte, error)
 }
 
-func autoupdateHandler(auth Authenticater, connecter Connecter) http.Handler {
+// autoupdateHandler handles the autoupdate requests.
+//
+// If longpollingTimeout is bigger then zero, longpolling requests return an
+// empty response after this time.
+//
+// If handoff is not nil, the connections are handed off to other instances,
+// when the server stops.
+//
+// The entries of the request for remote instances are streamed with
+// federation.
+func autoupdateHandler(auth Authenticater, connecter Connecter, longpollingTimeout time.Duration, meetings *MeetingMetric, slowThreshold time.Duration, handoff *handoff, federation Federator) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/octet-stream")
 		w.Header().Set("Cache-Control", "no-store, max-age=0")
 		ctx := r.Context()
+		slow := newSlowRequest(slowThreshold)
+
+		profile := negotiateProfile(r)
+		profile.writeHeader(w.Header())
 
 		defer r.Body.Close()
 		uid := auth.FromContext(r.Context())
 
-		queryBuilder, err := keysbuilder.FromKeys(strings.Split(r.URL.Query().Get("k"), ",")...)
+		request, err := parseAutoupdateRequest(r)
 		if err != nil {
-			handleErrorWithStatus(w, fmt.Errorf("building keysbuilder from query: %w", err))
+			handleErrorWithStatus(w, err)
 			return
 		}
+		builder := request.builder
+		capture.Request(uid, r.URL.RawQuery, request.body)
 
-		body, hashes, isLongPolling, err := parseBody(r)
+		remote, err := newRemoteRequest(federation, r.Header.Get("Authentication"), request.remote)
 		if err != nil {
-			handleErrorWithStatus(w, fmt.Errorf("parse Body: %w", err))
+			handleErrorWithStatus(w, err)
 			return
 		}
 
-		bodyBuilder, err := keysbuilder.ManyFromJSON(bytes.NewReader(body))
-		if err != nil {
-			handleErrorWithStatus(w, fmt.Errorf("building keysbuilder from body: %w", err))
+		if remote != nil && (r.URL.Query().Has("single") || request.longPolling) {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("data of remote instances can only be requested with a stream")})
 			return
 		}
+		slow.step("parse")
 
-		builder := keysbuilder.FromBuilders(queryBuilder, bodyBuilder)
+		// The attributes are used by the logs of slow requests and slow
+		// restrictions.
+		ctx = logging.ContextWith(
+			ctx,
+			"request_id", RequestIDFromContext(ctx),
+			"user_id", uid,
+			"query", r.URL.RawQuery,
+			"body", bodySummary(request.body),
+		)
 
-		var compress bool
-		if r.URL.Query().Has("compress") {
-			compress = true
-		}
+		compress := profile.compress
+
+		ctx, account := startAccounting(ctx)
+		defer account.Close()
 
 		if r.URL.Query().Has("single") {
+			// The restricted data is written before the handler returns, so
+			// the intermediate values of the restriction can be freed at once.
+			ctx, mem := arena.NewContext(ctx)
+			defer mem.Free()
+
 			data, err := connecter.SingleData(ctx, uid, builder)
 			if err != nil {
 				handleErrorWithStatus(w, fmt.Errorf("getting single data: %w", err))
 				return
 			}
+			slow.step("calculate")
+			payloadSize.observe(ctx, data)
+			account.SetMeeting(meetingFromData(data))
 
-			if err := writeData(w, data, compress); err != nil {
+			if err := writeSingleData(accountedWriter{ResponseWriter: w, account: account}, r, data, compress); err != nil {
 				handleErrorWithoutStatus(w, err)
 				return
 			}
+			slow.step("write")
+			slow.finish(ctx)
 			return
 		}
 
-		if isLongPolling {
-			if headersSent, err := handleLongpolling(ctx, w, uid, builder, connecter, compress, hashes); err != nil {
+		if request.longPolling {
+			if headersSent, err := handleLongpolling(ctx, accountedWriter{ResponseWriter: w, account: account}, uid, builder, connecter, compress, request.hashes, longpollingTimeout); err != nil {
 				if headersSent {
 					handleErrorWithoutStatus(w, err)
 				} else {
@@ -147,13 +413,64 @@ func autoupdateHandler(auth Authenticater, connecter Connecter) http.Handler {
 			return
 		}
 
-		if err := sendMessages(ctx, w, uid, builder, connecter, compress); err != nil {
+		resume := handoff.load(ctx, uid, r.URL.Query().Get("resume"))
+
+		events := newConnectionEvents(ctx, uid)
+		err = sendMessages(ctx, w, uid, builder, connecter, profile, meetings, slow, events, handoff, resume, remote)
+		events.disconnect(ctx, err)
+		if err != nil && !errors.Is(err, errHandoff) {
 			handleErrorWithoutStatus(w, err)
 			return
 		}
 	})
 }
 
+// autoupdateRequest is a parsed request to the autoupdate route.
+type autoupdateRequest struct {
+	builder     *keysbuilder.Builder
+	body        []byte
+	hashes      string
+	longPolling bool
+
+	// remote are the bodies for remote instances.
+	remote map[string][]byte
+}
+
+// parseAutoupdateRequest builds the keysbuilder from the query parameter `k`
+// and the body of a request. The entries of the body for remote instances are
+// not part of the keysbuilder.
+//
+// All errors are client errors. It must not panic on any input.
+func parseAutoupdateRequest(r *http.Request) (autoupdateRequest, error) {
+	queryBuilder, err := keysbuilder.FromKeys(strings.Split(r.URL.Query().Get("k"), ",")...)
+	if err != nil {
+		return autoupdateRequest{}, fmt.Errorf("building keysbuilder from query: %w", err)
+	}
+
+	body, hashes, isLongPolling, err := parseBody(r)
+	if err != nil {
+		return autoupdateRequest{}, fmt.Errorf("parse Body: %w", err)
+	}
+
+	localBody, remote, err := federation.Split(body)
+	if err != nil {
+		return autoupdateRequest{}, fmt.Errorf("splitting remote entries: %w", err)
+	}
+
+	bodyBuilder, err := keysbuilder.ManyFromJSON(bytes.NewReader(localBody))
+	if err != nil {
+		return autoupdateRequest{}, fmt.Errorf("building keysbuilder from body: %w", err)
+	}
+
+	return autoupdateRequest{
+		builder:     keysbuilder.FromBuilders(queryBuilder, bodyBuilder),
+		body:        body,
+		hashes:      hashes,
+		longPolling: isLongPolling,
+		remote:      remote,
+	}, nil
+}
+
 func parseBody(r *http.Request) ([]byte, string, bool, error) {
 	contentType := r.Header.Get("Content-Type")
 
@@ -206,18 +523,36 @@ func parseBodyNormal(r *http.Request) ([]byte, string, bool, error) {
 
 // HandleAutoupdate builds the requested keys from the body of a request. The
 // body has to be in the format specified in the keysbuilder package.
-func HandleAutoupdate(mux *http.ServeMux, auth Authenticater, connecter Connecter, connectionCount [2]*ConnectionCount) {
+//
+// The rate limit, the connection limit and the longpolling timeout are taken
+// from the config.
+func HandleAutoupdate(mux *http.ServeMux, auth Authenticater, connecter Connecter, connectionCount [2]*ConnectionCount, cfg Config) {
+	handoff := newHandoff(cfg.ResumeStore, cfg.HandoffTTL)
+
 	mux.Handle(
 		prefixPublic,
-		validRequest(
-			authMiddleware(
-				connectionCountMiddleware(
-					autoupdateHandler(auth, connecter),
-					auth,
-					connectionCount,
+		routeMiddleware(
+			validRequest(
+				memoryThrottleMiddleware(
+					connectionLimitMiddleware(
+						authMiddleware(
+							rateLimitMiddleware(
+								connectionCountMiddleware(
+									autoupdateHandler(auth, connecter, cfg.LongpollingTimeout, cfg.MeetingMetric, cfg.SlowRequest, handoff, cfg.Federation),
+									auth,
+									connectionCount,
+								),
+								auth,
+								cfg.rateLimiter(routeAutoupdate),
+							),
+							auth,
+						),
+						cfg.ConnectionLimiter,
+					),
+					cfg.MemoryThrottle,
 				),
-				auth,
 			),
+			routeAutoupdate,
 		),
 	)
 }
@@ -226,50 +561,72 @@ func HandleAutoupdate(mux *http.ServeMux, auth Authenticater, connecter Connecte
 // uses the user_id from an argument.
 //
 // /internal/autoupdate?user_id=23&single=1&k=user/1/username
-func HandleInternalAutoupdate(mux *http.ServeMux, auth Authenticater, connecter Connecter) {
+func HandleInternalAutoupdate(mux *http.ServeMux, auth Authenticater, connecter Connecter, cfg Config) {
 	mux.Handle(
 		prefixInternal,
-		validRequest(
-			internalAuthMiddleware(
-				autoupdateHandler(auth, connecter),
-				auth,
+		routeMiddleware(
+			validRequest(
+				internalAuthMiddleware(
+					rateLimitMiddleware(
+						autoupdateHandler(auth, connecter, 0, cfg.MeetingMetric, cfg.SlowRequest, newHandoff(cfg.ResumeStore, cfg.HandoffTTL), cfg.Federation),
+						auth,
+						cfg.rateLimiter(routeInternal),
+					),
+					auth,
+				),
 			),
+			routeInternal,
 		),
 	)
 }
 
-func writeData(w io.Writer, data map[dskey.Key][]byte, compress bool) error {
-	converted := make(map[string]json.RawMessage, len(data))
-	for k, v := range data {
-		converted[k.String()] = v
+// writeSingleData writes the data of a single request with an ETag.
+//
+// The ETag is the hash of the response. If the client already has this
+// version, only the status 304 is sent.
+func writeSingleData(w http.ResponseWriter, r *http.Request, data map[dskey.Key][]byte, compress bool) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	_, span := tracing.Start(r.Context(), "http.writeData", attribute.Int("keys", len(data)))
+	err := writeData(buf, data, compress)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("encoding data: %w", err)
 	}
 
-	if compress {
-		defer fmt.Fprintln(w)
-		base64Encoder := base64.NewEncoder(base64.RawStdEncoding, w)
-		defer base64Encoder.Close()
+	etag := fmt.Sprintf(`"%016x"`, xxh3.Hash(buf.Bytes()))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
 
-		zstdEncoder, err := zstd.NewWriter(base64Encoder)
-		if err != nil {
-			return fmt.Errorf("create encoder: %w", err)
-		}
-		defer zstdEncoder.Close()
-		w = zstdEncoder
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
 	}
 
-	if err := json.NewEncoder(w).Encode(converted); err != nil {
-		return fmt.Errorf("encode data: %w", err)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing response: %w", err)
 	}
-
 	return nil
 }
 
+// etagMatches returns true, if the etag is in the value of an If-None-Match
+// header.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleShowConnectionCount adds a handler to show the result of the connection counter.
-func HandleShowConnectionCount(mux *http.ServeMux, autoupdate *autoupdate.Autoupdate, auth Authenticater, connectionCount [2]*ConnectionCount) {
+func HandleShowConnectionCount(mux *http.ServeMux, autoupdate *autoupdate.Autoupdate, auth Authenticater, connectionCount [2]*ConnectionCount, cfg Config) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if connectionCount[0] == nil {
-			oserror.Handle(fmt.Errorf("Error connection count is not initialized"))
-			http.Error(w, "Counting not possible", 500)
+			handleErrorWithStatus(w, fmt.Errorf("connection count is not initialized"))
 			return
 		}
 
@@ -278,13 +635,12 @@ func HandleShowConnectionCount(mux *http.ServeMux, autoupdate *autoupdate.Autoup
 
 		allowed, meetingIDs, err := autoupdate.CanSeeConnectionCount(ctx, uid)
 		if err != nil {
-			oserror.Handle(fmt.Errorf("Error checking count permission %w", err))
-			http.Error(w, "Counting not possible", 500)
+			handleErrorWithStatus(w, fmt.Errorf("checking count permission: %w", err))
 			return
 		}
 
 		if !allowed {
-			http.Error(w, "Connection counting not allowed", 400)
+			handleErrorWithStatus(w, permissionDeniedError{"connection counting not allowed"})
 			return
 		}
 
@@ -294,71 +650,545 @@ func HandleShowConnectionCount(mux *http.ServeMux, autoupdate *autoupdate.Autoup
 
 		val1, err := connectionCount[0].Show(ctx, filter)
 		if err != nil {
-			oserror.Handle(fmt.Errorf("Error counting normal connection: %w", err))
-			http.Error(w, "Counting not possible", 500)
+			handleErrorWithStatus(w, fmt.Errorf("counting normal connection: %w", err))
 			return
 		}
 
 		val2, err := connectionCount[1].Show(ctx, filter)
 		if err != nil {
-			oserror.Handle(fmt.Errorf("Error counting longpolling connection: %w", err))
-			http.Error(w, "Counting not possible", 500)
+			handleErrorWithStatus(w, fmt.Errorf("counting longpolling connection: %w", err))
 			return
 		}
 
 		if err := autoupdate.FilterConnectionCount(ctx, meetingIDs, val2); err != nil {
-			oserror.Handle(fmt.Errorf("Error filtering connection count: %w", err))
-			http.Error(w, "Counting not possible", 500)
+			handleErrorWithStatus(w, fmt.Errorf("filtering connection count: %w", err))
 			return
 		}
 
 		if err := json.NewEncoder(w).Encode([2]map[int]int{val1, val2}); err != nil {
-			oserror.Handle(fmt.Errorf("Error decoding counter %w", err))
-			http.Error(w, "Counting not possible", 500)
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding counter: %w", err))
 			return
 		}
 	})
 
-	mux.Handle(prefixPublic+"/connection_count", authMiddleware(handler, auth))
+	mux.Handle(
+		prefixPublic+"/connection_count",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeConnectionCount)), auth),
+			routeConnectionCount,
+		),
+	)
 }
 
-// HistoryInformationer is an object, that can write the history information for
-// an object.
+// HistoryInformationer is an object, that can return the history information
+// for an object or a meeting.
 type HistoryInformationer interface {
-	HistoryInformation(ctx context.Context, uid int, fqid string, w io.Writer) error
+	HistoryInformation(ctx context.Context, uid int, query datastore.HistoryQuery) (map[string][]datastore.HistoryEntry, int, error)
 }
 
 // HandleHistoryInformation registers the route to return the history information info
-// for an fqid.
-func HandleHistoryInformation(mux *http.ServeMux, auth Authenticater, hi HistoryInformationer) {
+// for an fqid or a meeting.
+//
+// The entries can be filtered with the query arguments of parseHistoryQuery.
+// With `limit`, the result is paginated. The url of the next page is sent in
+// the Link header.
+//
+// /system/autoupdate/history_information?meeting_id=1&collection=motion&limit=100
+func HandleHistoryInformation(mux *http.ServeMux, auth Authenticater, hi HistoryInformationer, cfg Config) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		uid := auth.FromContext(r.Context())
 
-		fqid := r.URL.Query().Get("fqid")
-		if fqid == "" {
-			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("history information needs an fqid")})
+		query, err := parseHistoryQuery(r.URL.Query())
+		if err != nil {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("history information: %w", err)})
 			return
 		}
 
-		if err := hi.HistoryInformation(r.Context(), uid, fqid, w); err != nil {
+		entries, next, err := hi.HistoryInformation(r.Context(), uid, query)
+		if err != nil {
 			handleErrorWithStatus(w, fmt.Errorf("getting history information: %w", err))
 			return
 		}
+
+		if next != 0 {
+			nextQuery := r.URL.Query()
+			nextQuery.Set("cursor", strconv.Itoa(next))
+			w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, nextQuery.Encode()))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding history information: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/history_information",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeHistory)), auth),
+			routeHistory,
+		),
+	)
+}
+
+// HistoryExporter writes the changes of an fqid or a meeting.
+type HistoryExporter interface {
+	HistoryExport(ctx context.Context, uid int, query datastore.HistoryQuery, w io.Writer) error
+}
+
+// HandleHistoryExport registers the route to export all changes of an fqid or
+// of a meeting as newline delimited json.
+//
+// /system/autoupdate/history_export?fqid=motion/42
+// /system/autoupdate/history_export?meeting_id=1
+func HandleHistoryExport(mux *http.ServeMux, auth Authenticater, he HistoryExporter, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+
+		query, err := parseHistoryQuery(r.URL.Query())
+		if err != nil {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("history export: %w", err)})
+			return
+		}
+		query.Limit = 0
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+		cw := &countingWriter{ResponseWriter: w, status: http.StatusOK}
+		if err := he.HistoryExport(r.Context(), uid, query, cw); err != nil {
+			if cw.written > 0 {
+				handleErrorWithoutStatus(w, fmt.Errorf("exporting history: %w", err))
+				return
+			}
+			handleErrorWithStatus(w, fmt.Errorf("exporting history: %w", err))
+			return
+		}
 	})
 
-	mux.Handle(prefixPublic+"/history_information", authMiddleware(handler, auth))
+	mux.Handle(
+		prefixPublic+"/history_export",
+		routeMiddleware(
+			auditRoute(authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeHistory)), auth)),
+			routeHistory,
+		),
+	)
+}
+
+// parseHistoryQuery reads the query arguments of the history routes.
+//
+// fqid or meeting_id selects the history. user_id and collection filter the
+// entries. since and until are unix times or times in RFC 3339 format. limit
+// and cursor are used for pagination.
+func parseHistoryQuery(values url.Values) (datastore.HistoryQuery, error) {
+	query := datastore.HistoryQuery{
+		FQID:       values.Get("fqid"),
+		Collection: values.Get("collection"),
+	}
+
+	for _, arg := range []struct {
+		name   string
+		target *int
+	}{
+		{"meeting_id", &query.MeetingID},
+		{"user_id", &query.UserID},
+		{"limit", &query.Limit},
+		{"cursor", &query.After},
+	} {
+		raw := values.Get(arg.name)
+		if raw == "" {
+			continue
+		}
+
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			return query, fmt.Errorf("%s has to be a positive number, not `%s`", arg.name, raw)
+		}
+		*arg.target = value
+	}
+
+	for _, arg := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"since", &query.Since},
+		{"until", &query.Until},
+	} {
+		raw := values.Get(arg.name)
+		if raw == "" {
+			continue
+		}
+
+		timestamp, err := parseTimestamp(raw)
+		if err != nil {
+			return query, fmt.Errorf("%s: %w", arg.name, err)
+		}
+		*arg.target = timestamp
+	}
+
+	if query.FQID == "" && query.MeetingID == 0 {
+		return query, fmt.Errorf("needs an fqid or a meeting_id")
+	}
+
+	return query, nil
+}
+
+// HistoryDiffer returns the changed fields between two positions.
+type HistoryDiffer interface {
+	HistoryDiff(ctx context.Context, uid int, fqids []string, from, to int) (map[string]map[string]autoupdate.FieldDiff, error)
 }
 
-func handleLongpolling(ctx context.Context, w http.ResponseWriter, uid int, kb autoupdate.KeysBuilder, connecter Connecter, compress bool, hashes string) (bool, error) {
+// HandleHistoryDiff registers the route to get the changed fields of one or
+// more fqids between two positions.
+//
+// /system/autoupdate/history_diff?fqid=motion/42,motion/43&from=10&to=20
+func HandleHistoryDiff(mux *http.ServeMux, auth Authenticater, hd HistoryDiffer, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+
+		fqids, positions, err := parseHistoryDiffQuery(r.URL.Query())
+		if err != nil {
+			handleErrorWithStatus(w, invalidRequestError{err})
+			return
+		}
+
+		diff, err := hd.HistoryDiff(r.Context(), uid, fqids, positions[0], positions[1])
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("getting history diff: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(diff); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding history diff: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/history_diff",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeHistory)), auth),
+			routeHistory,
+		),
+	)
+}
+
+// parseHistoryDiffQuery returns the fqids and the positions `from` and `to`
+// of the history diff route.
+func parseHistoryDiffQuery(values url.Values) ([]string, [2]int, error) {
+	var fqids []string
+	for _, fqid := range strings.Split(values.Get("fqid"), ",") {
+		if fqid = strings.TrimSpace(fqid); fqid != "" {
+			fqids = append(fqids, fqid)
+		}
+	}
+
+	if len(fqids) == 0 {
+		return nil, [2]int{}, fmt.Errorf("history diff needs an fqid")
+	}
+
+	var positions [2]int
+	for i, name := range []string{"from", "to"} {
+		position, err := strconv.Atoi(values.Get(name))
+		if err != nil {
+			return nil, [2]int{}, fmt.Errorf("%s has to be a position, not `%s`", name, values.Get(name))
+		}
+		positions[i] = position
+	}
+
+	return fqids, positions, nil
+}
+
+// MeetingExporter returns the state of a meeting at a position.
+type MeetingExporter interface {
+	MeetingExport(ctx context.Context, uid int, meetingID int, position int) (autoupdate.MeetingSnapshot, error)
+}
+
+// HandleMeetingExport registers the route to export all objects of a meeting
+// at a position as one json document. Without a position, the latest position
+// is used.
+//
+// If the client accepts gzip, the document is compressed.
+//
+// /system/autoupdate/meeting_export?meeting_id=1&position=4711
+func HandleMeetingExport(mux *http.ServeMux, auth Authenticater, me MeetingExporter, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+		query := r.URL.Query()
+
+		meetingID, err := strconv.Atoi(query.Get("meeting_id"))
+		if err != nil || meetingID < 1 {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("meeting_id has to be a positive number, not `%s`", query.Get("meeting_id"))})
+			return
+		}
+
+		var position int
+		if rawPosition := query.Get("position"); rawPosition != "" {
+			position, err = strconv.Atoi(rawPosition)
+			if err != nil || position < 1 {
+				handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("position has to be a positive number, not `%s`", rawPosition)})
+				return
+			}
+		}
+
+		snapshot, err := me.MeetingExport(r.Context(), uid, meetingID, position)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("exporting meeting: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="meeting-%d-%d.json"`, meetingID, snapshot.Position))
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		var out io.Writer = w
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			out = gz
+		}
+
+		if err := json.NewEncoder(out).Encode(snapshot); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding meeting export: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/meeting_export",
+		routeMiddleware(
+			auditRoute(authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeHistory)), auth)),
+			routeHistory,
+		),
+	)
+}
+
+// HistorySubscriber streams the new positions of a meeting.
+type HistorySubscriber interface {
+	HistorySubscribe(ctx context.Context, uid int, meetingID int) (func(context.Context) ([]datastore.HistoryPosition, error), error)
+}
+
+// HandleHistorySubscribe registers the route to stream new history entries of
+// a meeting as newline delimited json. Each line is one position with the
+// changed fqids.
+//
+// The route is a long running connection like the autoupdate route and counts
+// for the connection limit.
+//
+// /system/autoupdate/history_subscribe?meeting_id=1
+func HandleHistorySubscribe(mux *http.ServeMux, auth Authenticater, hs HistorySubscriber, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid := auth.FromContext(ctx)
+
+		meetingID, err := strconv.Atoi(r.URL.Query().Get("meeting_id"))
+		if err != nil || meetingID < 1 {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("meeting_id has to be a positive number, not `%s`", r.URL.Query().Get("meeting_id"))})
+			return
+		}
+
+		next, err := hs.HistorySubscribe(ctx, uid, meetingID)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("history subscription: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		encoder := json.NewEncoder(w)
+		for {
+			// This blocks, until there are new positions or the client
+			// context is done.
+			positions, err := next(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				handleErrorWithoutStatus(w, fmt.Errorf("history subscription: %w", err))
+				return
+			}
+
+			for _, position := range positions {
+				if err := encoder.Encode(position); err != nil {
+					handleErrorWithoutStatus(w, fmt.Errorf("encoding position: %w", err))
+					return
+				}
+			}
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/history_subscribe",
+		routeMiddleware(
+			memoryThrottleMiddleware(
+				connectionLimitMiddleware(
+					authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeHistory)), auth),
+					cfg.ConnectionLimiter,
+				),
+				cfg.MemoryThrottle,
+			),
+			routeHistory,
+		),
+	)
+}
+
+// ProjectionPreviewer calculates the content of a projection, that does not
+// exist.
+type ProjectionPreviewer interface {
+	ProjectionPreview(ctx context.Context, uid int, p7on projector.Projection) (json.RawMessage, error)
+}
+
+// HandleProjectionPreview registers the route to calculate the content of a
+// projection without creating it. The body is a projection with the fields
+// meeting_id, content_object_id, type and options.
+//
+// /system/autoupdate/projection_preview
+func HandleProjectionPreview(mux *http.ServeMux, auth Authenticater, pp ProjectionPreviewer, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+
+		if r.Method != http.MethodPost {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("projection preview only supports POST requests")})
+			return
+		}
+
+		var p7on projector.Projection
+		if err := json.NewDecoder(r.Body).Decode(&p7on); err != nil {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("decoding projection: %w", err)})
+			return
+		}
+
+		content, err := pp.ProjectionPreview(r.Context(), uid, p7on)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("getting projection preview: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+		fmt.Fprintf(w, "%s\n", bytes.TrimSpace(content))
+	})
+
+	mux.Handle(
+		prefixPublic+"/projection_preview",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeProjector)), auth),
+			routeProjector,
+		),
+	)
+}
+
+// HistoryPositioner translates a time into a position.
+type HistoryPositioner interface {
+	HistoryPosition(ctx context.Context, uid int, timestamp time.Time, meetingID int) (int, time.Time, error)
+}
+
+// HandleHistoryPosition registers the route to get the position, that shows
+// the data as it was at a given time.
+//
+// The timestamp is a unix time in seconds or in RFC 3339 format.
+//
+// /system/autoupdate/history_position?timestamp=1700000000&meeting_id=1
+func HandleHistoryPosition(mux *http.ServeMux, auth Authenticater, hp HistoryPositioner, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+
+		timestamp, meetingID, err := parseHistoryPositionQuery(r.URL.Query())
+		if err != nil {
+			handleErrorWithStatus(w, invalidRequestError{err})
+			return
+		}
+
+		position, positionTime, err := hp.HistoryPosition(r.Context(), uid, timestamp, meetingID)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("getting history position: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"position":%d,"timestamp":%d}`+"\n", position, positionTime.Unix())
+	})
+
+	mux.Handle(
+		prefixPublic+"/history_position",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeHistory)), auth),
+			routeHistory,
+		),
+	)
+}
+
+// parseHistoryPositionQuery returns the timestamp and the optional meeting id
+// of the history position route.
+func parseHistoryPositionQuery(values url.Values) (time.Time, int, error) {
+	timestamp, err := parseTimestamp(values.Get("timestamp"))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	var meetingID int
+	if rawMeetingID := values.Get("meeting_id"); rawMeetingID != "" {
+		meetingID, err = strconv.Atoi(rawMeetingID)
+		if err != nil || meetingID < 1 {
+			return time.Time{}, 0, fmt.Errorf("meeting_id has to be a positive number, not %s", rawMeetingID)
+		}
+	}
+
+	return timestamp, meetingID, nil
+}
+
+// parseTimestamp parses a unix time in seconds or a time in RFC 3339 format.
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("timestamp is required")
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp has to be a unix time or in RFC 3339 format, not `%s`", value)
+	}
+	return timestamp, nil
+}
+
+// handleLongpolling waits for data and writes it as multipart response.
+//
+// If there is no new data before the timeout, an empty response with the old
+// hashes is returned. The client has to send the next request afterwards.
+func handleLongpolling(ctx context.Context, w http.ResponseWriter, uid int, kb autoupdate.KeysBuilder, connecter Connecter, compress bool, hashes string, timeout time.Duration) (bool, error) {
 	conn, err := connecter.Connect(ctx, uid, kb)
 	if err != nil {
 		return false, fmt.Errorf("getting connection: %w", err)
 	}
 
-	data, newHashes, err := conn.NextWithFilter(ctx, hashes)
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	data, newHashes, err := conn.NextWithFilter(waitCtx, hashes)
 	if err != nil {
-		return false, fmt.Errorf("getting data: %w", err)
+		if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			return false, fmt.Errorf("getting data: %w", err)
+		}
+
+		// Timeout without new data.
+		data = nil
+		newHashes = hashes
 	}
+	payloadSize.observe(ctx, data)
 
 	mp := multipart.NewWriter(w)
 	w.Header().Set("Content-Type", mp.FormDataContentType())
@@ -387,25 +1217,127 @@ func handleLongpolling(ctx context.Context, w http.ResponseWriter, uid int, kb a
 	return true, nil
 }
 
-func sendMessages(ctx context.Context, w io.Writer, uid int, kb autoupdate.KeysBuilder, connecter Connecter, compress bool) error {
+// sendMessages writes the data of the connection to w until the context is
+// done.
+//
+// slow measures the first message and events sends the lifecycle events. Both
+// can be nil.
+//
+// If handoff is not nil, the state of the connection is saved, when the server
+// stops, and errHandoff is returned. A not empty resume is the state of a
+// connection, that was handed off before.
+//
+// The data of the remote instances is written into the same stream. remote can
+// be nil.
+//
+// Without the feature control in the profile, no control messages are written.
+// The stream is just closed on a handoff or a termination.
+func sendMessages(ctx context.Context, w io.Writer, uid int, kb autoupdate.KeysBuilder, connecter Connecter, profile protocolProfile, meetings *MeetingMetric, slow *slowRequest, events *connectionEvents, handoff *handoff, resume string, remote *remoteRequest) error {
+	if slow == nil {
+		slow = newSlowRequest(0)
+	}
+
 	conn, err := connecter.Connect(ctx, uid, kb)
 	if err != nil {
 		return fmt.Errorf("getting connection: %w", err)
 	}
+	slow.step("connect")
+
+	if resume != "" {
+		if r, ok := conn.(resumer); ok {
+			if err := r.Resume(resume); err != nil {
+				return fmt.Errorf("resume connection: %w", err)
+			}
+		}
+	}
+
+	ctx, stopWatch := handoff.watch(ctx)
+	defer stopWatch()
+
+	compress := profile.compress
+	notice := newMaintenanceNotice(ctx, w, profile.control)
+	defer notice.stop()
+
+	ctx, stopRemote := remote.start(ctx, notice, compress)
+	defer stopRemote()
+
+	// updateTimer is implemented by the connections of the autoupdate
+	// package.
+	type updateTimer interface {
+		UpdateTime() time.Time
+	}
 
+	meetingID := -1
 	for f, ok := conn.Next(); ok; f, ok = conn.Next() {
 		// This blocks, until there is new data. It also unblocks, when the
 		// client context is done.
 		data, err := f(ctx)
 		if err != nil {
+			cause := context.Cause(ctx)
+			if errors.Is(cause, errHandoff) {
+				stopRemote()
+				notice.stop()
+				if !profile.control {
+					return errHandoff
+				}
+				if err := handoff.save(ctx, w, uid, conn); err != nil {
+					return fmt.Errorf("hand off connection: %w", err)
+				}
+				return errHandoff
+			}
+
+			var errRemote federation.Error
+			if errors.As(cause, &errRemote) {
+				return errRemote
+			}
+
+			var termination logout.Termination
+			if errors.As(cause, &termination) {
+				stopRemote()
+				if !profile.control {
+					return nil
+				}
+				return writeTermination(notice, termination, compress)
+			}
 			return fmt.Errorf("getting next message: %w", err)
 		}
+		slow.step("calculate")
 
-		if err := writeData(w, data, compress); err != nil {
-			return fmt.Errorf("write data: %w", err)
+		if meetingID == -1 {
+			meetingID = meetingFromData(data)
+			accounting.FromContext(ctx).SetMeeting(meetingID)
+			if meetingID != 0 {
+				defer meetings.connect(meetingID)()
+			}
 		}
-		w.(http.Flusher).Flush()
 
+		payloadSize.observe(ctx, data)
+		counter := &byteCounter{Writer: w}
+		writeStart := time.Now()
+		_, span := tracing.Start(ctx, "http.writeData", attribute.Int("keys", len(data)))
+		notice.mu.Lock()
+		err = writeData(counter, data, compress)
+		if err == nil {
+			w.(http.Flusher).Flush()
+		}
+		notice.mu.Unlock()
+		tracing.End(span, err)
+		if err != nil {
+			return fmt.Errorf("write data: %w", err)
+		}
+		slow.step("write")
+		slow.finish(ctx)
+		events.subscribe(len(data), meetingID)
+		events.written(time.Since(writeStart))
+		accounting.FromContext(ctx).Sent(counter.n)
+
+		if meetingID != 0 {
+			var updateTime time.Time
+			if timer, ok := conn.(updateTimer); ok {
+				updateTime = timer.UpdateTime()
+			}
+			meetings.sent(meetingID, counter.n, updateTime)
+		}
 	}
 	return ctx.Err()
 }
@@ -418,25 +1350,25 @@ func HandleHealth(mux *http.ServeMux) {
 		fmt.Fprintln(w, `{"healthy": true}`)
 	})
 
-	mux.Handle(url, handler)
-}
-
-// HandleProfile adds routes for profiling.
-func HandleProfile(mux *http.ServeMux) {
-	mux.HandleFunc("/debug/pprof/", pprof.Index)
-	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
-	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle(url, routeMiddleware(handler, routeHealth))
 }
 
 func authMiddleware(next http.Handler, auth Authenticater) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "auth.Authenticate")
 		ctx, err := auth.Authenticate(w, r)
+		tracing.End(span, err)
 		if err != nil {
-			handleErrorWithStatus(w, fmt.Errorf("authenticate request: %w", err))
-			return
+			var errTyped interface{ Type() string }
+			if !errors.As(err, &errTyped) || errTyped.Type() != "auth" || !relaxedAuth(r.Context()) {
+				handleErrorWithStatus(w, fmt.Errorf("authenticate request: %w", err))
+				return
+			}
+
+			// The route accepts invalid tokens as anonymous requests.
+			ctx = auth.AuthenticatedContext(r.Context(), 0)
 		}
+		setUserForLog(ctx, auth.FromContext(ctx))
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -452,6 +1384,7 @@ func internalAuthMiddleware(next http.Handler, auth Authenticater) http.Handler
 		}
 
 		ctx := auth.AuthenticatedContext(r.Context(), userID)
+		setUserForLog(ctx, userID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -480,15 +1413,13 @@ func handleErrorInternal(w http.ResponseWriter, err error) {
 // If the handler already started to write the body then it is not allowed to
 // set the http-status-code. In this case, writeStatusCode has to be fales.
 func handleError(w http.ResponseWriter, err error, writeStatusCode bool, internal bool) {
-	if writeStatusCode {
-		w.Header().Set("Content-Type", "application/octet-stream")
-	}
-
 	if oserror.ContextDone(err) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
 		// Client closed connection.
 		return
 	}
 
+	requestID := w.Header().Get(requestIDHeader)
+
 	status := http.StatusBadRequest
 	var StatusCoder interface{ StatusCode() int }
 	if errors.As(err, &StatusCoder) {
@@ -497,31 +1428,23 @@ func handleError(w http.ResponseWriter, err error, writeStatusCode bool, interna
 
 	var errClient ClientError
 	if errors.As(err, &errClient) {
-		if writeStatusCode {
-			w.WriteHeader(status)
-		}
-
-		fmt.Fprintf(w, `{"error": {"type": "%s", "msg": "%s"}}`, errClient.Type(), quote(errClient.Error()))
+		writeProblem(w, newProblem(errClient.Type(), status, errClient.Error(), requestID), writeStatusCode)
 		return
 	}
 
-	if writeStatusCode {
-		w.WriteHeader(http.StatusInternalServerError)
+	reportError(w, err)
+
+	if requestID != "" {
+		err = fmt.Errorf("request %s: %w", requestID, err)
 	}
 
-	clientOutput := `{"error": {"type": "InternalError", "msg": "Something went wrong on the server. The admin is already informed."}}`
+	detail := "Something went wrong on the server. The admin is already informed."
 	if internal {
-		clientOutput = err.Error()
+		detail = err.Error()
 	}
 
 	oserror.Handle(err)
-	fmt.Fprintln(w, clientOutput)
-}
-
-// quote decodes changes quotation marks with a backslash to make sure, they are
-// valid json.
-func quote(s string) string {
-	return strings.ReplaceAll(s, `"`, `\"`)
+	writeProblem(w, newProblem("InternalError", http.StatusInternalServerError, detail, requestID), writeStatusCode)
 }
 
 func validRequest(next http.Handler) http.Handler {
@@ -1,17 +1,23 @@
 package http_test
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
 	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 )
 
@@ -61,7 +67,7 @@ func TestKeysHandler(t *testing.T) {
 		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) { return f, true },
 	}
 
-	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{})
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
 
 	req := httptest.NewRequest("GET", "/system/autoupdate?k=user/1/username,user/2/username", nil).WithContext(ctx)
 	rec := httptest.NewRecorder()
@@ -97,7 +103,7 @@ func TestComplexHandler(t *testing.T) {
 		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) { return f, true },
 	}
 
-	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{})
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
 
 	req := httptest.NewRequest(
 		"GET",
@@ -152,7 +158,7 @@ func TestErrors(t *testing.T) {
 		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) { return f, true },
 	}
 
-	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{})
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
 
 	for _, tt := range []struct {
 		name    string
@@ -250,27 +256,27 @@ func TestErrors(t *testing.T) {
 }
 
 type HistoryInformationStub struct {
-	uid   int
-	fqid  string
-	write string
-	err   error
+	uid     int
+	query   datastore.HistoryQuery
+	entries map[string][]datastore.HistoryEntry
+	next    int
+	err     error
 }
 
-func (h *HistoryInformationStub) HistoryInformation(ctx context.Context, uid int, fqid string, w io.Writer) error {
+func (h *HistoryInformationStub) HistoryInformation(ctx context.Context, uid int, query datastore.HistoryQuery) (map[string][]datastore.HistoryEntry, int, error) {
 	h.uid = uid
-	h.fqid = fqid
-	if h.write != "" {
-		w.Write([]byte(h.write))
-	}
-	return h.err
+	h.query = query
+	return h.entries, h.next, h.err
 }
 
 func TestHistoryInformation(t *testing.T) {
 	mux := http.NewServeMux()
 	hi := &HistoryInformationStub{
-		write: "my information",
+		entries: map[string][]datastore.HistoryEntry{
+			"motion/42": {{Position: 1, Timestamp: 1700000000, UserID: 5, Information: []byte(`"created"`)}},
+		},
 	}
-	ahttp.HandleHistoryInformation(mux, fakeAuth(1), hi)
+	ahttp.HandleHistoryInformation(mux, fakeAuth(1), hi, ahttp.Config{})
 
 	resp := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/system/autoupdate/history_information?fqid=motion/42", nil)
@@ -281,25 +287,67 @@ func TestHistoryInformation(t *testing.T) {
 		t.Errorf("got status %s, expected %s", resp.Result().Status, http.StatusText(http.StatusOK))
 	}
 
-	if body, _ := io.ReadAll(resp.Result().Body); string(body) != "my information" {
-		t.Errorf("got body %s, expected `my information`", body)
+	expect := `{"motion/42":[{"position":1,"timestamp":1700000000,"user_id":5,"information":"created"}]}`
+	if body, _ := io.ReadAll(resp.Result().Body); strings.TrimSpace(string(body)) != expect {
+		t.Errorf("got body %s, expected `%s`", body, expect)
+	}
+
+	if resp.Header().Get("Link") != "" {
+		t.Errorf("got link header `%s` without pagination", resp.Header().Get("Link"))
 	}
 
 	if hi.uid != 1 {
 		t.Errorf("hi was called with user %d, expected 1", hi.uid)
 	}
 
-	if hi.fqid != "motion/42" {
-		t.Errorf("hi was called with `%s`, expected `motion/42`", hi.fqid)
+	if hi.query != (datastore.HistoryQuery{FQID: "motion/42"}) {
+		t.Errorf("hi was called with `%v`, expected fqid `motion/42`", hi.query)
 	}
 }
 
-func TestHistoryInformationNoFQID(t *testing.T) {
+func TestHistoryInformationFilter(t *testing.T) {
 	mux := http.NewServeMux()
-	hi := &HistoryInformationStub{
-		write: "my information",
+	hi := &HistoryInformationStub{next: 50}
+	ahttp.HandleHistoryInformation(mux, fakeAuth(1), hi, ahttp.Config{})
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/system/autoupdate/history_information?meeting_id=7&user_id=5&collection=motion&since=1700000000&until=2023-11-15T00:00:00Z&limit=10&cursor=40", nil)
+
+	mux.ServeHTTP(resp, req)
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d, expected 200", resp.Code)
 	}
-	ahttp.HandleHistoryInformation(mux, fakeAuth(1), hi)
+
+	expect := datastore.HistoryQuery{
+		MeetingID:  7,
+		UserID:     5,
+		Collection: "motion",
+		Since:      time.Unix(1700000000, 0),
+		Until:      time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC),
+		Limit:      10,
+		After:      40,
+	}
+	if hi.query.MeetingID != expect.MeetingID ||
+		hi.query.UserID != expect.UserID ||
+		hi.query.Collection != expect.Collection ||
+		!hi.query.Since.Equal(expect.Since) ||
+		!hi.query.Until.Equal(expect.Until) ||
+		hi.query.Limit != expect.Limit ||
+		hi.query.After != expect.After {
+		t.Errorf("got query %v, expected %v", hi.query, expect)
+	}
+
+	link := resp.Header().Get("Link")
+	if !strings.Contains(link, "cursor=50") || !strings.HasSuffix(link, `; rel="next"`) {
+		t.Errorf("got link header `%s`", link)
+	}
+}
+
+func TestHistoryInformationNoFQID(t *testing.T) {
+	mux := http.NewServeMux()
+	hi := &HistoryInformationStub{}
+	ahttp.HandleHistoryInformation(mux, fakeAuth(1), hi, ahttp.Config{})
 
 	resp := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/system/autoupdate/history_information", nil)
@@ -310,8 +358,12 @@ func TestHistoryInformationNoFQID(t *testing.T) {
 		t.Errorf("got status %s, expected %s", resp.Result().Status, http.StatusText(http.StatusBadRequest))
 	}
 
-	expect := `{"error": {"type": "invalid_request", "msg": "Invalid request: history information needs an fqid"}}`
-	if body, _ := io.ReadAll(resp.Result().Body); string(body) != expect {
+	if got := resp.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("got content type %s, expected application/problem+json", got)
+	}
+
+	expect := `{"type":"urn:openslides:autoupdate:error:invalid_request","title":"Bad Request","status":400,"detail":"Invalid request: history information: needs an fqid or a meeting_id","error":{"type":"invalid_request","msg":"Invalid request: history information: needs an fqid or a meeting_id"}}`
+	if body, _ := io.ReadAll(resp.Result().Body); strings.TrimSpace(string(body)) != expect {
 		t.Errorf("got body `%s`, expected `%s`", body, expect)
 	}
 }
@@ -321,7 +373,7 @@ func TestHistoryInformationError(t *testing.T) {
 	hi := &HistoryInformationStub{
 		err: fmt.Errorf("my error"),
 	}
-	ahttp.HandleHistoryInformation(mux, fakeAuth(1), hi)
+	ahttp.HandleHistoryInformation(mux, fakeAuth(1), hi, ahttp.Config{})
 
 	resp := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/system/autoupdate/history_information?fqid=motion/42", nil)
@@ -332,12 +384,497 @@ func TestHistoryInformationError(t *testing.T) {
 		t.Errorf("got status %s, expected %s", resp.Result().Status, http.StatusText(http.StatusInternalServerError))
 	}
 
-	expect := `{"error": {"type": "InternalError", "msg": "Something went wrong on the server. The admin is already informed."}}`
+	expect := `{"type":"urn:openslides:autoupdate:error:InternalError","title":"Internal Server Error","status":500,"detail":"Something went wrong on the server. The admin is already informed.","error":{"type":"InternalError","msg":"Something went wrong on the server. The admin is already informed."}}`
 	if body, _ := io.ReadAll(resp.Result().Body); strings.TrimSpace(string(body)) != expect {
 		t.Errorf("got body `%s`, expected `%s`", body, expect)
 	}
 }
 
+type HistoryExportStub struct {
+	query datastore.HistoryQuery
+	write string
+}
+
+func (h *HistoryExportStub) HistoryExport(ctx context.Context, uid int, query datastore.HistoryQuery, w io.Writer) error {
+	h.query = query
+	w.Write([]byte(h.write))
+	return nil
+}
+
+func TestHistoryExport(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		url         string
+		expectCode  int
+		expectQuery datastore.HistoryQuery
+	}{
+		{"fqid", "/system/autoupdate/history_export?fqid=motion/42", 200, datastore.HistoryQuery{FQID: "motion/42"}},
+		{"meeting", "/system/autoupdate/history_export?meeting_id=7", 200, datastore.HistoryQuery{MeetingID: 7}},
+		{"invalid meeting", "/system/autoupdate/history_export?meeting_id=foo", 400, datastore.HistoryQuery{}},
+		{"no query", "/system/autoupdate/history_export", 400, datastore.HistoryQuery{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			he := &HistoryExportStub{write: `{"position":1}` + "\n"}
+			ahttp.HandleHistoryExport(mux, fakeAuth(1), he, ahttp.Config{})
+
+			resp := httptest.NewRecorder()
+			mux.ServeHTTP(resp, httptest.NewRequest("GET", tt.url, nil))
+
+			if resp.Code != tt.expectCode {
+				t.Fatalf("got status %d, expected %d", resp.Code, tt.expectCode)
+			}
+
+			if he.query != tt.expectQuery {
+				t.Errorf("got query %v, expected %v", he.query, tt.expectQuery)
+			}
+
+			if tt.expectCode != 200 {
+				return
+			}
+
+			if got := resp.Header().Get("Content-Type"); got != "application/x-ndjson" {
+				t.Errorf("got content type %s", got)
+			}
+
+			if got := resp.Body.String(); got != he.write {
+				t.Errorf("got body `%s`, expected `%s`", got, he.write)
+			}
+		})
+	}
+}
+
+type HistoryDiffStub struct {
+	fqids    []string
+	from, to int
+}
+
+func (h *HistoryDiffStub) HistoryDiff(ctx context.Context, uid int, fqids []string, from, to int) (map[string]map[string]autoupdate.FieldDiff, error) {
+	h.fqids = fqids
+	h.from = from
+	h.to = to
+	return map[string]map[string]autoupdate.FieldDiff{
+		"motion/42": {"title": {Old: []byte(`"foo"`), New: []byte(`"bar"`)}},
+	}, nil
+}
+
+func TestHistoryDiff(t *testing.T) {
+	mux := http.NewServeMux()
+	hd := &HistoryDiffStub{}
+	ahttp.HandleHistoryDiff(mux, fakeAuth(1), hd, ahttp.Config{})
+
+	t.Run("valid", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/history_diff?fqid=motion/42,motion/43&from=10&to=20", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200", resp.Code)
+		}
+
+		if strings.Join(hd.fqids, ",") != "motion/42,motion/43" || hd.from != 10 || hd.to != 20 {
+			t.Errorf("got fqids %v from %d to %d", hd.fqids, hd.from, hd.to)
+		}
+
+		expect := `{"motion/42":{"title":{"old":"foo","new":"bar"}}}`
+		if got := strings.TrimSpace(resp.Body.String()); got != expect {
+			t.Errorf("got body `%s`, expected `%s`", got, expect)
+		}
+	})
+
+	t.Run("missing position", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/history_diff?fqid=motion/42&from=10", nil))
+
+		if resp.Code != 400 {
+			t.Errorf("got status %d, expected 400", resp.Code)
+		}
+	})
+}
+
+type HistoryPositionStub struct {
+	timestamp time.Time
+	meetingID int
+}
+
+func (h *HistoryPositionStub) HistoryPosition(ctx context.Context, uid int, timestamp time.Time, meetingID int) (int, time.Time, error) {
+	h.timestamp = timestamp
+	h.meetingID = meetingID
+	return 23, time.Unix(1700000000, 0), nil
+}
+
+func TestHistoryPosition(t *testing.T) {
+	mux := http.NewServeMux()
+	hp := &HistoryPositionStub{}
+	ahttp.HandleHistoryPosition(mux, fakeAuth(1), hp, ahttp.Config{})
+
+	for _, tt := range []struct {
+		name          string
+		query         string
+		expectCode    int
+		expectTime    time.Time
+		expectMeeting int
+	}{
+		{"unix time", "timestamp=1700000005", 200, time.Unix(1700000005, 0), 0},
+		{"rfc3339 with meeting", "timestamp=2023-11-14T22:13:25Z&meeting_id=7", 200, time.Unix(1700000005, 0), 7},
+		{"no timestamp", "meeting_id=7", 400, time.Time{}, 0},
+		{"invalid timestamp", "timestamp=yesterday", 400, time.Time{}, 0},
+		{"invalid meeting", "timestamp=1700000005&meeting_id=x", 400, time.Time{}, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			*hp = HistoryPositionStub{}
+			resp := httptest.NewRecorder()
+			mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/history_position?"+tt.query, nil))
+
+			if resp.Code != tt.expectCode {
+				t.Fatalf("got status %d, expected %d", resp.Code, tt.expectCode)
+			}
+
+			if tt.expectCode != 200 {
+				return
+			}
+
+			if !hp.timestamp.Equal(tt.expectTime) || hp.meetingID != tt.expectMeeting {
+				t.Errorf("got timestamp %s and meeting %d", hp.timestamp, hp.meetingID)
+			}
+
+			expect := `{"position":23,"timestamp":1700000000}`
+			if got := strings.TrimSpace(resp.Body.String()); got != expect {
+				t.Errorf("got body `%s`, expected `%s`", got, expect)
+			}
+		})
+	}
+}
+
+type MeetingExportStub struct {
+	meetingID int
+	position  int
+}
+
+func (m *MeetingExportStub) MeetingExport(ctx context.Context, uid int, meetingID int, position int) (autoupdate.MeetingSnapshot, error) {
+	m.meetingID = meetingID
+	m.position = position
+	return autoupdate.MeetingSnapshot{
+		MeetingID: meetingID,
+		Position:  4711,
+		Timestamp: 1700000000,
+		Data:      map[string]map[string]json.RawMessage{"meeting/1": {"name": []byte(`"foo"`)}},
+	}, nil
+}
+
+func TestMeetingExport(t *testing.T) {
+	mux := http.NewServeMux()
+	me := &MeetingExportStub{}
+	ahttp.HandleMeetingExport(mux, fakeAuth(1), me, ahttp.Config{})
+
+	expect := `{"meeting_id":1,"position":4711,"timestamp":1700000000,"data":{"meeting/1":{"name":"foo"}}}`
+
+	t.Run("plain", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/meeting_export?meeting_id=1&position=23", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200", resp.Code)
+		}
+
+		if me.meetingID != 1 || me.position != 23 {
+			t.Errorf("got meeting %d and position %d", me.meetingID, me.position)
+		}
+
+		if got := strings.TrimSpace(resp.Body.String()); got != expect {
+			t.Errorf("got body `%s`, expected `%s`", got, expect)
+		}
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/system/autoupdate/meeting_export?meeting_id=1", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, req)
+
+		if resp.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("got content encoding `%s`, expected gzip", resp.Header().Get("Content-Encoding"))
+		}
+
+		if me.position != 0 {
+			t.Errorf("got position %d, expected 0", me.position)
+		}
+
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+
+		body, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatalf("reading body: %v", err)
+		}
+
+		if got := strings.TrimSpace(string(body)); got != expect {
+			t.Errorf("got body `%s`, expected `%s`", got, expect)
+		}
+	})
+
+	t.Run("invalid meeting", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/meeting_export?meeting_id=0", nil))
+
+		if resp.Code != 400 {
+			t.Errorf("got status %d, expected 400", resp.Code)
+		}
+	})
+}
+
+type HistorySubscribeStub struct {
+	meetingID int
+	positions chan []datastore.HistoryPosition
+}
+
+func (h *HistorySubscribeStub) HistorySubscribe(ctx context.Context, uid int, meetingID int) (func(context.Context) ([]datastore.HistoryPosition, error), error) {
+	h.meetingID = meetingID
+	return func(ctx context.Context) ([]datastore.HistoryPosition, error) {
+		select {
+		case positions, ok := <-h.positions:
+			if !ok {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return positions, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, nil
+}
+
+func TestHistorySubscribe(t *testing.T) {
+	mux := http.NewServeMux()
+	hs := &HistorySubscribeStub{positions: make(chan []datastore.HistoryPosition, 1)}
+	ahttp.HandleHistorySubscribe(mux, fakeAuth(1), hs, ahttp.Config{})
+
+	hs.positions <- []datastore.HistoryPosition{
+		{Position: 5, Timestamp: 1700000000, UserID: 3, FQIDs: []string{"motion/1", "motion/2"}, Information: []byte(`null`)},
+	}
+	close(hs.positions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/history_subscribe?meeting_id=7", nil).WithContext(ctx))
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d, expected 200", resp.Code)
+	}
+
+	if hs.meetingID != 7 {
+		t.Errorf("got meeting %d, expected 7", hs.meetingID)
+	}
+
+	if got := resp.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("got content type %s", got)
+	}
+
+	expect := `{"position":5,"timestamp":1700000000,"user_id":3,"fqids":["motion/1","motion/2"],"information":null}`
+	if got := strings.TrimSpace(resp.Body.String()); got != expect {
+		t.Errorf("got body `%s`, expected `%s`", got, expect)
+	}
+}
+
+type ProjectionPreviewStub struct {
+	p7on projector.Projection
+}
+
+func (p *ProjectionPreviewStub) ProjectionPreview(ctx context.Context, uid int, p7on projector.Projection) (json.RawMessage, error) {
+	p.p7on = p7on
+	return []byte(`{"collection":"motion","title":"foo"}`), nil
+}
+
+func TestProjectionPreview(t *testing.T) {
+	mux := http.NewServeMux()
+	pp := &ProjectionPreviewStub{}
+	ahttp.HandleProjectionPreview(mux, fakeAuth(1), pp, ahttp.Config{})
+
+	t.Run("valid", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		body := strings.NewReader(`{"meeting_id":1,"content_object_id":"motion/5","options":{"mode":"diff"}}`)
+		mux.ServeHTTP(resp, httptest.NewRequest("POST", "/system/autoupdate/projection_preview", body))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200", resp.Code)
+		}
+
+		if pp.p7on.MeetingID != 1 || pp.p7on.ContentObjectID != "motion/5" || string(pp.p7on.Options) != `{"mode":"diff"}` {
+			t.Errorf("got projection %v", pp.p7on)
+		}
+
+		expect := `{"collection":"motion","title":"foo"}`
+		if got := strings.TrimSpace(resp.Body.String()); got != expect {
+			t.Errorf("got body `%s`, expected `%s`", got, expect)
+		}
+	})
+
+	t.Run("get request", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/projection_preview", nil))
+
+		if resp.Code != 400 {
+			t.Errorf("got status %d, expected 400", resp.Code)
+		}
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("POST", "/system/autoupdate/projection_preview", strings.NewReader("{")))
+
+		if resp.Code != 400 {
+			t.Errorf("got status %d, expected 400", resp.Code)
+		}
+	})
+}
+
+type ProjectorConnecterStub struct {
+	projectorID int
+	messages    []map[dskey.Key][]byte
+}
+
+func (p *ProjectorConnecterStub) ConnectProjector(ctx context.Context, userID int, projectorID int) (autoupdate.Connection, error) {
+	p.projectorID = projectorID
+	return &nexterMock{f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) {
+		if len(p.messages) == 0 {
+			return nil, false
+		}
+		data := p.messages[0]
+		p.messages = p.messages[1:]
+		return func(ctx context.Context) (map[dskey.Key][]byte, error) { return data, nil }, true
+	}}, nil
+}
+
+func TestProjectorStream(t *testing.T) {
+	mux := http.NewServeMux()
+	pc := &ProjectorConnecterStub{
+		messages: []map[dskey.Key][]byte{
+			{
+				dskey.MustKey("projector/1/scale"):                  []byte(`2`),
+				dskey.MustKey("projector/1/current_projection_ids"): []byte(`[5,6]`),
+				dskey.MustKey("projection/5/weight"):                []byte(`3`),
+				dskey.MustKey("projection/5/content"):               []byte(`"five"`),
+				dskey.MustKey("projection/6/weight"):                []byte(`1`),
+				dskey.MustKey("projection/6/content"):               []byte(`"six"`),
+			},
+			{
+				dskey.MustKey("projection/6/content"): []byte(`"six"`),
+			},
+			{
+				dskey.MustKey("projector/1/current_projection_ids"): []byte(`[5]`),
+				dskey.MustKey("projection/6/weight"):                nil,
+				dskey.MustKey("projection/6/content"):               nil,
+			},
+		},
+	}
+	ahttp.HandleProjectorStream(mux, fakeAuth(1), pc, ahttp.Config{})
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/projector?projector_id=1", nil))
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d, expected 200", resp.Code)
+	}
+
+	if pc.projectorID != 1 {
+		t.Errorf("got projector %d, expected 1", pc.projectorID)
+	}
+
+	if got := resp.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("got content type %s", got)
+	}
+
+	expect := "event: projector\n" +
+		`data: {"id":1,"scale":2,"projections":[{"id":6,"weight":1,"content":"six"},{"id":5,"weight":3,"content":"five"}]}` + "\n\n" +
+		"event: projector\n" +
+		`data: {"id":1,"scale":2,"projections":[{"id":5,"weight":3,"content":"five"}]}` + "\n\n"
+	if got := resp.Body.String(); got != expect {
+		t.Errorf("got body\n%s\nexpected\n%s", got, expect)
+	}
+}
+
+func TestProjectorStreamInvalidID(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleProjectorStream(mux, fakeAuth(1), &ProjectorConnecterStub{}, ahttp.Config{})
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/projector?projector_id=foo", nil))
+
+	if resp.Code != 400 {
+		t.Errorf("got status %d, expected 400", resp.Code)
+	}
+}
+
+type ProjectorSnapshotStub struct {
+	data map[dskey.Key][]byte
+}
+
+func (p *ProjectorSnapshotStub) ProjectorSnapshot(ctx context.Context, userID int, projectorID int) (map[dskey.Key][]byte, error) {
+	return p.data, nil
+}
+
+func TestProjectorSnapshot(t *testing.T) {
+	mux := http.NewServeMux()
+	ps := &ProjectorSnapshotStub{
+		data: map[dskey.Key][]byte{
+			dskey.MustKey("projector/1/current_projection_ids"): []byte(`[5]`),
+			dskey.MustKey("projection/5/content_object_id"):     []byte(`"motion/3"`),
+			dskey.MustKey("projection/5/content"):               []byte(`{"collection":"motion","title":"<b>Motion</b>"}`),
+		},
+	}
+	ahttp.HandleProjectorSnapshot(mux, fakeAuth(1), ps, ahttp.Config{})
+
+	t.Run("json", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/projector_snapshot?projector_id=1", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200", resp.Code)
+		}
+
+		expect := `{"id":1,"projections":[{"id":5,"content_object_id":"motion/3","content":{"collection":"motion","title":"\u003cb\u003eMotion\u003c/b\u003e"}}]}`
+		if got := strings.TrimSpace(resp.Body.String()); got != expect {
+			t.Errorf("got body `%s`, expected `%s`", got, expect)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/projector_snapshot?projector_id=1&format=html", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200", resp.Code)
+		}
+
+		if got := resp.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+			t.Errorf("got content type %s", got)
+		}
+
+		body := resp.Body.String()
+		for _, expect := range []string{
+			`data-content-object-id="motion/3"`,
+			`<h1>&lt;b&gt;Motion&lt;/b&gt;</h1>`,
+			`<script type="application/json" id="projector-data">{"id":1,`,
+		} {
+			if !strings.Contains(body, expect) {
+				t.Errorf("body does not contain `%s`:\n%s", expect, body)
+			}
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/projector_snapshot?projector_id=1&format=png", nil))
+
+		if resp.Code != 400 {
+			t.Errorf("got status %d, expected 400", resp.Code)
+		}
+	})
+}
+
 // fakeAuth implements the http.Authenticater interface. It allways returs the given
 // user id.
 type fakeAuth int
@@ -355,3 +892,95 @@ func (a fakeAuth) FromContext(ctx context.Context) int {
 func (a fakeAuth) AuthenticatedContext(ctx context.Context, _ int) context.Context {
 	return ctx
 }
+
+type blockingConnection struct{}
+
+func (blockingConnection) Next() (func(context.Context) (map[dskey.Key][]byte, error), bool) {
+	return nil, false
+}
+
+func (blockingConnection) NextWithFilter(ctx context.Context, _ string) (map[dskey.Key][]byte, string, error) {
+	<-ctx.Done()
+	return nil, "", ctx.Err()
+}
+
+type blockingConnecter struct{}
+
+func (blockingConnecter) Connect(context.Context, int, autoupdate.KeysBuilder) (autoupdate.Connection, error) {
+	return blockingConnection{}, nil
+}
+
+func (blockingConnecter) SingleData(context.Context, int, autoupdate.KeysBuilder) (map[dskey.Key][]byte, error) {
+	return nil, nil
+}
+
+func TestLongpollingTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), blockingConnecter{}, [2]*ahttp.ConnectionCount{}, ahttp.Config{LongpollingTimeout: time.Millisecond})
+
+	req := httptest.NewRequest("GET", "/system/autoupdate?longpolling=1&k=user/1/username", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("Got status %d, expected 200: %s", rec.Code, rec.Body.String())
+	}
+
+	_, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parsing content type: %v", err)
+	}
+
+	mr := multipart.NewReader(rec.Body, params["boundary"])
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("reading data part: %v", err)
+	}
+
+	data, _ := io.ReadAll(part)
+	if got := strings.TrimSpace(string(data)); got != "{}" {
+		t.Errorf("Got data `%s`, expected `{}`", got)
+	}
+}
+
+func TestSingleETag(t *testing.T) {
+	mux := http.NewServeMux()
+	f := func(ctx context.Context) (map[dskey.Key][]byte, error) {
+		return map[dskey.Key][]byte{myKey1: []byte(`"bar"`)}, nil
+	}
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) { return f, true },
+	}
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
+
+	req := httptest.NewRequest("GET", "/system/autoupdate?single=1&k=user/1/username", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	etag := rec.Header().Get("ETag")
+	if rec.Code != 200 || etag == "" {
+		t.Fatalf("Got status %d and etag `%s`, expected 200 and an etag", rec.Code, etag)
+	}
+
+	req = httptest.NewRequest("GET", "/system/autoupdate?single=1&k=user/1/username", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Got status %d, expected 304", rec.Code)
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("Got body `%s`, expected no body", rec.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/system/autoupdate?single=1&k=user/1/username", nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Errorf("Got status %d with other etag, expected 200", rec.Code)
+	}
+}
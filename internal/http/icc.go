@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/icc"
+)
+
+// InterClientCommunicator sends notify messages and applause between the
+// clients of a meeting.
+type InterClientCommunicator interface {
+	SendNotify(ctx context.Context, userID int, body io.Reader) error
+	ReceiveNotify(ctx context.Context, meetingID int, userID int, send func([]byte) error) error
+	SendApplause(ctx context.Context, meetingID int, userID int) error
+	ReceiveApplause(ctx context.Context, meetingID int, userID int, send func(icc.Applause) error) error
+}
+
+// HandleICC registers the routes of the inter client communication. The routes
+// are not registered, if communicator is nil.
+//
+// A GET request streams the messages as newline delimited json. A POST request
+// sends a message.
+//
+// /system/autoupdate/icc/notify?meeting_id=1
+// /system/autoupdate/icc/applause?meeting_id=1
+func HandleICC(mux *http.ServeMux, auth Authenticater, communicator InterClientCommunicator, cfg Config) {
+	if communicator == nil {
+		return
+	}
+
+	notify := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid := auth.FromContext(ctx)
+
+		if r.Method == http.MethodPost {
+			if err := communicator.SendNotify(ctx, uid, r.Body); err != nil {
+				handleErrorWithStatus(w, fmt.Errorf("sending notify message: %w", err))
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		meetingID, ok := iccMeetingID(w, r)
+		if !ok {
+			return
+		}
+
+		stream := newNDJSONStream(w)
+		if err := communicator.ReceiveNotify(ctx, meetingID, uid, stream.sendRaw); err != nil {
+			stream.handleError(ctx, fmt.Errorf("receiving notify messages: %w", err))
+		}
+	})
+
+	applause := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid := auth.FromContext(ctx)
+
+		meetingID, ok := iccMeetingID(w, r)
+		if !ok {
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			if err := communicator.SendApplause(ctx, meetingID, uid); err != nil {
+				handleErrorWithStatus(w, fmt.Errorf("sending applause: %w", err))
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		stream := newNDJSONStream(w)
+		err := communicator.ReceiveApplause(ctx, meetingID, uid, func(a icc.Applause) error {
+			return stream.send(a)
+		})
+		if err != nil {
+			stream.handleError(ctx, fmt.Errorf("receiving applause: %w", err))
+		}
+	})
+
+	for path, handler := range map[string]http.Handler{"/icc/notify": notify, "/icc/applause": applause} {
+		mux.Handle(
+			prefixPublic+path,
+			routeMiddleware(
+				memoryThrottleMiddleware(
+					connectionLimitMiddleware(
+						validRequest(authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeICC)), auth)),
+						cfg.ConnectionLimiter,
+					),
+					cfg.MemoryThrottle,
+				),
+				routeICC,
+			),
+		)
+	}
+}
+
+// iccMeetingID returns the meeting id from the query. If it is invalid, an
+// error is written to the client.
+func iccMeetingID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	meetingID, err := strconv.Atoi(r.URL.Query().Get("meeting_id"))
+	if err != nil || meetingID < 1 {
+		handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("meeting_id has to be a positive number, not `%s`", r.URL.Query().Get("meeting_id"))})
+		return 0, false
+	}
+	return meetingID, true
+}
+
+// ndjsonStream writes newline delimited json to the client. The header is
+// written with the first message, so errors before can still set the status
+// code.
+type ndjsonStream struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func newNDJSONStream(w http.ResponseWriter) *ndjsonStream {
+	return &ndjsonStream{w: w}
+}
+
+func (s *ndjsonStream) sendRaw(message []byte) error {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.w.Header().Set("Cache-Control", "no-store, max-age=0")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+
+	if _, err := s.w.Write(append(message, '\n')); err != nil {
+		return err
+	}
+	s.w.(http.Flusher).Flush()
+	return nil
+}
+
+func (s *ndjsonStream) send(v any) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	return s.sendRaw(encoded)
+}
+
+func (s *ndjsonStream) handleError(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	if s.started {
+		handleErrorWithoutStatus(s.w, err)
+		return
+	}
+	handleErrorWithStatus(s.w, err)
+}
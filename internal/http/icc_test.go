@@ -0,0 +1,155 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/icc"
+)
+
+type iccStub struct {
+	uid       int
+	meetingID int
+	body      string
+	err       error
+}
+
+func (s *iccStub) SendNotify(ctx context.Context, userID int, body io.Reader) error {
+	s.uid = userID
+	b, _ := io.ReadAll(body)
+	s.body = string(b)
+	return s.err
+}
+
+func (s *iccStub) ReceiveNotify(ctx context.Context, meetingID int, userID int, send func([]byte) error) error {
+	s.uid = userID
+	s.meetingID = meetingID
+	if s.err != nil {
+		return s.err
+	}
+
+	if err := send([]byte(`{"channel_id":"abc:1:1"}`)); err != nil {
+		return err
+	}
+	return send([]byte(`{"name":"hello"}`))
+}
+
+func (s *iccStub) SendApplause(ctx context.Context, meetingID int, userID int) error {
+	s.uid = userID
+	s.meetingID = meetingID
+	return s.err
+}
+
+func (s *iccStub) ReceiveApplause(ctx context.Context, meetingID int, userID int, send func(icc.Applause) error) error {
+	s.uid = userID
+	s.meetingID = meetingID
+	return send(icc.Applause{Level: 2, PresentUsers: 5})
+}
+
+type iccClientError struct{}
+
+func (iccClientError) Error() string { return "user 1 is not in meeting 7" }
+func (iccClientError) Type() string  { return "permission_denied" }
+
+func TestICC(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		err        error
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "receive notify",
+			method:     "GET",
+			url:        "/system/autoupdate/icc/notify?meeting_id=7",
+			expectCode: 200,
+			expectBody: "{\"channel_id\":\"abc:1:1\"}\n{\"name\":\"hello\"}\n",
+		},
+		{
+			name:       "send notify",
+			method:     "POST",
+			url:        "/system/autoupdate/icc/notify",
+			body:       `{"name":"hello"}`,
+			expectCode: 202,
+		},
+		{
+			name:       "receive applause",
+			method:     "GET",
+			url:        "/system/autoupdate/icc/applause?meeting_id=7",
+			expectCode: 200,
+			expectBody: "{\"level\":2,\"present_users\":5}\n",
+		},
+		{
+			name:       "send applause",
+			method:     "POST",
+			url:        "/system/autoupdate/icc/applause?meeting_id=7",
+			expectCode: 202,
+		},
+		{
+			name:       "invalid meeting",
+			method:     "GET",
+			url:        "/system/autoupdate/icc/notify?meeting_id=foo",
+			expectCode: 400,
+		},
+		{
+			name:       "permission denied",
+			method:     "GET",
+			url:        "/system/autoupdate/icc/notify?meeting_id=7",
+			err:        iccClientError{},
+			expectCode: 400,
+		},
+		{
+			name:       "server error",
+			method:     "POST",
+			url:        "/system/autoupdate/icc/applause?meeting_id=7",
+			err:        errors.New("redis is gone"),
+			expectCode: 500,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &iccStub{err: tt.err}
+			mux := http.NewServeMux()
+			ahttp.HandleICC(mux, fakeAuth(1), stub, ahttp.Config{})
+
+			resp := httptest.NewRecorder()
+			mux.ServeHTTP(resp, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+
+			if resp.Code != tt.expectCode {
+				t.Fatalf("got status %d, expected %d: %s", resp.Code, tt.expectCode, resp.Body.String())
+			}
+
+			if tt.expectBody != "" && resp.Body.String() != tt.expectBody {
+				t.Errorf("got body `%s`, expected `%s`", resp.Body.String(), tt.expectBody)
+			}
+
+			if tt.expectCode < 300 && stub.uid != 1 {
+				t.Errorf("icc was called with user %d, expected 1", stub.uid)
+			}
+
+			if tt.body != "" && stub.body != tt.body {
+				t.Errorf("icc got body `%s`, expected `%s`", stub.body, tt.body)
+			}
+		})
+	}
+}
+
+func TestICCDisabled(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleICC(mux, fakeAuth(1), nil, ahttp.Config{})
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/icc/notify?meeting_id=7", nil))
+
+	if resp.Code != 404 {
+		t.Errorf("got status %d, expected 404", resp.Code)
+	}
+}
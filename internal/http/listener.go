@@ -0,0 +1,120 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// systemdFirstFD is the first file descriptor passed by systemd socket
+// activation. See sd_listen_fds(3).
+const systemdFirstFD = 3
+
+var (
+	envSocket       = environment.NewVariable("OPENSLIDES_AUTOUPDATE_SOCKET", "", "Path of a unix socket the service listens on in addition to the tcp port.")
+	envInternalAddr = environment.NewVariable("AUTOUPDATE_INTERNAL_ADDR", "", "Address for the internal routes, for example `127.0.0.1:9015`. If empty, the internal routes are served on the public port.")
+)
+
+// listen creates all listeners for the public routes.
+//
+// If the process was started with systemd socket activation, the passed
+// sockets are used instead of the tcp address and the unix socket.
+func listen(addr string, socketPath string) ([]net.Listener, error) {
+	activated, err := systemdListeners()
+	if err != nil {
+		return nil, fmt.Errorf("using systemd sockets: %w", err)
+	}
+
+	if len(activated) > 0 {
+		return activated, nil
+	}
+
+	tcpListener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	listeners := []net.Listener{tcpListener}
+
+	if socketPath != "" {
+		unixListener, err := listenUnix(socketPath)
+		if err != nil {
+			tcpListener.Close()
+			return nil, fmt.Errorf("listen on unix socket %s: %w", socketPath, err)
+		}
+		listeners = append(listeners, unixListener)
+	}
+
+	return listeners, nil
+}
+
+// listenUnix listens on a unix socket. An old socket file from a previous run
+// is removed.
+func listenUnix(path string) (net.Listener, error) {
+	info, err := os.Stat(path)
+	if err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove old socket: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("checking socket path: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Allow the reverse proxy to connect, if it is in the same group.
+	if err := os.Chmod(path, 0o660); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("set permissions of socket: %w", err)
+	}
+
+	return listener, nil
+}
+
+// systemdListeners returns the listeners passed by systemd socket activation.
+//
+// Returns nil, if the process was not started with socket activation.
+func systemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	// The sockets should not be passed to child processes.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, count)
+	for fd := systemdFirstFD; fd < systemdFirstFD+count; fd++ {
+		file := os.NewFile(uintptr(fd), "systemd-socket-"+strconv.Itoa(fd))
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("file descriptor %d: %w", fd, err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
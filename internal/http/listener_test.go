@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "autoupdate.sock")
+
+	first, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("first listen: %v", err)
+	}
+	first.(*net.UnixListener).SetUnlinkOnClose(false)
+	first.Close()
+
+	// The old socket file is still there and has to be replaced.
+	second, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("second listen: %v", err)
+	}
+	defer second.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial socket: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListenUnixNoSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "some_file")
+	if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if _, err := listenUnix(path); err == nil {
+		t.Errorf("listenUnix on a normal file did not return an error")
+	}
+}
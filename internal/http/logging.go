@@ -0,0 +1,214 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+var logger = logging.For(logging.HTTP)
+
+var envAccessLog = environment.NewVariable("AUTOUPDATE_ACCESS_LOG", "false", "Write a log line for each finished request.")
+
+// reValidRequestID is used to decide, if a request id given by the client is
+// used or if a new one is generated.
+var reValidRequestID = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,128}$`)
+
+type requestInfoKey struct{}
+
+// requestInfo holds information about a request, that are gathered while the
+// request is processed.
+type requestInfo struct {
+	requestID string
+	clientIP  string
+	userID    int
+	hasUser   bool
+	route     string
+
+	// audit is true, if the request has to be written to the audit log.
+	audit bool
+}
+
+// RequestIDFromContext returns the request id of the request.
+//
+// Returns an empty string, if the context does not belong to a request.
+func RequestIDFromContext(ctx context.Context) string {
+	info, ok := ctx.Value(requestInfoKey{}).(*requestInfo)
+	if !ok {
+		return ""
+	}
+	return info.requestID
+}
+
+// setUserForLog saves the user id of the request for the access log.
+func setUserForLog(ctx context.Context, userID int) {
+	info, ok := ctx.Value(requestInfoKey{}).(*requestInfo)
+	if !ok {
+		return
+	}
+	info.userID = userID
+	info.hasUser = true
+}
+
+// requestIDMiddleware uses the X-Request-ID header from the request or
+// generates a new one. The id is saved in the context and written back to the
+// client as response header.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !reValidRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestInfoKey{}, &requestInfo{requestID: requestID})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// This can not happen on supported platforms.
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// accessLogMiddleware writes a log line after each request of the given route
+// groups.
+//
+// Has to be called inside the requestIDMiddleware.
+func accessLogMiddleware(next http.Handler, logger *slog.Logger, routes routeSet) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		cw := &countingWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(cw, r)
+
+		info, _ := r.Context().Value(requestInfoKey{}).(*requestInfo)
+		if info == nil {
+			info = new(requestInfo)
+		}
+
+		if !routes.has(info.route) {
+			return
+		}
+
+		user := "-"
+		if info.hasUser {
+			user = strconv.Itoa(info.userID)
+		}
+
+		logger.Info(
+			"Access",
+			"request_id", RequestIDFromContext(r.Context()),
+			"ip", ClientIPFromContext(r.Context()),
+			"method", r.Method,
+			"path", r.URL.Path,
+			"user", user,
+			"status", cw.status,
+			"duration", time.Since(start).Round(time.Millisecond),
+			"bytes", cw.written,
+		)
+	})
+}
+
+// countingWriter is a http.ResponseWriter that remembers the status code and
+// the amount of written bytes.
+type countingWriter struct {
+	http.ResponseWriter
+	status      int
+	written     int
+	wroteHeader bool
+}
+
+func (w *countingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.written += n
+	return n, err
+}
+
+// Flush implements the http.Flusher interface.
+func (w *countingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap is used by http.ResponseController.
+func (w *countingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// HandleLogLevel registers a route to read and change the log levels at
+// runtime.
+//
+// A GET request returns the level of each subsystem. A POST request sets the
+// level of one subsystem or of all subsystems, if no subsystem is given.
+//
+// The route needs an authenticated superadmin. It should only be served on the
+// internal listener.
+//
+// /debug/log_level?subsystem=auth&level=debug
+func HandleLogLevel(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			level, err := logging.ParseLevel(r.URL.Query().Get("level"))
+			if err != nil {
+				handleErrorWithStatus(w, invalidRequestError{err})
+				return
+			}
+
+			subsystems := logging.Subsystems
+			if subsystem := r.URL.Query().Get("subsystem"); subsystem != "" {
+				subsystems = []string{subsystem}
+			}
+
+			for _, subsystem := range subsystems {
+				if err := logging.SetLevel(subsystem, level); err != nil {
+					handleErrorWithStatus(w, invalidRequestError{err})
+					return
+				}
+			}
+
+			logger.Info("Log level changed", "subsystems", strings.Join(subsystems, ","), "level", level)
+
+		default:
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("only GET or POST requests are supported")})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(logging.Levels()); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding log levels: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/log_level", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+}
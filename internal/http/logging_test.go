@@ -0,0 +1,93 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var gotID string
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = RequestIDFromContext(r.Context())
+	}))
+
+	t.Run("from client", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(requestIDHeader, "my-id")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if gotID != "my-id" {
+			t.Errorf("got request id `%s`, expected `my-id`", gotID)
+		}
+
+		if got := rec.Header().Get(requestIDHeader); got != "my-id" {
+			t.Errorf("got response header `%s`, expected `my-id`", got)
+		}
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(requestIDHeader, "invalid id with spaces")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if len(gotID) != 32 {
+			t.Errorf("got request id `%s`, expected a generated id", gotID)
+		}
+
+		if got := rec.Header().Get(requestIDHeader); got != gotID {
+			t.Errorf("got response header `%s`, expected `%s`", got, gotID)
+		}
+	})
+}
+
+func TestRequestIDInError(t *testing.T) {
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("some error")})
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(requestIDHeader, "my-id")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	expect := `{"type":"urn:openslides:autoupdate:error:invalid_request","title":"Bad Request","status":400,"detail":"Invalid request: some error","request_id":"my-id","error":{"type":"invalid_request","msg":"Invalid request: some error","request_id":"my-id"}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != expect {
+		t.Errorf("got body `%s`, expected `%s`", got, expect)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+
+	handler := requestIDMiddleware(accessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setUserForLog(r.Context(), 42)
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	}), logger, routeSet{all: true}))
+
+	req := httptest.NewRequest("POST", "/system/autoupdate", nil)
+	req.Header.Set(requestIDHeader, "my-id")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := buf.String()
+	for _, expect := range []string{
+		"request_id=my-id",
+		"method=POST",
+		"path=/system/autoupdate",
+		"user=42",
+		"status=418",
+		"bytes=5",
+	} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("log line `%s` does not contain `%s`", got, expect)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logout"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+const (
+	// defaultLogoutWait is the time, the logout route waits for the reports of
+	// the instances, if the request does not set it.
+	defaultLogoutWait = 2 * time.Second
+
+	// maxLogoutWait is the longest time, a request can wait for the reports.
+	maxLogoutWait = 30 * time.Second
+)
+
+// ForcedLogouter closes the connections of a session or of a user on all
+// instances.
+type ForcedLogouter interface {
+	Logout(ctx context.Context, sessionID string, userID int, wait time.Duration) (logout.Result, error)
+}
+
+// HandleForcedLogout registers an internal route, that closes the
+// connections of a session or of all sessions of a user on all instances. The
+// session is also revoked.
+//
+// The route waits for the reports of the instances and returns, how many
+// instances answered and how many connections they closed.
+//
+// POST /internal/autoupdate/logout?session_id=abc
+// POST /internal/autoupdate/logout?user_id=5&wait=5s
+func HandleForcedLogout(mux *http.ServeMux, logouter ForcedLogouter) {
+	if logouter == nil {
+		return
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			handleErrorInternal(w, invalidRequestError{fmt.Errorf("only POST requests are supported")})
+			return
+		}
+
+		query := r.URL.Query()
+		userID, wait, err := parseLogoutQuery(query)
+		if err != nil {
+			handleErrorInternal(w, err)
+			return
+		}
+
+		result, err := logouter.Logout(r.Context(), query.Get("session_id"), userID, wait)
+		if err != nil {
+			handleErrorInternal(w, fmt.Errorf("forced logout: %w", err))
+			return
+		}
+
+		logger.Info("Forced logout", "user_id", userID, "instances", result.Instances, "closed", result.Closed)
+		writeLogoutResult(w, result)
+	})
+
+	mux.Handle(prefixInternal+"/logout", routeMiddleware(handler, routeInternal))
+}
+
+// Terminator closes the connections of a session or of a user on all instances
+// without a logout.
+type Terminator interface {
+	Terminate(ctx context.Context, sessionID string, userID int, reason string, message string, wait time.Duration) (logout.Result, error)
+}
+
+// HandleTerminate registers an internal route, that closes the connections of
+// a session or of all sessions of a user on all instances. In contrast to the
+// forced logout, the session stays valid.
+//
+// The streams get a last line with the reason code and the optional message:
+//
+// {"terminated":{"reason":"moderation","message":"..."}}
+//
+// The arguments are the same as for the forced logout. The reason defaults to
+// `terminated`.
+//
+// POST /internal/autoupdate/terminate?user_id=5&reason=moderation&message=Please+reload
+func HandleTerminate(mux *http.ServeMux, terminator Terminator) {
+	if terminator == nil {
+		return
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			handleErrorInternal(w, invalidRequestError{fmt.Errorf("only POST requests are supported")})
+			return
+		}
+
+		query := r.URL.Query()
+		userID, wait, err := parseLogoutQuery(query)
+		if err != nil {
+			handleErrorInternal(w, err)
+			return
+		}
+
+		reason := query.Get("reason")
+		if reason == "" {
+			reason = "terminated"
+		}
+
+		result, err := terminator.Terminate(r.Context(), query.Get("session_id"), userID, reason, query.Get("message"), wait)
+		if err != nil {
+			handleErrorInternal(w, fmt.Errorf("terminate connections: %w", err))
+			return
+		}
+
+		logger.Info("Terminated connections", "user_id", userID, "reason", reason, "instances", result.Instances, "closed", result.Closed)
+		writeLogoutResult(w, result)
+	})
+
+	mux.Handle(prefixInternal+"/terminate", routeMiddleware(handler, routeInternal))
+}
+
+// parseLogoutQuery reads the arguments user_id and wait.
+func parseLogoutQuery(query url.Values) (int, time.Duration, error) {
+	var userID int
+	if raw := query.Get("user_id"); raw != "" {
+		var err error
+		userID, err = strconv.Atoi(raw)
+		if err != nil {
+			return 0, 0, invalidRequestError{fmt.Errorf("user_id has to be a number, not %s", raw)}
+		}
+	}
+
+	wait := defaultLogoutWait
+	if raw := query.Get("wait"); raw != "" {
+		var err error
+		wait, err = environment.ParseDuration(raw)
+		if err != nil || wait < 0 || wait > maxLogoutWait {
+			return 0, 0, invalidRequestError{fmt.Errorf("wait has to be a duration up to %s, not %s", maxLogoutWait, raw)}
+		}
+	}
+
+	return userID, wait, nil
+}
+
+func writeLogoutResult(w http.ResponseWriter, result logout.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		handleErrorWithoutStatus(w, fmt.Errorf("encoding result: %w", err))
+		return
+	}
+}
+
+// writeTermination writes the reason of a termination as last line of a
+// stream.
+func writeTermination(notice *maintenanceNotice, termination logout.Termination, compress bool) error {
+	message, err := json.Marshal(map[string]logout.Termination{"terminated": termination})
+	if err != nil {
+		return fmt.Errorf("encoding termination: %w", err)
+	}
+
+	notice.mu.Lock()
+	defer notice.mu.Unlock()
+
+	if err := writeLine(notice.w, message, compress); err != nil {
+		return err
+	}
+	notice.w.(http.Flusher).Flush()
+	return nil
+}
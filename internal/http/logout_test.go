@@ -0,0 +1,124 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logout"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// terminatingAuth is like fakeAuth, but the context of the request can be
+// canceled with a cause.
+type terminatingAuth struct {
+	fakeAuth
+	cancel chan context.CancelCauseFunc
+}
+
+func (a terminatingAuth) Authenticate(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	ctx, cancel := context.WithCancelCause(r.Context())
+	a.cancel <- cancel
+	return ctx, nil
+}
+
+func TestTerminatedStream(t *testing.T) {
+	first := true
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) {
+			return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+				if first {
+					first = false
+					return map[dskey.Key][]byte{myKey1: []byte(`"bar"`)}, nil
+				}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}, true
+		},
+	}
+
+	auth := terminatingAuth{fakeAuth: 1, cancel: make(chan context.CancelCauseFunc, 1)}
+
+	mux := http.NewServeMux()
+	ahttp.HandleAutoupdate(mux, auth, connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/system/autoupdate?k=user/1/username", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() {
+		t.Fatalf("stream ended: %v", scanner.Err())
+	}
+
+	(<-auth.cancel)(logout.Termination{Reason: "moderation", Message: "Please reload"})
+
+	if !scanner.Scan() {
+		t.Fatalf("stream ended: %v", scanner.Err())
+	}
+
+	if got, expect := scanner.Text(), `{"terminated":{"reason":"moderation","message":"Please reload"}}`; got != expect {
+		t.Errorf("got line %s, expected %s", got, expect)
+	}
+
+	if scanner.Scan() {
+		t.Errorf("got line %s after the termination", scanner.Text())
+	}
+}
+
+type terminatorStub struct {
+	reason string
+}
+
+func (t *terminatorStub) Terminate(ctx context.Context, sessionID string, userID int, reason string, message string, wait time.Duration) (logout.Result, error) {
+	t.reason = reason
+	return logout.Result{Instances: 2, Closed: 3}, nil
+}
+
+func TestHandleTerminate(t *testing.T) {
+	terminator := &terminatorStub{}
+
+	mux := http.NewServeMux()
+	ahttp.HandleTerminate(mux, terminator)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/internal/autoupdate/terminate?user_id=5", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %s: %s", http.StatusText(rec.Code), rec.Body.String())
+	}
+
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"instances":2,"closed":3}` {
+		t.Errorf("got body %s", got)
+	}
+
+	if terminator.reason != "terminated" {
+		t.Errorf("got reason %s, expected the default", terminator.reason)
+	}
+
+	for _, query := range []string{"user_id=x", "user_id=5&wait=1h"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("POST", "/internal/autoupdate/terminate?"+query, nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %s, expected 400", query, http.StatusText(rec.Code))
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/maintenance"
+)
+
+// HandleMaintenance registers a route to start and end the maintenance mode.
+//
+// GET returns the state. POST with `active=true` starts the maintenance with
+// the optional argument `message`. POST with `active=false` ends it. The
+// maintenance only affects this instance.
+//
+// The route is protected like the profile routes.
+func HandleMaintenance(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			active, err := strconv.ParseBool(r.URL.Query().Get("active"))
+			if err != nil {
+				handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("argument active has to be true or false")})
+				return
+			}
+
+			if active {
+				maintenance.Start(r.URL.Query().Get("message"))
+				logger.Info("Maintenance started")
+			} else {
+				maintenance.End()
+				logger.Info("Maintenance ended")
+			}
+
+		default:
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("only GET or POST requests are supported")})
+			return
+		}
+
+		status, _ := maintenance.Current()
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding maintenance state: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/maintenance", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+}
+
+// maintenanceNotice writes a control message to a stream, when the maintenance
+// starts or ends:
+//
+// {"maintenance":{"active":true,"message":"...","since":1700000000}}
+//
+// A connection, that starts during maintenance, gets the message at once. The
+// data of the stream has to be written with the lock, so the messages are not
+// mixed.
+type maintenanceNotice struct {
+	mu   sync.Mutex
+	w    io.Writer
+	stop func()
+}
+
+// newMaintenanceNotice watches the maintenance until stop is called. Without
+// watch, no messages are written and only the lock is used.
+func newMaintenanceNotice(ctx context.Context, w io.Writer, watch bool) *maintenanceNotice {
+	if !watch {
+		return &maintenanceNotice{w: w, stop: func() {}}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	n := &maintenanceNotice{w: w}
+	n.stop = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+
+		status, changed := maintenance.Current()
+		if status.Active {
+			n.write(status)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+
+			status, changed = maintenance.Current()
+			n.write(status)
+		}
+	}()
+
+	return n
+}
+
+func (n *maintenanceNotice) write(status maintenance.Status) {
+	message, err := json.Marshal(map[string]maintenance.Status{"maintenance": status})
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// A failed write closes the connection. The error is handled, when the
+	// next data is written.
+	if _, err := n.w.Write(append(message, '\n')); err != nil {
+		return
+	}
+	n.w.(http.Flusher).Flush()
+}
@@ -0,0 +1,74 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/maintenance"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+func TestMaintenanceNotice(t *testing.T) {
+	defer maintenance.End()
+
+	first := true
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) {
+			return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+				if first {
+					first = false
+					return map[dskey.Key][]byte{myKey1: []byte(`"bar"`)}, nil
+				}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}, true
+		},
+	}
+
+	mux := http.NewServeMux()
+	ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/system/autoupdate?k=user/1/username", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	expectLine := func(expect string) {
+		t.Helper()
+
+		if !scanner.Scan() {
+			t.Fatalf("stream ended: %v", scanner.Err())
+		}
+
+		if got := scanner.Text(); got != expect {
+			t.Errorf("got line %s, expected %s", got, expect)
+		}
+	}
+
+	expectLine(`{"user/1/username":"bar"}`)
+
+	maintenance.Start("Update")
+	status, _ := maintenance.Current()
+	expectLine(`{"maintenance":{"active":true,"message":"Update","since":` + strconv.FormatInt(status.Since, 10) + `}}`)
+
+	maintenance.End()
+	expectLine(`{"maintenance":{"active":false}}`)
+}
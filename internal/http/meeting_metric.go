@@ -0,0 +1,215 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envMetricMeetings = environment.NewVariable("AUTOUPDATE_METRIC_MEETINGS", "true", "Add the connections and the sent data of each meeting to the metric.")
+
+// MeetingMetric counts the open connections and the sent data of each
+// meeting.
+//
+// A connection belongs to a meeting, if its first message contains fields of
+// exactly one meeting. Other connections are not counted.
+//
+// Has to be initialized with NewMeetingMetric().
+type MeetingMetric struct {
+	mu         sync.Mutex
+	meetings   map[int]*meetingCount
+	lastMetric time.Time
+}
+
+// meetingCount are the values of one meeting. The messages and the latency
+// are reset with each metric.
+type meetingCount struct {
+	connections  int
+	messages     int
+	bytes        int
+	latency      time.Duration
+	latencyCount int
+}
+
+// NewMeetingMetric initializes a MeetingMetric from the environment.
+//
+// Returns nil, if the metric is disabled.
+func NewMeetingMetric(lookup environment.Environmenter) (*MeetingMetric, error) {
+	enabled, err := strconv.ParseBool(envMetricMeetings.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envMetricMeetings.Key, err)
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+
+	return newMeetingMetric(), nil
+}
+
+func newMeetingMetric() *MeetingMetric {
+	return &MeetingMetric{
+		meetings:   make(map[int]*meetingCount),
+		lastMetric: time.Now(),
+	}
+}
+
+// connect counts a new connection of a meeting. The returned function has to
+// be called, when the connection is closed.
+//
+// Does nothing, if the MeetingMetric is nil.
+func (m *MeetingMetric) connect(meetingID int) func() {
+	if m == nil {
+		return func() {}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.get(meetingID).connections++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			m.get(meetingID).connections--
+		})
+	}
+}
+
+// sent counts a message to a client of a meeting.
+//
+// updateTime is the time of the datastore update, that caused the message. It
+// is ignored, if it is zero.
+func (m *MeetingMetric) sent(meetingID int, bytes int, updateTime time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := m.get(meetingID)
+	count.messages++
+	count.bytes += bytes
+	if !updateTime.IsZero() {
+		count.latency += time.Since(updateTime)
+		count.latencyCount++
+	}
+}
+
+// get returns the values of a meeting. Has to be called with the lock.
+func (m *MeetingMetric) get(meetingID int) *meetingCount {
+	count, ok := m.meetings[meetingID]
+	if !ok {
+		count = new(meetingCount)
+		m.meetings[meetingID] = count
+	}
+	return count
+}
+
+// connections returns the open connections of each meeting. In contrast to
+// values, nothing is reset.
+//
+// Returns nil, if the MeetingMetric is nil.
+func (m *MeetingMetric) connections() map[int]int {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[int]int, len(m.meetings))
+	for meetingID, count := range m.meetings {
+		if count.connections > 0 {
+			out[meetingID] = count.connections
+		}
+	}
+	return out
+}
+
+// Metric adds the values of each meeting to the metric.
+func (m *MeetingMetric) Metric(con metric.Container) {
+	for key, value := range m.values() {
+		con.Add(key, value)
+	}
+}
+
+// values returns the metric values of each meeting since the last call.
+//
+// Meetings without an open connection are removed afterwards.
+func (m *MeetingMetric) values() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make(map[string]int, len(m.meetings)*4)
+	seconds := time.Since(m.lastMetric).Seconds()
+	m.lastMetric = time.Now()
+
+	for meetingID, count := range m.meetings {
+		prefix := fmt.Sprintf("meeting_%d_", meetingID)
+		values[prefix+"connections"] = count.connections
+		values[prefix+"bytes_sent"] = count.bytes
+
+		var perSecond int
+		if seconds > 0 {
+			perSecond = int(math.Round(float64(count.messages) / seconds))
+		}
+		values[prefix+"messages_per_second"] = perSecond
+
+		var latency time.Duration
+		if count.latencyCount > 0 {
+			latency = count.latency / time.Duration(count.latencyCount)
+		}
+		values[prefix+"update_latency_ms"] = int(latency.Milliseconds())
+
+		if count.connections <= 0 {
+			delete(m.meetings, meetingID)
+			continue
+		}
+
+		count.messages = 0
+		count.latency = 0
+		count.latencyCount = 0
+	}
+	return values
+}
+
+// meetingFromData returns the id of the meeting, if the data contains fields
+// of exactly one meeting. Returns 0 otherwise.
+func meetingFromData(data map[dskey.Key][]byte) int {
+	var meetingID int
+	for key := range data {
+		if key.Collection() != "meeting" {
+			continue
+		}
+
+		if meetingID != 0 && meetingID != key.ID() {
+			return 0
+		}
+		meetingID = key.ID()
+	}
+	return meetingID
+}
+
+// byteCounter is an io.Writer that counts the written bytes.
+type byteCounter struct {
+	io.Writer
+	n int
+}
+
+func (w *byteCounter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += n
+	return n, err
+}
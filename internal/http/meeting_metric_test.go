@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// meetingConnecter returns a connection, that sends each message once and
+// then closes.
+type meetingConnecter struct {
+	messages   []map[dskey.Key][]byte
+	updateTime time.Time
+}
+
+func (c *meetingConnecter) Connect(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (autoupdate.Connection, error) {
+	return &meetingConnection{messages: c.messages, updateTime: c.updateTime}, nil
+}
+
+func (c *meetingConnecter) SingleData(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (map[dskey.Key][]byte, error) {
+	return nil, nil
+}
+
+type meetingConnection struct {
+	messages   []map[dskey.Key][]byte
+	updateTime time.Time
+}
+
+func (c *meetingConnection) Next() (func(context.Context) (map[dskey.Key][]byte, error), bool) {
+	if len(c.messages) == 0 {
+		return nil, false
+	}
+
+	data := c.messages[0]
+	c.messages = c.messages[1:]
+	return func(context.Context) (map[dskey.Key][]byte, error) { return data, nil }, true
+}
+
+func (c *meetingConnection) NextWithFilter(ctx context.Context, filterHashes string) (map[dskey.Key][]byte, string, error) {
+	return nil, "", nil
+}
+
+func (c *meetingConnection) UpdateTime() time.Time {
+	return c.updateTime
+}
+
+func TestMeetingMetric(t *testing.T) {
+	m := newMeetingMetric()
+
+	release := m.connect(5)
+	m.sent(5, 100, time.Now().Add(-20*time.Millisecond))
+	m.sent(5, 50, time.Time{})
+
+	values := m.values()
+	if values["meeting_5_connections"] != 1 {
+		t.Errorf("got %d connections, expected 1", values["meeting_5_connections"])
+	}
+
+	if values["meeting_5_bytes_sent"] != 150 {
+		t.Errorf("got %d bytes, expected 150", values["meeting_5_bytes_sent"])
+	}
+
+	if got := values["meeting_5_update_latency_ms"]; got < 20 {
+		t.Errorf("got latency %d ms, expected at least 20", got)
+	}
+
+	release()
+	release()
+
+	values = m.values()
+	if values["meeting_5_connections"] != 0 {
+		t.Errorf("got %d connections after release, expected 0", values["meeting_5_connections"])
+	}
+
+	if values := m.values(); len(values) != 0 {
+		t.Errorf("closed meeting is still in the metric: %v", values)
+	}
+}
+
+func TestMeetingMetricSendMessages(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		first     map[dskey.Key][]byte
+		expectKey string
+	}{
+		{
+			"one meeting",
+			map[dskey.Key][]byte{
+				dskey.MustKey("meeting/7/name"):       []byte(`"my meeting"`),
+				dskey.MustKey("meeting/7/motion_ids"): []byte(`[1]`),
+				dskey.MustKey("motion/1/title"):       []byte(`"my motion"`),
+			},
+			"meeting_7_bytes_sent",
+		},
+		{
+			"many meetings",
+			map[dskey.Key][]byte{
+				dskey.MustKey("meeting/7/name"): []byte(`"my meeting"`),
+				dskey.MustKey("meeting/8/name"): []byte(`"other meeting"`),
+			},
+			"",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMeetingMetric()
+			connecter := &meetingConnecter{
+				messages: []map[dskey.Key][]byte{
+					tt.first,
+					{dskey.MustKey("motion/1/title"): []byte(`"new title"`)},
+				},
+				updateTime: time.Now(),
+			}
+
+			if err := sendMessages(context.Background(), httptest.NewRecorder(), 1, nil, connecter, protocolProfile{control: true}, m, nil, nil, nil, "", nil); err != nil {
+				t.Fatalf("sendMessages: %v", err)
+			}
+
+			values := m.values()
+			if tt.expectKey == "" {
+				if len(values) != 0 {
+					t.Errorf("got metric %v, expected none", values)
+				}
+				return
+			}
+
+			if values[tt.expectKey] == 0 {
+				t.Errorf("got metric %v, expected %s", values, tt.expectKey)
+			}
+
+			if values["meeting_7_connections"] != 0 {
+				t.Errorf("connection was not released")
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// fakeUser implements the Authenticater interface. It allways returns the
+// given user id.
+type fakeUser int
+
+func (a fakeUser) Authenticate(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	return r.Context(), nil
+}
+
+func (a fakeUser) FromContext(ctx context.Context) int {
+	return int(a)
+}
+
+func (a fakeUser) AuthenticatedContext(ctx context.Context, _ int) context.Context {
+	return ctx
+}
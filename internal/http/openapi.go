@@ -0,0 +1,20 @@
+package http
+
+import (
+	_ "embed" // Needed for the openapi document.
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// HandleOpenAPI serves the OpenAPI document that describes the public routes.
+func HandleOpenAPI(mux *http.ServeMux) {
+	url := prefixPublic + "/openapi.json"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPIDocument)
+	})
+
+	mux.Handle(url, routeMiddleware(handler, routeOpenAPI))
+}
@@ -0,0 +1,46 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+)
+
+func TestOpenAPI(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleOpenAPI(mux)
+
+	req := httptest.NewRequest("GET", "/system/autoupdate/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("Got status %d, expected 200", rec.Code)
+	}
+
+	var document struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &document); err != nil {
+		t.Fatalf("Invalid json: %v", err)
+	}
+
+	if document.OpenAPI == "" {
+		t.Errorf("Document has no openapi version")
+	}
+
+	for _, path := range []string{
+		"/system/autoupdate",
+		"/system/autoupdate/health",
+		"/system/autoupdate/version",
+		"/system/autoupdate/history_information",
+	} {
+		if _, ok := document.Paths[path]; !ok {
+			t.Errorf("Path %s is not documented", path)
+		}
+	}
+}
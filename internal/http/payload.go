@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"sync"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// payloadSize holds a histogram for each collection with the bytes, the
+// collection contributes to one autoupdate response.
+var payloadSize = &collectionHistograms{histograms: make(map[string]*metric.Histogram)}
+
+type collectionHistograms struct {
+	mu         sync.Mutex
+	histograms map[string]*metric.Histogram
+}
+
+// observe adds the size of the values of each collection in data. The sizes
+// are the restricted values before compression.
+func (c *collectionHistograms) observe(ctx context.Context, data map[dskey.Key][]byte) {
+	sizes := make(map[string]int64)
+	for key, value := range data {
+		sizes[key.Collection()] += int64(len(value))
+	}
+
+	for collection, size := range sizes {
+		c.histogram(collection).ObserveValue(ctx, size)
+	}
+}
+
+// histogram returns the histogram of a collection. It is created on the first
+// call.
+func (c *collectionHistograms) histogram(collection string) *metric.Histogram {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.histograms[collection]
+	if !ok {
+		h = metric.NewSizeHistogram("payload_"+collection, metric.SizeBuckets...)
+		c.histograms[collection] = h
+	}
+	return h
+}
+
+// Metric adds the histograms of all collections, that were sent.
+func (c *collectionHistograms) Metric(con metric.Container) {
+	c.mu.Lock()
+	histograms := make([]*metric.Histogram, 0, len(c.histograms))
+	for _, h := range c.histograms {
+		histograms = append(histograms, h)
+	}
+	c.mu.Unlock()
+
+	for _, h := range histograms {
+		h.Metric(con)
+	}
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+func TestCollectionHistograms(t *testing.T) {
+	c := &collectionHistograms{histograms: make(map[string]*metric.Histogram)}
+
+	c.observe(context.Background(), map[dskey.Key][]byte{
+		dskey.MustKey("motion/1/title"):       []byte(`"first"`),
+		dskey.MustKey("motion/2/title"):       []byte(`"second"`),
+		dskey.MustKey("user/1/username"):      []byte(`"admin"`),
+		dskey.MustKey("mediafile/1/filename"): nil,
+	})
+
+	if len(c.histograms) != 3 {
+		t.Fatalf("got %d histograms, expected 3", len(c.histograms))
+	}
+
+	if _, ok := c.histograms["motion"]; !ok {
+		t.Errorf("no histogram for motion")
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+)
+
+// Presenter calls the presenters, that are computed on the server.
+type Presenter interface {
+	Present(ctx context.Context, uid int, requests []autoupdate.PresenterRequest) ([]json.RawMessage, error)
+}
+
+// HandlePresenter registers the route to call presenters. The body is a list of
+// presenter calls. The response is the list of the results in the same order.
+//
+// /system/autoupdate/presenter
+//
+//	[{"presenter": "eligible_voters", "data": {"poll_id": 1}}]
+func HandlePresenter(mux *http.ServeMux, auth Authenticater, presenter Presenter, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+
+		if r.Method != http.MethodPost {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("presenter only supports POST requests")})
+			return
+		}
+
+		var requests []autoupdate.PresenterRequest
+		if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("decoding body: %w", err)})
+			return
+		}
+
+		results, err := presenter.Present(r.Context(), uid, requests)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("calling presenters: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding presenter results: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/presenter",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routePresenter)), auth),
+			routePresenter,
+		),
+	)
+}
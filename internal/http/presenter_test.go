@@ -0,0 +1,52 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+)
+
+type presenterStub struct {
+	uid      int
+	requests []autoupdate.PresenterRequest
+}
+
+func (p *presenterStub) Present(ctx context.Context, uid int, requests []autoupdate.PresenterRequest) ([]json.RawMessage, error) {
+	p.uid = uid
+	p.requests = requests
+	return []json.RawMessage{[]byte(`{"entitled_users":3}`)}, nil
+}
+
+func TestPresenter(t *testing.T) {
+	stub := &presenterStub{}
+	mux := http.NewServeMux()
+	ahttp.HandlePresenter(mux, fakeAuth(1), stub, ahttp.Config{})
+
+	resp := httptest.NewRecorder()
+	body := `[{"presenter": "eligible_voters", "data": {"poll_id": 5}}]`
+	mux.ServeHTTP(resp, httptest.NewRequest("POST", "/system/autoupdate/presenter", strings.NewReader(body)))
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d, expected 200: %s", resp.Code, resp.Body.String())
+	}
+
+	if got := strings.TrimSpace(resp.Body.String()); got != `[{"entitled_users":3}]` {
+		t.Errorf("got body %s", got)
+	}
+
+	if stub.uid != 1 || len(stub.requests) != 1 || stub.requests[0].Presenter != "eligible_voters" {
+		t.Errorf("presenter was called with uid %d and requests %v", stub.uid, stub.requests)
+	}
+
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/presenter", nil))
+	if resp.Code != 400 {
+		t.Errorf("GET request: got status %d, expected 400", resp.Code)
+	}
+}
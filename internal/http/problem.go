@@ -0,0 +1,58 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// problemTypePrefix is the prefix of the problem type. The suffix is the type
+// of the ClientError.
+const problemTypePrefix = "urn:openslides:autoupdate:error:"
+
+// problemContentType is the content type for error responses from RFC 7807.
+const problemContentType = "application/problem+json"
+
+// problem is an error response in the format of RFC 7807.
+//
+// The field Legacy contains the error in the old format, so older clients can
+// still read it.
+type problem struct {
+	Type      string        `json:"type"`
+	Title     string        `json:"title"`
+	Status    int           `json:"status"`
+	Detail    string        `json:"detail"`
+	RequestID string        `json:"request_id,omitempty"`
+	Legacy    legacyProblem `json:"error"`
+}
+
+type legacyProblem struct {
+	Type      string `json:"type"`
+	Msg       string `json:"msg"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func newProblem(errType string, status int, detail string, requestID string) problem {
+	return problem{
+		Type:      problemTypePrefix + errType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		RequestID: requestID,
+		Legacy: legacyProblem{
+			Type:      errType,
+			Msg:       detail,
+			RequestID: requestID,
+		},
+	}
+}
+
+// writeProblem writes the problem as one line of json.
+func writeProblem(w http.ResponseWriter, p problem, writeStatusCode bool) {
+	if writeStatusCode {
+		w.Header().Set("Content-Type", problemContentType)
+		w.WriteHeader(p.Status)
+	}
+
+	// Errors can only happen, when the connection is closed.
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"time"
+)
+
+// maxProfileDuration is the longest time, a profile can be captured.
+const maxProfileDuration = 5 * time.Minute
+
+// Profiler decides, who is allowed to create profiles.
+type Profiler interface {
+	CanProfile(ctx context.Context, userID int) (bool, error)
+}
+
+// HandleProfile adds the routes of net/http/pprof and the capture route.
+//
+// All routes need an authenticated superadmin. They should only be served on
+// the internal listener.
+func HandleProfile(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	handle := func(path string, handler http.HandlerFunc) {
+		mux.Handle(path, routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+	}
+
+	handle("/debug/pprof/", pprof.Index)
+	handle("/debug/pprof/cmdline", pprof.Cmdline)
+	handle("/debug/pprof/profile", pprof.Profile)
+	handle("/debug/pprof/symbol", pprof.Symbol)
+	handle("/debug/pprof/trace", pprof.Trace)
+	handle("/debug/pprof/capture", profileCapture)
+}
+
+// profileAuthMiddleware only calls next, if the user is allowed to use the
+// debug routes.
+func profileAuthMiddleware(next http.Handler, auth Authenticater, profiler Profiler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		allowed, err := profiler.CanProfile(ctx, auth.FromContext(ctx))
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("checking profile permission: %w", err))
+			return
+		}
+
+		if !allowed {
+			handleErrorWithStatus(w, permissionDeniedError{"only superadmins can use the debug routes"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// profileCapture captures a profile and returns it as a file.
+//
+// The argument `profile` is `cpu`, `trace` or the name of a runtime profile
+// like `heap` or `goroutine`. The cpu profile and the trace are captured for
+// `seconds` seconds. The default is 30 seconds.
+//
+// /debug/pprof/capture?profile=cpu&seconds=60
+func profileCapture(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("profile")
+	if name == "" {
+		name = "cpu"
+	}
+
+	duration := 30 * time.Second
+	if raw := r.URL.Query().Get("seconds"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 1 || time.Duration(seconds)*time.Second > maxProfileDuration {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("seconds has to be a number between 1 and %d, not `%s`", int(maxProfileDuration.Seconds()), raw)})
+			return
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+
+	var profile *runtimepprof.Profile
+	if name != "cpu" && name != "trace" {
+		profile = runtimepprof.Lookup(name)
+		if profile == nil {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("unknown profile `%s`", name)})
+			return
+		}
+	}
+
+	extension := "pprof"
+	if name == "trace" {
+		extension = "trace"
+	}
+
+	filename := fmt.Sprintf("autoupdate-%s-%s.%s", name, time.Now().UTC().Format("20060102T150405Z"), extension)
+	setHeaders := func() {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	}
+
+	if profile != nil {
+		setHeaders()
+		if err := profile.WriteTo(w, 0); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("writing profile %s: %w", name, err))
+		}
+		return
+	}
+
+	start, stop := runtimepprof.StartCPUProfile, runtimepprof.StopCPUProfile
+	if name == "trace" {
+		start, stop = trace.Start, trace.Stop
+	}
+
+	// The headers have to be set before start writes to w.
+	setHeaders()
+	if err := start(w); err != nil {
+		// Only one cpu profile or trace can run at the same time.
+		w.Header().Del("Content-Disposition")
+		handleErrorWithStatus(w, tooManyRequestsError{})
+		return
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+	stop()
+}
@@ -0,0 +1,261 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+type profilerStub bool
+
+func (p profilerStub) CanProfile(ctx context.Context, userID int) (bool, error) {
+	return bool(p), nil
+}
+
+func TestProfilePermission(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleProfile(mux, fakeAuth(1), profilerStub(false))
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/capture?profile=heap"} {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", path, nil))
+
+		if resp.Code != 400 || !strings.Contains(resp.Body.String(), "permission_denied") {
+			t.Errorf("%s: got status %d with body %s, expected permission denied", path, resp.Code, resp.Body.String())
+		}
+	}
+}
+
+func TestProfileCapture(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleProfile(mux, fakeAuth(1), profilerStub(true))
+
+	t.Run("heap", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/pprof/capture?profile=heap", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d, expected 200", resp.Code)
+		}
+
+		if got := resp.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="autoupdate-heap-`) {
+			t.Errorf("got content disposition %s", got)
+		}
+
+		if resp.Body.Len() == 0 {
+			t.Errorf("got empty profile")
+		}
+	})
+
+	t.Run("cpu stops with the request", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/pprof/capture?profile=cpu&seconds=60", nil).WithContext(ctx))
+
+		if time.Since(start) > 10*time.Second {
+			t.Errorf("capture did not stop with the request")
+		}
+
+		if resp.Code != 200 {
+			t.Errorf("got status %d, expected 200", resp.Code)
+		}
+	})
+
+	t.Run("invalid seconds", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/pprof/capture?seconds=3600", nil))
+
+		if resp.Code != 400 {
+			t.Errorf("got status %d, expected 400", resp.Code)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/pprof/capture?profile=foo", nil))
+
+		if resp.Code != 400 {
+			t.Errorf("got status %d, expected 400", resp.Code)
+		}
+	})
+}
+
+func TestLogLevel(t *testing.T) {
+	defer logging.SetLevel(logging.Auth, slog.LevelInfo)
+
+	mux := http.NewServeMux()
+	ahttp.HandleLogLevel(mux, fakeAuth(1), profilerStub(true))
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("POST", "/debug/log_level?subsystem=auth&level=debug", nil))
+	if resp.Code != 200 {
+		t.Fatalf("got status %d with body %s, expected 200", resp.Code, resp.Body.String())
+	}
+
+	if got := logging.Levels()[logging.Auth]; got != "debug" {
+		t.Errorf("got level %s, expected debug", got)
+	}
+
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/log_level", nil))
+	if !strings.Contains(resp.Body.String(), `"auth":"debug"`) {
+		t.Errorf("got %s, expected auth to be debug", resp.Body.String())
+	}
+
+	for _, query := range []string{"subsystem=auth&level=loud", "subsystem=unknown&level=debug"} {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("POST", "/debug/log_level?"+query, nil))
+		if resp.Code != 400 {
+			t.Errorf("%s: got status %d, expected 400", query, resp.Code)
+		}
+	}
+}
+
+func TestLogLevelPermission(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleLogLevel(mux, fakeAuth(1), profilerStub(false))
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("POST", "/debug/log_level?level=debug", nil))
+
+	if resp.Code != 400 || !strings.Contains(resp.Body.String(), "permission_denied") {
+		t.Errorf("got status %d with body %s, expected permission denied", resp.Code, resp.Body.String())
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	t.Run("superadmin", func(t *testing.T) {
+		mux := http.NewServeMux()
+		ahttp.HandleRuntime(mux, fakeAuth(1), profilerStub(true))
+
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/runtime", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d with body %s, expected 200", resp.Code, resp.Body.String())
+		}
+
+		if !strings.Contains(resp.Body.String(), `"goroutines"`) {
+			t.Errorf("got %s, expected runtime information", resp.Body.String())
+		}
+	})
+
+	t.Run("no permission", func(t *testing.T) {
+		mux := http.NewServeMux()
+		ahttp.HandleRuntime(mux, fakeAuth(1), profilerStub(false))
+
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/runtime", nil))
+
+		if resp.Code != 400 || !strings.Contains(resp.Body.String(), "permission_denied") {
+			t.Errorf("got status %d with body %s, expected permission denied", resp.Code, resp.Body.String())
+		}
+	})
+}
+
+func TestConfigRoute(t *testing.T) {
+	cfg := ahttp.Config{
+		Settings: func() []environment.Setting {
+			return []environment.Setting{{Key: "AUTOUPDATE_PORT", Value: "9013", Source: environment.SourceFile}}
+		},
+	}
+
+	mux := http.NewServeMux()
+	ahttp.HandleConfig(mux, fakeAuth(1), profilerStub(true), cfg)
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/config", nil))
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d with body %s, expected 200", resp.Code, resp.Body.String())
+	}
+
+	if !strings.Contains(resp.Body.String(), `"source": "file"`) {
+		t.Errorf("got %s, expected the source of the setting", resp.Body.String())
+	}
+}
+
+func TestConfigSchemaRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleConfigSchema(mux, fakeAuth(1), profilerStub(true))
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/config/schema", nil))
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d with body %s, expected 200", resp.Code, resp.Body.String())
+	}
+
+	var schema []environment.SchemaVariable
+	if err := json.Unmarshal(resp.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+
+	for _, v := range schema {
+		if v.Key == "AUTOUPDATE_BODY_READ_TIMEOUT" {
+			if v.Type != environment.TypeDuration {
+				t.Errorf("AUTOUPDATE_BODY_READ_TIMEOUT has type %s, expected duration", v.Type)
+			}
+			return
+		}
+	}
+	t.Errorf("schema does not contain AUTOUPDATE_BODY_READ_TIMEOUT")
+}
+
+func TestDashboard(t *testing.T) {
+	t.Run("page", func(t *testing.T) {
+		mux := http.NewServeMux()
+		ahttp.HandleDashboard(mux, fakeAuth(1), profilerStub(false))
+
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/dashboard", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d with body %s, expected 200", resp.Code, resp.Body.String())
+		}
+
+		if got := resp.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+			t.Errorf("got content type %s, expected html", got)
+		}
+	})
+
+	t.Run("data", func(t *testing.T) {
+		mux := http.NewServeMux()
+		ahttp.HandleDashboard(mux, fakeAuth(1), profilerStub(true))
+
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/dashboard/data", nil))
+
+		if resp.Code != 200 {
+			t.Fatalf("got status %d with body %s, expected 200", resp.Code, resp.Body.String())
+		}
+
+		if !strings.Contains(resp.Body.String(), `"goroutines"`) {
+			t.Errorf("got %s, expected runtime information", resp.Body.String())
+		}
+	})
+
+	t.Run("data without permission", func(t *testing.T) {
+		mux := http.NewServeMux()
+		ahttp.HandleDashboard(mux, fakeAuth(1), profilerStub(false))
+
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", "/debug/dashboard/data", nil))
+
+		if resp.Code != 400 || !strings.Contains(resp.Body.String(), "permission_denied") {
+			t.Errorf("got status %d with body %s, expected permission denied", resp.Code, resp.Body.String())
+		}
+	})
+}
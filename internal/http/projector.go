@@ -0,0 +1,339 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// ProjectorConnecter returns a connection for the projections of a projector.
+type ProjectorConnecter interface {
+	ConnectProjector(ctx context.Context, userID int, projectorID int) (autoupdate.Connection, error)
+}
+
+// projectorFrame is the data, that is sent to a projector.
+type projectorFrame struct {
+	ID          int                   `json:"id"`
+	Scale       json.RawMessage       `json:"scale,omitempty"`
+	Scroll      json.RawMessage       `json:"scroll,omitempty"`
+	Projections []projectionFrameItem `json:"projections"`
+}
+
+// projectionFrameItem is one projection of a projector.
+type projectionFrameItem struct {
+	ID              int             `json:"id"`
+	Type            json.RawMessage `json:"type,omitempty"`
+	ContentObjectID json.RawMessage `json:"content_object_id,omitempty"`
+	Options         json.RawMessage `json:"options,omitempty"`
+	Stable          json.RawMessage `json:"stable,omitempty"`
+	Weight          json.RawMessage `json:"weight,omitempty"`
+	Content         json.RawMessage `json:"content,omitempty"`
+
+	weight int
+}
+
+// resolveProjector builds the frame of a projector from the autoupdate data.
+func resolveProjector(state map[dskey.Key][]byte, projectorID int) (projectorFrame, error) {
+	frame := projectorFrame{
+		ID:          projectorID,
+		Scale:       state[dskey.MustKey("projector/%d/scale", projectorID)],
+		Scroll:      state[dskey.MustKey("projector/%d/scroll", projectorID)],
+		Projections: []projectionFrameItem{},
+	}
+
+	var projectionIDs []int
+	if raw := state[dskey.MustKey("projector/%d/current_projection_ids", projectorID)]; raw != nil {
+		if err := json.Unmarshal(raw, &projectionIDs); err != nil {
+			return projectorFrame{}, fmt.Errorf("decoding current projection ids: %w", err)
+		}
+	}
+
+	for _, id := range projectionIDs {
+		item := projectionFrameItem{
+			ID:              id,
+			Type:            state[dskey.MustKey("projection/%d/type", id)],
+			ContentObjectID: state[dskey.MustKey("projection/%d/content_object_id", id)],
+			Options:         state[dskey.MustKey("projection/%d/options", id)],
+			Stable:          state[dskey.MustKey("projection/%d/stable", id)],
+			Weight:          state[dskey.MustKey("projection/%d/weight", id)],
+			Content:         state[dskey.MustKey("projection/%d/content", id)],
+		}
+
+		if item.Weight != nil {
+			if err := json.Unmarshal(item.Weight, &item.weight); err != nil {
+				return projectorFrame{}, fmt.Errorf("decoding weight of projection %d: %w", id, err)
+			}
+		}
+
+		frame.Projections = append(frame.Projections, item)
+	}
+
+	sort.SliceStable(frame.Projections, func(i, j int) bool {
+		a, b := frame.Projections[i], frame.Projections[j]
+		if a.weight != b.weight {
+			return a.weight < b.weight
+		}
+		return a.ID < b.ID
+	})
+
+	return frame, nil
+}
+
+// HandleProjectorStream registers the route for projectors.
+//
+// The route sends the current projections of a projector with their rendered
+// content as server-sent events. Each event contains the full projector, so a
+// projector can replace its state without merging. An event is only sent, if
+// the projector changed.
+//
+// The connection does not wait for the work pool of the autoupdate routes, so
+// projectors get their updates first. It counts for the connection limit.
+//
+// /system/autoupdate/projector?projector_id=1
+func HandleProjectorStream(mux *http.ServeMux, auth Authenticater, connecter ProjectorConnecter, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid := auth.FromContext(ctx)
+
+		projectorID, err := strconv.Atoi(r.URL.Query().Get("projector_id"))
+		if err != nil || projectorID < 1 {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("projector_id has to be a positive number, not `%s`", r.URL.Query().Get("projector_id"))})
+			return
+		}
+
+		conn, err := connecter.ConnectProjector(ctx, uid, projectorID)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("connect projector: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		if err := sendProjector(ctx, w, conn, projectorID); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprint(w, "event: error\ndata: ")
+			handleErrorWithoutStatus(w, err)
+			fmt.Fprint(w, "\n")
+			return
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/projector",
+		routeMiddleware(
+			memoryThrottleMiddleware(
+				connectionLimitMiddleware(
+					authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeProjector)), auth),
+					cfg.ConnectionLimiter,
+				),
+				cfg.MemoryThrottle,
+			),
+			routeProjector,
+		),
+	)
+}
+
+// sendProjector writes an event each time the projector changes.
+func sendProjector(ctx context.Context, w http.ResponseWriter, conn autoupdate.Connection, projectorID int) error {
+	state := make(map[dskey.Key][]byte)
+	var lastFrame []byte
+	for f, ok := conn.Next(); ok; f, ok = conn.Next() {
+		// This blocks, until there is new data. It also unblocks, when the
+		// client context is done.
+		data, err := f(ctx)
+		if err != nil {
+			return fmt.Errorf("getting next message: %w", err)
+		}
+
+		for k, v := range data {
+			if v == nil {
+				delete(state, k)
+				continue
+			}
+			state[k] = v
+		}
+
+		frame, err := resolveProjector(state, projectorID)
+		if err != nil {
+			return fmt.Errorf("resolve projector: %w", err)
+		}
+
+		encoded, err := json.Marshal(frame)
+		if err != nil {
+			return fmt.Errorf("encoding projector: %w", err)
+		}
+
+		if bytes.Equal(encoded, lastFrame) {
+			continue
+		}
+		lastFrame = encoded
+
+		if _, err := fmt.Fprintf(w, "event: projector\ndata: %s\n\n", encoded); err != nil {
+			return fmt.Errorf("write projector: %w", err)
+		}
+		w.(http.Flusher).Flush()
+	}
+	return ctx.Err()
+}
+
+// ProjectorSnapshotter returns the current data of a projector.
+type ProjectorSnapshotter interface {
+	ProjectorSnapshot(ctx context.Context, userID int, projectorID int) (map[dskey.Key][]byte, error)
+}
+
+// projectorHTML is a self-contained page of a projector. It contains the
+// projector as json, so a renderer can use the data without another request.
+var projectorHTML = template.Must(template.New("projector").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Projector {{.ID}}</title>
+<style>
+body { margin: 0; font-family: sans-serif; }
+.projection { padding: 1em 2em; }
+.projection pre { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<main class="projector" data-projector-id="{{.ID}}">
+{{- range .Projections}}
+<section class="projection" data-projection-id="{{.ID}}" data-type="{{.Type}}" data-content-object-id="{{.ContentObjectID}}">
+<h1>{{.Title}}</h1>
+<pre>{{.Content}}</pre>
+</section>
+{{- end}}
+</main>
+<script type="application/json" id="projector-data">{{.JSON}}</script>
+</body>
+</html>
+`))
+
+// projectorPage is the data for projectorHTML.
+type projectorPage struct {
+	ID          int
+	Projections []projectionPage
+	JSON        template.JS
+}
+
+type projectionPage struct {
+	ID              int
+	Type            string
+	ContentObjectID string
+	Title           string
+	Content         string
+}
+
+// newProjectorPage builds the data for projectorHTML from a projector frame.
+func newProjectorPage(frame projectorFrame, encoded []byte) projectorPage {
+	page := projectorPage{
+		ID:   frame.ID,
+		JSON: template.JS(encoded),
+	}
+
+	for _, p7on := range frame.Projections {
+		var p projectionPage
+		p.ID = p7on.ID
+		json.Unmarshal(p7on.Type, &p.Type)
+		json.Unmarshal(p7on.ContentObjectID, &p.ContentObjectID)
+
+		var content struct {
+			Title      string `json:"title"`
+			Collection string `json:"collection"`
+		}
+		json.Unmarshal(p7on.Content, &content)
+		p.Title = content.Title
+		if p.Title == "" {
+			p.Title = content.Collection
+		}
+
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, p7on.Content, "", "  "); err == nil {
+			p.Content = indented.String()
+		}
+
+		page.Projections = append(page.Projections, p)
+	}
+	return page
+}
+
+// HandleProjectorSnapshot registers the route, that returns the current
+// projections of a projector as one response. It is meant for screenshot
+// services and stream overlays.
+//
+// The argument `format=html` returns a self-contained html page. Otherwise the
+// projector is returned as json in the same format as the events of the
+// projector route.
+//
+// /system/autoupdate/projector_snapshot?projector_id=1&format=html
+func HandleProjectorSnapshot(mux *http.ServeMux, auth Authenticater, snapshotter ProjectorSnapshotter, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid := auth.FromContext(ctx)
+
+		projectorID, err := strconv.Atoi(r.URL.Query().Get("projector_id"))
+		if err != nil || projectorID < 1 {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("projector_id has to be a positive number, not `%s`", r.URL.Query().Get("projector_id"))})
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "html" {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("format has to be json or html, not `%s`", format)})
+			return
+		}
+
+		data, err := snapshotter.ProjectorSnapshot(ctx, uid, projectorID)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("projector snapshot: %w", err))
+			return
+		}
+
+		frame, err := resolveProjector(data, projectorID)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("resolve projector: %w", err))
+			return
+		}
+
+		encoded, err := json.Marshal(frame)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("encoding projector: %w", err))
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+		if format != "html" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(append(encoded, '\n'))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := projectorHTML.Execute(w, newProjectorPage(frame, encoded)); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("rendering projector: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/projector_snapshot",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeProjector)), auth),
+			routeProjector,
+		),
+	)
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+const (
+	// rateLimitPruneSize is the amount of buckets, after which full buckets
+	// are removed.
+	rateLimitPruneSize = 10_000
+
+	// rateLimitPruneInterval is the minimum time between two prunes. If many
+	// buckets are not full, a prune on each request would walk all of them.
+	rateLimitPruneInterval = time.Second
+)
+
+var (
+	envRateLimit      = environment.NewVariable("AUTOUPDATE_RATE_LIMIT", "0", "Allowed new requests per second and user. Public access is limited per client ip. Zero disables the rate limit.")
+	envRateLimitBurst = environment.NewVariable("AUTOUPDATE_RATE_LIMIT_BURST", "20", "Amount of requests a user can send at once before the rate limit applies.")
+)
+
+func init() {
+	tenant.Overridable(envRateLimit, envRateLimitBurst)
+}
+
+// RateLimiter limits how many requests a user can start.
+//
+// It is a token bucket for each user. Each request takes one token. The tokens
+// are refilled with a steady rate until the bucket is full.
+//
+// Each tenant can have its own rate. The empty tenant uses the rate from the
+// environment.
+//
+// Has to be initialized with NewRateLimiter().
+type RateLimiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	settings  map[string]rateSetting
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type rateSetting struct {
+	rate  float64
+	burst float64
+}
+
+type tokenBucket struct {
+	tenant string
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter initializes a RateLimiter from the environment.
+//
+// Returns nil, if the rate limit is disabled for all tenants.
+func NewRateLimiter(lookup environment.Environmenter) (*RateLimiter, error) {
+	settings, err := parseRateLimits(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := false
+	for _, setting := range settings {
+		if setting.rate > 0 {
+			enabled = true
+		}
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+
+	return &RateLimiter{
+		settings: settings,
+		now:      time.Now,
+		buckets:  make(map[string]*tokenBucket),
+	}, nil
+}
+
+// parseRateLimits returns the rate limit of each tenant.
+func parseRateLimits(lookup environment.Environmenter) (map[string]rateSetting, error) {
+	rate, burst, err := parseRateLimit(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]rateSetting{"": {rate: rate, burst: float64(burst)}}
+	for name, tenantLookup := range tenant.Lookups(lookup) {
+		rate, burst, err := parseRateLimit(tenantLookup)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		settings[name] = rateSetting{rate: rate, burst: float64(burst)}
+	}
+	return settings, nil
+}
+
+func parseRateLimit(lookup environment.Environmenter) (float64, int, error) {
+	rate, err := strconv.ParseFloat(envRateLimit.Value(lookup), 64)
+	if err != nil || rate < 0 {
+		return 0, 0, fmt.Errorf("invalid value for `%s`, expected positive number, got %s", envRateLimit.Key, envRateLimit.Value(lookup))
+	}
+
+	burst, err := strconv.Atoi(envRateLimitBurst.Value(lookup))
+	if err != nil || burst < 1 {
+		return 0, 0, fmt.Errorf("invalid value for `%s`, expected number bigger then 0, got %s", envRateLimitBurst.Key, envRateLimitBurst.Value(lookup))
+	}
+
+	return rate, burst, nil
+}
+
+// Reload reads the rate limit from the environment. The returned function
+// sets it. A rate of zero lets all requests pass.
+func (l *RateLimiter) Reload(lookup environment.Environmenter) (func(), error) {
+	settings, err := parseRateLimits(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	apply := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.settings = settings
+	}
+	return apply, nil
+}
+
+func newRateLimiter(rate float64, burst int, now func() time.Time) *RateLimiter {
+	return &RateLimiter{
+		settings: map[string]rateSetting{"": {rate: rate, burst: float64(burst)}},
+		now:      now,
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// setting returns the rate limit of a tenant. Has to be called with the lock.
+func (l *RateLimiter) setting(tenantName string) rateSetting {
+	if setting, ok := l.settings[tenantName]; ok {
+		return setting
+	}
+	return l.settings[""]
+}
+
+// allow takes a token for the key of a tenant. If there is no token, it
+// returns false and the duration until the next token is available.
+func (l *RateLimiter) allow(tenantName string, key string) (bool, time.Duration) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	setting := l.setting(tenantName)
+	if setting.rate == 0 {
+		return true, 0
+	}
+
+	if len(l.buckets) >= rateLimitPruneSize && now.Sub(l.lastPrune) >= rateLimitPruneInterval {
+		l.prune(now)
+	}
+
+	if tenantName != "" {
+		key = tenantName + "/" + key
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tenant: tenantName, tokens: setting.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(setting.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*setting.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / setting.rate * float64(time.Second))
+		return false, wait
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// prune removes all buckets that would be full at the given time. They are the
+// same as a new bucket.
+//
+// Has to be called with the lock.
+func (l *RateLimiter) prune(now time.Time) {
+	l.lastPrune = now
+	for key, bucket := range l.buckets {
+		setting := l.setting(bucket.tenant)
+		if setting.rate == 0 || bucket.tokens+now.Sub(bucket.last).Seconds()*setting.rate >= setting.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitMiddleware rejects requests when the user has no tokens left.
+//
+// Has to be called after the authMiddleware. If the limiter is nil, next is
+// returned unchanged.
+func rateLimitMiddleware(next http.Handler, auth Authenticater, limiter *RateLimiter) http.Handler {
+	if limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+
+		key := "user/" + strconv.Itoa(uid)
+		if uid == 0 {
+			key = "ip/" + ClientIPFromContext(r.Context())
+		}
+
+		allowed, wait := limiter.allow(tenant.FromContext(r.Context()), key)
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			handleErrorWithStatus(w, tooManyRequestsError{})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
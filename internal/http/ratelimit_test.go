@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(2, 3, func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limiter.allow("", "user/1"); !allowed {
+			t.Fatalf("request %d was not allowed", i+1)
+		}
+	}
+
+	allowed, wait := limiter.allow("", "user/1")
+	if allowed {
+		t.Fatalf("fourth request was allowed")
+	}
+
+	if wait != 500*time.Millisecond {
+		t.Errorf("got wait time %s, expected 500ms", wait)
+	}
+
+	if allowed, _ := limiter.allow("", "user/2"); !allowed {
+		t.Errorf("other user was not allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if allowed, _ := limiter.allow("", "user/1"); !allowed {
+		t.Errorf("request after refill was not allowed")
+	}
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(1, 3, func() time.Time { return now })
+
+	for i := 0; i < rateLimitPruneSize; i++ {
+		limiter.allow("", "user/"+strconv.Itoa(i))
+	}
+
+	// The buckets are not full, so the prune removes nothing.
+	limiter.allow("", "user/new")
+	pruned := limiter.lastPrune
+	if pruned.IsZero() {
+		t.Fatalf("buckets were not pruned")
+	}
+
+	now = now.Add(rateLimitPruneInterval / 2)
+	limiter.allow("", "user/other")
+	if limiter.lastPrune != pruned {
+		t.Errorf("buckets were pruned again before the interval")
+	}
+
+	now = now.Add(time.Hour)
+	limiter.allow("", "user/1")
+	if got := len(limiter.buckets); got != 1 {
+		t.Errorf("got %d buckets after the prune, expected 1", got)
+	}
+}
+
+func TestRateLimiterReload(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(1, 1, func() time.Time { return now })
+
+	if allowed, _ := limiter.allow("", "user/1"); !allowed {
+		t.Fatalf("first request was not allowed")
+	}
+
+	apply, err := limiter.Reload(environment.ForTests{"AUTOUPDATE_RATE_LIMIT": "0"})
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if allowed, _ := limiter.allow("", "user/1"); allowed {
+		t.Fatalf("second request was allowed before the reload was applied")
+	}
+
+	apply()
+
+	if allowed, _ := limiter.allow("", "user/1"); !allowed {
+		t.Errorf("request was not allowed after the rate limit was disabled")
+	}
+
+	if _, err := limiter.Reload(environment.ForTests{"AUTOUPDATE_RATE_LIMIT_BURST": "0"}); err == nil {
+		t.Errorf("Reload with invalid burst did not return an error")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(1, 1, func() time.Time { return now })
+
+	handler := rateLimitMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		fakeUser(5),
+		limiter,
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != 200 {
+		t.Errorf("first request got status %d, expected 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second request got status %d, expected 429", rec.Code)
+	}
+
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("got Retry-After `%s`, expected `1`", got)
+	}
+}
+
+func TestRateLimitUnixSocket(t *testing.T) {
+	proxies, err := NewTrustedProxies(environment.ForTests{"AUTOUPDATE_TRUSTED_PROXIES": "unix"})
+	if err != nil {
+		t.Fatalf("NewTrustedProxies: %v", err)
+	}
+
+	now := time.Now()
+	limiter := newRateLimiter(1, 1, func() time.Time { return now })
+
+	handler := requestIDMiddleware(proxies.Middleware(rateLimitMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		fakeUser(0),
+		limiter,
+	)))
+
+	socket := context.WithValue(context.Background(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/run/autoupdate.sock", Net: "unix"})
+	request := func(client string) int {
+		req := httptest.NewRequest("GET", "/", nil).WithContext(socket)
+		req.RemoteAddr = "@"
+		req.Header.Set("X-Forwarded-For", client)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request("198.51.100.1"); code != 200 {
+		t.Errorf("first client got status %d, expected 200", code)
+	}
+
+	if code := request("198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("second request of the first client got status %d, expected 429", code)
+	}
+
+	if code := request("198.51.100.2"); code != 200 {
+		t.Errorf("second client got status %d, expected 200", code)
+	}
+}
+
+func TestRateLimiterTenant(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(0, 1, func() time.Time { return now })
+	limiter.settings["meeting.example.com"] = rateSetting{rate: 1, burst: 1}
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limiter.allow("", "user/1"); !allowed {
+			t.Fatalf("request %d without tenant was not allowed", i+1)
+		}
+	}
+
+	if allowed, _ := limiter.allow("meeting.example.com", "user/1"); !allowed {
+		t.Fatalf("first request of the tenant was not allowed")
+	}
+
+	if allowed, _ := limiter.allow("meeting.example.com", "user/1"); allowed {
+		t.Errorf("second request of the tenant was allowed")
+	}
+}
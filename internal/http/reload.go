@@ -0,0 +1,42 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/reload"
+)
+
+// HandleReload registers a route to reload the settings, that can be changed
+// at runtime.
+//
+// It does the same as SIGHUP. Only POST requests are allowed. The response
+// contains the names of the reloaded settings. If one setting is invalid,
+// nothing is changed and the route returns an error.
+//
+// The route is protected like the profile routes.
+func HandleReload(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("only POST requests are supported")})
+			return
+		}
+
+		names, err := reload.Reload()
+		if err != nil {
+			handleErrorWithStatus(w, invalidRequestError{err})
+			return
+		}
+
+		logger.Info("Settings reloaded", "settings", names)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string][]string{"reloaded": names}); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding reloaded settings: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/reload", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/systemd"
+)
+
+// envInheritedFDs is set by the service, when it starts a new process of
+// itself. It contains the names of the passed file descriptors.
+const envInheritedFDs = "AUTOUPDATE_INHERITED_FDS"
+
+// File descriptor names for envInheritedFDs.
+const (
+	inheritedPublic   = "public"
+	inheritedInternal = "internal"
+	inheritedReady    = "ready"
+)
+
+// restartReadyTimeout is the time a new process has to start serving requests.
+// If it does not, it is killed and the old process keeps running.
+const restartReadyTimeout = 30 * time.Second
+
+// inheritance are the listeners passed from a previous process of the service.
+type inheritance struct {
+	public   []net.Listener
+	internal net.Listener
+	ready    *os.File
+}
+
+// inherit returns the listeners from a previous process.
+//
+// Returns an empty inheritance, if the process was not started by a restart.
+func inherit() (inheritance, error) {
+	value := os.Getenv(envInheritedFDs)
+	if value == "" {
+		return inheritance{}, nil
+	}
+
+	// Processes started from this process should not use the variable.
+	os.Unsetenv(envInheritedFDs)
+
+	var inh inheritance
+	for i, name := range strings.Split(value, ",") {
+		fd := systemdFirstFD + i
+		file := os.NewFile(uintptr(fd), "inherited-"+name+"-"+strconv.Itoa(fd))
+
+		if name == inheritedReady {
+			inh.ready = file
+			continue
+		}
+
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			inh.close()
+			return inheritance{}, fmt.Errorf("file descriptor %d: %w", fd, err)
+		}
+
+		switch name {
+		case inheritedPublic:
+			inh.public = append(inh.public, listener)
+		case inheritedInternal:
+			inh.internal = listener
+		default:
+			listener.Close()
+			inh.close()
+			return inheritance{}, fmt.Errorf("unknown file descriptor name %s", name)
+		}
+	}
+
+	return inh, nil
+}
+
+// close closes all inherited files.
+func (inh inheritance) close() {
+	for _, l := range inh.public {
+		l.Close()
+	}
+	if inh.internal != nil {
+		inh.internal.Close()
+	}
+	if inh.ready != nil {
+		inh.ready.Close()
+	}
+}
+
+// signalReady tells the previous process, that this process serves requests.
+func (inh inheritance) signalReady() error {
+	if inh.ready == nil {
+		return nil
+	}
+	defer inh.ready.Close()
+
+	if _, err := inh.ready.Write([]byte{1}); err != nil {
+		return fmt.Errorf("writing to previous process: %w", err)
+	}
+	return nil
+}
+
+// handover starts a new process of the service and passes the listeners to it.
+//
+// It returns after the new process serves requests. Afterwards, the listeners
+// of this process can be closed. The unix sockets are not removed on close.
+func handover(public []net.Listener, internal net.Listener) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding executable: %w", err)
+	}
+
+	var files []*os.File
+	var names []string
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	addListener := func(listener net.Listener, name string) error {
+		filer, ok := listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("listener %s can not be passed", listener.Addr())
+		}
+
+		file, err := filer.File()
+		if err != nil {
+			return fmt.Errorf("getting file of listener %s: %w", listener.Addr(), err)
+		}
+
+		files = append(files, file)
+		names = append(names, name)
+		return nil
+	}
+
+	for _, listener := range public {
+		if err := addListener(listener, inheritedPublic); err != nil {
+			return err
+		}
+	}
+
+	if internal != nil {
+		if err := addListener(internal, inheritedInternal); err != nil {
+			return err
+		}
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("creating pipe: %w", err)
+	}
+	defer readyReader.Close()
+	files = append(files, readyWriter)
+	names = append(names, inheritedReady)
+
+	// The new process becomes the main process of systemd, so it has to send
+	// the watchdog messages.
+	env := slices.DeleteFunc(os.Environ(), func(v string) bool { return strings.HasPrefix(v, "WATCHDOG_PID=") })
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(env, envInheritedFDs+"="+strings.Join(names, ","))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting new process: %w", err)
+	}
+
+	// Close the write end in this process, so the read returns, when the new
+	// process exits.
+	readyWriter.Close()
+
+	if err := waitReady(readyReader, restartReadyTimeout); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("waiting for new process: %w", err)
+	}
+
+	for _, listener := range public {
+		if unixListener, ok := listener.(*net.UnixListener); ok {
+			unixListener.SetUnlinkOnClose(false)
+		}
+	}
+
+	return nil
+}
+
+// notifySystemd tells systemd, that the service is ready. A process started
+// by a restart also tells, that it is the new main process.
+//
+// When the context is done, it tells systemd, that the service stops. This is
+// skipped on a restart, since the new process keeps running.
+func notifySystemd(ctx context.Context, restarted bool) {
+	state := "READY=1"
+	if restarted {
+		state = fmt.Sprintf("MAINPID=%d\nREADY=1", os.Getpid())
+	}
+
+	if err := systemd.Notify(state); err != nil {
+		logger.Warn("Can not notify systemd", "error", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		if errors.Is(context.Cause(ctx), errRestart) {
+			return
+		}
+
+		if err := systemd.Notify("STOPPING=1"); err != nil {
+			logger.Warn("Can not notify systemd", "error", err)
+		}
+	}()
+}
+
+// waitReady waits until the new process writes to the pipe.
+func waitReady(r *os.File, timeout time.Duration) error {
+	if err := r.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return fmt.Errorf("setting deadline: %w", err)
+	}
+
+	var buf [1]byte
+	if _, err := r.Read(buf[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("new process exited before it was ready")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWaitReady(t *testing.T) {
+	t.Run("ready", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("pipe: %v", err)
+		}
+		defer r.Close()
+
+		inh := inheritance{ready: w}
+		if err := inh.signalReady(); err != nil {
+			t.Fatalf("signalReady: %v", err)
+		}
+
+		if err := waitReady(r, time.Second); err != nil {
+			t.Errorf("waitReady: %v", err)
+		}
+	})
+
+	t.Run("process exits", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("pipe: %v", err)
+		}
+		defer r.Close()
+		w.Close()
+
+		if err := waitReady(r, time.Second); err == nil {
+			t.Errorf("waitReady returned no error")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("pipe: %v", err)
+		}
+		defer r.Close()
+		defer w.Close()
+
+		if err := waitReady(r, time.Millisecond); err == nil {
+			t.Errorf("waitReady returned no error")
+		}
+	})
+}
+
+func TestServeDrain(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	canceled := make(chan time.Time, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		canceled <- time.Now()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, handler, []net.Listener{listener}, Timeouts{Drain: 50 * time.Millisecond})
+	}()
+
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+
+	stopped := time.Now()
+	cancel()
+
+	select {
+	case at := <-canceled:
+		if at.Sub(stopped) < 50*time.Millisecond {
+			t.Errorf("request was canceled after %s, expected at least the drain time", at.Sub(stopped))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("request was not canceled after the drain time")
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envHandoffTTL = environment.NewVariable("AUTOUPDATE_HANDOFF_TTL", "0s", "Time the state of a connection is kept in redis, when the service stops and hands the connection to another instance. The client can resume it there and only gets the changed data. Zero disables the handoff.")
+
+// resumeTimeout is the time to save or load the state of a connection.
+const resumeTimeout = 2 * time.Second
+
+// errHandoff is the cause of the connection context, when the server stops and
+// the connection is handed off to another instance.
+var errHandoff = errors.New("connection handed off")
+
+// ResumeStore saves the state of connections, so they can be resumed by
+// another instance.
+type ResumeStore interface {
+	SaveResume(ctx context.Context, id string, state []byte, ttl time.Duration) error
+	LoadResume(ctx context.Context, id string) ([]byte, error)
+}
+
+// resumer is implemented by the connections of the autoupdate package.
+type resumer interface {
+	ResumeState() (string, error)
+	Resume(state string) error
+}
+
+// resumeState is the saved state of a connection.
+type resumeState struct {
+	UserID int    `json:"user_id"`
+	Hashes string `json:"hashes"`
+}
+
+// handoff hands the connections to other instances, when the server stops.
+//
+// All methods can be called on nil. In this case, the handoff is disabled.
+type handoff struct {
+	store ResumeStore
+	ttl   time.Duration
+}
+
+// newHandoff returns nil, if the store is nil or the ttl is zero.
+func newHandoff(store ResumeStore, ttl time.Duration) *handoff {
+	if store == nil || ttl <= 0 {
+		return nil
+	}
+	return &handoff{store: store, ttl: ttl}
+}
+
+// load returns the state of the connection with the given resume id.
+//
+// Returns an empty string, if the state does not exist or belongs to another
+// user. In this case, the client gets all data like on a new connection.
+func (h *handoff) load(ctx context.Context, uid int, id string) string {
+	if h == nil || id == "" {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resumeTimeout)
+	defer cancel()
+
+	raw, err := h.store.LoadResume(ctx, id)
+	if err != nil {
+		logger.WarnContext(ctx, "Can not load resume state", "error", err)
+		return ""
+	}
+
+	if raw == nil {
+		return ""
+	}
+
+	var state resumeState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		logger.WarnContext(ctx, "Invalid resume state", "error", err)
+		return ""
+	}
+
+	if state.UserID != uid {
+		logger.WarnContext(ctx, "Resume state of another user", "state_user_id", state.UserID)
+		return ""
+	}
+
+	return state.Hashes
+}
+
+// watch returns a context, that is canceled with errHandoff, when the server
+// starts to stop. The returned function has to be called, when the connection
+// is closed.
+func (h *handoff) watch(ctx context.Context) (context.Context, func()) {
+	stopping := serverContext(ctx)
+	if h == nil || stopping == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(stopping, func() { cancel(errHandoff) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// save saves the state of the connection and tells the client the resume id.
+// The client can use it to continue the connection on another instance.
+func (h *handoff) save(ctx context.Context, w io.Writer, uid int, conn autoupdate.Connection) error {
+	r, ok := conn.(resumer)
+	if !ok {
+		return fmt.Errorf("connection can not be resumed")
+	}
+
+	hashes, err := r.ResumeState()
+	if err != nil {
+		return fmt.Errorf("getting resume state: %w", err)
+	}
+
+	state, err := json.Marshal(resumeState{UserID: uid, Hashes: hashes})
+	if err != nil {
+		return fmt.Errorf("encoding resume state: %w", err)
+	}
+
+	id, err := newResumeID()
+	if err != nil {
+		return fmt.Errorf("creating resume id: %w", err)
+	}
+
+	// The connection context is already canceled.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resumeTimeout)
+	defer cancel()
+
+	if err := h.store.SaveResume(ctx, id, state, h.ttl); err != nil {
+		return fmt.Errorf("saving resume state: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w, `{"resume":%q}`+"\n", id); err != nil {
+		return fmt.Errorf("writing resume id: %w", err)
+	}
+	w.(http.Flusher).Flush()
+	return nil
+}
+
+// newResumeID returns a random id, that can not be guessed.
+func newResumeID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+type serverContextKey struct{}
+
+// withServerContext adds the context of the server to the request context. It
+// is done, when the server starts to stop.
+func withServerContext(ctx, server context.Context) context.Context {
+	return context.WithValue(ctx, serverContextKey{}, server)
+}
+
+// serverContext returns the context of the server or nil.
+func serverContext(ctx context.Context) context.Context {
+	server, _ := ctx.Value(serverContextKey{}).(context.Context)
+	return server
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+type memoryResumeStore struct {
+	mu     sync.Mutex
+	states map[string][]byte
+}
+
+func (s *memoryResumeStore) SaveResume(ctx context.Context, id string, state []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.states == nil {
+		s.states = make(map[string][]byte)
+	}
+	s.states[id] = state
+	return nil
+}
+
+func (s *memoryResumeStore) LoadResume(ctx context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.states[id]
+	delete(s.states, id)
+	return state, nil
+}
+
+// resumeConnecter returns a connection, that sends one message and then blocks
+// until the context is done.
+type resumeConnecter struct {
+	conn *resumeConnection
+}
+
+func (c *resumeConnecter) Connect(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (autoupdate.Connection, error) {
+	return c.conn, nil
+}
+
+func (c *resumeConnecter) SingleData(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (map[dskey.Key][]byte, error) {
+	return nil, nil
+}
+
+type resumeConnection struct {
+	resumed string
+	sent    bool
+}
+
+func (c *resumeConnection) Next() (func(context.Context) (map[dskey.Key][]byte, error), bool) {
+	return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+		if !c.sent {
+			c.sent = true
+			return map[dskey.Key][]byte{dskey.MustKey("user/1/username"): []byte(`"hugo"`)}, nil
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, true
+}
+
+func (c *resumeConnection) NextWithFilter(ctx context.Context, filterHashes string) (map[dskey.Key][]byte, string, error) {
+	return nil, "", nil
+}
+
+func (c *resumeConnection) ResumeState() (string, error) {
+	return "my-hashes", nil
+}
+
+func (c *resumeConnection) Resume(state string) error {
+	c.resumed = state
+	return nil
+}
+
+func TestSendMessagesHandoff(t *testing.T) {
+	serverCtx, stopServer := context.WithCancel(context.Background())
+	ctx := withServerContext(context.Background(), serverCtx)
+
+	store := &memoryResumeStore{}
+	handoff := newHandoff(store, time.Minute)
+	connecter := &resumeConnecter{conn: &resumeConnection{}}
+	recorder := httptest.NewRecorder()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sendMessages(ctx, recorder, 1, nil, connecter, protocolProfile{control: true}, nil, nil, nil, handoff, "old-hashes", nil)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	stopServer()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errHandoff) {
+			t.Fatalf("sendMessages returned %v, expected %v", err, errHandoff)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sendMessages did not return after the server stopped")
+	}
+
+	if connecter.conn.resumed != "old-hashes" {
+		t.Errorf("connection was resumed with %q, expected %q", connecter.conn.resumed, "old-hashes")
+	}
+
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	var message struct {
+		Resume string `json:"resume"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &message); err != nil || message.Resume == "" {
+		t.Fatalf("last line is %q, expected the resume id", lines[len(lines)-1])
+	}
+
+	if got := handoff.load(context.Background(), 2, message.Resume); got != "" {
+		t.Errorf("other user got state %q", got)
+	}
+
+	if err := handoff.save(ctx, httptest.NewRecorder(), 1, connecter.conn); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	for id := range store.states {
+		if got := handoff.load(context.Background(), 1, id); got != "my-hashes" {
+			t.Errorf("load returned %q, expected %q", got, "my-hashes")
+		}
+	}
+}
+
+func TestNewHandoffDisabled(t *testing.T) {
+	if h := newHandoff(&memoryResumeStore{}, 0); h != nil {
+		t.Errorf("handoff is enabled without a ttl")
+	}
+
+	var h *handoff
+	if got := h.load(context.Background(), 1, "some-id"); got != "" {
+		t.Errorf("disabled handoff loaded %q", got)
+	}
+
+	ctx := withServerContext(context.Background(), context.Background())
+	if got, _ := h.watch(ctx); got != ctx {
+		t.Errorf("disabled handoff changed the context")
+	}
+}
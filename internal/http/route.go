@@ -0,0 +1,197 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// Route groups. Each route belongs to one group. The optional middleware can be
+// enabled for each group.
+const (
+	routeAutoupdate      = "autoupdate"
+	routeHistory         = "history"
+	routeProjector       = "projector"
+	routeICC             = "icc"
+	routeSearch          = "search"
+	routePresenter       = "presenter"
+	routeConnectionCount = "connection_count"
+	routeHealth          = "health"
+	routeOpenAPI         = "openapi"
+	routeInternal        = "internal"
+	routeProfile         = "profile"
+)
+
+var routeGroups = []string{
+	routeAutoupdate,
+	routeHistory,
+	routeProjector,
+	routeICC,
+	routeSearch,
+	routePresenter,
+	routeConnectionCount,
+	routeHealth,
+	routeOpenAPI,
+	routeInternal,
+	routeProfile,
+}
+
+var (
+	envRateLimitRoutes   = environment.NewVariable("AUTOUPDATE_RATE_LIMIT_ROUTES", "autoupdate", "Comma separated list of route groups that are rate limited. Possible groups are `autoupdate`, `history`, `projector`, `icc`, `search`, `presenter`, `connection_count` and `internal`. Use `*` for all of them.")
+	envAccessLogRoutes   = environment.NewVariable("AUTOUPDATE_ACCESS_LOG_ROUTES", "*", "Comma separated list of route groups that are written to the access log. Possible groups are `autoupdate`, `history`, `projector`, `icc`, `search`, `presenter`, `connection_count`, `health`, `openapi`, `internal` and `profile`. Use `*` for all routes.")
+	envRelaxedAuthRoutes = environment.NewVariable("AUTOUPDATE_RELAXED_AUTH_ROUTES", "", "Comma separated list of route groups that handle a request with an invalid or expired token as anonymous request instead of returning an error. Possible groups are `autoupdate`, `history`, `projector`, `icc`, `search`, `presenter` and `connection_count`. Use `*` for all of them.")
+	envCompressRoutes    = environment.NewVariable("AUTOUPDATE_COMPRESS_ROUTES", "", "Comma separated list of route groups whose responses are compressed with gzip, if the client accepts it. Possible groups are `autoupdate`, `history`, `projector`, `icc`, `search`, `presenter`, `connection_count`, `health`, `openapi`, `internal` and `profile`. Use `*` for all routes.")
+)
+
+// routeSet is a set of route groups.
+type routeSet struct {
+	all    bool
+	groups map[string]bool
+}
+
+// parseRouteSet parses a comma separated list of route groups.
+func parseRouteSet(env environment.Variable, lookup environment.Environmenter, possible []string) (routeSet, error) {
+	value := env.Value(lookup)
+	if strings.TrimSpace(value) == "*" {
+		return routeSet{all: true}, nil
+	}
+
+	rs := routeSet{groups: make(map[string]bool)}
+	for _, group := range strings.Split(value, ",") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		if !contains(possible, group) {
+			return routeSet{}, fmt.Errorf("invalid value for `%s`, unknown route group `%s`", env.Key, group)
+		}
+		rs.groups[group] = true
+	}
+	return rs, nil
+}
+
+func (rs routeSet) has(group string) bool {
+	return rs.all || rs.groups[group]
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteMiddleware decides, which optional middleware is used for each route
+// group.
+//
+// Each route always uses the auth method it was written for. Routes with a
+// relaxed auth only handle invalid tokens as anonymous requests.
+type RouteMiddleware struct {
+	rateLimit   routeSet
+	accessLog   routeSet
+	relaxedAuth routeSet
+	compress    routeSet
+}
+
+// NewRouteMiddleware reads the route configuration from the environment.
+func NewRouteMiddleware(lookup environment.Environmenter) (RouteMiddleware, error) {
+	rateLimit, err := parseRouteSet(envRateLimitRoutes, lookup, []string{routeAutoupdate, routeHistory, routeProjector, routeICC, routeSearch, routePresenter, routeConnectionCount, routeInternal})
+	if err != nil {
+		return RouteMiddleware{}, err
+	}
+
+	accessLog, err := parseRouteSet(envAccessLogRoutes, lookup, routeGroups)
+	if err != nil {
+		return RouteMiddleware{}, err
+	}
+
+	// The internal and profile routes check the user themself, so an invalid
+	// token can not be relaxed there.
+	relaxedAuth, err := parseRouteSet(envRelaxedAuthRoutes, lookup, []string{routeAutoupdate, routeHistory, routeProjector, routeICC, routeSearch, routePresenter, routeConnectionCount})
+	if err != nil {
+		return RouteMiddleware{}, err
+	}
+
+	compress, err := parseRouteSet(envCompressRoutes, lookup, routeGroups)
+	if err != nil {
+		return RouteMiddleware{}, err
+	}
+
+	return RouteMiddleware{rateLimit: rateLimit, accessLog: accessLog, relaxedAuth: relaxedAuth, compress: compress}, nil
+}
+
+// rateLimiter returns the rate limiter for the route group or nil, if the
+// group is not rate limited.
+func (cfg Config) rateLimiter(group string) *RateLimiter {
+	if !cfg.Routes.rateLimit.has(group) {
+		return nil
+	}
+	return cfg.RateLimiter
+}
+
+// routeOptionsMiddleware saves the route options in the context and
+// compresses the responses of the route groups with compression.
+//
+// Has to be called inside the requestIDMiddleware.
+func routeOptionsMiddleware(next http.Handler, routes RouteMiddleware) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routeOptionsKey{}, routes)
+
+		if (!routes.compress.all && len(routes.compress.groups) == 0) || !acceptsGzip(r) {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		gw := &gzipWriter{ResponseWriter: w, enabled: func() bool { return routes.compress.has(routeFromContext(ctx)) }}
+		defer gw.close()
+
+		next.ServeHTTP(gw, r.WithContext(ctx))
+	})
+}
+
+type routeOptionsKey struct{}
+
+// relaxedAuth tells, if the route of the request handles invalid tokens as
+// anonymous requests.
+func relaxedAuth(ctx context.Context) bool {
+	routes, ok := ctx.Value(routeOptionsKey{}).(RouteMiddleware)
+	if !ok {
+		return false
+	}
+	return routes.relaxedAuth.has(routeFromContext(ctx))
+}
+
+// routeFromContext returns the route group of the request.
+//
+// Returns an empty string, before the routeMiddleware was called.
+func routeFromContext(ctx context.Context) string {
+	info, ok := ctx.Value(requestInfoKey{}).(*requestInfo)
+	if !ok {
+		return ""
+	}
+	return info.route
+}
+
+// routeMiddleware saves the route group of the request. It is used by the
+// access log, the relaxed auth and the compression.
+func routeMiddleware(next http.Handler, group string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setRouteForLog(r.Context(), group)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRouteForLog saves the route group of the request for the access log.
+func setRouteForLog(ctx context.Context, group string) {
+	info, ok := ctx.Value(requestInfoKey{}).(*requestInfo)
+	if !ok {
+		return
+	}
+	info.route = group
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestNewRouteMiddleware(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		routes, err := NewRouteMiddleware(environment.ForTests{})
+		if err != nil {
+			t.Fatalf("NewRouteMiddleware: %v", err)
+		}
+
+		if !routes.rateLimit.has(routeAutoupdate) || routes.rateLimit.has(routeHistory) {
+			t.Errorf("rate limit should only be used for autoupdate")
+		}
+
+		for _, group := range routeGroups {
+			if !routes.accessLog.has(group) {
+				t.Errorf("access log should be used for %s", group)
+			}
+		}
+	})
+
+	t.Run("Unknown group", func(t *testing.T) {
+		_, err := NewRouteMiddleware(environment.ForTests{"AUTOUPDATE_RATE_LIMIT_ROUTES": "autoupdate,health"})
+		if err == nil {
+			t.Errorf("expected an error for a group that can not be rate limited")
+		}
+	})
+}
+
+func TestAccessLogRoutes(t *testing.T) {
+	routes, err := NewRouteMiddleware(environment.ForTests{"AUTOUPDATE_ACCESS_LOG_ROUTES": "history"})
+	if err != nil {
+		t.Fatalf("NewRouteMiddleware: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	mux := http.NewServeMux()
+	HandleHealth(mux)
+	HandleHistoryInformation(mux, fakeUser(1), nil, Config{})
+	handler := requestIDMiddleware(accessLogMiddleware(mux, slog.New(slog.NewTextHandler(buf, nil)), routes.accessLog))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/system/autoupdate/health", nil))
+	if buf.Len() != 0 {
+		t.Errorf("health route was logged: %s", buf.String())
+	}
+
+	// The request fails, since it has no fqid. It is logged anyway.
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/system/autoupdate/history_information", nil))
+	if buf.Len() == 0 {
+		t.Errorf("history route was not logged")
+	}
+}
+
+// expiredAuth implements the Authenticater interface. It handles every request
+// as a request with an expired token.
+type expiredAuth struct{}
+
+type expiredAuthError struct{}
+
+func (expiredAuthError) Error() string { return "auth token is expired" }
+func (expiredAuthError) Type() string  { return "auth" }
+
+type expiredAuthKey struct{}
+
+func (expiredAuth) Authenticate(w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	return nil, expiredAuthError{}
+}
+
+func (expiredAuth) FromContext(ctx context.Context) int {
+	return ctx.Value(expiredAuthKey{}).(int)
+}
+
+func (expiredAuth) AuthenticatedContext(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, expiredAuthKey{}, userID)
+}
+
+func TestRelaxedAuthRoutes(t *testing.T) {
+	routes, err := NewRouteMiddleware(environment.ForTests{"AUTOUPDATE_RELAXED_AUTH_ROUTES": "history"})
+	if err != nil {
+		t.Fatalf("NewRouteMiddleware: %v", err)
+	}
+
+	auth := expiredAuth{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %d", auth.FromContext(r.Context()))
+	})
+
+	for _, tt := range []struct {
+		group  string
+		status int
+		body   string
+	}{
+		{routeHistory, 200, "user 0"},
+		{routeAutoupdate, 400, ""},
+	} {
+		t.Run(tt.group, func(t *testing.T) {
+			h := requestIDMiddleware(routeOptionsMiddleware(routeMiddleware(authMiddleware(handler, auth), tt.group), routes))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, expected %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("got body %q, expected %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+
+	t.Run("Internal routes", func(t *testing.T) {
+		_, err := NewRouteMiddleware(environment.ForTests{"AUTOUPDATE_RELAXED_AUTH_ROUTES": "internal"})
+		if err == nil {
+			t.Errorf("expected an error for a group that can not use the relaxed auth")
+		}
+	})
+}
+
+func TestCompressRoutes(t *testing.T) {
+	routes, err := NewRouteMiddleware(environment.ForTests{"AUTOUPDATE_COMPRESS_ROUTES": "history"})
+	if err != nil {
+		t.Fatalf("NewRouteMiddleware: %v", err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	})
+
+	for _, tt := range []struct {
+		name     string
+		group    string
+		accept   string
+		compress bool
+	}{
+		{"compressed route", routeHistory, "gzip, deflate", true},
+		{"other route", routeAutoupdate, "gzip", false},
+		{"no gzip", routeHistory, "deflate", false},
+		{"gzip refused", routeHistory, "gzip;q=0", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := requestIDMiddleware(routeOptionsMiddleware(routeMiddleware(handler, tt.group), routes))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Encoding", tt.accept)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding") == "gzip"; got != tt.compress {
+				t.Fatalf("compressed: %t, expected %t", got, tt.compress)
+			}
+
+			body := io.Reader(rec.Body)
+			if tt.compress {
+				body, err = gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader: %v", err)
+				}
+			}
+
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+
+			if string(got) != "hello" {
+				t.Errorf("got body %q, expected hello", got)
+			}
+		})
+	}
+
+	t.Run("Own encoding", func(t *testing.T) {
+		encoded := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			fmt.Fprint(w, "already compressed")
+		})
+		h := requestIDMiddleware(routeOptionsMiddleware(routeMiddleware(encoded, routeHistory), routes))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got := rec.Body.String(); got != "already compressed" {
+			t.Errorf("body was compressed twice: %q", got)
+		}
+	})
+}
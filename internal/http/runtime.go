@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/introspect"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// HandleRuntime registers a route, that returns information about the running
+// service as json.
+//
+// The response contains the goroutines, the heap, the cache, the topic, the
+// active background tasks and the effective configuration. Secrets in the
+// configuration are redacted.
+//
+// The route needs an authenticated superadmin. It should only be served on the
+// internal listener.
+//
+// /debug/runtime
+func HandleRuntime(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(introspect.Collect()); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding runtime information: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/runtime", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+}
+
+// HandleConfig registers a route, that returns the effective value of each
+// environment variable and its source as json. The source is `environment`,
+// `file`, `directory`, `profile`, `default` or `vault`. Secrets are redacted.
+//
+// The route needs an authenticated superadmin.
+//
+// /debug/config
+func HandleConfig(mux *http.ServeMux, auth Authenticater, profiler Profiler, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.Settings == nil {
+			handleErrorWithStatus(w, notFoundError{})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(cfg.Settings()); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding configuration: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/config", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+}
+
+// HandleConfigSchema registers a route, that returns all environment variables
+// with their type, default value and description as json. Deployment tooling
+// can generate its configuration from it.
+//
+// The route needs an authenticated superadmin.
+//
+// /debug/config/schema
+func HandleConfigSchema(mux *http.ServeMux, auth Authenticater, profiler Profiler) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(environment.Schema()); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding schema: %w", err))
+			return
+		}
+	})
+
+	mux.Handle("/debug/config/schema", routeMiddleware(authMiddleware(profileAuthMiddleware(handler, auth, profiler), auth), routeProfile))
+}
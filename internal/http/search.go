@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+)
+
+// defaultSearchLimit is the number of results, if the request has no limit.
+const defaultSearchLimit = 20
+
+// Searcher searches the text fields of a meeting.
+type Searcher interface {
+	Search(ctx context.Context, uid int, meetingID int, query string, limit int) ([]autoupdate.SearchResult, error)
+}
+
+// HandleSearch registers the route to search the motions, topics, agenda
+// items and users of a meeting. Only objects and fields, that the user can
+// see, are returned.
+//
+// /system/autoupdate/search?meeting_id=1&q=budget&limit=20
+func HandleSearch(mux *http.ServeMux, auth Authenticater, searcher Searcher, cfg Config) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := auth.FromContext(r.Context())
+		query := r.URL.Query()
+
+		meetingID, err := strconv.Atoi(query.Get("meeting_id"))
+		if err != nil || meetingID < 1 {
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("meeting_id has to be a positive number, not `%s`", query.Get("meeting_id"))})
+			return
+		}
+
+		limit := defaultSearchLimit
+		if query.Has("limit") {
+			limit, err = strconv.Atoi(query.Get("limit"))
+			if err != nil {
+				handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("limit has to be a number, not `%s`", query.Get("limit"))})
+				return
+			}
+		}
+
+		results, err := searcher.Search(r.Context(), uid, meetingID, query.Get("q"), limit)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("searching: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding search results: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(
+		prefixPublic+"/search",
+		routeMiddleware(
+			authMiddleware(rateLimitMiddleware(handler, auth, cfg.rateLimiter(routeSearch)), auth),
+			routeSearch,
+		),
+	)
+}
@@ -0,0 +1,61 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+)
+
+type searchStub struct {
+	uid       int
+	meetingID int
+	query     string
+	limit     int
+}
+
+func (s *searchStub) Search(ctx context.Context, uid int, meetingID int, query string, limit int) ([]autoupdate.SearchResult, error) {
+	s.uid = uid
+	s.meetingID = meetingID
+	s.query = query
+	s.limit = limit
+	return []autoupdate.SearchResult{{FQID: "motion/1", Fields: map[string]string{"title": "Budget"}}}, nil
+}
+
+func TestSearch(t *testing.T) {
+	stub := &searchStub{}
+	mux := http.NewServeMux()
+	ahttp.HandleSearch(mux, fakeAuth(1), stub, ahttp.Config{})
+
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, httptest.NewRequest("GET", "/system/autoupdate/search?meeting_id=7&q=bud", nil))
+
+	if resp.Code != 200 {
+		t.Fatalf("got status %d, expected 200: %s", resp.Code, resp.Body.String())
+	}
+
+	expect := `[{"fqid":"motion/1","fields":{"title":"Budget"}}]`
+	if got := strings.TrimSpace(resp.Body.String()); got != expect {
+		t.Errorf("got body %s, expected %s", got, expect)
+	}
+
+	if stub.uid != 1 || stub.meetingID != 7 || stub.query != "bud" || stub.limit != 20 {
+		t.Errorf("search was called with uid %d, meeting %d, query %q and limit %d", stub.uid, stub.meetingID, stub.query, stub.limit)
+	}
+
+	for _, url := range []string{
+		"/system/autoupdate/search?q=bud",
+		"/system/autoupdate/search?meeting_id=7&q=bud&limit=many",
+	} {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+
+		if resp.Code != 400 {
+			t.Errorf("%s: got status %d, expected 400", url, resp.Code)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// serverTime is the response of the time route.
+//
+// All times are unix times in seconds with millisecond precision, like the
+// field `projector_countdown/countdown_time`.
+type serverTime struct {
+	// ClientTime is the value of the query argument `client_time`. The client
+	// should send the time, when it sent the request.
+	ClientTime *float64 `json:"client_time,omitempty"`
+
+	// Received is the time, when the server received the request.
+	Received float64 `json:"server_received"`
+
+	// Sent is the time, when the server sent the response.
+	Sent float64 `json:"server_sent"`
+}
+
+// unixSeconds returns the unix time in seconds with millisecond precision.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
+
+// HandleServerTime registers a route that returns the time of the server.
+//
+// A client can calculate the offset of its clock with the time, when it
+// received the response:
+//
+//	offset = ((server_received - client_time) + (server_sent - client_received)) / 2
+//
+// The route does not need authentication.
+//
+// /system/autoupdate/time?client_time=1700000000.123
+func HandleServerTime(mux *http.ServeMux) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received := time.Now()
+
+		var response serverTime
+		if raw := r.URL.Query().Get("client_time"); raw != "" {
+			clientTime, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("client_time has to be a unix time in seconds, not `%s`", raw)})
+				return
+			}
+			response.ClientTime = &clientTime
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+		response.Received = unixSeconds(received)
+		response.Sent = unixSeconds(time.Now())
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding server time: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(prefixPublic+"/time", routeMiddleware(handler, routeProjector))
+}
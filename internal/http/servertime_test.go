@@ -0,0 +1,56 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+)
+
+func TestServerTime(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleServerTime(mux)
+
+	before := float64(time.Now().UnixMilli()) / 1000
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate/time?client_time=1700000000.5", nil))
+
+	after := float64(time.Now().UnixMilli()) / 1000
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, expected 200", rec.Code)
+	}
+
+	var got struct {
+		ClientTime float64 `json:"client_time"`
+		Received   float64 `json:"server_received"`
+		Sent       float64 `json:"server_sent"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+
+	if got.ClientTime != 1700000000.5 {
+		t.Errorf("got client time %f, expected 1700000000.5", got.ClientTime)
+	}
+
+	if got.Received < before || got.Sent < got.Received || got.Sent > after {
+		t.Errorf("got received %f and sent %f, expected between %f and %f", got.Received, got.Sent, before, after)
+	}
+}
+
+func TestServerTimeInvalid(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleServerTime(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate/time?client_time=now", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, expected 400", rec.Code)
+	}
+}
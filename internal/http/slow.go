@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envSlowRequest = environment.NewVariable("AUTOUPDATE_SLOW_REQUEST_THRESHOLD", "5s", "Autoupdate requests, whose first response takes longer, are logged with the request body and the time of each step. Zero disables the log.")
+
+// firstResponseTime is the time until the first response of autoupdate
+// requests.
+var firstResponseTime = metric.NewHistogram("autoupdate_first_response", metric.LatencyBuckets...)
+
+// maxBodySummary is the number of bytes of the body, that are written to the
+// log.
+const maxBodySummary = 1000
+
+func parseSlowRequest(lookup environment.Environmenter) (time.Duration, error) {
+	threshold, err := environment.ParseDuration(envSlowRequest.Value(lookup))
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envSlowRequest.Key, envSlowRequest.Value(lookup), err)
+	}
+	return threshold, nil
+}
+
+// slowRequest measures the steps until the first response of an autoupdate
+// request is written.
+type slowRequest struct {
+	threshold time.Duration
+	start     time.Time
+	last      time.Time
+	steps     []any
+	done      bool
+}
+
+// newSlowRequest starts the measurement. A threshold of zero disables the log.
+func newSlowRequest(threshold time.Duration) *slowRequest {
+	now := clock.Now()
+	return &slowRequest{
+		threshold: threshold,
+		start:     now,
+		last:      now,
+	}
+}
+
+// step saves the time since the last step with the given name.
+func (s *slowRequest) step(name string) {
+	if s.done {
+		return
+	}
+
+	now := clock.Now()
+	s.steps = append(s.steps, name+"_ms", now.Sub(s.last).Milliseconds())
+	s.last = now
+}
+
+// finish adds the time of the first response to the histogram and logs the
+// request, if it took longer then the threshold. Only the first call does
+// something.
+//
+// The request id, the user and the body are taken from the context.
+func (s *slowRequest) finish(ctx context.Context) {
+	if s.done {
+		return
+	}
+	s.done = true
+
+	duration := clock.Since(s.start)
+	firstResponseTime.Observe(ctx, duration)
+
+	if s.threshold <= 0 || duration <= s.threshold {
+		return
+	}
+
+	logger.WarnContext(ctx, "Slow request", append([]any{"duration_ms", duration.Milliseconds()}, s.steps...)...)
+}
+
+// bodySummary returns the start of the body for the log.
+func bodySummary(body []byte) string {
+	if len(body) <= maxBodySummary {
+		return string(body)
+	}
+	return fmt.Sprintf("%s... (%d bytes)", body[:maxBodySummary], len(body))
+}
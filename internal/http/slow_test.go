@@ -0,0 +1,71 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestSlowRequest(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+
+	fake := clock.NewFake(time.Now())
+	defer clock.Use(fake)()
+
+	connecter := &connecterMock{
+		f: func() (func(ctx context.Context) (map[dskey.Key][]byte, error), bool) {
+			return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+				fake.Advance(5 * time.Millisecond)
+				return map[dskey.Key][]byte{myKey1: []byte(`"bar"`)}, nil
+			}, true
+		},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		threshold time.Duration
+		expectLog bool
+	}{
+		{"slow", time.Millisecond, true},
+		{"fast", time.Hour, false},
+		{"disabled", 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := logging.New(environment.ForTests{}, buf); err != nil {
+				t.Fatalf("init logging: %v", err)
+			}
+
+			mux := http.NewServeMux()
+			ahttp.HandleAutoupdate(mux, fakeAuth(1), connecter, [2]*ahttp.ConnectionCount{}, ahttp.Config{SlowRequest: tt.threshold})
+
+			req := httptest.NewRequest("POST", "/system/autoupdate?single=1", strings.NewReader(`[{"ids":[1],"collection":"user","fields":{"username":null}}]`))
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			got := buf.String()
+			if !tt.expectLog {
+				if strings.Contains(got, "Slow request") {
+					t.Errorf("got log `%s`, expected no slow request", got)
+				}
+				return
+			}
+
+			for _, expect := range []string{`msg="Slow request"`, "calculate_ms=", "write_ms=", "user_id=1", `"collection\":\"user\"`} {
+				if !strings.Contains(got, expect) {
+					t.Errorf("log `%s` does not contain `%s`", got, expect)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/introspect"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/redis"
+)
+
+// Tenant is an organization with its own database and message bus. It is only
+// used with tenant isolation.
+type Tenant struct {
+	Auth       Authenticater
+	Autoupdate *autoupdate.Autoupdate
+
+	// MessageBus is the message bus of the organization. It saves the
+	// connection count and the handed off connections.
+	MessageBus *redis.Redis
+
+	// ICC handles the inter client communication of the organization. The
+	// routes are not registered, if it is nil.
+	ICC InterClientCommunicator
+}
+
+// tenantMiddleware adds the tenant of the request to the context. The tenant
+// is chosen by the host of the request.
+func tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name := tenant.Resolve(r.Host); name != "" {
+			r = r.WithContext(tenant.ContextWith(r.Context(), name))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tenantHandler serves the data routes of each organization with its own
+// backend. All other requests are served by shared.
+//
+// Each organization has its own connection count and meeting metric. Their
+// metric values have the prefix `tenant_<host>_`.
+func tenantHandler(
+	ctx context.Context,
+	shared http.Handler,
+	cfg Config,
+	saveConnectionCount bool,
+	saveInterval time.Duration,
+) http.Handler {
+	handlers := make(map[string]http.Handler, len(cfg.Tenants))
+	for name, t := range cfg.Tenants {
+		prefix := tenant.MetricPrefix(name)
+
+		var store *redis.Redis
+		if saveConnectionCount {
+			store = t.MessageBus
+		}
+
+		var connectionCount [2]*ConnectionCount
+		connectionCount[0] = newConnectionCount(ctx, store, saveInterval, "connections_stream")
+		connectionCount[1] = newConnectionCount(ctx, store, saveInterval, "connections_longpolling")
+		metric.Register(metric.Prefixed(prefix, connectionCount[0].Metric))
+		metric.Register(metric.Prefixed(prefix, connectionCount[1].Metric))
+
+		tenantCfg := cfg
+		tenantCfg.ICC = t.ICC
+		if cfg.ResumeStore != nil {
+			tenantCfg.ResumeStore = t.MessageBus
+		}
+		if cfg.MeetingMetric != nil {
+			tenantCfg.MeetingMetric = newMeetingMetric()
+			metric.Register(metric.Prefixed(prefix, tenantCfg.MeetingMetric.Metric))
+		}
+
+		introspect.Register(prefix+"connections", func() any {
+			return connectionInfo(connectionCount, tenantCfg.MeetingMetric)
+		})
+
+		mux := http.NewServeMux()
+		mux.Handle("/", shared)
+		handleData(mux, t.Auth, t.Autoupdate, connectionCount, tenantCfg)
+		handlers[name] = mux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := handlers[tenant.FromContext(r.Context())]; ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		shared.ServeHTTP(w, r)
+	})
+}
+
+// HandleUnknownOrganization registers the fallback for data requests to a
+// host, that is no organization.
+func HandleUnknownOrganization(mux *http.ServeMux) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenant.FromContext(r.Context()) != "" {
+			handleErrorWithStatus(w, notFoundError{})
+			return
+		}
+		handleErrorWithStatus(w, unknownOrganizationError{host: r.Host})
+	})
+
+	mux.Handle(prefixPublic, handler)
+	mux.Handle(prefixPublic+"/", handler)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func restrictNothing(ctx context.Context, getter flow.Getter, uid int) (context.Context, flow.Getter) {
+	return ctx, getter
+}
+
+func TestTenantHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tenants := make(map[string]Tenant)
+	for name, orgName := range map[string]string{"a.example.com": "A", "b.example.com": "B"} {
+		data := dsmock.NewFlow(dsmock.YAMLData(`organization/1/name: ` + orgName))
+		service, _, err := autoupdate.New(environment.ForTests{}, data, restrictNothing)
+		if err != nil {
+			t.Fatalf("autoupdate.New: %v", err)
+		}
+		tenants[name] = Tenant{Auth: fakeUser(1), Autoupdate: service}
+	}
+
+	shared := http.NewServeMux()
+	HandleVersion(shared)
+	HandleUnknownOrganization(shared)
+	handler := tenantHandler(ctx, shared, Config{Tenants: tenants}, false, 0)
+
+	request := func(host string, path string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest("GET", path, nil)
+		if host != "" {
+			req = req.WithContext(tenant.ContextWith(req.Context(), host))
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("data of the organization", func(t *testing.T) {
+		for host, expect := range map[string]string{"a.example.com": `"A"`, "b.example.com": `"B"`} {
+			rec := request(host, "/system/autoupdate/export?k=organization/1/name")
+			if rec.Code != 200 {
+				t.Fatalf("got status %d for %s, expected 200: %s", rec.Code, host, rec.Body.String())
+			}
+
+			if !strings.Contains(rec.Body.String(), expect) {
+				t.Errorf("got body %s for %s, expected %s", rec.Body.String(), host, expect)
+			}
+		}
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		rec := request("", "/system/autoupdate/export?k=organization/1/name")
+		if rec.Code != http.StatusMisdirectedRequest {
+			t.Errorf("got status %d, expected %d", rec.Code, http.StatusMisdirectedRequest)
+		}
+	})
+
+	t.Run("unknown route of an organization", func(t *testing.T) {
+		rec := request("a.example.com", "/system/autoupdate/unknown")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("got status %d, expected %d", rec.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("shared route", func(t *testing.T) {
+		for _, host := range []string{"", "a.example.com"} {
+			if rec := request(host, "/system/autoupdate/version"); rec.Code != 200 {
+				t.Errorf("got status %d for host %q, expected 200", rec.Code, host)
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envReadHeaderTimeout = environment.NewVariable("AUTOUPDATE_READ_HEADER_TIMEOUT", "10s", "Time a client has to send the request headers.")
+	envBodyReadTimeout   = environment.NewVariable("AUTOUPDATE_BODY_READ_TIMEOUT", "30s", "Time a client has to send the request body. The time for the response is not limited.")
+	envIdleTimeout       = environment.NewVariable("AUTOUPDATE_IDLE_TIMEOUT", "2m", "Time to keep an idle keep-alive connection open.")
+	envDrainTimeout      = environment.NewVariable("AUTOUPDATE_DRAIN_TIMEOUT", "0s", "Time open connections can continue after the service got the signal to stop or to restart. Zero closes them immediately.")
+	envMaxHeaderBytes    = environment.NewVariable("AUTOUPDATE_MAX_HEADER_BYTES", "65536", "Maximum size of the request headers in bytes.")
+	envMaxBodyBytes      = environment.NewVariable("AUTOUPDATE_MAX_BODY_BYTES", "10485760", "Maximum size of the request body in bytes. Larger requests are rejected with status 413. Zero disables the limit.")
+
+	envLongpollingTimeout = environment.NewVariable("AUTOUPDATE_LONGPOLLING_TIMEOUT", "25s", "Time after which a longpolling request returns an empty response, if there is no new data. Zero waits until there is data.")
+)
+
+// Timeouts holds the settings to protect the server against slow clients.
+//
+// There is no write timeout, since autoupdate responses stay open as long as
+// the client is connected.
+type Timeouts struct {
+	ReadHeader     time.Duration
+	BodyRead       time.Duration
+	Idle           time.Duration
+	MaxHeaderBytes int
+	MaxBodyBytes   int64
+
+	// Drain is the time running requests can continue after the server was
+	// stopped.
+	Drain time.Duration
+}
+
+// NewTimeouts reads the timeouts from the environment.
+func NewTimeouts(lookup environment.Environmenter) (Timeouts, error) {
+	var t Timeouts
+	for _, v := range []struct {
+		env    environment.Variable
+		target *time.Duration
+	}{
+		{envReadHeaderTimeout, &t.ReadHeader},
+		{envBodyReadTimeout, &t.BodyRead},
+		{envIdleTimeout, &t.Idle},
+		{envDrainTimeout, &t.Drain},
+	} {
+		d, err := environment.ParseDuration(v.env.Value(lookup))
+		if err != nil {
+			return Timeouts{}, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", v.env.Key, v.env.Value(lookup), err)
+		}
+		*v.target = d
+	}
+
+	maxHeaderBytes, err := strconv.Atoi(envMaxHeaderBytes.Value(lookup))
+	if err != nil {
+		return Timeouts{}, fmt.Errorf("invalid value for `%s`: %w", envMaxHeaderBytes.Key, err)
+	}
+	t.MaxHeaderBytes = maxHeaderBytes
+
+	maxBodyBytes, err := strconv.ParseInt(envMaxBodyBytes.Value(lookup), 10, 64)
+	if err != nil || maxBodyBytes < 0 {
+		return Timeouts{}, fmt.Errorf("invalid value for `%s`, expected a positive number got %s", envMaxBodyBytes.Key, envMaxBodyBytes.Value(lookup))
+	}
+	t.MaxBodyBytes = maxBodyBytes
+
+	return t, nil
+}
+
+// apply sets the timeouts on a http server.
+func (t Timeouts) apply(srv *http.Server) {
+	srv.ReadHeaderTimeout = t.ReadHeader
+	srv.IdleTimeout = t.Idle
+	srv.MaxHeaderBytes = t.MaxHeaderBytes
+}
+
+// bodyReadTimeoutMiddleware reads the request body before next is called. If
+// the client does not send the body in time, the request is canceled. A body
+// larger then maxBytes is rejected with status 413.
+//
+// Afterwards, the read deadline is removed so the response can stay open.
+//
+// Without a timeout, the body is not read in advance, but it is still limited.
+func bodyReadTimeoutMiddleware(next http.Handler, timeout time.Duration, maxBytes int64) http.Handler {
+	if timeout <= 0 && maxBytes <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if maxBytes > 0 {
+			if r.ContentLength > maxBytes {
+				handleErrorWithStatus(w, bodyTooLargeError{limit: maxBytes})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		}
+
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		deadlineSupported := true
+		if err := rc.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			if !errors.Is(err, http.ErrNotSupported) {
+				handleErrorWithStatus(w, fmt.Errorf("set read deadline: %w", err))
+				return
+			}
+			deadlineSupported = false
+		}
+
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				handleErrorWithStatus(w, bodyTooLargeError{limit: tooLarge.Limit})
+				return
+			}
+
+			handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("reading body: %w", err)})
+			return
+		}
+
+		if deadlineSupported {
+			if err := rc.SetReadDeadline(time.Time{}); err != nil {
+				handleErrorWithStatus(w, fmt.Errorf("reset read deadline: %w", err))
+				return
+			}
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
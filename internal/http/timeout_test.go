@@ -0,0 +1,114 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBodyReadTimeout(t *testing.T) {
+	handler := bodyReadTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}), 50*time.Millisecond, 1024)
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	t.Run("fast client", func(t *testing.T) {
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader("my body"))
+		if err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		got, _ := io.ReadAll(resp.Body)
+		if string(got) != "my body" {
+			t.Errorf("got body `%s`, expected `my body`", got)
+		}
+	})
+
+	t.Run("slow client", func(t *testing.T) {
+		bodyReader, bodyWriter := io.Pipe()
+		defer bodyWriter.Close()
+
+		go func() {
+			bodyWriter.Write([]byte("start"))
+			// Never finish the body.
+		}()
+
+		resp, err := http.Post(srv.URL, "application/json", bodyReader)
+		if err != nil {
+			// The server can close the connection before the response is
+			// read.
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("got status %s, expected %s", resp.Status, http.StatusText(http.StatusBadRequest))
+		}
+	})
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleErrorWithStatus(w, err)
+			return
+		}
+		w.Write(body)
+	})
+
+	for _, tt := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"with timeout", time.Second},
+		{"without timeout", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(bodyReadTimeoutMiddleware(echo, tt.timeout, 10))
+			defer srv.Close()
+
+			resp, err := http.Post(srv.URL, "application/json", strings.NewReader("small"))
+			if err != nil {
+				t.Fatalf("sending request: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("small body got status %s, expected 200", resp.Status)
+			}
+
+			resp, err = http.Post(srv.URL, "application/json", strings.NewReader("a body that is too large"))
+			if err != nil {
+				t.Fatalf("sending request: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusRequestEntityTooLarge {
+				t.Errorf("large body got status %s, expected 413", resp.Status)
+			}
+		})
+	}
+
+	t.Run("without content length", func(t *testing.T) {
+		srv := httptest.NewServer(bodyReadTimeoutMiddleware(echo, time.Second, 10))
+		defer srv.Close()
+
+		// A reader without a known size is sent chunked.
+		body := io.MultiReader(strings.NewReader("a body that "), strings.NewReader("is too large"))
+		resp, err := http.Post(srv.URL, "application/json", body)
+		if err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("got status %s, expected 413", resp.Status)
+		}
+	})
+}
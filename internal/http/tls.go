@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+// tlsReloadInterval defines how often the certificate files are checked for
+// changes.
+const tlsReloadInterval = 10 * time.Second
+
+var (
+	envTLSCertFile = environment.NewVariable("AUTOUPDATE_TLS_CERT_FILE", "", "Path to a PEM encoded certificate. If set, the service uses TLS. The file is reloaded when it changes.")
+	envTLSKeyFile  = environment.NewVariable("AUTOUPDATE_TLS_KEY_FILE", "", "Path to the PEM encoded private key of the certificate.")
+)
+
+// CertReloader holds a tls certificate and reloads it, when the files on disk
+// change.
+//
+// Has to be initialized with NewCertReloader().
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewCertReloader reads the certificate from the files configured in the
+// environment.
+//
+// Returns nil, if no certificate is configured.
+func NewCertReloader(lookup environment.Environmenter) (*CertReloader, error) {
+	certFile := envTLSCertFile.Value(lookup)
+	keyFile := envTLSKeyFile.Value(lookup)
+
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("`%s` and `%s` have to be set together", envTLSCertFile.Key, envTLSKeyFile.Key)
+	}
+
+	r := CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if _, err := r.reload(); err != nil {
+		return nil, fmt.Errorf("loading certificate: %w", err)
+	}
+
+	return &r, nil
+}
+
+// TLSConfig returns a tls config that uses the current certificate.
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}
+}
+
+func (r *CertReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// reload reads the certificate files, if they have changed since the last
+// call. Returns true, if the certificate was replaced.
+//
+// If the new files are invalid, the old certificate is kept.
+func (r *CertReloader) reload() (bool, error) {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// lastModified returns the newest modification time of the two files.
+func (r *CertReloader) lastModified() (time.Time, error) {
+	var newest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat %s: %w", file, err)
+		}
+
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+// Watch checks the certificate files from time to time and reloads them, when
+// they change.
+//
+// Blocks until the context is done.
+func (r *CertReloader) Watch(ctx context.Context) {
+	tick := time.NewTicker(tlsReloadInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+
+		if _, err := r.reload(); err != nil {
+			oserror.Handle(fmt.Errorf("reloading tls certificate: %w", err))
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func writeTestCert(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, modTime, modTime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+}
+
+func commonName(t *testing.T, r *CertReloader) string {
+	t.Helper()
+
+	cert, err := r.getCertificate(nil)
+	if err != nil {
+		t.Fatalf("get certificate: %v", err)
+	}
+
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	now := time.Now()
+
+	writeTestCert(t, certFile, keyFile, "first", now.Add(-time.Minute))
+
+	r, err := NewCertReloader(environment.ForTests{
+		"AUTOUPDATE_TLS_CERT_FILE": certFile,
+		"AUTOUPDATE_TLS_KEY_FILE":  keyFile,
+	})
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	if got := commonName(t, r); got != "first" {
+		t.Errorf("got certificate %s, expected first", got)
+	}
+
+	if changed, err := r.reload(); err != nil || changed {
+		t.Errorf("reload without changes returned %t, %v", changed, err)
+	}
+
+	writeTestCert(t, certFile, keyFile, "second", now)
+
+	if changed, err := r.reload(); err != nil || !changed {
+		t.Errorf("reload with changes returned %t, %v", changed, err)
+	}
+
+	if got := commonName(t, r); got != "second" {
+		t.Errorf("got certificate %s, expected second", got)
+	}
+}
+
+func TestCertReloaderDisabled(t *testing.T) {
+	r, err := NewCertReloader(environment.ForTests{})
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	if r != nil {
+		t.Errorf("got a cert reloader, expected nil")
+	}
+}
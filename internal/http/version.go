@@ -0,0 +1,28 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/buildinfo"
+)
+
+// HandleVersion registers a route that returns the version, the commit and the
+// build date of the service and the state of the feature flags.
+//
+// The route does not need authentication.
+//
+// /system/autoupdate/version
+func HandleVersion(mux *http.ServeMux) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(buildinfo.Get()); err != nil {
+			handleErrorWithoutStatus(w, fmt.Errorf("encoding version: %w", err))
+			return
+		}
+	})
+
+	mux.Handle(prefixPublic+"/version", routeMiddleware(handler, routeHealth))
+}
@@ -0,0 +1,32 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/buildinfo"
+	ahttp "github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+)
+
+func TestVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	ahttp.HandleVersion(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/system/autoupdate/version", nil))
+
+	if rec.Code != 200 {
+		t.Fatalf("Got status %d, expected 200", rec.Code)
+	}
+
+	var got buildinfo.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid json: %v", err)
+	}
+
+	if got.Version != buildinfo.Version || got.GoVersion == "" {
+		t.Errorf("Got %+v, expected the build information", got)
+	}
+}
@@ -0,0 +1,160 @@
+package icc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
+)
+
+const (
+	// applauseInterval is the interval, how often the applause of a meeting
+	// is calculated and sent to the receivers.
+	applauseInterval = time.Second
+
+	// defaultApplauseTimeout is the time in seconds, an applause counts, if
+	// the meeting has no timeout.
+	defaultApplauseTimeout = 5
+)
+
+// Applause is the applause of a meeting.
+type Applause struct {
+	// Level is the number of users, that applauded in the applause timeout
+	// of the meeting.
+	Level int `json:"level"`
+
+	// PresentUsers is the number of present users of the meeting.
+	PresentUsers int `json:"present_users"`
+}
+
+// SendApplause saves the applause of the user. The user has to be in the
+// meeting and the applause has to be enabled.
+func (icc *ICC) SendApplause(ctx context.Context, meetingID int, userID int) error {
+	if err := icc.checkMeeting(ctx, meetingID, userID); err != nil {
+		return err
+	}
+
+	enabled, err := dsfetch.New(icc.getter).Meeting_ApplauseEnable(meetingID).Value(ctx)
+	if err != nil {
+		return fmt.Errorf("checking applause of meeting %d: %w", meetingID, err)
+	}
+
+	if !enabled {
+		return invalidInputError{fmt.Sprintf("applause is not enabled in meeting %d", meetingID)}
+	}
+
+	if err := icc.backend.SendApplause(ctx, meetingID, userID, clock.Now()); err != nil {
+		return fmt.Errorf("sending applause: %w", err)
+	}
+	return nil
+}
+
+// ReceiveApplause calls send with the applause of the meeting, each time it
+// changes. Blocks until the context is done or send returns an error.
+func (icc *ICC) ReceiveApplause(ctx context.Context, meetingID int, userID int, send func(Applause) error) error {
+	if err := icc.checkMeeting(ctx, meetingID, userID); err != nil {
+		return err
+	}
+
+	ticker := clock.NewTicker(applauseInterval)
+	defer ticker.Stop()
+
+	var last Applause
+	first := true
+	for {
+		applause, err := icc.applauseOf(ctx, meetingID)
+		if err != nil {
+			return err
+		}
+
+		if first || applause != last {
+			if err := send(applause); err != nil {
+				return err
+			}
+			last = applause
+			first = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+		}
+	}
+}
+
+// applauseOf returns the applause of a meeting. It is calculated once per
+// interval for all receivers of the meeting.
+func (icc *ICC) applauseOf(ctx context.Context, meetingID int) (Applause, error) {
+	now := clock.Now()
+	if applause, ok := icc.applause.get(meetingID, now); ok {
+		return applause, nil
+	}
+
+	ds := dsfetch.New(icc.getter)
+	var timeout int
+	var presentUserIDs []int
+	ds.Meeting_ApplauseTimeout(meetingID).Lazy(&timeout)
+	ds.Meeting_PresentUserIDs(meetingID).Lazy(&presentUserIDs)
+	if err := ds.Execute(ctx); err != nil {
+		return Applause{}, fmt.Errorf("getting applause settings of meeting %d: %w", meetingID, err)
+	}
+
+	if timeout <= 0 {
+		timeout = defaultApplauseTimeout
+	}
+
+	level, err := icc.backend.ApplauseSince(ctx, meetingID, now.Add(-time.Duration(timeout)*time.Second))
+	if err != nil {
+		return Applause{}, fmt.Errorf("getting applause of meeting %d: %w", meetingID, err)
+	}
+
+	applause := Applause{Level: level, PresentUsers: len(presentUserIDs)}
+	icc.applause.set(meetingID, now, applause)
+	return applause, nil
+}
+
+// applauseCache holds the last calculated applause of each meeting.
+type applauseCache struct {
+	mu       *sync.Mutex
+	meetings map[int]cachedApplause
+}
+
+type cachedApplause struct {
+	at       time.Time
+	applause Applause
+}
+
+func newApplauseCache() applauseCache {
+	return applauseCache{mu: new(sync.Mutex), meetings: make(map[int]cachedApplause)}
+}
+
+// get returns the applause of the meeting, if it was calculated in the last
+// interval.
+func (c applauseCache) get(meetingID int, now time.Time) (Applause, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.meetings[meetingID]
+	if !ok || now.Sub(cached.at) >= applauseInterval {
+		return Applause{}, false
+	}
+	return cached.applause, true
+}
+
+// set saves the applause of the meeting. Old values of other meetings are
+// removed.
+func (c applauseCache) set(meetingID int, now time.Time, applause Applause) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, cached := range c.meetings {
+		if now.Sub(cached.at) >= applauseInterval {
+			delete(c.meetings, id)
+		}
+	}
+	c.meetings[meetingID] = cachedApplause{at: now, applause: applause}
+}
@@ -0,0 +1,84 @@
+// Package icc implements the inter client communication.
+//
+// Clients can send notify messages to other clients of a meeting and can
+// applaud in a meeting. Before, this was a separate streaming service. Now it
+// uses the auth and the connections of the autoupdate service.
+//
+// The messages and the applause are shared between the instances with redis.
+package icc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/ostcar/topic"
+)
+
+// pruneInterval is the interval, how often old notify messages are removed
+// from memory. A receiver, that is slower, misses messages.
+const pruneInterval = time.Minute
+
+// Backend shares the notify messages and the applause between the instances.
+type Backend interface {
+	SendNotify(ctx context.Context, message []byte) error
+	ReceiveNotify(ctx context.Context, id string) (string, [][]byte, error)
+	SendApplause(ctx context.Context, meetingID int, userID int, t time.Time) error
+	ApplauseSince(ctx context.Context, meetingID int, since time.Time) (int, error)
+}
+
+// ICC handles the notify messages and the applause.
+//
+// Has to be created with New.
+type ICC struct {
+	backend Backend
+	getter  flow.Getter
+
+	notify   *topic.Topic[*notifyMessage]
+	channels channelIDs
+	applause applauseCache
+}
+
+// New creates an ICC. The getter is used to check the permissions.
+//
+// The returned function reads the notify messages from the backend. It has to
+// be run in the background.
+func New(backend Backend, getter flow.Getter) (*ICC, func(context.Context, func(error))) {
+	icc := &ICC{
+		backend:  backend,
+		getter:   getter,
+		notify:   topic.New[*notifyMessage](),
+		channels: newChannelIDs(),
+		applause: newApplauseCache(),
+	}
+	return icc, icc.listen
+}
+
+// checkMeeting returns an error, if the user is not in the meeting.
+func (icc *ICC) checkMeeting(ctx context.Context, meetingID int, userID int) error {
+	if meetingID < 1 {
+		return invalidInputError{fmt.Sprintf("invalid meeting_id %d", meetingID)}
+	}
+
+	if userID == 0 {
+		return permissionDeniedError{"anonymous users can not use the inter client communication"}
+	}
+
+	userIDs, err := dsfetch.New(icc.getter).Meeting_UserIDs(meetingID).Value(ctx)
+	if err != nil {
+		var errNotExist dsfetch.DoesNotExistError
+		if errors.As(err, &errNotExist) {
+			return invalidInputError{fmt.Sprintf("meeting %d does not exist", meetingID)}
+		}
+		return fmt.Errorf("getting users of meeting %d: %w", meetingID, err)
+	}
+
+	if !slices.Contains(userIDs, userID) {
+		return permissionDeniedError{fmt.Sprintf("user %d is not in meeting %d", userID, meetingID)}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package icc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+)
+
+// memoryBackend shares the messages in memory.
+type memoryBackend struct {
+	mu       sync.Mutex
+	messages [][]byte
+	signal   chan struct{}
+	applause map[int]map[int]time.Time
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{
+		signal:   make(chan struct{}),
+		applause: make(map[int]map[int]time.Time),
+	}
+}
+
+func (b *memoryBackend) SendNotify(ctx context.Context, message []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, message)
+	close(b.signal)
+	b.signal = make(chan struct{})
+	return nil
+}
+
+// ReceiveNotify uses the number of read messages as id.
+func (b *memoryBackend) ReceiveNotify(ctx context.Context, id string) (string, [][]byte, error) {
+	b.mu.Lock()
+	pos := len(b.messages)
+	b.mu.Unlock()
+
+	if id != "" {
+		pos, _ = strconv.Atoi(id)
+	}
+
+	for {
+		b.mu.Lock()
+		messages := b.messages[pos:]
+		signal := b.signal
+		b.mu.Unlock()
+
+		if len(messages) > 0 {
+			return strconv.Itoa(pos + len(messages)), messages, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return id, nil, ctx.Err()
+		case <-signal:
+		}
+	}
+}
+
+func (b *memoryBackend) SendApplause(ctx context.Context, meetingID int, userID int, t time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.applause[meetingID] == nil {
+		b.applause[meetingID] = make(map[int]time.Time)
+	}
+	b.applause[meetingID][userID] = t
+	return nil
+}
+
+func (b *memoryBackend) ApplauseSince(ctx context.Context, meetingID int, since time.Time) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var count int
+	for _, t := range b.applause[meetingID] {
+		if !t.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+var testData = dsmock.YAMLData(`---
+meeting/1:
+	user_ids: [1, 2]
+	present_user_ids: [1]
+	applause_enable: true
+	applause_timeout: 5
+meeting/2/user_ids: [3]
+`)
+
+func TestCheckMeeting(t *testing.T) {
+	icc, _ := New(newMemoryBackend(), dsmock.Stub(testData))
+	ctx := context.Background()
+
+	if err := icc.checkMeeting(ctx, 1, 1); err != nil {
+		t.Errorf("user in meeting: %v", err)
+	}
+
+	var errPerm permissionDeniedError
+	if err := icc.checkMeeting(ctx, 1, 3); !errors.As(err, &errPerm) {
+		t.Errorf("user not in meeting: got %v, expected permission denied", err)
+	}
+
+	if err := icc.checkMeeting(ctx, 1, 0); !errors.As(err, &errPerm) {
+		t.Errorf("anonymous: got %v, expected permission denied", err)
+	}
+
+	var errInvalid invalidInputError
+	if err := icc.checkMeeting(ctx, 404, 1); !errors.As(err, &errInvalid) {
+		t.Errorf("unknown meeting: got %v, expected invalid input", err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	icc, background := New(newMemoryBackend(), dsmock.Stub(testData))
+	go background(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	type receiver struct {
+		channelID chan string
+		messages  chan string
+	}
+
+	receive := func(userID int) receiver {
+		r := receiver{channelID: make(chan string, 1), messages: make(chan string, 10)}
+		go icc.ReceiveNotify(ctx, 1, userID, func(message []byte) error {
+			if strings.Contains(string(message), `"channel_id"`) {
+				r.channelID <- string(message)
+				return nil
+			}
+			r.messages <- string(message)
+			return nil
+		})
+		return r
+	}
+
+	receiver1 := receive(1)
+	receiver2 := receive(2)
+	channel1 := strings.Split(<-receiver1.channelID, `"`)[3]
+	<-receiver2.channelID
+
+	// Wait until the receivers block on the topic.
+	time.Sleep(10 * time.Millisecond)
+
+	body := `{"channel_id": "` + channel1 + `", "meeting_id": 1, "to_users": [2], "name": "hello", "message": {"text": "hi"}}`
+	if err := icc.SendNotify(ctx, 1, strings.NewReader(body)); err != nil {
+		t.Fatalf("SendNotify: %v", err)
+	}
+
+	select {
+	case got := <-receiver2.messages:
+		expect := `{"sender_user_id":1,"sender_channel_id":"` + channel1 + `","name":"hello","message":{"text":"hi"}}`
+		if got != expect {
+			t.Errorf("got message %s, expected %s", got, expect)
+		}
+	case <-ctx.Done():
+		t.Fatalf("receiver 2 did not get the message")
+	}
+
+	select {
+	case got := <-receiver1.messages:
+		t.Errorf("receiver 1 got message %s", got)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestSendNotifyInvalid(t *testing.T) {
+	icc, _ := New(newMemoryBackend(), dsmock.Stub(testData))
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{`},
+		{"no name", `{"channel_id": "abc:1:1", "meeting_id": 1}`},
+		{"channel of other user", `{"channel_id": "abc:2:1", "meeting_id": 1, "name": "x"}`},
+		{"invalid channel", `{"channel_id": "abc", "meeting_id": 1, "name": "x"}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var errInvalid invalidInputError
+			if err := icc.SendNotify(ctx, 1, strings.NewReader(tt.body)); !errors.As(err, &errInvalid) {
+				t.Errorf("got %v, expected invalid input", err)
+			}
+		})
+	}
+}
+
+func TestApplause(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer clock.Use(fake)()
+
+	icc, _ := New(newMemoryBackend(), dsmock.Stub(testData))
+
+	received := make(chan Applause, 10)
+	go icc.ReceiveApplause(ctx, 1, 1, func(a Applause) error {
+		received <- a
+		return nil
+	})
+
+	if got := <-received; got != (Applause{Level: 0, PresentUsers: 1}) {
+		t.Errorf("first applause is %v, expected level 0 with 1 present user", got)
+	}
+
+	if err := icc.SendApplause(ctx, 1, 2); err != nil {
+		t.Fatalf("SendApplause: %v", err)
+	}
+
+	if err := fake.BlockUntil(ctx, 1); err != nil {
+		t.Fatalf("waiting for the ticker: %v", err)
+	}
+	fake.Advance(applauseInterval)
+
+	select {
+	case got := <-received:
+		if got.Level != 1 {
+			t.Errorf("got applause level %d, expected 1", got.Level)
+		}
+	case <-ctx.Done():
+		t.Fatalf("no applause after the interval")
+	}
+
+	var errInvalid invalidInputError
+	if err := icc.SendApplause(ctx, 2, 3); !errors.As(err, &errInvalid) {
+		t.Errorf("applause in meeting without applause: got %v, expected invalid input", err)
+	}
+}
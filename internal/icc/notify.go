@@ -0,0 +1,229 @@
+package icc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/ostcar/topic"
+)
+
+// notifyMessage is a notify message as it is saved in the backend.
+type notifyMessage struct {
+	MeetingID       int             `json:"meeting_id"`
+	SenderUserID    int             `json:"sender_user_id"`
+	SenderChannelID string          `json:"sender_channel_id"`
+	ToAll           bool            `json:"to_all,omitempty"`
+	ToUsers         []int           `json:"to_users,omitempty"`
+	ToChannels      []string        `json:"to_channels,omitempty"`
+	Name            string          `json:"name"`
+	Message         json.RawMessage `json:"message"`
+}
+
+// forReceiver tells, if the message is for a receiver in the meeting.
+func (m *notifyMessage) forReceiver(meetingID int, userID int, channelID string) bool {
+	if m.MeetingID != meetingID {
+		return false
+	}
+
+	return m.ToAll || slices.Contains(m.ToUsers, userID) || slices.Contains(m.ToChannels, channelID)
+}
+
+// notifyOutput is a notify message as it is sent to a receiver.
+type notifyOutput struct {
+	SenderUserID    int             `json:"sender_user_id"`
+	SenderChannelID string          `json:"sender_channel_id"`
+	Name            string          `json:"name"`
+	Message         json.RawMessage `json:"message"`
+}
+
+// SendNotify sends a notify message from the user. The body has the fields
+// `channel_id`, `meeting_id`, `name`, `message` and the receivers `to_all`,
+// `to_users` and `to_channels`.
+//
+// The channel id has to be one of the user. The user has to be in the meeting.
+func (icc *ICC) SendNotify(ctx context.Context, userID int, body io.Reader) error {
+	var request struct {
+		ChannelID  string          `json:"channel_id"`
+		MeetingID  int             `json:"meeting_id"`
+		ToAll      bool            `json:"to_all"`
+		ToUsers    []int           `json:"to_users"`
+		ToChannels []string        `json:"to_channels"`
+		Name       string          `json:"name"`
+		Message    json.RawMessage `json:"message"`
+	}
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		return invalidInputError{fmt.Sprintf("invalid notify message: %v", err)}
+	}
+
+	if request.Name == "" {
+		return invalidInputError{"notify message has no name"}
+	}
+
+	if uid, ok := channelUserID(request.ChannelID); !ok || uid != userID {
+		return invalidInputError{fmt.Sprintf("invalid channel_id `%s`", request.ChannelID)}
+	}
+
+	if err := icc.checkMeeting(ctx, request.MeetingID, userID); err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(notifyMessage{
+		MeetingID:       request.MeetingID,
+		SenderUserID:    userID,
+		SenderChannelID: request.ChannelID,
+		ToAll:           request.ToAll,
+		ToUsers:         request.ToUsers,
+		ToChannels:      request.ToChannels,
+		Name:            request.Name,
+		Message:         request.Message,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding notify message: %w", err)
+	}
+
+	if err := icc.backend.SendNotify(ctx, encoded); err != nil {
+		return fmt.Errorf("sending notify message: %w", err)
+	}
+	return nil
+}
+
+// ReceiveNotify calls send with each notify message for the user in the
+// meeting. Blocks until the context is done or send returns an error.
+//
+// The first message is the channel id of the receiver. It can be used to send
+// messages.
+func (icc *ICC) ReceiveNotify(ctx context.Context, meetingID int, userID int, send func([]byte) error) error {
+	if err := icc.checkMeeting(ctx, meetingID, userID); err != nil {
+		return err
+	}
+
+	channelID := icc.channels.new(userID)
+	first, err := json.Marshal(map[string]string{"channel_id": channelID})
+	if err != nil {
+		return fmt.Errorf("encoding channel id: %w", err)
+	}
+
+	if err := send(first); err != nil {
+		return err
+	}
+
+	tid := icc.notify.LastID()
+	for {
+		var messages []*notifyMessage
+		tid, messages, err = icc.notify.Receive(ctx, tid)
+		if err != nil {
+			var errUnknown topic.UnknownIDError
+			if errors.As(err, &errUnknown) {
+				// The receiver was too slow and the messages were pruned.
+				tid = icc.notify.LastID()
+				continue
+			}
+			return err
+		}
+
+		for _, m := range messages {
+			if !m.forReceiver(meetingID, userID, channelID) {
+				continue
+			}
+
+			encoded, err := json.Marshal(notifyOutput{
+				SenderUserID:    m.SenderUserID,
+				SenderChannelID: m.SenderChannelID,
+				Name:            m.Name,
+				Message:         m.Message,
+			})
+			if err != nil {
+				return fmt.Errorf("encoding notify message: %w", err)
+			}
+
+			if err := send(encoded); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// listen reads the notify messages from the backend until the context is done.
+func (icc *ICC) listen(ctx context.Context, errorHandler func(error)) {
+	var id string
+	lastPrune := clock.Now()
+	for {
+		newID, messages, err := icc.backend.ReceiveNotify(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			errorHandler(fmt.Errorf("receiving notify messages: %w", err))
+			if err := clock.Sleep(ctx, time.Second); err != nil {
+				return
+			}
+			continue
+		}
+		id = newID
+
+		decoded := make([]*notifyMessage, 0, len(messages))
+		for _, raw := range messages {
+			var m notifyMessage
+			if err := json.Unmarshal(raw, &m); err != nil {
+				errorHandler(fmt.Errorf("decoding notify message: %w", err))
+				continue
+			}
+			decoded = append(decoded, &m)
+		}
+
+		if len(decoded) > 0 {
+			icc.notify.Publish(decoded...)
+		}
+
+		if now := clock.Now(); now.Sub(lastPrune) >= pruneInterval {
+			// The topic saves the real time of each message.
+			icc.notify.Prune(time.Now().Add(-pruneInterval))
+			lastPrune = now
+		}
+	}
+}
+
+// channelIDs creates the channel ids of the receivers.
+//
+// A channel id has the form `instance:user_id:counter`, so it is unique for
+// all instances and the user of a channel is known.
+type channelIDs struct {
+	instance string
+	counter  *atomic.Uint64
+}
+
+func newChannelIDs() channelIDs {
+	var b [4]byte
+	rand.Read(b[:])
+	return channelIDs{instance: hex.EncodeToString(b[:]), counter: new(atomic.Uint64)}
+}
+
+func (c channelIDs) new(userID int) string {
+	return fmt.Sprintf("%s:%d:%d", c.instance, userID, c.counter.Add(1))
+}
+
+// channelUserID returns the user id of a channel id.
+func channelUserID(channelID string) (int, bool) {
+	parts := strings.Split(channelID, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	userID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
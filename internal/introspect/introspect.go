@@ -0,0 +1,110 @@
+// Package introspect collects information about the running service.
+//
+// Packages register sections with Register. Collect returns all sections
+// together with the runtime values of the go process.
+package introspect
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+var sections struct {
+	mu sync.Mutex
+	fs map[string]func() any
+}
+
+var tasks struct {
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// Register adds a section. The function is called each time the information
+// is collected. Its return value has to be encodable as json.
+//
+// A section with the same name is replaced.
+func Register(name string, f func() any) {
+	sections.mu.Lock()
+	defer sections.mu.Unlock()
+
+	if sections.fs == nil {
+		sections.fs = make(map[string]func() any)
+	}
+	sections.fs[name] = f
+}
+
+// Task wraps a background task, so it is listed as active while it runs.
+func Task(name string, f func(context.Context, func(error))) func(context.Context, func(error)) {
+	return func(ctx context.Context, errorHandler func(error)) {
+		tasks.mu.Lock()
+		if tasks.running == nil {
+			tasks.running = make(map[string]int)
+		}
+		tasks.running[name]++
+		tasks.mu.Unlock()
+
+		defer func() {
+			tasks.mu.Lock()
+			tasks.running[name]--
+			if tasks.running[name] <= 0 {
+				delete(tasks.running, name)
+			}
+			tasks.mu.Unlock()
+		}()
+
+		f(ctx, errorHandler)
+	}
+}
+
+// Collect returns all registered sections, the active background tasks and
+// the runtime values.
+func Collect() map[string]any {
+	sections.mu.Lock()
+	fs := make(map[string]func() any, len(sections.fs))
+	for name, f := range sections.fs {
+		fs[name] = f
+	}
+	sections.mu.Unlock()
+
+	out := make(map[string]any, len(fs)+2)
+	for name, f := range fs {
+		out[name] = f()
+	}
+
+	tasks.mu.Lock()
+	running := make(map[string]int, len(tasks.running))
+	for name, count := range tasks.running {
+		running[name] = count
+	}
+	tasks.mu.Unlock()
+	out["background_tasks"] = running
+
+	out["runtime"] = runtimeInfo()
+	return out
+}
+
+// runtimeInfo returns the values of the go runtime.
+func runtimeInfo() map[string]any {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return map[string]any{
+		"go_version": runtime.Version(),
+		"goroutines": runtime.NumGoroutine(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"heap": map[string]uint64{
+			"alloc_bytes":    mem.HeapAlloc,
+			"inuse_bytes":    mem.HeapInuse,
+			"idle_bytes":     mem.HeapIdle,
+			"released_bytes": mem.HeapReleased,
+			"sys_bytes":      mem.HeapSys,
+			"objects":        mem.HeapObjects,
+		},
+		"gc": map[string]uint64{
+			"count":          uint64(mem.NumGC),
+			"pause_total_ns": mem.PauseTotalNs,
+			"next_bytes":     mem.NextGC,
+		},
+	}
+}
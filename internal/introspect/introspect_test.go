@@ -0,0 +1,74 @@
+package introspect_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/introspect"
+)
+
+func TestCollect(t *testing.T) {
+	introspect.Register("section", func() any { return map[string]int{"value": 1} })
+	introspect.Register("section", func() any { return map[string]int{"value": 2} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	done := make(chan struct{})
+	task := introspect.Task("my_task", func(ctx context.Context, errorHandler func(error)) {
+		close(started)
+		<-ctx.Done()
+	})
+	go func() {
+		task(ctx, func(error) {})
+		close(done)
+	}()
+	<-started
+
+	got := decode(t, introspect.Collect())
+
+	if got.Section.Value != 2 {
+		t.Errorf("got section value %d, expected the replaced value 2", got.Section.Value)
+	}
+
+	if got.Tasks["my_task"] != 1 {
+		t.Errorf("got tasks %v, expected my_task", got.Tasks)
+	}
+
+	if got.Runtime.Goroutines == 0 || got.Runtime.Heap["alloc_bytes"] == 0 {
+		t.Errorf("got runtime %+v, expected goroutines and heap", got.Runtime)
+	}
+
+	cancel()
+	<-done
+
+	if got := decode(t, introspect.Collect()); len(got.Tasks) != 0 {
+		t.Errorf("finished task is still listed: %v", got.Tasks)
+	}
+}
+
+type collected struct {
+	Section struct {
+		Value int `json:"value"`
+	} `json:"section"`
+	Tasks   map[string]int `json:"background_tasks"`
+	Runtime struct {
+		Goroutines int               `json:"goroutines"`
+		Heap       map[string]uint64 `json:"heap"`
+	} `json:"runtime"`
+}
+
+func decode(t *testing.T, v map[string]any) collected {
+	t.Helper()
+
+	bs, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	var c collected
+	if err := json.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	return c
+}
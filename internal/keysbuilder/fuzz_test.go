@@ -0,0 +1,61 @@
+package keysbuilder_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// valueGetter returns the same value for each key.
+type valueGetter []byte
+
+func (v valueGetter) Get(_ context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	data := make(map[dskey.Key][]byte, len(keys))
+	for _, key := range keys {
+		data[key] = v
+	}
+	return data, nil
+}
+
+func FuzzManyFromJSON(f *testing.F) {
+	f.Add([]byte(`[{"ids":[1],"collection":"user","fields":{"username":null}}]`), []byte(`"hugo"`))
+	f.Add([]byte(`{"ids":[1],"collection":"user","fields":{"group_ids":{"type":"relation-list","collection":"group","fields":{"name":null}}}}`), []byte(`[1,2]`))
+	f.Add([]byte(`{"ids":[1],"collection":"motion","fields":{"lead_motion_id":{"type":"relation","collection":"motion","fields":{"title":null}}}}`), []byte(`5`))
+	f.Add([]byte(`{"ids":[1],"collection":"tag","fields":{"tagged_ids":{"type":"generic-relation-list","fields":{"title":null}}}}`), []byte(`["motion/30"]`))
+	f.Add([]byte(`{"ids":[1],"collection":"motion","fields":{"agenda_item_id":{"type":"generic-relation","fields":{"id":null}}}}`), []byte(`"agenda_item/1"`))
+	f.Add([]byte(`{"ids":[1],"collection":"user","fields":{"username":{"type":"template"}}}`), []byte(`null`))
+	f.Add([]byte(`[`), []byte(``))
+
+	f.Fuzz(func(t *testing.T, body []byte, value []byte) {
+		b, err := keysbuilder.ManyFromJSON(bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+
+		// Errors are expected. The test fails on a panic.
+		b.Update(context.Background(), valueGetter(value))
+	})
+}
+
+func FuzzFromKeys(f *testing.F) {
+	f.Add("user/1/username")
+	f.Add("user/1/username,motion/2/title")
+	f.Add("user//username")
+	f.Add("user/-1/username")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, query string) {
+		b, err := keysbuilder.FromKeys(strings.Split(query, ",")...)
+		if err != nil {
+			return
+		}
+
+		if _, err := b.Update(context.Background(), valueGetter(nil)); err != nil {
+			t.Errorf("Update with keys from %q: %v", query, err)
+		}
+	})
+}
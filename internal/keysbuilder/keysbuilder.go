@@ -10,6 +10,7 @@ import (
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/zeebo/xxh3"
 )
 
 // Builder builds the keys. It is not save for concourent use. There is one
@@ -22,6 +23,22 @@ type Builder struct {
 	mu sync.Mutex
 
 	bodies []body
+
+	// expanded are the keys, that each key with a description added in the
+	// last call to Update. levels are the keys of each level of the tree from
+	// the last call. They are used to skip decoding values, that did not
+	// change.
+	expanded map[keyDescription]expansion
+	levels   [][]keyDescription
+	reused   int
+}
+
+// expansion are the child keys, that a key with a description added for a
+// value. The children are levels[level][start:end].
+type expansion struct {
+	hash       uint64
+	level      int
+	start, end int
 }
 
 // FromKeys creates a keysbuilder from a list of keys.
@@ -77,6 +94,11 @@ func bodyFieldLen(bodies []body) int {
 // requested with the Keys() method. It travels the KeysRequests object like a
 // tree.
 //
+// Update is incremental. The values of all keys with a description are
+// fetched on each call, since a restricted value can change without a change
+// of its key. But if a value is the same as in the last call, the child keys
+// from the last call are used instead of decoding the value again.
+//
 // It is not allowed to call builder.Keys() after Update returned an error.
 func (b *Builder) Update(ctx context.Context, getter flow.Getter) ([]dskey.Key, error) {
 	b.mu.Lock()
@@ -97,11 +119,15 @@ func (b *Builder) Update(ctx context.Context, getter flow.Getter) ([]dskey.Key,
 	}
 
 	var keys []dskey.Key
+	var levels [][]keyDescription
+	expanded := make(map[keyDescription]expansion, len(b.expanded))
+	reused := 0
 
 	// neededX contains keys, where the value has to be fetched from the database.
 	var neededKeys []dskey.Key
 	var neededDescriptions []keyDescription
 	for len(queue) > 0 {
+		levels = append(levels, queue)
 		neededKeys = neededKeys[:0]
 		neededDescriptions = neededDescriptions[:0]
 
@@ -115,10 +141,9 @@ func (b *Builder) Update(ctx context.Context, getter flow.Getter) ([]dskey.Key,
 			neededKeys = append(neededKeys, kd.key)
 			neededDescriptions = append(neededDescriptions, keyDescription{key: kd.key, description: kd.description})
 		}
-		queue = queue[:0]
 
 		if len(neededKeys) == 0 {
-			continue
+			break
 		}
 
 		// Get values for all special (not none) fields.
@@ -127,6 +152,8 @@ func (b *Builder) Update(ctx context.Context, getter flow.Getter) ([]dskey.Key,
 			return nil, fmt.Errorf("load needed keys: %w", err)
 		}
 
+		// The children of this level are the next level.
+		next := make([]keyDescription, 0, len(neededDescriptions))
 		for _, kd := range neededDescriptions {
 			// This are fields that do not exist or the user has not the
 			// permission to see them.
@@ -134,8 +161,19 @@ func (b *Builder) Update(ctx context.Context, getter flow.Getter) ([]dskey.Key,
 				continue
 			}
 
+			hash := xxh3.Hash(data[kd.key])
+			start := len(next)
+			if last, ok := b.expanded[kd]; ok && last.hash == hash {
+				if last.end > last.start {
+					next = append(next, b.levels[last.level][last.start:last.end]...)
+				}
+				expanded[kd] = expansion{hash: hash, level: len(levels), start: start, end: len(next)}
+				reused++
+				continue
+			}
+
 			var err error
-			queue, err = kd.description.appendKeys(kd.key, data[kd.key], queue)
+			next, err = kd.description.appendKeys(kd.key, data[kd.key], next)
 			if err != nil {
 				var invalidErr *json.UnmarshalTypeError
 				if errors.As(err, &invalidErr) {
@@ -144,7 +182,22 @@ func (b *Builder) Update(ctx context.Context, getter flow.Getter) ([]dskey.Key,
 				}
 				return nil, fmt.Errorf("appending keys for key %s: %w", kd.key, err)
 			}
+			expanded[kd] = expansion{hash: hash, level: len(levels), start: start, end: len(next)}
 		}
+		queue = next
 	}
+
+	b.expanded = expanded
+	b.levels = levels
+	b.reused = reused
 	return keys, nil
 }
+
+// Reused returns the number of keys, where the last call to Update used the
+// child keys from the call before.
+func (b *Builder) Reused() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.reused
+}
@@ -430,6 +430,92 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateReused(t *testing.T) {
+	request := `{
+		"ids": [1],
+		"collection": "user",
+		"fields": {
+			"meeting_user_ids": {
+				"type": "relation-list",
+				"collection": "meeting_user",
+				"fields": {
+					"personal_note_ids": {
+						"type": "relation-list",
+						"collection": "personal_note",
+						"fields": {"note": null}
+					}
+				}
+			}
+		}
+	}`
+
+	b, err := keysbuilder.FromJSON(strings.NewReader(request))
+	if err != nil {
+		t.Fatalf("FromJSON() returned an unexpected error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		data   string
+		got    []dskey.Key
+		reused int
+	}{
+		{
+			"first",
+			`---
+			user/1/meeting_user_ids: [1,2]
+			meeting_user/1/personal_note_ids: [1]
+			meeting_user/2/personal_note_ids: [2]
+			`,
+			mustKeys("user/1/meeting_user_ids", "meeting_user/1/personal_note_ids", "meeting_user/2/personal_note_ids", "personal_note/1/note", "personal_note/2/note"),
+			0,
+		},
+		{
+			"no change",
+			`---
+			user/1/meeting_user_ids: [1,2]
+			meeting_user/1/personal_note_ids: [1]
+			meeting_user/2/personal_note_ids: [2]
+			`,
+			mustKeys("user/1/meeting_user_ids", "meeting_user/1/personal_note_ids", "meeting_user/2/personal_note_ids", "personal_note/1/note", "personal_note/2/note"),
+			3,
+		},
+		{
+			"inner change",
+			`---
+			user/1/meeting_user_ids: [1,2]
+			meeting_user/1/personal_note_ids: [1]
+			meeting_user/2/personal_note_ids: [3]
+			`,
+			mustKeys("user/1/meeting_user_ids", "meeting_user/1/personal_note_ids", "meeting_user/2/personal_note_ids", "personal_note/1/note", "personal_note/3/note"),
+			2,
+		},
+		{
+			"root change",
+			`---
+			user/1/meeting_user_ids: [2]
+			meeting_user/1/personal_note_ids: [1]
+			meeting_user/2/personal_note_ids: [3]
+			`,
+			mustKeys("user/1/meeting_user_ids", "meeting_user/2/personal_note_ids", "personal_note/3/note"),
+			1,
+		},
+	} {
+		keys, err := b.Update(context.Background(), dsmock.Stub(dsmock.YAMLData(tt.data)))
+		if err != nil {
+			t.Fatalf("%s: Update() returned an unexpect error: %v", tt.name, err)
+		}
+
+		if diff := cmpSet(set(tt.got...), set(keys...)); diff != nil {
+			t.Errorf("%s: Update() returned %v, expected %v", tt.name, diff, keys)
+		}
+
+		if got := b.Reused(); got != tt.reused {
+			t.Errorf("%s: Reused() returned %d, expected %d", tt.name, got, tt.reused)
+		}
+	}
+}
+
 func TestInvalidRequestsAtParsingTime(t *testing.T) {
 	for _, tt := range []struct {
 		name      string
@@ -0,0 +1,357 @@
+// Package lifecycle sends events about the autoupdate connections to a sink.
+//
+// Operations can use the events to build real-time dashboards of the clients.
+// The events are sent in the background. If the sink is too slow, events are
+// dropped instead of slowing down the connections.
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envSink         = environment.NewVariable("AUTOUPDATE_EVENT_SINK", "", "Where the connection events are sent. `log` writes them to the log, `redis` adds them to the redis stream `AUTOUPDATE_EVENT_STREAM` and an http or https url gets them as json array with POST. Empty disables the events.")
+	envStream       = environment.NewVariable("AUTOUPDATE_EVENT_STREAM", "autoupdate_events", "Name of the redis stream for the connection events.")
+	envSlowConsumer = environment.NewVariable("AUTOUPDATE_SLOW_CONSUMER_THRESHOLD", "5s", "Time, writing one message to a client can take, before a `slow_consumer` event is sent.")
+)
+
+const (
+	// bufferSize is the number of events, that are kept, while the sink is
+	// busy. More events are dropped.
+	bufferSize = 1024
+
+	// batchSize is the maximum number of events sent to the sink at once.
+	batchSize = 100
+
+	// batchWait is the time, events are collected before they are sent.
+	batchWait = time.Second
+
+	// sendTimeout is the time, the sink can take for one batch.
+	sendTimeout = 10 * time.Second
+
+	// streamMaxLen is the approximated maximum length of the redis stream.
+	streamMaxLen = 100_000
+)
+
+// Type is the kind of an event.
+type Type string
+
+// The types of the events.
+const (
+	// Connect is sent, when a client opens an autoupdate connection.
+	Connect Type = "connect"
+
+	// Subscribe is sent, when the first data of a connection was sent.
+	Subscribe Type = "subscribe"
+
+	// Resync is sent, when messages of the message bus were lost and the
+	// cache was refreshed.
+	Resync Type = "resync"
+
+	// SlowConsumer is sent, when writing a message to a client took longer
+	// then AUTOUPDATE_SLOW_CONSUMER_THRESHOLD.
+	SlowConsumer Type = "slow_consumer"
+
+	// Drain is sent, when the instance stops accepting connections.
+	Drain Type = "drain"
+
+	// Disconnect is sent, when a connection is closed.
+	Disconnect Type = "disconnect"
+)
+
+// The reasons of Disconnect and Drain events.
+const (
+	ReasonClientClosed = "client_closed"
+	ReasonShutdown     = "shutdown"
+	ReasonRestart      = "restart"
+	ReasonError        = "error"
+	ReasonHandoff      = "handoff"
+)
+
+// Event is one change of a connection or the instance.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       Type      `json:"type"`
+	Instance   string    `json:"instance"`
+	RequestID  string    `json:"request_id,omitempty"`
+	UserID     int       `json:"user_id"`
+	ClientIP   string    `json:"client_ip,omitempty"`
+	MeetingID  int       `json:"meeting_id,omitempty"`
+	Keys       int       `json:"keys,omitempty"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// StreamWriter adds a message to a redis stream.
+type StreamWriter interface {
+	AddToStream(ctx context.Context, stream string, maxLen int, field string, value []byte) error
+}
+
+// sink sends a batch of events.
+type sink interface {
+	send(ctx context.Context, events []Event) error
+}
+
+var logger = logging.For(logging.Autoupdate)
+
+var state struct {
+	mu           sync.Mutex
+	events       chan Event
+	instance     string
+	slowConsumer time.Duration
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// New configures the events from the environment. The stream is used, if the
+// sink is `redis`.
+//
+// The returned function sends the events. It has to be run in the background.
+func New(lookup environment.Environmenter, stream StreamWriter) (func(context.Context, func(error)), error) {
+	slowConsumer, err := environment.ParseDuration(envSlowConsumer.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envSlowConsumer.Key, envSlowConsumer.Value(lookup), err)
+	}
+
+	target, err := newSink(envSink.Value(lookup), envStream.Value(lookup), stream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envSink.Key, err)
+	}
+
+	if target == nil {
+		configure(nil, 0)
+		return func(context.Context, func(error)) {}, nil
+	}
+
+	events := make(chan Event, bufferSize)
+	configure(events, slowConsumer)
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		run(ctx, events, target, errorHandler)
+	}
+	return background, nil
+}
+
+func newSink(value string, streamName string, stream StreamWriter) (sink, error) {
+	switch {
+	case value == "":
+		return nil, nil
+	case value == "log":
+		return logSink{}, nil
+	case value == "redis":
+		if stream == nil {
+			return nil, fmt.Errorf("redis is not available")
+		}
+		return redisSink{stream: stream, name: streamName}, nil
+	case strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://"):
+		return webhookSink{url: value, client: &http.Client{Timeout: sendTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("expected `log`, `redis` or an url, got `%s`", value)
+	}
+}
+
+func configure(events chan Event, slowConsumer time.Duration) {
+	instance, _ := os.Hostname()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.events = events
+	state.instance = instance
+	state.slowConsumer = slowConsumer
+}
+
+// Enabled tells, if events are sent.
+func Enabled() bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.events != nil
+}
+
+// Emit sends an event to the sink. It does not block. If the buffer is full,
+// the event is dropped.
+//
+// The fields Time and Instance are set by Emit.
+func Emit(e Event) {
+	state.mu.Lock()
+	events := state.events
+	e.Instance = state.instance
+	state.mu.Unlock()
+
+	if events == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	select {
+	case events <- e:
+	default:
+		state.dropped.Add(1)
+	}
+}
+
+// IsSlowConsumer tells, if writing a message with the given duration is slow.
+func IsSlowConsumer(duration time.Duration) bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.events != nil && state.slowConsumer > 0 && duration > state.slowConsumer
+}
+
+// Metric adds the number of sent, dropped and failed events to the metric.
+func Metric(con metric.Container) {
+	con.Add("lifecycle_events_sent", int(state.sent.Load()))
+	con.Add("lifecycle_events_dropped", int(state.dropped.Load()))
+	con.Add("lifecycle_events_failed", int(state.failed.Load()))
+}
+
+// run sends the events in batches until the context is done. Afterwards, the
+// buffered events are sent.
+func run(ctx context.Context, events <-chan Event, target sink, errorHandler func(error)) {
+	batch := make([]Event, 0, batchSize)
+	timer := time.NewTimer(batchWait)
+	timer.Stop()
+
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+		defer cancel()
+
+		if err := target.send(ctx, batch); err != nil {
+			state.failed.Add(uint64(len(batch)))
+			errorHandler(fmt.Errorf("sending %d connection events: %w", len(batch), err))
+		} else {
+			state.sent.Add(uint64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-events:
+					batch = append(batch, e)
+					if len(batch) >= batchSize {
+						flush(context.WithoutCancel(ctx))
+					}
+				default:
+					flush(context.WithoutCancel(ctx))
+					return
+				}
+			}
+
+		case e := <-events:
+			if len(batch) == 0 {
+				timer.Reset(batchWait)
+			}
+
+			batch = append(batch, e)
+			if len(batch) >= batchSize {
+				timer.Stop()
+				flush(ctx)
+			}
+
+		case <-timer.C:
+			flush(ctx)
+		}
+	}
+}
+
+// logSink writes each event to the log.
+type logSink struct{}
+
+func (logSink) send(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		logger.InfoContext(
+			ctx,
+			"Connection event",
+			"event", e.Type,
+			"event_time", e.Time,
+			"instance", e.Instance,
+			"request_id", e.RequestID,
+			"user_id", e.UserID,
+			"client_ip", e.ClientIP,
+			"meeting_id", e.MeetingID,
+			"keys", e.Keys,
+			"duration_ms", e.DurationMS,
+			"reason", e.Reason,
+			"error", e.Error,
+		)
+	}
+	return nil
+}
+
+// redisSink adds each event as json to a redis stream.
+type redisSink struct {
+	stream StreamWriter
+	name   string
+}
+
+func (s redisSink) send(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		bs, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encoding event: %w", err)
+		}
+
+		if err := s.stream.AddToStream(ctx, s.name, streamMaxLen, "event", bs); err != nil {
+			return fmt.Errorf("adding event to stream %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+// webhookSink posts the events as json array to an url.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s webhookSink) send(ctx context.Context, events []Event) error {
+	bs, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("encoding events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(bs))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+type recordSink struct {
+	mu      sync.Mutex
+	batches [][]Event
+}
+
+func (s *recordSink) send(ctx context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = append(s.batches, append([]Event(nil), events...))
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	events := make(chan Event, bufferSize)
+	configure(events, time.Second)
+	t.Cleanup(func() { configure(nil, 0) })
+
+	Emit(Event{Type: Connect, UserID: 1})
+	Emit(Event{Type: Disconnect, UserID: 1, Reason: ReasonClientClosed})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sink := &recordSink{}
+	run(ctx, events, sink, func(err error) { t.Errorf("unexpected error: %v", err) })
+
+	if len(sink.batches) != 1 || len(sink.batches[0]) != 2 {
+		t.Fatalf("got batches %v, expected one batch with two events", sink.batches)
+	}
+
+	got := sink.batches[0]
+	if got[0].Type != Connect || got[1].Type != Disconnect {
+		t.Errorf("got types %s and %s, expected connect and disconnect", got[0].Type, got[1].Type)
+	}
+
+	if got[0].Time.IsZero() {
+		t.Errorf("time of the event is not set")
+	}
+}
+
+func TestEmitDropsWhenFull(t *testing.T) {
+	events := make(chan Event, 1)
+	configure(events, time.Second)
+	t.Cleanup(func() { configure(nil, 0) })
+
+	dropped := state.dropped.Load()
+	Emit(Event{Type: Connect})
+	Emit(Event{Type: Connect})
+
+	if got := state.dropped.Load() - dropped; got != 1 {
+		t.Errorf("dropped %d events, expected 1", got)
+	}
+}
+
+func TestEmitDisabled(t *testing.T) {
+	configure(nil, 0)
+
+	if Enabled() {
+		t.Errorf("Enabled() = true without sink")
+	}
+
+	// Does not block or panic.
+	Emit(Event{Type: Connect})
+}
+
+func TestIsSlowConsumer(t *testing.T) {
+	configure(make(chan Event, 1), time.Second)
+	t.Cleanup(func() { configure(nil, 0) })
+
+	if IsSlowConsumer(500 * time.Millisecond) {
+		t.Errorf("fast write is slow")
+	}
+
+	if !IsSlowConsumer(2 * time.Second) {
+		t.Errorf("slow write is not slow")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	sink, err := newSink(srv.URL, "", nil)
+	if err != nil {
+		t.Fatalf("newSink: %v", err)
+	}
+
+	if err := sink.send(context.Background(), []Event{{Type: Resync}}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Type != Resync {
+		t.Errorf("webhook got %v, expected one resync event", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Cleanup(func() { configure(nil, 0) })
+
+	for _, tt := range []struct {
+		name    string
+		env     map[string]string
+		enabled bool
+		wantErr bool
+	}{
+		{"disabled", nil, false, false},
+		{"log", map[string]string{"AUTOUPDATE_EVENT_SINK": "log"}, true, false},
+		{"webhook", map[string]string{"AUTOUPDATE_EVENT_SINK": "https://example.com/events"}, true, false},
+		{"redis without connection", map[string]string{"AUTOUPDATE_EVENT_SINK": "redis"}, false, true},
+		{"unknown", map[string]string{"AUTOUPDATE_EVENT_SINK": "kafka"}, false, true},
+		{"invalid threshold", map[string]string{"AUTOUPDATE_EVENT_SINK": "log", "AUTOUPDATE_SLOW_CONSUMER_THRESHOLD": "slow"}, false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(nil, 0)
+
+			_, err := New(environment.ForTests(tt.env), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New returned error %v, expected error: %t", err, tt.wantErr)
+			}
+
+			if Enabled() != tt.enabled {
+				t.Errorf("Enabled() = %t, expected %t", Enabled(), tt.enabled)
+			}
+		})
+	}
+}
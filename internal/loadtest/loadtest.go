@@ -0,0 +1,323 @@
+// Package loadtest opens many autoupdate connections to a running service and
+// measures how fast it answers.
+//
+// Two values are measured. The time to first byte is the time from sending
+// the request until the first byte of the response arrives. The propagation
+// latency is the time between the first connection and each other connection
+// receiving the same update. Connections with the same body and query receive
+// the same updates, so an update is recognized by its content.
+package loadtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// DefaultBody is used, if no bodies are given.
+const DefaultBody = `[{"collection":"organization","ids":[1],"fields":{"name":null}}]`
+
+// Config configures a load test.
+type Config struct {
+	// URL is the autoupdate route, for example
+	// http://localhost:9012/system/autoupdate.
+	URL string
+
+	// Connections is the amount of connections, that are open at the same
+	// time.
+	Connections int
+
+	// Duration is the time, the connections are kept open.
+	Duration time.Duration
+
+	// Ramp is the time to open all connections. The connections are started
+	// evenly over this time.
+	Ramp time.Duration
+
+	// Bodies are the request bodies. Connection i uses body i modulo the
+	// amount of bodies.
+	Bodies []string
+
+	// Queries are added to the URL like the bodies. Connection i uses query i
+	// modulo the amount of queries.
+	Queries []string
+
+	// Schedule is the start time of each connection. If it is set, it is used
+	// instead of the ramp.
+	Schedule []time.Duration
+
+	// Header is sent with each request, for example for authentication.
+	Header http.Header
+
+	// Client sends the requests. If nil, a client without timeout is used.
+	Client *http.Client
+}
+
+// Run runs the load test until the duration is over or the context is done.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if cfg.Connections < 1 {
+		return Report{}, fmt.Errorf("connections has to be at least 1, got %d", cfg.Connections)
+	}
+
+	if len(cfg.Bodies) == 0 {
+		cfg.Bodies = []string{DefaultBody}
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{}
+	}
+
+	if cfg.Schedule != nil {
+		if len(cfg.Schedule) != cfg.Connections {
+			return Report{}, fmt.Errorf("schedule has %d entries for %d connections", len(cfg.Schedule), cfg.Connections)
+		}
+		cfg.Ramp = slices.Max(cfg.Schedule)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Ramp+cfg.Duration)
+	defer cancel()
+
+	rec := newRecorder()
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Connections; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			delay := cfg.Ramp * time.Duration(i) / time.Duration(cfg.Connections)
+			if cfg.Schedule != nil {
+				delay = cfg.Schedule[i]
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			if err := connect(ctx, cfg, i, rec); err != nil && ctx.Err() == nil {
+				rec.fail(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return rec.report(cfg.Connections), nil
+}
+
+// connect opens the connection i and reads the messages, until the context is
+// done.
+func connect(ctx context.Context, cfg Config, i int, rec *recorder) error {
+	url := cfg.URL
+	var query string
+	if len(cfg.Queries) > 0 {
+		query = cfg.Queries[i%len(cfg.Queries)]
+	}
+	if query != "" {
+		url += "?" + query
+	}
+
+	body := cfg.Bodies[i%len(cfg.Bodies)]
+	request := query + "\x00" + body
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	for name, values := range cfg.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	start := time.Now()
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("got status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	first := true
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			now := time.Now()
+			if first {
+				rec.firstByte(now.Sub(start))
+				first = false
+			} else {
+				rec.update(request, line, now)
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("server closed the connection")
+			}
+			return fmt.Errorf("reading response: %w", err)
+		}
+	}
+}
+
+// recorder collects the measurements of all connections.
+type recorder struct {
+	mu          sync.Mutex
+	ttfb        []time.Duration
+	propagation []time.Duration
+	messages    int
+	errors      map[string]int
+	seen        map[[sha256.Size]byte]time.Time
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		errors: make(map[string]int),
+		seen:   make(map[[sha256.Size]byte]time.Time),
+	}
+}
+
+func (r *recorder) firstByte(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages++
+	r.ttfb = append(r.ttfb, d)
+}
+
+// update records a message. Connections with the same request receive the
+// same messages.
+func (r *recorder) update(request string, message []byte, now time.Time) {
+	key := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s", request, message))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages++
+	first, ok := r.seen[key]
+	if !ok {
+		r.seen[key] = now
+		return
+	}
+	r.propagation = append(r.propagation, now.Sub(first))
+}
+
+func (r *recorder) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors[err.Error()]++
+}
+
+func (r *recorder) report(connections int) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failed := 0
+	for _, count := range r.errors {
+		failed += count
+	}
+
+	return Report{
+		Connections: connections,
+		Failed:      failed,
+		Errors:      r.errors,
+		Messages:    r.messages,
+		Updates:     len(r.seen),
+		FirstByte:   percentiles(r.ttfb),
+		Propagation: percentiles(r.propagation),
+	}
+}
+
+// Report is the result of a load test.
+type Report struct {
+	Connections int
+	Failed      int
+	Errors      map[string]int
+	Messages    int
+	Updates     int
+	FirstByte   Percentiles
+	Propagation Percentiles
+}
+
+// Percentiles summarizes measured durations.
+type Percentiles struct {
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+func percentiles(values []time.Duration) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	at := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+
+	return Percentiles{
+		Count: len(sorted),
+		P50:   at(50),
+		P90:   at(90),
+		P99:   at(99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// Write writes the report as a table.
+func (r Report) Write(w io.Writer) error {
+	fmt.Fprintf(w, "connections: %d (%d failed)\n", r.Connections, r.Failed)
+	fmt.Fprintf(w, "messages:    %d (%d different updates)\n\n", r.Messages, r.Updates)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\tcount\tp50\tp90\tp99\tmax\n")
+	for _, row := range []struct {
+		name   string
+		values Percentiles
+	}{
+		{"first byte", r.FirstByte},
+		{"propagation", r.Propagation},
+	} {
+		v := row.values
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", row.name, v.Count, round(v.P50), round(v.P90), round(v.P99), round(v.Max))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "errors:")
+		for _, msg := range slices.Sorted(maps.Keys(r.Errors)) {
+			fmt.Fprintf(w, "  %dx %s\n", r.Errors[msg], msg)
+		}
+	}
+	return nil
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
@@ -0,0 +1,133 @@
+package loadtest_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/loadtest"
+)
+
+// updateServer sends the initial data and then an update every 10ms to all
+// connections.
+type updateServer struct {
+	mu        sync.Mutex
+	listeners []chan int
+}
+
+func (s *updateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get("Authentication") != "bearer token" {
+		http.Error(w, "missing token", http.StatusUnauthorized)
+		return
+	}
+
+	updates := make(chan int, 100)
+	s.mu.Lock()
+	s.listeners = append(s.listeners, updates)
+	s.mu.Unlock()
+
+	fmt.Fprintf(w, "{\"initial\":%q}\n", body)
+	w.(http.Flusher).Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case n := <-updates:
+			fmt.Fprintf(w, "{\"update\":%d}\n", n)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func (s *updateServer) publish(ctx context.Context) {
+	for n := 0; ; n++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		s.mu.Lock()
+		for _, l := range s.listeners {
+			select {
+			case l <- n:
+			default:
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := new(updateServer)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.publish(ctx)
+
+	report, err := loadtest.Run(ctx, loadtest.Config{
+		URL:         ts.URL,
+		Connections: 4,
+		Duration:    200 * time.Millisecond,
+		Header:      http.Header{"Authentication": {"bearer token"}},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if report.Failed != 0 {
+		t.Errorf("%d connections failed: %v", report.Failed, report.Errors)
+	}
+
+	if report.FirstByte.Count != 4 {
+		t.Errorf("got %d first byte measurements, expected 4", report.FirstByte.Count)
+	}
+
+	if report.Updates == 0 || report.Propagation.Count == 0 {
+		t.Errorf("got %d updates and %d propagation measurements, expected some", report.Updates, report.Propagation.Count)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := report.Write(buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "propagation") {
+		t.Errorf("report does not contain the propagation:\n%s", buf.String())
+	}
+}
+
+func TestRunFailed(t *testing.T) {
+	ts := httptest.NewServer(new(updateServer))
+	defer ts.Close()
+
+	report, err := loadtest.Run(context.Background(), loadtest.Config{
+		URL:         ts.URL,
+		Connections: 2,
+		Duration:    100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if report.Failed != 2 {
+		t.Errorf("got %d failed connections, expected 2", report.Failed)
+	}
+
+	for msg := range report.Errors {
+		if !strings.Contains(msg, "401") {
+			t.Errorf("got error %s, expected status 401", msg)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Package logging configures the structured logging of the service.
+//
+// Each subsystem has its own logger with its own level. The levels can be
+// changed at runtime with SetLevel.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envLogLevel  = environment.NewVariable("LOG_LEVEL", "info", "Log level of all subsystems. One of `debug`, `info`, `warn` or `error`.")
+	envLogFormat = environment.NewVariable("LOG_FORMAT", "text", "Format of the log output. `text` or `json`.")
+	envLogLevels = environment.NewVariable("LOG_LEVELS", "", "Comma separated list of log levels for single subsystems, for example `auth=debug,datastore=warn`.")
+
+	envPodName      = environment.NewVariable("POD_NAME", "", "Name of the Kubernetes pod, for example from the downward API. If set, it is added to each log entry and metric.")
+	envPodNamespace = environment.NewVariable("POD_NAMESPACE", "", "Namespace of the Kubernetes pod. If set, it is added to each log entry and metric.")
+	envNodeName     = environment.NewVariable("NODE_NAME", "", "Name of the Kubernetes node. If set, it is added to each log entry and metric.")
+)
+
+// Names of the subsystems.
+const (
+	Auth       = "auth"
+	Autoupdate = "autoupdate"
+	Datastore  = "datastore"
+	HTTP       = "http"
+	Metric     = "metric"
+	Projector  = "projector"
+	Restrict   = "restrict"
+)
+
+// Subsystems are all known subsystems.
+var Subsystems = []string{Auth, Autoupdate, Datastore, HTTP, Metric, Projector, Restrict}
+
+// base is the handler, that writes the log output. It is replaced by New.
+var base atomic.Pointer[slog.Handler]
+
+// levels holds the level of each subsystem. The empty name is the level of
+// the default logger.
+var levels = struct {
+	mu     sync.Mutex
+	levels map[string]*slog.LevelVar
+}{levels: make(map[string]*slog.LevelVar)}
+
+func init() {
+	setBase(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	slog.SetDefault(slog.New(&handler{level: levelVar("")}))
+}
+
+func setBase(h slog.Handler) {
+	base.Store(&h)
+}
+
+// levelVar returns the level of a subsystem. It is created with the level of
+// the default logger, if it does not exist.
+func levelVar(subsystem string) *slog.LevelVar {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	if lv, ok := levels.levels[subsystem]; ok {
+		return lv
+	}
+
+	lv := new(slog.LevelVar)
+	if dflt, ok := levels.levels[""]; ok {
+		lv.Set(dflt.Level())
+	}
+	levels.levels[subsystem] = lv
+	return lv
+}
+
+// New configures the log output and the levels from the environment.
+//
+// The output is written to w.
+func New(lookup environment.Environmenter, w io.Writer) error {
+	setLevels, err := ReloadLevels(lookup)
+	if err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var h slog.Handler
+	switch format := envLogFormat.Value(lookup); format {
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid value for `%s`: expected text or json, got `%s`", envLogFormat.Key, format)
+	}
+	setBase(h.WithAttrs(instanceAttrs(lookup)))
+
+	setLevels()
+	return nil
+}
+
+// instanceAttrs returns the pod metadata, that is added to each log entry.
+// Metrics are log entries, so they get them too.
+func instanceAttrs(lookup environment.Environmenter) []slog.Attr {
+	var attrs []slog.Attr
+	for _, v := range []struct {
+		name     string
+		variable environment.Variable
+	}{
+		{"pod", envPodName},
+		{"namespace", envPodNamespace},
+		{"node", envNodeName},
+	} {
+		if value := v.variable.Value(lookup); value != "" {
+			attrs = append(attrs, slog.String(v.name, value))
+		}
+	}
+	return attrs
+}
+
+// ReloadLevels parses the log levels from the environment. The returned
+// function sets them. Changes with SetLevel are overwritten.
+func ReloadLevels(lookup environment.Environmenter) (func(), error) {
+	level, err := ParseLevel(envLogLevel.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envLogLevel.Key, err)
+	}
+
+	subsystemLevels := make(map[string]slog.Level)
+	if raw := envLogLevels.Value(lookup); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			name, value, found := strings.Cut(strings.TrimSpace(part), "=")
+			if !found {
+				return nil, fmt.Errorf("invalid value for `%s`: expected subsystem=level, got `%s`", envLogLevels.Key, part)
+			}
+
+			if !known(name) {
+				return nil, fmt.Errorf("invalid value for `%s`: unknown subsystem `%s`", envLogLevels.Key, name)
+			}
+
+			subsystemLevel, err := ParseLevel(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for `%s`: %w", envLogLevels.Key, err)
+			}
+			subsystemLevels[name] = subsystemLevel
+		}
+	}
+
+	apply := func() {
+		levelVar("").Set(level)
+		for _, subsystem := range Subsystems {
+			levelVar(subsystem).Set(level)
+		}
+
+		for name, subsystemLevel := range subsystemLevels {
+			levelVar(name).Set(subsystemLevel)
+		}
+	}
+	return apply, nil
+}
+
+// ParseLevel parses a level like `debug` or `WARN`.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level `%s`", s)
+	}
+	return level, nil
+}
+
+// For returns the logger of a subsystem.
+//
+// The logger adds the attribute `subsystem` to each record.
+func For(subsystem string) *slog.Logger {
+	return slog.New(&handler{
+		level:     levelVar(subsystem),
+		subsystem: subsystem,
+		ops: []func(slog.Handler) slog.Handler{
+			func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("subsystem", subsystem)})
+			},
+		},
+	})
+}
+
+// SetLevel changes the level of a subsystem at runtime.
+func SetLevel(subsystem string, level slog.Level) error {
+	if !known(subsystem) {
+		return fmt.Errorf("unknown subsystem `%s`", subsystem)
+	}
+
+	levelVar(subsystem).Set(level)
+	return nil
+}
+
+// Levels returns the current level of each subsystem.
+func Levels() map[string]string {
+	out := make(map[string]string, len(Subsystems))
+	for _, subsystem := range Subsystems {
+		out[subsystem] = strings.ToLower(levelVar(subsystem).Level().String())
+	}
+	return out
+}
+
+type contextKey struct{}
+
+// ContextWith returns a context, that adds the attributes to each record,
+// that is logged with the context. The arguments are key value pairs like in
+// slog.Logger.Info.
+//
+// The attributes are only used by the methods of slog.Logger, that take a
+// context, like InfoContext.
+func ContextWith(ctx context.Context, args ...any) context.Context {
+	record := slog.NewRecord(time.Time{}, 0, "", 0)
+	record.Add(args...)
+
+	attrs := slices.Clone(attrsFromContext(ctx))
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return attrs
+}
+
+func known(subsystem string) bool {
+	return slices.Contains(Subsystems, subsystem)
+}
+
+// handler is a slog.Handler with its own level. It writes to the current base
+// handler, so loggers can be created before New is called.
+type handler struct {
+	level     *slog.LevelVar
+	subsystem string
+	ops       []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := attrsFromContext(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+
+	if r.Level >= slog.LevelError {
+		recent.add(h.subsystem, r)
+	}
+
+	out := *base.Load()
+	for _, op := range h.ops {
+		out = op(out)
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) *handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &handler{level: h.level, subsystem: h.subsystem, ops: append(ops, op)}
+}
@@ -0,0 +1,170 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestLevels(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+
+	buf := new(bytes.Buffer)
+	err := logging.New(environment.ForTests{"LOG_LEVEL": "warn", "LOG_LEVELS": "auth=debug"}, buf)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logging.For(logging.Auth).Debug("auth debug")
+	logging.For(logging.Datastore).Info("datastore info")
+	logging.For(logging.Datastore).Warn("datastore warn")
+
+	got := buf.String()
+	for _, expect := range []string{`msg="auth debug" subsystem=auth`, `msg="datastore warn" subsystem=datastore`} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("log `%s` does not contain `%s`", got, expect)
+		}
+	}
+
+	if strings.Contains(got, "datastore info") {
+		t.Errorf("log `%s` contains a message below the level", got)
+	}
+
+	if err := logging.SetLevel(logging.Datastore, slog.LevelInfo); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+
+	buf.Reset()
+	logging.For(logging.Datastore).Info("datastore info")
+	if !strings.Contains(buf.String(), "datastore info") {
+		t.Errorf("message was not logged after changing the level")
+	}
+
+	levels := logging.Levels()
+	if levels[logging.Auth] != "debug" || levels[logging.Datastore] != "info" || levels[logging.HTTP] != "warn" {
+		t.Errorf("got levels %v", levels)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+
+	buf := new(bytes.Buffer)
+	if err := logging.New(environment.ForTests{"LOG_FORMAT": "json"}, buf); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logging.For(logging.HTTP).Info("hello", "user", 5)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decoding log line `%s`: %v", buf.String(), err)
+	}
+
+	if got["msg"] != "hello" || got["subsystem"] != "http" || got["user"] != float64(5) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestPodMetadata(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+
+	buf := new(bytes.Buffer)
+	env := environment.ForTests{"LOG_FORMAT": "json", "POD_NAME": "autoupdate-7f9c", "POD_NAMESPACE": "openslides"}
+	if err := logging.New(env, buf); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logging.For(logging.Metric).Info("Metric")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decoding log line `%s`: %v", buf.String(), err)
+	}
+
+	if got["pod"] != "autoupdate-7f9c" || got["namespace"] != "openslides" {
+		t.Errorf("got %v", got)
+	}
+
+	if _, ok := got["node"]; ok {
+		t.Errorf("empty node name is logged: %v", got)
+	}
+}
+
+func TestInvalidEnvironment(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+
+	for _, env := range []environment.ForTests{
+		{"LOG_LEVEL": "loud"},
+		{"LOG_FORMAT": "xml"},
+		{"LOG_LEVELS": "auth"},
+		{"LOG_LEVELS": "unknown=debug"},
+	} {
+		if err := logging.New(env, new(bytes.Buffer)); err == nil {
+			t.Errorf("New(%v) did not return an error", env)
+		}
+	}
+}
+
+func TestRecentErrors(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+
+	if err := logging.New(environment.ForTests{}, new(bytes.Buffer)); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logging.For(logging.Projector).Warn("only a warning")
+	logging.For(logging.Projector).Error("first error")
+	logging.For(logging.Datastore).Error("second error", "key", "user/1/name")
+
+	got := logging.RecentErrors()
+	if len(got) < 2 {
+		t.Fatalf("got %d errors, expected at least 2", len(got))
+	}
+
+	if got[0].Subsystem != logging.Datastore || got[0].Message != "second error key=user/1/name" {
+		t.Errorf("newest error is %v", got[0])
+	}
+
+	if got[1].Subsystem != logging.Projector || got[1].Message != "first error" {
+		t.Errorf("second newest error is %v", got[1])
+	}
+
+	for _, record := range got {
+		if record.Message == "only a warning" {
+			t.Errorf("warning is in the recent errors")
+		}
+	}
+}
+
+func TestContextWith(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+
+	buf := new(bytes.Buffer)
+	if err := logging.New(environment.ForTests{}, buf); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx := logging.ContextWith(context.Background(), "request_id", "my-id")
+	ctx = logging.ContextWith(ctx, "user_id", 5)
+
+	logger := logging.For(logging.HTTP)
+	logger.InfoContext(ctx, "with context")
+	logger.Info("without context")
+
+	got := buf.String()
+	if !strings.Contains(got, `msg="with context" subsystem=http request_id=my-id user_id=5`) {
+		t.Errorf("log `%s` does not contain the attributes of the context", got)
+	}
+
+	if strings.Contains(got, `msg="without context" subsystem=http request_id`) {
+		t.Errorf("log `%s` contains attributes without the context", got)
+	}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentSize is the number of errors, that are kept by RecentErrors.
+const recentSize = 50
+
+// Record is a logged error.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Subsystem string    `json:"subsystem,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// recent holds the last errors in a ring buffer.
+var recent recentErrors
+
+type recentErrors struct {
+	mu      sync.Mutex
+	records [recentSize]Record
+	next    int
+	count   int
+}
+
+func (e *recentErrors) add(subsystem string, r slog.Record) {
+	msg := r.Message
+	if r.NumAttrs() > 0 {
+		var attrs []string
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, fmt.Sprintf("%s=%v", a.Key, a.Value))
+			return true
+		})
+		msg = msg + " " + strings.Join(attrs, " ")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.records[e.next] = Record{Time: r.Time, Subsystem: subsystem, Message: msg}
+	e.next = (e.next + 1) % recentSize
+	if e.count < recentSize {
+		e.count++
+	}
+}
+
+// RecentErrors returns the last logged errors of all subsystems. The newest
+// error is the first.
+func RecentErrors() []Record {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+
+	out := make([]Record, 0, recent.count)
+	for i := 1; i <= recent.count; i++ {
+		out = append(out, recent.records[(recent.next-i+recentSize)%recentSize])
+	}
+	return out
+}
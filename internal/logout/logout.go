@@ -0,0 +1,292 @@
+// Package logout closes the connections of a session or of a user on all
+// instances of the service.
+//
+// It is used, when an account is compromised. The forced logout is sent to all
+// instances with the message bus. Each instance closes the connections and
+// reports the number of closed connections back to the message bus.
+//
+// A termination closes the connections in the same way, but the session is
+// not revoked. The streams get the reason, before they are closed. It is used
+// for moderation and troubleshooting.
+package logout
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+)
+
+// reportTimeout is the time, an instance has to report the closed
+// connections.
+const reportTimeout = 5 * time.Second
+
+// maxMessageLength is the maximum length of the message of a termination.
+const maxMessageLength = 500
+
+// validReason is the format of the reason code of a termination.
+var validReason = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// Backend sends the forced logouts to all instances and collects their
+// reports.
+type Backend interface {
+	SendForcedLogout(ctx context.Context, message []byte) error
+	ReceiveForcedLogout(ctx context.Context, id string) (string, [][]byte, error)
+	ReportForcedLogout(ctx context.Context, requestID string, closed int) error
+	ForcedLogoutReports(ctx context.Context, requestID string) (map[string]int, error)
+}
+
+// Closer closes the connections of a session or of all sessions of a user.
+//
+// CloseConnections also revokes the session. TerminateConnections cancels the
+// connections with the cause.
+type Closer interface {
+	CloseConnections(sessionID string, userID int) int
+	TerminateConnections(sessionID string, userID int, cause error) int
+}
+
+// Closers closes the connections with each of its closers. With tenant
+// isolation, each organization has its own closer.
+type Closers []Closer
+
+// CloseConnections calls CloseConnections of each closer and returns the sum.
+func (cs Closers) CloseConnections(sessionID string, userID int) int {
+	closed := 0
+	for _, c := range cs {
+		closed += c.CloseConnections(sessionID, userID)
+	}
+	return closed
+}
+
+// TerminateConnections calls TerminateConnections of each closer and returns
+// the sum.
+func (cs Closers) TerminateConnections(sessionID string, userID int, cause error) int {
+	closed := 0
+	for _, c := range cs {
+		closed += c.TerminateConnections(sessionID, userID, cause)
+	}
+	return closed
+}
+
+// Request is a forced logout or a termination. Either the session id or the
+// user id is set.
+type Request struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id,omitempty"`
+	UserID    int    `json:"user_id,omitempty"`
+
+	// Termination is set for a termination without logout.
+	Termination *Termination `json:"termination,omitempty"`
+}
+
+// Termination is the reason, why the connections were closed. It is the cause
+// of the canceled connections.
+type Termination struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
+}
+
+func (t Termination) Error() string {
+	if t.Message == "" {
+		return "connection terminated: " + t.Reason
+	}
+	return fmt.Sprintf("connection terminated: %s: %s", t.Reason, t.Message)
+}
+
+// Result tells, how many instances handled a forced logout and how many
+// connections they closed.
+type Result struct {
+	Instances int `json:"instances"`
+	Closed    int `json:"closed"`
+}
+
+// Logout sends forced logouts and handles the forced logouts of all
+// instances.
+//
+// Has to be created with New.
+type Logout struct {
+	backend Backend
+	closer  Closer
+}
+
+var logger = logging.For(logging.Auth)
+
+// closedConnections is the number of connections, that were closed by forced
+// logouts on this instance.
+var closedConnections atomic.Int64
+
+// terminatedConnections is the number of connections, that were closed by
+// terminations on this instance.
+var terminatedConnections atomic.Int64
+
+// New creates a Logout.
+//
+// The returned function reads the forced logouts from the backend. It has to
+// be run in the background.
+func New(backend Backend, closer Closer) (*Logout, func(context.Context, func(error))) {
+	l := &Logout{
+		backend: backend,
+		closer:  closer,
+	}
+	return l, l.listen
+}
+
+// Logout closes the connections of a session or of all sessions of a user on
+// all instances. Exactly one of sessionID and userID has to be set.
+//
+// It waits for the time wait and returns the reports of the instances, that
+// handled the logout until then.
+func (l *Logout) Logout(ctx context.Context, sessionID string, userID int, wait time.Duration) (Result, error) {
+	return l.send(ctx, Request{SessionID: sessionID, UserID: userID}, wait)
+}
+
+// Terminate closes the connections of a session or of all sessions of a user
+// on all instances without a logout. The streams get the reason code and the
+// optional message.
+//
+// The reason has to be a short code like `moderation` with lower case letters,
+// digits, `_` and `-`.
+func (l *Logout) Terminate(ctx context.Context, sessionID string, userID int, reason string, message string, wait time.Duration) (Result, error) {
+	if !validReason.MatchString(reason) {
+		return Result{}, invalidInputError{fmt.Sprintf("invalid reason `%s`, expected up to 64 lower case letters, digits, _ or -", reason)}
+	}
+
+	if len(message) > maxMessageLength {
+		return Result{}, invalidInputError{fmt.Sprintf("message is longer then %d bytes", maxMessageLength)}
+	}
+
+	termination := Termination{Reason: reason, Message: message}
+	return l.send(ctx, Request{SessionID: sessionID, UserID: userID, Termination: &termination}, wait)
+}
+
+// send sends the request to all instances and waits for the reports.
+func (l *Logout) send(ctx context.Context, request Request, wait time.Duration) (Result, error) {
+	if (request.SessionID == "") == (request.UserID == 0) {
+		return Result{}, invalidInputError{"either session_id or user_id has to be set"}
+	}
+
+	if request.UserID < 0 {
+		return Result{}, invalidInputError{fmt.Sprintf("invalid user_id %d", request.UserID)}
+	}
+
+	id, err := newRequestID()
+	if err != nil {
+		return Result{}, fmt.Errorf("creating request id: %w", err)
+	}
+	request.ID = id
+
+	message, err := json.Marshal(request)
+	if err != nil {
+		return Result{}, fmt.Errorf("encoding forced logout: %w", err)
+	}
+
+	if err := l.backend.SendForcedLogout(ctx, message); err != nil {
+		return Result{}, fmt.Errorf("sending forced logout: %w", err)
+	}
+
+	if err := clock.Sleep(ctx, wait); err != nil {
+		return Result{}, err
+	}
+
+	reports, err := l.backend.ForcedLogoutReports(ctx, id)
+	if err != nil {
+		return Result{}, fmt.Errorf("reading reports: %w", err)
+	}
+
+	result := Result{Instances: len(reports)}
+	for _, closed := range reports {
+		result.Closed += closed
+	}
+	return result, nil
+}
+
+// listen reads the forced logouts and closes the connections.
+func (l *Logout) listen(ctx context.Context, errorHandler func(error)) {
+	var id string
+	for {
+		newID, messages, err := l.backend.ReceiveForcedLogout(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			errorHandler(fmt.Errorf("receiving forced logouts: %w", err))
+			if err := clock.Sleep(ctx, time.Second); err != nil {
+				return
+			}
+			continue
+		}
+		id = newID
+
+		for _, raw := range messages {
+			var request Request
+			if err := json.Unmarshal(raw, &request); err != nil {
+				errorHandler(fmt.Errorf("decoding forced logout: %w", err))
+				continue
+			}
+
+			if err := l.handle(ctx, request); err != nil {
+				errorHandler(fmt.Errorf("forced logout %s: %w", request.ID, err))
+			}
+		}
+	}
+}
+
+// handle closes the connections of one forced logout or termination and
+// reports the number.
+func (l *Logout) handle(ctx context.Context, request Request) error {
+	var closed int
+	if request.Termination != nil {
+		closed = l.closer.TerminateConnections(request.SessionID, request.UserID, *request.Termination)
+		terminatedConnections.Add(int64(closed))
+		logger.Info("Terminated connections", "request", request.ID, "user_id", request.UserID, "reason", request.Termination.Reason, "closed", closed)
+	} else {
+		closed = l.closer.CloseConnections(request.SessionID, request.UserID)
+		closedConnections.Add(int64(closed))
+		logger.Info("Forced logout", "request", request.ID, "user_id", request.UserID, "closed", closed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+
+	if err := l.backend.ReportForcedLogout(ctx, request.ID, closed); err != nil {
+		return fmt.Errorf("reporting closed connections: %w", err)
+	}
+	return nil
+}
+
+// Metric adds the number of connections, that were closed by forced logouts
+// and by terminations.
+func Metric(con metric.Container) {
+	con.Add("forced_logout_closed", int(closedConnections.Load()))
+	con.Add("terminated_connections", int(terminatedConnections.Load()))
+}
+
+// newRequestID returns a random id for a forced logout.
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type invalidInputError struct {
+	msg string
+}
+
+func (e invalidInputError) Error() string {
+	return e.msg
+}
+
+func (e invalidInputError) Type() string {
+	return "invalid_input"
+}
@@ -0,0 +1,281 @@
+package logout_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logout"
+)
+
+// memoryBackend is a message bus for one or more instances in one process.
+type memoryBackend struct {
+	mu       sync.Mutex
+	messages [][]byte
+	signal   chan struct{}
+	reports  map[string]map[string]int
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{
+		signal:  make(chan struct{}),
+		reports: make(map[string]map[string]int),
+	}
+}
+
+func (b *memoryBackend) SendForcedLogout(ctx context.Context, message []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, message)
+	close(b.signal)
+	b.signal = make(chan struct{})
+	return nil
+}
+
+func (b *memoryBackend) ReceiveForcedLogout(ctx context.Context, id string) (string, [][]byte, error) {
+	pos, _ := strconv.Atoi(id)
+	for {
+		b.mu.Lock()
+		messages := b.messages[pos:]
+		signal := b.signal
+		b.mu.Unlock()
+
+		if len(messages) > 0 {
+			return strconv.Itoa(pos + len(messages)), messages, nil
+		}
+
+		select {
+		case <-signal:
+		case <-ctx.Done():
+			return id, nil, ctx.Err()
+		}
+	}
+}
+
+// instance returns the backend of one instance.
+func (b *memoryBackend) instance(name string) instanceBackend {
+	return instanceBackend{memoryBackend: b, name: name}
+}
+
+type instanceBackend struct {
+	*memoryBackend
+	name string
+}
+
+func (b instanceBackend) ReportForcedLogout(ctx context.Context, requestID string, closed int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.reports[requestID] == nil {
+		b.reports[requestID] = make(map[string]int)
+	}
+	b.reports[requestID][b.name] = closed
+	return nil
+}
+
+func (b instanceBackend) ForcedLogoutReports(ctx context.Context, requestID string) (map[string]int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.reports[requestID], nil
+}
+
+// closerStub has one connection for each session of user 5.
+type closerStub struct {
+	mu       sync.Mutex
+	sessions map[string]int
+	revoked  []string
+	causes   []error
+}
+
+func (c *closerStub) CloseConnections(sessionID string, userID int) int {
+	closed := c.TerminateConnections(sessionID, userID, nil)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if sessionID != "" {
+		c.revoked = append(c.revoked, sessionID)
+	}
+	return closed
+}
+
+func (c *closerStub) TerminateConnections(sessionID string, userID int, cause error) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	closed := 0
+	for sid, uid := range c.sessions {
+		if sid == sessionID || uid == userID {
+			delete(c.sessions, sid)
+			c.causes = append(c.causes, cause)
+			closed++
+		}
+	}
+	return closed
+}
+
+func TestLogout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	backend := newMemoryBackend()
+
+	first := &closerStub{sessions: map[string]int{"a": 5, "b": 5, "c": 6}}
+	second := &closerStub{sessions: map[string]int{"d": 5}}
+
+	l, bg := logout.New(backend.instance("first"), first)
+	go bg(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	_, bg2 := logout.New(backend.instance("second"), second)
+	go bg2(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	result, err := l.Logout(ctx, "a", 0, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Logout session: %v", err)
+	}
+
+	if result != (logout.Result{Instances: 2, Closed: 1}) {
+		t.Errorf("got result %+v for the session, expected 2 instances and 1 closed connection", result)
+	}
+
+	result, err = l.Logout(ctx, "", 5, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Logout user: %v", err)
+	}
+
+	if result != (logout.Result{Instances: 2, Closed: 2}) {
+		t.Errorf("got result %+v for the user, expected 2 instances and 2 closed connections", result)
+	}
+
+	if _, ok := first.sessions["c"]; !ok {
+		t.Errorf("session of another user was closed")
+	}
+}
+
+func TestLogoutTenants(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tenantA := &closerStub{sessions: map[string]int{"a": 5}}
+	tenantB := &closerStub{sessions: map[string]int{"b": 5, "c": 6}}
+
+	l, bg := logout.New(newMemoryBackend().instance("first"), logout.Closers{tenantA, tenantB})
+	go bg(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	result, err := l.Logout(ctx, "", 5, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	if result != (logout.Result{Instances: 1, Closed: 2}) {
+		t.Errorf("got result %+v, expected 1 instance and 2 closed connections", result)
+	}
+
+	if len(tenantA.sessions) != 0 || len(tenantB.sessions) != 1 {
+		t.Errorf("got open sessions %v and %v, expected only c", tenantA.sessions, tenantB.sessions)
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	closer := &closerStub{sessions: map[string]int{"a": 5, "b": 6}}
+
+	l, bg := logout.New(newMemoryBackend().instance("first"), closer)
+	go bg(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	result, err := l.Terminate(ctx, "a", 0, "moderation", "Please reload", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Terminate: %v", err)
+	}
+
+	if result != (logout.Result{Instances: 1, Closed: 1}) {
+		t.Errorf("got result %+v, expected 1 instance and 1 closed connection", result)
+	}
+
+	closer.mu.Lock()
+	defer closer.mu.Unlock()
+
+	if len(closer.revoked) != 0 {
+		t.Errorf("sessions %v were revoked", closer.revoked)
+	}
+
+	expect := logout.Termination{Reason: "moderation", Message: "Please reload"}
+	if len(closer.causes) != 1 || closer.causes[0] != expect {
+		t.Errorf("got causes %v, expected %v", closer.causes, expect)
+	}
+}
+
+func TestTerminateTenants(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tenantA := &closerStub{sessions: map[string]int{"a": 5}}
+	tenantB := &closerStub{sessions: map[string]int{"b": 5}}
+
+	l, bg := logout.New(newMemoryBackend().instance("first"), logout.Closers{tenantA, tenantB})
+	go bg(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	result, err := l.Terminate(ctx, "", 5, "moderation", "", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Terminate: %v", err)
+	}
+
+	if result != (logout.Result{Instances: 1, Closed: 2}) {
+		t.Errorf("got result %+v, expected 1 instance and 2 closed connections", result)
+	}
+
+	for name, closer := range map[string]*closerStub{"A": tenantA, "B": tenantB} {
+		closer.mu.Lock()
+		if len(closer.causes) != 1 || len(closer.revoked) != 0 {
+			t.Errorf("tenant %s: got causes %v and revoked sessions %v, expected one cause and no revoked session", name, closer.causes, closer.revoked)
+		}
+		closer.mu.Unlock()
+	}
+}
+
+func TestTerminateInvalid(t *testing.T) {
+	l, _ := logout.New(newMemoryBackend().instance("first"), &closerStub{})
+
+	for _, tt := range []struct {
+		name    string
+		reason  string
+		message string
+	}{
+		{"no reason", "", ""},
+		{"upper case", "Moderation", ""},
+		{"long message", "moderation", strings.Repeat("x", 501)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := l.Terminate(context.Background(), "a", 0, tt.reason, tt.message, 0); err == nil {
+				t.Errorf("Terminate returned no error")
+			}
+		})
+	}
+}
+
+func TestLogoutInvalid(t *testing.T) {
+	l, _ := logout.New(newMemoryBackend().instance("first"), &closerStub{})
+
+	for _, tt := range []struct {
+		name      string
+		sessionID string
+		userID    int
+	}{
+		{"nothing", "", 0},
+		{"both", "a", 5},
+		{"negative user", "", -1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := l.Logout(context.Background(), tt.sessionID, tt.userID, 0); err == nil {
+				t.Errorf("Logout returned no error")
+			}
+		})
+	}
+}
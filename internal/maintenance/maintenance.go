@@ -0,0 +1,190 @@
+// Package maintenance switches the service into a read-only maintenance mode
+// at runtime.
+//
+// During maintenance, the data is only read from the cache. Keys, that are not
+// in the cache, return an Error. The updates of the message bus are held back
+// until the maintenance ends, so the clients do not get updates. The
+// streaming connections get a notice, when the maintenance starts and ends.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+)
+
+// defaultMessage is the message, if Start is called without one.
+const defaultMessage = "The service is in maintenance."
+
+var state struct {
+	mu      sync.Mutex
+	status  Status
+	changed chan struct{}
+
+	count    int
+	rejected int
+}
+
+// Status is the state of the maintenance.
+type Status struct {
+	Active  bool   `json:"active"`
+	Message string `json:"message,omitempty"`
+
+	// Since is the unix time, when the maintenance started.
+	Since int64 `json:"since,omitempty"`
+}
+
+// Start starts the maintenance. If it is already active, only the message is
+// changed.
+func Start(message string) {
+	if message == "" {
+		message = defaultMessage
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.status.Active {
+		state.status.Since = time.Now().Unix()
+		state.count++
+	}
+	state.status.Active = true
+	state.status.Message = message
+	notify()
+}
+
+// End ends the maintenance.
+func End() {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.status.Active {
+		return
+	}
+
+	state.status = Status{}
+	notify()
+}
+
+// notify wakes the callers of Current. Has to be called with the lock.
+func notify() {
+	if state.changed != nil {
+		close(state.changed)
+		state.changed = nil
+	}
+}
+
+// Current returns the state and a channel, that is closed, when the state
+// changes.
+func Current() (Status, <-chan struct{}) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.changed == nil {
+		state.changed = make(chan struct{})
+	}
+	return state.status, state.changed
+}
+
+// Active tells, if the service is in maintenance.
+func Active() bool {
+	status, _ := Current()
+	return status.Active
+}
+
+// wait blocks until the maintenance ends or the context is done.
+func wait(ctx context.Context) error {
+	for {
+		status, changed := Current()
+		if !status.Active {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Check returns an Error, if the service is in maintenance. It is used before
+// reading from the database.
+func Check() error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.status.Active {
+		return nil
+	}
+
+	state.rejected++
+	return Error{message: fmt.Sprintf("%s The data is not available until it ends.", state.status.Message)}
+}
+
+// Error is returned for reads from the database during maintenance.
+type Error struct {
+	message string
+}
+
+func (e Error) Error() string {
+	return e.message
+}
+
+// Type is the type of the error for the client.
+func (e Error) Type() string {
+	return "maintenance"
+}
+
+// StatusCode is the http status of the error.
+func (e Error) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+// Flow is used below the cache. It returns an Error for each request during
+// maintenance and holds back the updates until it ends.
+func Flow(f flow.Flow) flow.Flow {
+	return maintenanceFlow{flow: f}
+}
+
+type maintenanceFlow struct {
+	flow flow.Flow
+}
+
+func (f maintenanceFlow) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	if err := Check(); err != nil {
+		return nil, err
+	}
+	return f.flow.Get(ctx, keys...)
+}
+
+// Update gives the updates to updateFn after the maintenance ended.
+func (f maintenanceFlow) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
+	f.flow.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		if wait(ctx) != nil {
+			return
+		}
+		updateFn(data, err)
+	})
+}
+
+// Metric adds the state of the maintenance to the metric.
+func Metric(con metric.Container) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	active := 0
+	if state.status.Active {
+		active = 1
+	}
+
+	con.Add("maintenance_active", active)
+	con.Add("maintenance_count", state.count)
+	con.Add("maintenance_rejected_reads", state.rejected)
+}
@@ -0,0 +1,70 @@
+package maintenance_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/maintenance"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+)
+
+func TestFlow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer maintenance.End()
+
+	ds := dsmock.NewFlow(dsmock.YAMLData("user/1/username: hugo"))
+	flow := maintenance.Flow(ds)
+	key := dskey.MustKey("user/1/username")
+
+	if _, err := flow.Get(ctx, key); err != nil {
+		t.Fatalf("Get without maintenance: %v", err)
+	}
+
+	_, changed := maintenance.Current()
+	maintenance.Start("")
+
+	select {
+	case <-changed:
+	default:
+		t.Errorf("channel of Current was not closed")
+	}
+
+	status, _ := maintenance.Current()
+	if !status.Active || status.Message == "" || status.Since == 0 {
+		t.Errorf("got status %+v", status)
+	}
+
+	_, err := flow.Get(ctx, key)
+	var errMaintenance maintenance.Error
+	if !errors.As(err, &errMaintenance) || errMaintenance.StatusCode() != 503 {
+		t.Fatalf("Get during maintenance returned %v, expected a maintenance error", err)
+	}
+
+	updated := make(chan struct{})
+	go flow.Update(ctx, func(map[dskey.Key][]byte, error) {
+		close(updated)
+	})
+	ds.Send(map[dskey.Key][]byte{key: []byte(`"max"`)})
+
+	select {
+	case <-updated:
+		t.Fatalf("update was given during maintenance")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	maintenance.End()
+
+	select {
+	case <-updated:
+	case <-ctx.Done():
+		t.Fatalf("update was not given after the maintenance")
+	}
+
+	if maintenance.Active() {
+		t.Errorf("maintenance is still active")
+	}
+}
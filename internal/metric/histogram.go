@@ -0,0 +1,162 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LatencyBuckets are the bounds of histograms for the time of a request.
+var LatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// SizeBuckets are the bounds of histograms for payload sizes in bytes.
+var SizeBuckets = []int{
+	1 << 10,
+	10 << 10,
+	100 << 10,
+	1 << 20,
+	10 << 20,
+}
+
+// Exemplar is one observation of a histogram, that was part of a sampled
+// trace. It links a bucket of the histogram to the trace.
+type Exemplar struct {
+	TraceID string    `json:"trace_id"`
+	SpanID  string    `json:"span_id"`
+	Value   int64     `json:"value"`
+	Time    time.Time `json:"time"`
+}
+
+// Histogram counts values in buckets.
+//
+// For each bucket, it remembers the last observation with a sampled trace as
+// exemplar.
+//
+// Has to be created with NewHistogram or NewSizeHistogram.
+type Histogram struct {
+	name   string
+	unit   string
+	bounds []int64
+
+	mu        sync.Mutex
+	counts    []int
+	exemplars []Exemplar
+	count     int
+	sum       int64
+}
+
+// NewHistogram creates a histogram for durations with the given upper bounds
+// of the buckets. The values are counted in milliseconds.
+//
+// The bounds have to be sorted. There is an additional bucket for bigger
+// values.
+func NewHistogram(name string, bounds ...time.Duration) *Histogram {
+	ms := make([]int64, len(bounds))
+	for i, bound := range bounds {
+		ms[i] = bound.Milliseconds()
+	}
+	return newHistogram(name, "ms", ms)
+}
+
+// NewSizeHistogram creates a histogram for sizes in bytes with the given upper
+// bounds of the buckets.
+//
+// The bounds have to be sorted. There is an additional bucket for bigger
+// values.
+func NewSizeHistogram(name string, bounds ...int) *Histogram {
+	bs := make([]int64, len(bounds))
+	for i, bound := range bounds {
+		bs[i] = int64(bound)
+	}
+	return newHistogram(name, "bytes", bs)
+}
+
+func newHistogram(name string, unit string, bounds []int64) *Histogram {
+	return &Histogram{
+		name:      name + "_",
+		unit:      unit,
+		bounds:    bounds,
+		counts:    make([]int, len(bounds)+1),
+		exemplars: make([]Exemplar, len(bounds)+1),
+	}
+}
+
+// Observe adds a duration to the histogram.
+//
+// If the context contains a sampled span, it is used as exemplar of the
+// bucket.
+func (h *Histogram) Observe(ctx context.Context, d time.Duration) {
+	h.ObserveValue(ctx, d.Milliseconds())
+}
+
+// ObserveValue adds a value in the unit of the histogram.
+//
+// If the context contains a sampled span, it is used as exemplar of the
+// bucket.
+func (h *Histogram) ObserveValue(ctx context.Context, value int64) {
+	bucket := len(h.bounds)
+	for i, bound := range h.bounds {
+		if value <= bound {
+			bucket = i
+			break
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[bucket]++
+	h.count++
+	h.sum += value
+
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		h.exemplars[bucket] = Exemplar{
+			TraceID: span.TraceID().String(),
+			SpanID:  span.SpanID().String(),
+			Value:   value,
+			Time:    time.Now(),
+		}
+	}
+}
+
+// Metric writes the buckets, the count and the sum of the histogram.
+//
+// The buckets are cumulative like in Prometheus. The name of a bucket is the
+// upper bound in the unit of the histogram, for example
+// `restrict_duration_le_100`. The last bucket is called `le_inf`. The sum is
+// called `sum_ms` or `sum_bytes`.
+func (h *Histogram) Metric(con Container) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var cumulative int
+	for i, count := range h.counts {
+		cumulative += count
+
+		key := h.name + "le_inf"
+		if i < len(h.bounds) {
+			key = fmt.Sprintf("%sle_%d", h.name, h.bounds[i])
+		}
+
+		con.Add(key, cumulative)
+		if h.exemplars[i].TraceID != "" {
+			con.AddExemplar(key, h.exemplars[i])
+		}
+	}
+
+	con.Add(h.name+"count", h.count)
+	con.Add(h.name+"sum_"+h.unit, int(h.sum))
+}
@@ -0,0 +1,74 @@
+package metric
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("test", 10*time.Millisecond, 100*time.Millisecond)
+
+	traceID := trace.TraceID{1, 2, 3}
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	sampled := trace.ContextWithSpanContext(context.Background(), spanCtx)
+
+	h.Observe(context.Background(), 5*time.Millisecond)
+	h.Observe(sampled, 50*time.Millisecond)
+	h.Observe(context.Background(), time.Second)
+
+	con := Container{data: make(map[string]int), exemplars: make(map[string]Exemplar)}
+	h.Metric(con)
+
+	for key, expect := range map[string]int{
+		"test_le_10":  1,
+		"test_le_100": 2,
+		"test_le_inf": 3,
+		"test_count":  3,
+		"test_sum_ms": 1055,
+	} {
+		if got := con.data[key]; got != expect {
+			t.Errorf("%s = %d, expected %d", key, got, expect)
+		}
+	}
+
+	if len(con.exemplars) != 1 {
+		t.Fatalf("got %d exemplars, expected 1", len(con.exemplars))
+	}
+
+	exemplar := con.exemplars["test_le_100"]
+	if exemplar.TraceID != traceID.String() {
+		t.Errorf("exemplar has trace id %s, expected %s", exemplar.TraceID, traceID)
+	}
+
+	if exemplar.Value != 50 {
+		t.Errorf("exemplar has value %d, expected 50", exemplar.Value)
+	}
+}
+
+func TestSizeHistogram(t *testing.T) {
+	h := NewSizeHistogram("size", 1024)
+
+	h.ObserveValue(context.Background(), 100)
+	h.ObserveValue(context.Background(), 2048)
+
+	con := Container{data: make(map[string]int), exemplars: make(map[string]Exemplar)}
+	h.Metric(con)
+
+	for key, expect := range map[string]int{
+		"size_le_1024":   1,
+		"size_le_inf":    2,
+		"size_count":     2,
+		"size_sum_bytes": 2148,
+	} {
+		if got := con.data[key]; got != expect {
+			t.Errorf("%s = %d, expected %d", key, got, expect)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package metric
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,7 +24,7 @@ func Register(f func(Container)) {
 // Loop gathers the metric data from all registered callbacks.
 //
 // Blocks until the context is done.
-func Loop(ctx context.Context, d time.Duration, logger *log.Logger) {
+func Loop(ctx context.Context, d time.Duration, logger *slog.Logger) {
 	ticker := time.NewTicker(d)
 	defer ticker.Stop()
 
@@ -36,7 +36,10 @@ func Loop(ctx context.Context, d time.Duration, logger *log.Logger) {
 			return
 
 		case <-ticker.C:
-			data := Container{make(map[string]int, lastSize)}
+			data := Container{
+				data:      make(map[string]int, lastSize),
+				exemplars: make(map[string]Exemplar),
+			}
 
 			callbacks.mu.Lock()
 			for _, callback := range callbacks.fs {
@@ -48,19 +51,30 @@ func Loop(ctx context.Context, d time.Duration, logger *log.Logger) {
 
 			bs, err := json.Marshal(data)
 			if err != nil {
-				logger.Printf("Metric failed: converting data to json: %v", err)
+				logger.Error("Metric failed: converting data to json", "error", err)
 				return
 			}
 
-			logger.Printf("Metric: %s", bs)
+			if len(data.exemplars) == 0 {
+				logger.Info("Metric", "data", json.RawMessage(bs))
+				continue
+			}
+
+			exemplars, err := json.Marshal(data.exemplars)
+			if err != nil {
+				logger.Error("Metric failed: converting exemplars to json", "error", err)
+				return
+			}
 
+			logger.Info("Metric", "data", json.RawMessage(bs), "exemplars", json.RawMessage(exemplars))
 		}
 	}
 }
 
 // Container is given to the callbacks for them to add the values.
 type Container struct {
-	data map[string]int
+	data      map[string]int
+	exemplars map[string]Exemplar
 }
 
 // Add adds a metric value.
@@ -68,11 +82,35 @@ func (c *Container) Add(key string, value int) {
 	c.data[key] = value
 }
 
+// AddExemplar links a metric value to a trace.
+func (c *Container) AddExemplar(key string, exemplar Exemplar) {
+	c.exemplars[key] = exemplar
+}
+
 // MarshalJSON converts the data to json.
 func (c Container) MarshalJSON() ([]byte, error) {
 	return json.Marshal(c.data)
 }
 
+// Prefixed returns a callback, that adds the values of f with the prefix. It
+// is used for the values of one tenant.
+func Prefixed(prefix string, f func(Container)) func(Container) {
+	return func(con Container) {
+		inner := Container{
+			data:      make(map[string]int),
+			exemplars: make(map[string]Exemplar),
+		}
+		f(inner)
+
+		for key, value := range inner.data {
+			con.data[prefix+key] = value
+		}
+		for key, exemplar := range inner.exemplars {
+			con.exemplars[prefix+key] = exemplar
+		}
+	}
+}
+
 // CurrentCounter is a metric that shows a current value.
 type CurrentCounter struct {
 	name    string
@@ -0,0 +1,21 @@
+package metric
+
+import "testing"
+
+func TestPrefixed(t *testing.T) {
+	f := Prefixed("tenant_a_", func(con Container) {
+		con.Add("connections", 3)
+		con.AddExemplar("connections", Exemplar{Value: 3})
+	})
+
+	con := Container{data: map[string]int{"connections": 1}, exemplars: make(map[string]Exemplar)}
+	f(con)
+
+	if con.data["tenant_a_connections"] != 3 || con.data["connections"] != 1 {
+		t.Errorf("got %v, expected the prefixed value next to the other one", con.data)
+	}
+
+	if _, ok := con.exemplars["tenant_a_connections"]; !ok {
+		t.Errorf("exemplar was not prefixed: %v", con.exemplars)
+	}
+}
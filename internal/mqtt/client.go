@@ -0,0 +1,319 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The packet types of MQTT 3.1.1, that the client uses.
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPingreq    = 12
+	packetPingresp   = 13
+	packetDisconnect = 14
+)
+
+const (
+	// writeTimeout is the time, the broker can take to receive a packet.
+	writeTimeout = 10 * time.Second
+
+	// maxRemainingLength is the biggest packet, MQTT allows.
+	maxRemainingLength = 268_435_455
+)
+
+// brokerConfig are the settings of the connection to the broker.
+type brokerConfig struct {
+	address   string
+	tls       bool
+	clientID  string
+	username  string
+	password  string
+	keepAlive time.Duration
+
+	// willTopic gets the retained message `offline`, when the connection is
+	// lost.
+	willTopic string
+}
+
+// client is a minimal MQTT 3.1.1 client. It can only publish messages with
+// QoS 0.
+type client struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// dial connects to the broker and waits for its CONNACK.
+//
+// The client sends pings in the background. When the broker does not answer,
+// the connection is closed and done is closed.
+func dial(ctx context.Context, cfg brokerConfig) (*client, error) {
+	var conn net.Conn
+	var err error
+	if cfg.tls {
+		dialer := tls.Dialer{Config: &tls.Config{MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", cfg.address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", cfg.address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", cfg.address, err)
+	}
+
+	c := &client{
+		conn: conn,
+		done: make(chan struct{}),
+	}
+
+	if err := c.write(connectPacket(cfg)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sending connect: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(writeTimeout))
+	header, body, err := readPacket(reader)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading connack: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if header>>4 != packetConnack || len(body) != 2 {
+		conn.Close()
+		return nil, fmt.Errorf("expected connack, got packet type %d", header>>4)
+	}
+
+	if body[1] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("broker refused connection: %s", connackReason(body[1]))
+	}
+
+	pong := make(chan struct{}, 1)
+	go c.read(reader, pong)
+	go c.ping(cfg.keepAlive, pong)
+	return c, nil
+}
+
+// publish sends a message with QoS 0.
+func (c *client) publish(topic string, payload []byte, retain bool) error {
+	header := byte(packetPublish << 4)
+	if retain {
+		header |= 1
+	}
+
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return c.write(header, body)
+}
+
+// close sends DISCONNECT and closes the connection.
+func (c *client) close() {
+	c.write(packetDisconnect<<4, nil)
+	c.stop(errors.New("client closed"))
+}
+
+// stop closes the connection with the error.
+func (c *client) stop(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+// write sends one packet.
+func (c *client) write(header byte, body []byte) error {
+	if len(body) > maxRemainingLength {
+		return fmt.Errorf("packet with %d bytes is too big", len(body))
+	}
+
+	packet := []byte{header}
+	packet = appendLength(packet, len(body))
+	packet = append(packet, body...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(packet); err != nil {
+		c.stop(fmt.Errorf("writing packet: %w", err))
+		return err
+	}
+	return nil
+}
+
+// read reads the packets of the broker until the connection is closed.
+func (c *client) read(reader *bufio.Reader, pong chan<- struct{}) {
+	for {
+		header, _, err := readPacket(reader)
+		if err != nil {
+			c.stop(fmt.Errorf("reading packet: %w", err))
+			return
+		}
+
+		if header>>4 == packetPingresp {
+			select {
+			case pong <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// ping sends a PINGREQ after each half of the keep alive. If the broker does
+// not answer until the next ping, the connection is closed.
+func (c *client) ping(keepAlive time.Duration, pong <-chan struct{}) {
+	ticker := time.NewTicker(keepAlive / 2)
+	defer ticker.Stop()
+
+	waiting := false
+	for {
+		select {
+		case <-c.done:
+			return
+
+		case <-pong:
+			waiting = false
+
+		case <-ticker.C:
+			if waiting {
+				c.stop(errors.New("broker did not answer the ping"))
+				return
+			}
+
+			if err := c.write(packetPingreq<<4, nil); err != nil {
+				return
+			}
+			waiting = true
+		}
+	}
+}
+
+// connectPacket returns the header and body of the CONNECT packet.
+func connectPacket(cfg brokerConfig) (byte, []byte) {
+	// Clean session, will with QoS 0 and retain.
+	flags := byte(0x02 | 0x04 | 0x20)
+	if cfg.username != "" {
+		flags |= 0x80
+	}
+	if cfg.password != "" {
+		flags |= 0x40
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(cfg.keepAlive/time.Second))
+	body = appendString(body, cfg.clientID)
+	body = appendString(body, cfg.willTopic)
+	body = appendString(body, "offline")
+	if cfg.username != "" {
+		body = appendString(body, cfg.username)
+	}
+	if cfg.password != "" {
+		body = appendString(body, cfg.password)
+	}
+	return packetConnect << 4, body
+}
+
+// readPacket reads the fixed header, the length and the body of a packet.
+func readPacket(reader *bufio.Reader) (byte, []byte, error) {
+	header, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length := 0
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("invalid remaining length")
+		}
+
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		length |= int(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// appendLength appends the remaining length of a packet.
+func appendLength(b []byte, length int) []byte {
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if length == 0 {
+			return b
+		}
+	}
+}
+
+// appendString appends a string with its length.
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// validTopic returns an error, if the topic can not be used to publish.
+func validTopic(topic string) error {
+	if topic == "" {
+		return errors.New("topic is empty")
+	}
+
+	if len(topic) > 65535 {
+		return errors.New("topic is too long")
+	}
+
+	if strings.ContainsAny(topic, "+#\x00") {
+		return fmt.Errorf("topic `%s` must not contain +, # or a null character", topic)
+	}
+	return nil
+}
+
+// connackReason returns the meaning of the return code of a CONNACK.
+func connackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	default:
+		return fmt.Sprintf("return code %d", code)
+	}
+}
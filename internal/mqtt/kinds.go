@@ -0,0 +1,253 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// kind is a type of topic. request returns the autoupdate request for the id
+// of the topic and message builds the message from its data.
+type kind struct {
+	request func(id int) string
+	message func(s state, id int) any
+}
+
+var kinds = map[string]kind{
+	"projector": {projectorRequest, projectorMessage},
+	"speaker":   {speakerRequest, speakerMessage},
+	"poll":      {pollRequest, pollMessage},
+}
+
+func projectorRequest(projectorID int) string {
+	return fmt.Sprintf(`[{
+		"collection": "projector",
+		"ids": [%d],
+		"fields": {
+			"id": null,
+			"name": null,
+			"current_projection_ids": {
+				"type": "relation-list",
+				"collection": "projection",
+				"fields": {"type": null, "content_object_id": null, "stable": null, "content": null}
+			}
+		}
+	}]`, projectorID)
+}
+
+type projectionMessage struct {
+	ID              int             `json:"id"`
+	Type            string          `json:"type,omitempty"`
+	ContentObjectID string          `json:"content_object_id"`
+	Stable          bool            `json:"stable"`
+	Content         json.RawMessage `json:"content,omitempty"`
+}
+
+// projectorMessage returns the current projections of a projector or nil, if
+// the user can not see the projector.
+func projectorMessage(s state, projectorID int) any {
+	var id int
+	if !s.get("projector", projectorID, "id", &id) {
+		return nil
+	}
+
+	var name string
+	var projectionIDs []int
+	s.get("projector", projectorID, "name", &name)
+	s.get("projector", projectorID, "current_projection_ids", &projectionIDs)
+
+	projections := make([]projectionMessage, 0, len(projectionIDs))
+	for _, projectionID := range projectionIDs {
+		p := projectionMessage{ID: projectionID}
+		if !s.get("projection", projectionID, "content_object_id", &p.ContentObjectID) {
+			continue
+		}
+
+		s.get("projection", projectionID, "type", &p.Type)
+		s.get("projection", projectionID, "stable", &p.Stable)
+		s.get("projection", projectionID, "content", &p.Content)
+		projections = append(projections, p)
+	}
+
+	return struct {
+		ProjectorID int                 `json:"projector_id"`
+		Name        string              `json:"name"`
+		Projections []projectionMessage `json:"projections"`
+	}{projectorID, name, projections}
+}
+
+func speakerRequest(meetingID int) string {
+	return fmt.Sprintf(`[{
+		"collection": "meeting",
+		"ids": [%d],
+		"fields": {
+			"speaker_ids": {
+				"type": "relation-list",
+				"collection": "speaker",
+				"fields": {
+					"begin_time": null,
+					"end_time": null,
+					"pause_time": null,
+					"speech_state": null,
+					"point_of_order": null,
+					"list_of_speakers_id": null,
+					"meeting_user_id": {
+						"type": "relation",
+						"collection": "meeting_user",
+						"fields": {
+							"user_id": {
+								"type": "relation",
+								"collection": "user",
+								"fields": {"title": null, "first_name": null, "last_name": null, "username": null}
+							}
+						}
+					}
+				}
+			}
+		}
+	}]`, meetingID)
+}
+
+// speakerMessage returns the current speaker of a meeting or nil, if nobody
+// speaks.
+//
+// The current speaker has a begin time but no end time. If there are many, the
+// one, that started last, is used.
+func speakerMessage(s state, meetingID int) any {
+	var speakerIDs []int
+	s.get("meeting", meetingID, "speaker_ids", &speakerIDs)
+
+	current := 0
+	var currentBegin int
+	for _, speakerID := range speakerIDs {
+		var begin, end int
+		s.get("speaker", speakerID, "begin_time", &begin)
+		s.get("speaker", speakerID, "end_time", &end)
+		if begin == 0 || end != 0 {
+			continue
+		}
+
+		if begin > currentBegin || (begin == currentBegin && speakerID > current) {
+			current = speakerID
+			currentBegin = begin
+		}
+	}
+
+	if current == 0 {
+		return nil
+	}
+
+	var listOfSpeakersID, pauseTime, meetingUserID, userID int
+	var speechState string
+	var pointOfOrder bool
+	s.get("speaker", current, "list_of_speakers_id", &listOfSpeakersID)
+	s.get("speaker", current, "pause_time", &pauseTime)
+	s.get("speaker", current, "speech_state", &speechState)
+	s.get("speaker", current, "point_of_order", &pointOfOrder)
+	s.get("speaker", current, "meeting_user_id", &meetingUserID)
+	if meetingUserID != 0 {
+		s.get("meeting_user", meetingUserID, "user_id", &userID)
+	}
+
+	return struct {
+		SpeakerID        int    `json:"speaker_id"`
+		ListOfSpeakersID int    `json:"list_of_speakers_id"`
+		Name             string `json:"name"`
+		SpeechState      string `json:"speech_state,omitempty"`
+		PointOfOrder     bool   `json:"point_of_order"`
+		BeginTime        int    `json:"begin_time"`
+		Paused           bool   `json:"paused"`
+	}{current, listOfSpeakersID, userName(s, userID), speechState, pointOfOrder, currentBegin, pauseTime != 0}
+}
+
+// userName returns the title, first and last name of a user. If they are
+// empty, it returns the username.
+func userName(s state, userID int) string {
+	if userID == 0 {
+		return ""
+	}
+
+	var parts []string
+	for _, field := range []string{"title", "first_name", "last_name"} {
+		var part string
+		if s.get("user", userID, field, &part) && part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	if len(parts) == 0 {
+		var username string
+		s.get("user", userID, "username", &username)
+		return username
+	}
+	return strings.Join(parts, " ")
+}
+
+func pollRequest(meetingID int) string {
+	return fmt.Sprintf(`[{
+		"collection": "meeting",
+		"ids": [%d],
+		"fields": {
+			"poll_ids": {
+				"type": "relation-list",
+				"collection": "poll",
+				"fields": {
+					"title": null,
+					"state": null,
+					"type": null,
+					"pollmethod": null,
+					"content_object_id": null,
+					"votescast": null,
+					"votesvalid": null,
+					"votesinvalid": null
+				}
+			}
+		}
+	}]`, meetingID)
+}
+
+// pollMessage returns the latest started poll of a meeting or nil, if no poll
+// was started.
+//
+// The poll stays the message after it was finished or published until the
+// next poll is started. The results are only part of the message, if the user
+// can see them.
+func pollMessage(s state, meetingID int) any {
+	var pollIDs []int
+	s.get("meeting", meetingID, "poll_ids", &pollIDs)
+
+	current := 0
+	for _, pollID := range pollIDs {
+		var pollState string
+		if !s.get("poll", pollID, "state", &pollState) || pollState == "created" {
+			continue
+		}
+		current = max(current, pollID)
+	}
+
+	if current == 0 {
+		return nil
+	}
+
+	message := struct {
+		PollID          int             `json:"poll_id"`
+		Title           string          `json:"title"`
+		State           string          `json:"state"`
+		Type            string          `json:"type"`
+		Pollmethod      string          `json:"pollmethod"`
+		ContentObjectID string          `json:"content_object_id,omitempty"`
+		Votescast       json.RawMessage `json:"votescast,omitempty"`
+		Votesvalid      json.RawMessage `json:"votesvalid,omitempty"`
+		Votesinvalid    json.RawMessage `json:"votesinvalid,omitempty"`
+	}{PollID: current}
+
+	s.get("poll", current, "title", &message.Title)
+	s.get("poll", current, "state", &message.State)
+	s.get("poll", current, "type", &message.Type)
+	s.get("poll", current, "pollmethod", &message.Pollmethod)
+	s.get("poll", current, "content_object_id", &message.ContentObjectID)
+	s.get("poll", current, "votescast", &message.Votescast)
+	s.get("poll", current, "votesvalid", &message.Votesvalid)
+	s.get("poll", current, "votesinvalid", &message.Votesinvalid)
+	return message
+}
@@ -0,0 +1,417 @@
+// Package mqtt publishes the state of projectors, speakers and polls to an
+// MQTT broker.
+//
+// Venue hardware like LED walls, room displays or streaming switchers can
+// subscribe to the topics of the broker instead of opening autoupdate
+// connections. Operators register the topics in a YAML or JSON file. Each topic
+// has a type, an id and a user. The data is read with the permissions of the
+// user, so each topic only contains what its user can see.
+//
+// All messages are retained, so a new subscriber gets the current state at
+// once. The topic `<prefix>status` is `online` while the service is connected.
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
+	"github.com/goccy/go-yaml"
+)
+
+var (
+	envBroker       = environment.NewVariable("AUTOUPDATE_MQTT_BROKER", "", "Url of an MQTT broker like tcp://broker:1883 or tls://broker:8883, that gets the state of projectors, speakers and polls. Empty disables the MQTT bridge.")
+	envTopicsFile   = environment.NewVariable("AUTOUPDATE_MQTT_TOPICS_FILE", "", "Path of a YAML or JSON file with the topics of the MQTT bridge.")
+	envTopicPrefix  = environment.NewVariable("AUTOUPDATE_MQTT_TOPIC_PREFIX", "openslides/", "Prefix of all topics of the MQTT bridge.")
+	envClientID     = environment.NewVariable("AUTOUPDATE_MQTT_CLIENT_ID", "openslides-autoupdate", "Client id at the MQTT broker. Each instance needs its own id.")
+	envUsername     = environment.NewVariable("AUTOUPDATE_MQTT_USERNAME", "", "Username at the MQTT broker. Empty connects without username and password.")
+	envPasswordFile = environment.NewVariable("AUTOUPDATE_MQTT_PASSWORD_FILE", "/run/secrets/autoupdate_mqtt_password", "Path of the password at the MQTT broker.")
+)
+
+const (
+	// keepAlive is the time, after that the broker closes a silent connection.
+	keepAlive = 30 * time.Second
+
+	// minBackoff is the delay after a failed connection. It is doubled after
+	// each try until maxBackoff.
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+var counter struct {
+	published atomic.Uint64
+	connected atomic.Bool
+}
+
+// Connecter opens an autoupdate connection.
+type Connecter interface {
+	Connect(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (autoupdate.Connection, error)
+}
+
+// Topic is one configured topic.
+type Topic struct {
+	// Topic is the name of the topic without the prefix.
+	Topic string `json:"topic"`
+
+	// Type is projector, speaker or poll.
+	Type string `json:"type"`
+
+	// ID is the id of the projector or the id of the meeting for speaker and
+	// poll.
+	ID int `json:"id"`
+
+	// UserID is the user, whose permissions are used to read the data.
+	UserID int `json:"user_id"`
+}
+
+// New reads the topics from the file.
+//
+// The returned function connects to the broker and publishes the messages. It
+// has to be run in the background.
+func New(lookup environment.Environmenter, connecter Connecter) (func(context.Context, func(error)), error) {
+	broker := envBroker.Value(lookup)
+	if broker == "" {
+		return func(context.Context, func(error)) {}, nil
+	}
+
+	cfg, err := parseBroker(broker)
+	if err != nil {
+		return nil, fmt.Errorf("parsing `%s`: %w", envBroker.Key, err)
+	}
+
+	cfg.clientID = envClientID.Value(lookup)
+	cfg.keepAlive = keepAlive
+
+	prefix := envTopicPrefix.Value(lookup)
+	cfg.willTopic = prefix + "status"
+	if err := validTopic(cfg.willTopic); err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envTopicPrefix.Key, err)
+	}
+
+	cfg.username = envUsername.Value(lookup)
+	if cfg.username != "" {
+		password, err := environment.ReadSecret(lookup, envPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading mqtt password: %w", err)
+		}
+		cfg.password = strings.TrimSpace(password)
+	}
+
+	path := envTopicsFile.Value(lookup)
+	if path == "" {
+		return nil, fmt.Errorf("`%s` is set, but `%s` is empty", envBroker.Key, envTopicsFile.Key)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mqtt topics file: %w", err)
+	}
+
+	topics, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing mqtt topics file %s: %w", path, err)
+	}
+
+	b := newBridge()
+	background := func(ctx context.Context, errorHandler func(error)) {
+		var wg sync.WaitGroup
+		for _, topic := range topics {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.watch(ctx, connecter, prefix, topic, errorHandler)
+			}()
+		}
+
+		b.run(ctx, cfg, errorHandler)
+		wg.Wait()
+	}
+	return background, nil
+}
+
+// parseBroker parses the url of the broker.
+func parseBroker(broker string) (brokerConfig, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return brokerConfig{}, err
+	}
+
+	var cfg brokerConfig
+	defaultPort := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "tls", "ssl", "mqtts":
+		cfg.tls = true
+		defaultPort = "8883"
+	default:
+		return brokerConfig{}, fmt.Errorf("unknown scheme `%s`, expected tcp or tls", u.Scheme)
+	}
+
+	if u.Hostname() == "" {
+		return brokerConfig{}, fmt.Errorf("url has no host")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	cfg.address = u.Hostname() + ":" + port
+	if strings.Contains(u.Hostname(), ":") {
+		cfg.address = "[" + u.Hostname() + "]:" + port
+	}
+	return cfg, nil
+}
+
+// parse decodes the topics file.
+func parse(content []byte) ([]Topic, error) {
+	var topics []Topic
+	if err := yaml.Unmarshal(content, &topics); err != nil {
+		return nil, fmt.Errorf("decoding yaml: %w", err)
+	}
+
+	names := make(map[string]bool, len(topics))
+	for i, topic := range topics {
+		if err := validTopic(topic.Topic); err != nil {
+			return nil, fmt.Errorf("topic %d: %w", i+1, err)
+		}
+
+		if names[topic.Topic] {
+			return nil, fmt.Errorf("topic %s: is used twice", topic.Topic)
+		}
+		names[topic.Topic] = true
+
+		if _, ok := kinds[topic.Type]; !ok {
+			return nil, fmt.Errorf("topic %s: unknown type `%s`, expected projector, speaker or poll", topic.Topic, topic.Type)
+		}
+
+		if topic.ID < 1 {
+			return nil, fmt.Errorf("topic %s: invalid id %d", topic.Topic, topic.ID)
+		}
+
+		if topic.UserID < 0 {
+			return nil, fmt.Errorf("topic %s: invalid user_id %d", topic.Topic, topic.UserID)
+		}
+	}
+	return topics, nil
+}
+
+// Metric adds the number of published messages and the connection state to
+// the metric.
+func Metric(con metric.Container) {
+	connected := 0
+	if counter.connected.Load() {
+		connected = 1
+	}
+
+	con.Add("mqtt_published", int(counter.published.Load()))
+	con.Add("mqtt_connected", connected)
+}
+
+// bridge holds the last message of each topic.
+//
+// The topics write their messages into the bridge and the connection to the
+// broker publishes them. After a reconnect, all messages are published again.
+type bridge struct {
+	mu      sync.Mutex
+	last    map[string][]byte
+	pending set.Set[string]
+
+	// signal has a buffer of one. It is notified, when a message is pending.
+	signal chan struct{}
+}
+
+func newBridge() *bridge {
+	return &bridge{
+		last:    make(map[string][]byte),
+		pending: set.New[string](),
+		signal:  make(chan struct{}, 1),
+	}
+}
+
+// set saves the message of a topic. Messages, that did not change, are
+// ignored.
+func (b *bridge) set(topic string, message []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if old, ok := b.last[topic]; ok && bytes.Equal(old, message) {
+		return
+	}
+
+	b.last[topic] = message
+	b.pending.Add(topic)
+
+	select {
+	case b.signal <- struct{}{}:
+	default:
+	}
+}
+
+// takePending returns the pending messages.
+func (b *bridge) takePending() map[string][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	messages := make(map[string][]byte, b.pending.Len())
+	for _, topic := range b.pending.List() {
+		messages[topic] = b.last[topic]
+	}
+	b.pending = set.New[string]()
+	return messages
+}
+
+// resendAll marks all messages as pending.
+func (b *bridge) resendAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic := range b.last {
+		b.pending.Add(topic)
+	}
+}
+
+// run connects to the broker and publishes the messages until the context is
+// done.
+func (b *bridge) run(ctx context.Context, cfg brokerConfig, errorHandler func(error)) {
+	backoff := minBackoff
+	for {
+		connected, err := b.publish(ctx, cfg)
+		if ctx.Err() != nil {
+			return
+		}
+		errorHandler(fmt.Errorf("mqtt: %w", err))
+
+		if connected {
+			backoff = minBackoff
+		}
+
+		if err := clock.Sleep(ctx, backoff); err != nil {
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// publish opens one connection to the broker and publishes the messages. It
+// only returns with an error. connected is true, if the broker accepted the
+// connection.
+func (b *bridge) publish(ctx context.Context, cfg brokerConfig) (connected bool, err error) {
+	c, err := dial(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+	defer c.close()
+
+	counter.connected.Store(true)
+	defer counter.connected.Store(false)
+
+	if err := c.publish(cfg.willTopic, []byte("online"), true); err != nil {
+		return true, fmt.Errorf("publishing status: %w", err)
+	}
+
+	b.resendAll()
+	for {
+		for topic, message := range b.takePending() {
+			if err := c.publish(topic, message, true); err != nil {
+				return true, fmt.Errorf("publishing to %s: %w", topic, err)
+			}
+			counter.published.Add(1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-c.done:
+			return true, c.err
+		case <-b.signal:
+		}
+	}
+}
+
+// watch reads the data of one topic until the context is done.
+func (b *bridge) watch(ctx context.Context, connecter Connecter, prefix string, topic Topic, errorHandler func(error)) {
+	for {
+		err := b.stream(ctx, connecter, prefix, topic)
+		if ctx.Err() != nil {
+			return
+		}
+		errorHandler(fmt.Errorf("mqtt topic %s: %w", topic.Topic, err))
+
+		if err := clock.Sleep(ctx, minBackoff); err != nil {
+			return
+		}
+	}
+}
+
+// stream opens the autoupdate connection of the topic and saves a message
+// after each update. It only returns with an error.
+func (b *bridge) stream(ctx context.Context, connecter Connecter, prefix string, topic Topic) error {
+	k := kinds[topic.Type]
+
+	kb, err := keysbuilder.ManyFromJSON(strings.NewReader(k.request(topic.ID)))
+	if err != nil {
+		return fmt.Errorf("building keysbuilder: %w", err)
+	}
+
+	conn, err := connecter.Connect(ctx, topic.UserID, kb)
+	if err != nil {
+		return fmt.Errorf("connecting: %w", err)
+	}
+
+	state := make(state)
+	for f, ok := conn.Next(); ok; f, ok = conn.Next() {
+		data, err := f(ctx)
+		if err != nil {
+			return fmt.Errorf("getting next message: %w", err)
+		}
+
+		for key, value := range data {
+			if value == nil {
+				delete(state, key)
+				continue
+			}
+			state[key] = value
+		}
+
+		message, err := json.Marshal(k.message(state, topic.ID))
+		if err != nil {
+			return fmt.Errorf("encoding message: %w", err)
+		}
+
+		b.set(prefix+topic.Topic, message)
+	}
+	return ctx.Err()
+}
+
+// state is the data of a topic.
+type state map[dskey.Key][]byte
+
+// get decodes the value of a field into v. It returns false, if the field does
+// not exist or the user can not see it.
+func (s state) get(collection string, id int, field string, v any) bool {
+	key, err := dskey.FromParts(collection, id, field)
+	if err != nil {
+		return false
+	}
+
+	value, ok := s[key]
+	if !ok {
+		return false
+	}
+
+	return json.Unmarshal(value, v) == nil
+}
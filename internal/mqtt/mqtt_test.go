@@ -0,0 +1,271 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+)
+
+func TestParse(t *testing.T) {
+	topics, err := parse([]byte(`---
+- topic: hall/projector
+  type: projector
+  id: 1
+- topic: hall/speaker
+  type: speaker
+  id: 7
+  user_id: 5
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	expect := []Topic{{Topic: "hall/projector", Type: "projector", ID: 1}, {Topic: "hall/speaker", Type: "speaker", ID: 7, UserID: 5}}
+	if len(topics) != 2 || topics[0] != expect[0] || topics[1] != expect[1] {
+		t.Fatalf("got %v, expected %v", topics, expect)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{"no topic", `[{type: poll, id: 1}]`},
+		{"wildcard", `[{topic: "hall/#", type: poll, id: 1}]`},
+		{"same topic", `[{topic: a, type: poll, id: 1}, {topic: a, type: speaker, id: 1}]`},
+		{"unknown type", `[{topic: a, type: motion, id: 1}]`},
+		{"no id", `[{topic: a, type: poll}]`},
+		{"invalid user", `[{topic: a, type: poll, id: 1, user_id: -1}]`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parse([]byte(tt.content)); err == nil {
+				t.Errorf("parse returned no error")
+			}
+		})
+	}
+}
+
+func TestParseBroker(t *testing.T) {
+	for _, tt := range []struct {
+		broker  string
+		address string
+		tls     bool
+	}{
+		{"tcp://localhost", "localhost:1883", false},
+		{"mqtt://broker:1884", "broker:1884", false},
+		{"tls://broker", "broker:8883", true},
+		{"tcp://[::1]:1883", "[::1]:1883", false},
+	} {
+		t.Run(tt.broker, func(t *testing.T) {
+			cfg, err := parseBroker(tt.broker)
+			if err != nil {
+				t.Fatalf("parseBroker: %v", err)
+			}
+
+			if cfg.address != tt.address || cfg.tls != tt.tls {
+				t.Errorf("got address %s and tls %v, expected %s and %v", cfg.address, cfg.tls, tt.address, tt.tls)
+			}
+		})
+	}
+
+	for _, broker := range []string{"http://broker", "tcp://"} {
+		if _, err := parseBroker(broker); err == nil {
+			t.Errorf("parseBroker(%s) returned no error", broker)
+		}
+	}
+}
+
+func TestRequests(t *testing.T) {
+	for name, k := range kinds {
+		if _, err := keysbuilder.ManyFromJSON(strings.NewReader(k.request(1))); err != nil {
+			t.Errorf("request of %s is invalid: %v", name, err)
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	data := state(dsmock.YAMLData(`---
+	projector/1:
+		id: 1
+		name: Main
+		current_projection_ids: [2, 3]
+	projection/2:
+		type: agenda_item_list
+		content_object_id: meeting/1
+		stable: true
+		content: {"items": []}
+	projection/3/stable: false
+
+	meeting/1:
+		speaker_ids: [4, 5, 6]
+		poll_ids: [7, 8, 9]
+	speaker/4:
+		begin_time: 100
+		end_time: 200
+	speaker/5:
+		begin_time: 300
+		pause_time: 350
+		list_of_speakers_id: 10
+		meeting_user_id: 11
+	speaker/6/meeting_user_id: 12
+	meeting_user/11/user_id: 13
+	user/13:
+		first_name: Max
+		last_name: Mustermann
+
+	poll/7:
+		title: First
+		state: published
+		votescast: "12.000000"
+	poll/8:
+		title: Second
+		state: started
+		type: named
+		pollmethod: YN
+	poll/9/state: created
+	`))
+
+	for _, tt := range []struct {
+		kind   string
+		id     int
+		expect string
+	}{
+		{"projector", 1, `{"projector_id":1,"name":"Main","projections":[{"id":2,"type":"agenda_item_list","content_object_id":"meeting/1","stable":true,"content":{"items":[]}}]}`},
+		{"projector", 2, `null`},
+		{"speaker", 1, `{"speaker_id":5,"list_of_speakers_id":10,"name":"Max Mustermann","point_of_order":false,"begin_time":300,"paused":true}`},
+		{"speaker", 2, `null`},
+		{"poll", 1, `{"poll_id":8,"title":"Second","state":"started","type":"named","pollmethod":"YN"}`},
+		{"poll", 2, `null`},
+	} {
+		t.Run(tt.kind, func(t *testing.T) {
+			got := mustMarshal(t, kinds[tt.kind].message(data, tt.id))
+			if got != tt.expect {
+				t.Errorf("got %s, expected %s", got, tt.expect)
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+
+	message, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("encoding message: %v", err)
+	}
+	return string(message)
+}
+
+// chanConnecter returns a connection, that gets its messages from the
+// channel.
+type chanConnecter chan map[dskey.Key][]byte
+
+func (c chanConnecter) Connect(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (autoupdate.Connection, error) {
+	return c, nil
+}
+
+func (c chanConnecter) Next() (func(context.Context) (map[dskey.Key][]byte, error), bool) {
+	return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+		select {
+		case data := <-c:
+			return data, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, true
+}
+
+func (c chanConnecter) NextWithFilter(ctx context.Context, filterHashes string) (map[dskey.Key][]byte, string, error) {
+	return nil, "", nil
+}
+
+// fakeBroker accepts one connection and sends the published messages to the
+// channel.
+func fakeBroker(t *testing.T, published chan<- string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for {
+			header, body, err := readPacket(reader)
+			if err != nil {
+				return
+			}
+
+			switch header >> 4 {
+			case packetConnect:
+				conn.Write([]byte{packetConnack << 4, 2, 0, 0})
+
+			case packetPublish:
+				length := binary.BigEndian.Uint16(body)
+				topic := string(body[2 : 2+length])
+				published <- topic + " " + string(body[2+length:])
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestBridge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	published := make(chan string)
+	cfg := brokerConfig{
+		address:   fakeBroker(t, published),
+		clientID:  "test",
+		keepAlive: time.Minute,
+		willTopic: "os/status",
+	}
+
+	updates := make(chanConnecter)
+	b := newBridge()
+	go b.run(ctx, cfg, func(err error) {})
+
+	expectPublished := func(expect string) {
+		t.Helper()
+
+		select {
+		case got := <-published:
+			if got != expect {
+				t.Errorf("got %s, expected %s", got, expect)
+			}
+		case <-ctx.Done():
+			t.Fatalf("broker did not get %s", expect)
+		}
+	}
+
+	expectPublished("os/status online")
+
+	go b.watch(ctx, updates, "os/", Topic{Topic: "hall", Type: "projector", ID: 1}, func(err error) {})
+
+	updates <- map[dskey.Key][]byte{dskey.MustKey("projector/1/id"): []byte(`1`), dskey.MustKey("projector/1/name"): []byte(`"Main"`)}
+	expectPublished(`os/hall {"projector_id":1,"name":"Main","projections":[]}`)
+
+	// An update, that does not change the message, is not published.
+	updates <- map[dskey.Key][]byte{dskey.MustKey("projector/1/scale"): []byte(`5`)}
+	updates <- map[dskey.Key][]byte{dskey.MustKey("projector/1/id"): nil}
+	expectPublished("os/hall null")
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 )
 
 // Handle handles an error.
@@ -19,7 +19,7 @@ func Handle(err error) {
 		err = errAdmin
 	}
 
-	log.Printf("Error: %v", err)
+	slog.Error(err.Error())
 }
 
 // ContextDone returns true, if the given error contains a context.Canceled or
@@ -71,26 +71,6 @@ func (err adminError) Error() string {
 
 type ctxType string
 
-const bodyCTX ctxType = "body context"
-
-// ContextWithBody adds a body to the context.
-//
-// The value can be returned with the BodyFromContext function.
-func ContextWithBody(ctx context.Context, body string) context.Context {
-	return context.WithValue(ctx, bodyCTX, body)
-}
-
-// BodyFromContext returns the http body from a context.
-func BodyFromContext(ctx context.Context) (string, bool) {
-	v := ctx.Value(bodyCTX)
-	if v == nil {
-		return "", false
-	}
-
-	body, ok := v.(string)
-	return body, ok
-}
-
 // ContextWithTag adds a tag to the context
 func ContextWithTag(ctx context.Context, tag string) context.Context {
 	return context.WithValue(ctx, ctxType("tag-"+tag), struct{}{})
@@ -0,0 +1,89 @@
+package presenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
+)
+
+// EligibleVoters counts the users, that are entitled to vote in a poll, and how
+// many of them are present in the meeting.
+//
+// The user has to see the poll. The data is `{"poll_id": 1}`.
+func EligibleVoters(store *Store) {
+	store.Register("eligible_voters", func(ctx context.Context, fetch *dsfetch.Fetch, req Request) (any, error) {
+		var data struct {
+			PollID int `json:"poll_id"`
+		}
+		if err := decodeData(req.Data, &data); err != nil {
+			return nil, err
+		}
+
+		if data.PollID < 1 {
+			return nil, invalidInputError{"poll_id has to be a positive number"}
+		}
+
+		meetingID, err := fetch.Poll_MeetingID(data.PollID).Value(ctx)
+		if err != nil {
+			var errNotExist dsfetch.DoesNotExistError
+			if errors.As(err, &errNotExist) {
+				return nil, permissionDeniedError{fmt.Sprintf("you can not see poll %d", data.PollID)}
+			}
+			return nil, fmt.Errorf("getting meeting of poll: %w", err)
+		}
+
+		ds := req.Unrestricted
+		groupIDs, err := ds.Poll_EntitledGroupIDs(data.PollID).Value(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting entitled groups: %w", err)
+		}
+
+		groupMembers := make([][]int, len(groupIDs))
+		for i, groupID := range groupIDs {
+			ds.Group_MeetingUserIDs(groupID).Lazy(&groupMembers[i])
+		}
+		if err := ds.Execute(ctx); err != nil {
+			return nil, fmt.Errorf("getting members of entitled groups: %w", err)
+		}
+
+		meetingUserIDs := set.New[int]()
+		for _, members := range groupMembers {
+			meetingUserIDs.Add(members...)
+		}
+
+		userIDs := make([]int, meetingUserIDs.Len())
+		for i, meetingUserID := range meetingUserIDs.List() {
+			ds.MeetingUser_UserID(meetingUserID).Lazy(&userIDs[i])
+		}
+		if err := ds.Execute(ctx); err != nil {
+			return nil, fmt.Errorf("getting users of entitled groups: %w", err)
+		}
+
+		entitled := set.New(userIDs...)
+		presentIn := make([][]int, entitled.Len())
+		for i, userID := range entitled.List() {
+			ds.User_IsPresentInMeetingIDs(userID).Lazy(&presentIn[i])
+		}
+		if err := ds.Execute(ctx); err != nil {
+			return nil, fmt.Errorf("getting present users: %w", err)
+		}
+
+		var present int
+		for _, meetingIDs := range presentIn {
+			for _, id := range meetingIDs {
+				if id == meetingID {
+					present++
+					break
+				}
+			}
+		}
+
+		return struct {
+			Entitled int `json:"entitled_users"`
+			Present  int `json:"present_entitled_users"`
+		}{entitled.Len(), present}, nil
+	})
+}
@@ -0,0 +1,25 @@
+package presenter
+
+type permissionDeniedError struct {
+	msg string
+}
+
+func (e permissionDeniedError) Error() string {
+	return e.msg
+}
+
+func (e permissionDeniedError) Type() string {
+	return "permission_denied"
+}
+
+type invalidInputError struct {
+	msg string
+}
+
+func (e invalidInputError) Error() string {
+	return e.msg
+}
+
+func (e invalidInputError) Type() string {
+	return "invalid_input"
+}
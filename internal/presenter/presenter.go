@@ -0,0 +1,80 @@
+// Package presenter contains read endpoints, that are computed on the server.
+//
+// A presenter aggregates data, that a client would otherwise have to load
+// completely, for example the number of eligible voters of a poll. Presenters
+// are registered by name in a Store, like the slides of the projector.
+package presenter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
+)
+
+// Request is the input of a presenter.
+type Request struct {
+	// UserID is the user, that called the presenter. It is 0 for anonymous.
+	UserID int
+
+	// Data are the arguments of the presenter as sent by the client.
+	Data json.RawMessage
+
+	// Unrestricted reads the data without the restricter. A presenter, that
+	// uses it, has to check the permissions of the user itself and must only
+	// return aggregated values.
+	Unrestricted *dsfetch.Fetch
+}
+
+// Func computes the result of a presenter. fetch only returns the data, that
+// the user can see.
+type Func func(ctx context.Context, fetch *dsfetch.Fetch, req Request) (any, error)
+
+// Store holds the presenters by name.
+type Store struct {
+	presenters map[string]Func
+}
+
+// Register adds a presenter to the store.
+func (s *Store) Register(name string, f Func) {
+	if s.presenters == nil {
+		s.presenters = make(map[string]Func)
+	}
+
+	if _, ok := s.presenters[name]; ok {
+		panic(fmt.Sprintf("Presenter with name %s does already exist", name))
+	}
+	s.presenters[name] = f
+}
+
+// Get returns the presenter with the name.
+//
+// Returns nil, if the name is unknown.
+func (s *Store) Get(name string) Func {
+	return s.presenters[name]
+}
+
+// Presenters returns all presenters of OpenSlides.
+func Presenters() *Store {
+	s := new(Store)
+	EligibleVoters(s)
+	SpeakerStatistics(s)
+	return s
+}
+
+// decodeData decodes the data of a request. Unknown fields are an error, so a
+// typo of the client is not ignored.
+func decodeData(data json.RawMessage, v any) error {
+	if len(data) == 0 {
+		return invalidInputError{"the presenter needs data"}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return invalidInputError{fmt.Sprintf("invalid data: %v", err)}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package presenter_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/presenter"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+)
+
+var testData = dsmock.YAMLData(`---
+meeting/1:
+	id: 1
+	speaker_ids: [1, 2, 3, 4]
+
+poll/5:
+	meeting_id: 1
+	entitled_group_ids: [10, 11]
+
+group/10:
+	meeting_user_ids: [20, 21]
+	permissions: [list_of_speakers.can_see]
+group/11/meeting_user_ids: [21, 22]
+
+meeting_user/20:
+	user_id: 30
+	meeting_id: 1
+	group_ids: [10]
+meeting_user/21/user_id: 31
+meeting_user/22/user_id: 32
+
+user/30:
+	is_present_in_meeting_ids: [1]
+	meeting_user_ids: [20]
+user/31/is_present_in_meeting_ids: [2]
+user/32/is_present_in_meeting_ids: [2, 1]
+
+speaker/1:
+	begin_time: 1000
+	end_time: 1100
+	meeting_user_id: 20
+speaker/2:
+	begin_time: 2000
+	end_time: 2300
+	total_pause: 100
+	meeting_user_id: 21
+speaker/3:
+	begin_time: 3000
+	meeting_user_id: 20
+speaker/4/meeting_user_id: 22
+`)
+
+func present(t *testing.T, name string, uid int, data string) (string, error) {
+	t.Helper()
+
+	f := presenter.Presenters().Get(name)
+	if f == nil {
+		t.Fatalf("presenter %s does not exist", name)
+	}
+
+	ds := dsfetch.New(dsmock.Stub(testData))
+	result, err := f(context.Background(), ds, presenter.Request{UserID: uid, Data: []byte(data), Unrestricted: ds})
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("encoding result: %v", err)
+	}
+	return string(encoded), nil
+}
+
+func TestEligibleVoters(t *testing.T) {
+	got, err := present(t, "eligible_voters", 30, `{"poll_id": 5}`)
+	if err != nil {
+		t.Fatalf("eligible_voters: %v", err)
+	}
+
+	if expect := `{"entitled_users":3,"present_entitled_users":2}`; got != expect {
+		t.Errorf("got %s, expected %s", got, expect)
+	}
+
+	for _, data := range []string{``, `{"poll_id": 0}`, `{"poll": 5}`, `{"poll_id": 404}`} {
+		var errClient interface{ Type() string }
+		if _, err := present(t, "eligible_voters", 30, data); !errors.As(err, &errClient) {
+			t.Errorf("data `%s`: got %v, expected a client error", data, err)
+		}
+	}
+}
+
+func TestSpeakerStatistics(t *testing.T) {
+	defer clock.Use(clock.NewFake(time.Unix(3050, 0)))()
+
+	got, err := present(t, "speaker_statistics", 30, `{"meeting_id": 1}`)
+	if err != nil {
+		t.Fatalf("speaker_statistics: %v", err)
+	}
+
+	expect := `{"speeches":3,"total_duration":350,"speakers":[{"meeting_user_id":21,"speeches":1,"duration":200},{"meeting_user_id":20,"speeches":2,"duration":150}]}`
+	if got != expect {
+		t.Errorf("got %s, expected %s", got, expect)
+	}
+
+	var errClient interface{ Type() string }
+	if _, err := present(t, "speaker_statistics", 31, `{"meeting_id": 1}`); !errors.As(err, &errClient) || errClient.Type() != "permission_denied" {
+		t.Errorf("user without permission: got %v, expected permission denied", err)
+	}
+}
+
+func TestStoreRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("second Register did not panic")
+		}
+	}()
+
+	s := presenter.Presenters()
+	s.Register("eligible_voters", nil)
+}
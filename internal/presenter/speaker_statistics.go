@@ -0,0 +1,125 @@
+package presenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict/perm"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
+)
+
+// speakerStatistic is the speaking time of one participant.
+type speakerStatistic struct {
+	MeetingUserID int   `json:"meeting_user_id"`
+	Speeches      int   `json:"speeches"`
+	Duration      int64 `json:"duration"`
+}
+
+// SpeakerStatistics returns the number of speeches and the speaking time in
+// seconds of each participant of a meeting. A running speech counts until now.
+//
+// The user needs the permission list_of_speakers.can_see. The data is
+// `{"meeting_id": 1}`.
+func SpeakerStatistics(store *Store) {
+	store.Register("speaker_statistics", func(ctx context.Context, _ *dsfetch.Fetch, req Request) (any, error) {
+		var data struct {
+			MeetingID int `json:"meeting_id"`
+		}
+		if err := decodeData(req.Data, &data); err != nil {
+			return nil, err
+		}
+
+		if data.MeetingID < 1 {
+			return nil, invalidInputError{"meeting_id has to be a positive number"}
+		}
+
+		ds := req.Unrestricted
+		speakerIDs, err := ds.Meeting_SpeakerIDs(data.MeetingID).Value(ctx)
+		if err != nil {
+			var errNotExist dsfetch.DoesNotExistError
+			if errors.As(err, &errNotExist) {
+				return nil, invalidInputError{fmt.Sprintf("meeting %d does not exist", data.MeetingID)}
+			}
+			return nil, fmt.Errorf("getting speakers: %w", err)
+		}
+
+		p, err := perm.New(ctx, ds, req.UserID, data.MeetingID)
+		if err != nil {
+			return nil, fmt.Errorf("getting permissions: %w", err)
+		}
+
+		if !p.Has(perm.ListOfSpeakersCanSee) {
+			return nil, permissionDeniedError{fmt.Sprintf("you can not see the lists of speakers of meeting %d", data.MeetingID)}
+		}
+
+		type speaker struct {
+			begin, end, pause, totalPause int
+			meetingUserID                 dsfetch.Maybe[int]
+		}
+		speakers := make([]speaker, len(speakerIDs))
+		for i, id := range speakerIDs {
+			ds.Speaker_BeginTime(id).Lazy(&speakers[i].begin)
+			ds.Speaker_EndTime(id).Lazy(&speakers[i].end)
+			ds.Speaker_PauseTime(id).Lazy(&speakers[i].pause)
+			ds.Speaker_TotalPause(id).Lazy(&speakers[i].totalPause)
+			ds.Speaker_MeetingUserID(id).Lazy(&speakers[i].meetingUserID)
+		}
+		if err := ds.Execute(ctx); err != nil {
+			return nil, fmt.Errorf("getting speeches: %w", err)
+		}
+
+		now := clock.Now().Unix()
+		var speeches int
+		var total int64
+		byUser := make(map[int]*speakerStatistic)
+		for _, s := range speakers {
+			if s.begin == 0 {
+				// Waiting on the list.
+				continue
+			}
+
+			end := int64(s.end)
+			if end == 0 {
+				end = now
+				if s.pause > 0 {
+					end = int64(s.pause)
+				}
+			}
+			duration := max(end-int64(s.begin)-int64(s.totalPause), 0)
+
+			speeches++
+			total += duration
+
+			meetingUserID, ok := s.meetingUserID.Value()
+			if !ok {
+				continue
+			}
+
+			if byUser[meetingUserID] == nil {
+				byUser[meetingUserID] = &speakerStatistic{MeetingUserID: meetingUserID}
+			}
+			byUser[meetingUserID].Speeches++
+			byUser[meetingUserID].Duration += duration
+		}
+
+		statistics := make([]speakerStatistic, 0, len(byUser))
+		for _, s := range byUser {
+			statistics = append(statistics, *s)
+		}
+		sort.Slice(statistics, func(i, j int) bool {
+			if statistics[i].Duration != statistics[j].Duration {
+				return statistics[i].Duration > statistics[j].Duration
+			}
+			return statistics[i].MeetingUserID < statistics[j].MeetingUserID
+		})
+
+		return struct {
+			Speeches      int                `json:"speeches"`
+			TotalDuration int64              `json:"total_duration"`
+			Speakers      []speakerStatistic `json:"speakers"`
+		}{speeches, total, statistics}, nil
+	})
+}
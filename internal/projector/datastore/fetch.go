@@ -7,6 +7,7 @@ import (
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsfetch"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/fastjson"
 )
 
 // Fetcher is a helper to fetch many keys from the datastore.
@@ -54,7 +55,7 @@ func (f *Fetcher) Fetch(ctx context.Context, value interface{}, keyFmt string, a
 		return
 	}
 
-	if err := json.Unmarshal(fields[fqfield], value); err != nil {
+	if err := fastjson.Unmarshal(fields[fqfield], value); err != nil {
 		f.err = fmt.Errorf("unpacking value of %q: %w", fqfield, err)
 	}
 }
@@ -88,7 +89,7 @@ func (f *Fetcher) FetchIfExist(ctx context.Context, value interface{}, keyFmt st
 		return
 	}
 
-	if err := json.Unmarshal(fields[fqfield], value); err != nil {
+	if err := fastjson.Unmarshal(fields[fqfield], value); err != nil {
 		f.err = fmt.Errorf("unpacking value of %q: %w", fqfield, err)
 	}
 }
@@ -1,28 +1,32 @@
 package projector
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"hash/fnv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/errorreport"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector/datastore"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
-	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsrecorder"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
 )
 
 const longCalculation = time.Second
 
+var logger = logging.For(logging.Projector)
+
 // NewProjector initializes a new Projector.
 func NewProjector(ds flow.Flow, slides *SlideStore) *Projector {
 	return &Projector{
-		hotKeys: make(map[dskey.Key]map[dskey.Key]struct{}),
+		hotKeys: make(map[dskey.Key]map[dskey.Key]uint64),
 		cache:   make(map[dskey.Key][]byte),
 
 		flow:   ds,
@@ -35,14 +39,15 @@ func NewProjector(ds flow.Flow, slides *SlideStore) *Projector {
 // When such a key is requested with Get, it gets calculated.
 //
 // When keys get updated via Update, that where needed to calculate a field, the
-// field is updated.
+// field is updated. For this, the hash of each needed value is saved. An update,
+// that does not change one of these values, does not recalculate the field.
 //
 // Only projections with a current_projector_id get calculated. Fields from
 // other projections return nil. If current_projector_id get updated to nil/0,
 // then the field is removed from the cache.
 type Projector struct {
 	mu      sync.RWMutex
-	hotKeys map[dskey.Key]map[dskey.Key]struct{}
+	hotKeys map[dskey.Key]map[dskey.Key]uint64
 	cache   map[dskey.Key][]byte
 
 	flow   flow.Flow
@@ -54,7 +59,7 @@ func (p *Projector) Reset() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.cache = make(map[dskey.Key][]byte)
-	p.hotKeys = make(map[dskey.Key]map[dskey.Key]struct{})
+	p.hotKeys = make(map[dskey.Key]map[dskey.Key]uint64)
 }
 
 // Get is a Getter middleware that passes all keys though but calculates
@@ -119,6 +124,12 @@ func (p *Projector) Update(ctx context.Context, updateFn func(map[dskey.Key][]by
 
 		for _, key := range needUpdate {
 			value := p.calculate(ctx, key)
+			if old, ok := p.cache[key]; ok && bytes.Equal(old, value) {
+				// Only values of the recorded keys changed, but not the
+				// result. There is no need to send it to the clients.
+				continue
+			}
+
 			data[key] = value
 			p.cache[key] = value
 		}
@@ -127,11 +138,18 @@ func (p *Projector) Update(ctx context.Context, updateFn func(map[dskey.Key][]by
 	})
 }
 
+// needUpdate returns the calculated keys, where at least one needed value has
+// changed.
 func (p *Projector) needUpdate(data map[dskey.Key][]byte) []dskey.Key {
+	hashes := make(map[dskey.Key]uint64, len(data))
+	for key, value := range data {
+		hashes[key] = valueHash(value)
+	}
+
 	var needUpdate []dskey.Key
-	for calculated := range p.hotKeys {
-		for key := range data {
-			if _, ok := p.hotKeys[calculated][key]; ok {
+	for calculated, needed := range p.hotKeys {
+		for key, hash := range hashes {
+			if oldHash, ok := needed[key]; ok && oldHash != hash {
 				needUpdate = append(needUpdate, calculated)
 				break
 			}
@@ -158,7 +176,10 @@ func splitKeys(keys []dskey.Key) ([]dskey.Key, []dskey.Key) {
 func (p *Projector) calculate(ctx context.Context, fqfield dskey.Key) []byte {
 	bs, err := p.calculateHelper(ctx, fqfield)
 	if err != nil {
-		oserror.Handle(fmt.Errorf("Error calculating key %s: %v", fqfield, err))
+		if !oserror.ContextDone(err) {
+			logger.Error("Calculating key failed", "key", fqfield.String(), "error", err)
+			errorreport.Capture(ctx, err, map[string]string{"key": fqfield.String()})
+		}
 		msg := fmt.Sprintf("calculating key %s", fqfield)
 		return []byte(fmt.Sprintf(`{"error": "%s"}`, msg))
 	}
@@ -167,13 +188,13 @@ func (p *Projector) calculate(ctx context.Context, fqfield dskey.Key) []byte {
 }
 
 func (p *Projector) calculateHelper(ctx context.Context, fqfield dskey.Key) ([]byte, error) {
-	recorder := dsrecorder.New(p.flow)
+	recorder := newHashRecorder(p.flow)
 	fetch := datastore.NewFetcher(recorder)
 
 	defer func() {
 		// At the end, save all requested keys to check later if one has
 		// changed.
-		p.hotKeys[fqfield] = recorder.Keys()
+		p.hotKeys[fqfield] = recorder.hashes
 	}()
 
 	data := fetch.Object(
@@ -205,10 +226,26 @@ func (p *Projector) calculateHelper(ctx context.Context, fqfield dskey.Key) ([]b
 
 	if p7on.ContentObjectID == "" {
 		// There are broken projections in the datastore. Ignore them.
-		log.Printf("Bug in Backend: The projection %d has an empty content_object_id", p7on.ID)
+		logger.Warn("Bug in Backend: projection has an empty content_object_id", "projection_id", p7on.ID)
+		errorreport.Capture(ctx, fmt.Errorf("projection %d has an empty content_object_id", p7on.ID), map[string]string{"kind": "datastore_inconsistency"})
 		return nil, nil
 	}
 
+	return p.render(ctx, fetch, p7on)
+}
+
+// Preview calculates the content of a projection, that does not exist in the
+// datastore. The result is not cached.
+func (p *Projector) Preview(ctx context.Context, p7on *Projection) ([]byte, error) {
+	if p7on.ContentObjectID == "" {
+		return nil, fmt.Errorf("content_object_id is required")
+	}
+
+	return p.render(ctx, datastore.NewFetcher(p.flow), p7on)
+}
+
+// render calculates the content of a projection with its slide.
+func (p *Projector) render(ctx context.Context, fetch *datastore.Fetcher, p7on *Projection) ([]byte, error) {
 	slideName, err := p7on.slideName()
 	if err != nil {
 		return nil, fmt.Errorf("getting slide name: %w", err)
@@ -292,3 +329,37 @@ func (p *Projection) slideName() (string, error) {
 	}
 	return parts[0], nil
 }
+
+// hashRecorder is a getter that records the hash of all fetched values.
+type hashRecorder struct {
+	getter flow.Getter
+	hashes map[dskey.Key]uint64
+}
+
+func newHashRecorder(getter flow.Getter) *hashRecorder {
+	return &hashRecorder{
+		getter: getter,
+		hashes: make(map[dskey.Key]uint64),
+	}
+}
+
+// Get fetches the keys and records the hashes of the values.
+func (r *hashRecorder) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	data, err := r.getter.Get(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		r.hashes[key] = valueHash(data[key])
+	}
+	return data, nil
+}
+
+// valueHash returns the hash of a value. A nil value and an empty value have
+// the same hash, since both mean, that the key does not exist.
+func valueHash(value []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(value)
+	return h.Sum64()
+}
@@ -303,6 +303,62 @@ func TestProjectionUpdateOtherKey(t *testing.T) {
 	}
 }
 
+func TestProjectionUpdateSameValue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := dsmock.NewFlow(dsmock.YAMLData(`---
+		projection/1:
+			type:                 counted
+			content_object_id:    meeting/1
+			current_projector_id: 1
+		user/1/username: hugo
+	`))
+	key := dskey.MustKey("projection/1/content")
+
+	var calls int
+	slides := new(projector.SlideStore)
+	slides.RegisterSliderFunc("counted", func(ctx context.Context, fetch *datastore.Fetcher, p7on *projector.Projection) (encoded []byte, err error) {
+		calls++
+		var field json.RawMessage
+		fetch.Fetch(ctx, &field, "user/1/username")
+		return []byte(fmt.Sprintf(`{"value":%s}`, field)), nil
+	})
+	p := projector.NewProjector(flow, slides)
+
+	updates := make(chan map[dskey.Key][]byte)
+	go p.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		updates <- data
+	})
+
+	// Fetch data once to fill the hot keys.
+	if _, err := p.Get(ctx, key); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	flow.Send(dsmock.YAMLData("user/1/username: hugo"))
+	data := <-updates
+
+	if calls != 1 {
+		t.Errorf("slide was calculated %d times, expected 1", calls)
+	}
+
+	if _, ok := data[key]; ok {
+		t.Errorf("update contains the unchanged content")
+	}
+
+	flow.Send(dsmock.YAMLData("user/1/username: gustav"))
+	data = <-updates
+
+	if calls != 2 {
+		t.Errorf("slide was calculated %d times, expected 2", calls)
+	}
+
+	if equal, explain := cmpJson(data[key], []byte(`{"collection":"counted","value":"gustav"}`)); !equal {
+		t.Errorf("got != expect: %s", explain)
+	}
+}
+
 func TestOnTwoProjections(t *testing.T) {
 	// Test that when reading two different projections at the same time in
 	// different goroutines, there is no race condition.
@@ -349,6 +405,77 @@ func TestOnTwoProjections(t *testing.T) {
 	wg.Wait()
 }
 
+func TestPreview(t *testing.T) {
+	ctx := context.Background()
+	flow := dsmock.NewFlow(dsmock.YAMLData(`---
+	user/1/username: hugo
+	`))
+	p := projector.NewProjector(flow, testSlides())
+
+	got, err := p.Preview(ctx, &projector.Projection{ContentObjectID: "user/1", MeetingID: 1})
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+
+	expect := []byte(`{"collection":"user","value":"calculated with hugo"}`)
+	if equal, explain := cmpJson(got, expect); !equal {
+		t.Errorf("got != expect: %s", explain)
+	}
+
+	if _, err := p.Preview(ctx, &projector.Projection{ContentObjectID: "unknown/1"}); err == nil {
+		t.Errorf("Preview with unknown slide returned no error")
+	}
+}
+
+func TestPluginSlide(t *testing.T) {
+	ctx := context.Background()
+	projector.RegisterPluginSlide("test_plugin", "hello", func(ctx context.Context, fetch *datastore.Fetcher, p7on *projector.Projection) (encoded []byte, err error) {
+		return []byte(`{"value":"hello"}`), nil
+	})
+
+	flow := dsmock.NewFlow(dsmock.YAMLData(`---
+	projection/1:
+		type:                 test_plugin:hello
+		content_object_id:    meeting/1
+		current_projector_id: 1
+	`))
+	key := dskey.MustKey("projection/1/content")
+
+	slides := testSlides()
+	projector.PluginSlides(slides)
+	p := projector.NewProjector(flow, slides)
+
+	got, err := p.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	expect := []byte(`{"collection":"test_plugin:hello","value":"hello"}`)
+	if equal, explain := cmpJson(got[key], expect); !equal {
+		t.Errorf("got != expect: %s", explain)
+	}
+}
+
+func TestPluginSlideName(t *testing.T) {
+	for _, tt := range []struct {
+		namespace string
+		name      string
+		valid     bool
+	}{
+		{"seating", "plan", true},
+		{"my_plugin", "widget_2", true},
+		{"", "plan", false},
+		{"seating", "", false},
+		{"seat:ing", "plan", false},
+		{"Seating", "plan", false},
+	} {
+		_, err := projector.PluginSlideName(tt.namespace, tt.name)
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("PluginSlideName(%q, %q) returned error %v", tt.namespace, tt.name, err)
+		}
+	}
+}
+
 func testSlides() *projector.SlideStore {
 	s := new(projector.SlideStore)
 	s.RegisterSliderFunc("test1", func(ctx context.Context, fetch *datastore.Fetcher, p7on *projector.Projection) (encoded []byte, err error) {
@@ -13,6 +13,7 @@ import (
 
 type dbMeeting struct {
 	ID                                        int    `json:"id"`
+	Name                                      string `json:"name"`
 	MotionsEnableTextOnProjector              bool   `json:"motions_enable_text_on_projector"`
 	MotionsEnableReasonOnProjector            bool   `json:"motions_enable_reason_on_projector"`
 	MotionsShowReferringMotions               bool   `json:"motions_show_referring_motions"`
@@ -87,3 +88,79 @@ func WiFiAccessData(store *projector.SlideStore) {
 		return responseValue, nil
 	})
 }
+
+// wifiQRPayload returns the content of a QR code, that lets a phone join the
+// wifi network.
+//
+// Returns an empty string, if there is no ssid.
+func wifiQRPayload(ssid, password, encryption string) string {
+	if ssid == "" {
+		return ""
+	}
+
+	escape := strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+	if encryption == "" || encryption == "nopass" || password == "" {
+		return fmt.Sprintf("WIFI:T:nopass;S:%s;;", escape.Replace(ssid))
+	}
+	return fmt.Sprintf("WIFI:T:%s;S:%s;P:%s;;", escape.Replace(encryption), escape.Replace(ssid), escape.Replace(password))
+}
+
+// MeetingAccessData renders the meeting_access_data slide.
+//
+// The slide contains everything a participant needs to join the meeting: The
+// url of the meeting, the wifi credentials and the payloads for QR codes of
+// both.
+func MeetingAccessData(store *projector.SlideStore) {
+	store.RegisterSliderFunc("meeting_access_data", func(ctx context.Context, fetch *datastore.Fetcher, p7on *projector.Projection) (encoded []byte, err error) {
+		meetingID, err := strconv.Atoi(strings.Split(p7on.ContentObjectID, "/")[1])
+		if err != nil {
+			return nil, fmt.Errorf("convert string to int: %w", err)
+		}
+
+		meeting, err := getMeeting(ctx, fetch, meetingID, []string{
+			"name",
+			"users_pdf_wlan_ssid",
+			"users_pdf_wlan_password",
+			"users_pdf_wlan_encryption",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting meeting: %w", err)
+		}
+
+		var url string
+		if organizationURL := datastore.String(ctx, fetch.Fetch, "organization/1/url"); organizationURL != "" {
+			url = fmt.Sprintf("%s/%d", strings.TrimRight(organizationURL, "/"), meetingID)
+		}
+
+		type qrPayload struct {
+			URL  string `json:"url,omitempty"`
+			WiFi string `json:"wifi,omitempty"`
+		}
+
+		out := struct {
+			MeetingName            string    `json:"meeting_name,omitempty"`
+			URL                    string    `json:"url,omitempty"`
+			UsersPdfWLANSSID       string    `json:"users_pdf_wlan_ssid,omitempty"`
+			UsersPdfWLANPassword   string    `json:"users_pdf_wlan_password,omitempty"`
+			UsersPdfWLANEncryption string    `json:"users_pdf_wlan_encryption,omitempty"`
+			QR                     qrPayload `json:"qr"`
+		}{
+			MeetingName:            meeting.Name,
+			URL:                    url,
+			UsersPdfWLANSSID:       meeting.UsersPdfWLANSSID,
+			UsersPdfWLANPassword:   meeting.UsersPdfWLANPassword,
+			UsersPdfWLANEncryption: meeting.UsersPdfWLANEncryption,
+			QR: qrPayload{
+				URL:  url,
+				WiFi: wifiQRPayload(meeting.UsersPdfWLANSSID, meeting.UsersPdfWLANPassword, meeting.UsersPdfWLANEncryption),
+			},
+		}
+
+		responseValue, err := json.Marshal(out)
+		if err != nil {
+			return nil, fmt.Errorf("encoding response slide meeting_access_data: %w", err)
+		}
+		return responseValue, nil
+	})
+}
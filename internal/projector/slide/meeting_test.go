@@ -69,3 +69,88 @@ func TestMeeting(t *testing.T) {
 		})
 	}
 }
+
+func TestMeetingAccessData(t *testing.T) {
+	s := new(projector.SlideStore)
+	slide.MeetingAccessData(s)
+
+	accessSlide := s.GetSlider("meeting_access_data")
+	assert.NotNilf(t, accessSlide, "Slide with name `meeting_access_data` not found.")
+
+	for _, tt := range []struct {
+		name   string
+		data   map[dskey.Key][]byte
+		expect string
+	}{
+		{
+			"All data filled in",
+			dsmock.YAMLData(`
+			organization/1/url: https://example.com/
+			meeting/1:
+				name: Annual Meeting
+				users_pdf_wlan_encryption: WPA
+				users_pdf_wlan_password: Super;StrongP455Word
+				users_pdf_wlan_ssid: RandomWiWi
+			`),
+			`{
+				"meeting_name": "Annual Meeting",
+				"url": "https://example.com/1",
+				"users_pdf_wlan_encryption": "WPA",
+				"users_pdf_wlan_password": "Super;StrongP455Word",
+				"users_pdf_wlan_ssid": "RandomWiWi",
+				"qr": {
+					"url": "https://example.com/1",
+					"wifi": "WIFI:T:WPA;S:RandomWiWi;P:Super\\;StrongP455Word;;"
+				}
+			}
+			`,
+		},
+		{
+			"Open network",
+			dsmock.YAMLData(`
+			meeting/1:
+				name: Annual Meeting
+				users_pdf_wlan_ssid: Guest
+			`),
+			`{
+				"meeting_name": "Annual Meeting",
+				"users_pdf_wlan_ssid": "Guest",
+				"qr": {
+					"wifi": "WIFI:T:nopass;S:Guest;;"
+				}
+			}
+			`,
+		},
+		{
+			"No wifi",
+			dsmock.YAMLData(`
+			organization/1/url: https://example.com
+			meeting/1/name: Annual Meeting
+			`),
+			`{
+				"meeting_name": "Annual Meeting",
+				"url": "https://example.com/1",
+				"qr": {
+					"url": "https://example.com/1"
+				}
+			}
+			`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := dsmock.Stub(tt.data)
+			fetch := datastore.NewFetcher(ds)
+
+			p7on := &projector.Projection{
+				ContentObjectID: "meeting/1",
+				Type:            "meeting_access_data",
+				MeetingID:       1,
+			}
+
+			bs, err := accessSlide.Slide(context.Background(), fetch, p7on)
+			assert.NoError(t, err)
+			assert.NoError(t, fetch.Err())
+			assert.JSONEq(t, tt.expect, string(bs))
+		})
+	}
+}
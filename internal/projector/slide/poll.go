@@ -68,9 +68,10 @@ func optionGlobFromMap(in map[string]json.RawMessage) (*optionGlobRepr, error) {
 
 // Contains fields to be read, but never exported
 type dbPollWork struct {
-	OptionIDS      []int `json:"option_ids"`
-	MeetingID      int   `json:"meeting_id"`
-	GlobalOptionID int   `json:"global_option_id"`
+	OptionIDS        []int `json:"option_ids"`
+	MeetingID        int   `json:"meeting_id"`
+	GlobalOptionID   int   `json:"global_option_id"`
+	EntitledGroupIDs []int `json:"entitled_group_ids"`
 }
 
 type dbPoll struct {
@@ -93,6 +94,7 @@ type dbPoll struct {
 	Votesinvalid          *string          `json:"votesinvalid,omitempty"` // Python-DecimalField
 	Votescast             *string          `json:"votescast,omitempty"`    // Python-DecimalField
 	GlobalOption          *optionGlobRepr  `json:"global_option,omitempty"`
+	Result                *pollResult      `json:"result,omitempty"`
 	PollWork              *dbPollWork      `json:",omitempty"`
 }
 
@@ -141,6 +143,7 @@ func Poll(store *projector.SlideStore) {
 				"votesinvalid",
 				"votescast",
 				"global_option_id",
+				"entitled_group_ids",
 			}...)
 		}
 		data := fetch.Object(ctx, p7on.ContentObjectID, fetchFields...)
@@ -164,6 +167,11 @@ func Poll(store *projector.SlideStore) {
 			if err != nil {
 				return nil, fmt.Errorf("get GlobalOption func: %w", err)
 			}
+
+			poll.Result, err = calcPollResult(ctx, fetch, poll, p7on)
+			if err != nil {
+				return nil, fmt.Errorf("calculating poll result: %w", err)
+			}
 		}
 		if err := fetch.Err(); err != nil {
 			return nil, err
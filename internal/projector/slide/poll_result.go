@@ -0,0 +1,249 @@
+package slide
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector/datastore"
+)
+
+// pollResult contains the aggregated numbers of a published poll, so a
+// projector does not have to calculate them.
+type pollResult struct {
+	Options       []optionResult `json:"options"`
+	GlobalOption  *optionResult  `json:"global_option,omitempty"`
+	Entitled      int            `json:"entitled,omitempty"`
+	Voted         int            `json:"voted,omitempty"`
+	Participation *float64       `json:"participation,omitempty"`
+	QuorumReached *bool          `json:"quorum_reached,omitempty"`
+	Groups        []groupResult  `json:"groups,omitempty"`
+}
+
+// optionResult contains the percentages of one option. A percentage is nil, if
+// the onehundred percent base does not allow it.
+type optionResult struct {
+	ID      int      `json:"id,omitempty"`
+	Yes     *float64 `json:"yes,omitempty"`
+	No      *float64 `json:"no,omitempty"`
+	Abstain *float64 `json:"abstain,omitempty"`
+}
+
+// groupResult contains the sum of the vote weights of the members of a group.
+type groupResult struct {
+	ID      int                 `json:"id"`
+	Name    string              `json:"name"`
+	Options []groupOptionResult `json:"options"`
+}
+
+type groupOptionResult struct {
+	ID      int     `json:"id"`
+	Yes     float64 `json:"yes"`
+	No      float64 `json:"no"`
+	Abstain float64 `json:"abstain"`
+}
+
+// entitledUser is one entry of the field poll/entitled_users_at_stop.
+type entitledUser struct {
+	UserID  int  `json:"user_id"`
+	Voted   bool `json:"voted"`
+	Present bool `json:"present"`
+}
+
+// decimal parses a Python-DecimalField. Returns 0 for nil.
+func decimal(value *string) float64 {
+	if value == nil {
+		return 0
+	}
+
+	f, err := strconv.ParseFloat(*value, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// percent returns value relative to base in percent rounded to three decimal
+// places. Returns nil, if the base is not positive.
+func percent(value, base float64) *float64 {
+	if base <= 0 {
+		return nil
+	}
+
+	p := math.Round(value/base*100*1000) / 1000
+	return &p
+}
+
+// calcPollResult aggregates the numbers of a published poll.
+//
+// The percentages are calculated with the onehundred percent base of the poll.
+// The projection option `quorum` is the fraction of entitled users, that have
+// to vote. The votes are only broken down by the entitled groups, if the poll
+// is named, because the vote of each user is public there.
+func calcPollResult(ctx context.Context, fetch *datastore.Fetcher, poll *dbPoll, p7on *projector.Projection) (*pollResult, error) {
+	var options struct {
+		Quorum *float64 `json:"quorum"`
+	}
+	if p7on.Options != nil {
+		if err := json.Unmarshal(p7on.Options, &options); err != nil {
+			return nil, fmt.Errorf("decoding projection options: %w", err)
+		}
+	}
+
+	var result pollResult
+
+	// The field has an old format in some meetings. In this case, the number
+	// of entitled users is unknown.
+	var entitled []entitledUser
+	if poll.EntitledUsersAtStop != nil {
+		if err := json.Unmarshal(*poll.EntitledUsersAtStop, &entitled); err != nil {
+			entitled = nil
+		}
+	}
+
+	var present int
+	for _, user := range entitled {
+		if user.Voted {
+			result.Voted++
+		}
+		if user.Present {
+			present++
+		}
+	}
+	result.Entitled = len(entitled)
+	result.Participation = percent(float64(result.Voted), float64(result.Entitled))
+
+	if options.Quorum != nil && result.Entitled > 0 {
+		reached := float64(result.Voted) >= *options.Quorum*float64(result.Entitled)
+		result.QuorumReached = &reached
+	}
+
+	var base string
+	if poll.OnehundredPercentBase != nil {
+		base = *poll.OnehundredPercentBase
+	}
+
+	var sumYes, sumNo float64
+	for _, option := range poll.Options {
+		sumYes += decimal(option.Yes)
+		sumNo += decimal(option.No)
+	}
+
+	// pollBase is the base for the bases, that do not depend on the option.
+	var pollBase float64
+	switch base {
+	case "valid":
+		pollBase = decimal(poll.Votesvalid)
+	case "cast":
+		pollBase = decimal(poll.Votescast)
+	case "entitled":
+		pollBase = float64(result.Entitled)
+	case "entitled_present":
+		pollBase = float64(present)
+	}
+
+	result.Options = []optionResult{}
+	for _, option := range poll.Options {
+		yes, no, abstain := decimal(option.Yes), decimal(option.No), decimal(option.Abstain)
+
+		or := optionResult{ID: *option.id}
+		switch base {
+		case "Y":
+			or.Yes = percent(yes, sumYes)
+		case "N":
+			or.No = percent(no, sumNo)
+		case "YN":
+			or.Yes = percent(yes, yes+no)
+			or.No = percent(no, yes+no)
+		case "YNA":
+			or.Yes = percent(yes, yes+no+abstain)
+			or.No = percent(no, yes+no+abstain)
+			or.Abstain = percent(abstain, yes+no+abstain)
+		case "valid", "cast", "entitled", "entitled_present":
+			or.Yes = percent(yes, pollBase)
+			or.No = percent(no, pollBase)
+			or.Abstain = percent(abstain, pollBase)
+		}
+		result.Options = append(result.Options, or)
+	}
+
+	if poll.GlobalOption != nil && pollBase > 0 {
+		result.GlobalOption = &optionResult{
+			Yes:     percent(decimal(&poll.GlobalOption.Yes), pollBase),
+			No:      percent(decimal(&poll.GlobalOption.No), pollBase),
+			Abstain: percent(decimal(&poll.GlobalOption.Abstain), pollBase),
+		}
+	}
+
+	if poll.Type == "named" && (poll.IsPseudoanonymized == nil || !*poll.IsPseudoanonymized) {
+		groups, err := groupResults(ctx, fetch, poll)
+		if err != nil {
+			return nil, fmt.Errorf("calculating group results: %w", err)
+		}
+		result.Groups = groups
+	}
+
+	return &result, nil
+}
+
+// groupResults sums the votes of a named poll by the entitled groups of the
+// poll. A user in many groups counts for each of them.
+func groupResults(ctx context.Context, fetch *datastore.Fetcher, poll *dbPoll) ([]groupResult, error) {
+	userGroups := make(map[int][]int)
+	groups := make([]groupResult, len(poll.PollWork.EntitledGroupIDs))
+	for i, groupID := range poll.PollWork.EntitledGroupIDs {
+		groups[i].ID = groupID
+		groups[i].Name = datastore.String(ctx, fetch.FetchIfExist, "group/%d/name", groupID)
+		groups[i].Options = []groupOptionResult{}
+
+		for _, meetingUserID := range datastore.Ints(ctx, fetch.FetchIfExist, "group/%d/meeting_user_ids", groupID) {
+			userID := datastore.Int(ctx, fetch.FetchIfExist, "meeting_user/%d/user_id", meetingUserID)
+			userGroups[userID] = append(userGroups[userID], i)
+		}
+	}
+
+	for optionIdx, option := range poll.Options {
+		for i := range groups {
+			groups[i].Options = append(groups[i].Options, groupOptionResult{ID: *option.id})
+		}
+
+		for _, voteID := range datastore.Ints(ctx, fetch.FetchIfExist, "option/%d/vote_ids", *option.id) {
+			var value string
+			var weight *string
+			fetch.FetchIfExist(ctx, &value, "vote/%d/value", voteID)
+			fetch.FetchIfExist(ctx, &weight, "vote/%d/weight", voteID)
+			userID := datastore.Int(ctx, fetch.FetchIfExist, "vote/%d/user_id", voteID)
+
+			w := decimal(weight)
+			if weight == nil {
+				w = 1
+			}
+
+			for _, groupIdx := range userGroups[userID] {
+				gor := &groups[groupIdx].Options[optionIdx]
+				switch value {
+				case "Y":
+					gor.Yes += w
+				case "N":
+					gor.No += w
+				case "A":
+					gor.Abstain += w
+				}
+			}
+		}
+	}
+
+	if err := fetch.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+
+	return groups, nil
+}
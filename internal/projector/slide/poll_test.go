@@ -2,6 +2,7 @@ package slide_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
@@ -147,6 +148,12 @@ func TestPoll(t *testing.T) {
                     "yes":"14.000000",
                     "no":"15.000000",
                     "abstain":"16.000000"
+                },
+                "result": {
+                    "options": [
+                        {"id": 2, "yes": 41.667, "no": 33.333, "abstain": 25},
+                        {"id": 1, "yes": 26.667, "no": 33.333, "abstain": 40}
+                    ]
                 }
             }
             `,
@@ -213,3 +220,138 @@ func TestPoll(t *testing.T) {
 		})
 	}
 }
+
+func TestPollResult(t *testing.T) {
+	s := new(projector.SlideStore)
+	slide.Poll(s)
+	slide.Motion(s)
+
+	pollSlide := s.GetSlider("poll")
+	assert.NotNilf(t, pollSlide, "Slide with name `poll` not found.")
+
+	data := dsmock.YAMLData(`
+	poll/1:
+	    content_object_id: motion/1
+	    title: Poll
+	    type: named
+	    state: published
+	    option_ids: [1]
+	    is_pseudoanonymized: false
+	    pollmethod: YNA
+	    onehundred_percent_base: valid
+	    votesvalid: "4.000000"
+	    votesinvalid: "0.000000"
+	    votescast: "4.000000"
+	    global_option_id: 2
+	    entitled_group_ids: [7, 8]
+	    meeting_id: 1
+	    entitled_users_at_stop:
+	        - {user_id: 1, voted: true, present: true}
+	        - {user_id: 2, voted: true, present: true}
+	        - {user_id: 3, voted: false, present: false}
+	motion/1/title: Motion
+	option:
+	    1:
+	        yes: "3.000000"
+	        no: "1.000000"
+	        abstain: "0.000000"
+	        weight: 1
+	        vote_ids: [1, 2]
+	    2:
+	        yes: "0.000000"
+	        no: "0.000000"
+	        abstain: "0.000000"
+	vote:
+	    1:
+	        value: "Y"
+	        weight: "3.000000"
+	        user_id: 1
+	    2:
+	        value: "N"
+	        weight: "1.000000"
+	        user_id: 2
+	group:
+	    7:
+	        name: Delegates
+	        meeting_user_ids: [10, 20]
+	    8:
+	        name: Guests
+	        meeting_user_ids: [20]
+	meeting_user/10/user_id: 1
+	meeting_user/20/user_id: 2
+	`)
+
+	for _, tt := range []struct {
+		name    string
+		options string
+		expect  string
+	}{
+		{
+			"without quorum",
+			``,
+			`{
+				"options": [{"id": 1, "yes": 75, "no": 25, "abstain": 0}],
+				"global_option": {"yes": 0, "no": 0, "abstain": 0},
+				"entitled": 3,
+				"voted": 2,
+				"participation": 66.667,
+				"groups": [
+					{"id": 7, "name": "Delegates", "options": [{"id": 1, "yes": 3, "no": 1, "abstain": 0}]},
+					{"id": 8, "name": "Guests", "options": [{"id": 1, "yes": 0, "no": 1, "abstain": 0}]}
+				]
+			}`,
+		},
+		{
+			"quorum reached",
+			`{"quorum": 0.5}`,
+			`{
+				"options": [{"id": 1, "yes": 75, "no": 25, "abstain": 0}],
+				"global_option": {"yes": 0, "no": 0, "abstain": 0},
+				"entitled": 3,
+				"voted": 2,
+				"participation": 66.667,
+				"quorum_reached": true,
+				"groups": [
+					{"id": 7, "name": "Delegates", "options": [{"id": 1, "yes": 3, "no": 1, "abstain": 0}]},
+					{"id": 8, "name": "Guests", "options": [{"id": 1, "yes": 0, "no": 1, "abstain": 0}]}
+				]
+			}`,
+		},
+		{
+			"quorum not reached",
+			`{"quorum": 0.75}`,
+			`{
+				"options": [{"id": 1, "yes": 75, "no": 25, "abstain": 0}],
+				"global_option": {"yes": 0, "no": 0, "abstain": 0},
+				"entitled": 3,
+				"voted": 2,
+				"participation": 66.667,
+				"quorum_reached": false,
+				"groups": [
+					{"id": 7, "name": "Delegates", "options": [{"id": 1, "yes": 3, "no": 1, "abstain": 0}]},
+					{"id": 8, "name": "Guests", "options": [{"id": 1, "yes": 0, "no": 1, "abstain": 0}]}
+				]
+			}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fetch := datastore.NewFetcher(dsmock.Stub(data))
+
+			p7on := &projector.Projection{
+				ContentObjectID: "poll/1",
+			}
+			if tt.options != "" {
+				p7on.Options = []byte(tt.options)
+			}
+
+			bs, err := pollSlide.Slide(context.Background(), fetch, p7on)
+			assert.NoError(t, err)
+
+			var got struct {
+				Result json.RawMessage `json:"result"`
+			}
+			assert.NoError(t, json.Unmarshal(bs, &got))
+			assert.JSONEq(t, tt.expect, string(got.Result))
+		})
+	}
+}
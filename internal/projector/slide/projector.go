@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector/datastore"
@@ -50,6 +51,10 @@ func projectorMessageFromMap(in map[string]json.RawMessage) (*dbProjectorMessage
 }
 
 // ProjectorCountdown renders the projector_countdown slide.
+//
+// The slide contains the server time, when it was calculated. A running
+// countdown is calculated again, when it is started, so clients can use it
+// together with the time route to correct the offset of their clocks.
 func ProjectorCountdown(store *projector.SlideStore) {
 	store.RegisterSliderFunc("projector_countdown", func(ctx context.Context, fetch *datastore.Fetcher, p7on *projector.Projection) (encoded []byte, err error) {
 		data := fetch.Object(ctx, p7on.ContentObjectID, "id", "description", "running", "default_time", "countdown_time", "meeting_id")
@@ -62,7 +67,8 @@ func ProjectorCountdown(store *projector.SlideStore) {
 			return nil, err
 		}
 
-		responseValue, err := json.Marshal(map[string]interface{}{"description": pc.Description, "running": pc.Running, "default_time": pc.DefaultTime, "countdown_time": pc.CountdownTime, "warning_time": pcwarningTime})
+		serverTime := float64(time.Now().UnixMilli()) / 1000
+		responseValue, err := json.Marshal(map[string]interface{}{"description": pc.Description, "running": pc.Running, "default_time": pc.DefaultTime, "countdown_time": pc.CountdownTime, "warning_time": pcwarningTime, "server_time": serverTime})
 		if err != nil {
 			return nil, fmt.Errorf("encoding response for projector countdown slide: %w", err)
 		}
@@ -2,7 +2,9 @@ package slide_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector/datastore"
@@ -53,9 +55,21 @@ func TestProjectorCountdown(t *testing.T) {
 				ContentObjectID: "projector_countdown/1",
 			}
 
+			before := float64(time.Now().UnixMilli()) / 1000
 			bs, err := pcSlide.Slide(context.Background(), fetch, p7on)
 			assert.NoError(t, err)
-			assert.JSONEq(t, tt.expect, string(bs))
+
+			var got map[string]json.RawMessage
+			assert.NoError(t, json.Unmarshal(bs, &got))
+
+			var serverTime float64
+			assert.NoError(t, json.Unmarshal(got["server_time"], &serverTime))
+			assert.GreaterOrEqual(t, serverTime, before)
+			delete(got, "server_time")
+
+			withoutTime, err := json.Marshal(got)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expect, string(withoutTime))
 		})
 	}
 }
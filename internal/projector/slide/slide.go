@@ -4,7 +4,7 @@ import (
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector"
 )
 
-// Slides returns all OpenSlides-Slides.
+// Slides returns all OpenSlides-Slides and the registered plugin slides.
 func Slides() *projector.SlideStore {
 	s := new(projector.SlideStore)
 	AgendaItemList(s)
@@ -24,5 +24,7 @@ func Slides() *projector.SlideStore {
 	User(s)
 	PollCandidateList(s)
 	WiFiAccessData(s)
+	MeetingAccessData(s)
+	projector.PluginSlides(s)
 	return s
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sync"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/projector/datastore"
 )
@@ -77,3 +79,64 @@ func (s *SlideStore) RegisterGetTitleInformationFunc(collection string, f Titler
 func (s *SlideStore) GetTitleInformationFunc(name string) Titler {
 	return s.titles[name]
 }
+
+// plugins holds the slides registered with RegisterPluginSlide.
+var plugins = struct {
+	mu     sync.Mutex
+	slides map[string]SliderFunc
+}{}
+
+var rePluginPart = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// PluginSlideName returns the name of a plugin slide.
+//
+// The name is `namespace:name`. Core slides never contain a colon, so plugin
+// slides can not clash with them. Namespace and name have to be lower case
+// snake case.
+func PluginSlideName(namespace, name string) (string, error) {
+	if !rePluginPart.MatchString(namespace) {
+		return "", fmt.Errorf("invalid namespace `%s`", namespace)
+	}
+
+	if !rePluginPart.MatchString(name) {
+		return "", fmt.Errorf("invalid slide name `%s`", name)
+	}
+
+	return namespace + ":" + name, nil
+}
+
+// RegisterPluginSlide registers a slide, that is not part of the core slides.
+//
+// It is meant to be called from the init function of a plugin package. The
+// plugin package has to be imported by the main package.
+//
+// The slide is used for projections of a meeting with the type
+// `namespace:name`. It panics, if the name is invalid or already registered.
+func RegisterPluginSlide(namespace, name string, f SliderFunc) {
+	slideName, err := PluginSlideName(namespace, name)
+	if err != nil {
+		panic(fmt.Sprintf("Register plugin slide: %v", err))
+	}
+
+	plugins.mu.Lock()
+	defer plugins.mu.Unlock()
+
+	if plugins.slides == nil {
+		plugins.slides = make(map[string]SliderFunc)
+	}
+
+	if _, ok := plugins.slides[slideName]; ok {
+		panic(fmt.Sprintf("Plugin slide with name %s does already exist", slideName))
+	}
+	plugins.slides[slideName] = f
+}
+
+// PluginSlides adds all registered plugin slides to the store.
+func PluginSlides(store *SlideStore) {
+	plugins.mu.Lock()
+	defer plugins.mu.Unlock()
+
+	for name, f := range plugins.slides {
+		store.RegisterSliderFunc(name, f)
+	}
+}
@@ -0,0 +1,144 @@
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envWatchInterval = environment.NewVariable("OPENSLIDES_CONFIG_WATCH_INTERVAL", "10s", "Interval, how often the config file and the config directory are checked for changes. After a change, the settings are reloaded. Zero disables the check.")
+
+// ConfigPather is an environment, that is read from files.
+type ConfigPather interface {
+	ConfigPaths() []string
+}
+
+// WatchFiles checks the config file and the config directory for changes and
+// reloads the settings, when they change. This applies the updates of a
+// mounted Kubernetes ConfigMap.
+//
+// The returned function has to be run in the background.
+func WatchFiles(lookup environment.Environmenter) (func(context.Context, func(error)), error) {
+	interval, err := environment.ParseDuration(envWatchInterval.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envWatchInterval.Key, envWatchInterval.Value(lookup), err)
+	}
+
+	var paths []string
+	if pather, ok := lookup.(ConfigPather); ok {
+		paths = pather.ConfigPaths()
+	}
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		if len(paths) == 0 || interval <= 0 {
+			return
+		}
+
+		last, err := fingerprint(paths)
+		if err != nil {
+			errorHandler(fmt.Errorf("reading config files: %w", err))
+		}
+
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+
+			current, err := fingerprint(paths)
+			if err != nil {
+				errorHandler(fmt.Errorf("reading config files: %w", err))
+				continue
+			}
+
+			if current == last {
+				continue
+			}
+
+			// An invalid config is only reported once. The next change is
+			// tried again.
+			last = current
+
+			names, err := Reload()
+			if err != nil {
+				errorHandler(fmt.Errorf("reload after config change: %w", err))
+				continue
+			}
+
+			logger.Info("Settings reloaded after config change", "settings", names)
+		}
+	}
+
+	return background, nil
+}
+
+// fingerprint returns a hash over the content of the files. A directory is
+// hashed with the names and the content of its visible files.
+func fingerprint(paths []string) (string, error) {
+	h := sha256.New()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return "", err
+			}
+
+			files = files[:0]
+			for _, entry := range entries {
+				if entry.Name()[0] == '.' {
+					continue
+				}
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+			slices.Sort(files)
+		}
+
+		for _, file := range files {
+			if err := hashFile(h, file); err != nil {
+				return "", err
+			}
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(w, "%s\x00", path)
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	fmt.Fprint(w, "\x00")
+	return nil
+}
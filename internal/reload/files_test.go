@@ -0,0 +1,41 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	write("LOG_LEVEL", "info")
+	first, err := fingerprint([]string{dir})
+	if err != nil {
+		t.Fatalf("fingerprint: %v", err)
+	}
+
+	write(".hidden", "ignored")
+	second, err := fingerprint([]string{dir})
+	if err != nil {
+		t.Fatalf("fingerprint: %v", err)
+	}
+	if first != second {
+		t.Errorf("hidden file changed the fingerprint")
+	}
+
+	write("LOG_LEVEL", "debug")
+	third, err := fingerprint([]string{dir})
+	if err != nil {
+		t.Fatalf("fingerprint: %v", err)
+	}
+	if third == second {
+		t.Errorf("changed file did not change the fingerprint")
+	}
+}
@@ -0,0 +1,132 @@
+// Package reload applies a subset of the settings again without a restart.
+//
+// Packages register a function for each setting, that can be reloaded. A
+// reload reads the environment and the config file again. It is started with
+// SIGHUP or with Reload.
+//
+// All settings are parsed, before any of them is applied. If one setting is
+// invalid, nothing is changed.
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/systemd"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var logger = logging.For(logging.Autoupdate)
+
+// Func parses a setting from the environment. The returned function applies
+// the setting. It is only called, if all settings are valid.
+type Func func(lookup environment.Environmenter) (apply func(), err error)
+
+var registry struct {
+	mu        sync.Mutex
+	names     []string
+	funcs     map[string]Func
+	newLookup func() (environment.Environmenter, error)
+}
+
+// Register registers a setting, that can be reloaded.
+//
+// If the name is already registered, the function is replaced.
+func Register(name string, f Func) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if registry.funcs == nil {
+		registry.funcs = make(map[string]Func)
+	}
+
+	if _, ok := registry.funcs[name]; !ok {
+		registry.names = append(registry.names, name)
+	}
+	registry.funcs[name] = f
+}
+
+// SetLookup sets the function, that creates the environment for a reload.
+func SetLookup(newLookup func() (environment.Environmenter, error)) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.newLookup = newLookup
+}
+
+// Reload reads the environment again and applies all registered settings.
+//
+// Returns the names of the reloaded settings.
+func Reload() ([]string, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if registry.newLookup == nil {
+		return nil, fmt.Errorf("reload is not configured")
+	}
+
+	lookup, err := registry.newLookup()
+	if err != nil {
+		return nil, fmt.Errorf("reading configuration: %w", err)
+	}
+
+	applies := make([]func(), 0, len(registry.names))
+	var errs []error
+	for _, name := range registry.names {
+		apply, err := registry.funcs[name](lookup)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		applies = append(applies, apply)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid settings, nothing was changed: %w", errors.Join(errs...))
+	}
+
+	for _, apply := range applies {
+		apply()
+	}
+
+	return slices.Clone(registry.names), nil
+}
+
+// Watch reloads the settings on SIGHUP until the context is done.
+func Watch(ctx context.Context, errorHandler func(error)) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+		}
+
+		if err := systemd.Notify("RELOADING=1"); err != nil {
+			errorHandler(fmt.Errorf("notify systemd: %w", err))
+		}
+
+		names, err := Reload()
+
+		if err := systemd.Notify("READY=1"); err != nil {
+			errorHandler(fmt.Errorf("notify systemd: %w", err))
+		}
+
+		if err != nil {
+			errorHandler(fmt.Errorf("reload on SIGHUP: %w", err))
+			continue
+		}
+
+		logger.Info("Settings reloaded", "settings", names)
+	}
+}
@@ -0,0 +1,76 @@
+package reload
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func setup(t *testing.T, env environment.ForTests) {
+	t.Helper()
+
+	SetLookup(func() (environment.Environmenter, error) { return env, nil })
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		defer registry.mu.Unlock()
+
+		registry.names = nil
+		registry.funcs = nil
+		registry.newLookup = nil
+	})
+}
+
+func setting(key string, target *string) Func {
+	return func(lookup environment.Environmenter) (func(), error) {
+		value := lookup.Getenv(key)
+		if value == "invalid" {
+			return nil, fmt.Errorf("invalid value for `%s`", key)
+		}
+		return func() { *target = value }, nil
+	}
+}
+
+func TestReload(t *testing.T) {
+	setup(t, environment.ForTests{"A": "new a", "B": "new b"})
+
+	a, b := "old a", "old b"
+	Register("a", setting("A", &a))
+	Register("b", setting("B", &b))
+
+	names, err := Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if !slices.Equal(names, []string{"a", "b"}) {
+		t.Errorf("Reload returned %v, expected [a b]", names)
+	}
+
+	if a != "new a" || b != "new b" {
+		t.Errorf("got a=%q b=%q, expected the new values", a, b)
+	}
+}
+
+func TestReloadInvalid(t *testing.T) {
+	setup(t, environment.ForTests{"A": "new a", "B": "invalid"})
+
+	a, b := "old a", "old b"
+	Register("a", setting("A", &a))
+	Register("b", setting("B", &b))
+
+	if _, err := Reload(); err == nil {
+		t.Fatalf("Reload with an invalid setting did not return an error")
+	}
+
+	if a != "old a" || b != "old b" {
+		t.Errorf("got a=%q b=%q, expected the old values", a, b)
+	}
+}
+
+func TestReloadNotConfigured(t *testing.T) {
+	if _, err := Reload(); err == nil {
+		t.Errorf("Reload without lookup did not return an error")
+	}
+}
@@ -0,0 +1,198 @@
+package restrict
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict/collection"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
+)
+
+// relationKind is the type of relation of a field.
+type relationKind int
+
+const (
+	noRelation relationKind = iota
+	relation
+	relationList
+	genericRelation
+	genericRelationList
+)
+
+// cmIndex is the number of a collection mode in collectionModes.
+type cmIndex int
+
+// collectionModes are all collection modes of the restriction tables.
+// cmIndexes is the reverse lookup. The restricter uses the index of a
+// collection mode instead of the two strings of collection.CM.
+//
+// cmOrder is the position of each collection mode, in which the restrict
+// mode functions are called.
+var (
+	collectionModes, cmIndexes = buildCollectionModes()
+	cmOrder                    = buildCMOrder()
+)
+
+func buildCollectionModes() ([]collection.CM, map[collection.CM]cmIndex) {
+	indexes := make(map[collection.CM]cmIndex)
+	for collectionField, mode := range restrictionModes {
+		coll, _, _ := strings.Cut(collectionField, "/")
+		indexes[collection.CM{Collection: coll, Mode: mode}] = 0
+	}
+
+	modes := make([]collection.CM, 0, len(indexes))
+	for cm := range indexes {
+		modes = append(modes, cm)
+	}
+	sort.Slice(modes, func(i, j int) bool { return modes[i].String() < modes[j].String() })
+
+	for i, cm := range modes {
+		indexes[cm] = cmIndex(i)
+	}
+	return modes, indexes
+}
+
+func buildCMOrder() []int {
+	order := make([]int, len(collectionModes))
+	for i, cm := range collectionModes {
+		if id, ok := collectionOrder[cm.String()]; ok {
+			order[i] = id
+		} else if id, ok := collectionOrder[cm.Collection]; ok {
+			order[i] = id
+		}
+	}
+	return order
+}
+
+// cmSets are ids for each collection mode. The index is a cmIndex.
+type cmSets []set.Set[int]
+
+func newCMSets() cmSets {
+	return make(cmSets, len(collectionModes))
+}
+
+func (s cmSets) add(cm cmIndex, ids ...int) {
+	if s[cm].IsNotInitialized() {
+		s[cm] = set.New[int]()
+	}
+	s[cm].Add(ids...)
+}
+
+func (s cmSets) has(cm cmIndex, id int) bool {
+	return s[cm].Has(id)
+}
+
+// fieldInfo are the restriction settings of one collection field.
+type fieldInfo struct {
+	collectionField string
+	mode            string
+	cm              cmIndex
+	kind            relationKind
+
+	// target is the collection mode of the related field for relation and
+	// relation-list fields. targetErr is set, if the related field is
+	// unknown.
+	target    cmIndex
+	targetErr error
+
+	// generic maps the collection of a generic id to the collection mode of
+	// the related field for generic-relation and generic-relation-list
+	// fields.
+	generic map[string]cmIndex
+}
+
+// fieldInfos are the restriction settings indexed by
+// dskey.Key.CollectionFieldID.
+//
+// The restricter looks up each key. Using the number of the key instead of
+// the string "collection/field" saves building the string and hashing it.
+var fieldInfos = buildFieldInfos()
+
+func buildFieldInfos() []fieldInfo {
+	infos := make([]fieldInfo, dskey.CollectionFieldCount)
+	for collectionField, mode := range restrictionModes {
+		if id := collectionFieldID(collectionField); id != -1 {
+			coll, _, _ := strings.Cut(collectionField, "/")
+			infos[id].collectionField = collectionField
+			infos[id].mode = mode
+			infos[id].cm = cmIndexes[collection.CM{Collection: coll, Mode: mode}]
+		}
+	}
+
+	for collectionField, toCollectionField := range relationFields {
+		setRelationTarget(infos, collectionField, relation, toCollectionField)
+	}
+
+	for collectionField, toCollectionField := range relationListFields {
+		setRelationTarget(infos, collectionField, relationList, toCollectionField)
+	}
+
+	for collectionField, toCollectionFieldMap := range genericRelationFields {
+		if id := collectionFieldID(collectionField); id != -1 {
+			infos[id].kind = genericRelation
+			infos[id].generic = genericTargets(toCollectionFieldMap)
+		}
+	}
+
+	for collectionField, toCollectionFieldMap := range genericRelationListFields {
+		if id := collectionFieldID(collectionField); id != -1 {
+			infos[id].kind = genericRelationList
+			infos[id].generic = genericTargets(toCollectionFieldMap)
+		}
+	}
+
+	return infos
+}
+
+func setRelationTarget(infos []fieldInfo, collectionField string, kind relationKind, toCollectionField string) {
+	id := collectionFieldID(collectionField)
+	if id == -1 {
+		return
+	}
+
+	infos[id].kind = kind
+	coll, field, _ := strings.Cut(toCollectionField, "/")
+	fieldMode, err := restrictModeName(coll, field)
+	if err != nil {
+		infos[id].targetErr = fmt.Errorf("building relation field field mode: %w", err)
+		return
+	}
+	infos[id].target = cmIndexes[collection.CM{Collection: coll, Mode: fieldMode}]
+}
+
+// genericTargets returns the collection mode of the related field for each
+// collection of a generic relation. Collections with an unknown field are
+// left out.
+func genericTargets(toCollectionFieldMap map[string]string) map[string]cmIndex {
+	targets := make(map[string]cmIndex, len(toCollectionFieldMap))
+	for coll, field := range toCollectionFieldMap {
+		fieldMode, err := restrictModeName(coll, field)
+		if err != nil {
+			continue
+		}
+		targets[coll] = cmIndexes[collection.CM{Collection: coll, Mode: fieldMode}]
+	}
+	return targets
+}
+
+func collectionFieldID(collectionField string) int {
+	coll, field, _ := strings.Cut(collectionField, "/")
+	return dskey.CollectionFieldID(coll, field)
+}
+
+// fieldInfoFor returns the restriction settings of the key.
+func fieldInfoFor(key dskey.Key) *fieldInfo {
+	return &fieldInfos[key.CollectionFieldID()]
+}
+
+// keyCollectionMode returns the collection mode of the key.
+func keyCollectionMode(key dskey.Key) (cmIndex, error) {
+	info := fieldInfoFor(key)
+	if info.mode == "" {
+		_, err := restrictModeName(key.Collection(), key.Field())
+		return 0, err
+	}
+	return info.cm, nil
+}
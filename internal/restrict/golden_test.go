@@ -0,0 +1,202 @@
+package restrict_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	restrict "github.com/OpenSlides/openslides-autoupdate-service/internal/restrict"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+)
+
+var updateGolden = flag.Bool("update", false, "write the golden files of TestGolden")
+
+// goldenUsers are the user archetypes of testdata/golden/example-data.json.
+var goldenUsers = []struct {
+	name   string
+	userID int
+}{
+	{"anonymous", 0},
+	{"superadmin", 1},
+	{"organization_manager", 2},
+	{"committee_manager", 3},
+	{"meeting_admin", 4},
+	{"delegate", 5},
+	{"guest", 6},
+	{"outsider", 7},
+}
+
+// TestGolden restricts the example data for each user archetype and compares
+// the visible keys with the golden files.
+//
+// After an intended change of the restrictions, the golden files are written
+// with:
+//
+//	go test ./internal/restrict -run TestGolden -update
+func TestGolden(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "golden", "example-data.json"))
+	if err != nil {
+		t.Fatalf("reading example data: %v", err)
+	}
+
+	data, err := exampleKeys(content)
+	if err != nil {
+		t.Fatalf("parsing example data: %v", err)
+	}
+
+	keys := make([]dskey.Key, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	for _, user := range goldenUsers {
+		t.Run(user.name, func(t *testing.T) {
+			ctx, restricter := restrict.Middleware(context.Background(), dsmock.Stub(data), user.userID)
+			restricted, err := restricter.Get(ctx, keys...)
+			if err != nil {
+				t.Fatalf("restrict: %v", err)
+			}
+
+			got, err := encodeGolden(restricted)
+			if err != nil {
+				t.Fatalf("encoding result: %v", err)
+			}
+
+			path := filepath.Join("testdata", "golden", user.name+".json")
+			if *updateGolden {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading golden file: %v. Create it with -update", err)
+			}
+
+			if diff := diffGolden(expected, got); diff != "" {
+				t.Errorf("restricted data of user %d changed (- golden file, + now). Run with -update, if this is intended:\n%s", user.userID, diff)
+			}
+		})
+	}
+}
+
+// exampleKeys converts the content of an example-data.json to keys.
+func exampleKeys(content []byte) (map[dskey.Key][]byte, error) {
+	objects, err := datastore.ParseExampleData(content)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[dskey.Key][]byte)
+	for fqid, fields := range objects {
+		for field, value := range fields {
+			key, err := dskey.FromString(fqid + "/" + field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key %s/%s: %w", fqid, field, err)
+			}
+			data[key] = value
+		}
+	}
+	return data, nil
+}
+
+// encodeGolden writes the visible keys as json object with one key per line,
+// so a change is easy to read in a diff.
+func encodeGolden(data map[dskey.Key][]byte) ([]byte, error) {
+	keys := make([]string, 0, len(data))
+	for k, v := range data {
+		if v != nil {
+			keys = append(keys, k.String())
+		}
+	}
+	sort.Strings(keys)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("{\n")
+	for i, k := range keys {
+		value := new(bytes.Buffer)
+		if err := json.Compact(value, data[dskey.MustKey(k)]); err != nil {
+			return nil, fmt.Errorf("value of %s: %w", k, err)
+		}
+
+		fmt.Fprintf(buf, "  %q: %s", k, value)
+		if i < len(keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// diffGolden returns the lines, that differ between the two golden files.
+func diffGolden(expected, got []byte) string {
+	lines := func(b []byte) map[string]bool {
+		m := make(map[string]bool)
+		for _, line := range strings.Split(string(b), "\n") {
+			m[strings.TrimSuffix(strings.TrimSpace(line), ",")] = true
+		}
+		return m
+	}
+
+	expectedLines := lines(expected)
+	gotLines := lines(got)
+
+	var diff []string
+	for line := range expectedLines {
+		if !gotLines[line] {
+			diff = append(diff, "- "+line)
+		}
+	}
+	for line := range gotLines {
+		if !expectedLines[line] {
+			diff = append(diff, "+ "+line)
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i][2:] < diff[j][2:]
+	})
+	return strings.Join(diff, "\n")
+}
+
+func BenchmarkRestrictExampleData(b *testing.B) {
+	content, err := os.ReadFile(filepath.Join("testdata", "golden", "example-data.json"))
+	if err != nil {
+		b.Fatalf("reading example data: %v", err)
+	}
+
+	data, err := exampleKeys(content)
+	if err != nil {
+		b.Fatalf("parsing example data: %v", err)
+	}
+
+	keys := make([]dskey.Key, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	for _, user := range goldenUsers {
+		b.Run(user.name, func(b *testing.B) {
+			getter := dsmock.Stub(data)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ctx, restricter := restrict.Middleware(context.Background(), getter, user.userID)
+				if _, err := restricter.Get(ctx, keys...); err != nil {
+					b.Fatalf("restrict: %v", err)
+				}
+			}
+		})
+	}
+}
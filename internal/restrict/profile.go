@@ -1,15 +1,56 @@
 package restrict
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 )
 
-const slowCalls = 3 * time.Second
+var envSlowRestrict = environment.NewVariable("AUTOUPDATE_SLOW_RESTRICT_THRESHOLD", "3s", "Restrictions that take longer are logged with the time of each collection. Zero disables the log.")
+
+var logger = logging.For(logging.Restrict)
+
+// restrictTime is the time of each call to the restricter without fetching
+// the data.
+var restrictTime = metric.NewHistogram("restrict_duration", metric.LatencyBuckets...)
+
+// Metric adds the histogram of the restriction time to the metric.
+func Metric(con metric.Container) {
+	restrictTime.Metric(con)
+}
+
+// slowCalls is the duration after which a restriction is logged. Zero
+// disables the log.
+var slowCalls atomic.Int64
+
+func init() {
+	slowCalls.Store(int64(3 * time.Second))
+}
+
+// Configure reads the settings of the restricter from the environment.
+func Configure(lookup environment.Environmenter) error {
+	threshold, err := environment.ParseDuration(envSlowRestrict.Value(lookup))
+	if err != nil {
+		return fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envSlowRestrict.Key, envSlowRestrict.Value(lookup), err)
+	}
+
+	slowCalls.Store(int64(threshold))
+	return nil
+}
+
+// isSlow tells, if a restriction with the given duration has to be logged.
+func isSlow(duration time.Duration) bool {
+	threshold := time.Duration(slowCalls.Load())
+	return threshold > 0 && duration > threshold
+}
 
 type timeCount struct {
 	time  time.Duration
@@ -29,7 +70,10 @@ func (tc timeCount) MarshalJSON() ([]byte, error) {
 	return json.Marshal(decodable)
 }
 
-func profile(request string, duration time.Duration, times map[string]timeCount) {
+// profile logs a slow restriction.
+//
+// The request id and the body of the request are taken from the context.
+func profile(ctx context.Context, uid int, keys int, fetch time.Duration, duration time.Duration, times map[string]timeCount) {
 	timeStrings := make([]string, 0, len(times))
 	for collection, tc := range times {
 		timeStrings = append(timeStrings, fmt.Sprintf("%s: %d keys in %d ms", collection, tc.count, tc.time.Milliseconds()))
@@ -38,5 +82,13 @@ func profile(request string, duration time.Duration, times map[string]timeCount)
 		return timeStrings[i] < timeStrings[j]
 	})
 
-	log.Printf("Profile: Restrict: Slow request:\nProfile: Request: %s\nProfile: Duration: %d ms\nProfile: %s\n", request, duration.Milliseconds(), strings.Join(timeStrings, "\nProfile: "))
+	logger.WarnContext(
+		ctx,
+		"Slow restriction",
+		"user_id", uid,
+		"keys", keys,
+		"fetch_ms", fetch.Milliseconds(),
+		"duration_ms", duration.Milliseconds(),
+		"collections", strings.Join(timeStrings, "; "),
+	)
 }
@@ -3,6 +3,7 @@ package restrict
 //go:generate  sh -c "go run gen_field_def/main.go > field_def.go"
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/accounting"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/arena"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict/collection"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict/perm"
@@ -21,6 +24,10 @@ import (
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
 )
 
+// arenaMinKeys is the number of keys, from which the intermediate values of a
+// restriction are allocated in the arena of the request.
+const arenaMinKeys = 10_000
+
 // Middleware can be used as a flow.Getter that restrict the data for a
 // user.
 //
@@ -47,25 +54,25 @@ type restricter struct {
 
 // Get returns restricted data.
 func (r restricter) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	start := time.Now()
 	data, err := r.getter.Get(ctx, keys...)
 	if err != nil {
 		return nil, fmt.Errorf("getting data: %w", err)
 	}
+	fetch := time.Since(start)
 
-	start := time.Now()
+	start = time.Now()
 	times, err := restrict(ctx, r.getter, data)
 	if err != nil {
 		return nil, fmt.Errorf("restricting data: %w", err)
 	}
 
 	duration := time.Since(start)
+	restrictTime.Observe(ctx, duration)
+	accounting.AddRestrictTime(ctx, duration)
 
-	if times != nil && (duration > slowCalls || oserror.HasTagFromContext(ctx, "profile_restrict")) {
-		body, ok := oserror.BodyFromContext(ctx)
-		if !ok {
-			body = "unknown body, probably simple request"
-		}
-		profile(body, duration, times)
+	if times != nil && (isSlow(duration) || oserror.HasTagFromContext(ctx, "profile_restrict")) {
+		profile(ctx, r.uid, len(keys), fetch, duration, times)
 	}
 
 	return data, nil
@@ -76,28 +83,36 @@ func (r restricter) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][
 func restrict(ctx context.Context, getter flow.Getter, data map[dskey.Key][]byte) (map[string]timeCount, error) {
 	ds := dsfetch.New(getter)
 
+	// Small requests do not need the arena. It would only keep memory until
+	// the end of the request.
+	mem := arena.FromContext(ctx)
+	if len(data) < arenaMinKeys {
+		mem = nil
+	}
+
 	// Get all required collections with there ids.
-	restrictModeIDs := make(map[collection.CM]set.Set[int])
+	restrictModeIDs := newCMSets()
 	for key := range data {
 		if data[key] == nil {
 			continue
 		}
 
-		if err := groupKeysByCollection(key, data[key], restrictModeIDs); err != nil {
+		if err := groupKeysByCollection(mem, key, data[key], restrictModeIDs); err != nil {
 			return nil, fmt.Errorf("grouping keys by collection: %w", err)
 		}
 	}
 
-	if len(restrictModeIDs) == 0 {
+	orderedCMs := sortRestrictModeIDs(restrictModeIDs)
+	if len(orderedCMs) == 0 {
 		return nil, nil
 	}
 
 	// Call restrict Mode function for each collection.
-	times := make(map[string]timeCount, len(restrictModeIDs))
-	orderedCMs := sortRestrictModeIDs(restrictModeIDs)
-	allowedMods := make(map[collection.CM]set.Set[int])
-	for _, cm := range orderedCMs {
-		ids := restrictModeIDs[cm].List()
+	times := make(map[string]timeCount, len(orderedCMs))
+	allowedMods := newCMSets()
+	for _, idx := range orderedCMs {
+		cm := collectionModes[idx]
+		ids := restrictModeIDs[idx].List()
 		start := time.Now()
 
 		modeFunc, err := restrictModefunc(ctx, cm.Collection, cm.Mode)
@@ -109,7 +124,7 @@ func restrict(ctx context.Context, getter flow.Getter, data map[dskey.Key][]byte
 		if err != nil {
 			return nil, fmt.Errorf("calling collection %s modefunc %s with ids %v: %w", cm.Collection, cm.Mode, ids, err)
 		}
-		allowedMods[cm] = set.New(allowedIDs...)
+		allowedMods[idx] = set.New(allowedIDs...)
 
 		duration := time.Since(start)
 		times[cm.Collection+"/"+cm.Mode] = timeCount{time: duration, count: len(ids)}
@@ -121,18 +136,17 @@ func restrict(ctx context.Context, getter flow.Getter, data map[dskey.Key][]byte
 			continue
 		}
 
-		restrictionMode, err := restrictModeName(key.Collection(), key.Field())
+		cm, err := keyCollectionMode(key)
 		if err != nil {
 			return nil, fmt.Errorf("getting restriction Mode for %s: %w", key, err)
 		}
 
-		cm := collection.CM{Collection: key.Collection(), Mode: restrictionMode}
-		if !allowedMods[cm].Has(key.ID()) {
+		if !allowedMods.has(cm, key.ID()) {
 			data[key] = nil
 			continue
 		}
 
-		newValue, ok, err := manipulateRelations(key, data[key], allowedMods)
+		newValue, ok, err := manipulateRelations(mem, key, data[key], allowedMods)
 		if err != nil {
 			return nil, fmt.Errorf("new value for relation key %s: %w", key, err)
 		}
@@ -146,78 +160,61 @@ func restrict(ctx context.Context, getter flow.Getter, data map[dskey.Key][]byte
 }
 
 // groupKeysByCollection groups all the keys in data by there collection.
-func groupKeysByCollection(key dskey.Key, value []byte, restrictModeIDs map[collection.CM]set.Set[int]) error {
-	restrictionMode, err := restrictModeName(key.Collection(), key.Field())
+func groupKeysByCollection(mem *arena.Arena, key dskey.Key, value []byte, restrictModeIDs cmSets) error {
+	cm, err := keyCollectionMode(key)
 	if err != nil {
 		return fmt.Errorf("getting restriction Mode for %s: %w", key, err)
 	}
+	restrictModeIDs.add(cm, key.ID())
 
-	cm := collection.CM{Collection: key.Collection(), Mode: restrictionMode}
-	if restrictModeIDs[cm].IsNotInitialized() {
-		restrictModeIDs[cm] = set.New[int]()
-	}
-	restrictModeIDs[cm].Add(key.ID())
-
-	if err := addRelationToRestrictModeIDs(key, value, restrictModeIDs); err != nil {
+	if err := addRelationToRestrictModeIDs(mem, key, value, restrictModeIDs); err != nil {
 		return fmt.Errorf("check %s for relation: %w", key, err)
 	}
 
 	return nil
 }
 
-func addRelationToRestrictModeIDs(key dskey.Key, value []byte, restrictModeIDs map[collection.CM]set.Set[int]) error {
-	collectionField := key.CollectionField()
+func addRelationToRestrictModeIDs(mem *arena.Arena, key dskey.Key, value []byte, restrictModeIDs cmSets) error {
+	info := fieldInfoFor(key)
 
-	cm, id, ok, err := isRelation(collectionField, value)
+	cm, id, ok, err := isRelation(info, value)
 	if err != nil {
 		return fmt.Errorf("checking for relation: %w", err)
 	}
 
 	if ok {
-		if restrictModeIDs[cm].IsNotInitialized() {
-			restrictModeIDs[cm] = set.New[int]()
-		}
-		restrictModeIDs[cm].Add(id)
+		restrictModeIDs.add(cm, id)
 		return nil
 	}
 
-	cm, ids, ok, err := isRelationList(collectionField, value)
+	cm, ids, ok, err := isRelationList(mem, info, value)
 	if err != nil {
 		return fmt.Errorf("checking for relation-list: %w", err)
 	}
 
 	if ok {
-		if restrictModeIDs[cm].IsNotInitialized() {
-			restrictModeIDs[cm] = set.New[int]()
-		}
-		restrictModeIDs[cm].Add(ids...)
+		restrictModeIDs.add(cm, ids...)
 		return nil
 	}
 
-	cm, id, ok, err = isGenericRelation(collectionField, value)
+	cm, id, ok, err = isGenericRelation(info, value)
 	if err != nil {
 		return fmt.Errorf("checking for generic-relation: %w", err)
 	}
 
 	if ok {
-		if restrictModeIDs[cm].IsNotInitialized() {
-			restrictModeIDs[cm] = set.New[int]()
-		}
-		restrictModeIDs[cm].Add(id)
+		restrictModeIDs.add(cm, id)
 		return nil
 	}
 
-	mcm, _, ok, err := isGenericRelationList(collectionField, value)
+	mcm, _, ok, err := isGenericRelationList(info, value)
 	if err != nil {
 		return fmt.Errorf("checking for generic-relation-list: %w", err)
 	}
 
 	if ok {
 		for _, cmID := range mcm {
-			if restrictModeIDs[cmID.cm].IsNotInitialized() {
-				restrictModeIDs[cmID.cm] = set.New[int]()
-			}
-			restrictModeIDs[cmID.cm].Add(cmID.id)
+			restrictModeIDs.add(cmID.cm, cmID.id)
 		}
 	}
 
@@ -228,51 +225,51 @@ func addRelationToRestrictModeIDs(key dskey.Key, value []byte, restrictModeIDs m
 //
 // The first return value is the new value. The second is, if the value was
 // manipulated.q
-func manipulateRelations(key dskey.Key, value []byte, allowedRestrictions map[collection.CM]set.Set[int]) ([]byte, bool, error) {
-	collectionField := key.CollectionField()
+//
+// The new value of a relation-list is allocated in the arena, so it can only be
+// used until the end of the request.
+func manipulateRelations(mem *arena.Arena, key dskey.Key, value []byte, allowedRestrictions cmSets) ([]byte, bool, error) {
+	info := fieldInfoFor(key)
 
-	cm, id, ok, err := isRelation(collectionField, value)
+	cm, id, ok, err := isRelation(info, value)
 	if err != nil {
 		return nil, false, fmt.Errorf("checking %s for relation: %w", key, err)
 	}
 
 	if ok {
-		return nil, !allowedRestrictions[cm].Has(id), nil
+		return nil, !allowedRestrictions.has(cm, id), nil
 	}
 
-	cm, ids, ok, err := isRelationList(collectionField, value)
+	cm, ids, ok, err := isRelationList(mem, info, value)
 	if err != nil {
 		return nil, false, fmt.Errorf("checking %s for relation-list: %w", key, err)
 	}
 
 	if ok {
-		allowed := make([]int, 0, len(ids))
+		allowed := arena.MakeSlice[int](mem, 0, len(ids))
 		for _, id := range ids {
-			if allowedRestrictions[cm].Has(id) {
+			if allowedRestrictions.has(cm, id) {
 				allowed = append(allowed, id)
 			}
 		}
 
 		if len(allowed) != len(ids) {
-			newValue, err := json.Marshal(allowed)
-			if err != nil {
-				return nil, false, fmt.Errorf("marshal new value for key %s: %w", key, err)
-			}
-			return newValue, true, nil
+			// The new list is not longer than the old value.
+			return appendIntList(arena.MakeSlice[byte](mem, 0, len(value)), allowed), true, nil
 		}
 		return nil, false, nil
 	}
 
-	cm, id, ok, err = isGenericRelation(collectionField, value)
+	cm, id, ok, err = isGenericRelation(info, value)
 	if err != nil {
 		return nil, false, fmt.Errorf("checking %s for generic-relation: %w", key, err)
 	}
 
 	if ok {
-		return nil, !allowedRestrictions[cm].Has(id), nil
+		return nil, !allowedRestrictions.has(cm, id), nil
 	}
 
-	mcm, genericIDs, ok, err := isGenericRelationList(collectionField, value)
+	mcm, genericIDs, ok, err := isGenericRelationList(info, value)
 	if err != nil {
 		return nil, false, fmt.Errorf("checking %s for generic-relation-list: %w", key, err)
 	}
@@ -280,7 +277,7 @@ func manipulateRelations(key dskey.Key, value []byte, allowedRestrictions map[co
 	if ok {
 		allowed := make([]string, 0, len(genericIDs))
 		for genericID, cmID := range mcm {
-			if allowedRestrictions[cmID.cm].Has(cmID.id) {
+			if allowedRestrictions.has(cmID.cm, cmID.id) {
 				allowed = append(allowed, genericID)
 			}
 		}
@@ -299,84 +296,76 @@ func manipulateRelations(key dskey.Key, value []byte, allowedRestrictions map[co
 	return nil, false, nil
 }
 
-func isRelation(collectionField string, value []byte) (collection.CM, int, bool, error) {
-	toCollectionfield, ok := relationFields[collectionField]
-	if !ok {
-		return collection.CM{}, 0, false, nil
+func isRelation(info *fieldInfo, value []byte) (cmIndex, int, bool, error) {
+	if info.kind != relation {
+		return 0, 0, false, nil
 	}
 
 	id, err := fastjson.DecodeInt(value)
 	if err != nil {
-		return collection.CM{}, 0, false, fmt.Errorf("decoding %q (`%s`): %w", collectionField, value, err)
+		return 0, 0, false, fmt.Errorf("decoding %q (`%s`): %w", info.collectionField, value, err)
 	}
 
-	coll, field, _ := strings.Cut(toCollectionfield, "/")
-	fieldMode, err := restrictModeName(coll, field)
-	if err != nil {
-		return collection.CM{}, 0, false, fmt.Errorf("building relation field field mode: %w", err)
+	if info.targetErr != nil {
+		return 0, 0, false, info.targetErr
 	}
 
-	return collection.CM{Collection: coll, Mode: fieldMode}, id, true, nil
+	return info.target, id, true, nil
 }
 
-func isRelationList(keyPrefix string, value []byte) (collection.CM, []int, bool, error) {
-	toCollectionfield, ok := relationListFields[keyPrefix]
-	if !ok {
-		return collection.CM{}, nil, false, nil
+func isRelationList(mem *arena.Arena, info *fieldInfo, value []byte) (cmIndex, []int, bool, error) {
+	if info.kind != relationList {
+		return 0, nil, false, nil
 	}
 
-	ids, err := fastjson.DecodeIntList(value)
+	ids, err := fastjson.AppendIntList(arena.MakeSlice[int](mem, 0, bytes.Count(value, []byte(","))+1), value)
 	if err != nil {
-		return collection.CM{}, nil, false, fmt.Errorf("decoding value (size: %d): %w", len(value), err)
+		return 0, nil, false, fmt.Errorf("decoding value (size: %d): %w", len(value), err)
 	}
 
-	coll, field, _ := strings.Cut(toCollectionfield, "/")
-	fieldMode, err := restrictModeName(coll, field)
-	if err != nil {
-		return collection.CM{}, nil, false, fmt.Errorf("building relation field field mode: %w", err)
+	if info.targetErr != nil {
+		return 0, nil, false, info.targetErr
 	}
 
-	return collection.CM{Collection: coll, Mode: fieldMode}, ids, true, nil
+	return info.target, ids, true, nil
 }
 
-func isGenericRelation(keyPrefix string, value []byte) (collection.CM, int, bool, error) {
-	toCollectionfield, ok := genericRelationFields[keyPrefix]
-	if !ok {
-		return collection.CM{}, 0, false, nil
+func isGenericRelation(info *fieldInfo, value []byte) (cmIndex, int, bool, error) {
+	if info.kind != genericRelation {
+		return 0, 0, false, nil
 	}
 
 	var genericID string
-	if err := json.Unmarshal(value, &genericID); err != nil {
-		return collection.CM{}, 0, false, fmt.Errorf("decoding %q: %w", keyPrefix, err)
+	if err := fastjson.Unmarshal(value, &genericID); err != nil {
+		return 0, 0, false, fmt.Errorf("decoding %q: %w", info.collectionField, err)
 	}
 
-	cm, id, err := genericKeyToCollectionMode(genericID, toCollectionfield)
+	cm, id, err := genericKeyToCollectionMode(genericID, info.generic)
 	if err != nil {
-		return collection.CM{}, 0, false, fmt.Errorf("parsing generic key: %w", err)
+		return 0, 0, false, fmt.Errorf("parsing generic key: %w", err)
 	}
 
 	return cm, id, true, nil
 }
 
 type collectionModeID struct {
-	cm collection.CM
+	cm cmIndex
 	id int
 }
 
-func isGenericRelationList(keyPrefix string, value []byte) (map[string]collectionModeID, []string, bool, error) {
-	toCollectionfield, ok := genericRelationListFields[keyPrefix]
-	if !ok {
+func isGenericRelationList(info *fieldInfo, value []byte) (map[string]collectionModeID, []string, bool, error) {
+	if info.kind != genericRelationList {
 		return nil, nil, false, nil
 	}
 
 	var genericIDs []string
-	if err := json.Unmarshal(value, &genericIDs); err != nil {
-		return nil, nil, false, fmt.Errorf("decoding %q: %w", keyPrefix, err)
+	if err := fastjson.Unmarshal(value, &genericIDs); err != nil {
+		return nil, nil, false, fmt.Errorf("decoding %q: %w", info.collectionField, err)
 	}
 
 	mcm := make(map[string]collectionModeID, len(genericIDs))
 	for _, genericID := range genericIDs {
-		cm, id, err := genericKeyToCollectionMode(genericID, toCollectionfield)
+		cm, id, err := genericKeyToCollectionMode(genericID, info.generic)
 		if err != nil {
 			return nil, nil, false, fmt.Errorf("parsing generic key: %w", err)
 		}
@@ -386,32 +375,28 @@ func isGenericRelationList(keyPrefix string, value []byte) (map[string]collectio
 	return mcm, genericIDs, true, nil
 }
 
-// genericKeyToCollectionMode calls f for each collection mode.
-func genericKeyToCollectionMode(genericID string, toCollectionFieldMap map[string]string) (collection.CM, int, error) {
+// genericKeyToCollectionMode returns the collection mode and the id of a
+// generic id like "motion/5".
+func genericKeyToCollectionMode(genericID string, targets map[string]cmIndex) (cmIndex, int, error) {
 	coll, rawID, found := strings.Cut(genericID, "/")
 	if !found {
 		// TODO LAST ERROR
-		return collection.CM{}, 0, fmt.Errorf("invalid generic relation: %s", genericID)
+		return 0, 0, fmt.Errorf("invalid generic relation: %s", genericID)
 	}
 
 	id, err := strconv.Atoi(rawID)
 	if err != nil {
 		// TODO LAST ERROR
-		return collection.CM{}, 0, fmt.Errorf("invalid generic relation, no id: %s", genericID)
+		return 0, 0, fmt.Errorf("invalid generic relation, no id: %s", genericID)
 	}
 
-	toField := toCollectionFieldMap[coll]
-	if toField == "" {
+	cm, ok := targets[coll]
+	if !ok {
 		// TODO LAST ERROR
-		return collection.CM{}, 0, fmt.Errorf("unknown generic relation: %s", coll)
-	}
-
-	fieldMode, err := restrictModeName(coll, toField)
-	if err != nil {
-		return collection.CM{}, 0, fmt.Errorf("building generic relation field mode: %w", err)
+		return 0, 0, fmt.Errorf("unknown generic relation: %s", coll)
 	}
 
-	return collection.CM{Collection: coll, Mode: fieldMode}, id, nil
+	return cm, id, nil
 }
 
 // restrictModeName returns the restriction mode for a collection and field.
@@ -443,30 +428,21 @@ func restrictModefunc(ctx context.Context, collectionName, fieldMode string) (co
 	return modefunc, nil
 }
 
-func sortRestrictModeIDs(data map[collection.CM]set.Set[int]) []collection.CM {
-	keys := make([]collection.CM, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
-	}
-
-	sort.Slice(keys, func(a, b int) bool {
-		var aid, bid int
-		if id, ok := collectionOrder[keys[a].String()]; ok {
-			aid = id
-		} else if id, ok := collectionOrder[keys[a].Collection]; ok {
-			aid = id
-		}
-
-		if id, ok := collectionOrder[keys[b].String()]; ok {
-			bid = id
-		} else if id, ok := collectionOrder[keys[b].Collection]; ok {
-			bid = id
+// sortRestrictModeIDs returns the collection modes with ids in the order, the
+// restrict mode functions have to be called.
+func sortRestrictModeIDs(data cmSets) []cmIndex {
+	var cms []cmIndex
+	for idx, ids := range data {
+		if !ids.IsNotInitialized() {
+			cms = append(cms, cmIndex(idx))
 		}
+	}
 
-		return aid < bid
+	sort.Slice(cms, func(a, b int) bool {
+		return cmOrder[cms[a]] < cmOrder[cms[b]]
 	})
 
-	return keys
+	return cms
 }
 
 var collectionOrder = map[string]int{
@@ -519,3 +495,16 @@ var collectionOrder = map[string]int{
 	"motion_editor":                    57,
 	"gender":                           58,
 }
+
+// appendIntList appends the ids as json list to buf. It is the same as
+// json.Marshal, but without allocations.
+func appendIntList(buf []byte, ids []int) []byte {
+	buf = append(buf, '[')
+	for i, id := range ids {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendInt(buf, int64(id), 10)
+	}
+	return append(buf, ']')
+}
@@ -2,9 +2,12 @@ package restrict
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/arena"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/set"
 )
 
@@ -50,3 +53,70 @@ func TestCollectionOrderContainsAll(t *testing.T) {
 		t.Errorf("CollectionOrder is incorrect")
 	}
 }
+
+func TestFieldInfosContainAll(t *testing.T) {
+	for collectionField, mode := range restrictionModes {
+		id := collectionFieldID(collectionField)
+		if id == -1 {
+			// There can not be a key for this field.
+			continue
+		}
+
+		info := fieldInfos[id]
+		if info.mode != mode {
+			t.Errorf("%s has mode %q, expected %q", collectionField, info.mode, mode)
+		}
+
+		coll, _, _ := strings.Cut(collectionField, "/")
+		if cm := collectionModes[info.cm]; cm.Collection != coll || cm.Mode != mode {
+			t.Errorf("%s has collection mode %s, expected %s/%s", collectionField, cm, coll, mode)
+		}
+
+		if info.targetErr != nil {
+			t.Errorf("%s: %v", collectionField, info.targetErr)
+		}
+	}
+
+	for collectionField, toCollectionFieldMap := range genericRelationFields {
+		if got := len(fieldInfos[collectionFieldID(collectionField)].generic); got != len(toCollectionFieldMap) {
+			t.Errorf("%s has %d generic targets, expected %d", collectionField, got, len(toCollectionFieldMap))
+		}
+	}
+
+	for collectionField := range relationListFields {
+		if kind := fieldInfos[collectionFieldID(collectionField)].kind; kind != relationList {
+			t.Errorf("%s has kind %d, expected relation-list", collectionField, kind)
+		}
+	}
+}
+
+func TestAppendIntList(t *testing.T) {
+	for _, ids := range [][]int{{}, {1}, {1, 2, 3}, {-5, 0, 1234567890}} {
+		expected, err := json.Marshal(ids)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+
+		if got := appendIntList(nil, ids); string(got) != string(expected) {
+			t.Errorf("appendIntList(%v) = %s, expected %s", ids, got, expected)
+		}
+	}
+}
+
+func TestManipulateRelationsWithArena(t *testing.T) {
+	mem := arena.New()
+	defer mem.Free()
+
+	key := dskey.MustKey("committee/1/meeting_ids")
+	allowed := newCMSets()
+	allowed.add(fieldInfoFor(key).target, 2, 3)
+
+	got, changed, err := manipulateRelations(mem, key, []byte("[1, 2, 3]"), allowed)
+	if err != nil {
+		t.Fatalf("manipulateRelations: %v", err)
+	}
+
+	if !changed || string(got) != "[2,3]" {
+		t.Errorf("got (%s, %t), expected ([2,3], true)", got, changed)
+	}
+}
@@ -1,12 +1,17 @@
 package restrict_test
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
 	restrict "github.com/OpenSlides/openslides-autoupdate-service/internal/restrict"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 )
 
 func TestRestrict(t *testing.T) {
@@ -161,3 +166,40 @@ func TestRestrictSuperAdmin(t *testing.T) {
 		t.Errorf("personal_note/2/id got not restricted")
 	}
 }
+
+func TestRestrictSlowLog(t *testing.T) {
+	defer logging.New(environment.ForTests{}, os.Stderr)
+	defer restrict.Configure(environment.ForTests{})
+
+	buf := new(bytes.Buffer)
+	if err := logging.New(environment.ForTests{}, buf); err != nil {
+		t.Fatalf("init logging: %v", err)
+	}
+
+	if err := restrict.Configure(environment.ForTests{"AUTOUPDATE_SLOW_RESTRICT_THRESHOLD": "1ns"}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+
+	ds := dsmock.Stub(dsmock.YAMLData(`---
+	user/1/username: admin
+	`))
+
+	ctx := logging.ContextWith(context.Background(), "request_id", "my-id")
+	ctx, restricter := restrict.Middleware(ctx, ds, 1)
+	if _, err := restricter.Get(ctx, dskey.MustKey("user/1/username")); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	got := buf.String()
+	for _, expect := range []string{`msg="Slow restriction"`, "user_id=1", "fetch_ms=", "user/B: 1 keys", "request_id=my-id"} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("log `%s` does not contain `%s`", got, expect)
+		}
+	}
+}
+
+func TestRestrictSlowLogInvalid(t *testing.T) {
+	if err := restrict.Configure(environment.ForTests{"AUTOUPDATE_SLOW_RESTRICT_THRESHOLD": "fast"}); err == nil {
+		t.Errorf("Configure with invalid value: got no error")
+	}
+}
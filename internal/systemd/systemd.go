@@ -0,0 +1,96 @@
+// Package systemd implements the notify protocol of systemd.
+//
+// With `Type=notify`, systemd waits for READY=1 before it starts dependent
+// units. With `WatchdogSec`, it restarts the service, if it does not send
+// WATCHDOG=1 in time. Without NOTIFY_SOCKET, all functions do nothing.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+)
+
+// Notify sends a state like READY=1 to systemd.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// Abstract sockets start with @.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("connecting to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("sending state: %w", err)
+	}
+	return nil
+}
+
+// WatchdogInterval returns the time, in which systemd expects WATCHDOG=1.
+//
+// Returns 0, if the watchdog is disabled or meant for another process.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.Atoi(os.Getenv("WATCHDOG_USEC"))
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Watchdog returns a background task, that sends WATCHDOG=1 twice per
+// interval. Each time, check has to succeed first. If it fails or does not
+// return in half the interval, the message is not sent and systemd restarts
+// the service.
+func Watchdog(check func(context.Context) error) func(context.Context, func(error)) {
+	return func(ctx context.Context, errorHandler func(error)) {
+		interval := WatchdogInterval()
+		if interval <= 0 {
+			return
+		}
+
+		tick := clock.NewTicker(interval / 2)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C():
+			}
+
+			checkCtx, cancel := context.WithTimeout(ctx, interval/2)
+			err := check(checkCtx)
+			cancel()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				errorHandler(fmt.Errorf("watchdog check: %w", err))
+				continue
+			}
+
+			if err := Notify("WATCHDOG=1"); err != nil {
+				errorHandler(fmt.Errorf("sending watchdog: %w", err))
+			}
+		}
+	}
+}
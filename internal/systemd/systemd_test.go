@@ -0,0 +1,116 @@
+package systemd_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/systemd"
+)
+
+func listen(t *testing.T) *net.UnixConn {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func read(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("reading notify socket: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	conn := listen(t)
+
+	if err := systemd.Notify("READY=1"); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if got := read(t, conn); got != "READY=1" {
+		t.Errorf("got %q, expected READY=1", got)
+	}
+}
+
+func TestNotifyWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	if err := systemd.Notify("READY=1"); err != nil {
+		t.Errorf("Notify without socket: %v", err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		usec   string
+		pid    string
+		expect time.Duration
+	}{
+		{"disabled", "", "", 0},
+		{"enabled", "2000000", "", 2 * time.Second},
+		{"this process", "2000000", strconv.Itoa(os.Getpid()), 2 * time.Second},
+		{"other process", "2000000", "1", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+
+			if got := systemd.WatchdogInterval(); got != tt.expect {
+				t.Errorf("got %s, expected %s", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	conn := listen(t)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failing := make(chan error, 1)
+	failing <- errors.New("stalled")
+	check := func(context.Context) error {
+		select {
+		case err := <-failing:
+			return err
+		default:
+			return nil
+		}
+	}
+
+	errs := make(chan error, 10)
+	go systemd.Watchdog(check)(ctx, func(err error) { errs <- err })
+
+	if got := read(t, conn); got != "WATCHDOG=1" {
+		t.Errorf("got %q, expected WATCHDOG=1", got)
+	}
+
+	select {
+	case <-errs:
+	default:
+		t.Errorf("failed check was not reported")
+	}
+}
@@ -0,0 +1,297 @@
+// Package tenant overrides settings for single tenants.
+//
+// When one service is used for many organizations, each organization is
+// reached with its own hostname. The tenants file has a section for each
+// hostname with the settings, that differ from the environment.
+//
+// A package marks its variables with Overridable. At startup, it creates its
+// settings for each tenant with the environment from Lookups. At request
+// time, it uses FromContext to choose the settings.
+//
+// With AUTOUPDATE_TENANT_ISOLATION, each tenant is an own organization with
+// its own database and message bus. The service creates a cache, an auth
+// service and an autoupdate service for each tenant.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/goccy/go-yaml"
+)
+
+var (
+	envFile      = environment.NewVariable("AUTOUPDATE_TENANTS_FILE", "", "Path of a YAML or JSON file with settings for single tenants. The keys are hostnames, the values are environment variables with their values. Empty disables the overrides.")
+	envIsolation = environment.NewVariable("AUTOUPDATE_TENANT_ISOLATION", "false", "If true, each tenant is an own organization with its own database, message bus and cache. Requests to other hosts only get the routes without data.")
+)
+
+// backendKeys are the variables of the database, the message bus, the vote
+// service, the auth service and the anonymization. They can only be set with
+// isolation, because the data of the tenant needs its own cache.
+var backendKeys = []string{
+	"DATABASE_HOST",
+	"DATABASE_PORT",
+	"DATABASE_NAME",
+	"DATABASE_USER",
+	"DATABASE_PASSWORD_FILE",
+	"MESSAGE_BUS_HOST",
+	"MESSAGE_BUS_PORT",
+	"VOTE_HOST",
+	"VOTE_PORT",
+	"VOTE_PROTOCOL",
+	"KEYCLOAK_HOST",
+	"KEYCLOAK_PORT",
+	"KEYCLOAK_PROTOCOL",
+	"AUTH_TOKEN_KEY_FILE",
+	"AUTH_COOKIE_KEY_FILE",
+	"OPENSLIDES_KEYCLOAK_URL",
+	"OPENSLIDES_TOKEN_ISSUER",
+	"OPENSLIDES_AUTH_CLIENT_ID",
+	"AUTOUPDATE_ANONYMIZE",
+	"AUTOUPDATE_ANONYMIZE_FIELDS",
+	"AUTOUPDATE_ANONYMIZE_SECRET_FILE",
+}
+
+var registry struct {
+	mu          sync.Mutex
+	overridable map[string]bool
+	tenants     map[string]map[string]string
+	isolated    bool
+}
+
+// Overridable marks variables, that can be set for a tenant.
+//
+// Has to be called at package level.
+func Overridable(variables ...environment.Variable) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if registry.overridable == nil {
+		registry.overridable = make(map[string]bool)
+	}
+
+	for _, v := range variables {
+		registry.overridable[v.Key] = true
+	}
+}
+
+// New reads the tenants file.
+//
+// Has to be called before the packages create their settings.
+func New(lookup environment.Environmenter) error {
+	path := envFile.Value(lookup)
+
+	isolated, err := strconv.ParseBool(envIsolation.Value(lookup))
+	if err != nil {
+		return fmt.Errorf("invalid value for `%s`, expected boolean got %s: %w", envIsolation.Key, envIsolation.Value(lookup), err)
+	}
+
+	var tenants map[string]map[string]string
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading tenants file: %w", err)
+		}
+
+		tenants, err = parse(content)
+		if err != nil {
+			return fmt.Errorf("parsing tenants file %s: %w", path, err)
+		}
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(tenants)) {
+		for _, key := range slices.Sorted(maps.Keys(tenants[name])) {
+			if slices.Contains(backendKeys, key) {
+				if !isolated {
+					return fmt.Errorf("tenant %s: `%s` can only be set with `%s`", name, key, envIsolation.Key)
+				}
+				continue
+			}
+
+			if !registry.overridable[key] {
+				return fmt.Errorf("tenant %s: `%s` can not be set for a tenant", name, key)
+			}
+		}
+	}
+
+	if isolated {
+		if err := checkIsolation(lookup, tenants); err != nil {
+			return err
+		}
+	}
+
+	registry.tenants = tenants
+	registry.isolated = isolated
+	return nil
+}
+
+// isolatedBackends are the backends, that no two organizations may share. A
+// shared token key or issuer would make the tokens of one organization valid
+// for the other.
+var isolatedBackends = []struct {
+	name string
+	keys []string
+}{
+	{"database", []string{"DATABASE_HOST", "DATABASE_PORT", "DATABASE_NAME"}},
+	{"message bus", []string{"MESSAGE_BUS_HOST", "MESSAGE_BUS_PORT"}},
+	{"auth token key", []string{"AUTH_TOKEN_KEY_FILE"}},
+	{"auth cookie key", []string{"AUTH_COOKIE_KEY_FILE"}},
+	{"token issuer", []string{"OPENSLIDES_TOKEN_ISSUER"}},
+}
+
+// checkIsolation makes sure, that no two organizations use the same database,
+// message bus, auth keys or token issuer. The environment is the first
+// organization.
+//
+// The development mode is refused, because it uses the same debug keys for
+// every organization.
+func checkIsolation(lookup environment.Environmenter, tenants map[string]map[string]string) error {
+	if dev, _ := strconv.ParseBool(environment.EnvDevelopment.Value(lookup)); dev {
+		return fmt.Errorf("`%s` can not be used with `%s`, because all organizations would use the same auth keys", envIsolation.Key, environment.EnvDevelopment.Key)
+	}
+
+	used := make([]map[string]string, len(isolatedBackends))
+	for i, b := range isolatedBackends {
+		used[i] = map[string]string{backend(lookup, b.keys...): "the environment"}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(tenants)) {
+		tenantLookup := overlay{base: lookup, values: tenants[name]}
+
+		for i, b := range isolatedBackends {
+			value := backend(tenantLookup, b.keys...)
+			if other, ok := used[i][value]; ok {
+				return fmt.Errorf("tenant %s: uses the same %s as %s", name, b.name, other)
+			}
+			used[i][value] = "tenant " + name
+		}
+	}
+	return nil
+}
+
+// backend returns the values of the keys as one string. Keys, that are not
+// set, have the default of their variable.
+func backend(lookup environment.Environmenter, keys ...string) string {
+	defaults := make(map[string]string)
+	for _, v := range environment.Defined() {
+		defaults[v.Key] = v.Default
+	}
+
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = lookup.Getenv(key)
+		if values[i] == "" {
+			values[i] = defaults[key]
+		}
+	}
+	return strings.ToLower(strings.Join(values, "/"))
+}
+
+// Isolated returns true, if each tenant is an own organization.
+func Isolated() bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	return registry.isolated
+}
+
+// MetricPrefix returns the prefix of the metric values of a tenant. The dots of
+// the hostname are replaced.
+func MetricPrefix(name string) string {
+	return "tenant_" + strings.NewReplacer(".", "_", "-", "_", ":", "_").Replace(name) + "_"
+}
+
+// parse decodes the tenants file. The hostnames are lowercase.
+func parse(content []byte) (map[string]map[string]string, error) {
+	var raw map[string]map[string]any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("decoding yaml: %w", err)
+	}
+
+	tenants := make(map[string]map[string]string, len(raw))
+	for name, values := range raw {
+		settings := make(map[string]string, len(values))
+		for key, value := range values {
+			switch value.(type) {
+			case string, bool, int, int64, uint64, float64:
+			default:
+				return nil, fmt.Errorf("tenant %s: value of %s: expected a value, got %T", name, key, value)
+			}
+			settings[key] = fmt.Sprint(value)
+		}
+		tenants[strings.ToLower(name)] = settings
+	}
+	return tenants, nil
+}
+
+// Lookups returns an environment for each tenant. It returns the values of
+// the tenant and the values of base for all other variables.
+func Lookups(base environment.Environmenter) map[string]environment.Environmenter {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	lookups := make(map[string]environment.Environmenter, len(registry.tenants))
+	for name, values := range registry.tenants {
+		lookups[name] = overlay{base: base, values: values}
+	}
+	return lookups
+}
+
+// Resolve returns the tenant for the host of a request. The port is ignored.
+//
+// Returns an empty string, if the host has no settings.
+func Resolve(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	host = strings.ToLower(host)
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, ok := registry.tenants[host]; !ok {
+		return ""
+	}
+	return host
+}
+
+type contextKey struct{}
+
+// ContextWith returns a context with the tenant.
+func ContextWith(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// FromContext returns the tenant of the request. It is empty, if the request
+// uses the settings from the environment.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(contextKey{}).(string)
+	return name
+}
+
+// overlay is the environment of a tenant.
+type overlay struct {
+	base   environment.Environmenter
+	values map[string]string
+}
+
+func (o overlay) Getenv(key string) string {
+	if value, ok := o.values[key]; ok {
+		return value
+	}
+	return o.base.Getenv(key)
+}
+
+func (o overlay) UseVariable(v environment.Variable) {
+	o.base.UseVariable(v)
+}
@@ -0,0 +1,142 @@
+package tenant_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envTestLimit = environment.NewVariable("TEST_TENANT_LIMIT", "1", "")
+
+func init() {
+	tenant.Overridable(envTestLimit)
+}
+
+func writeFile(t *testing.T, content string) environment.ForTests {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tenants.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing tenants file: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := tenant.New(environment.ForTests{}); err != nil {
+			t.Errorf("resetting tenants: %v", err)
+		}
+	})
+
+	return environment.ForTests{"AUTOUPDATE_TENANTS_FILE": path}
+}
+
+func TestTenant(t *testing.T) {
+	env := writeFile(t, "Meeting.Example.com:\n  TEST_TENANT_LIMIT: 5\n")
+	if err := tenant.New(env); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for host, expect := range map[string]string{
+		"meeting.example.com":     "meeting.example.com",
+		"MEETING.example.com:443": "meeting.example.com",
+		"other.example.com":       "",
+		"":                        "",
+	} {
+		if got := tenant.Resolve(host); got != expect {
+			t.Errorf("Resolve(%q) = %q, expected %q", host, got, expect)
+		}
+	}
+
+	base := environment.ForTests{"TEST_TENANT_LIMIT": "2", "OTHER": "value"}
+	lookup, ok := tenant.Lookups(base)["meeting.example.com"]
+	if !ok {
+		t.Fatalf("no environment for meeting.example.com")
+	}
+
+	if got := envTestLimit.Value(lookup); got != "5" {
+		t.Errorf("TEST_TENANT_LIMIT is %s, expected 5", got)
+	}
+
+	if got := lookup.Getenv("OTHER"); got != "value" {
+		t.Errorf("OTHER is %s, expected the value from the base environment", got)
+	}
+}
+
+func TestTenantNotOverridable(t *testing.T) {
+	env := writeFile(t, "meeting.example.com:\n  AUTOUPDATE_PORT: 9013\n")
+	if err := tenant.New(env); err == nil {
+		t.Errorf("New returned no error for a variable, that can not be overridden")
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		content   string
+		isolation string
+		valid     bool
+	}{
+		{
+			"database without isolation",
+			"a.example.com:\n  DATABASE_NAME: a\n  MESSAGE_BUS_HOST: redis-a\n",
+			"false",
+			false,
+		},
+		{
+			"own backend",
+			"a.example.com:\n  DATABASE_NAME: a\n  MESSAGE_BUS_HOST: redis-a\n  AUTH_TOKEN_KEY_FILE: /a/token\n  AUTH_COOKIE_KEY_FILE: /a/cookie\n  OPENSLIDES_TOKEN_ISSUER: https://a.example.com\nb.example.com:\n  DATABASE_NAME: b\n  MESSAGE_BUS_HOST: redis-b\n  AUTH_TOKEN_KEY_FILE: /b/token\n  AUTH_COOKIE_KEY_FILE: /b/cookie\n  OPENSLIDES_TOKEN_ISSUER: https://b.example.com\n",
+			"true",
+			true,
+		},
+		{
+			"same token key",
+			"a.example.com:\n  DATABASE_NAME: a\n  MESSAGE_BUS_HOST: redis-a\n  AUTH_TOKEN_KEY_FILE: /a/token\n  AUTH_COOKIE_KEY_FILE: /a/cookie\n  OPENSLIDES_TOKEN_ISSUER: https://a.example.com\nb.example.com:\n  DATABASE_NAME: b\n  MESSAGE_BUS_HOST: redis-b\n  AUTH_TOKEN_KEY_FILE: /a/token\n  AUTH_COOKIE_KEY_FILE: /b/cookie\n  OPENSLIDES_TOKEN_ISSUER: https://b.example.com\n",
+			"true",
+			false,
+		},
+		{
+			"issuer of the environment",
+			"a.example.com:\n  DATABASE_NAME: a\n  MESSAGE_BUS_HOST: redis-a\n  AUTH_TOKEN_KEY_FILE: /a/token\n  AUTH_COOKIE_KEY_FILE: /a/cookie\n",
+			"true",
+			false,
+		},
+		{
+			"database of the environment",
+			"a.example.com:\n  MESSAGE_BUS_HOST: redis-a\n",
+			"true",
+			false,
+		},
+		{
+			"same message bus",
+			"a.example.com:\n  DATABASE_NAME: a\n  MESSAGE_BUS_HOST: redis\nb.example.com:\n  DATABASE_NAME: b\n  MESSAGE_BUS_HOST: REDIS\n",
+			"true",
+			false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			env := writeFile(t, tt.content)
+			env["AUTOUPDATE_TENANT_ISOLATION"] = tt.isolation
+			env["OPENSLIDES_DEVELOPMENT"] = "false"
+
+			err := tenant.New(env)
+			if tt.valid != (err == nil) {
+				t.Fatalf("New returned error %v, expected valid: %v", err, tt.valid)
+			}
+
+			if tt.valid && !tenant.Isolated() {
+				t.Errorf("Isolated returned false")
+			}
+		})
+	}
+}
+
+func TestTenantIsolationDevelopment(t *testing.T) {
+	env := writeFile(t, "a.example.com:\n  DATABASE_NAME: a\n  MESSAGE_BUS_HOST: redis-a\n  AUTH_TOKEN_KEY_FILE: /a/token\n  AUTH_COOKIE_KEY_FILE: /a/cookie\n  OPENSLIDES_TOKEN_ISSUER: https://a.example.com\n")
+	env["AUTOUPDATE_TENANT_ISOLATION"] = "true"
+
+	if err := tenant.New(env); err == nil {
+		t.Errorf("New returned no error for isolation in development mode")
+	}
+}
@@ -0,0 +1,245 @@
+// Package throttle protects the service against running out of memory.
+//
+// A background task compares the heap with a watermark. When the heap is
+// bigger, the service is throttled: New connections are rejected, the clients
+// get their updates with a delay and the caches are cleared. The throttle ends,
+// when the heap is below 90 percent of the watermark.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var (
+	envWatermark = environment.NewVariable("AUTOUPDATE_MEMORY_WATERMARK", "0", "Heap size, for example `2GiB` or `512MiB`, above which the service throttles itself. It rejects new connections, delays updates and clears the caches. Zero disables the throttle.")
+	envDelay     = environment.NewVariable("AUTOUPDATE_MEMORY_THROTTLE_DELAY", "2s", "Time, updates are delayed while the service is throttled. Updates in this time are sent together.")
+)
+
+const (
+	// checkInterval is the time between two checks of the heap.
+	checkInterval = time.Second
+
+	// evictInterval is the minimum time between two evictions of the caches
+	// while the service is throttled.
+	evictInterval = 30 * time.Second
+
+	// releaseRatio is the part of the watermark the heap has to fall below,
+	// before the throttle ends.
+	releaseRatio = 0.9
+
+	heapMetric = "/memory/classes/heap/objects:bytes"
+)
+
+var logger = logging.For(logging.Autoupdate)
+
+var state struct {
+	watermark atomic.Uint64
+	delay     atomic.Int64
+	throttled atomic.Bool
+	heap      atomic.Uint64
+	count     atomic.Uint64
+	rejected  atomic.Uint64
+
+	mu        sync.Mutex
+	evictors  []func()
+	lastEvict time.Time
+}
+
+// New configures the throttle from the environment.
+//
+// The returned function checks the heap. It has to be run in the background.
+func New(lookup environment.Environmenter) (func(context.Context, func(error)), error) {
+	apply, err := Reload(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	apply()
+	state.throttled.Store(false)
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check(readHeap(), time.Now())
+			}
+		}
+	}
+
+	return background, nil
+}
+
+// Reload reads the watermark and the delay from the environment. The returned
+// function sets them.
+//
+// A watermark of zero ends a running throttle.
+func Reload(lookup environment.Environmenter) (func(), error) {
+	watermark, err := ParseBytes(envWatermark.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`: %w", envWatermark.Key, err)
+	}
+
+	delay, err := environment.ParseDuration(envDelay.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envDelay.Key, envDelay.Value(lookup), err)
+	}
+
+	apply := func() {
+		state.watermark.Store(watermark)
+		state.delay.Store(int64(delay))
+	}
+	return apply, nil
+}
+
+// OnEvict registers a function, that clears a cache. It is called, when the
+// service gets throttled.
+func OnEvict(f func()) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.evictors = append(state.evictors, f)
+}
+
+// Throttled tells, if the heap is above the watermark.
+func Throttled() bool {
+	return state.throttled.Load()
+}
+
+// Reject tells, if a new connection should be rejected, because the service is
+// throttled. Rejected connections are counted.
+func Reject() bool {
+	if !Throttled() {
+		return false
+	}
+	state.rejected.Add(1)
+	return true
+}
+
+// Delay returns the time, an update should be delayed. It is zero, if the
+// service is not throttled.
+func Delay() time.Duration {
+	if !Throttled() {
+		return 0
+	}
+	return time.Duration(state.delay.Load())
+}
+
+// Metric adds the state of the throttle to the metric.
+func Metric(con metric.Container) {
+	throttled := 0
+	if Throttled() {
+		throttled = 1
+	}
+
+	con.Add("memory_throttled", throttled)
+	con.Add("memory_throttle_count", int(state.count.Load()))
+	con.Add("memory_throttle_rejected", int(state.rejected.Load()))
+	con.Add("memory_heap_bytes", int(state.heap.Load()))
+}
+
+// check compares the heap with the watermark and changes the state.
+func check(heap uint64, now time.Time) {
+	state.heap.Store(heap)
+	watermark := state.watermark.Load()
+	if watermark == 0 {
+		state.throttled.Store(false)
+		return
+	}
+
+	if !Throttled() {
+		if heap <= watermark {
+			return
+		}
+
+		state.throttled.Store(true)
+		state.count.Add(1)
+		logger.Warn("Memory above watermark, throttling", "heap_bytes", heap, "watermark_bytes", watermark)
+		evict(now)
+		return
+	}
+
+	if heap < uint64(float64(watermark)*releaseRatio) {
+		state.throttled.Store(false)
+		logger.Info("Memory below watermark, throttle ends", "heap_bytes", heap, "watermark_bytes", watermark)
+		return
+	}
+
+	if heap > watermark {
+		evict(now)
+	}
+}
+
+// evict clears all caches, if the last eviction is longer then evictInterval
+// ago.
+func evict(now time.Time) {
+	state.mu.Lock()
+	if now.Sub(state.lastEvict) < evictInterval {
+		state.mu.Unlock()
+		return
+	}
+	state.lastEvict = now
+	evictors := state.evictors
+	state.mu.Unlock()
+
+	for _, f := range evictors {
+		f()
+	}
+	debug.FreeOSMemory()
+}
+
+// readHeap returns the bytes of the heap objects.
+func readHeap() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// ParseBytes parses a size like `512MiB`, `2GiB` or `1000`.
+func ParseBytes(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	units := []struct {
+		suffix string
+		factor uint64
+	}{
+		{"KiB", 1 << 10},
+		{"MiB", 1 << 20},
+		{"GiB", 1 << 30},
+		{"TiB", 1 << 40},
+		{"B", 1},
+	}
+
+	factor := uint64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			factor = unit.factor
+			break
+		}
+	}
+
+	value, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a size like 512MiB, got `%s`", s)
+	}
+	return value * factor, nil
+}
@@ -0,0 +1,155 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func setup(t *testing.T, watermark string) *int {
+	t.Helper()
+
+	if _, err := New(environment.ForTests{"AUTOUPDATE_MEMORY_WATERMARK": watermark, "AUTOUPDATE_MEMORY_THROTTLE_DELAY": "1s"}); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var evicted int
+	state.mu.Lock()
+	state.evictors = []func(){func() { evicted++ }}
+	state.lastEvict = time.Time{}
+	state.mu.Unlock()
+
+	t.Cleanup(func() {
+		state.mu.Lock()
+		state.evictors = nil
+		state.mu.Unlock()
+		state.watermark.Store(0)
+		state.throttled.Store(false)
+	})
+
+	return &evicted
+}
+
+func TestThrottle(t *testing.T) {
+	evicted := setup(t, "1000")
+	now := time.Now()
+
+	check(900, now)
+	if Throttled() || Delay() != 0 || Reject() {
+		t.Fatalf("throttled below the watermark")
+	}
+
+	check(1100, now)
+	if !Throttled() {
+		t.Fatalf("not throttled above the watermark")
+	}
+
+	if got := Delay(); got != time.Second {
+		t.Errorf("Delay() = %s, expected 1s", got)
+	}
+
+	if !Reject() {
+		t.Errorf("Reject() = false while throttled")
+	}
+
+	if *evicted != 1 {
+		t.Errorf("caches were evicted %d times, expected 1", *evicted)
+	}
+
+	check(1200, now.Add(time.Second))
+	if *evicted != 1 {
+		t.Errorf("caches were evicted again before the evict interval")
+	}
+
+	check(1200, now.Add(evictInterval))
+	if *evicted != 2 {
+		t.Errorf("caches were evicted %d times after the evict interval, expected 2", *evicted)
+	}
+
+	check(950, now.Add(2*evictInterval))
+	if !Throttled() {
+		t.Errorf("throttle ended above the release ratio")
+	}
+
+	check(850, now.Add(2*evictInterval))
+	if Throttled() {
+		t.Errorf("throttle did not end below the release ratio")
+	}
+}
+
+func TestThrottleDisabled(t *testing.T) {
+	evicted := setup(t, "0")
+
+	check(1<<40, time.Now())
+	if Throttled() {
+		t.Errorf("throttled without a watermark")
+	}
+
+	if *evicted != 0 {
+		t.Errorf("caches were evicted without a watermark")
+	}
+}
+
+func TestThrottleReload(t *testing.T) {
+	setup(t, "1000")
+
+	check(1100, time.Now())
+	if !Throttled() {
+		t.Fatalf("not throttled above the watermark")
+	}
+
+	apply, err := Reload(environment.ForTests{"AUTOUPDATE_MEMORY_WATERMARK": "2000", "AUTOUPDATE_MEMORY_THROTTLE_DELAY": "3s"})
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if got := Delay(); got != time.Second {
+		t.Errorf("Delay() = %s before apply, expected 1s", got)
+	}
+
+	apply()
+
+	if got := Delay(); got != 3*time.Second {
+		t.Errorf("Delay() = %s after apply, expected 3s", got)
+	}
+
+	check(1100, time.Now())
+	if Throttled() {
+		t.Errorf("still throttled below the new watermark")
+	}
+
+	if _, err := Reload(environment.ForTests{"AUTOUPDATE_MEMORY_WATERMARK": "much"}); err == nil {
+		t.Errorf("Reload with invalid watermark did not return an error")
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	for _, tt := range []struct {
+		value  string
+		expect uint64
+	}{
+		{"0", 0},
+		{"1000", 1000},
+		{"10B", 10},
+		{"2KiB", 2048},
+		{"512MiB", 512 << 20},
+		{"2 GiB", 2 << 30},
+	} {
+		got, err := ParseBytes(tt.value)
+		if err != nil {
+			t.Errorf("ParseBytes(%q): %v", tt.value, err)
+			continue
+		}
+
+		if got != tt.expect {
+			t.Errorf("ParseBytes(%q) = %d, expected %d", tt.value, got, tt.expect)
+		}
+	}
+
+	for _, value := range []string{"", "2GB", "-1", "much"} {
+		if _, err := ParseBytes(value); err == nil {
+			t.Errorf("ParseBytes(%q) returned no error", value)
+		}
+	}
+}
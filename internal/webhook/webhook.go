@@ -0,0 +1,342 @@
+// Package webhook sends the changes of restricted data to external urls.
+//
+// Operators register webhooks in a YAML or JSON file. Each webhook has an url,
+// a user and an autoupdate request. The service opens an autoupdate connection
+// for the user and sends each message of the connection with POST to the url.
+// So integrations like signage or stream overlays get the data without a
+// client.
+//
+// Each request is signed with HMAC-SHA256 and the secret of the webhook. If the
+// url can not be reached, the request is repeated with a growing delay. After
+// the last try, the webhook starts again with all data.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/keysbuilder"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/goccy/go-yaml"
+)
+
+var (
+	envFile     = environment.NewVariable("AUTOUPDATE_WEBHOOKS_FILE", "", "Path of a YAML or JSON file with webhooks, that get the changes of restricted data. Empty disables the webhooks.")
+	envMaxTries = environment.NewVariable("AUTOUPDATE_WEBHOOKS_MAX_TRIES", "5", "Number of tries to send one message to a webhook. Afterwards, the webhook starts again with all data.")
+)
+
+const (
+	// sendTimeout is the time, the url can take for one request.
+	sendTimeout = 10 * time.Second
+
+	// minBackoff is the delay after the first failed request. It is doubled
+	// after each try until maxBackoff.
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// The headers of each request.
+const (
+	HeaderName      = "X-Autoupdate-Webhook"
+	HeaderTimestamp = "X-Autoupdate-Timestamp"
+	HeaderSignature = "X-Autoupdate-Signature"
+)
+
+var logger = logging.For(logging.Autoupdate)
+
+var counter struct {
+	sent    atomic.Uint64
+	retries atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// Connecter opens an autoupdate connection.
+type Connecter interface {
+	Connect(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (autoupdate.Connection, error)
+}
+
+// Hook is one registered webhook.
+type Hook struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	UserID int    `json:"user_id"`
+
+	// SecretFile is the path of the secret, the requests are signed with.
+	// Each webhook has its own secret, so a receiver can not sign requests of
+	// other webhooks.
+	SecretFile string `json:"secret_file"`
+
+	// Request is the body of an autoupdate request.
+	Request json.RawMessage `json:"request"`
+
+	secret []byte
+}
+
+// New reads the webhooks from the file.
+//
+// The returned function sends the messages. It has to be run in the
+// background.
+func New(lookup environment.Environmenter, connecter Connecter) (func(context.Context, func(error)), error) {
+	path := envFile.Value(lookup)
+	if path == "" {
+		return func(context.Context, func(error)) {}, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading webhooks file: %w", err)
+	}
+
+	hooks, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing webhooks file %s: %w", path, err)
+	}
+
+	for i, hook := range hooks {
+		secret, err := environment.ReadSecretFile(lookup, hook.SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: reading secret: %w", hook.Name, err)
+		}
+		hooks[i].secret = []byte(secret)
+	}
+
+	maxTries, err := strconv.Atoi(envMaxTries.Value(lookup))
+	if err != nil || maxTries < 1 {
+		return nil, fmt.Errorf("invalid value for `%s`, expected positive number, got %s", envMaxTries.Key, envMaxTries.Value(lookup))
+	}
+
+	s := sender{
+		client:   &http.Client{Timeout: sendTimeout},
+		maxTries: maxTries,
+	}
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		var wg sync.WaitGroup
+		for _, hook := range hooks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.run(ctx, connecter, hook, errorHandler)
+			}()
+		}
+		wg.Wait()
+	}
+	return background, nil
+}
+
+// parse decodes the webhooks file.
+func parse(content []byte) ([]Hook, error) {
+	var raw []struct {
+		Name       string `yaml:"name"`
+		URL        string `yaml:"url"`
+		UserID     int    `yaml:"user_id"`
+		SecretFile string `yaml:"secret_file"`
+		Request    any    `yaml:"request"`
+	}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("decoding yaml: %w", err)
+	}
+
+	names := make(map[string]bool, len(raw))
+	hooks := make([]Hook, 0, len(raw))
+	for i, r := range raw {
+		if r.Name == "" {
+			return nil, fmt.Errorf("webhook %d: name is missing", i+1)
+		}
+
+		if names[r.Name] {
+			return nil, fmt.Errorf("webhook %s: name is used twice", r.Name)
+		}
+		names[r.Name] = true
+
+		if !strings.HasPrefix(r.URL, "http://") && !strings.HasPrefix(r.URL, "https://") {
+			return nil, fmt.Errorf("webhook %s: expected an http or https url, got `%s`", r.Name, r.URL)
+		}
+
+		if r.SecretFile == "" {
+			return nil, fmt.Errorf("webhook %s: secret_file is missing", r.Name)
+		}
+
+		if r.UserID < 0 {
+			return nil, fmt.Errorf("webhook %s: invalid user_id %d", r.Name, r.UserID)
+		}
+
+		request, err := json.Marshal(r.Request)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: encoding request: %w", r.Name, err)
+		}
+
+		if _, err := keysbuilder.ManyFromJSON(bytes.NewReader(request)); err != nil {
+			return nil, fmt.Errorf("webhook %s: invalid request: %w", r.Name, err)
+		}
+
+		hooks = append(hooks, Hook{
+			Name:       r.Name,
+			URL:        r.URL,
+			UserID:     r.UserID,
+			SecretFile: r.SecretFile,
+			Request:    request,
+		})
+	}
+	return hooks, nil
+}
+
+// Metric adds the number of sent, repeated and failed requests to the metric.
+func Metric(con metric.Container) {
+	con.Add("webhook_sent", int(counter.sent.Load()))
+	con.Add("webhook_retries", int(counter.retries.Load()))
+	con.Add("webhook_failed", int(counter.failed.Load()))
+}
+
+// errGiveUp is returned by deliver, when the last try failed.
+var errGiveUp = errors.New("giving up")
+
+type sender struct {
+	client   *http.Client
+	maxTries int
+}
+
+// run sends the messages of one webhook until the context is done.
+//
+// When a message can not be delivered, the connection is opened again, so the
+// next message contains all data.
+func (s sender) run(ctx context.Context, connecter Connecter, hook Hook, errorHandler func(error)) {
+	for {
+		err := s.stream(ctx, connecter, hook)
+		if ctx.Err() != nil {
+			return
+		}
+		errorHandler(fmt.Errorf("webhook %s: %w", hook.Name, err))
+
+		if err := clock.Sleep(ctx, minBackoff); err != nil {
+			return
+		}
+	}
+}
+
+// stream opens the connection of the webhook and sends its messages. It only
+// returns with an error.
+func (s sender) stream(ctx context.Context, connecter Connecter, hook Hook) error {
+	kb, err := keysbuilder.ManyFromJSON(bytes.NewReader(hook.Request))
+	if err != nil {
+		return fmt.Errorf("building keysbuilder: %w", err)
+	}
+
+	conn, err := connecter.Connect(ctx, hook.UserID, kb)
+	if err != nil {
+		return fmt.Errorf("connecting: %w", err)
+	}
+
+	for f, ok := conn.Next(); ok; f, ok = conn.Next() {
+		data, err := f(ctx)
+		if err != nil {
+			return fmt.Errorf("getting next message: %w", err)
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		body, err := encode(data)
+		if err != nil {
+			return fmt.Errorf("encoding message: %w", err)
+		}
+
+		if err := s.deliver(ctx, hook, body); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// deliver sends one message. Failed requests are repeated with a growing delay.
+func (s sender) deliver(ctx context.Context, hook Hook, body []byte) error {
+	backoff := minBackoff
+	for try := 1; ; try++ {
+		err := s.send(ctx, hook, body)
+		if err == nil {
+			counter.sent.Add(1)
+			return nil
+		}
+
+		if try >= s.maxTries || ctx.Err() != nil {
+			counter.failed.Add(1)
+			return fmt.Errorf("%w after %d tries: %w", errGiveUp, try, err)
+		}
+
+		counter.retries.Add(1)
+		logger.DebugContext(ctx, "Webhook request failed", "webhook", hook.Name, "try", try, "error", err)
+		if err := clock.Sleep(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// send posts the body to the url of the webhook.
+func (s sender) send(ctx context.Context, hook Hook, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(clock.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderName, hook.Name)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(hook.secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and the body,
+// separated by a dot.
+//
+// A receiver can check the header X-Autoupdate-Signature with it.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// encode returns the data as json object. Values, that were deleted or are
+// not visible anymore, are null.
+func encode(data map[dskey.Key][]byte) ([]byte, error) {
+	converted := make(map[string]json.RawMessage, len(data))
+	for k, v := range data {
+		if v == nil {
+			v = []byte("null")
+		}
+		converted[k.String()] = v
+	}
+	return json.Marshal(converted)
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+func TestParse(t *testing.T) {
+	hooks, err := parse([]byte(`---
+- name: signage
+  url: https://signage.example/hook
+  user_id: 5
+  secret_file: /run/secrets/signage
+  request:
+    - collection: projector
+      ids: [1]
+      fields:
+        name: null
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if len(hooks) != 1 || hooks[0].Name != "signage" || hooks[0].UserID != 5 || hooks[0].SecretFile != "/run/secrets/signage" {
+		t.Fatalf("got %v, expected the signage hook", hooks)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{"no name", `[{url: "https://example.com", secret_file: /s, request: [{collection: projector, ids: [1], fields: {name: null}}]}]`},
+		{"invalid url", `[{name: a, url: "ftp://example.com", secret_file: /s, request: [{collection: projector, ids: [1], fields: {name: null}}]}]`},
+		{"same name", `[{name: a, url: "https://example.com", secret_file: /s, request: [{collection: projector, ids: [1], fields: {name: null}}]}, {name: a, url: "https://example.com", secret_file: /s, request: [{collection: projector, ids: [1], fields: {name: null}}]}]`},
+		{"no secret", `[{name: a, url: "https://example.com", request: [{collection: projector, ids: [1], fields: {name: null}}]}]`},
+		{"empty request", `[{name: a, url: "https://example.com", secret_file: /s, request: []}]`},
+		{"invalid request", `[{name: a, url: "https://example.com", secret_file: /s, request: [{collection: projector}]}]`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parse([]byte(tt.content)); err == nil {
+				t.Errorf("parse returned no error")
+			}
+		})
+	}
+}
+
+type fakeConnecter struct {
+	messages []map[dskey.Key][]byte
+}
+
+func (c fakeConnecter) Connect(ctx context.Context, userID int, kb autoupdate.KeysBuilder) (autoupdate.Connection, error) {
+	return &fakeConnection{messages: c.messages}, nil
+}
+
+type fakeConnection struct {
+	messages []map[dskey.Key][]byte
+}
+
+func (c *fakeConnection) Next() (func(context.Context) (map[dskey.Key][]byte, error), bool) {
+	return func(ctx context.Context) (map[dskey.Key][]byte, error) {
+		if len(c.messages) == 0 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		data := c.messages[0]
+		c.messages = c.messages[1:]
+		return data, nil
+	}, true
+}
+
+func (c *fakeConnection) NextWithFilter(ctx context.Context, filterHashes string) (map[dskey.Key][]byte, string, error) {
+	return nil, "", nil
+}
+
+func TestStreamRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer clock.Use(fake)()
+
+	secret := []byte("my-secret")
+
+	var mu sync.Mutex
+	var requests int
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		first := requests == 1
+		mu.Unlock()
+
+		if first {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		expected := "sha256=" + Sign(secret, r.Header.Get(HeaderTimestamp), body)
+		if got := r.Header.Get(HeaderSignature); got != expected {
+			t.Errorf("got signature %s, expected %s", got, expected)
+		}
+
+		if got := r.Header.Get(HeaderName); got != "signage" {
+			t.Errorf("got webhook name %s, expected signage", got)
+		}
+		received <- string(body)
+	}))
+	defer srv.Close()
+
+	s := sender{client: srv.Client(), maxTries: 3}
+	hook := Hook{Name: "signage", URL: srv.URL, UserID: 1, secret: secret, Request: []byte(`[{"collection":"projector","ids":[1],"fields":{"name":null}}]`)}
+	connecter := fakeConnecter{messages: []map[dskey.Key][]byte{
+		{dskey.MustKey("projector/1/name"): []byte(`"main"`), dskey.MustKey("projector/1/scale"): nil},
+	}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.stream(ctx, connecter, hook)
+	}()
+
+	if err := fake.BlockUntil(ctx, 1); err != nil {
+		t.Fatalf("waiting for the backoff: %v", err)
+	}
+	fake.Advance(minBackoff)
+
+	select {
+	case body := <-received:
+		if body != `{"projector/1/name":"main","projector/1/scale":null}` {
+			t.Errorf("got body %s", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("webhook did not get the message")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("stream returned %v, expected context.Canceled", err)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	defer clock.Use(fake)()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	s := sender{client: srv.Client(), maxTries: 1}
+	err := s.deliver(context.Background(), Hook{Name: "a", URL: srv.URL}, []byte(`{}`))
+	if !errors.Is(err, errGiveUp) {
+		t.Errorf("deliver returned %v, expected %v", err, errGiveUp)
+	}
+}
@@ -2,22 +2,51 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"maps"
 	gohttp "net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/accounting"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/anonymize"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/audit"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/autoupdate"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/buildinfo"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/capture"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/chaos"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/errorreport"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/feature"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/federation"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/http"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/icc"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/introspect"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/lifecycle"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/loadtest"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logout"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/maintenance"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/mqtt"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/reload"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/restrict"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/systemd"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/tenant"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/throttle"
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/webhook"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/auth"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/redis"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracing"
 	"github.com/alecthomas/kong"
 )
 
@@ -29,12 +58,54 @@ var (
 	envMetricSaveInterval     = environment.NewVariable("METRIC_SAVE_INTERVAL", "5m", "Interval, how often the metric should be saved to redis. Redis will ignore entries, that are twice at old then the save interval.")
 	envDisableConnectionCount = environment.NewVariable("DISABLE_CONNECTION_COUNT", "false", "Do not count connections.")
 	envPublicAccessOnly       = environment.NewVariable("OPENSLIDES_PUBLIC_ACCESS_ONLY", "false", "Start for only public access. Does not write to redis or connect to the vote-service.")
+	envSelfTest               = environment.NewVariable("AUTOUPDATE_SELF_TEST", "false", "Before the service listens, it loads the auth configuration, reads one value from the datastore and one message from the message bus. If one of them fails, the service exits.")
+	envSelfTestTimeout        = environment.NewVariable("AUTOUPDATE_SELF_TEST_TIMEOUT", "30s", "Time for all steps of the self test together.")
 )
 
 var cli struct {
+	Config      string `help:"Path of a YAML or JSON config file. Environment variables override its values. Defaults to OPENSLIDES_CONFIG." type:"path"`
+	PrintConfig bool   `help:"Print the effective configuration with the source of each value and exit. Secrets are redacted."`
+	PrintSchema bool   `help:"Print all environment variables with their type, default value and description as json and exit."`
+
 	Run      struct{} `cmd:"" help:"Runs the service." default:"withargs"`
 	BuildDoc struct{} `cmd:"" help:"Build the environment documentation."`
 	Health   struct{} `cmd:"" help:"Runs a health check."`
+	Version  struct{} `cmd:"" help:"Prints the version, the commit and the build date."`
+
+	CheckConfig struct {
+		Timeout time.Duration `help:"Time for all checks together." default:"30s"`
+	} `cmd:"" help:"Validates the configuration and checks the connections to keycloak, the datastore and the message bus."`
+
+	Resync struct {
+		Reason string `help:"Reason, that is logged by each instance." default:"manual resync"`
+	} `cmd:"" help:"Tells all instances, that use the message bus from MESSAGE_BUS_HOST and MESSAGE_BUS_PORT, to fetch all values of their cache again."`
+
+	VerifyAudit struct {
+		File string `arg:"" help:"Path of the audit file." type:"existingfile"`
+	} `cmd:"" help:"Checks the hash chain of an audit file."`
+
+	Loadtest struct {
+		URL         string        `help:"URL of the autoupdate route." default:"http://localhost:9012/system/autoupdate"`
+		Connections int           `help:"Amount of connections, that are open at the same time." short:"n" default:"100"`
+		Duration    time.Duration `help:"Time, the connections are kept open." default:"30s"`
+		Ramp        time.Duration `help:"Time to open all connections." default:"5s"`
+		Bodies      string        `help:"Path of a file with one request body per line, for example recorded requests. Defaults to a request for the organization." type:"existingfile"`
+		Header      []string      `help:"Header for each request, for example 'Authentication: bearer ...'. Can be used many times." short:"H" sep:"none"`
+	} `cmd:"" help:"Opens many connections to a running service and prints the time to first byte and the propagation latency of updates."`
+
+	ExampleData struct {
+		File     string        `arg:"" help:"Path of the example-data.json." type:"existingfile"`
+		Interval time.Duration `help:"Interval, how often the file is checked for changes." default:"1s"`
+	} `cmd:"" help:"Runs the service with the data from an example-data.json for local development. It needs no datastore, message bus or keycloak. Every request uses the user 1."`
+
+	Replay struct {
+		File      string        `arg:"" help:"Path of the capture file." type:"existingfile"`
+		URL       string        `help:"URL of the autoupdate route of the test instance." default:"http://localhost:9012/system/autoupdate"`
+		Speed     float64       `help:"Factor for the recorded times. 2 replays twice as fast." default:"1"`
+		Linger    time.Duration `help:"Time, the connections are kept open after the last record." default:"10s"`
+		Header    []string      `help:"Header for each request, for example 'Authentication: bearer ...'. Can be used many times." short:"H" sep:"none"`
+		NoUpdates bool          `help:"Only replay the requests. Otherwise the updates are written to the message bus from MESSAGE_BUS_HOST and MESSAGE_BUS_PORT."`
+	} `cmd:"" help:"Replays a traffic capture against a test instance and prints the same report as the load test."`
 }
 
 func main() {
@@ -42,6 +113,22 @@ func main() {
 	defer cancel()
 
 	kongCTX := kong.Parse(&cli, kong.UsageOnError())
+	if cli.PrintConfig {
+		if err := printConfig(); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cli.PrintSchema {
+		if err := printSchema(); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	switch kongCTX.Command() {
 	case "run":
 		if err := run(ctx); err != nil {
@@ -60,24 +147,127 @@ func main() {
 			oserror.Handle(err)
 			os.Exit(1)
 		}
+
+	case "version":
+		if err := printVersion(); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+
+	case "check-config":
+		if err := checkConfig(ctx, cli.CheckConfig.Timeout); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+
+	case "loadtest":
+		if err := loadTest(ctx); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+
+	case "example-data <file>":
+		if err := runExampleData(ctx); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+
+	case "replay <file>":
+		if err := replay(ctx); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+
+	case "resync":
+		if err := forceResync(ctx, cli.Resync.Reason); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
+
+	case "verify-audit <file>":
+		if err := verifyAudit(cli.VerifyAudit.File); err != nil {
+			oserror.Handle(err)
+			os.Exit(1)
+		}
 	}
 }
 
 func run(ctx context.Context) error {
-	lookup := new(environment.ForProduction)
+	lookup, err := environment.NewForProduction(cli.Config)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
 
-	service, err := initService(lookup)
+	reload.SetLookup(func() (environment.Environmenter, error) {
+		return environment.NewForProduction(cli.Config)
+	})
+
+	service, _, err := initService(lookup)
 	if err != nil {
 		return fmt.Errorf("init services: %w", err)
 	}
 
+	for _, deprecated := range lookup.Deprecated() {
+		slog.Warn("Environment variable is deprecated", "name", deprecated.Old, "use", deprecated.New)
+	}
+
 	return service(ctx)
 }
 
+// runExampleData runs the service with the data from an example-data.json.
+//
+// Only the parts, that are needed by the clients, are started. Other services
+// are not used.
+func runExampleData(ctx context.Context) error {
+	lookup, err := environment.NewForProduction(cli.Config)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	if err := logging.New(lookup, os.Stderr); err != nil {
+		return fmt.Errorf("init logging: %w", err)
+	}
+
+	flow, err := autoupdate.NewExampleFlow(cli.ExampleData.File, cli.ExampleData.Interval)
+	if err != nil {
+		return fmt.Errorf("init example data: %w", err)
+	}
+
+	if err := restrict.Configure(lookup); err != nil {
+		return fmt.Errorf("init restricter: %w", err)
+	}
+
+	auService, auBackground, err := autoupdate.New(lookup, flow, restrict.Middleware)
+	if err != nil {
+		return fmt.Errorf("init autoupdate: %w", err)
+	}
+	introspect.Register("autoupdate", auService.Introspect)
+
+	httpConfig, err := http.NewConfig(lookup)
+	if err != nil {
+		return fmt.Errorf("init http config: %w", err)
+	}
+
+	// There is no message bus and the auth is fake. Their checks always
+	// succeed, so the default readiness checks can be used.
+	readinessChecks := map[string]http.ReadinessCheck{
+		"datastore":  flow.Ping,
+		"messagebus": func(context.Context) error { return nil },
+		"auth":       func(context.Context) error { return nil },
+		"cache":      flow.CacheWarm,
+	}
+
+	go auBackground(ctx, oserror.Handle)
+
+	listenAddr := ":" + envAutoupdatePort.Value(lookup)
+	slog.Info("Listen with example data", "addr", listenAddr, "file", cli.ExampleData.File)
+	return http.Run(ctx, httpConfig, listenAddr, auth.NewFake(), auService, nil, 0, readinessChecks)
+}
+
 func buildDocu() error {
 	lookup := new(environment.ForDocu)
 
-	if _, err := initService(lookup); err != nil {
+	if _, _, err := initService(lookup); err != nil {
 		return fmt.Errorf("init services: %w", err)
 	}
 
@@ -91,21 +281,69 @@ func buildDocu() error {
 }
 
 func health(ctx context.Context) error {
-	port, found := os.LookupEnv("AUTOUPDATE_PORT")
-	if !found {
+	lookup, err := environment.NewForProduction(cli.Config)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	return requestHealth(ctx, lookup)
+}
+
+// forceResync tells all instances to fetch the values of their cache again.
+func forceResync(ctx context.Context, reason string) error {
+	lookup, err := environment.NewForProduction(cli.Config)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	messageBus, err := redis.New(lookup)
+	if err != nil {
+		return fmt.Errorf("init message bus: %w", err)
+	}
+
+	if err := messageBus.ForceResync(ctx, reason); err != nil {
+		return fmt.Errorf("force resync: %w", err)
+	}
+
+	fmt.Println("All instances resync their cache")
+	return nil
+}
+
+// requestHealth calls the health route of the service on localhost.
+func requestHealth(ctx context.Context, lookup environment.Environmenter) error {
+	port := lookup.Getenv("AUTOUPDATE_PORT")
+	if port == "" {
 		port = "9012"
 	}
 
-	req, err := gohttp.NewRequestWithContext(ctx, "GET", "http://localhost:"+port+"/system/autoupdate/health", nil)
+	basePath := lookup.Getenv("AUTOUPDATE_BASE_PATH")
+	if basePath == "" {
+		basePath = "/system/autoupdate"
+	}
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	scheme := "http"
+	client := gohttp.DefaultClient
+	if lookup.Getenv("AUTOUPDATE_TLS_CERT_FILE") != "" {
+		// The certificate is not issued for localhost.
+		scheme = "https"
+		client = &gohttp.Client{
+			Transport: &gohttp.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		}
+	}
+
+	req, err := gohttp.NewRequestWithContext(ctx, "GET", scheme+"://localhost:"+port+basePath+"/health", nil)
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
 
-	resp, err := gohttp.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("sending request: %w", err)
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != 200 {
 		return fmt.Errorf("health returned status %s", resp.Status)
 	}
@@ -124,56 +362,543 @@ func health(ctx context.Context) error {
 	return nil
 }
 
+// printVersion writes the build information and the feature flags from the
+// configuration to stdout.
+func printVersion() error {
+	lookup, err := environment.NewForProduction(cli.Config)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	if _, err := feature.New(lookup, nil); err != nil {
+		return fmt.Errorf("reading feature flags: %w", err)
+	}
+
+	info := buildinfo.Get()
+	fmt.Printf("openslides-autoupdate-service %s\n", info.Version)
+	fmt.Printf("commit:     %s\n", info.Commit)
+	fmt.Printf("build date: %s\n", info.Date)
+	fmt.Printf("go:         %s\n", info.GoVersion)
+	if info.Modified {
+		fmt.Println("modified:   true")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(info.Features)) {
+		fmt.Printf("feature:    %s=%s\n", name, info.Features[name])
+	}
+	return nil
+}
+
+// printConfig writes the value and the source of each environment variable as
+// json to stdout.
+func printConfig() error {
+	lookup, err := environment.NewForProduction(cli.Config)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(lookup.Resolve(environment.Defined())); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// loadTest runs a load test against a running service and writes the report
+// to stdout.
+func loadTest(ctx context.Context) error {
+	cfg := loadtest.Config{
+		URL:         cli.Loadtest.URL,
+		Connections: cli.Loadtest.Connections,
+		Duration:    cli.Loadtest.Duration,
+		Ramp:        cli.Loadtest.Ramp,
+		Header:      make(gohttp.Header),
+	}
+
+	if err := parseHeaders(cfg.Header, cli.Loadtest.Header); err != nil {
+		return err
+	}
+
+	if cli.Loadtest.Bodies != "" {
+		content, err := os.ReadFile(cli.Loadtest.Bodies)
+		if err != nil {
+			return fmt.Errorf("reading bodies: %w", err)
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				cfg.Bodies = append(cfg.Bodies, line)
+			}
+		}
+	}
+
+	report, err := loadtest.Run(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("load test: %w", err)
+	}
+
+	if err := report.Write(os.Stdout); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	if report.Failed == report.Connections {
+		return fmt.Errorf("all connections failed")
+	}
+	return nil
+}
+
+// replay replays a traffic capture against a test instance and writes the
+// report to stdout.
+func replay(ctx context.Context) error {
+	f, err := os.Open(cli.Replay.File)
+	if err != nil {
+		return fmt.Errorf("opening capture: %w", err)
+	}
+	defer f.Close()
+
+	records, err := capture.Read(f)
+	if err != nil {
+		return fmt.Errorf("reading capture: %w", err)
+	}
+
+	cfg := capture.ReplayConfig{
+		URL:    cli.Replay.URL,
+		Speed:  cli.Replay.Speed,
+		Linger: cli.Replay.Linger,
+		Header: make(gohttp.Header),
+	}
+
+	if err := parseHeaders(cfg.Header, cli.Replay.Header); err != nil {
+		return err
+	}
+
+	if !cli.Replay.NoUpdates {
+		lookup, err := environment.NewForProduction(cli.Config)
+		if err != nil {
+			return fmt.Errorf("reading config: %w", err)
+		}
+
+		messageBus, err := redis.New(lookup)
+		if err != nil {
+			return fmt.Errorf("init redis: %w", err)
+		}
+		cfg.Publisher = messageBus
+	}
+
+	report, err := capture.Replay(ctx, records, cfg)
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+
+	if err := report.Write(os.Stdout); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+// parseHeaders adds headers in the form `Name: value` to header.
+func parseHeaders(header gohttp.Header, values []string) error {
+	for _, v := range values {
+		name, value, found := strings.Cut(v, ":")
+		if !found {
+			return fmt.Errorf("invalid header `%s`, expected `Name: value`", v)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return nil
+}
+
+// printSchema writes all environment variables with their type as json to
+// stdout.
+func printSchema() error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(environment.Schema()); err != nil {
+		return fmt.Errorf("writing schema: %w", err)
+	}
+	return nil
+}
+
+// checkResult is the result of one check of check-config.
+type checkResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// checkConfig validates the configuration like the service at startup and
+// checks, that keycloak, the datastore and the message bus are reachable.
+//
+// It writes a report as json to stdout. Returns an error, if one check failed.
+func checkConfig(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var results []checkResult
+	add := func(name string, err error) {
+		result := checkResult{Name: name, OK: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	errNotChecked := errors.New("not checked, the configuration is invalid")
+
+	lookup, err := environment.NewForProduction(cli.Config)
+	if err != nil {
+		add("config", err)
+		add("keycloak", errNotChecked)
+		add("datastore", errNotChecked)
+		add("messagebus", errNotChecked)
+		return writeCheckReport(results)
+	}
+
+	// Check keycloak first. Without it, initService does not return.
+	add("keycloak", auth.CheckProvider(ctx, lookup))
+
+	type initResult struct {
+		checks map[string]http.ReadinessCheck
+		err    error
+	}
+	done := make(chan initResult, 1)
+	go func() {
+		_, checks, err := initService(lookup)
+		done <- initResult{checks: checks, err: err}
+	}()
+
+	var checks map[string]http.ReadinessCheck
+	select {
+	case <-ctx.Done():
+		err = fmt.Errorf("initialization did not finish: %w", ctx.Err())
+	case result := <-done:
+		checks, err = result.checks, result.err
+	}
+	add("config", err)
+
+	for _, name := range []string{"datastore", "messagebus"} {
+		if checks == nil {
+			add(name, errNotChecked)
+			continue
+		}
+		add(name, checks[name](ctx))
+	}
+
+	return writeCheckReport(results)
+}
+
+// writeCheckReport writes the results of check-config to stdout.
+func writeCheckReport(results []checkResult) error {
+	ok := true
+	for _, result := range results {
+		ok = ok && result.OK
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	report := struct {
+		OK     bool          `json:"ok"`
+		Checks []checkResult `json:"checks"`
+	}{ok, results}
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	if !ok {
+		return errors.New("configuration check failed")
+	}
+	return nil
+}
+
+func verifyAudit(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open audit file: %w", err)
+	}
+	defer file.Close()
+
+	count, err := audit.Verify(file)
+	if err != nil {
+		return fmt.Errorf("audit file is invalid after %d valid entries: %w", count, err)
+	}
+
+	fmt.Printf("%d entries are valid\n", count)
+	return nil
+}
+
 // initService initializes all packages needed for the autoupdate service.
 //
-// Returns a the service as callable.
-func initService(lookup environment.Environmenter) (func(context.Context) error, error) {
+// Returns a the service as callable and the checks for the readiness route.
+func initService(lookup environment.Environmenter) (func(context.Context) error, map[string]http.ReadinessCheck, error) {
 	var backgroundTasks []func(context.Context, func(error))
 	listenAddr := ":" + envAutoupdatePort.Value(lookup)
 
+	// Logging.
+	if err := logging.New(lookup, os.Stderr); err != nil {
+		return nil, nil, fmt.Errorf("init logging: %w", err)
+	}
+	introspect.Register("recent_errors", func() any { return logging.RecentErrors() })
+	reload.Register("log_level", logging.ReloadLevels)
+
+	// Settings for single tenants.
+	if err := tenant.New(lookup); err != nil {
+		return nil, nil, fmt.Errorf("init tenants: %w", err)
+	}
+
+	// Effective configuration for the runtime route.
+	if settings, ok := lookup.(interface{ Settings() map[string]string }); ok {
+		introspect.Register("config", func() any { return settings.Settings() })
+	}
+
+	// Traffic captures.
+	if err := capture.New(lookup); err != nil {
+		return nil, nil, fmt.Errorf("init capture: %w", err)
+	}
+
+	// Secrets from HashiCorp Vault.
+	vaultBackground, err := environment.NewVault(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init vault: %w", err)
+	}
+	backgroundTasks = append(backgroundTasks, introspect.Task("vault", vaultBackground))
+
+	// Error reporting to Sentry or GlitchTip.
+	errorReportBackground, err := errorreport.New(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init error reporting: %w", err)
+	}
+	backgroundTasks = append(backgroundTasks, introspect.Task("error_report", errorReportBackground))
+
+	// Tracing with OpenTelemetry.
+	tracingBackground, err := tracing.New(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init tracing: %w", err)
+	}
+	backgroundTasks = append(backgroundTasks, introspect.Task("tracing", tracingBackground))
+
 	// Redis as message bus for datastore and logout events.
-	messageBus := redis.New(lookup)
+	messageBus, err := redis.New(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init message bus: %w", err)
+	}
+	metric.Register(messageBus.Metric)
+	introspect.Register("message_bus", func() any {
+		position := messageBus.Position()
+		return map[string]any{
+			"consumed_id":    position.Consumed,
+			"latest_id":      position.Latest,
+			"lag_ms":         position.Lag.Milliseconds(),
+			"gaps":           position.Gaps,
+			"newest_id":      position.Newest,
+			"replica_lag_ms": position.ReplicaLag.Milliseconds(),
+		}
+	})
+
+	// Fault injection for resilience tests.
+	if err := chaos.New(lookup); err != nil {
+		return nil, nil, fmt.Errorf("init fault injection: %w", err)
+	}
+	metric.Register(chaos.Metric)
+	reload.Register("chaos", chaos.Reload)
 
 	publicAccessOnly, _ := strconv.ParseBool(envPublicAccessOnly.Value(lookup))
 
 	// Autoupdate data flow.
 	flow, flowBackground, err := autoupdate.NewFlow(lookup, messageBus, publicAccessOnly)
 	if err != nil {
-		return nil, fmt.Errorf("init autoupdate data flow: %w", err)
+		return nil, nil, fmt.Errorf("init autoupdate data flow: %w", err)
 	}
-	backgroundTasks = append(backgroundTasks, flowBackground)
+	metric.Register(flow.Metric)
+	introspect.Register("cache", flow.Introspect)
+	backgroundTasks = append(backgroundTasks, introspect.Task("datastore_flow", flowBackground))
 
 	// Auth Service.
-	authService, authBackground, err := auth.New(lookup, messageBus)
+	authService, authBackground, err := auth.New(lookup, chaos.LogoutEventer(messageBus))
 	if err != nil {
-		return nil, fmt.Errorf("init connection to auth: %w", err)
+		return nil, nil, fmt.Errorf("init connection to auth: %w", err)
+	}
+	backgroundTasks = append(backgroundTasks, introspect.Task("auth", authBackground))
+
+	// Restricter.
+	if err := restrict.Configure(lookup); err != nil {
+		return nil, nil, fmt.Errorf("init restricter: %w", err)
 	}
-	backgroundTasks = append(backgroundTasks, authBackground)
+	metric.Register(restrict.Metric)
 
 	// Autoupdate Service.
 	auService, auBackground, err := autoupdate.New(lookup, flow, restrict.Middleware)
 	if err != nil {
-		return nil, fmt.Errorf("init autoupdate: %w", err)
+		return nil, nil, fmt.Errorf("init autoupdate: %w", err)
 	}
-	backgroundTasks = append(backgroundTasks, auBackground)
+	introspect.Register("autoupdate", auService.Introspect)
+	backgroundTasks = append(backgroundTasks, introspect.Task("autoupdate", auBackground))
+
+	// Self-throttling, when the memory is low.
+	throttleBackground, err := throttle.New(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init memory throttle: %w", err)
+	}
+	throttle.OnEvict(flow.ResetCache)
+	metric.Register(throttle.Metric)
+	reload.Register("memory_throttle", throttle.Reload)
+	backgroundTasks = append(backgroundTasks, introspect.Task("memory_throttle", throttleBackground))
+
+	// Pseudonyms for personal data.
+	metric.Register(anonymize.Metric)
+
+	// Consumption of each meeting and organization.
+	if err := accounting.Configure(lookup); err != nil {
+		return nil, nil, fmt.Errorf("init accounting: %w", err)
+	}
+	metric.Register(accounting.Metric(tenant.MetricPrefix))
+
+	// Read-only maintenance mode.
+	metric.Register(maintenance.Metric)
+	introspect.Register("maintenance", func() any {
+		status, _ := maintenance.Current()
+		return status
+	})
+
+	// Events about the connections.
+	lifecycleBackground, err := lifecycle.New(lookup, messageBus)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init connection events: %w", err)
+	}
+	metric.Register(lifecycle.Metric)
+	backgroundTasks = append(backgroundTasks, introspect.Task("lifecycle", lifecycleBackground))
+
+	// Webhooks.
+	webhookBackground, err := webhook.New(lookup, auService)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init webhooks: %w", err)
+	}
+	metric.Register(webhook.Metric)
+	backgroundTasks = append(backgroundTasks, introspect.Task("webhooks", webhookBackground))
+
+	// MQTT bridge.
+	mqttBackground, err := mqtt.New(lookup, auService)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init mqtt bridge: %w", err)
+	}
+	metric.Register(mqtt.Metric)
+	backgroundTasks = append(backgroundTasks, introspect.Task("mqtt", mqttBackground))
+
+	// Inter client communication.
+	iccService, iccBackground := icc.New(messageBus, flow)
+	backgroundTasks = append(backgroundTasks, introspect.Task("icc", iccBackground))
+
+	// Data of remote instances.
+	federationService, err := federation.New(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init federation: %w", err)
+	}
+	metric.Register(federation.Metric)
+
+	// Feature flags.
+	featureBackground, err := feature.New(lookup, messageBus)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init feature flags: %w", err)
+	}
+	reload.Register("features", feature.Reload)
+	introspect.Register("features", func() any { return feature.Flags() })
+	backgroundTasks = append(backgroundTasks, introspect.Task("features", featureBackground))
+
+	// Reload settings on SIGHUP.
+	backgroundTasks = append(backgroundTasks, introspect.Task("reload", reload.Watch))
+
+	// Tell systemd, that the service is alive. The watchdog uses the health
+	// route, so it fails, when the server does not answer.
+	watchdog := systemd.Watchdog(func(ctx context.Context) error { return requestHealth(ctx, lookup) })
+	backgroundTasks = append(backgroundTasks, introspect.Task("systemd_watchdog", watchdog))
+
+	// Reload settings, when the config file or directory changes.
+	watchBackground, err := reload.WatchFiles(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init config watch: %w", err)
+	}
+	backgroundTasks = append(backgroundTasks, introspect.Task("config_watch", watchBackground))
 
 	// Start metrics.
 	metric.Register(metric.Runtime)
 	metricTime, err := environment.ParseDuration(envMetricInterval.Value(lookup))
 	if err != nil {
-		return nil, fmt.Errorf("invalid value for `METRIC_INTERVAL`, expected duration got %s: %w", envMetricInterval.Value(lookup), err)
+		return nil, nil, fmt.Errorf("invalid value for `METRIC_INTERVAL`, expected duration got %s: %w", envMetricInterval.Value(lookup), err)
 	}
 
 	metricSaveInterval, err := environment.ParseDuration(envMetricSaveInterval.Value(lookup))
 	if err != nil {
-		return nil, fmt.Errorf("invalid value for `METRIC_SAVE_INTERVAL`, expected duration got %s: %w", envMetricInterval.Value(lookup), err)
+		return nil, nil, fmt.Errorf("invalid value for `METRIC_SAVE_INTERVAL`, expected duration got %s: %w", envMetricInterval.Value(lookup), err)
 	}
 
 	if metricTime > 0 {
 		runMetirc := func(ctx context.Context, errorHandler func(error)) {
-			metric.Loop(ctx, metricTime, log.Default())
+			metric.Loop(ctx, metricTime, logging.For(logging.Metric))
 		}
-		backgroundTasks = append(backgroundTasks, runMetirc)
+		backgroundTasks = append(backgroundTasks, introspect.Task("metric", runMetirc))
+	}
+
+	// HTTP server settings.
+	httpConfig, err := http.NewConfig(lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init http config: %w", err)
+	}
+	httpConfig.ResumeStore = messageBus
+	httpConfig.ICC = iccService
+	httpConfig.Federation = federationService
+
+	readinessChecks := map[string]http.ReadinessCheck{
+		"datastore":  flow.Ping,
+		"messagebus": messageBus.Ping,
+		"auth":       authService.Ready,
+		"cache":      flow.CacheWarm,
+		"replicas":   messageBus.CheckReplicaLag,
+	}
+
+	// The connections of all organizations are closed by forced logouts and
+	// terminations.
+	closers := logout.Closers{authService}
+
+	// Organizations with their own database and message bus.
+	if tenant.Isolated() {
+		httpConfig.Tenants = make(map[string]http.Tenant)
+		for name, tenantLookup := range tenant.Lookups(lookup) {
+			t, tenantBackground, tenantReadiness, err := initTenant(name, tenantLookup, publicAccessOnly)
+			if err != nil {
+				return nil, nil, fmt.Errorf("init tenant %s: %w", name, err)
+			}
+
+			httpConfig.Tenants[name] = t
+			closers = append(closers, t.Auth.(logout.Closer))
+			backgroundTasks = append(backgroundTasks, tenantBackground...)
+			for check, tenantCheck := range tenantReadiness {
+				readinessChecks[check] = tenantReady(readinessChecks[check], name, tenantCheck)
+			}
+		}
+	}
+
+	// Forced logouts and terminations on all instances.
+	logoutService, logoutBackground := logout.New(messageBus, closers)
+	metric.Register(logout.Metric)
+	backgroundTasks = append(backgroundTasks, introspect.Task("forced_logout", logoutBackground))
+	httpConfig.ForcedLogout = logoutService
+	httpConfig.Terminate = logoutService
+
+	// Startup self test.
+	selfTest, err := strconv.ParseBool(envSelfTest.Value(lookup))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value for `%s`, expected boolean got %s: %w", envSelfTest.Key, envSelfTest.Value(lookup), err)
+	}
+
+	selfTestTimeout, err := environment.ParseDuration(envSelfTestTimeout.Value(lookup))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envSelfTestTimeout.Key, envSelfTestTimeout.Value(lookup), err)
+	}
+
+	selfTestSteps := []selfTestStep{
+		{"auth", func(ctx context.Context) error { return auth.CheckProvider(ctx, lookup) }},
+		{"datastore", flow.CheckRead},
+		{"messagebus", messageBus.ReceiveLatest},
 	}
 
 	metricStorage := messageBus
@@ -186,10 +911,108 @@ func initService(lookup environment.Environmenter) (func(context.Context) error,
 			go bg(ctx, oserror.Handle)
 		}
 
+		if selfTest {
+			if err := runSelfTest(ctx, selfTestTimeout, selfTestSteps); err != nil {
+				return err
+			}
+		}
+
 		// Start http server.
-		fmt.Printf("Listen on %s\n", listenAddr)
-		return http.Run(ctx, listenAddr, authService, auService, metricStorage, metricSaveInterval)
+		slog.Info("Listen", "addr", listenAddr)
+		return http.Run(ctx, httpConfig, listenAddr, authService, auService, metricStorage, metricSaveInterval, readinessChecks)
 	}
 
-	return service, nil
+	return service, readinessChecks, nil
+}
+
+// initTenant creates the backend of an organization with tenant isolation. The
+// metric values and background tasks have the prefix of the tenant.
+func initTenant(name string, lookup environment.Environmenter, publicAccessOnly bool) (http.Tenant, []func(context.Context, func(error)), map[string]http.ReadinessCheck, error) {
+	prefix := tenant.MetricPrefix(name)
+
+	messageBus, err := redis.New(lookup)
+	if err != nil {
+		return http.Tenant{}, nil, nil, fmt.Errorf("init message bus: %w", err)
+	}
+	metric.Register(metric.Prefixed(prefix, messageBus.Metric))
+
+	flow, flowBackground, err := autoupdate.NewFlow(lookup, messageBus, publicAccessOnly)
+	if err != nil {
+		return http.Tenant{}, nil, nil, fmt.Errorf("init autoupdate data flow: %w", err)
+	}
+	metric.Register(metric.Prefixed(prefix, flow.Metric))
+	introspect.Register(prefix+"cache", flow.Introspect)
+	throttle.OnEvict(flow.ResetCache)
+
+	authService, authBackground, err := auth.New(lookup, chaos.LogoutEventer(messageBus))
+	if err != nil {
+		return http.Tenant{}, nil, nil, fmt.Errorf("init connection to auth: %w", err)
+	}
+
+	auService, auBackground, err := autoupdate.New(lookup, flow, restrict.Middleware)
+	if err != nil {
+		return http.Tenant{}, nil, nil, fmt.Errorf("init autoupdate: %w", err)
+	}
+	introspect.Register(prefix+"autoupdate", auService.Introspect)
+
+	iccService, iccBackground := icc.New(messageBus, flow)
+
+	backgroundTasks := []func(context.Context, func(error)){
+		introspect.Task(prefix+"datastore_flow", flowBackground),
+		introspect.Task(prefix+"auth", authBackground),
+		introspect.Task(prefix+"autoupdate", auBackground),
+		introspect.Task(prefix+"icc", iccBackground),
+	}
+
+	readinessChecks := map[string]http.ReadinessCheck{
+		"datastore":  flow.Ping,
+		"messagebus": messageBus.Ping,
+		"auth":       authService.Ready,
+		"cache":      flow.CacheWarm,
+		"replicas":   messageBus.CheckReplicaLag,
+	}
+
+	t := http.Tenant{
+		Auth:       authService,
+		Autoupdate: auService,
+		MessageBus: messageBus,
+		ICC:        iccService,
+	}
+	return t, backgroundTasks, readinessChecks, nil
+}
+
+// tenantReady returns a readiness check, that also runs the check of a tenant.
+func tenantReady(check http.ReadinessCheck, name string, tenantCheck http.ReadinessCheck) http.ReadinessCheck {
+	return func(ctx context.Context) error {
+		if err := check(ctx); err != nil {
+			return err
+		}
+
+		if err := tenantCheck(ctx); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+		return nil
+	}
+}
+
+// selfTestStep is one component, that is checked at startup.
+type selfTestStep struct {
+	component string
+	check     func(context.Context) error
+}
+
+// runSelfTest runs the steps one after the other. It returns an error with
+// the name of the first component, that failed.
+func runSelfTest(ctx context.Context, timeout time.Duration, steps []selfTestStep) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, step := range steps {
+		start := time.Now()
+		if err := step.check(ctx); err != nil {
+			return fmt.Errorf("startup self test failed at component %s: %w", step.component, err)
+		}
+		slog.Info("Self test passed", "component", step.component, "duration", time.Since(start))
+	}
+	return nil
 }
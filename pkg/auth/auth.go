@@ -6,15 +6,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
 	"github.com/OpenSlides/openslides-autoupdate-service/internal/oserror"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracecontext"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/ostcar/topic"
 
@@ -27,21 +30,20 @@ const (
 	DebugCookieKey = "auth-dev-cookie-key"
 )
 
+var logger = logging.For(logging.Auth)
+
 var (
-	envAuthHost     = environment.NewVariable("KEYCLOAK_HOST", "localhost", "Host of the auth service.")
-	envAuthPort     = environment.NewVariable("KEYCLOAK_PORT", "9004", "Port of the auth service.")
-	envAuthProtocol = environment.NewVariable("KEYCLOAK_PROTOCOL", "http", "Protocol of the auth service.")
+	envAuthHost     = environment.NewVariable("KEYCLOAK_HOST", "localhost", "Host of the auth service.").Alias("AUTH_HOST")
+	envAuthPort     = environment.NewVariable("KEYCLOAK_PORT", "9004", "Port of the auth service.").Alias("AUTH_PORT")
+	envAuthProtocol = environment.NewVariable("KEYCLOAK_PROTOCOL", "http", "Protocol of the auth service.").Alias("AUTH_PROTOCOL")
 	envAuthFake     = environment.NewVariable("AUTH_FAKE", "false", "Use user id 1 for every request. Ignores all other auth environment variables.")
 
 	envAuthTokenFile  = environment.NewVariable("AUTH_TOKEN_KEY_FILE", "/run/secrets/auth_token_key", "Key to sign the JWT auth tocken.")
 	envAuthCookieFile = environment.NewVariable("AUTH_COOKIE_KEY_FILE", "/run/secrets/auth_cookie_key", "Key to sign the JWT auth cookie.")
 
-	keycloakUrl                        = environment.NewVariable("OPENSLIDES_KEYCLOAK_URL", "", "The issuer of the token.")
-	issuer                             = environment.NewVariable("OPENSLIDES_TOKEN_ISSUER", "", "The issuer of the token.")
-	clientID                           = environment.NewVariable("OPENSLIDES_AUTH_CLIENT_ID", "", "The client ID of the application.")
-	ctx                                = context.Background()
-	oidcProvider *oidc.Provider        = nil
-	verifier     *oidc.IDTokenVerifier = nil
+	keycloakUrl = environment.NewVariable("OPENSLIDES_KEYCLOAK_URL", "", "The issuer of the token.")
+	issuer      = environment.NewVariable("OPENSLIDES_TOKEN_ISSUER", "", "The issuer of the token.")
+	clientID    = environment.NewVariable("OPENSLIDES_AUTH_CLIENT_ID", "", "The client ID of the application.")
 )
 
 type CustomTransport struct {
@@ -56,7 +58,7 @@ func (t *CustomTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		// Modify the request to point to the new host and scheme
 		req.URL.Scheme = keycloakUrl.Scheme
 		req.URL.Host = keycloakUrl.Host
-		fmt.Printf("Redirecting to: %s\n", req.URL.String())
+		logger.Debug("Redirecting well-known request", "url", req.URL.String())
 	}
 
 	// Use the base RoundTripper to perform the request
@@ -71,9 +73,13 @@ const (
 	authHeader = "Authentication"
 )
 
-func validateAccessToken(tokenString string) (*oidc.IDToken, error) {
+func (a *Auth) validateAccessToken(ctx context.Context, tokenString string) (*oidc.IDToken, error) {
+	if a.verifier == nil {
+		return nil, fmt.Errorf("no token verifier configured")
+	}
+
 	// Parse and verify the token using the verifier.
-	idToken, err := verifier.Verify(ctx, tokenString)
+	idToken, err := a.verifier.Verify(ctx, tokenString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify token: %v", err)
 	}
@@ -96,10 +102,34 @@ type LogoutEventer interface {
 type Auth struct {
 	fake bool
 
+	// verifier checks the tokens of the identity provider. Each Auth has its
+	// own verifier, so tenants do not accept the tokens of each other.
+	verifier *oidc.IDTokenVerifier
+
 	logedoutSessions *topic.Topic[string]
 
-	tokenKey  string
-	cookieKey string
+	tokenKey  *keyRing
+	cookieKey *keyRing
+
+	connections struct {
+		mu sync.Mutex
+		m  map[*connection]struct{}
+	}
+
+	// revokedUsers is the time of the last forced logout of each user. Tokens
+	// of the user, that were issued until then, are rejected.
+	revokedUsers struct {
+		mu sync.Mutex
+		m  map[int]time.Time
+	}
+}
+
+// connection is an authenticated request, that can be closed with
+// CloseConnections or TerminateConnections.
+type connection struct {
+	userID    int
+	sessionID string
+	cancel    context.CancelCauseFunc
 }
 
 // New initializes the Auth object.
@@ -107,23 +137,33 @@ type Auth struct {
 // Returns the initialized Auth objectand a function to be called in the
 // background.
 func New(lookup environment.Environmenter, messageBus LogoutEventer) (*Auth, func(context.Context, func(error)), error) {
+	fake, _ := strconv.ParseBool(envAuthFake.Value(lookup))
+	if fake {
+		// The fake auth does not need keycloak.
+		return NewFake(), func(context.Context, func(error)) {}, nil
+	}
 
-	http.DefaultTransport = &CustomTransport{
-		Base:        http.DefaultTransport,
-		keycloakUrl: keycloakUrl.Value(lookup),
+	// The client is only used for the requests to the identity provider of
+	// this Auth. The verifier loads the signing keys with it.
+	client := &http.Client{
+		Transport: &CustomTransport{
+			Base:        &tracecontext.Transport{},
+			keycloakUrl: keycloakUrl.Value(lookup),
+		},
 	}
+	providerCtx := oidc.ClientContext(context.Background(), client)
 
 	var err error
 
 	var oidcProvider *oidc.Provider
 
 	for {
-		oidcProvider, err = oidc.NewProvider(ctx, issuer.Value(lookup))
+		oidcProvider, err = oidc.NewProvider(providerCtx, issuer.Value(lookup))
 		if err == nil {
 			break
 		}
 
-		log.Println("Fehler beim Initialisieren des OIDC-Providers (%v). Neuer Versuch in 2s ...\n", err)
+		logger.Warn("Initializing OIDC provider failed, retrying in 2s", "error", err)
 		time.Sleep(2 * time.Second)
 	}
 
@@ -131,9 +171,7 @@ func New(lookup environment.Environmenter, messageBus LogoutEventer) (*Auth, fun
 	oidcConfig := &oidc.Config{
 		ClientID: clientID.Value(lookup),
 	}
-	verifier = oidcProvider.Verifier(oidcConfig)
-
-	fake, _ := strconv.ParseBool(envAuthFake.Value(lookup))
+	verifier := oidcProvider.Verifier(oidcConfig)
 
 	authToken, err := environment.ReadSecretWithDefault(lookup, envAuthTokenFile, DebugTokenKey)
 	if err != nil {
@@ -145,28 +183,78 @@ func New(lookup environment.Environmenter, messageBus LogoutEventer) (*Auth, fun
 		return nil, nil, fmt.Errorf("reading cookie token: %w", err)
 	}
 
+	overlap, err := environment.ParseDuration(envKeyOverlap.Value(lookup))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envKeyOverlap.Key, envKeyOverlap.Value(lookup), err)
+	}
+
 	a := &Auth{
-		fake:             fake,
+		verifier:         verifier,
 		logedoutSessions: topic.New[string](),
-		tokenKey:         authToken,
-		cookieKey:        cookieToken,
+		tokenKey:         newKeyRing(lookup, envAuthTokenFile, authToken, overlap),
+		cookieKey:        newKeyRing(lookup, envAuthCookieFile, cookieToken, overlap),
 	}
 
 	// Make sure the topic is not empty
 	a.logedoutSessions.Publish("")
 
 	background := func(ctx context.Context, errorHandler func(error)) {
-		if fake {
-			return
-		}
-
 		go a.listenOnLogouts(ctx, messageBus, errorHandler)
 		go a.pruneOldData(ctx)
+		go a.watchKeys(ctx, errorHandler)
 	}
 
 	return a, background, nil
 }
 
+// CheckProvider tries once to load the OIDC configuration of the issuer. It
+// returns an error, if Keycloak is not reachable.
+func CheckProvider(ctx context.Context, lookup environment.Environmenter) error {
+	if issuer.Value(lookup) == "" {
+		return fmt.Errorf("`%s` is not set", issuer.Key)
+	}
+
+	client := &http.Client{
+		Transport: &CustomTransport{
+			Base:        http.DefaultTransport,
+			keycloakUrl: keycloakUrl.Value(lookup),
+		},
+	}
+
+	if _, err := oidc.NewProvider(oidc.ClientContext(ctx, client), issuer.Value(lookup)); err != nil {
+		return fmt.Errorf("loading oidc configuration: %w", err)
+	}
+	return nil
+}
+
+// Ready returns an error, if the auth can not check tokens, because the OIDC
+// verifier is not set up or a key is not loaded.
+func (a *Auth) Ready(ctx context.Context) error {
+	if a.fake {
+		return nil
+	}
+
+	if a.verifier == nil {
+		return fmt.Errorf("no token verifier configured")
+	}
+
+	for name, ring := range map[string]*keyRing{"token": a.tokenKey, "cookie": a.cookieKey} {
+		if ring == nil {
+			return fmt.Errorf("auth %s key is not loaded", name)
+		}
+
+		if current, _ := ring.keys(clock.Now()); current == "" {
+			return fmt.Errorf("auth %s key is empty", name)
+		}
+	}
+	return nil
+}
+
+// NewFake returns an Auth, that uses the user id 1 for every request.
+func NewFake() *Auth {
+	return &Auth{fake: true}
+}
+
 // Authenticate uses the headers from the given request to get the user id. The
 // returned context will be cancled, if the session is revoked.
 func (a *Auth) Authenticate(w http.ResponseWriter, r *http.Request) (context.Context, error) {
@@ -176,14 +264,13 @@ func (a *Auth) Authenticate(w http.ResponseWriter, r *http.Request) (context.Con
 
 	ctx := r.Context()
 
-	p := new(OpenSlidesClaims)
 	// 0 means anonymous user
-	p.UserID = 0
-	if err := a.loadToken(w, r, p); err != nil {
+	userID, sessionID, err := a.loadClaims(w, r)
+	if err != nil {
 		return nil, fmt.Errorf("reading token: %w", err)
 	}
 
-	if p.UserID == 0 {
+	if userID == 0 {
 		return a.AuthenticatedContext(ctx, 0), nil
 	}
 
@@ -192,18 +279,20 @@ func (a *Auth) Authenticate(w http.ResponseWriter, r *http.Request) (context.Con
 		return nil, fmt.Errorf("getting already logged out sessions: %w", err)
 	}
 	for _, sid := range sessionIDs {
-		if sid == p.SessionID {
+		if sid == sessionID {
 			return nil, &authError{"invalid session", nil}
 		}
 	}
 
-	userID := p.UserID
-	ctx, cancelCtx := context.WithCancel(a.AuthenticatedContext(ctx, userID))
+	ctx, cancelCtx := context.WithCancelCause(a.AuthenticatedContext(ctx, userID))
+	conn := &connection{userID: userID, sessionID: sessionID, cancel: cancelCtx}
+	a.addConnection(conn)
 
-	println("Authenticated user: ", userID)
+	logger.Debug("Authenticated user", "user_id", userID)
 
 	go func() {
-		defer cancelCtx()
+		defer cancelCtx(nil)
+		defer a.removeConnection(conn)
 
 		var cid uint64
 		var sessionIDs []string
@@ -215,7 +304,7 @@ func (a *Auth) Authenticate(w http.ResponseWriter, r *http.Request) (context.Con
 			}
 
 			for _, sid := range sessionIDs {
-				if sid == p.SessionID {
+				if sid == sessionID {
 					return
 				}
 			}
@@ -225,6 +314,29 @@ func (a *Auth) Authenticate(w http.ResponseWriter, r *http.Request) (context.Con
 	return ctx, nil
 }
 
+// claimsPool reuses the claims for decoding tokens. They are decoded on each
+// request and each reconnect.
+var claimsPool = sync.Pool{
+	New: func() any { return new(OpenSlidesClaims) },
+}
+
+// loadClaims returns the user id and the session id from the token of the
+// request. The user id is 0 for a request without token.
+func (a *Auth) loadClaims(w http.ResponseWriter, r *http.Request) (int, string, error) {
+	payload := claimsPool.Get().(*OpenSlidesClaims)
+	defer claimsPool.Put(payload)
+	*payload = OpenSlidesClaims{}
+
+	if err := a.loadToken(w, r, payload); err != nil {
+		return 0, "", err
+	}
+
+	if a.userRevoked(payload.UserID, payload.IssuedAt) {
+		return 0, "", authError{"all sessions of the user were revoked", nil}
+	}
+	return payload.UserID, payload.SessionID, nil
+}
+
 // AuthenticatedContext returns a new context that contains an userID.
 //
 // Should only used for internal URLs. All other URLs should use auth.Authenticate.
@@ -250,6 +362,90 @@ func (a *Auth) FromContext(ctx context.Context) int {
 	return v.(int)
 }
 
+func (a *Auth) addConnection(conn *connection) {
+	a.connections.mu.Lock()
+	defer a.connections.mu.Unlock()
+
+	if a.connections.m == nil {
+		a.connections.m = make(map[*connection]struct{})
+	}
+	a.connections.m[conn] = struct{}{}
+}
+
+func (a *Auth) removeConnection(conn *connection) {
+	a.connections.mu.Lock()
+	defer a.connections.mu.Unlock()
+
+	delete(a.connections.m, conn)
+}
+
+// CloseConnections closes the requests of a session or of all sessions of a
+// user. Only one of the arguments has to be set. The session is also revoked
+// like with a logout event, so it can not be used again. For a user, all
+// tokens issued until now are revoked.
+//
+// Returns the number of closed requests.
+func (a *Auth) CloseConnections(sessionID string, userID int) int {
+	if userID != 0 {
+		a.revokeUser(userID)
+	}
+
+	closed := a.TerminateConnections(sessionID, userID, nil)
+
+	if sessionID != "" && a.logedoutSessions != nil {
+		a.logedoutSessions.Publish(sessionID)
+	}
+	return closed
+}
+
+// revokeUser rejects all tokens of the user, that were issued until now.
+func (a *Auth) revokeUser(userID int) {
+	a.revokedUsers.mu.Lock()
+	defer a.revokedUsers.mu.Unlock()
+
+	if a.revokedUsers.m == nil {
+		a.revokedUsers.m = make(map[int]time.Time)
+	}
+	a.revokedUsers.m[userID] = clock.Now()
+}
+
+// userRevoked tells, if a token of the user with the issue time issuedAt was
+// revoked by a forced logout.
+func (a *Auth) userRevoked(userID int, issuedAt int64) bool {
+	if userID == 0 {
+		return false
+	}
+
+	a.revokedUsers.mu.Lock()
+	defer a.revokedUsers.mu.Unlock()
+
+	revoked, ok := a.revokedUsers.m[userID]
+	// The issue time has only seconds, so a token of the same second is also
+	// revoked.
+	return ok && issuedAt <= revoked.Unix()
+}
+
+// TerminateConnections closes the requests of a session or of all sessions of
+// a user like CloseConnections, but the session is not revoked. The context of
+// each request is canceled with the cause, so the handler can tell the client
+// the reason.
+//
+// Returns the number of closed requests.
+func (a *Auth) TerminateConnections(sessionID string, userID int, cause error) int {
+	a.connections.mu.Lock()
+	defer a.connections.mu.Unlock()
+
+	closed := 0
+	for conn := range a.connections.m {
+		if (sessionID != "" && conn.sessionID == sessionID) || (userID != 0 && conn.userID == userID) {
+			conn.cancel(cause)
+			delete(a.connections.m, conn)
+			closed++
+		}
+	}
+	return closed
+}
+
 // listenOnLogouts listen on logout events and closes the connections.
 func (a *Auth) listenOnLogouts(ctx context.Context, logoutEventer LogoutEventer, errHandler func(error)) {
 	if errHandler == nil {
@@ -264,7 +460,9 @@ func (a *Auth) listenOnLogouts(ctx context.Context, logoutEventer LogoutEventer,
 			}
 
 			errHandler(fmt.Errorf("receiving logout event: %w", err))
-			time.Sleep(time.Second)
+			if err := clock.Sleep(ctx, time.Second); err != nil {
+				return
+			}
 			continue
 		}
 
@@ -272,70 +470,108 @@ func (a *Auth) listenOnLogouts(ctx context.Context, logoutEventer LogoutEventer,
 	}
 }
 
-// pruneOldData removes old logout events.
+// pruneOldData removes old logout events and forced logouts of users.
 func (a *Auth) pruneOldData(ctx context.Context) {
-	tick := time.NewTicker(5 * time.Minute)
+	tick := clock.NewTicker(5 * time.Minute)
 	defer tick.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-tick.C:
-			a.logedoutSessions.Prune(time.Now().Add(-pruneTime))
+		case <-tick.C():
+			a.logedoutSessions.Prune(clock.Now().Add(-pruneTime))
+			a.pruneRevokedUsers(clock.Now().Add(-pruneTime))
+		}
+	}
+}
+
+// pruneRevokedUsers removes the forced logouts before the time. The tokens
+// issued before them are already expired.
+func (a *Auth) pruneRevokedUsers(before time.Time) {
+	a.revokedUsers.mu.Lock()
+	defer a.revokedUsers.mu.Unlock()
+
+	for userID, revoked := range a.revokedUsers.m {
+		if revoked.Before(before) {
+			delete(a.revokedUsers.m, userID)
 		}
 	}
 }
 
 // TrimPrefixCaseInsensitive trims the prefix from the string s. The prefix is
+// compared case insensitive.
 func TrimPrefixCaseInsensitive(s, prefix string) string {
-	if strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix)) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
 		return s[len(prefix):]
 	}
 	return s
 }
 
-// loadToken loads and validates the token. If the token is expired, it tries
-// to renew it and writes the new token to the responsewriter.
-func (a *Auth) loadToken(w http.ResponseWriter, r *http.Request, payload *OpenSlidesClaims) error {
-	header := r.Header.Get(authHeader)
-
+// tokenFromHeader returns the encoded token from the value of the auth
+// header. Returns false, if the header does not contain a bearer token.
+func tokenFromHeader(header string) (string, bool) {
 	encodedToken := TrimPrefixCaseInsensitive(header, "bearer ")
+	if encodedToken == header {
+		return "", false
+	}
+	return encodedToken, true
+}
 
-	if header == encodedToken {
-		// No token. Handle the request as public access requst.
-		return nil
+// parseToken decodes the token into payload. If the signature does not match
+// the current key, the previous key is tried.
+//
+// The returned error is from the jwt package. It must not panic on any input.
+func parseToken(encodedToken string, currentKey, previousKey string, payload *OpenSlidesClaims) error {
+	_, err := jwt.ParseWithClaims(encodedToken, payload, func(token *jwt.Token) (interface{}, error) {
+		return []byte(currentKey), nil
+	})
+
+	var signatureErr *jwt.ValidationError
+	if previousKey != "" && errors.As(err, &signatureErr) && signatureErr.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+		// The key was rotated. Tokens signed with the old key are valid for
+		// the overlap time.
+		_, err = jwt.ParseWithClaims(encodedToken, payload, func(token *jwt.Token) (interface{}, error) {
+			return []byte(previousKey), nil
+		})
 	}
 
-	token_validated, err := validateAccessToken(encodedToken)
-	println("Token validated: ", token_validated)
+	return err
+}
 
-	token, err := jwt.ParseWithClaims(encodedToken, payload, func(token *jwt.Token) (interface{}, error) {
-		return []byte(a.tokenKey), nil
-	})
+// loadToken loads and validates the token. A token, that is expired or that
+// has no valid signature, returns an authError and payload stays anonymous.
+func (a *Auth) loadToken(w http.ResponseWriter, r *http.Request, payload *OpenSlidesClaims) error {
+	encodedToken, ok := tokenFromHeader(r.Header.Get(authHeader))
+	if !ok {
+		// No token. Handle the request as public access requst.
+		return nil
+	}
 
-	claims, _ := token.Claims.(*OpenSlidesClaims)
-	fmt.Printf("UserID: %d\n", claims.UserID)
-	//fmt.Printf("Issuer: %s\n", claims.Issuer)
+	idToken, err := a.validateAccessToken(r.Context(), encodedToken)
+	if err == nil {
+		// The identity provider already parsed and verified the token. Its
+		// claims are used instead of parsing the token again.
+		if err := idToken.Claims(payload); err != nil {
+			return authError{"invalid token claims", err}
+		}
+		logger.Debug("Token claims", "user_id", payload.UserID)
+		return nil
+	}
+	logger.Debug("Token not validated by the identity provider", "error", err)
 
-	payload.UserID = claims.UserID
+	currentKey, previousKey := a.tokenKey.keys(clock.Now())
+	if err := parseToken(encodedToken, currentKey, previousKey, payload); err != nil {
+		// The claims of a token, that was not verified, must not be used.
+		*payload = OpenSlidesClaims{}
 
-	if err != nil {
 		var invalid *jwt.ValidationError
 		if errors.As(err, &invalid) {
 			return a.handleInvalidToken(r.Context(), invalid, w, encodedToken)
 		}
+		return authError{"invalid auth token", err}
 	}
-
-	//var claims OpenSlidesClaims
-	//if err := token.Claims(&claims); err != nil {
-	//	log.Fatalf("Failed to parse claims: %v", err)
-	//}
-	//
-	//fmt.Printf("UserID: %s\n", payload.UserID)
-	////fmt.Printf("Issuer: %s\n", claims.Issuer)
-
-	payload.UserID = claims.UserID
+	logger.Debug("Token claims", "user_id", payload.UserID)
 
 	return nil
 }
@@ -345,7 +581,7 @@ func (a *Auth) handleInvalidToken(ctx context.Context, invalid *jwt.ValidationEr
 		return authError{"auth token is expired", invalid}
 	}
 
-	return nil
+	return authError{"invalid auth token", invalid}
 }
 
 func tokenExpired(errNo uint32) bool {
@@ -2,167 +2,179 @@ package auth_test
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
-	"strings"
 	"testing"
 	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/auth"
-	"github.com/OpenSlides/openslides-autoupdate-service/pkg/auth/authtest"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-func parseURL(raw string) (host, port, protocol string) {
-	parsed, err := url.Parse(raw)
+// testProvider is an identity provider, that signs tokens with its own rsa
+// key.
+type testProvider struct {
+	URL string
+	key *rsa.PrivateKey
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
-		panic(fmt.Sprintf("parsing url %s: %v", raw, err))
+		t.Fatalf("generating key: %v", err)
 	}
 
-	return parsed.Hostname(), parsed.Port(), parsed.Scheme
+	p := &testProvider{key: key}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]any{
+				"issuer":   p.URL,
+				"jwks_uri": p.URL + "/certs",
+			})
+
+		case "/certs":
+			json.NewEncoder(w).Encode(map[string]any{
+				"keys": []map[string]string{{
+					"kty": "RSA",
+					"alg": "RS256",
+					"use": "sig",
+					"kid": "test",
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				}},
+			})
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	p.URL = server.URL
+	return p
 }
 
-func TestAuth(t *testing.T) {
-	const invalidSecret = "wrong-auth-dev-key"
-	const cookieName = "refreshId"
+// sign returns the auth header with a token of the provider for the user.
+func (p *testProvider) sign(t *testing.T, userID int) string {
+	t.Helper()
 
-	cookie, authHeader, validHeader, err := authtest.ValidTokens([]byte(auth.DebugCookieKey), []byte(auth.DebugTokenKey), 1)
+	claims := auth.OpenSlidesClaims{UserID: userID, SessionID: "123"}
+	claims.Issuer = p.URL
+	claims.Audience = "autoupdate"
+	claims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(p.key)
 	if err != nil {
-		t.Fatalf("Create tokens: %v", err)
+		t.Fatalf("signing token: %v", err)
 	}
+	return "bearer " + signed
+}
 
-	validCookie := cookie.String()
+// newProviderAuth returns an Auth with the debug keys, that uses the identity
+// provider.
+func newProviderAuth(t *testing.T, provider *testProvider) *auth.Auth {
+	t.Helper()
 
-	oldHeader, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId":    1,
-		"sessionId": "123",
-		"exp":       123,
-	}).SignedString([]byte(auth.DebugTokenKey))
+	a, _, err := auth.New(environment.ForTests{
+		"OPENSLIDES_DEVELOPMENT":    "true",
+		"OPENSLIDES_TOKEN_ISSUER":   provider.URL,
+		"OPENSLIDES_AUTH_CLIENT_ID": "autoupdate",
+	}, nil)
 	if err != nil {
-		t.Fatalf("Can not sign token token: %v", err)
+		t.Fatalf("auth.New: %v", err)
 	}
-	oldHeader = "bearer " + oldHeader
+	return a
+}
 
-	invalidCookie, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sessionId": "123",
-	}).SignedString([]byte(invalidSecret))
-	if err != nil {
-		t.Fatalf("Can not sign cookie token: %v", err)
-	}
-	invalidCookie = cookieName + "=bearer%20" + invalidCookie
+// newTestAuth returns an Auth with the debug keys. Tests use tokens signed
+// with the auth token key, that are not accepted by the identity provider.
+func newTestAuth(t *testing.T) *auth.Auth {
+	t.Helper()
+
+	return newProviderAuth(t, newTestProvider(t))
+}
 
-	invalidHeader, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId":    1,
-		"sessionId": "123",
-	}).SignedString([]byte(invalidSecret))
+func signToken(t *testing.T, key string, claims auth.OpenSlidesClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
 	if err != nil {
-		t.Fatalf("Can not sign token token: %v", err)
+		t.Fatalf("signing token: %v", err)
 	}
-	invalidHeader = "bearer " + invalidHeader
+	return "bearer " + token
+}
 
-	authSRV := httptest.NewServer(&mockAuth{token: "NEWTOKEN"})
-	defer authSRV.Close()
+func TestAuth(t *testing.T) {
+	a := newTestAuth(t)
 
-	host, port, schema := parseURL(authSRV.URL)
-	env := environment.ForTests(map[string]string{
-		"AUTH_HOST":     host,
-		"AUTH_PORT":     port,
-		"AUTH_PROTOCOL": schema,
-	})
-	a, _, _ := auth.New(env, nil)
+	valid := auth.OpenSlidesClaims{UserID: 1, SessionID: "123"}
+	valid.ExpiresAt = time.Now().Add(time.Hour).Unix()
+
+	expired := auth.OpenSlidesClaims{UserID: 1, SessionID: "123"}
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
 
 	for _, tt := range []struct {
-		name    string
-		request *http.Request
-		uid     *string
-		header  string
-		errMSG  string
+		name   string
+		header string
+		uid    int
+		errMSG string
 	}{
 		{
-			"No cookie no token",
-			&http.Request{},
-			nil,
+			"No token",
 			"",
+			0,
 			"",
 		},
 		{
-			"Valid cookie no token",
-			&http.Request{
-				Header: map[string][]string{
-					"Cookie": {validCookie},
-				},
-			},
-			nil,
+			"Other scheme",
+			"basic abc",
+			0,
 			"",
-			"Can not find auth token",
 		},
 		{
-			"No cookie Valid token",
-			&http.Request{
-				Header: map[string][]string{
-					authHeader: {validHeader},
-				},
-			},
-			nil,
+			"Valid token",
+			signToken(t, auth.DebugTokenKey, valid),
+			1,
 			"",
-			"Can not find auth cookie",
 		},
 		{
-			"Invalid cookie Valid token",
-			&http.Request{
-				Header: map[string][]string{
-					"Cookie":   {invalidCookie},
-					authHeader: {validHeader},
-				},
-			},
-			nil,
-			"",
-			"Invalid auth token",
+			"Wrong signature",
+			signToken(t, "attacker-key", valid),
+			0,
+			"invalid auth token",
 		},
 		{
-			"Valid cookie Invalid token",
-			&http.Request{
-				Header: map[string][]string{
-					"Cookie":   {validCookie},
-					authHeader: {invalidHeader},
-				},
-			},
-			nil,
-			"",
-			"Invalid auth token",
+			"Malformed token",
+			"bearer a.b.c",
+			0,
+			"invalid auth token",
 		},
 		{
-			"Valid cookie Valid token",
-			&http.Request{
-				Header: map[string][]string{
-					"Cookie":   {validCookie},
-					authHeader: {validHeader},
-				},
-			},
-			StringPtr("1"),
-			"",
-			"",
-		},
-		{
-			"Valid cookie Valid Old token",
-			&http.Request{
-				Header: map[string][]string{
-					"Cookie":   {validCookie},
-					authHeader: {oldHeader},
-				},
-			},
-			StringPtr("1"),
-			"NEWTOKEN",
-			"",
+			"Expired token",
+			signToken(t, auth.DebugTokenKey, expired),
+			0,
+			"auth token is expired",
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			w := httptest.NewRecorder()
-			ctx, err := a.Authenticate(w, tt.request)
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authentication", tt.header)
+			}
+
+			ctx, err := a.Authenticate(httptest.NewRecorder(), r)
 
 			if tt.errMSG != "" {
 				if err == nil {
@@ -192,23 +204,15 @@ func TestAuth(t *testing.T) {
 				t.Fatalf("Auth returned an unexpected error: %v", err)
 			}
 
-			if got := w.Result().Header.Get(authHeader); got != tt.header {
-				t.Errorf("Got header `%s`, expected `%s`", got, tt.header)
-			}
-
-			if got := a.FromContext(ctx); strings.Compare(got, *tt.uid) != 0 {
-				t.Errorf("Got uid %s, expected %d", got, tt.uid)
+			if got := a.FromContext(ctx); got != tt.uid {
+				t.Errorf("Got uid %d, expected %d", got, tt.uid)
 			}
 		})
 	}
 }
 
-func StringPtr(s string) *string {
-	return &s
-}
-
 func TestFromContext(t *testing.T) {
-	a, _, _ := auth.New(environment.ForTests{}, nil)
+	a := newTestAuth(t)
 
 	t.Run("Empty Context", func(t *testing.T) {
 		defer func() {
@@ -220,151 +224,103 @@ func TestFromContext(t *testing.T) {
 		a.FromContext(context.Background())
 	})
 
-	t.Run("Context from Authenticate", func(t *testing.T) {
-		ctx, err := a.Authenticate(validSession(t))
-		if err != nil {
-			t.Fatalf("Can not create context from Authenticate: %v", err)
-		}
-
-		got := a.FromContext(ctx)
-		if got != "1" {
-			t.Errorf("Got uid %s from auth-context. Expected 1", got)
-		}
-	})
-
 	t.Run("Context from AuthenticatedContext", func(t *testing.T) {
-		ctx := context.Background()
-		userID := 7
-		ctx = a.AuthenticatedContext(ctx, &userID)
+		ctx := a.AuthenticatedContext(context.Background(), 7)
 
-		got := a.FromContext(ctx)
-		if got != 7 {
-			t.Errorf("Got uid %s from auth-context. Expected 7", got)
+		if got := a.FromContext(ctx); got != 7 {
+			t.Errorf("Got uid %d from auth-context. Expected 7", got)
 		}
 	})
 }
 
-func TestLogout(t *testing.T) {
-	shutdownCtx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	var lastErr error
-	errHandler := func(err error) {
-		lastErr = err
+func TestReady(t *testing.T) {
+	if err := auth.NewFake().Ready(context.Background()); err != nil {
+		t.Errorf("fake auth is not ready: %v", err)
 	}
 
-	logouter := NewLockoutEventMock()
-	defer logouter.Close()
-
-	a, bg, _ := auth.New(environment.ForTests{}, logouter)
-	go bg(shutdownCtx, errHandler)
-
-	t.Run("Closing session", func(t *testing.T) {
-		ctx, err := a.Authenticate(validSession(t, withSessionID("session1")))
-		if err != nil {
-			t.Fatalf("Can not authenticat: %v", err)
-		}
-
-		logouter.Send([]string{"session1"})
+	a := newTestAuth(t)
+	if err := a.Ready(context.Background()); err != nil {
+		t.Errorf("auth is not ready: %v", err)
+	}
+}
 
-		timer := time.NewTimer(time.Millisecond)
-		defer timer.Stop()
-		select {
-		case <-ctx.Done():
-		case <-timer.C:
-			t.Errorf("context is not closed after logout")
-		}
+func TestTenantProviders(t *testing.T) {
+	providerA := newTestProvider(t)
+	providerB := newTestProvider(t)
 
-		if lastErr != nil {
-			t.Errorf("Got error on logout: %v", err)
-		}
-	})
+	// The auth of tenant A is created first, so it would use the verifier of
+	// tenant B, if the verifier was shared.
+	authA := newProviderAuth(t, providerA)
+	authB := newProviderAuth(t, providerB)
 
-	t.Run("Already closed session", func(t *testing.T) {
-		_, err := a.Authenticate(validSession(t, withSessionID("session1")))
-		if err == nil {
-			t.Fatalf("Got no error. Expected an auth error")
-		}
+	authenticate := func(a *auth.Auth, header string) (int, error) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authentication", header)
 
-		var clientErr interface {
-			Type() string
-			Error() string
+		ctx, err := a.Authenticate(httptest.NewRecorder(), r)
+		if err != nil {
+			return 0, err
 		}
+		return a.FromContext(ctx), nil
+	}
 
-		if !errors.As(err, &clientErr) {
-			t.Fatalf("Expected a client error, got: %v", err)
-		}
+	if uid, err := authenticate(authA, providerA.sign(t, 1)); err != nil || uid != 1 {
+		t.Errorf("token of tenant A on tenant A: got user %d and error %v, expected user 1", uid, err)
+	}
 
-		if clientErr.Type() != "auth" {
-			t.Fatalf("Got error of type %s, expected `auth`", clientErr.Type())
-		}
-	})
+	if uid, err := authenticate(authB, providerB.sign(t, 2)); err != nil || uid != 2 {
+		t.Errorf("token of tenant B on tenant B: got user %d and error %v, expected user 2", uid, err)
+	}
 
-	t.Run("Closing other session", func(t *testing.T) {
-		ctx, err := a.Authenticate(validSession(t, withSessionID("session2")))
-		if err != nil {
-			t.Fatalf("Can not authenticat: %v", err)
-		}
+	if uid, err := authenticate(authA, providerB.sign(t, 2)); err == nil {
+		t.Errorf("token of tenant B was accepted on tenant A as user %d", uid)
+	}
+}
 
-		logouter.Send([]string{"session3"})
+func TestForcedLogoutOfUser(t *testing.T) {
+	a := newTestAuth(t)
 
-		timer := time.NewTimer(time.Millisecond)
-		defer timer.Stop()
-		select {
-		case <-ctx.Done():
-			t.Errorf("context is closed after logout of other session")
-		case <-timer.C:
-		}
+	// The forced logout happens a minute ago.
+	defer clock.Use(clock.NewFake(time.Now().Add(-time.Minute)))()
 
-		if lastErr != nil {
-			t.Errorf("Got error on logout: %v", err)
-		}
-	})
-}
+	oldClaims := auth.OpenSlidesClaims{UserID: 1, SessionID: "123"}
+	oldClaims.IssuedAt = time.Now().Add(-2 * time.Minute).Unix()
+	oldClaims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	oldToken := signToken(t, auth.DebugTokenKey, oldClaims)
 
-func validSession(t *testing.T, opts ...validOption) (http.ResponseWriter, *http.Request) {
-	config := &validConfig{
-		sessionID: "123",
-	}
-	for _, o := range opts {
-		o(config)
+	authenticate := func(header string) (context.Context, error) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authentication", header)
+		return a.Authenticate(httptest.NewRecorder(), r)
 	}
 
-	validCookie, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sessionId": config.sessionID,
-	}).SignedString([]byte(auth.DebugCookieKey))
+	ctx, err := authenticate(oldToken)
 	if err != nil {
-		t.Fatalf("Can not sign cookie token: %v", err)
+		t.Fatalf("Authenticate before the logout: %v", err)
 	}
-	validCookie = "refreshId=" + validCookie
 
-	validHeader, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId":    1,
-		"sessionId": config.sessionID,
-	}).SignedString([]byte(auth.DebugTokenKey))
-	if err != nil {
-		t.Fatalf("Can not sign token token: %v", err)
+	if closed := a.CloseConnections("", 1); closed != 1 {
+		t.Errorf("CloseConnections closed %d connections, expected 1", closed)
 	}
-	validHeader = "bearer " + validHeader
 
-	w := httptest.NewRecorder()
-	r := &http.Request{
-		Header: map[string][]string{
-			"Cookie":         {validCookie},
-			"Authentication": {validHeader},
-		},
+	if ctx.Err() == nil {
+		t.Errorf("connection was not closed")
 	}
-	return w, r
-}
 
-type validConfig struct {
-	sessionID string
-}
+	if _, err := authenticate(oldToken); err == nil {
+		t.Errorf("reconnect with the token from before the logout was accepted")
+	}
 
-type validOption func(*validConfig)
+	newClaims := oldClaims
+	newClaims.SessionID = "456"
+	newClaims.IssuedAt = time.Now().Unix()
+	if _, err := authenticate(signToken(t, auth.DebugTokenKey, newClaims)); err != nil {
+		t.Errorf("token issued after the logout: %v", err)
+	}
 
-func withSessionID(sid string) validOption {
-	return func(v *validConfig) {
-		v.sessionID = sid
+	otherClaims := oldClaims
+	otherClaims.UserID = 2
+	if _, err := authenticate(signToken(t, auth.DebugTokenKey, otherClaims)); err != nil {
+		t.Errorf("token of another user: %v", err)
 	}
 }
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestLoadClaimsReusesClaims(t *testing.T) {
+	lookup := environment.ForTests{"OPENSLIDES_DEVELOPMENT": "true"}
+	a := &Auth{tokenKey: newKeyRing(lookup, envAuthTokenFile, "token-key", time.Minute)}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, OpenSlidesClaims{
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+		UserID:         5,
+		SessionID:      "session",
+	}).SignedString([]byte("token-key"))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(authHeader, "bearer "+token)
+	userID, sessionID, err := a.loadClaims(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatalf("loadClaims: %v", err)
+	}
+
+	if userID != 5 || sessionID != "session" {
+		t.Errorf("got user %d with session %q, expected user 5 with session \"session\"", userID, sessionID)
+	}
+
+	// The pooled claims of the last call must not leak into a request
+	// without a token.
+	r = httptest.NewRequest("GET", "/", nil)
+	userID, sessionID, err = a.loadClaims(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatalf("loadClaims without token: %v", err)
+	}
+
+	if userID != 0 || sessionID != "" {
+		t.Errorf("got user %d with session %q for a request without token, expected user 0", userID, sessionID)
+	}
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func FuzzParseToken(f *testing.F) {
+	claims := OpenSlidesClaims{UserID: 1, SessionID: "session"}
+	claims.ExpiresAt = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	valid, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("current"))
+	if err != nil {
+		f.Fatalf("creating token: %v", err)
+	}
+
+	f.Add("bearer " + valid)
+	f.Add("Bearer " + valid[:len(valid)-4])
+	f.Add("BEARER a.b.c")
+	f.Add("bearer ")
+	f.Add("bearer eyJhbGciOiJub25lIn0.e30.")
+	f.Add("basic abc")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, header string) {
+		encodedToken, ok := tokenFromHeader(header)
+		if !ok {
+			return
+		}
+
+		if len(encodedToken) >= len(header) {
+			t.Errorf("tokenFromHeader(%q) returned %q without removing the prefix", header, encodedToken)
+		}
+
+		var payload OpenSlidesClaims
+		if err := parseToken(encodedToken, "current", "previous", &payload); err == nil && payload.UserID == 0 && encodedToken == valid {
+			t.Errorf("parseToken(%q) did not decode the user id", encodedToken)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+var envKeyOverlap = environment.NewVariable("AUTH_KEY_ROTATION_OVERLAP", "15m", "Time, the previous auth key stays valid, after the key file was changed.")
+
+// keyReloadInterval defines how often the key files are checked for changes.
+const keyReloadInterval = 10 * time.Second
+
+// keyRing holds the current key from a secret file and the previous key for
+// some time after the file changed.
+type keyRing struct {
+	path    string
+	overlap time.Duration
+
+	mu            sync.RWMutex
+	current       string
+	previous      string
+	previousUntil time.Time
+	modTime       time.Time
+}
+
+// newKeyRing creates a key ring with the key read at startup.
+//
+// The file is only watched, if it exists and the service is not in
+// development mode. Otherwise, path is empty.
+func newKeyRing(lookup environment.Environmenter, pathVariable environment.Variable, key string, overlap time.Duration) *keyRing {
+	k := keyRing{current: key, overlap: overlap}
+
+	if dev, _ := strconv.ParseBool(environment.EnvDevelopment.Value(lookup)); dev {
+		return &k
+	}
+
+	path := pathVariable.Value(lookup)
+	info, err := os.Stat(path)
+	if err != nil {
+		// The key was read from Vault.
+		return &k
+	}
+
+	k.path = path
+	k.modTime = info.ModTime()
+	return &k
+}
+
+// keys returns the current key and the previous key, if it is still valid.
+func (k *keyRing) keys(now time.Time) (current string, previous string) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if now.Before(k.previousUntil) {
+		return k.current, k.previous
+	}
+	return k.current, ""
+}
+
+// reload reads the key file, if it has changed. The old key stays valid for
+// the overlap time.
+//
+// If the file can not be read or is empty, the old key is kept.
+func (k *keyRing) reload(now time.Time) (bool, error) {
+	if k.path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return false, fmt.Errorf("stat %s: %w", k.path, err)
+	}
+
+	k.mu.RLock()
+	unchanged := info.ModTime().Equal(k.modTime)
+	k.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	key, err := os.ReadFile(k.path)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", k.path, err)
+	}
+
+	if len(key) == 0 {
+		return false, fmt.Errorf("key file %s is empty", k.path)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.modTime = info.ModTime()
+	if string(key) == k.current {
+		return false, nil
+	}
+
+	k.previous = k.current
+	k.previousUntil = now.Add(k.overlap)
+	k.current = string(key)
+	return true, nil
+}
+
+// watchKeys checks the key files from time to time and reloads them, when they
+// change.
+//
+// Blocks until the context is done.
+func (a *Auth) watchKeys(ctx context.Context, errorHandler func(error)) {
+	tick := time.NewTicker(keyReloadInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+
+		for name, ring := range map[string]*keyRing{"token": a.tokenKey, "cookie": a.cookieKey} {
+			changed, err := ring.reload(time.Now())
+			if err != nil {
+				errorHandler(fmt.Errorf("reloading auth %s key: %w", name, err))
+				continue
+			}
+
+			if changed {
+				logger.Info("Auth key reloaded", "key", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/coreos/go-oidc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestKeyRing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth_token_key")
+	if err := os.WriteFile(path, []byte("old-key"), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+
+	lookup := environment.ForTests{
+		"OPENSLIDES_DEVELOPMENT": "false",
+		"AUTH_TOKEN_KEY_FILE":    path,
+	}
+	ring := newKeyRing(lookup, envAuthTokenFile, "old-key", time.Minute)
+
+	now := time.Now()
+	if changed, err := ring.reload(now); err != nil || changed {
+		t.Fatalf("reload without change returned %v, %v", changed, err)
+	}
+
+	if err := os.WriteFile(path, []byte("new-key"), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	if err := os.Chtimes(path, now, now.Add(time.Second)); err != nil {
+		t.Fatalf("changing mod time: %v", err)
+	}
+
+	changed, err := ring.reload(now)
+	if err != nil || !changed {
+		t.Fatalf("reload after change returned %v, %v", changed, err)
+	}
+
+	current, previous := ring.keys(now.Add(30 * time.Second))
+	if current != "new-key" || previous != "old-key" {
+		t.Errorf("got keys %q and %q in the overlap, expected new-key and old-key", current, previous)
+	}
+
+	current, previous = ring.keys(now.Add(2 * time.Minute))
+	if current != "new-key" || previous != "" {
+		t.Errorf("got keys %q and %q after the overlap, expected only new-key", current, previous)
+	}
+}
+
+func TestKeyRingDevelopment(t *testing.T) {
+	ring := newKeyRing(environment.ForTests{}, envAuthTokenFile, DebugTokenKey, time.Minute)
+
+	if changed, err := ring.reload(time.Now()); err != nil || changed {
+		t.Errorf("reload in development mode returned %v, %v", changed, err)
+	}
+
+	if current, _ := ring.keys(time.Now()); current != DebugTokenKey {
+		t.Errorf("got key %q, expected the debug key", current)
+	}
+}
+
+func TestPreviousKeyAfterOverlap(t *testing.T) {
+	lookup := environment.ForTests{"OPENSLIDES_DEVELOPMENT": "true"}
+	ring := newKeyRing(lookup, envAuthTokenFile, "new-key", time.Minute)
+	ring.previous = "old-key"
+	a := &Auth{tokenKey: ring}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, OpenSlidesClaims{
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+		UserID:         5,
+	}).SignedString([]byte("old-key"))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(authHeader, "bearer "+token)
+
+	ring.previousUntil = time.Now().Add(time.Minute)
+	if userID, _, err := a.loadClaims(httptest.NewRecorder(), r); err != nil || userID != 5 {
+		t.Errorf("in the overlap, got user %d and error %v, expected user 5", userID, err)
+	}
+
+	ring.previousUntil = time.Now().Add(-time.Second)
+	userID, _, err := a.loadClaims(httptest.NewRecorder(), r)
+	if err == nil {
+		t.Errorf("after the overlap, the token of the previous key was accepted as user %d", userID)
+	}
+
+	if userID != 0 {
+		t.Errorf("after the overlap, got user %d, expected 0", userID)
+	}
+}
+
+func TestReadyWithoutKey(t *testing.T) {
+	a := &Auth{verifier: new(oidc.IDTokenVerifier), tokenKey: &keyRing{current: "token-key"}, cookieKey: &keyRing{}}
+	if err := a.Ready(context.Background()); err == nil {
+		t.Errorf("Ready returned no error for an empty cookie key")
+	}
+
+	a.cookieKey.current = "cookie-key"
+	if err := a.Ready(context.Background()); err != nil {
+		t.Errorf("Ready: %v", err)
+	}
+}
@@ -2,9 +2,6 @@ package auth_test
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
 	"time"
 )
 
@@ -48,20 +45,3 @@ func (m *LockoutEventMock) Send(values []string) {
 func (m *LockoutEventMock) Close() {
 	m.t.Stop()
 }
-
-type mockAuth struct {
-	token string
-}
-
-func (m *mockAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	p := struct {
-		Message string `json:"message"`
-	}{
-		"test auth",
-	}
-	w.Header().Add("Authentication", m.token)
-
-	if err := json.NewEncoder(w).Encode(p); err != nil {
-		http.Error(w, fmt.Sprintf("Can not encode data: %v", err), 500)
-	}
-}
@@ -0,0 +1,130 @@
+// Package clock gives the time to the service.
+//
+// All timing of the background tasks, the backoff and the delays uses the
+// functions of this package instead of the time package. By default, they use
+// the real time. Tests and simulations can replace the clock with a Fake and
+// advance the time deterministically instead of sleeping.
+package clock
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is a source of time.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer sends the time once on its channel. See time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker sends the time on its channel in an interval. See time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// holder is needed, since atomic.Value needs the same concrete type on each
+// store.
+type holder struct {
+	clock Clock
+}
+
+var current atomic.Value
+
+func init() {
+	current.Store(holder{Real{}})
+}
+
+func get() Clock {
+	return current.Load().(holder).clock
+}
+
+// Use replaces the clock of the service. It returns a function to restore the
+// clock, that was used before.
+//
+//	fake := clock.NewFake(time.Now())
+//	defer clock.Use(fake)()
+func Use(c Clock) func() {
+	old := get()
+	current.Store(holder{c})
+	return func() {
+		current.Store(holder{old})
+	}
+}
+
+// Now returns the current time.
+func Now() time.Time {
+	return get().Now()
+}
+
+// Since returns the time elapsed since t.
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// NewTimer creates a timer, that fires after the duration.
+func NewTimer(d time.Duration) Timer {
+	return get().NewTimer(d)
+}
+
+// NewTicker creates a ticker with the interval d. Panics, if d is not
+// positive.
+func NewTicker(d time.Duration) Ticker {
+	return get().NewTicker(d)
+}
+
+// Sleep waits for the duration. It returns early with the error of the
+// context, if the context is done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Real is the clock of the time package.
+type Real struct{}
+
+// Now returns time.Now().
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a time.Timer.
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// NewTicker returns a time.Ticker.
+func (Real) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,115 @@
+package clock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestUse(t *testing.T) {
+	fake := clock.NewFake(start)
+	restore := clock.Use(fake)
+
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("Now() == %s, expected %s", got, start)
+	}
+
+	fake.Advance(time.Minute)
+	if got := clock.Since(start); got != time.Minute {
+		t.Errorf("Since() == %s, expected 1m", got)
+	}
+
+	restore()
+	if clock.Now().Year() == 2024 {
+		t.Errorf("Now() returned the fake time after restore")
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	fake := clock.NewFake(start)
+	timer := fake.NewTimer(time.Second)
+
+	fake.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatalf("timer fired before its time")
+	default:
+	}
+
+	fake.Advance(time.Millisecond)
+	select {
+	case got := <-timer.C():
+		if !got.Equal(start.Add(time.Second)) {
+			t.Errorf("timer sent %s, expected %s", got, start.Add(time.Second))
+		}
+	default:
+		t.Fatalf("timer did not fire")
+	}
+
+	if timer.Stop() {
+		t.Errorf("Stop() of a fired timer returned true")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	fake := clock.NewFake(start)
+	ticker := fake.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		fake.Advance(time.Second)
+		if got := <-ticker.C(); !got.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Errorf("tick %d at %s", i, got)
+		}
+	}
+
+	// Ticks are dropped, when nobody reads them.
+	fake.Advance(10 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Errorf("got a second tick after one advance")
+	default:
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := clock.NewFake(start)
+	defer clock.Use(fake)()
+
+	done := make(chan error)
+	go func() {
+		done <- clock.Sleep(ctx, 5*time.Second)
+	}()
+
+	if err := fake.BlockUntil(ctx, 1); err != nil {
+		t.Fatalf("BlockUntil: %v", err)
+	}
+	fake.Advance(5 * time.Second)
+
+	if err := <-done; err != nil {
+		t.Errorf("Sleep returned %v", err)
+	}
+
+	if fake.Waiters() != 0 {
+		t.Errorf("Waiters() == %d after sleep, expected 0", fake.Waiters())
+	}
+
+	go func() {
+		done <- clock.Sleep(ctx, time.Hour)
+	}()
+	fake.BlockUntil(ctx, 1)
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Sleep with canceled context returned %v", err)
+	}
+}
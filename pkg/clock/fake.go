@@ -0,0 +1,185 @@
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a clock, that only moves, when Advance is called.
+//
+// Timers and tickers fire during Advance in the order of their time. Like
+// with the time package, a ticker drops ticks, when its channel is full.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+
+	// changed is closed and replaced, when a timer or ticker is created or
+	// stopped.
+	changed chan struct{}
+}
+
+// NewFake initializes a fake clock with the start time.
+func NewFake(start time.Time) *Fake {
+	return &Fake{
+		now:     start,
+		changed: make(chan struct{}),
+	}
+}
+
+// fakeWaiter is a timer or a ticker of a fake clock.
+type fakeWaiter struct {
+	clock  *Fake
+	at     time.Time
+	period time.Duration
+	ch     chan time.Time
+}
+
+// Now returns the time of the fake clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// NewTimer creates a timer, that fires, when the clock is advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.add(d, 0)
+}
+
+// NewTicker creates a ticker, that fires each time the clock is advanced by
+// d.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+func (f *Fake) add(d time.Duration, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{
+		clock:  f,
+		at:     f.now.Add(d),
+		period: period,
+		ch:     make(chan time.Time, 1),
+	}
+
+	if period == 0 && d <= 0 {
+		w.ch <- f.now
+		return w
+	}
+
+	f.waiters = append(f.waiters, w)
+	f.notify()
+	return w
+}
+
+// notify wakes up BlockUntil. Has to be called with the lock.
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// remove stops a waiter. Returns false, if it was already stopped or fired.
+func (f *Fake) remove(w *fakeWaiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward and fires all timers and tickers, that are
+// due.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for {
+		sort.SliceStable(f.waiters, func(i, j int) bool {
+			return f.waiters[i].at.Before(f.waiters[j].at)
+		})
+
+		if len(f.waiters) == 0 || f.waiters[0].at.After(end) {
+			break
+		}
+
+		w := f.waiters[0]
+		f.now = w.at
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+
+		if w.period == 0 {
+			f.waiters = f.waiters[1:]
+			f.notify()
+			continue
+		}
+		w.at = w.at.Add(w.period)
+	}
+	f.now = end
+}
+
+// Waiters returns the number of active timers and tickers.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.waiters)
+}
+
+// BlockUntil blocks until there are at least n active timers and tickers.
+//
+// It is used to wait for a goroutine, before the clock is advanced.
+func (f *Fake) BlockUntil(ctx context.Context, n int) error {
+	for {
+		f.mu.Lock()
+		count := len(f.waiters)
+		changed := f.changed
+		f.mu.Unlock()
+
+		if count >= n {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// C returns the channel of the timer or ticker.
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.ch
+}
+
+// Stop stops the timer or ticker.
+func (w *fakeWaiter) Stop() bool {
+	return w.clock.remove(w)
+}
+
+// fakeTicker is a fakeWaiter with the Stop method of a ticker.
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+// Stop stops the ticker.
+func (t fakeTicker) Stop() {
+	t.clock.remove(t.fakeWaiter)
+}
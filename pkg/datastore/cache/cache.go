@@ -1,9 +1,12 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/cache/pendingmap"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
@@ -26,6 +29,9 @@ type Cache struct {
 
 	onlyCollectionField bool
 	collectionField     dskey.Key
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
 }
 
 // New creates an initialized cache instance.
@@ -79,6 +85,8 @@ func (c *Cache) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byt
 // Possible Errors: context.Canceled or context.DeadlineExeeded.
 func (c *Cache) fetchMissing(ctx context.Context, keys []dskey.Key) error {
 	missingKeys := c.data.MarkPending(keys...)
+	c.hits.Add(uint64(len(keys) - len(missingKeys)))
+	c.misses.Add(uint64(len(missingKeys)))
 
 	if len(missingKeys) == 0 {
 		return nil
@@ -128,6 +136,19 @@ func (c *Cache) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte,
 	}
 
 	c.flow.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		if errors.Is(err, flow.ErrResync) {
+			updateFn(nil, err)
+
+			changed, err := c.resync(ctx)
+			if err != nil {
+				updateFn(nil, fmt.Errorf("resync cache: %w", err))
+				return
+			}
+
+			updateFn(changed, nil)
+			return
+		}
+
 		if err != nil {
 			updateFn(nil, err)
 			return
@@ -138,16 +159,67 @@ func (c *Cache) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte,
 	})
 }
 
+// resync fetches all keys in the cache again. It returns the values, that
+// have changed.
+//
+// If the values can not be fetched, the cache is cleared.
+func (c *Cache) resync(ctx context.Context) (map[dskey.Key][]byte, error) {
+	keys := c.data.Keys()
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	old, err := c.data.Get(ctx, keys...)
+	if err != nil {
+		c.data.Reset()
+		return nil, fmt.Errorf("reading cached values: %w", err)
+	}
+
+	data, err := c.flow.Get(ctx, keys...)
+	if err != nil {
+		c.data.Reset()
+		return nil, fmt.Errorf("getting data from flow: %w", err)
+	}
+
+	changed := make(map[dskey.Key][]byte)
+	for key, value := range data {
+		if !bytes.Equal(old[key], value) {
+			changed[key] = value
+		}
+	}
+
+	c.data.SetIfPendingOrExists(changed)
+	return changed, nil
+}
+
 // Len returns the amount of keys in the cache.
 func (c *Cache) Len() int {
 	return c.data.Len()
 }
 
+// Keys returns all keys in the cache. Keys, that are fetched at the moment,
+// are not returned.
+func (c *Cache) Keys() []dskey.Key {
+	return c.data.Keys()
+}
+
 // Size returns the size of all values in the cache.
 func (c *Cache) Size() int {
 	return c.data.Size()
 }
 
+// Stats returns how many keys were found in the cache and how many keys had to
+// be fetched since the cache was created.
+func (c *Cache) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// LockWaits returns, how often a caller had to wait for a lock of the cache
+// and how long it waited together.
+func (c *Cache) LockWaits() (uint64, time.Duration) {
+	return c.data.LockWaits()
+}
+
 // Reset clears the cache.
 func (c *Cache) Reset() {
 	c.data.Reset()
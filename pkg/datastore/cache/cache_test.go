@@ -2,6 +2,7 @@ package cache_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/cache"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
 )
 
 func TestCache_call_Get_returns_the_value_from_flow(t *testing.T) {
@@ -283,3 +285,95 @@ func TestCache_flow_returns_null_should_return_nil(t *testing.T) {
 		t.Errorf("Got %v, expected %v", got, expect)
 	}
 }
+
+func TestCache_Stats_counts_hits_and_misses(t *testing.T) {
+	ctx := context.Background()
+	flow := dsmock.NewFlow(dsmock.YAMLData(`---
+	user/1/username: value
+	user/2/username: other
+	`))
+	c := cache.New(flow)
+
+	if _, err := c.Get(ctx, dskey.MustKey("user/1/username")); err != nil {
+		t.Fatalf("cache.Get(): %v", err)
+	}
+
+	if _, err := c.Get(ctx, dskey.MustKey("user/1/username"), dskey.MustKey("user/2/username")); err != nil {
+		t.Fatalf("cache.Get(): %v", err)
+	}
+
+	hits, misses := c.Stats()
+	if hits != 1 || misses != 2 {
+		t.Errorf("got %d hits and %d misses, expected 1 and 2", hits, misses)
+	}
+}
+
+// resyncFlow is a flow, that sends errors to the update function.
+type resyncFlow struct {
+	dsmock.Stub
+	errors chan error
+}
+
+func (f resyncFlow) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
+	for {
+		select {
+		case err := <-f.errors:
+			updateFn(nil, err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func TestCache_Update_with_resync_fetches_all_values_again(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	myKey1 := dskey.MustKey("user/1/username")
+	myKey2 := dskey.MustKey("user/2/username")
+	stub := dsmock.Stub(dsmock.YAMLData(`---
+	user/1/username: old
+	user/2/username: same
+	`))
+	resync := resyncFlow{Stub: stub, errors: make(chan error)}
+	c := cache.New(resync)
+
+	if _, err := c.Get(ctx, myKey1, myKey2); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	type dataErr struct {
+		data map[dskey.Key][]byte
+		err  error
+	}
+	updates := make(chan dataErr, 2)
+	go c.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		updates <- dataErr{data: data, err: err}
+	})
+
+	stub[myKey1] = []byte("new")
+	resync.errors <- fmt.Errorf("lost messages: %w", flow.ErrResync)
+
+	if got := <-updates; !errors.Is(got.err, flow.ErrResync) {
+		t.Errorf("first update has error %v, expected the resync error", got.err)
+	}
+
+	got := <-updates
+	if got.err != nil {
+		t.Fatalf("second update: %v", got.err)
+	}
+
+	expect := map[dskey.Key][]byte{myKey1: []byte("new")}
+	if !reflect.DeepEqual(got.data, expect) {
+		t.Errorf("got changed data %v, expected %v", converted(got.data), converted(expect))
+	}
+
+	data, err := c.Get(ctx, myKey1)
+	if err != nil {
+		t.Fatalf("Get after resync: %v", err)
+	}
+
+	if string(data[myKey1]) != "new" {
+		t.Errorf("got %s after resync, expected new", data[myKey1])
+	}
+}
@@ -3,7 +3,10 @@ package pendingmap
 import (
 	"context"
 	"errors"
+	"math/bits"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 )
@@ -38,17 +41,121 @@ var ErrNotExist = errors.New("key does not exist")
 // To set a value, there are different methods. SetIfExist() sets values if they
 // are pending or already stored. SetIfPending() sets a value only if it is
 // pending. SetEmptyIfPending() sets a value to its zero value if it is pending.
+//
+// The keys are split into shards by a hash. Each shard has its own lock, so
+// callers with different keys do not block each other. A method, that uses
+// keys of more than one shard, locks the shards in the order of their index.
+// So Get still returns all values at the same version.
 type PendingMap struct {
+	shards [shardCount]shard
+
+	lockWaits    atomic.Uint64
+	lockWaitTime atomic.Int64
+}
+
+// shardCount is the number of shards. It has to be 64, so a set of shards fits
+// in an uint64.
+const shardCount = 64
+
+type shard struct {
 	mu      sync.RWMutex
 	data    map[dskey.Key][]byte
 	pending map[dskey.Key]chan struct{}
+
+	// Each shard is on its own cache line, so the locks of different shards
+	// do not share one.
+	_ [64]byte
 }
 
 // New initializes a pendingDict.
 func New() *PendingMap {
-	return &PendingMap{
-		data:    make(map[dskey.Key][]byte),
-		pending: make(map[dskey.Key]chan struct{}),
+	pm := new(PendingMap)
+	for i := range pm.shards {
+		pm.shards[i].data = make(map[dskey.Key][]byte)
+		pm.shards[i].pending = make(map[dskey.Key]chan struct{})
+	}
+	return pm
+}
+
+// shardIndex returns the index of the shard of a key.
+func shardIndex(key dskey.Key) int {
+	// The id is in the upper bits of the key. Multiplying with the golden
+	// ratio mixes all bits into the upper six bits.
+	return int((uint64(key) * 0x9E3779B97F4A7C15) >> 58)
+}
+
+func (pm *PendingMap) shardOf(key dskey.Key) *shard {
+	return &pm.shards[shardIndex(key)]
+}
+
+// shardSet is a set of shard indexes.
+type shardSet uint64
+
+const allShards = ^shardSet(0)
+
+func shardsOfKeys(keys []dskey.Key) shardSet {
+	var set shardSet
+	for _, key := range keys {
+		set |= 1 << shardIndex(key)
+	}
+	return set
+}
+
+// next removes the shard with the lowest index from the set and returns its
+// index. The set must not be empty.
+func (set *shardSet) next() int {
+	i := bits.TrailingZeros64(uint64(*set))
+	*set &= *set - 1
+	return i
+}
+
+func shardsOfData(data map[dskey.Key][]byte) shardSet {
+	var set shardSet
+	for key := range data {
+		set |= 1 << shardIndex(key)
+	}
+	return set
+}
+
+// lock locks all shards in the set in the order of their index.
+func (pm *PendingMap) lock(set shardSet) {
+	for set != 0 {
+		mu := &pm.shards[set.next()].mu
+		if mu.TryLock() {
+			continue
+		}
+
+		start := time.Now()
+		mu.Lock()
+		pm.lockWaits.Add(1)
+		pm.lockWaitTime.Add(int64(time.Since(start)))
+	}
+}
+
+func (pm *PendingMap) unlock(set shardSet) {
+	for set != 0 {
+		pm.shards[set.next()].mu.Unlock()
+	}
+}
+
+// rlock is like lock, but only for reading.
+func (pm *PendingMap) rlock(set shardSet) {
+	for set != 0 {
+		mu := &pm.shards[set.next()].mu
+		if mu.TryRLock() {
+			continue
+		}
+
+		start := time.Now()
+		mu.RLock()
+		pm.lockWaits.Add(1)
+		pm.lockWaitTime.Add(int64(time.Since(start)))
+	}
+}
+
+func (pm *PendingMap) runlock(set shardSet) {
+	for set != 0 {
+		pm.shards[set.next()].mu.RUnlock()
 	}
 }
 
@@ -73,18 +180,16 @@ func (pm *PendingMap) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key
 	}
 
 	out := make(map[dskey.Key][]byte, len(keys))
-	err := pm.reading(func() error {
-		for _, k := range keys {
-			v, ok := pm.data[k]
-			if !ok {
-				return ErrNotExist
-			}
-			out[k] = v
+	set := shardsOfKeys(keys)
+	pm.rlock(set)
+	defer pm.runlock(set)
+
+	for _, k := range keys {
+		v, ok := pm.shardOf(k).data[k]
+		if !ok {
+			return nil, ErrNotExist
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		out[k] = v
 	}
 
 	return out, nil
@@ -97,26 +202,15 @@ func (pm *PendingMap) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key
 //
 // Possible Errors: context.Canceled or context.DeadlineExeeded
 func (pm *PendingMap) waitForPending(ctx context.Context, keys []dskey.Key) error {
-	hasPendingKey := false
-	pm.reading(func() error {
-		for _, key := range keys {
-			if _, ok := pm.pending[key]; ok {
-				hasPendingKey = true
-				return nil
-			}
-		}
-		return nil
-	})
-	if !hasPendingKey {
+	if !pm.hasPending(keys) {
 		return nil
 	}
 
 	for _, k := range keys {
-		var pending chan struct{}
-		pm.reading(func() error {
-			pending = pm.pending[k]
-			return nil
-		})
+		shard := pm.shardOf(k)
+		shard.mu.RLock()
+		pending := shard.pending[k]
+		shard.mu.RUnlock()
 
 		if pending == nil {
 			continue
@@ -131,71 +225,96 @@ func (pm *PendingMap) waitForPending(ctx context.Context, keys []dskey.Key) erro
 	return nil
 }
 
+// hasPending returns true, if one of the keys is pending.
+func (pm *PendingMap) hasPending(keys []dskey.Key) bool {
+	set := shardsOfKeys(keys)
+	pm.rlock(set)
+	defer pm.runlock(set)
+
+	for _, key := range keys {
+		if _, ok := pm.shardOf(key).pending[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // MarkPending marks one or more keys as pending.
 //
 // Skips keys that are already pending or are already in the map.
 //
 // Returns all keys that where marked as pending (did not exist).
 func (pm *PendingMap) MarkPending(keys ...dskey.Key) []dskey.Key {
-	var needMark []dskey.Key
-	pm.reading(func() error {
-		for _, key := range keys {
-			if _, inStore := pm.data[key]; inStore {
-				continue
-			}
-			if _, isPending := pm.pending[key]; isPending {
-				continue
-			}
-
-			needMark = append(needMark, key)
-		}
-		return nil
-	})
-
+	needMark := pm.notExisting(keys)
 	if len(needMark) == 0 {
 		return nil
 	}
 
 	marked := make([]dskey.Key, 0, len(needMark))
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	set := shardsOfKeys(needMark)
+	pm.lock(set)
+	defer pm.unlock(set)
 
 	for _, key := range needMark {
-		if _, ok := pm.pending[key]; ok {
+		shard := pm.shardOf(key)
+		if _, ok := shard.pending[key]; ok {
 			// It can happen, that another caller has already set the key.
 			continue
 		}
 
-		if _, inStore := pm.data[key]; inStore {
+		if _, inStore := shard.data[key]; inStore {
 			// The other caller has already the data
 			continue
 		}
 
-		pm.pending[key] = make(chan struct{})
+		shard.pending[key] = make(chan struct{})
 		marked = append(marked, key)
 	}
 	return marked
 }
 
+// notExisting returns the keys, that are neither pending nor in the map.
+func (pm *PendingMap) notExisting(keys []dskey.Key) []dskey.Key {
+	set := shardsOfKeys(keys)
+	pm.rlock(set)
+	defer pm.runlock(set)
+
+	var notExisting []dskey.Key
+	for _, key := range keys {
+		shard := pm.shardOf(key)
+		if _, inStore := shard.data[key]; inStore {
+			continue
+		}
+		if _, isPending := shard.pending[key]; isPending {
+			continue
+		}
+
+		notExisting = append(notExisting, key)
+	}
+	return notExisting
+}
+
 // UnMarkPending sets any key that is still pending not to be pending.
 //
 // Skips keys that are already pending or are already in the database.
 func (pm *PendingMap) UnMarkPending(keys ...dskey.Key) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	set := shardsOfKeys(keys)
+	pm.lock(set)
+	defer pm.unlock(set)
 
 	for _, key := range keys {
-		if _, ok := pm.data[key]; ok {
+		shard := pm.shardOf(key)
+		if _, ok := shard.data[key]; ok {
 			continue
 		}
-		pending := pm.pending[key]
+		pending := shard.pending[key]
 
 		if pending == nil {
 			continue
 		}
 
 		close(pending)
-		delete(pm.pending, key)
+		delete(shard.pending, key)
 	}
 }
 
@@ -203,22 +322,24 @@ func (pm *PendingMap) UnMarkPending(keys ...dskey.Key) {
 //
 // If the key is pending, it is unmarked and all listeners are informed.
 func (pm *PendingMap) SetIfPendingOrExists(data map[dskey.Key][]byte) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	set := shardsOfData(data)
+	pm.lock(set)
+	defer pm.unlock(set)
 
 	for key, value := range data {
-		pending := pm.pending[key]
-		_, exists := pm.data[key]
+		shard := pm.shardOf(key)
+		pending := shard.pending[key]
+		_, exists := shard.data[key]
 
 		if pending == nil && !exists {
 			continue
 		}
 
-		pm.data[key] = value
+		shard.data[key] = value
 
 		if pending != nil {
 			close(pending)
-			delete(pm.pending, key)
+			delete(shard.pending, key)
 		}
 	}
 }
@@ -227,50 +348,73 @@ func (pm *PendingMap) SetIfPendingOrExists(data map[dskey.Key][]byte) {
 //
 // Informs all listeners.
 func (pm *PendingMap) SetIfPending(data map[dskey.Key][]byte) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	set := shardsOfData(data)
+	pm.lock(set)
+	defer pm.unlock(set)
 
 	for key, value := range data {
-		if pending, isPending := pm.pending[key]; isPending {
-			pm.data[key] = value
+		shard := pm.shardOf(key)
+		if pending, isPending := shard.pending[key]; isPending {
+			shard.data[key] = value
 			close(pending)
-			delete(pm.pending, key)
+			delete(shard.pending, key)
 		}
 	}
 }
 
 // Reset removes all data from PendingMap
 func (pm *PendingMap) Reset() {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	pm.lock(allShards)
+	defer pm.unlock(allShards)
 
-	pm.data = make(map[dskey.Key][]byte)
-	pm.pending = make(map[dskey.Key]chan struct{})
+	for i := range pm.shards {
+		pm.shards[i].data = make(map[dskey.Key][]byte)
+		pm.shards[i].pending = make(map[dskey.Key]chan struct{})
+	}
 }
 
-// Len returns the amout of keys in the pending map.
-func (pm *PendingMap) Len() int {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
+// Keys returns all existing keys. Pending keys are not returned.
+func (pm *PendingMap) Keys() []dskey.Key {
+	pm.rlock(allShards)
+	defer pm.runlock(allShards)
 
-	return len(pm.data)
+	var keys []dskey.Key
+	for i := range pm.shards {
+		for key := range pm.shards[i].data {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
-func (pm *PendingMap) reading(cmd func() error) error {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
+// Len returns the amout of keys in the pending map.
+func (pm *PendingMap) Len() int {
+	pm.rlock(allShards)
+	defer pm.runlock(allShards)
 
-	return cmd()
+	var length int
+	for i := range pm.shards {
+		length += len(pm.shards[i].data)
+	}
+	return length
 }
 
 // Size returns the size of all values in the cache in bytes.
 func (pm *PendingMap) Size() int {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
+	pm.rlock(allShards)
+	defer pm.runlock(allShards)
 
 	var size int
-	for _, v := range pm.data {
-		size += len(v)
+	for i := range pm.shards {
+		for _, v := range pm.shards[i].data {
+			size += len(v)
+		}
 	}
 	return size
 }
+
+// LockWaits returns, how often a caller had to wait for the lock of a shard and
+// how long it waited together.
+func (pm *PendingMap) LockWaits() (uint64, time.Duration) {
+	return pm.lockWaits.Load(), time.Duration(pm.lockWaitTime.Load())
+}
@@ -3,6 +3,7 @@ package pendingmap_test
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -47,3 +48,79 @@ func TestGet_When_a_key_gets_unmarked_while_waiting_an_error_is_returned(t *test
 		t.Errorf("got %v, expected nil", result.data)
 	}
 }
+
+func TestGetReturnsOneVersion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pm := pendingmap.New()
+
+	// The keys are in different shards.
+	keys := make([]dskey.Key, 200)
+	for i := range keys {
+		keys[i] = dskey.MustKey("user/%d/username", i+1)
+	}
+	pm.MarkPending(keys...)
+	pm.SetIfPending(versionData(keys, 0))
+
+	go func() {
+		for version := 1; ctx.Err() == nil; version++ {
+			pm.SetIfPendingOrExists(versionData(keys, version))
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		got, err := pm.Get(ctx, keys...)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+
+		first := string(got[keys[0]])
+		for _, k := range keys {
+			if string(got[k]) != first {
+				t.Fatalf("got version %s for %s and version %s for %s", got[k], k, first, keys[0])
+			}
+		}
+	}
+}
+
+func versionData(keys []dskey.Key, version int) map[dskey.Key][]byte {
+	data := make(map[dskey.Key][]byte, len(keys))
+	value := []byte(strconv.Itoa(version))
+	for _, k := range keys {
+		data[k] = value
+	}
+	return data
+}
+
+func BenchmarkGetParallel(b *testing.B) {
+	ctx := context.Background()
+	pm := pendingmap.New()
+
+	keys := make([]dskey.Key, 1000)
+	for i := range keys {
+		keys[i] = dskey.MustKey("user/%d/username", i+1)
+	}
+	pm.MarkPending(keys...)
+	pm.SetIfPending(versionData(keys, 0))
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			if i%100 == 0 {
+				pm.SetIfPendingOrExists(versionData(keys[:10], i))
+				continue
+			}
+
+			if _, err := pm.Get(ctx, keys[i%len(keys)]); err != nil {
+				b.Fatalf("Get: %v", err)
+			}
+		}
+	})
+
+	waits, waitTime := pm.LockWaits()
+	b.ReportMetric(float64(waits)/float64(b.N), "waits/op")
+	b.ReportMetric(float64(waitTime.Nanoseconds())/float64(b.N), "wait-ns/op")
+}
@@ -69,7 +69,7 @@ func (v *ValueBool) convert(p []byte) (bool, error) {
 		return zero, nil
 	}
 	var value bool
-	if err := json.Unmarshal(p, &value); err != nil {
+	if err := fastjson.Unmarshal(p, &value); err != nil {
 		return zero, fmt.Errorf("decoding value %q: %w", p, err)
 	}
 	return value, nil
@@ -148,7 +148,7 @@ func (v *ValueFloat) convert(p []byte) (float32, error) {
 		return zero, nil
 	}
 	var value float32
-	if err := json.Unmarshal(p, &value); err != nil {
+	if err := fastjson.Unmarshal(p, &value); err != nil {
 		return zero, fmt.Errorf("decoding value %q: %w", p, err)
 	}
 	return value, nil
@@ -385,7 +385,7 @@ func (v *ValueJSON) convert(p []byte) (json.RawMessage, error) {
 		return zero, nil
 	}
 	var value json.RawMessage
-	if err := json.Unmarshal(p, &value); err != nil {
+	if err := fastjson.Unmarshal(p, &value); err != nil {
 		return zero, fmt.Errorf("decoding value %q: %w", p, err)
 	}
 	return value, nil
@@ -464,7 +464,7 @@ func (v *ValueMaybeInt) convert(p []byte) (Maybe[int], error) {
 		return zero, nil
 	}
 	var value Maybe[int]
-	if err := json.Unmarshal(p, &value); err != nil {
+	if err := fastjson.Unmarshal(p, &value); err != nil {
 		return zero, fmt.Errorf("decoding value %q: %w", p, err)
 	}
 	return value, nil
@@ -543,7 +543,7 @@ func (v *ValueMaybeString) convert(p []byte) (Maybe[string], error) {
 		return zero, nil
 	}
 	var value Maybe[string]
-	if err := json.Unmarshal(p, &value); err != nil {
+	if err := fastjson.Unmarshal(p, &value); err != nil {
 		return zero, fmt.Errorf("decoding value %q: %w", p, err)
 	}
 	return value, nil
@@ -622,7 +622,7 @@ func (v *ValueString) convert(p []byte) (string, error) {
 		return zero, nil
 	}
 	var value string
-	if err := json.Unmarshal(p, &value); err != nil {
+	if err := fastjson.Unmarshal(p, &value); err != nil {
 		return zero, fmt.Errorf("decoding value %q: %w", p, err)
 	}
 	return value, nil
@@ -701,7 +701,7 @@ func (v *ValueStringSlice) convert(p []byte) ([]string, error) {
 		return zero, nil
 	}
 	var value []string
-	if err := json.Unmarshal(p, &value); err != nil {
+	if err := fastjson.Unmarshal(p, &value); err != nil {
 		return zero, fmt.Errorf("decoding value %q: %w", p, err)
 	}
 	return value, nil
@@ -1,6 +1,6 @@
 package dsfetch
 
-import "encoding/json"
+import "github.com/OpenSlides/openslides-autoupdate-service/pkg/fastjson"
 
 // Maybe holds a type or null.
 type Maybe[T any] struct {
@@ -44,7 +44,7 @@ func (m *Maybe[T]) UnmarshalJSON(bs []byte) error {
 	}
 
 	var v T
-	if err := json.Unmarshal(bs, &v); err != nil {
+	if err := fastjson.Unmarshal(bs, &v); err != nil {
 		return err
 	}
 	m.Set(v)
@@ -1,7 +1,5 @@
 package dskey
 
-import "fmt"
-
 type collectionField struct {
 	collection string
 	field      string
@@ -20,5 +18,19 @@ func joinInt(i1, i2 int) uint64 {
 // ValidateCollectionField returns, if the combination of collection and field
 // exists.
 func ValidateCollectionField(collection, field string) bool {
-	return collectionFieldToID(fmt.Sprintf("%s/%s", collection, field)) != -1
+	return collectionFieldToID(collection, field) != -1
+}
+
+// CollectionFieldID returns the number of the combination of collection and
+// field. It is -1, if the combination does not exist.
+//
+// The numbers are between 1 and CollectionFieldCount-1. They can be used as
+// index of a slice instead of a map with the string "collection/field" as
+// key.
+func CollectionFieldID(collection, field string) int {
+	return collectionFieldToID(collection, field)
 }
+
+// CollectionFieldCount is the number of known combinations of collection and
+// field plus one for the invalid combination 0.
+const CollectionFieldCount = len(collectionFields)
@@ -935,1869 +935,2006 @@ var collectionFields = [...]collectionField{
 	{"vote", "weight"},
 }
 
-func collectionFieldToID(cf string) int {
-	switch cf {
-	case "action_worker/A":
-		return 1
-	case "action_worker/created":
-		return 2
-	case "action_worker/id":
-		return 3
-	case "action_worker/name":
-		return 4
-	case "action_worker/result":
-		return 5
-	case "action_worker/state":
-		return 6
-	case "action_worker/timestamp":
-		return 7
-	case "action_worker/user_id":
-		return 8
-	case "agenda_item/A":
-		return 9
-	case "agenda_item/B":
-		return 10
-	case "agenda_item/C":
-		return 11
-	case "agenda_item/child_ids":
-		return 12
-	case "agenda_item/closed":
-		return 13
-	case "agenda_item/comment":
-		return 14
-	case "agenda_item/content_object_id":
-		return 15
-	case "agenda_item/duration":
-		return 16
-	case "agenda_item/id":
-		return 17
-	case "agenda_item/is_hidden":
-		return 18
-	case "agenda_item/is_internal":
-		return 19
-	case "agenda_item/item_number":
-		return 20
-	case "agenda_item/level":
-		return 21
-	case "agenda_item/meeting_id":
-		return 22
-	case "agenda_item/parent_id":
-		return 23
-	case "agenda_item/projection_ids":
-		return 24
-	case "agenda_item/tag_ids":
-		return 25
-	case "agenda_item/type":
-		return 26
-	case "agenda_item/weight":
-		return 27
-	case "assignment/A":
-		return 28
-	case "assignment/agenda_item_id":
-		return 29
-	case "assignment/attachment_meeting_mediafile_ids":
-		return 30
-	case "assignment/candidate_ids":
-		return 31
-	case "assignment/default_poll_description":
-		return 32
-	case "assignment/description":
-		return 33
-	case "assignment/id":
-		return 34
-	case "assignment/list_of_speakers_id":
-		return 35
-	case "assignment/meeting_id":
-		return 36
-	case "assignment/number_poll_candidates":
-		return 37
-	case "assignment/open_posts":
-		return 38
-	case "assignment/phase":
-		return 39
-	case "assignment/poll_ids":
-		return 40
-	case "assignment/projection_ids":
-		return 41
-	case "assignment/sequential_number":
-		return 42
-	case "assignment/tag_ids":
-		return 43
-	case "assignment/title":
-		return 44
-	case "assignment_candidate/A":
-		return 45
-	case "assignment_candidate/assignment_id":
-		return 46
-	case "assignment_candidate/id":
-		return 47
-	case "assignment_candidate/meeting_id":
-		return 48
-	case "assignment_candidate/meeting_user_id":
-		return 49
-	case "assignment_candidate/weight":
-		return 50
-	case "chat_group/A":
-		return 51
-	case "chat_group/chat_message_ids":
-		return 52
-	case "chat_group/id":
-		return 53
-	case "chat_group/meeting_id":
-		return 54
-	case "chat_group/name":
-		return 55
-	case "chat_group/read_group_ids":
-		return 56
-	case "chat_group/weight":
-		return 57
-	case "chat_group/write_group_ids":
-		return 58
-	case "chat_message/A":
-		return 59
-	case "chat_message/chat_group_id":
-		return 60
-	case "chat_message/content":
-		return 61
-	case "chat_message/created":
-		return 62
-	case "chat_message/id":
-		return 63
-	case "chat_message/meeting_id":
-		return 64
-	case "chat_message/meeting_user_id":
-		return 65
-	case "committee/A":
-		return 66
-	case "committee/B":
-		return 67
-	case "committee/default_meeting_id":
-		return 68
-	case "committee/description":
-		return 69
-	case "committee/external_id":
-		return 70
-	case "committee/forward_to_committee_ids":
-		return 71
-	case "committee/forwarding_user_id":
-		return 72
-	case "committee/id":
-		return 73
-	case "committee/manager_ids":
-		return 74
-	case "committee/meeting_ids":
-		return 75
-	case "committee/name":
-		return 76
-	case "committee/organization_id":
-		return 77
-	case "committee/organization_tag_ids":
-		return 78
-	case "committee/receive_forwardings_from_committee_ids":
-		return 79
-	case "committee/user_ids":
-		return 80
-	case "gender/A":
-		return 81
-	case "gender/id":
-		return 82
-	case "gender/name":
-		return 83
-	case "gender/organization_id":
-		return 84
-	case "gender/user_ids":
-		return 85
-	case "group/A":
-		return 86
-	case "group/admin_group_for_meeting_id":
-		return 87
-	case "group/anonymous_group_for_meeting_id":
-		return 88
-	case "group/default_group_for_meeting_id":
-		return 89
-	case "group/external_id":
-		return 90
-	case "group/id":
-		return 91
-	case "group/meeting_id":
-		return 92
-	case "group/meeting_mediafile_access_group_ids":
-		return 93
-	case "group/meeting_mediafile_inherited_access_group_ids":
-		return 94
-	case "group/meeting_user_ids":
-		return 95
-	case "group/name":
-		return 96
-	case "group/permissions":
-		return 97
-	case "group/poll_ids":
-		return 98
-	case "group/read_chat_group_ids":
-		return 99
-	case "group/read_comment_section_ids":
-		return 100
-	case "group/used_as_assignment_poll_default_id":
-		return 101
-	case "group/used_as_motion_poll_default_id":
-		return 102
-	case "group/used_as_poll_default_id":
-		return 103
-	case "group/used_as_topic_poll_default_id":
-		return 104
-	case "group/weight":
-		return 105
-	case "group/write_chat_group_ids":
-		return 106
-	case "group/write_comment_section_ids":
-		return 107
-	case "import_preview/A":
-		return 108
-	case "import_preview/created":
-		return 109
-	case "import_preview/id":
-		return 110
-	case "import_preview/name":
-		return 111
-	case "import_preview/result":
-		return 112
-	case "import_preview/state":
-		return 113
-	case "list_of_speakers/A":
-		return 114
-	case "list_of_speakers/B":
-		return 115
-	case "list_of_speakers/closed":
-		return 116
-	case "list_of_speakers/content_object_id":
-		return 117
-	case "list_of_speakers/id":
-		return 118
-	case "list_of_speakers/meeting_id":
-		return 119
-	case "list_of_speakers/moderator_notes":
-		return 120
-	case "list_of_speakers/projection_ids":
-		return 121
-	case "list_of_speakers/sequential_number":
-		return 122
-	case "list_of_speakers/speaker_ids":
-		return 123
-	case "list_of_speakers/structure_level_list_of_speakers_ids":
-		return 124
-	case "mediafile/A":
-		return 125
-	case "mediafile/child_ids":
-		return 126
-	case "mediafile/create_timestamp":
-		return 127
-	case "mediafile/filename":
-		return 128
-	case "mediafile/filesize":
-		return 129
-	case "mediafile/id":
-		return 130
-	case "mediafile/is_directory":
-		return 131
-	case "mediafile/meeting_mediafile_ids":
-		return 132
-	case "mediafile/mimetype":
-		return 133
-	case "mediafile/owner_id":
-		return 134
-	case "mediafile/parent_id":
-		return 135
-	case "mediafile/pdf_information":
-		return 136
-	case "mediafile/published_to_meetings_in_organization_id":
-		return 137
-	case "mediafile/title":
-		return 138
-	case "mediafile/token":
-		return 139
-	case "meeting/A":
-		return 140
-	case "meeting/B":
-		return 141
-	case "meeting/C":
-		return 142
-	case "meeting/E":
-		return 143
-	case "meeting/admin_group_id":
-		return 144
-	case "meeting/agenda_enable_numbering":
-		return 145
-	case "meeting/agenda_item_creation":
-		return 146
-	case "meeting/agenda_item_ids":
-		return 147
-	case "meeting/agenda_new_items_default_visibility":
-		return 148
-	case "meeting/agenda_number_prefix":
-		return 149
-	case "meeting/agenda_numeral_system":
-		return 150
-	case "meeting/agenda_show_internal_items_on_projector":
-		return 151
-	case "meeting/agenda_show_subtitles":
-		return 152
-	case "meeting/agenda_show_topic_navigation_on_detail_view":
-		return 153
-	case "meeting/all_projection_ids":
-		return 154
-	case "meeting/anonymous_group_id":
-		return 155
-	case "meeting/applause_enable":
-		return 156
-	case "meeting/applause_max_amount":
-		return 157
-	case "meeting/applause_min_amount":
-		return 158
-	case "meeting/applause_particle_image_url":
-		return 159
-	case "meeting/applause_show_level":
-		return 160
-	case "meeting/applause_timeout":
-		return 161
-	case "meeting/applause_type":
-		return 162
-	case "meeting/assignment_candidate_ids":
-		return 163
-	case "meeting/assignment_ids":
-		return 164
-	case "meeting/assignment_poll_add_candidates_to_list_of_speakers":
-		return 165
-	case "meeting/assignment_poll_ballot_paper_number":
-		return 166
-	case "meeting/assignment_poll_ballot_paper_selection":
-		return 167
-	case "meeting/assignment_poll_default_backend":
-		return 168
-	case "meeting/assignment_poll_default_group_ids":
-		return 169
-	case "meeting/assignment_poll_default_method":
-		return 170
-	case "meeting/assignment_poll_default_onehundred_percent_base":
-		return 171
-	case "meeting/assignment_poll_default_type":
-		return 172
-	case "meeting/assignment_poll_enable_max_votes_per_option":
-		return 173
-	case "meeting/assignment_poll_sort_poll_result_by_votes":
-		return 174
-	case "meeting/assignments_export_preamble":
-		return 175
-	case "meeting/assignments_export_title":
-		return 176
-	case "meeting/chat_group_ids":
-		return 177
-	case "meeting/chat_message_ids":
-		return 178
-	case "meeting/committee_id":
-		return 179
-	case "meeting/conference_auto_connect":
-		return 180
-	case "meeting/conference_auto_connect_next_speakers":
-		return 181
-	case "meeting/conference_enable_helpdesk":
-		return 182
-	case "meeting/conference_los_restriction":
-		return 183
-	case "meeting/conference_open_microphone":
-		return 184
-	case "meeting/conference_open_video":
-		return 185
-	case "meeting/conference_show":
-		return 186
-	case "meeting/conference_stream_poster_url":
-		return 187
-	case "meeting/conference_stream_url":
-		return 188
-	case "meeting/custom_translations":
-		return 189
-	case "meeting/default_group_id":
-		return 190
-	case "meeting/default_meeting_for_committee_id":
-		return 191
-	case "meeting/default_projector_agenda_item_list_ids":
-		return 192
-	case "meeting/default_projector_amendment_ids":
-		return 193
-	case "meeting/default_projector_assignment_ids":
-		return 194
-	case "meeting/default_projector_assignment_poll_ids":
-		return 195
-	case "meeting/default_projector_countdown_ids":
-		return 196
-	case "meeting/default_projector_current_list_of_speakers_ids":
-		return 197
-	case "meeting/default_projector_list_of_speakers_ids":
-		return 198
-	case "meeting/default_projector_mediafile_ids":
-		return 199
-	case "meeting/default_projector_message_ids":
-		return 200
-	case "meeting/default_projector_motion_block_ids":
-		return 201
-	case "meeting/default_projector_motion_ids":
-		return 202
-	case "meeting/default_projector_motion_poll_ids":
-		return 203
-	case "meeting/default_projector_poll_ids":
-		return 204
-	case "meeting/default_projector_topic_ids":
-		return 205
-	case "meeting/description":
-		return 206
-	case "meeting/enable_anonymous":
-		return 207
-	case "meeting/end_time":
-		return 208
-	case "meeting/export_csv_encoding":
-		return 209
-	case "meeting/export_csv_separator":
-		return 210
-	case "meeting/export_pdf_fontsize":
-		return 211
-	case "meeting/export_pdf_line_height":
-		return 212
-	case "meeting/export_pdf_page_margin_bottom":
-		return 213
-	case "meeting/export_pdf_page_margin_left":
-		return 214
-	case "meeting/export_pdf_page_margin_right":
-		return 215
-	case "meeting/export_pdf_page_margin_top":
-		return 216
-	case "meeting/export_pdf_pagenumber_alignment":
-		return 217
-	case "meeting/export_pdf_pagesize":
-		return 218
-	case "meeting/external_id":
-		return 219
-	case "meeting/font_bold_id":
-		return 220
-	case "meeting/font_bold_italic_id":
-		return 221
-	case "meeting/font_chyron_speaker_name_id":
-		return 222
-	case "meeting/font_italic_id":
-		return 223
-	case "meeting/font_monospace_id":
-		return 224
-	case "meeting/font_projector_h1_id":
-		return 225
-	case "meeting/font_projector_h2_id":
-		return 226
-	case "meeting/font_regular_id":
-		return 227
-	case "meeting/forwarded_motion_ids":
-		return 228
-	case "meeting/group_ids":
-		return 229
-	case "meeting/id":
-		return 230
-	case "meeting/imported_at":
-		return 231
-	case "meeting/is_active_in_organization_id":
-		return 232
-	case "meeting/is_archived_in_organization_id":
-		return 233
-	case "meeting/jitsi_domain":
-		return 234
-	case "meeting/jitsi_room_name":
-		return 235
-	case "meeting/jitsi_room_password":
-		return 236
-	case "meeting/language":
-		return 237
-	case "meeting/list_of_speakers_allow_multiple_speakers":
-		return 238
-	case "meeting/list_of_speakers_amount_last_on_projector":
-		return 239
-	case "meeting/list_of_speakers_amount_next_on_projector":
-		return 240
-	case "meeting/list_of_speakers_can_create_point_of_order_for_others":
-		return 241
-	case "meeting/list_of_speakers_can_set_contribution_self":
-		return 242
-	case "meeting/list_of_speakers_closing_disables_point_of_order":
-		return 243
-	case "meeting/list_of_speakers_countdown_id":
-		return 244
-	case "meeting/list_of_speakers_couple_countdown":
-		return 245
-	case "meeting/list_of_speakers_default_structure_level_time":
-		return 246
-	case "meeting/list_of_speakers_enable_interposed_question":
-		return 247
-	case "meeting/list_of_speakers_enable_point_of_order_categories":
-		return 248
-	case "meeting/list_of_speakers_enable_point_of_order_speakers":
-		return 249
-	case "meeting/list_of_speakers_enable_pro_contra_speech":
-		return 250
-	case "meeting/list_of_speakers_hide_contribution_count":
-		return 251
-	case "meeting/list_of_speakers_ids":
-		return 252
-	case "meeting/list_of_speakers_initially_closed":
-		return 253
-	case "meeting/list_of_speakers_intervention_time":
-		return 254
-	case "meeting/list_of_speakers_present_users_only":
-		return 255
-	case "meeting/list_of_speakers_show_amount_of_speakers_on_slide":
-		return 256
-	case "meeting/list_of_speakers_show_first_contribution":
-		return 257
-	case "meeting/list_of_speakers_speaker_note_for_everyone":
-		return 258
-	case "meeting/location":
-		return 259
-	case "meeting/locked_from_inside":
-		return 260
-	case "meeting/logo_pdf_ballot_paper_id":
-		return 261
-	case "meeting/logo_pdf_footer_l_id":
-		return 262
-	case "meeting/logo_pdf_footer_r_id":
-		return 263
-	case "meeting/logo_pdf_header_l_id":
-		return 264
-	case "meeting/logo_pdf_header_r_id":
-		return 265
-	case "meeting/logo_projector_header_id":
-		return 266
-	case "meeting/logo_projector_main_id":
-		return 267
-	case "meeting/logo_web_header_id":
-		return 268
-	case "meeting/mediafile_ids":
-		return 269
-	case "meeting/meeting_mediafile_ids":
-		return 270
-	case "meeting/meeting_user_ids":
-		return 271
-	case "meeting/motion_block_ids":
-		return 272
-	case "meeting/motion_category_ids":
-		return 273
-	case "meeting/motion_change_recommendation_ids":
-		return 274
-	case "meeting/motion_comment_ids":
-		return 275
-	case "meeting/motion_comment_section_ids":
-		return 276
-	case "meeting/motion_editor_ids":
-		return 277
-	case "meeting/motion_ids":
-		return 278
-	case "meeting/motion_poll_ballot_paper_number":
-		return 279
-	case "meeting/motion_poll_ballot_paper_selection":
-		return 280
-	case "meeting/motion_poll_default_backend":
-		return 281
-	case "meeting/motion_poll_default_group_ids":
-		return 282
-	case "meeting/motion_poll_default_method":
-		return 283
-	case "meeting/motion_poll_default_onehundred_percent_base":
-		return 284
-	case "meeting/motion_poll_default_type":
-		return 285
-	case "meeting/motion_state_ids":
-		return 286
-	case "meeting/motion_submitter_ids":
-		return 287
-	case "meeting/motion_workflow_ids":
-		return 288
-	case "meeting/motion_working_group_speaker_ids":
-		return 289
-	case "meeting/motions_amendments_enabled":
-		return 290
-	case "meeting/motions_amendments_in_main_list":
-		return 291
-	case "meeting/motions_amendments_multiple_paragraphs":
-		return 292
-	case "meeting/motions_amendments_of_amendments":
-		return 293
-	case "meeting/motions_amendments_prefix":
-		return 294
-	case "meeting/motions_amendments_text_mode":
-		return 295
-	case "meeting/motions_block_slide_columns":
-		return 296
-	case "meeting/motions_create_enable_additional_submitter_text":
-		return 297
-	case "meeting/motions_default_amendment_workflow_id":
-		return 298
-	case "meeting/motions_default_line_numbering":
-		return 299
-	case "meeting/motions_default_sorting":
-		return 300
-	case "meeting/motions_default_workflow_id":
-		return 301
-	case "meeting/motions_enable_editor":
-		return 302
-	case "meeting/motions_enable_reason_on_projector":
-		return 303
-	case "meeting/motions_enable_recommendation_on_projector":
-		return 304
-	case "meeting/motions_enable_sidebox_on_projector":
-		return 305
-	case "meeting/motions_enable_text_on_projector":
-		return 306
-	case "meeting/motions_enable_working_group_speaker":
-		return 307
-	case "meeting/motions_export_follow_recommendation":
-		return 308
-	case "meeting/motions_export_preamble":
-		return 309
-	case "meeting/motions_export_submitter_recommendation":
-		return 310
-	case "meeting/motions_export_title":
-		return 311
-	case "meeting/motions_hide_metadata_background":
-		return 312
-	case "meeting/motions_line_length":
-		return 313
-	case "meeting/motions_number_min_digits":
-		return 314
-	case "meeting/motions_number_type":
-		return 315
-	case "meeting/motions_number_with_blank":
-		return 316
-	case "meeting/motions_preamble":
-		return 317
-	case "meeting/motions_reason_required":
-		return 318
-	case "meeting/motions_recommendation_text_mode":
-		return 319
-	case "meeting/motions_recommendations_by":
-		return 320
-	case "meeting/motions_show_referring_motions":
-		return 321
-	case "meeting/motions_show_sequential_number":
-		return 322
-	case "meeting/motions_supporters_min_amount":
-		return 323
-	case "meeting/name":
-		return 324
-	case "meeting/option_ids":
-		return 325
-	case "meeting/organization_tag_ids":
-		return 326
-	case "meeting/personal_note_ids":
-		return 327
-	case "meeting/point_of_order_category_ids":
-		return 328
-	case "meeting/poll_ballot_paper_number":
-		return 329
-	case "meeting/poll_ballot_paper_selection":
-		return 330
-	case "meeting/poll_candidate_ids":
-		return 331
-	case "meeting/poll_candidate_list_ids":
-		return 332
-	case "meeting/poll_countdown_id":
-		return 333
-	case "meeting/poll_couple_countdown":
-		return 334
-	case "meeting/poll_default_backend":
-		return 335
-	case "meeting/poll_default_group_ids":
-		return 336
-	case "meeting/poll_default_method":
-		return 337
-	case "meeting/poll_default_onehundred_percent_base":
-		return 338
-	case "meeting/poll_default_type":
-		return 339
-	case "meeting/poll_ids":
-		return 340
-	case "meeting/poll_sort_poll_result_by_votes":
-		return 341
-	case "meeting/present_user_ids":
-		return 342
-	case "meeting/projection_ids":
-		return 343
-	case "meeting/projector_countdown_default_time":
-		return 344
-	case "meeting/projector_countdown_ids":
-		return 345
-	case "meeting/projector_countdown_warning_time":
-		return 346
-	case "meeting/projector_ids":
-		return 347
-	case "meeting/projector_message_ids":
-		return 348
-	case "meeting/reference_projector_id":
-		return 349
-	case "meeting/speaker_ids":
-		return 350
-	case "meeting/start_time":
-		return 351
-	case "meeting/structure_level_ids":
-		return 352
-	case "meeting/structure_level_list_of_speakers_ids":
-		return 353
-	case "meeting/tag_ids":
-		return 354
-	case "meeting/template_for_organization_id":
-		return 355
-	case "meeting/topic_ids":
-		return 356
-	case "meeting/topic_poll_default_group_ids":
-		return 357
-	case "meeting/user_ids":
-		return 358
-	case "meeting/users_allow_self_set_present":
-		return 359
-	case "meeting/users_email_body":
-		return 360
-	case "meeting/users_email_replyto":
-		return 361
-	case "meeting/users_email_sender":
-		return 362
-	case "meeting/users_email_subject":
-		return 363
-	case "meeting/users_enable_presence_view":
-		return 364
-	case "meeting/users_enable_vote_delegations":
-		return 365
-	case "meeting/users_enable_vote_weight":
-		return 366
-	case "meeting/users_forbid_delegator_as_submitter":
-		return 367
-	case "meeting/users_forbid_delegator_as_supporter":
-		return 368
-	case "meeting/users_forbid_delegator_in_list_of_speakers":
-		return 369
-	case "meeting/users_forbid_delegator_to_vote":
-		return 370
-	case "meeting/users_pdf_welcometext":
-		return 371
-	case "meeting/users_pdf_welcometitle":
-		return 372
-	case "meeting/users_pdf_wlan_encryption":
-		return 373
-	case "meeting/users_pdf_wlan_password":
-		return 374
-	case "meeting/users_pdf_wlan_ssid":
-		return 375
-	case "meeting/vote_ids":
-		return 376
-	case "meeting/welcome_text":
-		return 377
-	case "meeting/welcome_title":
-		return 378
-	case "meeting_mediafile/A":
-		return 379
-	case "meeting_mediafile/access_group_ids":
-		return 380
-	case "meeting_mediafile/attachment_ids":
-		return 381
-	case "meeting_mediafile/id":
-		return 382
-	case "meeting_mediafile/inherited_access_group_ids":
-		return 383
-	case "meeting_mediafile/is_public":
-		return 384
-	case "meeting_mediafile/list_of_speakers_id":
-		return 385
-	case "meeting_mediafile/mediafile_id":
-		return 386
-	case "meeting_mediafile/meeting_id":
-		return 387
-	case "meeting_mediafile/projection_ids":
-		return 388
-	case "meeting_mediafile/used_as_font_bold_in_meeting_id":
-		return 389
-	case "meeting_mediafile/used_as_font_bold_italic_in_meeting_id":
-		return 390
-	case "meeting_mediafile/used_as_font_chyron_speaker_name_in_meeting_id":
-		return 391
-	case "meeting_mediafile/used_as_font_italic_in_meeting_id":
-		return 392
-	case "meeting_mediafile/used_as_font_monospace_in_meeting_id":
-		return 393
-	case "meeting_mediafile/used_as_font_projector_h1_in_meeting_id":
-		return 394
-	case "meeting_mediafile/used_as_font_projector_h2_in_meeting_id":
-		return 395
-	case "meeting_mediafile/used_as_font_regular_in_meeting_id":
-		return 396
-	case "meeting_mediafile/used_as_logo_pdf_ballot_paper_in_meeting_id":
-		return 397
-	case "meeting_mediafile/used_as_logo_pdf_footer_l_in_meeting_id":
-		return 398
-	case "meeting_mediafile/used_as_logo_pdf_footer_r_in_meeting_id":
-		return 399
-	case "meeting_mediafile/used_as_logo_pdf_header_l_in_meeting_id":
-		return 400
-	case "meeting_mediafile/used_as_logo_pdf_header_r_in_meeting_id":
-		return 401
-	case "meeting_mediafile/used_as_logo_projector_header_in_meeting_id":
-		return 402
-	case "meeting_mediafile/used_as_logo_projector_main_in_meeting_id":
-		return 403
-	case "meeting_mediafile/used_as_logo_web_header_in_meeting_id":
-		return 404
-	case "meeting_user/A":
-		return 405
-	case "meeting_user/B":
-		return 406
-	case "meeting_user/C":
-		return 407
-	case "meeting_user/D":
-		return 408
-	case "meeting_user/E":
-		return 409
-	case "meeting_user/about_me":
-		return 410
-	case "meeting_user/assignment_candidate_ids":
-		return 411
-	case "meeting_user/chat_message_ids":
-		return 412
-	case "meeting_user/comment":
-		return 413
-	case "meeting_user/group_ids":
-		return 414
-	case "meeting_user/id":
-		return 415
-	case "meeting_user/locked_out":
-		return 416
-	case "meeting_user/meeting_id":
-		return 417
-	case "meeting_user/motion_editor_ids":
-		return 418
-	case "meeting_user/motion_submitter_ids":
-		return 419
-	case "meeting_user/motion_working_group_speaker_ids":
-		return 420
-	case "meeting_user/number":
-		return 421
-	case "meeting_user/personal_note_ids":
-		return 422
-	case "meeting_user/speaker_ids":
-		return 423
-	case "meeting_user/structure_level_ids":
-		return 424
-	case "meeting_user/supported_motion_ids":
-		return 425
-	case "meeting_user/user_id":
-		return 426
-	case "meeting_user/vote_delegated_to_id":
-		return 427
-	case "meeting_user/vote_delegations_from_ids":
-		return 428
-	case "meeting_user/vote_weight":
-		return 429
-	case "motion/A":
-		return 430
-	case "motion/B":
-		return 431
-	case "motion/C":
-		return 432
-	case "motion/D":
-		return 433
-	case "motion/E":
-		return 434
-	case "motion/additional_submitter":
-		return 435
-	case "motion/agenda_item_id":
-		return 436
-	case "motion/all_derived_motion_ids":
-		return 437
-	case "motion/all_origin_ids":
-		return 438
-	case "motion/amendment_ids":
-		return 439
-	case "motion/amendment_paragraphs":
-		return 440
-	case "motion/attachment_meeting_mediafile_ids":
-		return 441
-	case "motion/block_id":
-		return 442
-	case "motion/category_id":
-		return 443
-	case "motion/category_weight":
-		return 444
-	case "motion/change_recommendation_ids":
-		return 445
-	case "motion/comment_ids":
-		return 446
-	case "motion/created":
-		return 447
-	case "motion/derived_motion_ids":
-		return 448
-	case "motion/editor_ids":
-		return 449
-	case "motion/forwarded":
-		return 450
-	case "motion/id":
-		return 451
-	case "motion/identical_motion_ids":
-		return 452
-	case "motion/last_modified":
-		return 453
-	case "motion/lead_motion_id":
-		return 454
-	case "motion/list_of_speakers_id":
-		return 455
-	case "motion/meeting_id":
-		return 456
-	case "motion/modified_final_version":
-		return 457
-	case "motion/number":
-		return 458
-	case "motion/number_value":
-		return 459
-	case "motion/option_ids":
-		return 460
-	case "motion/origin_id":
-		return 461
-	case "motion/origin_meeting_id":
-		return 462
-	case "motion/personal_note_ids":
-		return 463
-	case "motion/poll_ids":
-		return 464
-	case "motion/projection_ids":
-		return 465
-	case "motion/reason":
-		return 466
-	case "motion/recommendation_extension":
-		return 467
-	case "motion/recommendation_extension_reference_ids":
-		return 468
-	case "motion/recommendation_id":
-		return 469
-	case "motion/referenced_in_motion_recommendation_extension_ids":
-		return 470
-	case "motion/referenced_in_motion_state_extension_ids":
-		return 471
-	case "motion/sequential_number":
-		return 472
-	case "motion/sort_child_ids":
-		return 473
-	case "motion/sort_parent_id":
-		return 474
-	case "motion/sort_weight":
-		return 475
-	case "motion/start_line_number":
-		return 476
-	case "motion/state_extension":
-		return 477
-	case "motion/state_extension_reference_ids":
-		return 478
-	case "motion/state_id":
-		return 479
-	case "motion/submitter_ids":
-		return 480
-	case "motion/supporter_meeting_user_ids":
-		return 481
-	case "motion/tag_ids":
-		return 482
-	case "motion/text":
-		return 483
-	case "motion/text_hash":
-		return 484
-	case "motion/title":
-		return 485
-	case "motion/workflow_timestamp":
-		return 486
-	case "motion/working_group_speaker_ids":
-		return 487
-	case "motion_block/A":
-		return 488
-	case "motion_block/agenda_item_id":
-		return 489
-	case "motion_block/id":
-		return 490
-	case "motion_block/internal":
-		return 491
-	case "motion_block/list_of_speakers_id":
-		return 492
-	case "motion_block/meeting_id":
-		return 493
-	case "motion_block/motion_ids":
-		return 494
-	case "motion_block/projection_ids":
-		return 495
-	case "motion_block/sequential_number":
-		return 496
-	case "motion_block/title":
-		return 497
-	case "motion_category/A":
-		return 498
-	case "motion_category/child_ids":
-		return 499
-	case "motion_category/id":
-		return 500
-	case "motion_category/level":
-		return 501
-	case "motion_category/meeting_id":
-		return 502
-	case "motion_category/motion_ids":
-		return 503
-	case "motion_category/name":
-		return 504
-	case "motion_category/parent_id":
-		return 505
-	case "motion_category/prefix":
-		return 506
-	case "motion_category/sequential_number":
-		return 507
-	case "motion_category/weight":
-		return 508
-	case "motion_change_recommendation/A":
-		return 509
-	case "motion_change_recommendation/creation_time":
-		return 510
-	case "motion_change_recommendation/id":
-		return 511
-	case "motion_change_recommendation/internal":
-		return 512
-	case "motion_change_recommendation/line_from":
-		return 513
-	case "motion_change_recommendation/line_to":
-		return 514
-	case "motion_change_recommendation/meeting_id":
-		return 515
-	case "motion_change_recommendation/motion_id":
-		return 516
-	case "motion_change_recommendation/other_description":
-		return 517
-	case "motion_change_recommendation/rejected":
-		return 518
-	case "motion_change_recommendation/text":
-		return 519
-	case "motion_change_recommendation/type":
-		return 520
-	case "motion_comment/A":
-		return 521
-	case "motion_comment/comment":
-		return 522
-	case "motion_comment/id":
-		return 523
-	case "motion_comment/meeting_id":
-		return 524
-	case "motion_comment/motion_id":
-		return 525
-	case "motion_comment/section_id":
-		return 526
-	case "motion_comment_section/A":
-		return 527
-	case "motion_comment_section/comment_ids":
-		return 528
-	case "motion_comment_section/id":
-		return 529
-	case "motion_comment_section/meeting_id":
-		return 530
-	case "motion_comment_section/name":
-		return 531
-	case "motion_comment_section/read_group_ids":
-		return 532
-	case "motion_comment_section/sequential_number":
-		return 533
-	case "motion_comment_section/submitter_can_write":
-		return 534
-	case "motion_comment_section/weight":
-		return 535
-	case "motion_comment_section/write_group_ids":
-		return 536
-	case "motion_editor/A":
-		return 537
-	case "motion_editor/id":
-		return 538
-	case "motion_editor/meeting_id":
-		return 539
-	case "motion_editor/meeting_user_id":
-		return 540
-	case "motion_editor/motion_id":
-		return 541
-	case "motion_editor/weight":
-		return 542
-	case "motion_state/A":
-		return 543
-	case "motion_state/allow_create_poll":
-		return 544
-	case "motion_state/allow_motion_forwarding":
-		return 545
-	case "motion_state/allow_submitter_edit":
-		return 546
-	case "motion_state/allow_support":
-		return 547
-	case "motion_state/css_class":
-		return 548
-	case "motion_state/first_state_of_workflow_id":
-		return 549
-	case "motion_state/id":
-		return 550
-	case "motion_state/is_internal":
-		return 551
-	case "motion_state/meeting_id":
-		return 552
-	case "motion_state/merge_amendment_into_final":
-		return 553
-	case "motion_state/motion_ids":
-		return 554
-	case "motion_state/motion_recommendation_ids":
-		return 555
-	case "motion_state/name":
-		return 556
-	case "motion_state/next_state_ids":
-		return 557
-	case "motion_state/previous_state_ids":
-		return 558
-	case "motion_state/recommendation_label":
-		return 559
-	case "motion_state/restrictions":
-		return 560
-	case "motion_state/set_number":
-		return 561
-	case "motion_state/set_workflow_timestamp":
-		return 562
-	case "motion_state/show_recommendation_extension_field":
-		return 563
-	case "motion_state/show_state_extension_field":
-		return 564
-	case "motion_state/submitter_withdraw_back_ids":
-		return 565
-	case "motion_state/submitter_withdraw_state_id":
-		return 566
-	case "motion_state/weight":
-		return 567
-	case "motion_state/workflow_id":
-		return 568
-	case "motion_submitter/A":
-		return 569
-	case "motion_submitter/id":
-		return 570
-	case "motion_submitter/meeting_id":
-		return 571
-	case "motion_submitter/meeting_user_id":
-		return 572
-	case "motion_submitter/motion_id":
-		return 573
-	case "motion_submitter/weight":
-		return 574
-	case "motion_workflow/A":
-		return 575
-	case "motion_workflow/default_amendment_workflow_meeting_id":
-		return 576
-	case "motion_workflow/default_workflow_meeting_id":
-		return 577
-	case "motion_workflow/first_state_id":
-		return 578
-	case "motion_workflow/id":
-		return 579
-	case "motion_workflow/meeting_id":
-		return 580
-	case "motion_workflow/name":
-		return 581
-	case "motion_workflow/sequential_number":
-		return 582
-	case "motion_workflow/state_ids":
-		return 583
-	case "motion_working_group_speaker/A":
-		return 584
-	case "motion_working_group_speaker/id":
-		return 585
-	case "motion_working_group_speaker/meeting_id":
-		return 586
-	case "motion_working_group_speaker/meeting_user_id":
-		return 587
-	case "motion_working_group_speaker/motion_id":
-		return 588
-	case "motion_working_group_speaker/weight":
-		return 589
-	case "option/A":
-		return 590
-	case "option/B":
-		return 591
-	case "option/abstain":
-		return 592
-	case "option/content_object_id":
-		return 593
-	case "option/id":
-		return 594
-	case "option/meeting_id":
-		return 595
-	case "option/no":
-		return 596
-	case "option/poll_id":
-		return 597
-	case "option/text":
-		return 598
-	case "option/used_as_global_option_in_poll_id":
-		return 599
-	case "option/vote_ids":
-		return 600
-	case "option/weight":
-		return 601
-	case "option/yes":
-		return 602
-	case "organization/A":
-		return 603
-	case "organization/B":
-		return 604
-	case "organization/C":
-		return 605
-	case "organization/D":
-		return 606
-	case "organization/E":
-		return 607
-	case "organization/active_meeting_ids":
-		return 608
-	case "organization/archived_meeting_ids":
-		return 609
-	case "organization/committee_ids":
-		return 610
-	case "organization/default_language":
-		return 611
-	case "organization/description":
-		return 612
-	case "organization/enable_anonymous":
-		return 613
-	case "organization/enable_chat":
-		return 614
-	case "organization/enable_electronic_voting":
-		return 615
-	case "organization/gender_ids":
-		return 616
-	case "organization/id":
-		return 617
-	case "organization/legal_notice":
-		return 618
-	case "organization/limit_of_meetings":
-		return 619
-	case "organization/limit_of_users":
-		return 620
-	case "organization/login_text":
-		return 621
-	case "organization/mediafile_ids":
-		return 622
-	case "organization/name":
-		return 623
-	case "organization/organization_tag_ids":
-		return 624
-	case "organization/privacy_policy":
-		return 625
-	case "organization/published_mediafile_ids":
-		return 626
-	case "organization/require_duplicate_from":
-		return 627
-	case "organization/reset_password_verbose_errors":
-		return 628
-	case "organization/saml_attr_mapping":
-		return 629
-	case "organization/saml_enabled":
-		return 630
-	case "organization/saml_login_button_text":
-		return 631
-	case "organization/saml_metadata_idp":
-		return 632
-	case "organization/saml_metadata_sp":
-		return 633
-	case "organization/saml_private_key":
-		return 634
-	case "organization/template_meeting_ids":
-		return 635
-	case "organization/theme_id":
-		return 636
-	case "organization/theme_ids":
-		return 637
-	case "organization/url":
-		return 638
-	case "organization/user_ids":
-		return 639
-	case "organization/users_email_body":
-		return 640
-	case "organization/users_email_replyto":
-		return 641
-	case "organization/users_email_sender":
-		return 642
-	case "organization/users_email_subject":
-		return 643
-	case "organization/vote_decrypt_public_main_key":
-		return 644
-	case "organization_tag/A":
-		return 645
-	case "organization_tag/color":
-		return 646
-	case "organization_tag/id":
-		return 647
-	case "organization_tag/name":
-		return 648
-	case "organization_tag/organization_id":
-		return 649
-	case "organization_tag/tagged_ids":
-		return 650
-	case "personal_note/A":
-		return 651
-	case "personal_note/content_object_id":
-		return 652
-	case "personal_note/id":
-		return 653
-	case "personal_note/meeting_id":
-		return 654
-	case "personal_note/meeting_user_id":
-		return 655
-	case "personal_note/note":
-		return 656
-	case "personal_note/star":
-		return 657
-	case "point_of_order_category/A":
-		return 658
-	case "point_of_order_category/id":
-		return 659
-	case "point_of_order_category/meeting_id":
-		return 660
-	case "point_of_order_category/rank":
-		return 661
-	case "point_of_order_category/speaker_ids":
-		return 662
-	case "point_of_order_category/text":
-		return 663
-	case "poll/A":
-		return 664
-	case "poll/B":
-		return 665
-	case "poll/C":
-		return 666
-	case "poll/D":
-		return 667
-	case "poll/backend":
-		return 668
-	case "poll/content_object_id":
-		return 669
-	case "poll/crypt_key":
-		return 670
-	case "poll/crypt_signature":
-		return 671
-	case "poll/description":
-		return 672
-	case "poll/entitled_group_ids":
-		return 673
-	case "poll/entitled_users_at_stop":
-		return 674
-	case "poll/global_abstain":
-		return 675
-	case "poll/global_no":
-		return 676
-	case "poll/global_option_id":
-		return 677
-	case "poll/global_yes":
-		return 678
-	case "poll/id":
-		return 679
-	case "poll/is_pseudoanonymized":
-		return 680
-	case "poll/max_votes_amount":
-		return 681
-	case "poll/max_votes_per_option":
-		return 682
-	case "poll/meeting_id":
-		return 683
-	case "poll/min_votes_amount":
-		return 684
-	case "poll/onehundred_percent_base":
-		return 685
-	case "poll/option_ids":
-		return 686
-	case "poll/pollmethod":
-		return 687
-	case "poll/projection_ids":
-		return 688
-	case "poll/sequential_number":
-		return 689
-	case "poll/state":
-		return 690
-	case "poll/title":
-		return 691
-	case "poll/type":
-		return 692
-	case "poll/vote_count":
-		return 693
-	case "poll/voted_ids":
-		return 694
-	case "poll/votes_raw":
-		return 695
-	case "poll/votes_signature":
-		return 696
-	case "poll/votescast":
-		return 697
-	case "poll/votesinvalid":
-		return 698
-	case "poll/votesvalid":
-		return 699
-	case "poll_candidate/A":
-		return 700
-	case "poll_candidate/id":
-		return 701
-	case "poll_candidate/meeting_id":
-		return 702
-	case "poll_candidate/poll_candidate_list_id":
-		return 703
-	case "poll_candidate/user_id":
-		return 704
-	case "poll_candidate/weight":
-		return 705
-	case "poll_candidate_list/A":
-		return 706
-	case "poll_candidate_list/id":
-		return 707
-	case "poll_candidate_list/meeting_id":
-		return 708
-	case "poll_candidate_list/option_id":
-		return 709
-	case "poll_candidate_list/poll_candidate_ids":
-		return 710
-	case "projection/A":
-		return 711
-	case "projection/content":
-		return 712
-	case "projection/content_object_id":
-		return 713
-	case "projection/current_projector_id":
-		return 714
-	case "projection/history_projector_id":
-		return 715
-	case "projection/id":
-		return 716
-	case "projection/meeting_id":
-		return 717
-	case "projection/options":
-		return 718
-	case "projection/preview_projector_id":
-		return 719
-	case "projection/stable":
-		return 720
-	case "projection/type":
-		return 721
-	case "projection/weight":
-		return 722
-	case "projector/A":
-		return 723
-	case "projector/aspect_ratio_denominator":
-		return 724
-	case "projector/aspect_ratio_numerator":
-		return 725
-	case "projector/background_color":
-		return 726
-	case "projector/chyron_background_color":
-		return 727
-	case "projector/chyron_background_color_2":
-		return 728
-	case "projector/chyron_font_color":
-		return 729
-	case "projector/chyron_font_color_2":
-		return 730
-	case "projector/color":
-		return 731
-	case "projector/current_projection_ids":
-		return 732
-	case "projector/header_background_color":
-		return 733
-	case "projector/header_font_color":
-		return 734
-	case "projector/header_h1_color":
-		return 735
-	case "projector/history_projection_ids":
-		return 736
-	case "projector/id":
-		return 737
-	case "projector/is_internal":
-		return 738
-	case "projector/meeting_id":
-		return 739
-	case "projector/name":
-		return 740
-	case "projector/preview_projection_ids":
-		return 741
-	case "projector/scale":
-		return 742
-	case "projector/scroll":
-		return 743
-	case "projector/sequential_number":
-		return 744
-	case "projector/show_clock":
-		return 745
-	case "projector/show_header_footer":
-		return 746
-	case "projector/show_logo":
-		return 747
-	case "projector/show_title":
-		return 748
-	case "projector/used_as_default_projector_for_agenda_item_list_in_meeting_id":
-		return 749
-	case "projector/used_as_default_projector_for_amendment_in_meeting_id":
-		return 750
-	case "projector/used_as_default_projector_for_assignment_in_meeting_id":
-		return 751
-	case "projector/used_as_default_projector_for_assignment_poll_in_meeting_id":
-		return 752
-	case "projector/used_as_default_projector_for_countdown_in_meeting_id":
-		return 753
-	case "projector/used_as_default_projector_for_current_list_of_speakers_in_meeting_id":
-		return 754
-	case "projector/used_as_default_projector_for_list_of_speakers_in_meeting_id":
-		return 755
-	case "projector/used_as_default_projector_for_mediafile_in_meeting_id":
-		return 756
-	case "projector/used_as_default_projector_for_message_in_meeting_id":
-		return 757
-	case "projector/used_as_default_projector_for_motion_block_in_meeting_id":
-		return 758
-	case "projector/used_as_default_projector_for_motion_in_meeting_id":
-		return 759
-	case "projector/used_as_default_projector_for_motion_poll_in_meeting_id":
-		return 760
-	case "projector/used_as_default_projector_for_poll_in_meeting_id":
-		return 761
-	case "projector/used_as_default_projector_for_topic_in_meeting_id":
-		return 762
-	case "projector/used_as_reference_projector_meeting_id":
-		return 763
-	case "projector/width":
-		return 764
-	case "projector_countdown/A":
-		return 765
-	case "projector_countdown/countdown_time":
-		return 766
-	case "projector_countdown/default_time":
-		return 767
-	case "projector_countdown/description":
-		return 768
-	case "projector_countdown/id":
-		return 769
-	case "projector_countdown/meeting_id":
-		return 770
-	case "projector_countdown/projection_ids":
-		return 771
-	case "projector_countdown/running":
-		return 772
-	case "projector_countdown/title":
-		return 773
-	case "projector_countdown/used_as_list_of_speakers_countdown_meeting_id":
-		return 774
-	case "projector_countdown/used_as_poll_countdown_meeting_id":
-		return 775
-	case "projector_message/A":
-		return 776
-	case "projector_message/id":
-		return 777
-	case "projector_message/meeting_id":
-		return 778
-	case "projector_message/message":
-		return 779
-	case "projector_message/projection_ids":
-		return 780
-	case "speaker/A":
-		return 781
-	case "speaker/begin_time":
-		return 782
-	case "speaker/end_time":
-		return 783
-	case "speaker/id":
-		return 784
-	case "speaker/list_of_speakers_id":
-		return 785
-	case "speaker/meeting_id":
-		return 786
-	case "speaker/meeting_user_id":
-		return 787
-	case "speaker/note":
-		return 788
-	case "speaker/pause_time":
-		return 789
-	case "speaker/point_of_order":
-		return 790
-	case "speaker/point_of_order_category_id":
-		return 791
-	case "speaker/speech_state":
-		return 792
-	case "speaker/structure_level_list_of_speakers_id":
-		return 793
-	case "speaker/total_pause":
-		return 794
-	case "speaker/unpause_time":
-		return 795
-	case "speaker/weight":
-		return 796
-	case "structure_level/A":
-		return 797
-	case "structure_level/color":
-		return 798
-	case "structure_level/default_time":
-		return 799
-	case "structure_level/id":
-		return 800
-	case "structure_level/meeting_id":
-		return 801
-	case "structure_level/meeting_user_ids":
-		return 802
-	case "structure_level/name":
-		return 803
-	case "structure_level/structure_level_list_of_speakers_ids":
-		return 804
-	case "structure_level_list_of_speakers/A":
-		return 805
-	case "structure_level_list_of_speakers/additional_time":
-		return 806
-	case "structure_level_list_of_speakers/current_start_time":
-		return 807
-	case "structure_level_list_of_speakers/id":
-		return 808
-	case "structure_level_list_of_speakers/initial_time":
-		return 809
-	case "structure_level_list_of_speakers/list_of_speakers_id":
-		return 810
-	case "structure_level_list_of_speakers/meeting_id":
-		return 811
-	case "structure_level_list_of_speakers/remaining_time":
-		return 812
-	case "structure_level_list_of_speakers/speaker_ids":
-		return 813
-	case "structure_level_list_of_speakers/structure_level_id":
-		return 814
-	case "tag/A":
-		return 815
-	case "tag/id":
-		return 816
-	case "tag/meeting_id":
-		return 817
-	case "tag/name":
-		return 818
-	case "tag/tagged_ids":
-		return 819
-	case "theme/A":
-		return 820
-	case "theme/abstain":
-		return 821
-	case "theme/accent_100":
-		return 822
-	case "theme/accent_200":
-		return 823
-	case "theme/accent_300":
-		return 824
-	case "theme/accent_400":
-		return 825
-	case "theme/accent_50":
-		return 826
-	case "theme/accent_500":
-		return 827
-	case "theme/accent_600":
-		return 828
-	case "theme/accent_700":
-		return 829
-	case "theme/accent_800":
-		return 830
-	case "theme/accent_900":
-		return 831
-	case "theme/accent_a100":
-		return 832
-	case "theme/accent_a200":
-		return 833
-	case "theme/accent_a400":
-		return 834
-	case "theme/accent_a700":
-		return 835
-	case "theme/headbar":
-		return 836
-	case "theme/id":
-		return 837
-	case "theme/name":
-		return 838
-	case "theme/no":
-		return 839
-	case "theme/organization_id":
-		return 840
-	case "theme/primary_100":
-		return 841
-	case "theme/primary_200":
-		return 842
-	case "theme/primary_300":
-		return 843
-	case "theme/primary_400":
-		return 844
-	case "theme/primary_50":
-		return 845
-	case "theme/primary_500":
-		return 846
-	case "theme/primary_600":
-		return 847
-	case "theme/primary_700":
-		return 848
-	case "theme/primary_800":
-		return 849
-	case "theme/primary_900":
-		return 850
-	case "theme/primary_a100":
-		return 851
-	case "theme/primary_a200":
-		return 852
-	case "theme/primary_a400":
-		return 853
-	case "theme/primary_a700":
-		return 854
-	case "theme/theme_for_organization_id":
-		return 855
-	case "theme/warn_100":
-		return 856
-	case "theme/warn_200":
-		return 857
-	case "theme/warn_300":
-		return 858
-	case "theme/warn_400":
-		return 859
-	case "theme/warn_50":
-		return 860
-	case "theme/warn_500":
-		return 861
-	case "theme/warn_600":
-		return 862
-	case "theme/warn_700":
-		return 863
-	case "theme/warn_800":
-		return 864
-	case "theme/warn_900":
-		return 865
-	case "theme/warn_a100":
-		return 866
-	case "theme/warn_a200":
-		return 867
-	case "theme/warn_a400":
-		return 868
-	case "theme/warn_a700":
-		return 869
-	case "theme/yes":
-		return 870
-	case "topic/A":
-		return 871
-	case "topic/agenda_item_id":
-		return 872
-	case "topic/attachment_meeting_mediafile_ids":
-		return 873
-	case "topic/id":
-		return 874
-	case "topic/list_of_speakers_id":
-		return 875
-	case "topic/meeting_id":
-		return 876
-	case "topic/poll_ids":
-		return 877
-	case "topic/projection_ids":
-		return 878
-	case "topic/sequential_number":
-		return 879
-	case "topic/text":
-		return 880
-	case "topic/title":
-		return 881
-	case "user/A":
-		return 882
-	case "user/B":
-		return 883
-	case "user/D":
-		return 884
-	case "user/E":
-		return 885
-	case "user/F":
-		return 886
-	case "user/G":
-		return 887
-	case "user/H":
-		return 888
-	case "user/can_change_own_password":
-		return 889
-	case "user/committee_ids":
-		return 890
-	case "user/committee_management_ids":
-		return 891
-	case "user/default_password":
-		return 892
-	case "user/default_vote_weight":
-		return 893
-	case "user/delegated_vote_ids":
-		return 894
-	case "user/email":
-		return 895
-	case "user/first_name":
-		return 896
-	case "user/forwarding_committee_ids":
-		return 897
-	case "user/gender_id":
-		return 898
-	case "user/id":
-		return 899
-	case "user/is_active":
-		return 900
-	case "user/is_demo_user":
-		return 901
-	case "user/is_physical_person":
-		return 902
-	case "user/is_present_in_meeting_ids":
-		return 903
-	case "user/last_email_sent":
-		return 904
-	case "user/last_login":
-		return 905
-	case "user/last_name":
-		return 906
-	case "user/meeting_ids":
-		return 907
-	case "user/meeting_user_ids":
-		return 908
-	case "user/member_number":
-		return 909
-	case "user/option_ids":
-		return 910
-	case "user/organization_id":
-		return 911
-	case "user/organization_management_level":
-		return 912
-	case "user/password":
-		return 913
-	case "user/poll_candidate_ids":
-		return 914
-	case "user/poll_voted_ids":
-		return 915
-	case "user/pronoun":
-		return 916
-	case "user/saml_id":
-		return 917
-	case "user/title":
-		return 918
-	case "user/username":
-		return 919
-	case "user/vote_ids":
-		return 920
-	case "vote/A":
-		return 921
-	case "vote/B":
-		return 922
-	case "vote/delegated_user_id":
-		return 923
-	case "vote/id":
-		return 924
-	case "vote/meeting_id":
-		return 925
-	case "vote/option_id":
-		return 926
-	case "vote/user_id":
-		return 927
-	case "vote/user_token":
-		return 928
-	case "vote/value":
-		return 929
-	case "vote/weight":
-		return 930
-	default:
-		return -1
+func collectionFieldToID(collection, field string) int {
+	switch collection {
+	case "action_worker":
+		switch field {
+		case "A":
+			return 1
+		case "created":
+			return 2
+		case "id":
+			return 3
+		case "name":
+			return 4
+		case "result":
+			return 5
+		case "state":
+			return 6
+		case "timestamp":
+			return 7
+		case "user_id":
+			return 8
+		}
+	case "agenda_item":
+		switch field {
+		case "A":
+			return 9
+		case "B":
+			return 10
+		case "C":
+			return 11
+		case "child_ids":
+			return 12
+		case "closed":
+			return 13
+		case "comment":
+			return 14
+		case "content_object_id":
+			return 15
+		case "duration":
+			return 16
+		case "id":
+			return 17
+		case "is_hidden":
+			return 18
+		case "is_internal":
+			return 19
+		case "item_number":
+			return 20
+		case "level":
+			return 21
+		case "meeting_id":
+			return 22
+		case "parent_id":
+			return 23
+		case "projection_ids":
+			return 24
+		case "tag_ids":
+			return 25
+		case "type":
+			return 26
+		case "weight":
+			return 27
+		}
+	case "assignment":
+		switch field {
+		case "A":
+			return 28
+		case "agenda_item_id":
+			return 29
+		case "attachment_meeting_mediafile_ids":
+			return 30
+		case "candidate_ids":
+			return 31
+		case "default_poll_description":
+			return 32
+		case "description":
+			return 33
+		case "id":
+			return 34
+		case "list_of_speakers_id":
+			return 35
+		case "meeting_id":
+			return 36
+		case "number_poll_candidates":
+			return 37
+		case "open_posts":
+			return 38
+		case "phase":
+			return 39
+		case "poll_ids":
+			return 40
+		case "projection_ids":
+			return 41
+		case "sequential_number":
+			return 42
+		case "tag_ids":
+			return 43
+		case "title":
+			return 44
+		}
+	case "assignment_candidate":
+		switch field {
+		case "A":
+			return 45
+		case "assignment_id":
+			return 46
+		case "id":
+			return 47
+		case "meeting_id":
+			return 48
+		case "meeting_user_id":
+			return 49
+		case "weight":
+			return 50
+		}
+	case "chat_group":
+		switch field {
+		case "A":
+			return 51
+		case "chat_message_ids":
+			return 52
+		case "id":
+			return 53
+		case "meeting_id":
+			return 54
+		case "name":
+			return 55
+		case "read_group_ids":
+			return 56
+		case "weight":
+			return 57
+		case "write_group_ids":
+			return 58
+		}
+	case "chat_message":
+		switch field {
+		case "A":
+			return 59
+		case "chat_group_id":
+			return 60
+		case "content":
+			return 61
+		case "created":
+			return 62
+		case "id":
+			return 63
+		case "meeting_id":
+			return 64
+		case "meeting_user_id":
+			return 65
+		}
+	case "committee":
+		switch field {
+		case "A":
+			return 66
+		case "B":
+			return 67
+		case "default_meeting_id":
+			return 68
+		case "description":
+			return 69
+		case "external_id":
+			return 70
+		case "forward_to_committee_ids":
+			return 71
+		case "forwarding_user_id":
+			return 72
+		case "id":
+			return 73
+		case "manager_ids":
+			return 74
+		case "meeting_ids":
+			return 75
+		case "name":
+			return 76
+		case "organization_id":
+			return 77
+		case "organization_tag_ids":
+			return 78
+		case "receive_forwardings_from_committee_ids":
+			return 79
+		case "user_ids":
+			return 80
+		}
+	case "gender":
+		switch field {
+		case "A":
+			return 81
+		case "id":
+			return 82
+		case "name":
+			return 83
+		case "organization_id":
+			return 84
+		case "user_ids":
+			return 85
+		}
+	case "group":
+		switch field {
+		case "A":
+			return 86
+		case "admin_group_for_meeting_id":
+			return 87
+		case "anonymous_group_for_meeting_id":
+			return 88
+		case "default_group_for_meeting_id":
+			return 89
+		case "external_id":
+			return 90
+		case "id":
+			return 91
+		case "meeting_id":
+			return 92
+		case "meeting_mediafile_access_group_ids":
+			return 93
+		case "meeting_mediafile_inherited_access_group_ids":
+			return 94
+		case "meeting_user_ids":
+			return 95
+		case "name":
+			return 96
+		case "permissions":
+			return 97
+		case "poll_ids":
+			return 98
+		case "read_chat_group_ids":
+			return 99
+		case "read_comment_section_ids":
+			return 100
+		case "used_as_assignment_poll_default_id":
+			return 101
+		case "used_as_motion_poll_default_id":
+			return 102
+		case "used_as_poll_default_id":
+			return 103
+		case "used_as_topic_poll_default_id":
+			return 104
+		case "weight":
+			return 105
+		case "write_chat_group_ids":
+			return 106
+		case "write_comment_section_ids":
+			return 107
+		}
+	case "import_preview":
+		switch field {
+		case "A":
+			return 108
+		case "created":
+			return 109
+		case "id":
+			return 110
+		case "name":
+			return 111
+		case "result":
+			return 112
+		case "state":
+			return 113
+		}
+	case "list_of_speakers":
+		switch field {
+		case "A":
+			return 114
+		case "B":
+			return 115
+		case "closed":
+			return 116
+		case "content_object_id":
+			return 117
+		case "id":
+			return 118
+		case "meeting_id":
+			return 119
+		case "moderator_notes":
+			return 120
+		case "projection_ids":
+			return 121
+		case "sequential_number":
+			return 122
+		case "speaker_ids":
+			return 123
+		case "structure_level_list_of_speakers_ids":
+			return 124
+		}
+	case "mediafile":
+		switch field {
+		case "A":
+			return 125
+		case "child_ids":
+			return 126
+		case "create_timestamp":
+			return 127
+		case "filename":
+			return 128
+		case "filesize":
+			return 129
+		case "id":
+			return 130
+		case "is_directory":
+			return 131
+		case "meeting_mediafile_ids":
+			return 132
+		case "mimetype":
+			return 133
+		case "owner_id":
+			return 134
+		case "parent_id":
+			return 135
+		case "pdf_information":
+			return 136
+		case "published_to_meetings_in_organization_id":
+			return 137
+		case "title":
+			return 138
+		case "token":
+			return 139
+		}
+	case "meeting":
+		switch field {
+		case "A":
+			return 140
+		case "B":
+			return 141
+		case "C":
+			return 142
+		case "E":
+			return 143
+		case "admin_group_id":
+			return 144
+		case "agenda_enable_numbering":
+			return 145
+		case "agenda_item_creation":
+			return 146
+		case "agenda_item_ids":
+			return 147
+		case "agenda_new_items_default_visibility":
+			return 148
+		case "agenda_number_prefix":
+			return 149
+		case "agenda_numeral_system":
+			return 150
+		case "agenda_show_internal_items_on_projector":
+			return 151
+		case "agenda_show_subtitles":
+			return 152
+		case "agenda_show_topic_navigation_on_detail_view":
+			return 153
+		case "all_projection_ids":
+			return 154
+		case "anonymous_group_id":
+			return 155
+		case "applause_enable":
+			return 156
+		case "applause_max_amount":
+			return 157
+		case "applause_min_amount":
+			return 158
+		case "applause_particle_image_url":
+			return 159
+		case "applause_show_level":
+			return 160
+		case "applause_timeout":
+			return 161
+		case "applause_type":
+			return 162
+		case "assignment_candidate_ids":
+			return 163
+		case "assignment_ids":
+			return 164
+		case "assignment_poll_add_candidates_to_list_of_speakers":
+			return 165
+		case "assignment_poll_ballot_paper_number":
+			return 166
+		case "assignment_poll_ballot_paper_selection":
+			return 167
+		case "assignment_poll_default_backend":
+			return 168
+		case "assignment_poll_default_group_ids":
+			return 169
+		case "assignment_poll_default_method":
+			return 170
+		case "assignment_poll_default_onehundred_percent_base":
+			return 171
+		case "assignment_poll_default_type":
+			return 172
+		case "assignment_poll_enable_max_votes_per_option":
+			return 173
+		case "assignment_poll_sort_poll_result_by_votes":
+			return 174
+		case "assignments_export_preamble":
+			return 175
+		case "assignments_export_title":
+			return 176
+		case "chat_group_ids":
+			return 177
+		case "chat_message_ids":
+			return 178
+		case "committee_id":
+			return 179
+		case "conference_auto_connect":
+			return 180
+		case "conference_auto_connect_next_speakers":
+			return 181
+		case "conference_enable_helpdesk":
+			return 182
+		case "conference_los_restriction":
+			return 183
+		case "conference_open_microphone":
+			return 184
+		case "conference_open_video":
+			return 185
+		case "conference_show":
+			return 186
+		case "conference_stream_poster_url":
+			return 187
+		case "conference_stream_url":
+			return 188
+		case "custom_translations":
+			return 189
+		case "default_group_id":
+			return 190
+		case "default_meeting_for_committee_id":
+			return 191
+		case "default_projector_agenda_item_list_ids":
+			return 192
+		case "default_projector_amendment_ids":
+			return 193
+		case "default_projector_assignment_ids":
+			return 194
+		case "default_projector_assignment_poll_ids":
+			return 195
+		case "default_projector_countdown_ids":
+			return 196
+		case "default_projector_current_list_of_speakers_ids":
+			return 197
+		case "default_projector_list_of_speakers_ids":
+			return 198
+		case "default_projector_mediafile_ids":
+			return 199
+		case "default_projector_message_ids":
+			return 200
+		case "default_projector_motion_block_ids":
+			return 201
+		case "default_projector_motion_ids":
+			return 202
+		case "default_projector_motion_poll_ids":
+			return 203
+		case "default_projector_poll_ids":
+			return 204
+		case "default_projector_topic_ids":
+			return 205
+		case "description":
+			return 206
+		case "enable_anonymous":
+			return 207
+		case "end_time":
+			return 208
+		case "export_csv_encoding":
+			return 209
+		case "export_csv_separator":
+			return 210
+		case "export_pdf_fontsize":
+			return 211
+		case "export_pdf_line_height":
+			return 212
+		case "export_pdf_page_margin_bottom":
+			return 213
+		case "export_pdf_page_margin_left":
+			return 214
+		case "export_pdf_page_margin_right":
+			return 215
+		case "export_pdf_page_margin_top":
+			return 216
+		case "export_pdf_pagenumber_alignment":
+			return 217
+		case "export_pdf_pagesize":
+			return 218
+		case "external_id":
+			return 219
+		case "font_bold_id":
+			return 220
+		case "font_bold_italic_id":
+			return 221
+		case "font_chyron_speaker_name_id":
+			return 222
+		case "font_italic_id":
+			return 223
+		case "font_monospace_id":
+			return 224
+		case "font_projector_h1_id":
+			return 225
+		case "font_projector_h2_id":
+			return 226
+		case "font_regular_id":
+			return 227
+		case "forwarded_motion_ids":
+			return 228
+		case "group_ids":
+			return 229
+		case "id":
+			return 230
+		case "imported_at":
+			return 231
+		case "is_active_in_organization_id":
+			return 232
+		case "is_archived_in_organization_id":
+			return 233
+		case "jitsi_domain":
+			return 234
+		case "jitsi_room_name":
+			return 235
+		case "jitsi_room_password":
+			return 236
+		case "language":
+			return 237
+		case "list_of_speakers_allow_multiple_speakers":
+			return 238
+		case "list_of_speakers_amount_last_on_projector":
+			return 239
+		case "list_of_speakers_amount_next_on_projector":
+			return 240
+		case "list_of_speakers_can_create_point_of_order_for_others":
+			return 241
+		case "list_of_speakers_can_set_contribution_self":
+			return 242
+		case "list_of_speakers_closing_disables_point_of_order":
+			return 243
+		case "list_of_speakers_countdown_id":
+			return 244
+		case "list_of_speakers_couple_countdown":
+			return 245
+		case "list_of_speakers_default_structure_level_time":
+			return 246
+		case "list_of_speakers_enable_interposed_question":
+			return 247
+		case "list_of_speakers_enable_point_of_order_categories":
+			return 248
+		case "list_of_speakers_enable_point_of_order_speakers":
+			return 249
+		case "list_of_speakers_enable_pro_contra_speech":
+			return 250
+		case "list_of_speakers_hide_contribution_count":
+			return 251
+		case "list_of_speakers_ids":
+			return 252
+		case "list_of_speakers_initially_closed":
+			return 253
+		case "list_of_speakers_intervention_time":
+			return 254
+		case "list_of_speakers_present_users_only":
+			return 255
+		case "list_of_speakers_show_amount_of_speakers_on_slide":
+			return 256
+		case "list_of_speakers_show_first_contribution":
+			return 257
+		case "list_of_speakers_speaker_note_for_everyone":
+			return 258
+		case "location":
+			return 259
+		case "locked_from_inside":
+			return 260
+		case "logo_pdf_ballot_paper_id":
+			return 261
+		case "logo_pdf_footer_l_id":
+			return 262
+		case "logo_pdf_footer_r_id":
+			return 263
+		case "logo_pdf_header_l_id":
+			return 264
+		case "logo_pdf_header_r_id":
+			return 265
+		case "logo_projector_header_id":
+			return 266
+		case "logo_projector_main_id":
+			return 267
+		case "logo_web_header_id":
+			return 268
+		case "mediafile_ids":
+			return 269
+		case "meeting_mediafile_ids":
+			return 270
+		case "meeting_user_ids":
+			return 271
+		case "motion_block_ids":
+			return 272
+		case "motion_category_ids":
+			return 273
+		case "motion_change_recommendation_ids":
+			return 274
+		case "motion_comment_ids":
+			return 275
+		case "motion_comment_section_ids":
+			return 276
+		case "motion_editor_ids":
+			return 277
+		case "motion_ids":
+			return 278
+		case "motion_poll_ballot_paper_number":
+			return 279
+		case "motion_poll_ballot_paper_selection":
+			return 280
+		case "motion_poll_default_backend":
+			return 281
+		case "motion_poll_default_group_ids":
+			return 282
+		case "motion_poll_default_method":
+			return 283
+		case "motion_poll_default_onehundred_percent_base":
+			return 284
+		case "motion_poll_default_type":
+			return 285
+		case "motion_state_ids":
+			return 286
+		case "motion_submitter_ids":
+			return 287
+		case "motion_workflow_ids":
+			return 288
+		case "motion_working_group_speaker_ids":
+			return 289
+		case "motions_amendments_enabled":
+			return 290
+		case "motions_amendments_in_main_list":
+			return 291
+		case "motions_amendments_multiple_paragraphs":
+			return 292
+		case "motions_amendments_of_amendments":
+			return 293
+		case "motions_amendments_prefix":
+			return 294
+		case "motions_amendments_text_mode":
+			return 295
+		case "motions_block_slide_columns":
+			return 296
+		case "motions_create_enable_additional_submitter_text":
+			return 297
+		case "motions_default_amendment_workflow_id":
+			return 298
+		case "motions_default_line_numbering":
+			return 299
+		case "motions_default_sorting":
+			return 300
+		case "motions_default_workflow_id":
+			return 301
+		case "motions_enable_editor":
+			return 302
+		case "motions_enable_reason_on_projector":
+			return 303
+		case "motions_enable_recommendation_on_projector":
+			return 304
+		case "motions_enable_sidebox_on_projector":
+			return 305
+		case "motions_enable_text_on_projector":
+			return 306
+		case "motions_enable_working_group_speaker":
+			return 307
+		case "motions_export_follow_recommendation":
+			return 308
+		case "motions_export_preamble":
+			return 309
+		case "motions_export_submitter_recommendation":
+			return 310
+		case "motions_export_title":
+			return 311
+		case "motions_hide_metadata_background":
+			return 312
+		case "motions_line_length":
+			return 313
+		case "motions_number_min_digits":
+			return 314
+		case "motions_number_type":
+			return 315
+		case "motions_number_with_blank":
+			return 316
+		case "motions_preamble":
+			return 317
+		case "motions_reason_required":
+			return 318
+		case "motions_recommendation_text_mode":
+			return 319
+		case "motions_recommendations_by":
+			return 320
+		case "motions_show_referring_motions":
+			return 321
+		case "motions_show_sequential_number":
+			return 322
+		case "motions_supporters_min_amount":
+			return 323
+		case "name":
+			return 324
+		case "option_ids":
+			return 325
+		case "organization_tag_ids":
+			return 326
+		case "personal_note_ids":
+			return 327
+		case "point_of_order_category_ids":
+			return 328
+		case "poll_ballot_paper_number":
+			return 329
+		case "poll_ballot_paper_selection":
+			return 330
+		case "poll_candidate_ids":
+			return 331
+		case "poll_candidate_list_ids":
+			return 332
+		case "poll_countdown_id":
+			return 333
+		case "poll_couple_countdown":
+			return 334
+		case "poll_default_backend":
+			return 335
+		case "poll_default_group_ids":
+			return 336
+		case "poll_default_method":
+			return 337
+		case "poll_default_onehundred_percent_base":
+			return 338
+		case "poll_default_type":
+			return 339
+		case "poll_ids":
+			return 340
+		case "poll_sort_poll_result_by_votes":
+			return 341
+		case "present_user_ids":
+			return 342
+		case "projection_ids":
+			return 343
+		case "projector_countdown_default_time":
+			return 344
+		case "projector_countdown_ids":
+			return 345
+		case "projector_countdown_warning_time":
+			return 346
+		case "projector_ids":
+			return 347
+		case "projector_message_ids":
+			return 348
+		case "reference_projector_id":
+			return 349
+		case "speaker_ids":
+			return 350
+		case "start_time":
+			return 351
+		case "structure_level_ids":
+			return 352
+		case "structure_level_list_of_speakers_ids":
+			return 353
+		case "tag_ids":
+			return 354
+		case "template_for_organization_id":
+			return 355
+		case "topic_ids":
+			return 356
+		case "topic_poll_default_group_ids":
+			return 357
+		case "user_ids":
+			return 358
+		case "users_allow_self_set_present":
+			return 359
+		case "users_email_body":
+			return 360
+		case "users_email_replyto":
+			return 361
+		case "users_email_sender":
+			return 362
+		case "users_email_subject":
+			return 363
+		case "users_enable_presence_view":
+			return 364
+		case "users_enable_vote_delegations":
+			return 365
+		case "users_enable_vote_weight":
+			return 366
+		case "users_forbid_delegator_as_submitter":
+			return 367
+		case "users_forbid_delegator_as_supporter":
+			return 368
+		case "users_forbid_delegator_in_list_of_speakers":
+			return 369
+		case "users_forbid_delegator_to_vote":
+			return 370
+		case "users_pdf_welcometext":
+			return 371
+		case "users_pdf_welcometitle":
+			return 372
+		case "users_pdf_wlan_encryption":
+			return 373
+		case "users_pdf_wlan_password":
+			return 374
+		case "users_pdf_wlan_ssid":
+			return 375
+		case "vote_ids":
+			return 376
+		case "welcome_text":
+			return 377
+		case "welcome_title":
+			return 378
+		}
+	case "meeting_mediafile":
+		switch field {
+		case "A":
+			return 379
+		case "access_group_ids":
+			return 380
+		case "attachment_ids":
+			return 381
+		case "id":
+			return 382
+		case "inherited_access_group_ids":
+			return 383
+		case "is_public":
+			return 384
+		case "list_of_speakers_id":
+			return 385
+		case "mediafile_id":
+			return 386
+		case "meeting_id":
+			return 387
+		case "projection_ids":
+			return 388
+		case "used_as_font_bold_in_meeting_id":
+			return 389
+		case "used_as_font_bold_italic_in_meeting_id":
+			return 390
+		case "used_as_font_chyron_speaker_name_in_meeting_id":
+			return 391
+		case "used_as_font_italic_in_meeting_id":
+			return 392
+		case "used_as_font_monospace_in_meeting_id":
+			return 393
+		case "used_as_font_projector_h1_in_meeting_id":
+			return 394
+		case "used_as_font_projector_h2_in_meeting_id":
+			return 395
+		case "used_as_font_regular_in_meeting_id":
+			return 396
+		case "used_as_logo_pdf_ballot_paper_in_meeting_id":
+			return 397
+		case "used_as_logo_pdf_footer_l_in_meeting_id":
+			return 398
+		case "used_as_logo_pdf_footer_r_in_meeting_id":
+			return 399
+		case "used_as_logo_pdf_header_l_in_meeting_id":
+			return 400
+		case "used_as_logo_pdf_header_r_in_meeting_id":
+			return 401
+		case "used_as_logo_projector_header_in_meeting_id":
+			return 402
+		case "used_as_logo_projector_main_in_meeting_id":
+			return 403
+		case "used_as_logo_web_header_in_meeting_id":
+			return 404
+		}
+	case "meeting_user":
+		switch field {
+		case "A":
+			return 405
+		case "B":
+			return 406
+		case "C":
+			return 407
+		case "D":
+			return 408
+		case "E":
+			return 409
+		case "about_me":
+			return 410
+		case "assignment_candidate_ids":
+			return 411
+		case "chat_message_ids":
+			return 412
+		case "comment":
+			return 413
+		case "group_ids":
+			return 414
+		case "id":
+			return 415
+		case "locked_out":
+			return 416
+		case "meeting_id":
+			return 417
+		case "motion_editor_ids":
+			return 418
+		case "motion_submitter_ids":
+			return 419
+		case "motion_working_group_speaker_ids":
+			return 420
+		case "number":
+			return 421
+		case "personal_note_ids":
+			return 422
+		case "speaker_ids":
+			return 423
+		case "structure_level_ids":
+			return 424
+		case "supported_motion_ids":
+			return 425
+		case "user_id":
+			return 426
+		case "vote_delegated_to_id":
+			return 427
+		case "vote_delegations_from_ids":
+			return 428
+		case "vote_weight":
+			return 429
+		}
+	case "motion":
+		switch field {
+		case "A":
+			return 430
+		case "B":
+			return 431
+		case "C":
+			return 432
+		case "D":
+			return 433
+		case "E":
+			return 434
+		case "additional_submitter":
+			return 435
+		case "agenda_item_id":
+			return 436
+		case "all_derived_motion_ids":
+			return 437
+		case "all_origin_ids":
+			return 438
+		case "amendment_ids":
+			return 439
+		case "amendment_paragraphs":
+			return 440
+		case "attachment_meeting_mediafile_ids":
+			return 441
+		case "block_id":
+			return 442
+		case "category_id":
+			return 443
+		case "category_weight":
+			return 444
+		case "change_recommendation_ids":
+			return 445
+		case "comment_ids":
+			return 446
+		case "created":
+			return 447
+		case "derived_motion_ids":
+			return 448
+		case "editor_ids":
+			return 449
+		case "forwarded":
+			return 450
+		case "id":
+			return 451
+		case "identical_motion_ids":
+			return 452
+		case "last_modified":
+			return 453
+		case "lead_motion_id":
+			return 454
+		case "list_of_speakers_id":
+			return 455
+		case "meeting_id":
+			return 456
+		case "modified_final_version":
+			return 457
+		case "number":
+			return 458
+		case "number_value":
+			return 459
+		case "option_ids":
+			return 460
+		case "origin_id":
+			return 461
+		case "origin_meeting_id":
+			return 462
+		case "personal_note_ids":
+			return 463
+		case "poll_ids":
+			return 464
+		case "projection_ids":
+			return 465
+		case "reason":
+			return 466
+		case "recommendation_extension":
+			return 467
+		case "recommendation_extension_reference_ids":
+			return 468
+		case "recommendation_id":
+			return 469
+		case "referenced_in_motion_recommendation_extension_ids":
+			return 470
+		case "referenced_in_motion_state_extension_ids":
+			return 471
+		case "sequential_number":
+			return 472
+		case "sort_child_ids":
+			return 473
+		case "sort_parent_id":
+			return 474
+		case "sort_weight":
+			return 475
+		case "start_line_number":
+			return 476
+		case "state_extension":
+			return 477
+		case "state_extension_reference_ids":
+			return 478
+		case "state_id":
+			return 479
+		case "submitter_ids":
+			return 480
+		case "supporter_meeting_user_ids":
+			return 481
+		case "tag_ids":
+			return 482
+		case "text":
+			return 483
+		case "text_hash":
+			return 484
+		case "title":
+			return 485
+		case "workflow_timestamp":
+			return 486
+		case "working_group_speaker_ids":
+			return 487
+		}
+	case "motion_block":
+		switch field {
+		case "A":
+			return 488
+		case "agenda_item_id":
+			return 489
+		case "id":
+			return 490
+		case "internal":
+			return 491
+		case "list_of_speakers_id":
+			return 492
+		case "meeting_id":
+			return 493
+		case "motion_ids":
+			return 494
+		case "projection_ids":
+			return 495
+		case "sequential_number":
+			return 496
+		case "title":
+			return 497
+		}
+	case "motion_category":
+		switch field {
+		case "A":
+			return 498
+		case "child_ids":
+			return 499
+		case "id":
+			return 500
+		case "level":
+			return 501
+		case "meeting_id":
+			return 502
+		case "motion_ids":
+			return 503
+		case "name":
+			return 504
+		case "parent_id":
+			return 505
+		case "prefix":
+			return 506
+		case "sequential_number":
+			return 507
+		case "weight":
+			return 508
+		}
+	case "motion_change_recommendation":
+		switch field {
+		case "A":
+			return 509
+		case "creation_time":
+			return 510
+		case "id":
+			return 511
+		case "internal":
+			return 512
+		case "line_from":
+			return 513
+		case "line_to":
+			return 514
+		case "meeting_id":
+			return 515
+		case "motion_id":
+			return 516
+		case "other_description":
+			return 517
+		case "rejected":
+			return 518
+		case "text":
+			return 519
+		case "type":
+			return 520
+		}
+	case "motion_comment":
+		switch field {
+		case "A":
+			return 521
+		case "comment":
+			return 522
+		case "id":
+			return 523
+		case "meeting_id":
+			return 524
+		case "motion_id":
+			return 525
+		case "section_id":
+			return 526
+		}
+	case "motion_comment_section":
+		switch field {
+		case "A":
+			return 527
+		case "comment_ids":
+			return 528
+		case "id":
+			return 529
+		case "meeting_id":
+			return 530
+		case "name":
+			return 531
+		case "read_group_ids":
+			return 532
+		case "sequential_number":
+			return 533
+		case "submitter_can_write":
+			return 534
+		case "weight":
+			return 535
+		case "write_group_ids":
+			return 536
+		}
+	case "motion_editor":
+		switch field {
+		case "A":
+			return 537
+		case "id":
+			return 538
+		case "meeting_id":
+			return 539
+		case "meeting_user_id":
+			return 540
+		case "motion_id":
+			return 541
+		case "weight":
+			return 542
+		}
+	case "motion_state":
+		switch field {
+		case "A":
+			return 543
+		case "allow_create_poll":
+			return 544
+		case "allow_motion_forwarding":
+			return 545
+		case "allow_submitter_edit":
+			return 546
+		case "allow_support":
+			return 547
+		case "css_class":
+			return 548
+		case "first_state_of_workflow_id":
+			return 549
+		case "id":
+			return 550
+		case "is_internal":
+			return 551
+		case "meeting_id":
+			return 552
+		case "merge_amendment_into_final":
+			return 553
+		case "motion_ids":
+			return 554
+		case "motion_recommendation_ids":
+			return 555
+		case "name":
+			return 556
+		case "next_state_ids":
+			return 557
+		case "previous_state_ids":
+			return 558
+		case "recommendation_label":
+			return 559
+		case "restrictions":
+			return 560
+		case "set_number":
+			return 561
+		case "set_workflow_timestamp":
+			return 562
+		case "show_recommendation_extension_field":
+			return 563
+		case "show_state_extension_field":
+			return 564
+		case "submitter_withdraw_back_ids":
+			return 565
+		case "submitter_withdraw_state_id":
+			return 566
+		case "weight":
+			return 567
+		case "workflow_id":
+			return 568
+		}
+	case "motion_submitter":
+		switch field {
+		case "A":
+			return 569
+		case "id":
+			return 570
+		case "meeting_id":
+			return 571
+		case "meeting_user_id":
+			return 572
+		case "motion_id":
+			return 573
+		case "weight":
+			return 574
+		}
+	case "motion_workflow":
+		switch field {
+		case "A":
+			return 575
+		case "default_amendment_workflow_meeting_id":
+			return 576
+		case "default_workflow_meeting_id":
+			return 577
+		case "first_state_id":
+			return 578
+		case "id":
+			return 579
+		case "meeting_id":
+			return 580
+		case "name":
+			return 581
+		case "sequential_number":
+			return 582
+		case "state_ids":
+			return 583
+		}
+	case "motion_working_group_speaker":
+		switch field {
+		case "A":
+			return 584
+		case "id":
+			return 585
+		case "meeting_id":
+			return 586
+		case "meeting_user_id":
+			return 587
+		case "motion_id":
+			return 588
+		case "weight":
+			return 589
+		}
+	case "option":
+		switch field {
+		case "A":
+			return 590
+		case "B":
+			return 591
+		case "abstain":
+			return 592
+		case "content_object_id":
+			return 593
+		case "id":
+			return 594
+		case "meeting_id":
+			return 595
+		case "no":
+			return 596
+		case "poll_id":
+			return 597
+		case "text":
+			return 598
+		case "used_as_global_option_in_poll_id":
+			return 599
+		case "vote_ids":
+			return 600
+		case "weight":
+			return 601
+		case "yes":
+			return 602
+		}
+	case "organization":
+		switch field {
+		case "A":
+			return 603
+		case "B":
+			return 604
+		case "C":
+			return 605
+		case "D":
+			return 606
+		case "E":
+			return 607
+		case "active_meeting_ids":
+			return 608
+		case "archived_meeting_ids":
+			return 609
+		case "committee_ids":
+			return 610
+		case "default_language":
+			return 611
+		case "description":
+			return 612
+		case "enable_anonymous":
+			return 613
+		case "enable_chat":
+			return 614
+		case "enable_electronic_voting":
+			return 615
+		case "gender_ids":
+			return 616
+		case "id":
+			return 617
+		case "legal_notice":
+			return 618
+		case "limit_of_meetings":
+			return 619
+		case "limit_of_users":
+			return 620
+		case "login_text":
+			return 621
+		case "mediafile_ids":
+			return 622
+		case "name":
+			return 623
+		case "organization_tag_ids":
+			return 624
+		case "privacy_policy":
+			return 625
+		case "published_mediafile_ids":
+			return 626
+		case "require_duplicate_from":
+			return 627
+		case "reset_password_verbose_errors":
+			return 628
+		case "saml_attr_mapping":
+			return 629
+		case "saml_enabled":
+			return 630
+		case "saml_login_button_text":
+			return 631
+		case "saml_metadata_idp":
+			return 632
+		case "saml_metadata_sp":
+			return 633
+		case "saml_private_key":
+			return 634
+		case "template_meeting_ids":
+			return 635
+		case "theme_id":
+			return 636
+		case "theme_ids":
+			return 637
+		case "url":
+			return 638
+		case "user_ids":
+			return 639
+		case "users_email_body":
+			return 640
+		case "users_email_replyto":
+			return 641
+		case "users_email_sender":
+			return 642
+		case "users_email_subject":
+			return 643
+		case "vote_decrypt_public_main_key":
+			return 644
+		}
+	case "organization_tag":
+		switch field {
+		case "A":
+			return 645
+		case "color":
+			return 646
+		case "id":
+			return 647
+		case "name":
+			return 648
+		case "organization_id":
+			return 649
+		case "tagged_ids":
+			return 650
+		}
+	case "personal_note":
+		switch field {
+		case "A":
+			return 651
+		case "content_object_id":
+			return 652
+		case "id":
+			return 653
+		case "meeting_id":
+			return 654
+		case "meeting_user_id":
+			return 655
+		case "note":
+			return 656
+		case "star":
+			return 657
+		}
+	case "point_of_order_category":
+		switch field {
+		case "A":
+			return 658
+		case "id":
+			return 659
+		case "meeting_id":
+			return 660
+		case "rank":
+			return 661
+		case "speaker_ids":
+			return 662
+		case "text":
+			return 663
+		}
+	case "poll":
+		switch field {
+		case "A":
+			return 664
+		case "B":
+			return 665
+		case "C":
+			return 666
+		case "D":
+			return 667
+		case "backend":
+			return 668
+		case "content_object_id":
+			return 669
+		case "crypt_key":
+			return 670
+		case "crypt_signature":
+			return 671
+		case "description":
+			return 672
+		case "entitled_group_ids":
+			return 673
+		case "entitled_users_at_stop":
+			return 674
+		case "global_abstain":
+			return 675
+		case "global_no":
+			return 676
+		case "global_option_id":
+			return 677
+		case "global_yes":
+			return 678
+		case "id":
+			return 679
+		case "is_pseudoanonymized":
+			return 680
+		case "max_votes_amount":
+			return 681
+		case "max_votes_per_option":
+			return 682
+		case "meeting_id":
+			return 683
+		case "min_votes_amount":
+			return 684
+		case "onehundred_percent_base":
+			return 685
+		case "option_ids":
+			return 686
+		case "pollmethod":
+			return 687
+		case "projection_ids":
+			return 688
+		case "sequential_number":
+			return 689
+		case "state":
+			return 690
+		case "title":
+			return 691
+		case "type":
+			return 692
+		case "vote_count":
+			return 693
+		case "voted_ids":
+			return 694
+		case "votes_raw":
+			return 695
+		case "votes_signature":
+			return 696
+		case "votescast":
+			return 697
+		case "votesinvalid":
+			return 698
+		case "votesvalid":
+			return 699
+		}
+	case "poll_candidate":
+		switch field {
+		case "A":
+			return 700
+		case "id":
+			return 701
+		case "meeting_id":
+			return 702
+		case "poll_candidate_list_id":
+			return 703
+		case "user_id":
+			return 704
+		case "weight":
+			return 705
+		}
+	case "poll_candidate_list":
+		switch field {
+		case "A":
+			return 706
+		case "id":
+			return 707
+		case "meeting_id":
+			return 708
+		case "option_id":
+			return 709
+		case "poll_candidate_ids":
+			return 710
+		}
+	case "projection":
+		switch field {
+		case "A":
+			return 711
+		case "content":
+			return 712
+		case "content_object_id":
+			return 713
+		case "current_projector_id":
+			return 714
+		case "history_projector_id":
+			return 715
+		case "id":
+			return 716
+		case "meeting_id":
+			return 717
+		case "options":
+			return 718
+		case "preview_projector_id":
+			return 719
+		case "stable":
+			return 720
+		case "type":
+			return 721
+		case "weight":
+			return 722
+		}
+	case "projector":
+		switch field {
+		case "A":
+			return 723
+		case "aspect_ratio_denominator":
+			return 724
+		case "aspect_ratio_numerator":
+			return 725
+		case "background_color":
+			return 726
+		case "chyron_background_color":
+			return 727
+		case "chyron_background_color_2":
+			return 728
+		case "chyron_font_color":
+			return 729
+		case "chyron_font_color_2":
+			return 730
+		case "color":
+			return 731
+		case "current_projection_ids":
+			return 732
+		case "header_background_color":
+			return 733
+		case "header_font_color":
+			return 734
+		case "header_h1_color":
+			return 735
+		case "history_projection_ids":
+			return 736
+		case "id":
+			return 737
+		case "is_internal":
+			return 738
+		case "meeting_id":
+			return 739
+		case "name":
+			return 740
+		case "preview_projection_ids":
+			return 741
+		case "scale":
+			return 742
+		case "scroll":
+			return 743
+		case "sequential_number":
+			return 744
+		case "show_clock":
+			return 745
+		case "show_header_footer":
+			return 746
+		case "show_logo":
+			return 747
+		case "show_title":
+			return 748
+		case "used_as_default_projector_for_agenda_item_list_in_meeting_id":
+			return 749
+		case "used_as_default_projector_for_amendment_in_meeting_id":
+			return 750
+		case "used_as_default_projector_for_assignment_in_meeting_id":
+			return 751
+		case "used_as_default_projector_for_assignment_poll_in_meeting_id":
+			return 752
+		case "used_as_default_projector_for_countdown_in_meeting_id":
+			return 753
+		case "used_as_default_projector_for_current_list_of_speakers_in_meeting_id":
+			return 754
+		case "used_as_default_projector_for_list_of_speakers_in_meeting_id":
+			return 755
+		case "used_as_default_projector_for_mediafile_in_meeting_id":
+			return 756
+		case "used_as_default_projector_for_message_in_meeting_id":
+			return 757
+		case "used_as_default_projector_for_motion_block_in_meeting_id":
+			return 758
+		case "used_as_default_projector_for_motion_in_meeting_id":
+			return 759
+		case "used_as_default_projector_for_motion_poll_in_meeting_id":
+			return 760
+		case "used_as_default_projector_for_poll_in_meeting_id":
+			return 761
+		case "used_as_default_projector_for_topic_in_meeting_id":
+			return 762
+		case "used_as_reference_projector_meeting_id":
+			return 763
+		case "width":
+			return 764
+		}
+	case "projector_countdown":
+		switch field {
+		case "A":
+			return 765
+		case "countdown_time":
+			return 766
+		case "default_time":
+			return 767
+		case "description":
+			return 768
+		case "id":
+			return 769
+		case "meeting_id":
+			return 770
+		case "projection_ids":
+			return 771
+		case "running":
+			return 772
+		case "title":
+			return 773
+		case "used_as_list_of_speakers_countdown_meeting_id":
+			return 774
+		case "used_as_poll_countdown_meeting_id":
+			return 775
+		}
+	case "projector_message":
+		switch field {
+		case "A":
+			return 776
+		case "id":
+			return 777
+		case "meeting_id":
+			return 778
+		case "message":
+			return 779
+		case "projection_ids":
+			return 780
+		}
+	case "speaker":
+		switch field {
+		case "A":
+			return 781
+		case "begin_time":
+			return 782
+		case "end_time":
+			return 783
+		case "id":
+			return 784
+		case "list_of_speakers_id":
+			return 785
+		case "meeting_id":
+			return 786
+		case "meeting_user_id":
+			return 787
+		case "note":
+			return 788
+		case "pause_time":
+			return 789
+		case "point_of_order":
+			return 790
+		case "point_of_order_category_id":
+			return 791
+		case "speech_state":
+			return 792
+		case "structure_level_list_of_speakers_id":
+			return 793
+		case "total_pause":
+			return 794
+		case "unpause_time":
+			return 795
+		case "weight":
+			return 796
+		}
+	case "structure_level":
+		switch field {
+		case "A":
+			return 797
+		case "color":
+			return 798
+		case "default_time":
+			return 799
+		case "id":
+			return 800
+		case "meeting_id":
+			return 801
+		case "meeting_user_ids":
+			return 802
+		case "name":
+			return 803
+		case "structure_level_list_of_speakers_ids":
+			return 804
+		}
+	case "structure_level_list_of_speakers":
+		switch field {
+		case "A":
+			return 805
+		case "additional_time":
+			return 806
+		case "current_start_time":
+			return 807
+		case "id":
+			return 808
+		case "initial_time":
+			return 809
+		case "list_of_speakers_id":
+			return 810
+		case "meeting_id":
+			return 811
+		case "remaining_time":
+			return 812
+		case "speaker_ids":
+			return 813
+		case "structure_level_id":
+			return 814
+		}
+	case "tag":
+		switch field {
+		case "A":
+			return 815
+		case "id":
+			return 816
+		case "meeting_id":
+			return 817
+		case "name":
+			return 818
+		case "tagged_ids":
+			return 819
+		}
+	case "theme":
+		switch field {
+		case "A":
+			return 820
+		case "abstain":
+			return 821
+		case "accent_100":
+			return 822
+		case "accent_200":
+			return 823
+		case "accent_300":
+			return 824
+		case "accent_400":
+			return 825
+		case "accent_50":
+			return 826
+		case "accent_500":
+			return 827
+		case "accent_600":
+			return 828
+		case "accent_700":
+			return 829
+		case "accent_800":
+			return 830
+		case "accent_900":
+			return 831
+		case "accent_a100":
+			return 832
+		case "accent_a200":
+			return 833
+		case "accent_a400":
+			return 834
+		case "accent_a700":
+			return 835
+		case "headbar":
+			return 836
+		case "id":
+			return 837
+		case "name":
+			return 838
+		case "no":
+			return 839
+		case "organization_id":
+			return 840
+		case "primary_100":
+			return 841
+		case "primary_200":
+			return 842
+		case "primary_300":
+			return 843
+		case "primary_400":
+			return 844
+		case "primary_50":
+			return 845
+		case "primary_500":
+			return 846
+		case "primary_600":
+			return 847
+		case "primary_700":
+			return 848
+		case "primary_800":
+			return 849
+		case "primary_900":
+			return 850
+		case "primary_a100":
+			return 851
+		case "primary_a200":
+			return 852
+		case "primary_a400":
+			return 853
+		case "primary_a700":
+			return 854
+		case "theme_for_organization_id":
+			return 855
+		case "warn_100":
+			return 856
+		case "warn_200":
+			return 857
+		case "warn_300":
+			return 858
+		case "warn_400":
+			return 859
+		case "warn_50":
+			return 860
+		case "warn_500":
+			return 861
+		case "warn_600":
+			return 862
+		case "warn_700":
+			return 863
+		case "warn_800":
+			return 864
+		case "warn_900":
+			return 865
+		case "warn_a100":
+			return 866
+		case "warn_a200":
+			return 867
+		case "warn_a400":
+			return 868
+		case "warn_a700":
+			return 869
+		case "yes":
+			return 870
+		}
+	case "topic":
+		switch field {
+		case "A":
+			return 871
+		case "agenda_item_id":
+			return 872
+		case "attachment_meeting_mediafile_ids":
+			return 873
+		case "id":
+			return 874
+		case "list_of_speakers_id":
+			return 875
+		case "meeting_id":
+			return 876
+		case "poll_ids":
+			return 877
+		case "projection_ids":
+			return 878
+		case "sequential_number":
+			return 879
+		case "text":
+			return 880
+		case "title":
+			return 881
+		}
+	case "user":
+		switch field {
+		case "A":
+			return 882
+		case "B":
+			return 883
+		case "D":
+			return 884
+		case "E":
+			return 885
+		case "F":
+			return 886
+		case "G":
+			return 887
+		case "H":
+			return 888
+		case "can_change_own_password":
+			return 889
+		case "committee_ids":
+			return 890
+		case "committee_management_ids":
+			return 891
+		case "default_password":
+			return 892
+		case "default_vote_weight":
+			return 893
+		case "delegated_vote_ids":
+			return 894
+		case "email":
+			return 895
+		case "first_name":
+			return 896
+		case "forwarding_committee_ids":
+			return 897
+		case "gender_id":
+			return 898
+		case "id":
+			return 899
+		case "is_active":
+			return 900
+		case "is_demo_user":
+			return 901
+		case "is_physical_person":
+			return 902
+		case "is_present_in_meeting_ids":
+			return 903
+		case "last_email_sent":
+			return 904
+		case "last_login":
+			return 905
+		case "last_name":
+			return 906
+		case "meeting_ids":
+			return 907
+		case "meeting_user_ids":
+			return 908
+		case "member_number":
+			return 909
+		case "option_ids":
+			return 910
+		case "organization_id":
+			return 911
+		case "organization_management_level":
+			return 912
+		case "password":
+			return 913
+		case "poll_candidate_ids":
+			return 914
+		case "poll_voted_ids":
+			return 915
+		case "pronoun":
+			return 916
+		case "saml_id":
+			return 917
+		case "title":
+			return 918
+		case "username":
+			return 919
+		case "vote_ids":
+			return 920
+		}
+	case "vote":
+		switch field {
+		case "A":
+			return 921
+		case "B":
+			return 922
+		case "delegated_user_id":
+			return 923
+		case "id":
+			return 924
+		case "meeting_id":
+			return 925
+		case "option_id":
+			return 926
+		case "user_id":
+			return 927
+		case "user_token":
+			return 928
+		case "value":
+			return 929
+		case "weight":
+			return 930
+		}
 	}
+	return -1
 }
@@ -89,25 +89,24 @@ var collectionFields = [...]collectionField{
 	{{- end}}
 }
 
-func collectionFieldToID(cf string) int{
-	switch cf{
-	{{- range $idx, $cf := .CollectionFields}}
-	case "{{$cf.Collection}}/{{$cf.Field}}":
-		return {{add1 $idx}}
+func collectionFieldToID(collection, field string) int {
+	switch collection {
+	{{- range $c := .Collections}}
+	case "{{$c.Name}}":
+		switch field {
+		{{- range $f := $c.Fields}}
+		case "{{$f.Name}}":
+			return {{$f.ID}}
+		{{- end}}
+		}
 	{{- end}}
-	default: 
-		return -1
 	}
+	return -1
 }
 `
 
 func writeFile(w io.Writer, collectionFields []collectionField) error {
-	t := template.New("t").Funcs(template.FuncMap{
-		"add1": func(num int) int {
-			return num + 1
-		},
-	})
-	t, err := t.Parse(tpl)
+	t, err := template.New("t").Parse(tpl)
 	if err != nil {
 		return fmt.Errorf("parsing template: %w", err)
 	}
@@ -116,8 +115,10 @@ func writeFile(w io.Writer, collectionFields []collectionField) error {
 
 	templateData := struct {
 		CollectionFields []collectionField
+		Collections      []collectionIDs
 	}{
 		CollectionFields: collectionFields,
+		Collections:      groupByCollection(collectionFields),
 	}
 
 	if err := t.Execute(buf, templateData); err != nil {
@@ -134,3 +135,29 @@ func writeFile(w io.Writer, collectionFields []collectionField) error {
 	}
 	return nil
 }
+
+type fieldID struct {
+	Name string
+	ID   int
+}
+
+type collectionIDs struct {
+	Name   string
+	Fields []fieldID
+}
+
+// groupByCollection groups the sorted collection fields by there collection.
+// The id of a field is its index in the generated array, that starts with the
+// invalid key.
+func groupByCollection(collectionFields []collectionField) []collectionIDs {
+	var result []collectionIDs
+	for i, cf := range collectionFields {
+		if len(result) == 0 || result[len(result)-1].Name != cf.Collection {
+			result = append(result, collectionIDs{Name: cf.Collection})
+		}
+
+		last := &result[len(result)-1]
+		last.Fields = append(last.Fields, fieldID{Name: cf.Field, ID: i + 1})
+	}
+	return result
+}
@@ -11,21 +11,25 @@ type Key uint64
 
 // FromString parses a string to a Key.
 //
-// This uses a regular expression to validate the key. This can be slow if
-// called many times. It is faster to manually validate the key.
+// If there are no arguments, the format is the key and is parsed without
+// allocations.
 func FromString(format string, a ...any) (Key, error) {
-	keyStr := fmt.Sprintf(format, a...)
+	keyStr := format
+	if len(a) > 0 {
+		keyStr = fmt.Sprintf(format, a...)
+	}
+
 	idx1 := strings.IndexByte(keyStr, '/')
 	idx2 := strings.LastIndexByte(keyStr, '/')
 	if idx1 == -1 || idx1 == idx2 {
-		return 0, InvalidKeyError{keyStr}
+		return 0, InvalidKeyError{strings.Clone(keyStr)}
 	}
 
 	id, _ := strconv.Atoi(keyStr[idx1+1 : idx2])
 
-	cfID := collectionFieldToID(keyStr[:idx1] + "/" + keyStr[idx2+1:])
+	cfID := collectionFieldToID(keyStr[:idx1], keyStr[idx2+1:])
 	if cfID == -1 {
-		return 0, InvalidKeyError{keyStr}
+		return 0, InvalidKeyError{strings.Clone(keyStr)}
 	}
 	return Key(joinInt(cfID, id)), nil
 }
@@ -33,7 +37,7 @@ func FromString(format string, a ...any) (Key, error) {
 // FromParts create a key from collection, id an field.
 func FromParts(collection string, id int, field string) (Key, error) {
 	// TODO: Use a separate function with different namespace for mode-keys
-	cfID := collectionFieldToID(collection + "/" + field)
+	cfID := collectionFieldToID(collection, field)
 	if cfID == -1 {
 		return 0, InvalidKeyError{fmt.Sprintf("%s/%d/%s", collection, id, field)}
 	}
@@ -57,7 +61,19 @@ func MustKey(format string, a ...any) Key {
 }
 
 func (k Key) String() string {
-	return fmt.Sprintf("%s/%d/%s", k.Collection(), k.ID(), k.Field())
+	var buf [64]byte
+	return string(k.AppendString(buf[:0]))
+}
+
+// AppendString appends the string form of the key to buf and returns the
+// extended buffer. Unlike String, it does not allocate, if buf is big enough.
+func (k Key) AppendString(buf []byte) []byte {
+	cfIdx, id := splitUInt64(uint64(k))
+	buf = append(buf, collectionFields[cfIdx].collection...)
+	buf = append(buf, '/')
+	buf = strconv.AppendInt(buf, int64(id), 10)
+	buf = append(buf, '/')
+	return append(buf, collectionFields[cfIdx].field...)
 }
 
 // ID returns the id attribute from the Key.
@@ -81,7 +97,11 @@ func (k Key) Field() string {
 // FQID returns the FQID part of the field
 func (k Key) FQID() string {
 	cfIdx, id := splitUInt64(uint64(k))
-	return fmt.Sprintf("%s/%d", collectionFields[cfIdx].collection, id)
+
+	var buf [64]byte
+	b := append(buf[:0], collectionFields[cfIdx].collection...)
+	b = append(b, '/')
+	return string(strconv.AppendInt(b, int64(id), 10))
 }
 
 // CollectionField returns the first and last part of the key.
@@ -90,16 +110,26 @@ func (k Key) CollectionField() string {
 	return collectionFields[cfIdx].collection + "/" + collectionFields[cfIdx].field
 }
 
+// CollectionFieldID returns the number of the first and last part of the key.
+// See the function CollectionFieldID.
+func (k Key) CollectionFieldID() int {
+	cfIdx, _ := splitUInt64(uint64(k))
+	return cfIdx
+}
+
 // IDField retuns the the /id field for the key.
 func (k Key) IDField() Key {
-	idCfID := collectionFieldToID(k.Collection() + "/id")
+	idCfID := collectionFieldToID(k.Collection(), "id")
 
 	return Key(joinInt(idCfID, k.ID()))
 }
 
 // MarshalJSON converts the key to a json string.
 func (k Key) MarshalJSON() ([]byte, error) {
-	return []byte(`"` + k.String() + `"`), nil
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '"')
+	buf = k.AppendString(buf)
+	return append(buf, '"'), nil
 }
 
 // InvalidKeyError is returned from dskey.FromKey or dskey.FromParts, if the key
@@ -146,6 +146,26 @@ func TestFQID(t *testing.T) {
 	}
 }
 
+func TestAppendString(t *testing.T) {
+	for _, tt := range []string{
+		"user/1/username",
+		"user/12/username",
+		"motion/123456/title",
+	} {
+		t.Run(tt, func(t *testing.T) {
+			key, err := dskey.FromString(tt)
+			if err != nil {
+				t.Fatalf("Key is not valid: %v", err)
+			}
+
+			got := key.AppendString([]byte("prefix:"))
+			if string(got) != "prefix:"+tt {
+				t.Errorf("got %s, expected prefix:%s", got, tt)
+			}
+		})
+	}
+}
+
 func TestCollectionField(t *testing.T) {
 	for _, tt := range []struct {
 		key    string
@@ -189,3 +209,47 @@ func TestIDField(t *testing.T) {
 		})
 	}
 }
+
+func TestAllocations(t *testing.T) {
+	key := dskey.MustKey("motion_comment_section/123/read_group_ids")
+	buf := make([]byte, 0, 64)
+
+	var keySink dskey.Key
+	var stringSink string
+	var bytesSink []byte
+	for _, tt := range []struct {
+		name   string
+		f      func()
+		expect float64
+	}{
+		{"FromString", func() { keySink, _ = dskey.FromString("motion_comment_section/123/read_group_ids") }, 0},
+		{"FromParts", func() { keySink, _ = dskey.FromParts("motion_comment_section", 123, "read_group_ids") }, 0},
+		{"IDField", func() { keySink = key.IDField() }, 0},
+		{"AppendString", func() { bytesSink = key.AppendString(buf[:0]) }, 0},
+		{"String", func() { stringSink = key.String() }, 1},
+		{"FQID", func() { stringSink = key.FQID() }, 1},
+		{"MarshalJSON", func() { bytesSink, _ = key.MarshalJSON() }, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testing.AllocsPerRun(100, tt.f); got != tt.expect {
+				t.Errorf("got %v allocations, expected %v", got, tt.expect)
+			}
+		})
+	}
+	_, _, _ = keySink, stringSink, bytesSink
+}
+
+func BenchmarkFromString(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := dskey.FromString("motion_comment_section/123/read_group_ids"); err != nil {
+			b.Fatalf("FromString: %v", err)
+		}
+	}
+}
+
+func BenchmarkKeyString(b *testing.B) {
+	key := dskey.MustKey("motion_comment_section/123/read_group_ids")
+	for i := 0; i < b.N; i++ {
+		_ = key.String()
+	}
+}
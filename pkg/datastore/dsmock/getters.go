@@ -22,12 +22,19 @@ func (s Stub) Get(_ context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, e
 	return requested, nil
 }
 
+// flowUpdate is a message from Send or Play to Update.
+type flowUpdate struct {
+	data map[dskey.Key][]byte
+	err  error
+}
+
 // Flow is is a mock flow.
 type Flow struct {
 	mu sync.RWMutex
 
-	stub Stub
-	ch   chan map[dskey.Key][]byte
+	stub     Stub
+	ch       chan flowUpdate
+	position int
 
 	getter flow.Getter
 
@@ -46,7 +53,7 @@ func NewFlow(data map[dskey.Key][]byte, middlewares ...func(flow.Getter) flow.Ge
 
 	return &Flow{
 		stub:        stub,
-		ch:          make(chan map[dskey.Key][]byte),
+		ch:          make(chan flowUpdate),
 		getter:      getter,
 		middlewares: initialized,
 	}
@@ -67,8 +74,8 @@ func (s *Flow) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, e
 	}
 	for {
 		select {
-		case newValues := <-s.ch:
-			updateFn(newValues, nil)
+		case update := <-s.ch:
+			updateFn(update.data, update.err)
 			continue
 
 		case <-ctx.Done():
@@ -83,8 +90,18 @@ func (s *Flow) Send(values map[dskey.Key][]byte) {
 	for k, v := range values {
 		s.stub[k] = v
 	}
+	s.position++
 	s.mu.Unlock()
-	s.ch <- values
+	s.ch <- flowUpdate{data: values}
+}
+
+// Position returns the position of the last write. It is 0 before the first
+// call to Send or Play.
+func (s *Flow) Position() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.position
 }
 
 // Middlewares returns a list of Getters that where used in
@@ -0,0 +1,100 @@
+package dsmock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+)
+
+// Write is one write of a timeline, that can be played with Flow.Play.
+type Write struct {
+	// Position is the position of the write. It has to be higher then the
+	// position of the write before. If it is 0, the next position is used.
+	Position int
+
+	// After is the time after the start of Play, when the write happens.
+	After time.Duration
+
+	// Data are the changed keys. A nil value deletes a key.
+	Data map[dskey.Key][]byte
+
+	// Lost simulates a lost message of the message bus. The data is written,
+	// but Update is called with an error, that wraps flow.ErrResync, instead
+	// of the changed keys.
+	Lost bool
+}
+
+// Play writes the timeline to the flow. Each write happens at its time and is
+// given to the callback of Update.
+//
+// Play blocks until all writes are done or the context is canceled. Like Send,
+// each write waits until it is received by Update.
+//
+// For example, to change a key at position 2 after two seconds and to lose
+// the message of position 3:
+//
+//	go ds.Play(ctx,
+//		dsmock.Write{Position: 2, After: 2 * time.Second, Data: dsmock.YAMLData("motion/1/title: new")},
+//		dsmock.Write{Position: 3, After: 3 * time.Second, Data: dsmock.YAMLData("motion/1/title: lost"), Lost: true},
+//	)
+func (s *Flow) Play(ctx context.Context, writes ...Write) error {
+	writes = append([]Write(nil), writes...)
+	sort.SliceStable(writes, func(i, j int) bool {
+		return writes[i].After < writes[j].After
+	})
+
+	position := s.Position()
+	for i, w := range writes {
+		if w.Position == 0 {
+			w.Position = position + 1
+		}
+
+		if w.Position <= position {
+			return fmt.Errorf("write %d has position %d, expected a position after %d", i, w.Position, position)
+		}
+		position = w.Position
+		writes[i] = w
+	}
+
+	start := time.Now()
+	for _, w := range writes {
+		timer := time.NewTimer(time.Until(start.Add(w.After)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		if err := s.write(ctx, w); err != nil {
+			return fmt.Errorf("position %d: %w", w.Position, err)
+		}
+	}
+	return nil
+}
+
+// write sets the data of one write and sends it to Update.
+func (s *Flow) write(ctx context.Context, w Write) error {
+	s.mu.Lock()
+	for k, v := range w.Data {
+		s.stub[k] = v
+	}
+	s.position = w.Position
+	s.mu.Unlock()
+
+	update := flowUpdate{data: w.Data}
+	if w.Lost {
+		update = flowUpdate{err: fmt.Errorf("message of position %d was lost: %w", w.Position, flow.ErrResync)}
+	}
+
+	select {
+	case s.ch <- update:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
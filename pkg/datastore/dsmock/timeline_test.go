@@ -0,0 +1,71 @@
+package dsmock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dsmock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+)
+
+func TestPlay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	key := dskey.MustKey("motion/1/title")
+	ds := dsmock.NewFlow(dsmock.YAMLData("motion/1/title: first"))
+
+	type update struct {
+		data map[dskey.Key][]byte
+		err  error
+		at   time.Duration
+	}
+	updates := make(chan update, 3)
+	start := time.Now()
+	go ds.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		updates <- update{data: data, err: err, at: time.Since(start)}
+	})
+
+	err := ds.Play(ctx,
+		dsmock.Write{Position: 5, After: 20 * time.Millisecond, Data: dsmock.YAMLData("motion/1/title: lost"), Lost: true},
+		dsmock.Write{Position: 2, After: 10 * time.Millisecond, Data: dsmock.YAMLData("motion/1/title: second")},
+		dsmock.Write{After: 30 * time.Millisecond, Data: map[dskey.Key][]byte{key: nil}},
+	)
+	if err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+
+	got := <-updates
+	if got.err != nil || string(got.data[key]) != `"second"` || got.at < 10*time.Millisecond {
+		t.Errorf("first update is %v, %v after %s, expected the second title after 10ms", got.data, got.err, got.at)
+	}
+
+	got = <-updates
+	if !errors.Is(got.err, flow.ErrResync) || got.data != nil {
+		t.Errorf("second update is %v, %v, expected only a resync error", got.data, got.err)
+	}
+
+	got = <-updates
+	if value, ok := got.data[key]; got.err != nil || !ok || value != nil {
+		t.Errorf("third update is %v, %v, expected the deleted title", got.data, got.err)
+	}
+
+	if pos := ds.Position(); pos != 6 {
+		t.Errorf("Position() == %d, expected 6", pos)
+	}
+}
+
+func TestPlayInvalidPosition(t *testing.T) {
+	ds := dsmock.NewFlow(nil)
+
+	err := ds.Play(context.Background(),
+		dsmock.Write{Position: 3},
+		dsmock.Write{Position: 3, After: time.Millisecond},
+	)
+	if err == nil {
+		t.Errorf("Play with the same position twice did not return an error")
+	}
+}
@@ -2,6 +2,7 @@ package flow
 
 import (
 	"context"
+	"errors"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 )
@@ -11,6 +12,11 @@ type Getter interface {
 	Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error)
 }
 
+// ErrResync is given to the callback of an Updater, when updates were lost.
+// All values, that were fetched before, can be outdated and have to be fetched
+// again.
+var ErrResync = errors.New("updates were lost")
+
 // Updater is a blocking function. It expects a callback. The callback is
 // called, when there is new data.
 type Updater interface {
@@ -0,0 +1,340 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+// maxExampleVersions is the amount of file versions, that are kept for the
+// history.
+const maxExampleVersions = 100
+
+// FlowExample serves the data from an OpenSlides example-data.json file.
+//
+// It is meant for local development without postgres and redis. The file is
+// checked for changes in an interval. Each change creates a new position, so
+// the history routes can be tested with edits of the file.
+type FlowExample struct {
+	path     string
+	interval time.Duration
+
+	mu       sync.RWMutex
+	versions []exampleVersion
+}
+
+// exampleVersion is the content of the file at one position.
+type exampleVersion struct {
+	position int
+	time     time.Time
+	objects  map[string]map[string]json.RawMessage
+	meetings map[int]bool
+}
+
+// NewFlowExample reads the example data from the file.
+func NewFlowExample(path string, interval time.Duration) (*FlowExample, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading example data: %w", err)
+	}
+
+	objects, err := ParseExampleData(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return &FlowExample{
+		path:     path,
+		interval: interval,
+		versions: []exampleVersion{{position: 1, time: clock.Now(), objects: objects}},
+	}, nil
+}
+
+// ParseExampleData parses the format of example-data.json. It returns the
+// objects by fqid. Top level keys that start with an underscore, like
+// `_migration_index`, are ignored.
+func ParseExampleData(content []byte) (map[string]map[string]json.RawMessage, error) {
+	var collections map[string]json.RawMessage
+	if err := json.Unmarshal(content, &collections); err != nil {
+		return nil, fmt.Errorf("decoding json: %w", err)
+	}
+
+	objects := make(map[string]map[string]json.RawMessage)
+	for collection, value := range collections {
+		if strings.HasPrefix(collection, "_") {
+			continue
+		}
+
+		var byID map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(value, &byID); err != nil {
+			return nil, fmt.Errorf("decoding collection %s: %w", collection, err)
+		}
+
+		for rawID, fields := range byID {
+			if id, err := strconv.Atoi(rawID); err != nil || id < 1 {
+				return nil, fmt.Errorf("collection %s: invalid id %s", collection, rawID)
+			}
+
+			if fields == nil {
+				continue
+			}
+			objects[collection+"/"+rawID] = fields
+		}
+	}
+	return objects, nil
+}
+
+// current returns the newest version.
+func (f *FlowExample) current() exampleVersion {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.versions[len(f.versions)-1]
+}
+
+// Get returns the values of the keys from the newest version of the file.
+func (f *FlowExample) Get(_ context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	return exampleValues(f.current().objects, keys), nil
+}
+
+func exampleValues(objects map[string]map[string]json.RawMessage, keys []dskey.Key) map[dskey.Key][]byte {
+	values := make(map[dskey.Key][]byte, len(keys))
+	for _, key := range keys {
+		value := objects[key.FQID()][key.Field()]
+		if bytes.Equal(value, []byte("null")) {
+			value = nil
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// Update checks the file for changes in the interval. It calls updateFn with
+// the changed keys.
+func (f *FlowExample) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
+	tick := clock.NewTicker(f.interval)
+	defer tick.Stop()
+
+	var lastErr string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C():
+		}
+
+		changed, err := f.reload()
+		if err != nil {
+			// A half saved file is reported once.
+			if err.Error() != lastErr {
+				updateFn(nil, err)
+			}
+			lastErr = err.Error()
+			continue
+		}
+		lastErr = ""
+
+		if len(changed) > 0 {
+			updateFn(changed, nil)
+		}
+	}
+}
+
+// reload reads the file and creates a new position, if it changed.
+func (f *FlowExample) reload() (map[dskey.Key][]byte, error) {
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("reading example data: %w", err)
+	}
+
+	objects, err := ParseExampleData(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", f.path, err)
+	}
+
+	last := f.current()
+	changed, meetings := diffExampleData(last.objects, objects)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.versions = append(f.versions, exampleVersion{
+		position: last.position + 1,
+		time:     clock.Now(),
+		objects:  objects,
+		meetings: meetings,
+	})
+	if len(f.versions) > maxExampleVersions {
+		f.versions = f.versions[len(f.versions)-maxExampleVersions:]
+	}
+
+	return changed, nil
+}
+
+// diffExampleData returns the changed keys and the ids of the meetings, that
+// contain a changed object. Fields, that are not in the models, are ignored.
+func diffExampleData(old, new map[string]map[string]json.RawMessage) (map[dskey.Key][]byte, map[int]bool) {
+	changed := make(map[dskey.Key][]byte)
+	meetings := make(map[int]bool)
+
+	compare := func(fqid string, field string) {
+		oldValue, oldOK := old[fqid][field]
+		newValue, newOK := new[fqid][field]
+		if oldOK == newOK && bytes.Equal(oldValue, newValue) {
+			return
+		}
+
+		collection, rawID, _ := strings.Cut(fqid, "/")
+		id, _ := strconv.Atoi(rawID)
+		key, err := dskey.FromParts(collection, id, field)
+		if err != nil {
+			return
+		}
+
+		if bytes.Equal(newValue, []byte("null")) {
+			newValue = nil
+		}
+		changed[key] = newValue
+
+		if collection == "meeting" {
+			meetings[id] = true
+		}
+		for _, object := range []map[string]json.RawMessage{old[fqid], new[fqid]} {
+			if meetingID, err := strconv.Atoi(string(object["meeting_id"])); err == nil {
+				meetings[meetingID] = true
+			}
+		}
+	}
+
+	for fqid, fields := range new {
+		for field := range fields {
+			compare(fqid, field)
+		}
+	}
+
+	for fqid, fields := range old {
+		for field := range fields {
+			if _, ok := new[fqid][field]; !ok {
+				compare(fqid, field)
+			}
+		}
+	}
+
+	return changed, meetings
+}
+
+// Ping checks, that the file is readable.
+func (f *FlowExample) Ping(_ context.Context) error {
+	if _, err := os.Stat(f.path); err != nil {
+		return fmt.Errorf("example data: %w", err)
+	}
+	return nil
+}
+
+// version returns the version of a position.
+func (f *FlowExample) version(position int) (exampleVersion, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, v := range f.versions {
+		if v.position == position {
+			return v, nil
+		}
+	}
+	return exampleVersion{}, fmt.Errorf("position %d does not exist", position)
+}
+
+// PositionTime returns the time of a position.
+func (f *FlowExample) PositionTime(_ context.Context, position int) (time.Time, error) {
+	v, err := f.version(position)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return v.time, nil
+}
+
+// PositionAt returns the last position at or before the timestamp.
+//
+// If meetingID is not 0, only positions that changed the meeting or one of its
+// objects are used. The first position counts for all meetings.
+//
+// Returns 0, if there is no such position.
+func (f *FlowExample) PositionAt(_ context.Context, timestamp time.Time, meetingID int) (int, time.Time, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for i := len(f.versions) - 1; i >= 0; i-- {
+		v := f.versions[i]
+		if v.time.After(timestamp) {
+			continue
+		}
+
+		if meetingID != 0 && v.meetings != nil && !v.meetings[meetingID] {
+			continue
+		}
+		return v.position, v.time, nil
+	}
+	return 0, time.Time{}, nil
+}
+
+// AtPosition returns a getter for the data at the given position.
+func (f *FlowExample) AtPosition(position int) *ExampleGetter {
+	return &ExampleGetter{flow: f, position: position}
+}
+
+// ExampleGetter reads the data as it was at a position. Has to be created
+// with FlowExample.AtPosition().
+type ExampleGetter struct {
+	flow     *FlowExample
+	position int
+}
+
+// Get returns the values of the keys at the position of the getter.
+func (g *ExampleGetter) Get(_ context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	v, err := g.flow.version(g.position)
+	if err != nil {
+		return nil, err
+	}
+	return exampleValues(v.objects, keys), nil
+}
+
+// Object returns all fields of an object at the position of the getter.
+//
+// Returns nil, if the object did not exist at that position.
+func (g *ExampleGetter) Object(_ context.Context, fqid string) (map[string]json.RawMessage, error) {
+	v, err := g.flow.version(g.position)
+	if err != nil {
+		return nil, err
+	}
+	return v.objects[fqid], nil
+}
+
+// MeetingObjects returns all objects of a meeting, including the meeting
+// itself, at the position of the getter.
+func (g *ExampleGetter) MeetingObjects(_ context.Context, meetingID int) (map[string]map[string]json.RawMessage, error) {
+	v, err := g.flow.version(g.position)
+	if err != nil {
+		return nil, err
+	}
+
+	meetingFQID := fmt.Sprintf("meeting/%d", meetingID)
+	objects := make(map[string]map[string]json.RawMessage)
+	for fqid, object := range v.objects {
+		if fqid == meetingFQID || string(object["meeting_id"]) == strconv.Itoa(meetingID) {
+			objects[fqid] = object
+		}
+	}
+	return objects, nil
+}
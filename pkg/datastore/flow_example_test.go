@@ -0,0 +1,106 @@
+package datastore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+)
+
+func TestFlowExample(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := clock.NewFake(time.Now())
+	defer clock.Use(fake)()
+
+	path := filepath.Join(t.TempDir(), "example-data.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("writing example data: %v", err)
+		}
+	}
+
+	write(`{
+		"_migration_index": 50,
+		"meeting": {"1": {"id": 1, "name": "first"}},
+		"motion": {"5": {"id": 5, "title": "foo", "meeting_id": 1}},
+		"topic": {"7": {"id": 7, "title": "other", "meeting_id": 2}}
+	}`)
+
+	ds, err := datastore.NewFlowExample(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewFlowExample: %v", err)
+	}
+
+	title := dskey.MustKey("motion/5/title")
+	got, err := ds.Get(ctx, title, dskey.MustKey("motion/6/title"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if string(got[title]) != `"foo"` || got[dskey.MustKey("motion/6/title")] != nil {
+		t.Errorf("Get returned %v", got)
+	}
+
+	updates := make(chan map[dskey.Key][]byte, 1)
+	go ds.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		if err != nil {
+			t.Errorf("Update: %v", err)
+			return
+		}
+		updates <- data
+	})
+
+	if err := fake.BlockUntil(ctx, 1); err != nil {
+		t.Fatalf("waiting for the ticker: %v", err)
+	}
+
+	before := clock.Now()
+	write(`{
+		"meeting": {"1": {"id": 1, "name": "first"}},
+		"motion": {"5": {"id": 5, "title": "bar", "meeting_id": 1}}
+	}`)
+	fake.Advance(10 * time.Millisecond)
+
+	var data map[dskey.Key][]byte
+	select {
+	case data = <-updates:
+	case <-time.After(time.Second):
+		t.Fatalf("no update after the file changed")
+	}
+
+	if len(data) != 4 || string(data[title]) != `"bar"` || data[dskey.MustKey("topic/7/title")] != nil {
+		t.Errorf("got update %v, expected the new title and the deleted topic", data)
+	}
+
+	position, _, err := ds.PositionAt(ctx, clock.Now(), 0)
+	if err != nil || position != 2 {
+		t.Errorf("PositionAt returned %d, %v, expected position 2", position, err)
+	}
+
+	position, _, err = ds.PositionAt(ctx, before, 1)
+	if err != nil || position != 1 {
+		t.Errorf("PositionAt before the change returned %d, %v, expected position 1", position, err)
+	}
+
+	objects, err := ds.AtPosition(1).MeetingObjects(ctx, 1)
+	if err != nil {
+		t.Fatalf("MeetingObjects: %v", err)
+	}
+
+	if len(objects) != 2 || string(objects["motion/5"]["title"]) != `"foo"` {
+		t.Errorf("MeetingObjects at position 1 returned %v", objects)
+	}
+}
+
+func TestParseExampleDataInvalidID(t *testing.T) {
+	if _, err := datastore.ParseExampleData([]byte(`{"motion": {"first": {"id": 1}}}`)); err == nil {
+		t.Errorf("ParseExampleData with an invalid id did not return an error")
+	}
+}
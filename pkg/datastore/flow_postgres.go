@@ -4,20 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/logging"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracing"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const maxFieldsOnQuery = 1_500
 
+var logger = logging.For(logging.Datastore)
+
 var (
 	envPostgresHost         = environment.NewVariable("DATABASE_HOST", "localhost", "Postgres Host.")
 	envPostgresPort         = environment.NewVariable("DATABASE_PORT", "5432", "Postgres Post.")
@@ -77,7 +81,14 @@ func NewFlowPostgres(lookup environment.Environmenter, updater flow.Updater) (*F
 }
 
 // Get fetches the keys from postgres.
-func (p *FlowPostgres) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+func (p *FlowPostgres) Get(ctx context.Context, keys ...dskey.Key) (_ map[dskey.Key][]byte, err error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "datastore.Get", attribute.Int("keys", len(keys)))
+	defer func() {
+		tracing.End(span, err)
+		logger.Debug("Get", "keys", len(keys), "duration", time.Since(start), "error", err)
+	}()
+
 	uniqueFieldsStr, fieldIndex, uniqueFQID := prepareQuery(keys)
 
 	// For very big SQL Queries, split them in part
@@ -145,43 +156,87 @@ func (p *FlowPostgres) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Ke
 	return values, nil
 }
 
-// HistoryInformation fetches the history information for one fqid.
-func (p *FlowPostgres) HistoryInformation(ctx context.Context, fqid string, w io.Writer) error {
-	sql := `select distinct on (position) position, timestamp, user_id, information from positions natural join events
-	where fqid = $1 and information::text<>'null'::text order by position asc`
+// Ping checks, that postgres is reachable.
+func (p *FlowPostgres) Ping(ctx context.Context) error {
+	if err := p.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("postgres ping: %w", err)
+	}
+	return nil
+}
+
+// HistoryEntry is the information of one position for one object.
+type HistoryEntry struct {
+	Position    int             `json:"position"`
+	Timestamp   int             `json:"timestamp"`
+	UserID      int             `json:"user_id"`
+	Information json.RawMessage `json:"information"`
+}
 
-	rows, err := p.pool.Query(ctx, sql, fqid)
+// HistoryInformation returns the history information of the positions that
+// match the query. The entries are grouped by fqid and ordered by position.
+//
+// If the query has a limit and there could be more entries, the second return
+// value is the cursor for the next page. Otherwise it is 0.
+func (p *FlowPostgres) HistoryInformation(ctx context.Context, query HistoryQuery) (map[string][]HistoryEntry, int, error) {
+	where, args, err := query.where()
 	if err != nil {
-		return fmt.Errorf("sending query: %w", err)
+		return nil, 0, fmt.Errorf("invalid query: %w", err)
 	}
-	defer rows.Close()
+	where += ` and information::text<>'null'::text`
 
-	type historyInformation struct {
-		Position    int             `json:"position"`
-		Timestamp   int             `json:"timestamp"`
-		UserID      int             `json:"user_id"`
-		Information json.RawMessage `json:"information"`
+	if query.Limit > 0 {
+		where += fmt.Sprintf(` and position in (
+			select distinct position from positions natural join events
+			where %s order by position asc limit %d
+		)`, where, query.Limit)
 	}
 
-	output := make(map[string][]historyInformation, 1)
+	sql := `select distinct on (position, fqid) position, timestamp, user_id, information, fqid
+	from positions natural join events
+	where ` + where + `
+	order by position asc, fqid asc`
 
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("sending query: %w", err)
+	}
+	defer rows.Close()
+
+	output := make(map[string][]HistoryEntry, 1)
+	var positions int
+	var lastPosition int
 	for rows.Next() {
-		var hi historyInformation
+		var entry HistoryEntry
 		var timestamp time.Time
+		var userID *int
+		var fqid string
+
+		if err := rows.Scan(&entry.Position, &timestamp, &userID, &entry.Information, &fqid); err != nil {
+			return nil, 0, fmt.Errorf("scan: %w", err)
+		}
 
-		if err = rows.Scan(&hi.Position, &timestamp, &hi.UserID, &hi.Information); err != nil {
-			return fmt.Errorf("scan: %w", err)
+		entry.Timestamp = int(timestamp.Unix())
+		if userID != nil {
+			entry.UserID = *userID
 		}
+		output[fqid] = append(output[fqid], entry)
 
-		hi.Timestamp = int(timestamp.Unix())
-		output[fqid] = append(output[fqid], hi)
+		if entry.Position != lastPosition {
+			positions++
+			lastPosition = entry.Position
+		}
 	}
 
-	if err := json.NewEncoder(w).Encode(output); err != nil {
-		return fmt.Errorf("encode: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading postgres result: %w", err)
 	}
 
-	return nil
+	var next int
+	if query.Limit > 0 && positions == query.Limit {
+		next = lastPosition
+	}
+
+	return output, next, nil
 }
 
 // Update calls the updater.
@@ -257,6 +257,21 @@ func (tp *testPostgres) addSchema(ctx context.Context) error {
 		fqid VARCHAR(48) PRIMARY KEY,
 		data JSONB NOT NULL,
 		deleted BOOLEAN NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS positions (
+		position SERIAL PRIMARY KEY,
+		timestamp TIMESTAMPTZ,
+		user_id INTEGER NOT NULL,
+		information JSON,
+		migration_index INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS events (
+		id BIGSERIAL PRIMARY KEY,
+		position INTEGER REFERENCES positions(position) ON DELETE CASCADE,
+		fqid VARCHAR(48) NOT NULL,
+		type VARCHAR(16) NOT NULL,
+		data JSONB,
+		weight INTEGER
 	);`
 	conn, err := tp.conn(ctx)
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracecontext"
 )
 
 var (
@@ -30,8 +31,13 @@ type FlowVoteCount struct {
 
 	mu        sync.Mutex
 	voteCount map[int]int
-	update    chan map[int]int
 	ready     chan struct{}
+
+	// pending are the counts, that were not returned by Update. Each message
+	// from the vote service is merged into it, so no count gets lost, when
+	// Update is called less often than messages are received.
+	pending map[int]int
+	signal  chan struct{}
 }
 
 // NewFlowVoteCount initializes the object.
@@ -45,10 +51,11 @@ func NewFlowVoteCount(lookup environment.Environmenter) *FlowVoteCount {
 
 	flow := FlowVoteCount{
 		voteServiceURL: url,
-		client:         &http.Client{},
-		update:         make(chan map[int]int, 1),
+		client:         &http.Client{Transport: &tracecontext.Transport{}},
 		voteCount:      make(map[int]int),
 		ready:          make(chan struct{}),
+		pending:        make(map[int]int),
+		signal:         make(chan struct{}, 1),
 	}
 
 	return &flow
@@ -106,6 +113,7 @@ func (s *FlowVoteCount) connect(ctx context.Context) error {
 
 		s.mu.Lock()
 		for k, v := range counts {
+			s.pending[k] = v
 			if v == 0 {
 				delete(s.voteCount, k)
 				continue
@@ -121,7 +129,7 @@ func (s *FlowVoteCount) connect(ctx context.Context) error {
 		}
 
 		select {
-		case s.update <- counts:
+		case s.signal <- struct{}{}:
 		default:
 		}
 	}
@@ -156,14 +164,18 @@ func (s *FlowVoteCount) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.K
 // Update has to be called frequently. It blocks, until there is new data.
 func (s *FlowVoteCount) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
 	for {
-		var data map[int]int
 		select {
 		case <-ctx.Done():
 			return // TODO: Should the error be returned?
 
-		case data = <-s.update:
+		case <-s.signal:
 		}
 
+		s.mu.Lock()
+		data := s.pending
+		s.pending = make(map[int]int)
+		s.mu.Unlock()
+
 		out := make(map[dskey.Key][]byte, len(data))
 		for pollID, count := range data {
 			bs := []byte(strconv.Itoa(count))
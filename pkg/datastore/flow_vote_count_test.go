@@ -200,6 +200,64 @@ func TestVoteCountSourceUpdate(t *testing.T) {
 	})
 }
 
+func TestVoteCountSourceUpdateMergesMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender := make(chan string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{}`)
+		w.(http.Flusher).Flush()
+
+		for msg := range sender {
+			fmt.Fprintln(w, msg)
+			w.(http.Flusher).Flush()
+		}
+	}))
+
+	host, port, schema := parseURL(ts.URL)
+	env := environment.ForTests(map[string]string{
+		"VOTE_HOST":     host,
+		"VOTE_PORT":     port,
+		"VOTE_PROTOCOL": schema,
+	})
+
+	flow := datastore.NewFlowVoteCount(env)
+	eventer := func() (<-chan time.Time, func() bool) { return make(chan time.Time), func() bool { return true } }
+
+	waitForResponse(ctx, flow, func() {
+		go flow.Connect(ctx, eventer, func(error) {})
+	})
+
+	// All messages are received before Update is called.
+	sender <- `{"1":42}`
+	sender <- `{"2":7}`
+	sender <- `{"1":43}`
+
+	key1 := dskey.MustKey("poll/1/vote_count")
+	key2 := dskey.MustKey("poll/2/vote_count")
+	for {
+		got, err := flow.Get(ctx, key1)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if string(got[key1]) == "43" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	got, err := updateResult(ctx, flow, func() {})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	expect := map[dskey.Key][]byte{key1: []byte("43"), key2: []byte("7")}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Update() returned %v, expected %v", got, expect)
+	}
+}
+
 func TestReconnect(t *testing.T) {
 	msg := `{"1":23}`
 	sender := make(chan struct{})
@@ -0,0 +1,540 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/fastjson"
+	"github.com/jackc/pgx/v5"
+)
+
+// HistoryQuery selects the events for HistoryExport and HistoryInformation.
+//
+// Exactly one of FQID and MeetingID has to be set. All other fields are
+// optional filters.
+type HistoryQuery struct {
+	// FQID selects the events of one object.
+	FQID string
+
+	// MeetingID selects the events of the meeting and all its objects.
+	MeetingID int
+
+	// Since hides all events that are older. The zero value returns all
+	// events.
+	Since time.Time
+
+	// Until hides all events that are newer. The zero value returns all
+	// events.
+	Until time.Time
+
+	// UserID selects the events of positions created by this user.
+	UserID int
+
+	// Collection selects the events of objects in this collection.
+	Collection string
+
+	// After is a cursor. Only events of later positions are returned.
+	After int
+
+	// Limit is the maximum number of positions. Zero means no limit. It is
+	// only used by HistoryInformation.
+	Limit int
+}
+
+// HistoryEvent is one change of one object.
+type HistoryEvent struct {
+	Position    int             `json:"position"`
+	Timestamp   int             `json:"timestamp"`
+	UserID      int             `json:"user_id"`
+	FQID        string          `json:"fqid"`
+	Type        string          `json:"type"`
+	Fields      []string        `json:"fields"`
+	Information json.RawMessage `json:"information"`
+}
+
+// HistoryExport writes all events that match the query as newline delimited
+// json. The events are ordered by position.
+//
+// Each event is written as soon as it is read from the database, so the
+// export of a big meeting does not have to fit in memory.
+func (p *FlowPostgres) HistoryExport(ctx context.Context, query HistoryQuery, w io.Writer) error {
+	where, args, err := query.where()
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+
+	sql := `select position, timestamp, user_id, information, fqid, type, data
+	from positions natural join events
+	where ` + where + `
+	order by position asc, weight asc`
+
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("sending query: %w", err)
+	}
+	defer rows.Close()
+
+	encoder := json.NewEncoder(w)
+	for rows.Next() {
+		var event HistoryEvent
+		var timestamp time.Time
+		var userID *int
+		var data []byte
+
+		if err := rows.Scan(&event.Position, &timestamp, &userID, &event.Information, &event.FQID, &event.Type, &data); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		event.Timestamp = int(timestamp.Unix())
+		if userID != nil {
+			event.UserID = *userID
+		}
+		event.Fields = changedFields(event.Type, data)
+
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("encode: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading postgres result: %w", err)
+	}
+
+	return nil
+}
+
+// where returns the sql condition for the query and its arguments.
+func (q HistoryQuery) where() (string, []any, error) {
+	var conditions []string
+	var args []any
+
+	// add adds a condition. Each `$?` in the condition is replaced with the
+	// placeholder of the argument.
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	switch {
+	case q.FQID != "" && q.MeetingID != 0:
+		return "", nil, fmt.Errorf("fqid and meeting id can not be used together")
+
+	case q.FQID != "":
+		add(`fqid = $?`, q.FQID)
+
+	case q.MeetingID != 0:
+		// Deleted objects are still in the models table.
+		add(`(fqid = 'meeting/' || $?::text or fqid in (select fqid from models where data->'meeting_id' = to_jsonb($?::int)))`, q.MeetingID)
+
+	default:
+		return "", nil, fmt.Errorf("fqid or meeting id is required")
+	}
+
+	add(`timestamp >= $?`, q.Since)
+
+	if !q.Until.IsZero() {
+		add(`timestamp <= $?`, q.Until)
+	}
+
+	if q.UserID != 0 {
+		add(`user_id = $?`, q.UserID)
+	}
+
+	if q.Collection != "" {
+		add(`fqid like $? || '/%'`, q.Collection)
+	}
+
+	if q.After != 0 {
+		add(`position > $?`, q.After)
+	}
+
+	return strings.Join(conditions, " and "), args, nil
+}
+
+// HistoryPosition is one position with the objects that it changed.
+type HistoryPosition struct {
+	Position    int             `json:"position"`
+	Timestamp   int             `json:"timestamp"`
+	UserID      int             `json:"user_id"`
+	FQIDs       []string        `json:"fqids"`
+	Information json.RawMessage `json:"information"`
+}
+
+// HistoryPositions returns the positions that match the query ordered by
+// position. FQIDs only contains the objects that match the query.
+//
+// In contrast to HistoryInformation, positions without information are also
+// returned.
+func (p *FlowPostgres) HistoryPositions(ctx context.Context, query HistoryQuery) ([]HistoryPosition, error) {
+	where, args, err := query.where()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	sql := `select distinct on (position, fqid) position, timestamp, user_id, information, fqid
+	from positions natural join events
+	where ` + where + `
+	order by position asc, fqid asc`
+
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sending query: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []HistoryPosition
+	for rows.Next() {
+		var position HistoryPosition
+		var timestamp time.Time
+		var userID *int
+		var fqid string
+
+		if err := rows.Scan(&position.Position, &timestamp, &userID, &position.Information, &fqid); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+
+		if last := len(positions) - 1; last >= 0 && positions[last].Position == position.Position {
+			positions[last].FQIDs = append(positions[last].FQIDs, fqid)
+			continue
+		}
+
+		position.Timestamp = int(timestamp.Unix())
+		if userID != nil {
+			position.UserID = *userID
+		}
+		position.FQIDs = []string{fqid}
+		positions = append(positions, position)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading postgres result: %w", err)
+	}
+
+	return positions, nil
+}
+
+// PositionTime returns the time of a position.
+func (p *FlowPostgres) PositionTime(ctx context.Context, position int) (time.Time, error) {
+	var timestamp time.Time
+	err := p.pool.QueryRow(ctx, `select timestamp from positions where position = $1`, position).Scan(&timestamp)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, fmt.Errorf("position %d does not exist", position)
+		}
+		return time.Time{}, fmt.Errorf("sending query: %w", err)
+	}
+	return timestamp, nil
+}
+
+// PositionAt returns the last position at or before the timestamp. This is
+// the position, that shows the data as it was at that time.
+//
+// If meetingID is not 0, only positions that changed the meeting or one of its
+// objects are used.
+//
+// Returns 0, if there is no such position.
+func (p *FlowPostgres) PositionAt(ctx context.Context, timestamp time.Time, meetingID int) (int, time.Time, error) {
+	sql := `select position, timestamp from positions where timestamp <= $1
+	order by position desc limit 1`
+	args := []any{timestamp}
+
+	if meetingID != 0 {
+		sql = `select position, timestamp from positions natural join events
+		where timestamp <= $1 and (fqid = 'meeting/' || $2::text or fqid in (select fqid from models where data->'meeting_id' = to_jsonb($2::int)))
+		order by position desc limit 1`
+		args = append(args, meetingID)
+	}
+
+	var position int
+	var positionTime time.Time
+	if err := p.pool.QueryRow(ctx, sql, args...).Scan(&position, &positionTime); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, time.Time{}, nil
+		}
+		return 0, time.Time{}, fmt.Errorf("sending query: %w", err)
+	}
+	return position, positionTime, nil
+}
+
+// changedFields returns the names of the fields that an event changes.
+//
+// Create and update events contain the new values as object. A deletefields
+// event contains the list of removed fields. A listfields event contains the
+// fields in the objects `add` and `remove`. Other events do not change single
+// fields.
+func changedFields(eventType string, data []byte) []string {
+	fields := make(map[string]struct{})
+
+	switch eventType {
+	case "create", "update":
+		var values map[string]json.RawMessage
+		if err := fastjson.Unmarshal(data, &values); err != nil {
+			return []string{}
+		}
+		for field := range values {
+			fields[field] = struct{}{}
+		}
+
+	case "deletefields":
+		var names []string
+		if err := fastjson.Unmarshal(data, &names); err != nil {
+			return []string{}
+		}
+		for _, field := range names {
+			fields[field] = struct{}{}
+		}
+
+	case "listfields":
+		var lists struct {
+			Add    map[string]json.RawMessage `json:"add"`
+			Remove map[string]json.RawMessage `json:"remove"`
+		}
+		if err := fastjson.Unmarshal(data, &lists); err != nil {
+			return []string{}
+		}
+		for field := range lists.Add {
+			fields[field] = struct{}{}
+		}
+		for field := range lists.Remove {
+			fields[field] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(fields))
+	for field := range fields {
+		sorted = append(sorted, field)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// HistoryGetter reads the data as it was at a position.
+//
+// It replays all events of an object up to the position. Has to be created
+// with FlowPostgres.AtPosition().
+type HistoryGetter struct {
+	pool     *FlowPostgres
+	position int
+}
+
+// AtPosition returns a getter for the data at the given position.
+func (p *FlowPostgres) AtPosition(position int) *HistoryGetter {
+	return &HistoryGetter{pool: p, position: position}
+}
+
+// Get returns the values of the keys at the position of the getter.
+func (h *HistoryGetter) Get(ctx context.Context, keys ...dskey.Key) (map[dskey.Key][]byte, error) {
+	fqids := make([]string, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if _, ok := seen[k.FQID()]; ok {
+			continue
+		}
+		seen[k.FQID()] = struct{}{}
+		fqids = append(fqids, k.FQID())
+	}
+
+	objects, err := h.objects(ctx, fqids)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[dskey.Key][]byte, len(keys))
+	for _, k := range keys {
+		value := []byte(objects[k.FQID()][k.Field()])
+		if string(value) == "null" {
+			value = nil
+		}
+		values[k] = value
+	}
+	return values, nil
+}
+
+// Object returns all fields of an object at the position of the getter.
+//
+// Returns nil, if the object did not exist at that position.
+func (h *HistoryGetter) Object(ctx context.Context, fqid string) (map[string]json.RawMessage, error) {
+	objects, err := h.objects(ctx, []string{fqid})
+	if err != nil {
+		return nil, err
+	}
+	return objects[fqid], nil
+}
+
+// MeetingObjects returns all objects of a meeting, including the meeting
+// itself, at the position of the getter.
+func (h *HistoryGetter) MeetingObjects(ctx context.Context, meetingID int) (map[string]map[string]json.RawMessage, error) {
+	// Deleted objects are still in the models table.
+	return h.replay(ctx, `(fqid = 'meeting/' || $1::text or fqid in (select fqid from models where data->'meeting_id' = to_jsonb($1::int)))`, meetingID)
+}
+
+func (h *HistoryGetter) objects(ctx context.Context, fqids []string) (map[string]map[string]json.RawMessage, error) {
+	return h.replay(ctx, `fqid = any($1)`, fqids)
+}
+
+// replay builds the objects that match the condition from their events. The
+// condition has to use the argument $1.
+func (h *HistoryGetter) replay(ctx context.Context, where string, arg any) (map[string]map[string]json.RawMessage, error) {
+	sql := `select fqid, type, data from events
+	where ` + where + ` and position <= $2
+	order by position asc, weight asc`
+
+	rows, err := h.pool.pool.Query(ctx, sql, arg, h.position)
+	if err != nil {
+		return nil, fmt.Errorf("sending query: %w", err)
+	}
+	defer rows.Close()
+
+	replays := make(map[string]*replay)
+	for rows.Next() {
+		var fqid, eventType string
+		var data []byte
+		if err := rows.Scan(&fqid, &eventType, &data); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+
+		r, ok := replays[fqid]
+		if !ok {
+			r = new(replay)
+			replays[fqid] = r
+		}
+
+		if err := r.apply(eventType, data); err != nil {
+			return nil, fmt.Errorf("replay %s event of %s: %w", eventType, fqid, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading postgres result: %w", err)
+	}
+
+	objects := make(map[string]map[string]json.RawMessage, len(replays))
+	for fqid, r := range replays {
+		if !r.deleted && r.fields != nil {
+			objects[fqid] = r.fields
+		}
+	}
+	return objects, nil
+}
+
+// replay builds the state of an object from its events.
+type replay struct {
+	fields  map[string]json.RawMessage
+	deleted bool
+}
+
+// apply changes the state with one event. See changedFields for the format of
+// the events.
+func (r *replay) apply(eventType string, data []byte) error {
+	switch eventType {
+	case "create":
+		var fields map[string]json.RawMessage
+		if err := fastjson.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		r.fields = fields
+		r.deleted = false
+
+	case "update":
+		var fields map[string]json.RawMessage
+		if err := fastjson.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		if r.fields == nil {
+			r.fields = make(map[string]json.RawMessage, len(fields))
+		}
+		for field, value := range fields {
+			if string(value) == "null" {
+				delete(r.fields, field)
+				continue
+			}
+			r.fields[field] = value
+		}
+
+	case "deletefields":
+		var names []string
+		if err := fastjson.Unmarshal(data, &names); err != nil {
+			return err
+		}
+		for _, field := range names {
+			delete(r.fields, field)
+		}
+
+	case "listfields":
+		var lists struct {
+			Add    map[string][]json.RawMessage `json:"add"`
+			Remove map[string][]json.RawMessage `json:"remove"`
+		}
+		if err := fastjson.Unmarshal(data, &lists); err != nil {
+			return err
+		}
+		if r.fields == nil {
+			r.fields = make(map[string]json.RawMessage)
+		}
+		for field, add := range lists.Add {
+			if err := r.changeList(field, add, nil); err != nil {
+				return err
+			}
+		}
+		for field, remove := range lists.Remove {
+			if err := r.changeList(field, nil, remove); err != nil {
+				return err
+			}
+		}
+
+	case "delete":
+		r.deleted = true
+
+	case "restore":
+		r.deleted = false
+	}
+
+	return nil
+}
+
+// changeList adds and removes elements from a list field.
+func (r *replay) changeList(field string, add, remove []json.RawMessage) error {
+	var list []json.RawMessage
+	if value, ok := r.fields[field]; ok {
+		if err := fastjson.Unmarshal(value, &list); err != nil {
+			return fmt.Errorf("field %s is not a list: %w", field, err)
+		}
+	}
+
+	removeSet := make(map[string]struct{}, len(remove))
+	for _, v := range remove {
+		removeSet[string(v)] = struct{}{}
+	}
+
+	present := make(map[string]struct{}, len(list))
+	changed := make([]json.RawMessage, 0, len(list)+len(add))
+	for _, v := range list {
+		if _, ok := removeSet[string(v)]; ok {
+			continue
+		}
+		present[string(v)] = struct{}{}
+		changed = append(changed, v)
+	}
+
+	for _, v := range add {
+		if _, ok := present[string(v)]; ok {
+			continue
+		}
+		present[string(v)] = struct{}{}
+		changed = append(changed, v)
+	}
+
+	encoded, err := json.Marshal(changed)
+	if err != nil {
+		return err
+	}
+	r.fields[field] = encoded
+	return nil
+}
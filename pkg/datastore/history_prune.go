@@ -0,0 +1,212 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// historyPruneLock is the id of the postgres advisory lock, that is held while
+// the history is pruned. So only one instance prunes at the same time.
+const historyPruneLock = 0x6175746f // "auto"
+
+// PruneHistory deletes the history before a time from the database.
+//
+// The events of each object before that time are replaced by one create event
+// with the state of the object at that time, so the data of later positions
+// does not change. Objects, that were deleted at that time, lose all older
+// events. Positions without events are removed. The other positions before
+// that time lose their user and their information.
+//
+// The meetings in keepMeetings and their objects are not changed. Positions,
+// that also changed one of them, are kept completely.
+//
+// Returns the number of removed positions. If an other instance prunes at the
+// same time, nothing is done.
+func (p *FlowPostgres) PruneHistory(ctx context.Context, before time.Time, keepMeetings []int) (int, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `select pg_try_advisory_xact_lock($1)`, historyPruneLock).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("getting lock: %w", err)
+	}
+
+	if !locked {
+		return 0, nil
+	}
+
+	var last *int
+	if err := tx.QueryRow(ctx, `select max(position) from positions where timestamp < $1`, before).Scan(&last); err != nil {
+		return 0, fmt.Errorf("getting last position: %w", err)
+	}
+
+	if last == nil {
+		return 0, nil
+	}
+
+	meetingFQIDs := make([]string, len(keepMeetings))
+	meetingIDs := make([]string, len(keepMeetings))
+	for i, id := range keepMeetings {
+		meetingFQIDs[i] = "meeting/" + strconv.Itoa(id)
+		meetingIDs[i] = strconv.Itoa(id)
+	}
+	args := []any{*last, meetingFQIDs, meetingIDs}
+
+	remove, creates, err := compactHistory(ctx, tx, args)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `delete from events where id = any($1)`, remove); err != nil {
+		return 0, fmt.Errorf("deleting events: %w", err)
+	}
+
+	batch := new(pgx.Batch)
+	for id, data := range creates {
+		batch.Queue(`update events set type = 'create', data = $2 where id = $1`, id, data)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, fmt.Errorf("replacing events: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `delete from positions p where position <= $1
+	and not exists (select 1 from events e where e.position = p.position)`, *last)
+	if err != nil {
+		return 0, fmt.Errorf("deleting positions: %w", err)
+	}
+
+	// The datastore does not allow positions without user id, so the user is
+	// set to 0.
+	if _, err := tx.Exec(ctx, `update positions p set user_id = 0, information = null
+	where position <= $1
+	and not exists (select 1 from events e where e.position = p.position and `+keptCondition("e.fqid")+`)`, args...); err != nil {
+		return 0, fmt.Errorf("removing information: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// keptCondition is the sql condition for the events of the kept meetings. The
+// argument $2 are the fqids of the meetings and $3 their ids as text.
+func keptCondition(column string) string {
+	// Deleted objects are still in the models table.
+	return `(` + column + ` = any($2) or ` + column + ` in (select fqid from models where data->>'meeting_id' = any($3)))`
+}
+
+// compactHistory reads the events before the last position. It returns the
+// ids of the events, that are deleted, and the data of the events, that are
+// replaced by a create event.
+func compactHistory(ctx context.Context, tx pgx.Tx, args []any) ([]int64, map[int64][]byte, error) {
+	rows, err := tx.Query(ctx, `select id, fqid, type, data from events
+	where position <= $1 and not `+keptCondition("fqid")+`
+	order by fqid asc, position asc, weight asc`, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sending query: %w", err)
+	}
+	defer rows.Close()
+
+	var remove []int64
+	creates := make(map[int64][]byte)
+
+	var fqid string
+	var events []pruneEvent
+	flush := func() error {
+		if len(events) == 0 {
+			return nil
+		}
+
+		c, err := compactEvents(events)
+		if err != nil {
+			return fmt.Errorf("compacting %s: %w", fqid, err)
+		}
+
+		remove = append(remove, c.remove...)
+		if c.create != nil {
+			creates[c.createID] = c.create
+		}
+		events = events[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		var e pruneEvent
+		var eventFQID string
+		if err := rows.Scan(&e.id, &eventFQID, &e.eventType, &e.data); err != nil {
+			return nil, nil, fmt.Errorf("scan: %w", err)
+		}
+
+		if eventFQID != fqid {
+			if err := flush(); err != nil {
+				return nil, nil, err
+			}
+			fqid = eventFQID
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading postgres result: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return nil, nil, err
+	}
+
+	return remove, creates, nil
+}
+
+// pruneEvent is one event of an object, that is pruned.
+type pruneEvent struct {
+	id        int64
+	eventType string
+	data      []byte
+}
+
+// compacted are the changes to the events of one object.
+type compacted struct {
+	// remove are the ids of the events, that are deleted.
+	remove []int64
+
+	// createID is the event, that is replaced by a create event with the data
+	// create. create is nil, if all events are deleted.
+	createID int64
+	create   []byte
+}
+
+// compactEvents replaces the events of an object, ordered by position, with
+// one create event. If the object is deleted after the events, all events are
+// removed.
+func compactEvents(events []pruneEvent) (compacted, error) {
+	var r replay
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		if err := r.apply(e.eventType, e.data); err != nil {
+			return compacted{}, fmt.Errorf("replay %s event: %w", e.eventType, err)
+		}
+		ids[i] = e.id
+	}
+
+	if r.deleted || r.fields == nil {
+		return compacted{remove: ids}, nil
+	}
+
+	data, err := json.Marshal(r.fields)
+	if err != nil {
+		return compacted{}, fmt.Errorf("encoding object: %w", err)
+	}
+
+	last := len(ids) - 1
+	return compacted{remove: ids[:last], createID: ids[last], create: data}, nil
+}
@@ -0,0 +1,253 @@
+package datastore_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestPruneHistory(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("Postgres Test")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tp, err := newTestPostgres(ctx)
+	if err != nil {
+		t.Fatalf("starting postgres: %v", err)
+	}
+	defer tp.Close()
+
+	conn, err := tp.conn(ctx)
+	if err != nil {
+		t.Fatalf("creating connection: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	// Meeting 2 has a legal hold. Topic 1 is deleted at position 3, so this
+	// position has no events after the prune. Position 5 is after the
+	// retention.
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	models := `INSERT INTO models (fqid, data, deleted) VALUES
+		('meeting/1', '{"id":1}', false),
+		('motion/1', '{"id":1,"meeting_id":1,"title":"b","text":"t"}', false),
+		('topic/1', '{"id":1,"meeting_id":1}', true),
+		('meeting/2', '{"id":2}', false),
+		('motion/2', '{"id":2,"meeting_id":2,"title":"z"}', false);`
+
+	positions := `INSERT INTO positions (position, timestamp, user_id, information, migration_index) VALUES
+		(1, $1::timestamptz, 1, '"one"', 1),
+		(2, $1::timestamptz + interval '1 day', 2, '"two"', 1),
+		(3, $1::timestamptz + interval '2 day', 3, '"three"', 1),
+		(4, $1::timestamptz + interval '3 day', 4, '"four"', 1),
+		(5, $1::timestamptz + interval '10 day', 5, '"five"', 1);`
+
+	events := `INSERT INTO events (position, fqid, type, data, weight) VALUES
+		(1, 'meeting/1', 'create', '{"id":1}', 1),
+		(1, 'motion/1', 'create', '{"id":1,"meeting_id":1,"title":"a"}', 2),
+		(1, 'topic/1', 'create', '{"id":1,"meeting_id":1}', 3),
+		(1, 'meeting/2', 'create', '{"id":2}', 4),
+		(1, 'motion/2', 'create', '{"id":2,"meeting_id":2,"title":"x"}', 5),
+		(2, 'motion/1', 'update', '{"title":"b"}', 1),
+		(3, 'topic/1', 'delete', null, 1),
+		(4, 'motion/2', 'update', '{"title":"z"}', 1),
+		(5, 'motion/1', 'update', '{"text":"t"}', 1);`
+
+	if _, err := conn.Exec(ctx, models); err != nil {
+		t.Fatalf("adding models: %v", err)
+	}
+
+	if _, err := conn.Exec(ctx, positions, start); err != nil {
+		t.Fatalf("adding positions: %v", err)
+	}
+
+	if _, err := conn.Exec(ctx, events); err != nil {
+		t.Fatalf("adding events: %v", err)
+	}
+
+	source, err := datastore.NewFlowPostgres(environment.ForTests(tp.Env), nil)
+	if err != nil {
+		t.Fatalf("NewFlowPostgres: %v", err)
+	}
+
+	fqids := []string{"meeting/1", "motion/1", "topic/1", "meeting/2", "motion/2"}
+	objects := func(position int) map[string]map[string]json.RawMessage {
+		t.Helper()
+
+		data := make(map[string]map[string]json.RawMessage)
+		for _, fqid := range fqids {
+			object, err := source.AtPosition(position).Object(ctx, fqid)
+			if err != nil {
+				t.Fatalf("reading %s at position %d: %v", fqid, position, err)
+			}
+			data[fqid] = object
+		}
+		return data
+	}
+
+	keptEvents := func() []string {
+		t.Helper()
+
+		rows, err := conn.Query(ctx, `select id, position, fqid, type, data from events
+		where fqid in ('meeting/2', 'motion/2') order by id`)
+		if err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+		defer rows.Close()
+
+		var events []string
+		for rows.Next() {
+			var id int64
+			var position int
+			var fqid, eventType string
+			var data []byte
+			if err := rows.Scan(&id, &position, &fqid, &eventType, &data); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			events = append(events, fmt.Sprintf("%d %d %s %s %s", id, position, fqid, eventType, data))
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+		return events
+	}
+
+	beforeRetention := start.Add(5 * 24 * time.Hour)
+	expectObjects := objects(5)
+	expectKept := keptEvents()
+
+	t.Run("Locked by another instance", func(t *testing.T) {
+		other, err := tp.conn(ctx)
+		if err != nil {
+			t.Fatalf("creating connection: %v", err)
+		}
+		defer other.Close(ctx)
+
+		tx, err := other.Begin(ctx)
+		if err != nil {
+			t.Fatalf("starting transaction: %v", err)
+		}
+		defer tx.Rollback(ctx)
+
+		if _, err := tx.Exec(ctx, `select pg_advisory_xact_lock($1)`, 0x6175746f); err != nil {
+			t.Fatalf("getting lock: %v", err)
+		}
+
+		removed, err := source.PruneHistory(ctx, beforeRetention, []int{2})
+		if err != nil {
+			t.Fatalf("PruneHistory: %v", err)
+		}
+
+		if removed != 0 {
+			t.Errorf("removed %d positions while the lock was held", removed)
+		}
+
+		var count int
+		if err := conn.QueryRow(ctx, `select count(*) from events`).Scan(&count); err != nil {
+			t.Fatalf("counting events: %v", err)
+		}
+
+		if count != 9 {
+			t.Errorf("got %d events, expected the 9 events to be unchanged", count)
+		}
+	})
+
+	removed, err := source.PruneHistory(ctx, beforeRetention, []int{2})
+	if err != nil {
+		t.Fatalf("PruneHistory: %v", err)
+	}
+
+	if removed != 1 {
+		t.Errorf("removed %d positions, expected 1", removed)
+	}
+
+	t.Run("Later positions have the same data", func(t *testing.T) {
+		if got := objects(5); !reflect.DeepEqual(got, expectObjects) {
+			t.Errorf("got objects\n%s\nexpected\n%s", got, expectObjects)
+		}
+	})
+
+	t.Run("Legal hold", func(t *testing.T) {
+		if got := keptEvents(); !reflect.DeepEqual(got, expectKept) {
+			t.Errorf("events of the kept meeting changed:\n%v\nexpected\n%v", got, expectKept)
+		}
+	})
+
+	t.Run("Compacted events", func(t *testing.T) {
+		rows, err := conn.Query(ctx, `select position, type, data from events where fqid = $1 order by position`, "motion/1")
+		if err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+		defer rows.Close()
+
+		var got []string
+		for rows.Next() {
+			var position int
+			var eventType string
+			var data []byte
+			if err := rows.Scan(&position, &eventType, &data); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			got = append(got, fmt.Sprintf("%d %s", position, eventType))
+		}
+
+		expect := []string{"2 create", "5 update"}
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("got events %v, expected %v", got, expect)
+		}
+
+		var topicEvents int
+		if err := conn.QueryRow(ctx, `select count(*) from events where fqid = 'topic/1'`).Scan(&topicEvents); err != nil {
+			t.Fatalf("counting events: %v", err)
+		}
+
+		if topicEvents != 0 {
+			t.Errorf("deleted object has %d events, expected none", topicEvents)
+		}
+	})
+
+	t.Run("Positions", func(t *testing.T) {
+		rows, err := conn.Query(ctx, `select position, user_id, information::text from positions order by position`)
+		if err != nil {
+			t.Fatalf("reading positions: %v", err)
+		}
+		defer rows.Close()
+
+		var got []string
+		for rows.Next() {
+			var position, userID int
+			var information *string
+			if err := rows.Scan(&position, &userID, &information); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+
+			info := "null"
+			if information != nil {
+				info = *information
+			}
+			got = append(got, fmt.Sprintf("%d %d %s", position, userID, info))
+		}
+
+		// Position 1 and 4 changed the kept meeting, position 2 lost its user
+		// and position 3 has no events left.
+		expect := []string{
+			`1 1 "one"`,
+			`2 0 null`,
+			`4 4 "four"`,
+			`5 5 "five"`,
+		}
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("got positions %v, expected %v", got, expect)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package datastore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedFields(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		eventType string
+		data      string
+		expect    []string
+	}{
+		{"create", "create", `{"id":1,"title":"foo"}`, []string{"id", "title"}},
+		{"update", "update", `{"title":"bar"}`, []string{"title"}},
+		{"deletefields", "deletefields", `["text","title"]`, []string{"text", "title"}},
+		{"listfields", "listfields", `{"add":{"tag_ids":[1]},"remove":{"tag_ids":[2],"block_ids":[3]}}`, []string{"block_ids", "tag_ids"}},
+		{"delete", "delete", `null`, []string{}},
+		{"invalid data", "update", `[1]`, []string{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changedFields(tt.eventType, []byte(tt.data))
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestHistoryQueryWhere(t *testing.T) {
+	if _, _, err := (HistoryQuery{}).where(); err == nil {
+		t.Errorf("empty query returned no error")
+	}
+
+	if _, _, err := (HistoryQuery{FQID: "motion/1", MeetingID: 1}).where(); err == nil {
+		t.Errorf("query with fqid and meeting returned no error")
+	}
+
+	if _, args, err := (HistoryQuery{FQID: "motion/1"}).where(); err != nil || args[0] != "motion/1" {
+		t.Errorf("fqid query: got args %v and error %v", args, err)
+	}
+
+	where, args, err := (HistoryQuery{MeetingID: 1, UserID: 5, Collection: "motion", After: 10}).where()
+	if err != nil {
+		t.Fatalf("filter query: %v", err)
+	}
+
+	expect := `(fqid = 'meeting/' || $1::text or fqid in (select fqid from models where data->'meeting_id' = to_jsonb($1::int))) and timestamp >= $2 and user_id = $3 and fqid like $4 || '/%' and position > $5`
+	if where != expect {
+		t.Errorf("filter query: got `%s`, expected `%s`", where, expect)
+	}
+
+	if len(args) != 5 || args[2] != 5 || args[3] != "motion" || args[4] != 10 {
+		t.Errorf("filter query: got args %v", args)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var r replay
+	for _, event := range []struct {
+		eventType string
+		data      string
+	}{
+		{"create", `{"id":1,"title":"foo","tag_ids":[1,2]}`},
+		{"update", `{"title":"bar","text":"hello"}`},
+		{"listfields", `{"add":{"tag_ids":[3]},"remove":{"tag_ids":[1]}}`},
+		{"deletefields", `["text"]`},
+		{"update", `{"number":null}`},
+	} {
+		if err := r.apply(event.eventType, []byte(event.data)); err != nil {
+			t.Fatalf("apply %s: %v", event.eventType, err)
+		}
+	}
+
+	got := make(map[string]string, len(r.fields))
+	for k, v := range r.fields {
+		got[k] = string(v)
+	}
+
+	expect := map[string]string{"id": "1", "title": `"bar"`, "tag_ids": "[2,3]"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("got %v, expected %v", got, expect)
+	}
+
+	if err := r.apply("delete", nil); err != nil || !r.deleted {
+		t.Errorf("object is not deleted: %v", err)
+	}
+
+	if err := r.apply("restore", nil); err != nil || r.deleted {
+		t.Errorf("object is not restored: %v", err)
+	}
+}
+
+func TestCompactEvents(t *testing.T) {
+	events := []pruneEvent{
+		{1, "create", []byte(`{"id":1,"title":"foo","tag_ids":[1]}`)},
+		{2, "update", []byte(`{"title":"bar"}`)},
+		{3, "listfields", []byte(`{"add":{"tag_ids":[2]}}`)},
+	}
+
+	got, err := compactEvents(events)
+	if err != nil {
+		t.Fatalf("compactEvents: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.remove, []int64{1, 2}) || got.createID != 3 {
+		t.Errorf("got remove %v and create %d, expected [1 2] and 3", got.remove, got.createID)
+	}
+
+	if expect := `{"id":1,"tag_ids":[1,2],"title":"bar"}`; string(got.create) != expect {
+		t.Errorf("got create %s, expected %s", got.create, expect)
+	}
+
+	deleted, err := compactEvents(append(events, pruneEvent{4, "delete", nil}))
+	if err != nil {
+		t.Fatalf("compactEvents with delete: %v", err)
+	}
+
+	if !reflect.DeepEqual(deleted.remove, []int64{1, 2, 3, 4}) || deleted.create != nil {
+		t.Errorf("deleted object: got remove %v and create %s, expected all events to be removed", deleted.remove, deleted.create)
+	}
+}
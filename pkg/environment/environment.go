@@ -8,8 +8,10 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -33,12 +35,76 @@ type Variable struct {
 }
 
 // NewVariable initializes a environment.Variable
+//
+// The variable is remembered for Defined.
 func NewVariable(key, defaultValue, description string) Variable {
-	return Variable{
+	v := Variable{
 		Key:         key,
 		Default:     defaultValue,
 		Description: description,
 	}
+
+	defined.mu.Lock()
+	defer defined.mu.Unlock()
+	if defined.variables == nil {
+		defined.variables = make(map[string]Variable)
+	}
+	defined.variables[key] = v
+
+	return v
+}
+
+var defined struct {
+	mu        sync.Mutex
+	variables map[string]Variable
+}
+
+// Defined returns all variables created with NewVariable sorted by key.
+//
+// Since the variables are package level variables, the list is complete
+// without starting the service.
+func Defined() []Variable {
+	defined.mu.Lock()
+	defer defined.mu.Unlock()
+
+	variables := make([]Variable, 0, len(defined.variables))
+	for _, v := range defined.variables {
+		variables = append(variables, v)
+	}
+	slices.SortFunc(variables, func(a, b Variable) int { return strings.Compare(a.Key, b.Key) })
+	return variables
+}
+
+// Alias declares old names of the variable. They are deprecated but still
+// work, so existing deployments do not break after a rename.
+//
+// ForProduction uses an alias, if the variable itself is not set in the same
+// source. It remembers the used aliases for Deprecated.
+//
+// Alias has to be called at package level on the result of NewVariable.
+func (v Variable) Alias(oldKeys ...string) Variable {
+	aliases.mu.Lock()
+	defer aliases.mu.Unlock()
+
+	if aliases.names == nil {
+		aliases.names = make(map[string][]string)
+	}
+	aliases.names[v.Key] = append(aliases.names[v.Key], oldKeys...)
+
+	return v
+}
+
+var aliases struct {
+	mu    sync.Mutex
+	names map[string][]string
+}
+
+// aliasesOf returns the old names of a variable.
+func aliasesOf(key string) []string {
+	aliases.mu.Lock()
+	defer aliases.mu.Unlock()
+
+	return slices.Clone(aliases.names[key])
 }
 
 // Value returns the value for an environment.Variable using a Getenver.
@@ -67,16 +133,181 @@ type Environmenter interface {
 
 // ForProduction is an environment used for production.
 //
-// It fetches the environment variables from os.Getenv().
-type ForProduction struct{}
+// It fetches the environment variables from os.Getenv(). If it was created
+// with NewForProduction, the values of the config file, the config directory
+// and then the values of the profile are used for variables, that are not
+// set.
+type ForProduction struct {
+	mu            sync.Mutex
+	usedVariables map[string]Variable
+	usedAliases   map[string]string
+	path          string
+	file          map[string]string
+	dirPath       string
+	dir           map[string]string
+	profile       map[string]string
+}
 
-// Getenv calls os.Getenv.
+// Getenv calls os.Getenv. If the variable is not set, the value of the config
+// file, the config directory or the profile is returned.
+//
+// In the environment, the config file and the config directory, a deprecated
+// alias is used, if the variable itself is not set.
 func (e *ForProduction) Getenv(key string) string {
-	return os.Getenv(key)
+	value, name, _ := e.find(key)
+	if name != key {
+		e.mu.Lock()
+		if e.usedAliases == nil {
+			e.usedAliases = make(map[string]string)
+		}
+		e.usedAliases[name] = key
+		e.mu.Unlock()
+	}
+	return value
+}
+
+// find returns the value of a variable, the name it was found with and its
+// source. If the variable is not set, value and source are empty.
+func (e *ForProduction) find(key string) (value string, name string, source string) {
+	names := append([]string{key}, aliasesOf(key)...)
+
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value, name, SourceEnvironment
+		}
+	}
+
+	for _, name := range names {
+		if value := e.file[name]; value != "" {
+			return value, name, SourceFile
+		}
+	}
+
+	for _, name := range names {
+		if value := e.dir[name]; value != "" {
+			return value, name, SourceDirectory
+		}
+	}
+
+	if value := e.profile[key]; value != "" {
+		return value, key, SourceProfile
+	}
+
+	return "", key, ""
+}
+
+// Deprecation is a deprecated alias, that was used instead of the new name.
+type Deprecation struct {
+	Old string
+	New string
+}
+
+// Deprecated returns the aliases, that were used to read a variable, sorted
+// by the old name.
+func (e *ForProduction) Deprecated() []Deprecation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	deprecated := make([]Deprecation, 0, len(e.usedAliases))
+	for old, key := range e.usedAliases {
+		deprecated = append(deprecated, Deprecation{Old: old, New: key})
+	}
+	slices.SortFunc(deprecated, func(a, b Deprecation) int { return strings.Compare(a.Old, b.Old) })
+	return deprecated
 }
 
 // UseVariable saves the used Variables
-func (e *ForProduction) UseVariable(v Variable) {}
+func (e *ForProduction) UseVariable(v Variable) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.usedVariables == nil {
+		e.usedVariables = make(map[string]Variable)
+	}
+	e.usedVariables[v.Key] = v
+}
+
+// Settings returns the effective value of each used variable.
+//
+// The values of secrets are replaced. A variable is a secret, if a part of its
+// name is PASSWORD, SECRET, TOKEN, KEY or DSN and it does not end with _FILE.
+// Variables ending with _FILE only contain the path to a secret.
+func (e *ForProduction) Settings() map[string]string {
+	e.mu.Lock()
+	used := make([]Variable, 0, len(e.usedVariables))
+	for _, v := range e.usedVariables {
+		used = append(used, v)
+	}
+	e.mu.Unlock()
+
+	settings := make(map[string]string, len(used))
+	for _, setting := range e.Resolve(used) {
+		settings[setting.Key] = setting.Value
+	}
+	return settings
+}
+
+// The sources of a setting.
+const (
+	SourceEnvironment = "environment"
+	SourceFile        = "file"
+	SourceDirectory   = "directory"
+	SourceProfile     = "profile"
+	SourceDefault     = "default"
+
+	// SourceVault means, that the variable is the path of a secret file, but
+	// the secret is read from Vault.
+	SourceVault = "vault"
+)
+
+// Setting is the effective value of a variable and where it came from.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Resolve returns the effective value and the source of each variable.
+// Secrets are redacted like in Settings.
+func (e *ForProduction) Resolve(variables []Variable) []Setting {
+	settings := make([]Setting, len(variables))
+	for i, v := range variables {
+		value, _, source := e.find(v.Key)
+		if value == "" {
+			value = v.Default
+			source = SourceDefault
+		}
+
+		if strings.HasSuffix(v.Key, "_FILE") {
+			if _, ok := vaultSecret(value); ok {
+				source = SourceVault
+			}
+		}
+
+		if value != "" && isSecret(v.Key) {
+			value = redacted
+		}
+
+		settings[i] = Setting{Key: v.Key, Value: value, Source: source}
+	}
+	return settings
+}
+
+const redacted = "<redacted>"
+
+func isSecret(key string) bool {
+	if strings.HasSuffix(key, "_FILE") {
+		return false
+	}
+
+	for _, part := range strings.Split(key, "_") {
+		switch part {
+		case "PASSWORD", "SECRET", "TOKEN", "KEY", "DSN":
+			return true
+		}
+	}
+	return false
+}
 
 // ForDocu is an environment to gether the used environment variables.
 //
@@ -103,8 +334,13 @@ func (e *ForDocu) UseVariable(v Variable) {
 
 // BuildDoc create the environment documentation with all used variables.
 func (e *ForDocu) BuildDoc() (string, error) {
+	type docVariable struct {
+		Variable
+		Deprecated string
+	}
+
 	var variables struct {
-		Env []Variable
+		Env []docVariable
 	}
 
 	seen := set.New[string]()
@@ -115,7 +351,10 @@ func (e *ForDocu) BuildDoc() (string, error) {
 		}
 		seen.Add(v.Key)
 
-		variables.Env = append(variables.Env, v)
+		variables.Env = append(variables.Env, docVariable{
+			Variable:   v,
+			Deprecated: strings.Join(aliasesOf(v.Key), "$, $"),
+		})
 	}
 
 	tmpl, err := template.New("Doc").Parse(tmplDoc)
@@ -133,6 +372,9 @@ func (e *ForDocu) BuildDoc() (string, error) {
 
 // ReadSecret reads a secret from a file given by an environment variable.
 //
+// If Vault was configured with NewVault and it has a value with the name of
+// the file, the value from Vault is returned instead.
+//
 // If OPENSLIDES_DEVELOPMENT is set, then this will always return the string
 // 'openslides'
 func ReadSecret(lookup Environmenter, pathVariable Variable) (string, error) {
@@ -142,13 +384,27 @@ func ReadSecret(lookup Environmenter, pathVariable Variable) (string, error) {
 // ReadSecretWithDefault is like ReadSecret, but it allows to set another
 // default value then "openslides".
 func ReadSecretWithDefault(lookup Environmenter, pathVariable Variable, defaultValue string) (string, error) {
+	return readSecretPath(lookup, pathVariable.Value(lookup), defaultValue)
+}
+
+// ReadSecretFile is like ReadSecret, but the path is not given by an
+// environment variable. It is for secrets, that are configured in other
+// files.
+func ReadSecretFile(lookup Environmenter, path string) (string, error) {
+	return readSecretPath(lookup, path, "openslides")
+}
+
+func readSecretPath(lookup Environmenter, path string, defaultValue string) (string, error) {
 	useDev, _ := strconv.ParseBool(EnvDevelopment.Value(lookup))
-	path := pathVariable.Value(lookup)
 
 	if useDev {
 		return defaultValue, nil
 	}
 
+	if secret, ok := vaultSecret(path); ok {
+		return secret, nil
+	}
+
 	secret, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("read secret from %s: %w", path, err)
@@ -210,4 +466,5 @@ const tmplDoc = `<!--- Code generated with go generate ./... DO NOT EDIT. --->
 The Service uses the following environment variables:
 {{range .Env}}
 * ${{.Key}}$: {{.Description}} The default is ${{.Default}}$.
+{{- if .Deprecated}} Deprecated names: ${{.Deprecated}}$.{{end}}
 {{- end}}`
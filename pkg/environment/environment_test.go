@@ -0,0 +1,152 @@
+package environment_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestSettings(t *testing.T) {
+	t.Setenv("SENTRY_DSN", "http://key@sentry/1")
+	t.Setenv("KEYCLOAK_HOST", "keycloak")
+
+	lookup := new(environment.ForProduction)
+	for _, v := range []environment.Variable{
+		environment.NewVariable("SENTRY_DSN", "", ""),
+		environment.NewVariable("KEYCLOAK_HOST", "localhost", ""),
+		environment.NewVariable("AUTH_TOKEN_KEY_FILE", "/run/secrets/auth_token_key", ""),
+		environment.NewVariable("DATABASE_PASSWORD", "", ""),
+		environment.NewVariable("AUTOUPDATE_PORT", "9012", ""),
+	} {
+		v.Value(lookup)
+	}
+
+	got := lookup.Settings()
+	expect := map[string]string{
+		"SENTRY_DSN":          "<redacted>",
+		"KEYCLOAK_HOST":       "keycloak",
+		"AUTH_TOKEN_KEY_FILE": "/run/secrets/auth_token_key",
+		"DATABASE_PASSWORD":   "",
+		"AUTOUPDATE_PORT":     "9012",
+	}
+
+	if len(got) != len(expect) {
+		t.Errorf("got %d settings, expected %d: %v", len(got), len(expect), got)
+	}
+
+	for key, value := range expect {
+		if got[key] != value {
+			t.Errorf("%s: got `%s`, expected `%s`", key, got[key], value)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("MESSAGE_BUS_HOST: redis\nSENTRY_DSN: http://key@sentry/1\n"), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+	t.Setenv("KEYCLOAK_HOST", "keycloak")
+
+	lookup, err := environment.NewForProduction(path)
+	if err != nil {
+		t.Fatalf("NewForProduction: %v", err)
+	}
+
+	got := lookup.Resolve([]environment.Variable{
+		environment.NewVariable("KEYCLOAK_HOST", "localhost", ""),
+		environment.NewVariable("MESSAGE_BUS_HOST", "localhost", ""),
+		environment.NewVariable("SENTRY_DSN", "", ""),
+		environment.NewVariable("AUTOUPDATE_PORT", "9012", ""),
+	})
+
+	expect := []environment.Setting{
+		{Key: "KEYCLOAK_HOST", Value: "keycloak", Source: environment.SourceEnvironment},
+		{Key: "MESSAGE_BUS_HOST", Value: "redis", Source: environment.SourceFile},
+		{Key: "SENTRY_DSN", Value: "<redacted>", Source: environment.SourceFile},
+		{Key: "AUTOUPDATE_PORT", Value: "9012", Source: environment.SourceDefault},
+	}
+
+	if !slices.Equal(got, expect) {
+		t.Errorf("got %v, expected %v", got, expect)
+	}
+}
+
+func TestDefined(t *testing.T) {
+	environment.NewVariable("TEST_DEFINED", "value", "")
+
+	for _, v := range environment.Defined() {
+		if v.Key == "TEST_DEFINED" {
+			return
+		}
+	}
+	t.Errorf("variable TEST_DEFINED is not in Defined()")
+}
+
+func TestAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("TEST_ALIAS_OLD_PORT: 9000\n"), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+	t.Setenv("TEST_ALIAS_OLD_HOST", "old-host")
+
+	host := environment.NewVariable("TEST_ALIAS_HOST", "localhost", "").Alias("TEST_ALIAS_OLD_HOST")
+	port := environment.NewVariable("TEST_ALIAS_PORT", "80", "").Alias("TEST_ALIAS_OLD_PORT")
+
+	t.Run("alias is used", func(t *testing.T) {
+		lookup, err := environment.NewForProduction(path)
+		if err != nil {
+			t.Fatalf("NewForProduction: %v", err)
+		}
+
+		if got := host.Value(lookup); got != "old-host" {
+			t.Errorf("host is %s, expected old-host", got)
+		}
+
+		if got := port.Value(lookup); got != "9000" {
+			t.Errorf("port is %s, expected 9000", got)
+		}
+
+		expect := []environment.Deprecation{
+			{Old: "TEST_ALIAS_OLD_HOST", New: "TEST_ALIAS_HOST"},
+			{Old: "TEST_ALIAS_OLD_PORT", New: "TEST_ALIAS_PORT"},
+		}
+		if got := lookup.Deprecated(); !slices.Equal(got, expect) {
+			t.Errorf("got deprecations %v, expected %v", got, expect)
+		}
+	})
+
+	t.Run("new name in the same source wins", func(t *testing.T) {
+		t.Setenv("TEST_ALIAS_HOST", "new-host")
+
+		lookup, err := environment.NewForProduction(path)
+		if err != nil {
+			t.Fatalf("NewForProduction: %v", err)
+		}
+
+		if got := host.Value(lookup); got != "new-host" {
+			t.Errorf("host is %s, expected new-host", got)
+		}
+
+		if got := lookup.Deprecated(); len(got) != 0 {
+			t.Errorf("got deprecations %v, expected none", got)
+		}
+	})
+
+	t.Run("alias in environment wins over file", func(t *testing.T) {
+		t.Setenv("TEST_ALIAS_OLD_PORT", "9001")
+		lookup, err := environment.NewForProduction(path)
+		if err != nil {
+			t.Fatalf("NewForProduction: %v", err)
+		}
+
+		got := lookup.Resolve([]environment.Variable{port})
+		expect := []environment.Setting{{Key: "TEST_ALIAS_PORT", Value: "9001", Source: environment.SourceEnvironment}}
+		if !slices.Equal(got, expect) {
+			t.Errorf("got %v, expected %v", got, expect)
+		}
+	})
+}
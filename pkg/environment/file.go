@@ -0,0 +1,162 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ConfigFileKey is the environment variable with the path of the config file.
+// It is used, if no path is given to NewForProduction.
+const ConfigFileKey = "OPENSLIDES_CONFIG"
+
+// ConfigDirKey is the environment variable with the path of the config
+// directory.
+const ConfigDirKey = "OPENSLIDES_CONFIG_DIR"
+
+// NewForProduction creates an environment, that reads the values from the
+// environment variables and from a config file.
+//
+// The config file is a YAML or JSON file with the names of the variables as
+// keys. Environment variables override the values of the file. A list is
+// joined with commas.
+//
+// If path is empty, the path is read from OPENSLIDES_CONFIG. Without a path,
+// only the environment variables are used.
+//
+// If OPENSLIDES_CONFIG_DIR is set, each file in the directory is a variable
+// with the file name as key, like a mounted Kubernetes ConfigMap. The values
+// of the config file override the values of the directory.
+//
+// If OPENSLIDES_PROFILE is set in the environment or in the file, the values
+// of the profile are used for variables, that are not set otherwise.
+func NewForProduction(path string) (*ForProduction, error) {
+	if path == "" {
+		path = os.Getenv(ConfigFileKey)
+	}
+
+	e := new(ForProduction)
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+
+		values, err := parseConfigFile(content)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+		e.path = path
+		e.file = values
+	}
+
+	if dir := os.Getenv(ConfigDirKey); dir != "" {
+		values, err := readConfigDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("reading config directory: %w", err)
+		}
+		e.dirPath = dir
+		e.dir = values
+	}
+
+	profile, err := loadProfile(e)
+	if err != nil {
+		return nil, err
+	}
+	e.profile = profile
+
+	return e, nil
+}
+
+// ConfigPaths returns the path of the config file and the config directory,
+// if they are used.
+func (e *ForProduction) ConfigPaths() []string {
+	var paths []string
+	for _, path := range []string{e.path, e.dirPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// readConfigDir returns the content of each file in the directory. A trailing
+// newline is removed.
+//
+// Hidden files are skipped. Kubernetes uses them for the atomic update of a
+// ConfigMap.
+func readConfigDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		// The files of a ConfigMap are symlinks, so entry.IsDir is not enough.
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values[entry.Name()] = strings.TrimRight(string(content), "\r\n")
+	}
+	return values, nil
+}
+
+// parseConfigFile returns the values of a config file as strings.
+func parseConfigFile(content []byte) (map[string]string, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("decoding yaml: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		converted, err := configValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("value of %s: %w", key, err)
+		}
+		values[key] = converted
+	}
+	return values, nil
+}
+
+func configValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, element := range v {
+			part, err := configValue(element)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("expected a value or a list, got %T", value)
+	}
+}
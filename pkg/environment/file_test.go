@@ -0,0 +1,134 @@
+package environment_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestNewForProduction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := `
+AUTOUPDATE_PORT: 9013
+AUTOUPDATE_ACCESS_LOG: true
+AUTOUPDATE_TRUSTED_PROXIES:
+  - 10.0.0.0/8
+  - 192.168.0.0/16
+MESSAGE_BUS_HOST: redis
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	t.Setenv("MESSAGE_BUS_HOST", "other-redis")
+
+	lookup, err := environment.NewForProduction(path)
+	if err != nil {
+		t.Fatalf("NewForProduction: %v", err)
+	}
+
+	for key, expect := range map[string]string{
+		"AUTOUPDATE_PORT":            "9013",
+		"AUTOUPDATE_ACCESS_LOG":      "true",
+		"AUTOUPDATE_TRUSTED_PROXIES": "10.0.0.0/8,192.168.0.0/16",
+		"MESSAGE_BUS_HOST":           "other-redis",
+		"MESSAGE_BUS_PORT":           "",
+	} {
+		if got := lookup.Getenv(key); got != expect {
+			t.Errorf("%s: got `%s`, expected `%s`", key, got, expect)
+		}
+	}
+}
+
+func TestNewForProductionFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"AUTOUPDATE_PORT": "9014"}`), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+	t.Setenv(environment.ConfigFileKey, path)
+
+	lookup, err := environment.NewForProduction("")
+	if err != nil {
+		t.Fatalf("NewForProduction: %v", err)
+	}
+
+	if got := lookup.Getenv("AUTOUPDATE_PORT"); got != "9014" {
+		t.Errorf("got `%s`, expected `9014`", got)
+	}
+}
+
+func TestNewForProductionInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"not yaml": "AUTOUPDATE_PORT: [",
+		"nested":   "AUTOUPDATE:\n  PORT: 9012\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("writing config file: %v", err)
+			}
+
+			if _, err := environment.NewForProduction(path); err == nil {
+				t.Errorf("NewForProduction returned no error")
+			}
+		})
+	}
+
+	if _, err := environment.NewForProduction(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Errorf("NewForProduction with missing file returned no error")
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	// Kubernetes mounts a ConfigMap as symlinks to a hidden directory.
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..data")
+	if err := os.Mkdir(data, 0o700); err != nil {
+		t.Fatalf("creating data dir: %v", err)
+	}
+
+	for key, value := range map[string]string{
+		"MESSAGE_BUS_HOST": "redis\n",
+		"AUTOUPDATE_PORT":  "9014",
+	} {
+		if err := os.WriteFile(filepath.Join(data, key), []byte(value), 0o600); err != nil {
+			t.Fatalf("writing file: %v", err)
+		}
+		if err := os.Symlink(filepath.Join("..data", key), filepath.Join(dir, key)); err != nil {
+			t.Fatalf("creating symlink: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("AUTOUPDATE_PORT: 9013\n"), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	t.Setenv(environment.ConfigDirKey, dir)
+
+	lookup, err := environment.NewForProduction(path)
+	if err != nil {
+		t.Fatalf("NewForProduction: %v", err)
+	}
+
+	for key, expect := range map[string]string{
+		"MESSAGE_BUS_HOST": "redis",
+		"AUTOUPDATE_PORT":  "9013",
+		"..data":           "",
+	} {
+		if got := lookup.Getenv(key); got != expect {
+			t.Errorf("%s: got `%s`, expected `%s`", key, got, expect)
+		}
+	}
+
+	got := lookup.Resolve([]environment.Variable{environment.NewVariable("MESSAGE_BUS_HOST", "localhost", "")})
+	if got[0].Source != environment.SourceDirectory {
+		t.Errorf("MESSAGE_BUS_HOST has source %s, expected %s", got[0].Source, environment.SourceDirectory)
+	}
+
+	if paths := lookup.ConfigPaths(); len(paths) != 2 || paths[0] != path || paths[1] != dir {
+		t.Errorf("got config paths %v, expected [%s %s]", paths, path, dir)
+	}
+}
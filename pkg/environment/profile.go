@@ -0,0 +1,56 @@
+package environment
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var envProfile = NewVariable("OPENSLIDES_PROFILE", "", "Name of a preset for the other variables. One of `dev`, `staging` or `production`. Explicit values override the preset. Empty uses no preset.")
+
+// profiles are the presets for OPENSLIDES_PROFILE.
+var profiles = map[string]map[string]string{
+	"dev": {
+		"OPENSLIDES_DEVELOPMENT": "true",
+		"AUTH_FAKE":              "true",
+		"LOG_LEVEL":              "debug",
+		"AUTOUPDATE_ACCESS_LOG":  "true",
+		"MESSAGE_BUS_HOST":       "localhost",
+		"DATABASE_HOST":          "localhost",
+		"KEYCLOAK_HOST":          "localhost",
+	},
+	"staging": {
+		"LOG_FORMAT":            "json",
+		"AUTOUPDATE_ACCESS_LOG": "true",
+	},
+	"production": {
+		"LOG_FORMAT": "json",
+	},
+}
+
+// loadProfile returns the values of the profile, that is set in the
+// environment or in the config file.
+//
+// The production profile does not allow OPENSLIDES_DEVELOPMENT, because it
+// replaces the secrets with public values.
+func loadProfile(e *ForProduction) (map[string]string, error) {
+	name := envProfile.Value(e)
+	if name == "" {
+		return nil, nil
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid value for `%s`, expected one of %s, got %s", envProfile.Key, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "), name)
+	}
+
+	if name == "production" {
+		if dev, _ := strconv.ParseBool(EnvDevelopment.Value(e)); dev {
+			return nil, fmt.Errorf("`%s` is not allowed with the profile production", EnvDevelopment.Key)
+		}
+	}
+
+	return profile, nil
+}
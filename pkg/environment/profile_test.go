@@ -0,0 +1,49 @@
+package environment_test
+
+import (
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestProfile(t *testing.T) {
+	t.Setenv("OPENSLIDES_PROFILE", "dev")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	lookup, err := environment.NewForProduction("")
+	if err != nil {
+		t.Fatalf("NewForProduction: %v", err)
+	}
+
+	for key, expect := range map[string]string{
+		"AUTH_FAKE":       "true",
+		"LOG_LEVEL":       "warn",
+		"AUTOUPDATE_PORT": "",
+	} {
+		if got := lookup.Getenv(key); got != expect {
+			t.Errorf("%s: got `%s`, expected `%s`", key, got, expect)
+		}
+	}
+
+	got := lookup.Resolve([]environment.Variable{environment.NewVariable("AUTH_FAKE", "false", "")})
+	if got[0].Source != environment.SourceProfile {
+		t.Errorf("AUTH_FAKE has source %s, expected %s", got[0].Source, environment.SourceProfile)
+	}
+}
+
+func TestProfileInvalid(t *testing.T) {
+	t.Setenv("OPENSLIDES_PROFILE", "testing")
+
+	if _, err := environment.NewForProduction(""); err == nil {
+		t.Errorf("NewForProduction with an unknown profile did not return an error")
+	}
+}
+
+func TestProfileProductionDevelopment(t *testing.T) {
+	t.Setenv("OPENSLIDES_PROFILE", "production")
+	t.Setenv("OPENSLIDES_DEVELOPMENT", "true")
+
+	if _, err := environment.NewForProduction(""); err == nil {
+		t.Errorf("NewForProduction with development mode in production did not return an error")
+	}
+}
@@ -0,0 +1,83 @@
+package environment
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of a variable in the schema.
+const (
+	TypeString   = "string"
+	TypeBool     = "bool"
+	TypeInt      = "int"
+	TypeNumber   = "number"
+	TypeDuration = "duration"
+	TypeList     = "list"
+	TypePath     = "path"
+)
+
+// SchemaVariable describes a variable for deployment tooling.
+type SchemaVariable struct {
+	Key         string   `json:"key"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default"`
+	Description string   `json:"description"`
+	Deprecated  []string `json:"deprecated,omitempty"`
+}
+
+// Schema returns all variables from Defined with their type.
+//
+// The type is derived from the name, the default value and the description,
+// since the variables do not declare it.
+func Schema() []SchemaVariable {
+	variables := Defined()
+	schema := make([]SchemaVariable, len(variables))
+	for i, v := range variables {
+		schema[i] = SchemaVariable{
+			Key:         v.Key,
+			Type:        typeOf(v),
+			Default:     v.Default,
+			Description: v.Description,
+			Deprecated:  aliasesOf(v.Key),
+		}
+	}
+	return schema
+}
+
+func typeOf(v Variable) string {
+	if strings.HasSuffix(v.Key, "_FILE") || strings.HasSuffix(v.Key, "_DIR") || strings.HasSuffix(v.Key, "_SOCKET") {
+		return TypePath
+	}
+
+	if strings.HasPrefix(v.Description, "Comma separated") {
+		return TypeList
+	}
+
+	switch v.Default {
+	case "true", "false":
+		return TypeBool
+	}
+
+	if strings.HasSuffix(v.Key, "_RATIO") || strings.HasSuffix(v.Key, "_SAMPLE_RATE") {
+		return TypeNumber
+	}
+
+	if _, err := strconv.Atoi(v.Default); err == nil {
+		if strings.HasPrefix(v.Description, "Time") || strings.HasPrefix(v.Description, "Interval") {
+			// ParseDuration uses seconds for numbers.
+			return TypeDuration
+		}
+		return TypeInt
+	}
+
+	if _, err := time.ParseDuration(v.Default); err == nil {
+		return TypeDuration
+	}
+
+	if _, err := strconv.ParseFloat(v.Default, 64); err == nil {
+		return TypeNumber
+	}
+
+	return TypeString
+}
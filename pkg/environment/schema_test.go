@@ -0,0 +1,51 @@
+package environment_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestSchema(t *testing.T) {
+	environment.NewVariable("TEST_SCHEMA_ENABLED", "false", "")
+	environment.NewVariable("TEST_SCHEMA_TIMEOUT", "30s", "")
+	environment.NewVariable("TEST_SCHEMA_INTERVAL", "10", "Interval, how often something happens.")
+	environment.NewVariable("TEST_SCHEMA_PORT", "9012", "")
+	environment.NewVariable("TEST_SCHEMA_RATIO", "1", "")
+	environment.NewVariable("TEST_SCHEMA_ROUTES", "", "Comma separated list of routes.")
+	environment.NewVariable("TEST_SCHEMA_KEY_FILE", "/run/secrets/key", "")
+	environment.NewVariable("TEST_SCHEMA_HOST", "localhost", "").Alias("TEST_SCHEMA_OLD_HOST")
+
+	expect := map[string]string{
+		"TEST_SCHEMA_ENABLED":  environment.TypeBool,
+		"TEST_SCHEMA_TIMEOUT":  environment.TypeDuration,
+		"TEST_SCHEMA_INTERVAL": environment.TypeDuration,
+		"TEST_SCHEMA_PORT":     environment.TypeInt,
+		"TEST_SCHEMA_RATIO":    environment.TypeNumber,
+		"TEST_SCHEMA_ROUTES":   environment.TypeList,
+		"TEST_SCHEMA_KEY_FILE": environment.TypePath,
+		"TEST_SCHEMA_HOST":     environment.TypeString,
+	}
+
+	found := 0
+	for _, v := range environment.Schema() {
+		typ, ok := expect[v.Key]
+		if !ok {
+			continue
+		}
+		found++
+
+		if v.Type != typ {
+			t.Errorf("%s has type %s, expected %s", v.Key, v.Type, typ)
+		}
+
+		if v.Key == "TEST_SCHEMA_HOST" && !slices.Equal(v.Deprecated, []string{"TEST_SCHEMA_OLD_HOST"}) {
+			t.Errorf("TEST_SCHEMA_HOST has deprecated names %v, expected [TEST_SCHEMA_OLD_HOST]", v.Deprecated)
+		}
+	}
+
+	if found != len(expect) {
+		t.Errorf("found %d of %d variables in the schema", found, len(expect))
+	}
+}
@@ -0,0 +1,215 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	envVaultAddr      = NewVariable("VAULT_ADDR", "", "Address of a HashiCorp Vault server, for example `https://vault:8200`. If set, secrets are read from Vault before the files in /run/secrets are used. Empty disables Vault.")
+	envVaultTokenFile = NewVariable("VAULT_TOKEN_FILE", "/run/secrets/vault_token", "Path to the file with the Vault token. The token is renewed, before it expires.")
+	envVaultPath      = NewVariable("VAULT_SECRET_PATH", "secret/data/openslides", "API path of the Vault secret with the values. The keys are the names of the secret files, for example `auth_token_key` or `postgres_password`.")
+)
+
+// vaultTimeout is the timeout of one request to Vault.
+const vaultTimeout = 10 * time.Second
+
+// vault is the secret source, when VAULT_ADDR is set.
+var vault struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// NewVault reads the secrets from HashiCorp Vault, if VAULT_ADDR is set.
+//
+// Afterwards, ReadSecret uses the values from Vault. The key of a value is the
+// file name of the secret, for example `auth_token_key` for
+// /run/secrets/auth_token_key. Secrets, that are not in Vault, are read from
+// the file.
+//
+// The returned function renews the Vault token. It has to be run in the
+// background.
+func NewVault(lookup Environmenter) (func(context.Context, func(error)), error) {
+	addr := envVaultAddr.Value(lookup)
+	tokenFile := envVaultTokenFile.Value(lookup)
+	path := envVaultPath.Value(lookup)
+
+	if addr == "" {
+		vault.mu.Lock()
+		vault.values = nil
+		vault.mu.Unlock()
+		return func(context.Context, func(error)) {}, nil
+	}
+
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("read vault token from %s: %w", tokenFile, err)
+	}
+
+	client := vaultClient{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  strings.TrimSpace(string(token)),
+		client: &http.Client{Timeout: vaultTimeout},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vaultTimeout)
+	defer cancel()
+
+	values, err := client.secret(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("read secret %s from vault: %w", path, err)
+	}
+
+	ttl, renewable, err := client.lookupSelf(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lookup vault token: %w", err)
+	}
+
+	vault.mu.Lock()
+	vault.values = values
+	vault.mu.Unlock()
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		if !renewable || ttl <= 0 {
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(ttl / 2):
+			}
+
+			newTTL, err := client.renewSelf(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				errorHandler(fmt.Errorf("renew vault token: %w", err))
+
+				// Try again before the token expires.
+				ttl /= 2
+				if ttl < time.Second {
+					ttl = time.Second
+				}
+				continue
+			}
+			ttl = newTTL
+		}
+	}
+
+	return background, nil
+}
+
+// vaultSecret returns the value of a secret from Vault.
+func vaultSecret(path string) (string, bool) {
+	vault.mu.Lock()
+	defer vault.mu.Unlock()
+
+	value, ok := vault.values[filepath.Base(path)]
+	return value, ok
+}
+
+// vaultClient uses the HTTP API of Vault.
+type vaultClient struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// secret reads the values of a secret. It supports the key value engine in
+// version 1 and 2.
+func (c vaultClient) secret(ctx context.Context, path string) (map[string]string, error) {
+	var response struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := c.request(ctx, "GET", path, &response); err != nil {
+		return nil, err
+	}
+
+	data := response.Data
+	if nested, ok := data["data"]; ok {
+		// Version 2 of the key value engine wraps the values.
+		data = nil
+		if err := json.Unmarshal(nested, &data); err != nil {
+			return nil, fmt.Errorf("decoding values: %w", err)
+		}
+	}
+
+	values := make(map[string]string, len(data))
+	for key, raw := range data {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("value of %s: expected a string: %w", key, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// lookupSelf returns the time to live of the token and if it can be renewed.
+func (c vaultClient) lookupSelf(ctx context.Context) (time.Duration, bool, error) {
+	var response struct {
+		Data struct {
+			TTL       int  `json:"ttl"`
+			Renewable bool `json:"renewable"`
+		} `json:"data"`
+	}
+	if err := c.request(ctx, "GET", "auth/token/lookup-self", &response); err != nil {
+		return 0, false, err
+	}
+
+	return time.Duration(response.Data.TTL) * time.Second, response.Data.Renewable, nil
+}
+
+// renewSelf renews the token and returns its new time to live.
+func (c vaultClient) renewSelf(ctx context.Context) (time.Duration, error) {
+	var response struct {
+		Auth struct {
+			LeaseDuration int `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := c.request(ctx, "POST", "auth/token/renew-self", &response); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(response.Auth.LeaseDuration) * time.Second, nil
+}
+
+func (c vaultClient) request(ctx context.Context, method string, path string, v any) error {
+	var body io.Reader
+	if method == "POST" {
+		body = strings.NewReader("{}")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+"/v1/"+strings.TrimPrefix(path, "/"), body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault returned status %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
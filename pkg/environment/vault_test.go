@@ -0,0 +1,110 @@
+package environment_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+)
+
+func TestVault(t *testing.T) {
+	renewed := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "my-token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/openslides":
+			w.Write([]byte(`{"data":{"data":{"auth_token_key":"from-vault"},"metadata":{"version":1}}}`))
+		case "/v1/auth/token/lookup-self":
+			w.Write([]byte(`{"data":{"ttl":2,"renewable":true}}`))
+		case "/v1/auth/token/renew-self":
+			select {
+			case renewed <- struct{}{}:
+			default:
+			}
+			w.Write([]byte(`{"auth":{"lease_duration":2}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "vault_token")
+	if err := os.WriteFile(tokenFile, []byte("my-token\n"), 0o600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+
+	otherFile := filepath.Join(dir, "auth_cookie_key")
+	if err := os.WriteFile(otherFile, []byte("from-file"), 0o600); err != nil {
+		t.Fatalf("writing secret file: %v", err)
+	}
+
+	lookup := environment.ForTests{
+		"OPENSLIDES_DEVELOPMENT": "false",
+		"VAULT_ADDR":             server.URL,
+		"VAULT_TOKEN_FILE":       tokenFile,
+		"AUTH_TOKEN_KEY_FILE":    "/run/secrets/auth_token_key",
+		"AUTH_COOKIE_KEY_FILE":   otherFile,
+	}
+
+	background, err := environment.NewVault(lookup)
+	if err != nil {
+		t.Fatalf("NewVault: %v", err)
+	}
+	t.Cleanup(func() { environment.NewVault(environment.ForTests{}) })
+
+	tokenKey, err := environment.ReadSecret(lookup, environment.NewVariable("AUTH_TOKEN_KEY_FILE", "", ""))
+	if err != nil {
+		t.Fatalf("ReadSecret from vault: %v", err)
+	}
+	if tokenKey != "from-vault" {
+		t.Errorf("got secret `%s`, expected `from-vault`", tokenKey)
+	}
+
+	cookieKey, err := environment.ReadSecret(lookup, environment.NewVariable("AUTH_COOKIE_KEY_FILE", "", ""))
+	if err != nil {
+		t.Fatalf("ReadSecret from file: %v", err)
+	}
+	if cookieKey != "from-file" {
+		t.Errorf("got secret `%s`, expected `from-file`", cookieKey)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go background(ctx, func(err error) { t.Errorf("background: %v", err) })
+
+	select {
+	case <-renewed:
+	case <-time.After(3 * time.Second):
+		t.Errorf("token was not renewed")
+	}
+}
+
+func TestVaultInvalidToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "vault_token")
+	if err := os.WriteFile(tokenFile, []byte("wrong"), 0o600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+
+	_, err := environment.NewVault(environment.ForTests{
+		"VAULT_ADDR":       server.URL,
+		"VAULT_TOKEN_FILE": tokenFile,
+	})
+	if err == nil {
+		t.Errorf("NewVault with an invalid token did not return an error")
+	}
+}
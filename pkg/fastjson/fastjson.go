@@ -15,28 +15,33 @@ func DecodeInt(bs []byte) (int, error) {
 
 // DecodeIntList decodes a json List[int] value to an []int type.
 func DecodeIntList(bs []byte) ([]int, error) {
+	return AppendIntList(make([]int, 0, bytes.Count(bs, []byte(","))+1), bs)
+}
+
+// AppendIntList decodes a json List[int] value and appends the numbers to
+// dst.
+func AppendIntList(dst []int, bs []byte) ([]int, error) {
 	// Remove [ and ]
 	if len(bs) < 2 {
 		return nil, fmt.Errorf("invalid int list: %s", bs)
 	}
 	bs = bs[1 : len(bs)-1]
 
-	numbers := bytes.Split(bs, []byte(","))
+	for i := 0; ; i++ {
+		n, rest, more := bytes.Cut(bs, []byte(","))
+		bs = rest
 
-	out := make([]int, 0, len(numbers))
-	for i, n := range numbers {
 		n = bytes.TrimSpace(n)
-		if len(n) == 0 {
-			continue
+		if len(n) > 0 {
+			v, err := DecodeInt(n)
+			if err != nil {
+				return nil, fmt.Errorf("%dth value, `%s`,  is not a number: %w", i, n, err)
+			}
+			dst = append(dst, v)
 		}
 
-		v, err := DecodeInt(n)
-		if err != nil {
-			return nil, fmt.Errorf("%dth value, `%s`,  is not a number: %w", i, n, err)
+		if !more {
+			return dst, nil
 		}
-
-		out = append(out, v)
 	}
-
-	return out, nil
 }
@@ -0,0 +1,28 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package fastjson
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+)
+
+// Backend is the name of the json implementation, that is used by Unmarshal.
+const Backend = "encoding/json/v2"
+
+// unmarshalOptions keep the behavior of encoding/json, where the datastore
+// values depend on it.
+var unmarshalOptions = json.JoinOptions(
+	json.MatchCaseInsensitiveNames(true),
+	jsontext.AllowDuplicateNames(true),
+	jsontext.AllowInvalidUTF8(true),
+)
+
+// Unmarshal decodes a json value from the datastore into v.
+//
+// It uses encoding/json/v2, when the service is build with go 1.27 or later
+// and the jsonv2 experiment is not disabled. Otherwise, it is the same as
+// json.Unmarshal.
+func Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v, unmarshalOptions)
+}
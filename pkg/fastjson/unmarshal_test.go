@@ -0,0 +1,66 @@
+package fastjson_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/fastjson"
+)
+
+func TestUnmarshalLikeEncodingJSON(t *testing.T) {
+	type element struct {
+		ID     int    `json:"id"`
+		Name   string `json:"name"`
+		Hidden bool
+	}
+
+	for _, tt := range []struct {
+		name  string
+		value string
+		new   func() any
+	}{
+		{"bool", `true`, func() any { return new(bool) }},
+		{"string", `"hello ä"`, func() any { return new(string) }},
+		{"float", `1.5`, func() any { return new(float32) }},
+		{"string list", `["a","b"]`, func() any { return new([]string) }},
+		{"empty list", `[]`, func() any { return new([]string) }},
+		{"null list", `null`, func() any { return new([]string) }},
+		{"raw message", `{"a": [1, 2]}`, func() any { return new(json.RawMessage) }},
+		{"raw map", `{"a":1,"b":"x"}`, func() any { return new(map[string]json.RawMessage) }},
+		{"struct", `{"id":1,"name":"n","hidden":true}`, func() any { return new(element) }},
+		{"duplicate names", `{"id":1,"id":2}`, func() any { return new(element) }},
+		{"unknown field", `{"id":1,"other":2}`, func() any { return new(element) }},
+		{"wrong type", `"1"`, func() any { return new(int) }},
+		{"invalid", `{"id":`, func() any { return new(element) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := tt.new()
+			expectedErr := json.Unmarshal([]byte(tt.value), expected)
+
+			got := tt.new()
+			err := fastjson.Unmarshal([]byte(tt.value), got)
+
+			if (err != nil) != (expectedErr != nil) {
+				t.Fatalf("%s returned error `%v`, encoding/json returned `%v`", fastjson.Backend, err, expectedErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s decoded %v, encoding/json decoded %v", fastjson.Backend, got, expected)
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshalStringList(b *testing.B) {
+	value := []byte(`["first","second","third","fourth","fifth","sixth","seventh","eighth"]`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var got []string
+		if err := fastjson.Unmarshal(value, &got); err != nil {
+			b.Fatalf("unmarshal: %v", err)
+		}
+	}
+}
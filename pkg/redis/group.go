@@ -0,0 +1,422 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// groupConsumerName is the name of the only consumer in each group.
+	groupConsumerName = "autoupdate"
+
+	// staleGroupAge is the time, after that the group of an instance, that
+	// does not read anymore, is removed.
+	staleGroupAge = time.Hour
+
+	// groupBlock is the time, XREADGROUP blocks, if the check interval is
+	// zero. The group has to be used regularly, so it is not removed as stale.
+	groupBlock = time.Minute
+)
+
+// groupStreams are the streams, that are read with the consumer group.
+var groupStreams = []string{fieldChangedTopic, resyncTopic}
+
+// errNoGroup is returned, when the consumer group does not exist anymore.
+var errNoGroup = errors.New("consumer group does not exist")
+
+// groupName returns the name of the consumer group of this instance.
+func (r *Redis) groupName() string {
+	return r.consumerGroup + ":" + r.replicas.instanceID
+}
+
+// updateWithGroup is like Update but reads the streams with a consumer group.
+//
+// Each instance has its own group. Messages are acknowledged after updateFn
+// returned. Messages, that were read but not acknowledged, are read again
+// before new messages. If the group was lost, for example because redis was
+// restarted without persistence, updateFn is called with flow.ErrResync.
+func (r *Redis) updateWithGroup(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
+	defer r.destroyGroups()
+
+	id := "$"
+	created := false
+	readPending := false
+
+	var lastCheck time.Time
+	for ctx.Err() == nil {
+		if r.checkInterval > 0 && clock.Since(lastCheck) >= r.checkInterval {
+			lastCheck = clock.Now()
+
+			var err error
+			id, err = r.checkPosition(ctx, id)
+			if err != nil {
+				updateFn(nil, fmt.Errorf("checking position: %w", err))
+			}
+
+			if err := r.shareReplicaPosition(ctx); err != nil {
+				updateFn(nil, fmt.Errorf("sharing position: %w", err))
+			}
+		}
+
+		if !created {
+			if err := r.createGroups(ctx); err != nil {
+				updateFn(nil, fmt.Errorf("creating consumer group: %w", err))
+				clock.Sleep(ctx, 5*time.Second)
+				continue
+			}
+			created = true
+			readPending = false
+
+			if err := r.removeStaleGroups(ctx); err != nil {
+				updateFn(nil, fmt.Errorf("removing stale consumer groups: %w", err))
+			}
+		}
+
+		result, err := r.groupUpdate(ctx, readPending)
+		if err != nil {
+			if errors.Is(err, errNoGroup) {
+				created = false
+				err = fmt.Errorf("%w: %w", err, flow.ErrResync)
+			}
+
+			updateFn(nil, err)
+			readPending = true
+			clock.Sleep(ctx, 5*time.Second)
+			continue
+		}
+
+		updateFn(result.data, nil)
+		if err := resyncError(result.reasons); err != nil {
+			updateFn(nil, err)
+		}
+
+		if result.deleted > 0 {
+			updateFn(nil, fmt.Errorf("%d messages were deleted before they were acknowledged: %w", result.deleted, flow.ErrResync))
+		}
+
+		if err := r.ack(ctx, result.ids); err != nil {
+			updateFn(nil, fmt.Errorf("acknowledging messages: %w", err))
+			readPending = true
+			continue
+		}
+
+		if readPending && len(result.ids[fieldChangedTopic])+len(result.ids[resyncTopic]) == 0 {
+			readPending = false
+		}
+
+		if newer(id, result.lastID) {
+			id = result.lastID
+		}
+	}
+}
+
+// newer returns true, if next is a stream id after id. Pending messages can be
+// older then the last read message.
+func newer(id, next string) bool {
+	if next == "" {
+		return false
+	}
+
+	current, err := parseStreamID(id)
+	if err != nil {
+		// id is `$`.
+		return true
+	}
+
+	nextID, err := parseStreamID(next)
+	if err != nil {
+		return false
+	}
+	return current.less(nextID)
+}
+
+// groupResult is the result of one XREADGROUP.
+type groupResult struct {
+	lastID  string
+	data    map[dskey.Key][]byte
+	reasons []string
+
+	// ids are the ids of the read messages for each stream.
+	ids map[string][]string
+
+	// deleted is the number of pending messages, that were deleted from the
+	// stream.
+	deleted int
+}
+
+// groupUpdate reads the next messages with the consumer group. If pending is
+// true, the messages, that were read before but not acknowledged, are read.
+func (r *Redis) groupUpdate(ctx context.Context, pending bool) (groupResult, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	startID := ">"
+	if pending {
+		startID = "0"
+	}
+
+	block := r.checkInterval
+	if block == 0 {
+		block = groupBlock
+	}
+
+	args := []any{"GROUP", r.groupName(), groupConsumerName, "COUNT", maxMessages, "BLOCK", block.Milliseconds(), "STREAMS"}
+	for _, stream := range groupStreams {
+		args = append(args, stream)
+	}
+	for range groupStreams {
+		args = append(args, startID)
+	}
+
+	reply, err := redis.DoContext(conn, ctx, "XREADGROUP", args...)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "NOGROUP") {
+			return groupResult{}, errNoGroup
+		}
+		return groupResult{}, fmt.Errorf("redis `XREADGROUP GROUP %s`: %w", r.groupName(), err)
+	}
+
+	result := groupResult{ids: make(map[string][]string)}
+	if reply == nil {
+		// This happens, when the redis command times out.
+		return result, nil
+	}
+
+	for _, stream := range groupStreams {
+		ids, deleted, err := streamEntryIDs(reply, stream)
+		if err != nil && !errors.Is(err, errStreamNotFound) {
+			return groupResult{}, fmt.Errorf("parsing ids of %s: %w", stream, err)
+		}
+		result.ids[stream] = ids
+		result.deleted += deleted
+	}
+
+	if len(result.ids[fieldChangedTopic]) > 0 {
+		result.lastID, result.data, err = parseMessageBus(reply)
+		if err != nil {
+			return groupResult{}, fmt.Errorf("parsing message bus: %w", err)
+		}
+	}
+
+	if len(result.ids[resyncTopic]) > 0 {
+		_, result.reasons, err = resyncStream(reply)
+		if err != nil {
+			return groupResult{}, fmt.Errorf("parsing resync stream: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// ack acknowledges the messages of each stream.
+func (r *Redis) ack(ctx context.Context, ids map[string][]string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	for stream, streamIDs := range ids {
+		if len(streamIDs) == 0 {
+			continue
+		}
+
+		args := []any{stream, r.groupName()}
+		for _, id := range streamIDs {
+			args = append(args, id)
+		}
+
+		if _, err := redis.DoContext(conn, ctx, "XACK", args...); err != nil {
+			return fmt.Errorf("redis `XACK %s %s`: %w", stream, r.groupName(), err)
+		}
+	}
+	return nil
+}
+
+// createGroups creates the consumer group of this instance on each stream. The
+// group starts with the newest message, because the cache is empty.
+//
+// The consumer is created with the group. Otherwise it would only exist after
+// the first XREADGROUP and the group would have no idle time.
+func (r *Redis) createGroups(ctx context.Context) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	for _, stream := range groupStreams {
+		_, err := redis.DoContext(conn, ctx, "XGROUP", "CREATE", stream, r.groupName(), "$", "MKSTREAM")
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("redis `XGROUP CREATE %s %s`: %w", stream, r.groupName(), err)
+		}
+
+		if _, err := redis.DoContext(conn, ctx, "XGROUP", "CREATECONSUMER", stream, r.groupName(), groupConsumerName); err != nil {
+			return fmt.Errorf("redis `XGROUP CREATECONSUMER %s %s`: %w", stream, r.groupName(), err)
+		}
+	}
+	return nil
+}
+
+// destroyGroups removes the consumer groups of this instance, when it stops.
+func (r *Redis) destroyGroups() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	for _, stream := range groupStreams {
+		redis.DoContext(conn, ctx, "XGROUP", "DESTROY", stream, r.groupName())
+	}
+}
+
+// removeStaleGroups removes the groups of other instances, that did not read
+// for staleGroupAge. This happens, when an instance was stopped without
+// removing its groups.
+func (r *Redis) removeStaleGroups(ctx context.Context) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	for _, stream := range groupStreams {
+		groups, err := redis.Values(redis.DoContext(conn, ctx, "XINFO", "GROUPS", stream))
+		if err != nil {
+			return fmt.Errorf("redis `XINFO GROUPS %s`: %w", stream, err)
+		}
+
+		for _, group := range groups {
+			name, err := infoString(group, "name")
+			if err != nil {
+				return fmt.Errorf("parsing group: %w", err)
+			}
+
+			if !strings.HasPrefix(name, r.consumerGroup+":") || name == r.groupName() {
+				continue
+			}
+
+			consumers, err := redis.Values(redis.DoContext(conn, ctx, "XINFO", "CONSUMERS", stream, name))
+			if err != nil {
+				return fmt.Errorf("redis `XINFO CONSUMERS %s %s`: %w", stream, name, err)
+			}
+
+			stale, err := staleGroup(consumers)
+			if err != nil {
+				return fmt.Errorf("parsing consumers of %s: %w", name, err)
+			}
+
+			if !stale {
+				continue
+			}
+
+			if _, err := redis.DoContext(conn, ctx, "XGROUP", "DESTROY", stream, name); err != nil {
+				return fmt.Errorf("redis `XGROUP DESTROY %s %s`: %w", stream, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// staleGroup returns true, if no consumer of the group was used for
+// staleGroupAge.
+//
+// A group without consumers is not stale. Another instance could have created
+// it right now and not have created its consumer yet.
+func staleGroup(consumers []any) (bool, error) {
+	if len(consumers) == 0 {
+		return false, nil
+	}
+
+	for _, consumer := range consumers {
+		idle, err := infoString(consumer, "idle")
+		if err != nil {
+			return false, err
+		}
+
+		ms, err := strconv.ParseInt(idle, 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid idle time `%s`", idle)
+		}
+
+		if time.Duration(ms)*time.Millisecond < staleGroupAge {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// infoString returns a value from the reply of XINFO. The reply is a list of
+// names and values.
+func infoString(reply any, field string) (string, error) {
+	values, err := redis.Values(reply, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i+1 < len(values); i += 2 {
+		name, err := redis.String(values[i], nil)
+		if err != nil || name != field {
+			continue
+		}
+
+		switch v := values[i+1].(type) {
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		default:
+			return redis.String(v, nil)
+		}
+	}
+	return "", fmt.Errorf("field %s not found", field)
+}
+
+// streamEntryIDs returns the ids of the messages of one stream from a
+// XREADGROUP reply.
+//
+// Pending messages, that were deleted from the stream, have no fields. They
+// are counted as deleted and are also returned, so they can be acknowledged.
+func streamEntryIDs(reply any, stream string) ([]string, int, error) {
+	streams, err := redis.Values(reply, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parsing reply: %w", err)
+	}
+
+	for _, s := range streams {
+		nameEntries, ok := s.([]any)
+		if !ok || len(nameEntries) != 2 {
+			return nil, 0, errors.New("stream entry expects two value result")
+		}
+
+		name, err := redis.String(nameEntries[0], nil)
+		if err != nil || name != stream {
+			continue
+		}
+
+		entries, err := redis.Values(nameEntries[1], nil)
+		if err != nil {
+			return nil, 0, fmt.Errorf("parsing entries: %w", err)
+		}
+
+		ids := make([]string, 0, len(entries))
+		deleted := 0
+		for i, entry := range entries {
+			idFields, ok := entry.([]any)
+			if !ok || len(idFields) != 2 {
+				return nil, 0, fmt.Errorf("invalid stream value %d, got %v", i, entry)
+			}
+
+			id, err := redis.String(idFields[0], nil)
+			if err != nil {
+				return nil, 0, fmt.Errorf("parsing id from entry %d: %w", i, err)
+			}
+
+			ids = append(ids, id)
+			if idFields[1] == nil {
+				deleted++
+			}
+		}
+		return ids, deleted, nil
+	}
+	return nil, 0, errStreamNotFound
+}
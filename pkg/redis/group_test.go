@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStreamEntryIDs(t *testing.T) {
+	var data any
+	err := json.Unmarshal([]byte(`
+	[
+		[
+			"ModifiedFields",
+			[
+				["12345-0", ["user/1/username", "Helga"]],
+				["12346-0", null]
+			]
+		],
+		[
+			"autoupdate_resync",
+			[]
+		]
+	]`), &data)
+	if err != nil {
+		t.Fatalf("Data is invalid json: %v", err)
+	}
+
+	ids, deleted, err := streamEntryIDs(data, fieldChangedTopic)
+	if err != nil {
+		t.Fatalf("streamEntryIDs: %v", err)
+	}
+
+	if !reflect.DeepEqual(ids, []string{"12345-0", "12346-0"}) || deleted != 1 {
+		t.Errorf("got ids %v and %d deleted, expected two ids and one deleted", ids, deleted)
+	}
+
+	ids, deleted, err = streamEntryIDs(data, resyncTopic)
+	if err != nil || len(ids) != 0 || deleted != 0 {
+		t.Errorf("resync stream: got %v, %d, %v, expected no ids", ids, deleted, err)
+	}
+
+	lastID, got, err := parseMessageBus(data)
+	if err != nil {
+		t.Fatalf("parseMessageBus: %v", err)
+	}
+
+	if lastID != "12346-0" || len(got) != 1 {
+		t.Errorf("parseMessageBus returned %s and %v, expected the last id and one value", lastID, got)
+	}
+}
+
+func TestStaleGroup(t *testing.T) {
+	consumer := func(idle int64) any {
+		return []any{"name", "autoupdate", "pending", int64(0), "idle", idle}
+	}
+
+	for _, tt := range []struct {
+		name      string
+		consumers []any
+		expect    bool
+	}{
+		{"no consumer", nil, false},
+		{"active", []any{consumer(1000)}, false},
+		{"idle", []any{consumer(2 * staleGroupAge.Milliseconds())}, true},
+		{"one active", []any{consumer(2 * staleGroupAge.Milliseconds()), consumer(10)}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := staleGroup(tt.consumers)
+			if err != nil {
+				t.Fatalf("staleGroup: %v", err)
+			}
+
+			if got != tt.expect {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+
+	if _, err := staleGroup([]any{[]any{"name", "autoupdate"}}); err == nil {
+		t.Errorf("consumer without idle time returned no error")
+	}
+}
+
+func TestNewer(t *testing.T) {
+	for _, tt := range []struct {
+		id     string
+		next   string
+		expect bool
+	}{
+		{"$", "5-0", true},
+		{"5-0", "6-0", true},
+		{"5-1", "5-0", false},
+		{"5-0", "", false},
+	} {
+		if got := newer(tt.id, tt.next); got != tt.expect {
+			t.Errorf("newer(%s, %s) = %v, expected %v", tt.id, tt.next, got, tt.expect)
+		}
+	}
+}
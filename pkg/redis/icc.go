@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// notifyTopic is the redis key name of the stream with the notify
+	// messages of the inter client communication.
+	notifyTopic = "icc_notify"
+
+	// notifyMaxLen is the number of notify messages, that are kept in the
+	// stream.
+	notifyMaxLen = 1000
+
+	// applauseKeyPrefix is the prefix of the sorted sets with the applause of
+	// each meeting.
+	applauseKeyPrefix = "icc_applause_"
+
+	// applauseKeep is the time, the applause of a user is kept.
+	applauseKeep = time.Hour
+)
+
+// SendNotify adds a notify message to the stream. Each instance reads it with
+// ReceiveNotify.
+func (r *Redis) SendNotify(ctx context.Context, message []byte) error {
+	return r.AddToStream(ctx, notifyTopic, notifyMaxLen, "message", message)
+}
+
+// ReceiveNotify blocks until there are notify messages after the id. An empty
+// id means the current time.
+//
+// Returns the id of the last message, that has to be used for the next call.
+func (r *Redis) ReceiveNotify(ctx context.Context, id string) (string, [][]byte, error) {
+	if id == "" {
+		id = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, "XREAD", "COUNT", maxMessages, "BLOCK", "0", "STREAMS", notifyTopic, id)
+	if err != nil {
+		return id, nil, fmt.Errorf("redis `XREAD %s`: %w", notifyTopic, err)
+	}
+
+	var messages [][]byte
+	lastID, err := onlyStream(reply, notifyTopic, func(k, v []byte) {
+		if string(k) != "message" {
+			return
+		}
+		messages = append(messages, v)
+	})
+	if err != nil {
+		return id, nil, fmt.Errorf("parsing notify stream: %w", err)
+	}
+
+	return lastID, messages, nil
+}
+
+// SendApplause saves the applause of a user in a meeting. The older applause of
+// the user is replaced.
+func (r *Redis) SendApplause(ctx context.Context, meetingID int, userID int, t time.Time) error {
+	key := applauseKeyPrefix + strconv.Itoa(meetingID)
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	if _, err := redis.DoContext(conn, ctx, "ZADD", key, t.UnixMilli(), userID); err != nil {
+		return fmt.Errorf("redis `ZADD %s`: %w", key, err)
+	}
+
+	if _, err := redis.DoContext(conn, ctx, "ZREMRANGEBYSCORE", key, "-inf", t.Add(-applauseKeep).UnixMilli()); err != nil {
+		return fmt.Errorf("redis `ZREMRANGEBYSCORE %s`: %w", key, err)
+	}
+
+	if _, err := redis.DoContext(conn, ctx, "EXPIRE", key, int(applauseKeep/time.Second)); err != nil {
+		return fmt.Errorf("redis `EXPIRE %s`: %w", key, err)
+	}
+	return nil
+}
+
+// ApplauseSince returns the number of users, that applauded in the meeting
+// since the given time.
+func (r *Redis) ApplauseSince(ctx context.Context, meetingID int, since time.Time) (int, error) {
+	key := applauseKeyPrefix + strconv.Itoa(meetingID)
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	count, err := redis.Int(redis.DoContext(conn, ctx, "ZCOUNT", key, since.UnixMilli(), "+inf"))
+	if err != nil {
+		return 0, fmt.Errorf("redis `ZCOUNT %s`: %w", key, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// forcedLogoutTopic is the redis key name of the stream with the forced
+	// logouts.
+	forcedLogoutTopic = "autoupdate_logout"
+
+	// forcedLogoutMaxLen is the number of forced logouts, that are kept in the
+	// stream.
+	forcedLogoutMaxLen = 1000
+
+	// forcedLogoutReportPrefix is the prefix of the hashes with the number of
+	// closed connections of each instance.
+	forcedLogoutReportPrefix = "autoupdate_logout_report_"
+
+	// forcedLogoutReportTTL is the time, the reports of a forced logout are
+	// kept.
+	forcedLogoutReportTTL = 10 * time.Minute
+)
+
+// SendForcedLogout adds a forced logout to the stream. Each instance reads it
+// with ReceiveForcedLogout.
+func (r *Redis) SendForcedLogout(ctx context.Context, message []byte) error {
+	return r.AddToStream(ctx, forcedLogoutTopic, forcedLogoutMaxLen, "message", message)
+}
+
+// ReceiveForcedLogout blocks until there are forced logouts after the id. An
+// empty id means the current time.
+//
+// Returns the id of the last message, that has to be used for the next call.
+func (r *Redis) ReceiveForcedLogout(ctx context.Context, id string) (string, [][]byte, error) {
+	if id == "" {
+		id = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, "XREAD", "COUNT", maxMessages, "BLOCK", "0", "STREAMS", forcedLogoutTopic, id)
+	if err != nil {
+		return id, nil, fmt.Errorf("redis `XREAD %s`: %w", forcedLogoutTopic, err)
+	}
+
+	var messages [][]byte
+	lastID, err := onlyStream(reply, forcedLogoutTopic, func(k, v []byte) {
+		if string(k) != "message" {
+			return
+		}
+		messages = append(messages, v)
+	})
+	if err != nil {
+		return id, nil, fmt.Errorf("parsing forced logout stream: %w", err)
+	}
+
+	return lastID, messages, nil
+}
+
+// ReportForcedLogout saves the number of connections, this instance closed
+// for a forced logout.
+func (r *Redis) ReportForcedLogout(ctx context.Context, requestID string, closed int) error {
+	key := forcedLogoutReportPrefix + requestID
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	if _, err := redis.DoContext(conn, ctx, "HSET", key, r.replicas.instanceID, closed); err != nil {
+		return fmt.Errorf("redis `HSET %s`: %w", key, err)
+	}
+
+	if _, err := redis.DoContext(conn, ctx, "EXPIRE", key, int(forcedLogoutReportTTL/time.Second)); err != nil {
+		return fmt.Errorf("redis `EXPIRE %s`: %w", key, err)
+	}
+	return nil
+}
+
+// ForcedLogoutReports returns the number of closed connections of each
+// instance, that handled the forced logout.
+func (r *Redis) ForcedLogoutReports(ctx context.Context, requestID string) (map[string]int, error) {
+	key := forcedLogoutReportPrefix + requestID
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	reports, err := redis.IntMap(redis.DoContext(conn, ctx, "HGETALL", key))
+	if err != nil {
+		return nil, fmt.Errorf("redis `HGETALL %s`: %w", key, err)
+	}
+	return reports, nil
+}
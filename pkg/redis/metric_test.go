@@ -20,7 +20,10 @@ func TestMetric(t *testing.T) {
 	tr := newTestRedis(t)
 	defer tr.Close()
 
-	r := redis.New(environment.ForTests(tr.Env))
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
 	r.Wait(ctx)
 
 	t.Run("Save value", func(t *testing.T) {
@@ -0,0 +1,217 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/internal/metric"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Position is the state of the autoupdate stream.
+type Position struct {
+	// Consumed is the id of the last message, that was read.
+	Consumed string
+
+	// Latest is the id of the last message in the stream.
+	Latest string
+
+	// Lag is the time between the last read message and the latest message.
+	Lag time.Duration
+
+	// Gaps is the number of times, messages were lost.
+	Gaps int
+
+	// Newest is the id of the last message, that was read by any instance.
+	Newest string
+
+	// ReplicaLag is the time between the last read message and the newest
+	// message, that was read by any instance.
+	ReplicaLag time.Duration
+}
+
+// position holds the Position of a Redis instance.
+type position struct {
+	mu    sync.Mutex
+	state Position
+}
+
+// Position returns the state of the autoupdate stream.
+//
+// The values are updated every MESSAGE_BUS_CHECK_INTERVAL.
+func (r *Redis) Position() Position {
+	r.position.mu.Lock()
+	defer r.position.mu.Unlock()
+
+	return r.position.state
+}
+
+// Metric adds the lag and the gaps of the autoupdate stream and the lag behind
+// the other instances to the metric.
+func (r *Redis) Metric(con metric.Container) {
+	p := r.Position()
+	con.Add("message_bus_lag_ms", int(p.Lag.Milliseconds()))
+	con.Add("message_bus_gaps", p.Gaps)
+	con.Add("message_bus_replica_lag_ms", int(p.ReplicaLag.Milliseconds()))
+}
+
+// checkPosition compares the id of the last read message with the stream.
+//
+// It returns the id, where the stream should be read next. If id is `$`, it
+// is replaced with the id of the latest message.
+//
+// If messages were lost, the returned error wraps flow.ErrResync. This happens
+// if messages after the id were deleted or if the stream was recreated.
+func (r *Redis) checkPosition(ctx context.Context, id string) (string, error) {
+	latest, maxDeleted, err := r.streamInfo(ctx)
+	if err != nil {
+		return id, fmt.Errorf("reading stream info: %w", err)
+	}
+
+	return r.updatePosition(id, latest, maxDeleted)
+}
+
+// updatePosition saves the position and detects lost messages. See
+// checkPosition.
+func (r *Redis) updatePosition(id string, latest, maxDeleted streamID) (string, error) {
+	if id == "$" {
+		id = latest.String()
+	}
+
+	consumed, err := parseStreamID(id)
+	if err != nil {
+		return id, fmt.Errorf("parsing consumed id: %w", err)
+	}
+
+	var lag time.Duration
+	if consumed.less(latest) {
+		lag = time.Duration(latest.ms-consumed.ms) * time.Millisecond
+	}
+
+	var reason string
+	switch {
+	case latest.less(consumed):
+		reason = "the stream was recreated"
+	case consumed.less(maxDeleted):
+		reason = fmt.Sprintf("messages until %s were deleted before they were read", maxDeleted)
+	}
+
+	r.position.mu.Lock()
+	defer r.position.mu.Unlock()
+
+	r.position.state.Consumed = consumed.String()
+	r.position.state.Latest = latest.String()
+	r.position.state.Lag = lag
+
+	if reason == "" {
+		return id, nil
+	}
+
+	r.position.state.Gaps++
+	r.position.state.Consumed = latest.String()
+	r.position.state.Lag = 0
+	return latest.String(), fmt.Errorf("message bus at %s, latest message %s: %s: %w", consumed, latest, reason, flow.ErrResync)
+}
+
+// streamInfo returns the last generated id and the biggest deleted id of the
+// autoupdate stream.
+//
+// The deleted id is only returned by redis 7 and newer. Returns zero ids, if
+// the stream does not exist.
+func (r *Redis) streamInfo(ctx context.Context) (latest streamID, maxDeleted streamID, err error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, "XINFO", "STREAM", fieldChangedTopic)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return streamID{}, streamID{}, nil
+		}
+		return streamID{}, streamID{}, fmt.Errorf("redis `XINFO STREAM %s`: %w", fieldChangedTopic, err)
+	}
+
+	return parseStreamInfo(reply)
+}
+
+func parseStreamInfo(reply any) (latest streamID, maxDeleted streamID, err error) {
+	values, err := redis.Values(reply, nil)
+	if err != nil {
+		return streamID{}, streamID{}, fmt.Errorf("parsing reply: %w", err)
+	}
+
+	if len(values)%2 != 0 {
+		return streamID{}, streamID{}, fmt.Errorf("invalid stream info with %d values", len(values))
+	}
+
+	for i := 0; i < len(values); i += 2 {
+		name, err := redis.String(values[i], nil)
+		if err != nil {
+			return streamID{}, streamID{}, fmt.Errorf("parsing name of value %d: %w", i, err)
+		}
+
+		var target *streamID
+		switch name {
+		case "last-generated-id":
+			target = &latest
+		case "max-deleted-entry-id":
+			target = &maxDeleted
+		default:
+			continue
+		}
+
+		value, err := redis.String(values[i+1], nil)
+		if err != nil {
+			return streamID{}, streamID{}, fmt.Errorf("parsing %s: %w", name, err)
+		}
+
+		*target, err = parseStreamID(value)
+		if err != nil {
+			return streamID{}, streamID{}, fmt.Errorf("parsing %s: %w", name, err)
+		}
+	}
+
+	return latest, maxDeleted, nil
+}
+
+// streamID is the id of a message in a redis stream.
+type streamID struct {
+	ms  uint64
+	seq uint64
+}
+
+// parseStreamID parses an id like `1700000000000-0`. The sequence is
+// optional.
+func parseStreamID(id string) (streamID, error) {
+	rawMS, rawSeq, hasSeq := strings.Cut(id, "-")
+
+	ms, err := strconv.ParseUint(rawMS, 10, 64)
+	if err != nil {
+		return streamID{}, fmt.Errorf("invalid stream id `%s`", id)
+	}
+
+	var seq uint64
+	if hasSeq {
+		seq, err = strconv.ParseUint(rawSeq, 10, 64)
+		if err != nil {
+			return streamID{}, fmt.Errorf("invalid stream id `%s`", id)
+		}
+	}
+
+	return streamID{ms: ms, seq: seq}, nil
+}
+
+func (id streamID) less(other streamID) bool {
+	if id.ms != other.ms {
+		return id.ms < other.ms
+	}
+	return id.seq < other.seq
+}
+
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
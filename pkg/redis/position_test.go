@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+)
+
+func TestParseStreamInfo(t *testing.T) {
+	reply := []any{
+		[]byte("length"), int64(2),
+		[]byte("last-generated-id"), []byte("1700000005000-1"),
+		[]byte("max-deleted-entry-id"), []byte("1700000001000-0"),
+		[]byte("first-entry"), []any{[]byte("1700000004000-0"), []any{}},
+	}
+
+	latest, maxDeleted, err := parseStreamInfo(reply)
+	if err != nil {
+		t.Fatalf("parseStreamInfo: %v", err)
+	}
+
+	if latest.String() != "1700000005000-1" {
+		t.Errorf("got latest %s, expected 1700000005000-1", latest)
+	}
+
+	if maxDeleted.String() != "1700000001000-0" {
+		t.Errorf("got max deleted %s, expected 1700000001000-0", maxDeleted)
+	}
+}
+
+func TestUpdatePosition(t *testing.T) {
+	id := func(s string) streamID {
+		t.Helper()
+		parsed, err := parseStreamID(s)
+		if err != nil {
+			t.Fatalf("parseStreamID: %v", err)
+		}
+		return parsed
+	}
+
+	for _, tt := range []struct {
+		name       string
+		consumed   string
+		latest     string
+		maxDeleted string
+		expectID   string
+		expectLag  time.Duration
+		expectGap  bool
+	}{
+		{"start", "$", "1000-3", "0-0", "1000-3", 0, false},
+		{"up to date", "1000-3", "1000-3", "0-0", "1000-3", 0, false},
+		{"behind", "1000-3", "3500-0", "500-0", "1000-3", 2500 * time.Millisecond, false},
+		{"deleted before read", "1000-3", "3500-0", "2000-0", "3500-0", 0, true},
+		{"stream recreated", "1000-3", "0-0", "0-0", "0-0", 0, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := new(Redis)
+
+			got, err := r.updatePosition(tt.consumed, id(tt.latest), id(tt.maxDeleted))
+			if tt.expectGap != errors.Is(err, flow.ErrResync) {
+				t.Errorf("got error %v, expected gap: %v", err, tt.expectGap)
+			}
+
+			if got != tt.expectID {
+				t.Errorf("got id %s, expected %s", got, tt.expectID)
+			}
+
+			position := r.Position()
+			if position.Lag != tt.expectLag {
+				t.Errorf("got lag %s, expected %s", position.Lag, tt.expectLag)
+			}
+
+			expectGaps := 0
+			if tt.expectGap {
+				expectGaps = 1
+			}
+			if position.Gaps != expectGaps {
+				t.Errorf("got %d gaps, expected %d", position.Gaps, expectGaps)
+			}
+		})
+	}
+}
+
+func TestReplicaLag(t *testing.T) {
+	var newest streamID
+	var err error
+	for _, position := range []string{"1000-0", "3500-2", "2000-0"} {
+		newest, err = newestPosition{}.Combine(position, newest)
+		if err != nil {
+			t.Fatalf("Combine: %v", err)
+		}
+	}
+
+	if newest.String() != "3500-2" {
+		t.Fatalf("got newest position %s, expected 3500-2", newest)
+	}
+
+	r := &Redis{maxReplicaLag: 2 * time.Second}
+	r.updateReplicaLag(streamID{ms: 1000}, newest)
+
+	if lag := r.Position().ReplicaLag; lag != 2500*time.Millisecond {
+		t.Errorf("got replica lag %s, expected 2.5s", lag)
+	}
+
+	if err := r.CheckReplicaLag(context.Background()); err == nil {
+		t.Errorf("CheckReplicaLag returned no error")
+	}
+
+	r.updateReplicaLag(newest, newest)
+	if err := r.CheckReplicaLag(context.Background()); err != nil {
+		t.Errorf("CheckReplicaLag returned %v", err)
+	}
+}
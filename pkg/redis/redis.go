@@ -4,10 +4,12 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 	"github.com/gomodule/redigo/redis"
@@ -30,18 +32,39 @@ const (
 var (
 	envMessageBusHost = environment.NewVariable("MESSAGE_BUS_HOST", "localhost", "Host of the redis server.")
 	envMessageBusPort = environment.NewVariable("MESSAGE_BUS_PORT", "6379", "Port of the redis server.")
+
+	envMessageBusCheckInterval = environment.NewVariable("MESSAGE_BUS_CHECK_INTERVAL", "5s", "Interval, how often the position in the autoupdate stream is compared with the latest message. Lost messages cause a reload of the cache. Zero disables the check.")
+	envMessageBusConsumerGroup = environment.NewVariable("MESSAGE_BUS_CONSUMER_GROUP", "", "Prefix of the redis consumer groups. If set, each instance reads the autoupdate stream with its own consumer group and acknowledges each message after it was applied. Messages, that were not acknowledged, are read again after a lost connection. Empty reads the stream without consumer groups.")
+	envMessageBusMaxReplicaLag = environment.NewVariable("MESSAGE_BUS_MAX_REPLICA_LAG", "0", "Time, this instance can be behind the newest instance in the autoupdate stream, before the readiness check fails. The positions are shared every MESSAGE_BUS_CHECK_INTERVAL. Zero disables the check.")
 )
 
 // Redis holds the state of the redis receiver.
 type Redis struct {
 	pool         *redis.Pool
 	lastLogoutID string
+
+	checkInterval time.Duration
+	position      position
+	consumerGroup string
+
+	replicas      Metric[streamID]
+	maxReplicaLag time.Duration
 }
 
 // New initializes a Redis instance.
-func New(lookup environment.Environmenter) *Redis {
+func New(lookup environment.Environmenter) (*Redis, error) {
 	addr := envMessageBusHost.Value(lookup) + ":" + envMessageBusPort.Value(lookup)
 
+	checkInterval, err := environment.ParseDuration(envMessageBusCheckInterval.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envMessageBusCheckInterval.Key, envMessageBusCheckInterval.Value(lookup), err)
+	}
+
+	maxReplicaLag, err := environment.ParseDuration(envMessageBusMaxReplicaLag.Value(lookup))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for `%s`, expected duration got %s: %w", envMessageBusMaxReplicaLag.Key, envMessageBusMaxReplicaLag.Value(lookup), err)
+	}
+
 	pool := &redis.Pool{
 		MaxActive:   100,
 		Wait:        true,
@@ -50,9 +73,14 @@ func New(lookup environment.Environmenter) *Redis {
 		Dial:        func() (redis.Conn, error) { return redis.Dial("tcp", addr) },
 	}
 
-	return &Redis{
-		pool: pool,
+	r := &Redis{
+		pool:          pool,
+		checkInterval: checkInterval,
+		maxReplicaLag: maxReplicaLag,
+		consumerGroup: envMessageBusConsumerGroup.Value(lookup),
 	}
+	r.replicas = replicaMetric(r, checkInterval)
+	return r, nil
 }
 
 // Wait blocks until a connection can be established.
@@ -68,50 +96,177 @@ func (r *Redis) Wait(ctx context.Context) error {
 		}
 		lastErr = err
 
-		select {
-		case <-time.After(200 * time.Millisecond):
-		case <-ctx.Done():
+		if err := clock.Sleep(ctx, 200*time.Millisecond); err != nil {
 			return lastErr
 		}
 	}
 }
 
+// Ping checks, that redis is reachable.
+func (r *Redis) Ping(ctx context.Context) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	if _, err := redis.DoContext(conn, ctx, "PING"); err != nil {
+		return fmt.Errorf("redis ping: %w", err)
+	}
+	return nil
+}
+
 // Update implements the Flow interface.
+//
+// Every checkInterval, the position in the stream is checked and shared with
+// the other instances. If messages were lost or a resync was forced with
+// ForceResync, updateFn is called with an error, that wraps flow.ErrResync.
+//
+// If MESSAGE_BUS_CONSUMER_GROUP is set, the stream is read with a consumer
+// group.
 func (r *Redis) Update(ctx context.Context, updateFn func(map[dskey.Key][]byte, error)) {
+	if r.consumerGroup != "" {
+		r.updateWithGroup(ctx, updateFn)
+		return
+	}
+
 	id := "$"
 
+	// Only resync messages after the start are relevant. The cache is empty
+	// before.
+	resyncID := strconv.FormatInt(clock.Now().UnixMilli(), 10)
+
+	var lastCheck time.Time
 	for ctx.Err() == nil {
-		newID, data, err := r.singleUpdate(ctx, id)
+		if r.checkInterval > 0 && clock.Since(lastCheck) >= r.checkInterval {
+			lastCheck = clock.Now()
+
+			var err error
+			id, err = r.checkPosition(ctx, id)
+			if err != nil {
+				updateFn(nil, fmt.Errorf("checking position: %w", err))
+			}
+
+			if err := r.shareReplicaPosition(ctx); err != nil {
+				updateFn(nil, fmt.Errorf("sharing position: %w", err))
+			}
+		}
+
+		newID, data, newResyncID, reasons, err := r.singleUpdate(ctx, id, resyncID)
 		if err != nil {
 			updateFn(nil, err)
-			time.Sleep(5 * time.Second)
+			clock.Sleep(ctx, 5*time.Second)
 			continue
 		}
+
 		updateFn(data, nil)
+		if err := resyncError(reasons); err != nil {
+			updateFn(nil, err)
+		}
+
 		id = newID
+		resyncID = newResyncID
 	}
 }
 
-func (r *Redis) singleUpdate(ctx context.Context, id string) (string, map[dskey.Key][]byte, error) {
+func (r *Redis) singleUpdate(ctx context.Context, id, resyncID string) (string, map[dskey.Key][]byte, string, []string, error) {
 	conn := r.pool.Get()
 	defer conn.Close()
 
-	reply, err := redis.DoContext(conn, ctx, "XREAD", "COUNT", maxMessages, "BLOCK", "0", "STREAMS", fieldChangedTopic, id)
+	// With the check, XREAD returns after the interval, so the position can
+	// be checked again.
+	block := strconv.FormatInt(r.checkInterval.Milliseconds(), 10)
+	reply, err := redis.DoContext(conn, ctx, "XREAD", "COUNT", maxMessages, "BLOCK", block, "STREAMS", fieldChangedTopic, resyncTopic, id, resyncID)
 	if err != nil {
-		return "", nil, fmt.Errorf("redis `XREAD count %s BLOCK 0 STREAMS %s %s: %w", maxMessages, fieldChangedTopic, id, err)
+		return "", nil, "", nil, fmt.Errorf("redis `XREAD count %s BLOCK %s STREAMS %s %s %s %s: %w", maxMessages, block, fieldChangedTopic, resyncTopic, id, resyncID, err)
 	}
 
 	if reply == nil {
 		// This happens, when the redis command times out.
-		return id, nil, nil
+		return id, nil, resyncID, nil, nil
+	}
+
+	newID, data, err := parseMessageBus(reply)
+	switch {
+	case errors.Is(err, errStreamNotFound):
+		// Only the resync stream has messages.
+		newID = id
+	case err != nil:
+		return "", nil, "", nil, fmt.Errorf("parsing message bus: %w", err)
+	}
+
+	newResyncID, reasons, err := resyncStream(reply)
+	switch {
+	case errors.Is(err, errStreamNotFound):
+		newResyncID = resyncID
+	case err != nil:
+		return "", nil, "", nil, fmt.Errorf("parsing resync stream: %w", err)
 	}
 
-	id, data, err := parseMessageBus(reply)
+	return newID, data, newResyncID, reasons, nil
+}
+
+// AddToStream adds a message with one field to a redis stream. The stream is
+// trimmed to about maxLen messages.
+func (r *Redis) AddToStream(ctx context.Context, stream string, maxLen int, field string, value []byte) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	if _, err := redis.DoContext(conn, ctx, "XADD", stream, "MAXLEN", "~", maxLen, "*", field, value); err != nil {
+		return fmt.Errorf("redis `XADD %s`: %w", stream, err)
+	}
+	return nil
+}
+
+// Publish writes an update to the autoupdate stream, like the datastore
+// writer does. A nil value is written as null, which deletes the key.
+func (r *Redis) Publish(ctx context.Context, data map[dskey.Key][]byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	args := []any{fieldChangedTopic, "*"}
+	for key, value := range data {
+		if value == nil {
+			value = []byte("null")
+		}
+		args = append(args, key.String(), value)
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	if _, err := redis.DoContext(conn, ctx, "XADD", args...); err != nil {
+		return fmt.Errorf("redis `XADD %s`: %w", fieldChangedTopic, err)
+	}
+	return nil
+}
+
+// ReceiveLatest reads and parses the newest message of the autoupdate stream.
+// An empty stream is not an error.
+func (r *Redis) ReceiveLatest(ctx context.Context) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, "XREVRANGE", fieldChangedTopic, "+", "-", "COUNT", 1)
 	if err != nil {
-		return "", nil, fmt.Errorf("parsing message bus: %w", err)
+		return fmt.Errorf("redis `XREVRANGE %s`: %w", fieldChangedTopic, err)
 	}
 
-	return id, data, nil
+	if _, err := parseStream(reply, func(k, v []byte) {}); err != nil {
+		return fmt.Errorf("parsing message: %w", err)
+	}
+	return nil
+}
+
+// HashGetAll returns all fields of a redis hash. It returns an empty map, if
+// the key does not exist.
+func (r *Redis) HashGetAll(ctx context.Context, key string) (map[string]string, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.StringMap(redis.DoContext(conn, ctx, "HGETALL", key))
+	if err != nil {
+		return nil, fmt.Errorf("redis `HGETALL %s`: %w", key, err)
+	}
+	return values, nil
 }
 
 // LogoutEvent is a blocking function that returns, when a session was revoked.
@@ -2,14 +2,17 @@ package redis_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/dskey"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
 	"github.com/OpenSlides/openslides-autoupdate-service/pkg/redis"
+	redigo "github.com/gomodule/redigo/redis"
 )
 
 func TestUpdate(t *testing.T) {
@@ -19,7 +22,10 @@ func TestUpdate(t *testing.T) {
 	tr := newTestRedis(t)
 	defer tr.Close()
 
-	r := redis.New(environment.ForTests(tr.Env))
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
 	r.Wait(ctx)
 
 	done := make(chan error)
@@ -56,6 +62,94 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateConsumerGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	env := map[string]string{"MESSAGE_BUS_CONSUMER_GROUP": "autoupdate"}
+	for k, v := range tr.Env {
+		env[k] = v
+	}
+
+	r, err := redis.New(environment.ForTests(env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	received := make(chan map[dskey.Key][]byte)
+	go r.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		if err != nil {
+			t.Errorf("Update() returned an unexpected error %v", err)
+			return
+		}
+
+		if len(data) > 0 {
+			received <- data
+		}
+	})
+
+	conn, err := tr.conn(ctx)
+	if err != nil {
+		t.Fatalf("Creating test connection: %v", err)
+	}
+
+	// Wait until the group was created.
+	for {
+		groups, err := redigo.Values(conn.Do("XINFO", "GROUPS", "ModifiedFields"))
+		if err == nil && len(groups) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := conn.Do("XADD", "ModifiedFields", "*", "user/1/username", "Hubert"); err != nil {
+		t.Fatalf("Insert test data: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		expect := map[dskey.Key][]byte{dskey.MustKey("user/1/username"): []byte("Hubert")}
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("Update() returned %v, expected %v", got, expect)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Update() did not return the message")
+	}
+
+	// The message is acknowledged after updateFn returned.
+	for i := 0; ; i++ {
+		groups, err := redigo.Values(conn.Do("XINFO", "GROUPS", "ModifiedFields"))
+		if err != nil {
+			t.Fatalf("XINFO GROUPS: %v", err)
+		}
+
+		info, err := redigo.Values(groups[0], nil)
+		if err != nil {
+			t.Fatalf("parsing group: %v", err)
+		}
+
+		var pending int64 = -1
+		for j := 0; j+1 < len(info); j += 2 {
+			if name, _ := redigo.String(info[j], nil); name == "pending" {
+				pending, _ = redigo.Int64(info[j+1], nil)
+			}
+		}
+
+		if pending == 0 {
+			break
+		}
+
+		if i == 100 {
+			t.Fatalf("message was not acknowledged, %d pending", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestLogout(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -63,7 +157,10 @@ func TestLogout(t *testing.T) {
 	tr := newTestRedis(t)
 	defer tr.Close()
 
-	r := redis.New(environment.ForTests(tr.Env))
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
 	r.Wait(ctx)
 
 	done := make(chan error)
@@ -97,3 +194,268 @@ func TestLogout(t *testing.T) {
 		t.Errorf("LogoutEvent() returned %v, expected %v", got, expect)
 	}
 }
+
+func TestAddToStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	if err := r.AddToStream(ctx, "events", 10, "event", []byte(`{"type":"connect"}`)); err != nil {
+		t.Fatalf("AddToStream: %v", err)
+	}
+
+	conn, err := tr.conn(ctx)
+	if err != nil {
+		t.Fatalf("Creating test connection: %v", err)
+	}
+
+	reply, err := redigo.Values(conn.Do("XRANGE", "events", "-", "+"))
+	if err != nil {
+		t.Fatalf("Reading stream: %v", err)
+	}
+
+	if len(reply) != 1 {
+		t.Fatalf("got %d messages, expected 1", len(reply))
+	}
+
+	message, err := redigo.Values(reply[0], nil)
+	if err != nil {
+		t.Fatalf("Parsing message: %v", err)
+	}
+
+	fields, err := redigo.Strings(message[1], nil)
+	if err != nil {
+		t.Fatalf("Parsing fields: %v", err)
+	}
+
+	expect := []string{"event", `{"type":"connect"}`}
+	if !reflect.DeepEqual(fields, expect) {
+		t.Errorf("got fields %v, expected %v", fields, expect)
+	}
+}
+
+func TestHashGetAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	conn, err := tr.conn(ctx)
+	if err != nil {
+		t.Fatalf("Creating test connection: %v", err)
+	}
+
+	if _, err := conn.Do("HSET", "features", "delta", "true", "shared_cache", "25%"); err != nil {
+		t.Fatalf("Writing hash: %v", err)
+	}
+
+	got, err := r.HashGetAll(ctx, "features")
+	if err != nil {
+		t.Fatalf("HashGetAll: %v", err)
+	}
+
+	expect := map[string]string{"delta": "true", "shared_cache": "25%"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("got %v, expected %v", got, expect)
+	}
+}
+
+func TestReceiveLatest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	if err := r.ReceiveLatest(ctx); err != nil {
+		t.Errorf("ReceiveLatest on an empty stream: %v", err)
+	}
+
+	conn, err := tr.conn(ctx)
+	if err != nil {
+		t.Fatalf("Creating test connection: %v", err)
+	}
+
+	if _, err := conn.Do("XADD", "ModifiedFields", "*", "user/1/name", `"Hugo"`); err != nil {
+		t.Fatalf("Writing message: %v", err)
+	}
+
+	if err := r.ReceiveLatest(ctx); err != nil {
+		t.Errorf("ReceiveLatest: %v", err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	done := make(chan map[dskey.Key][]byte)
+	go r.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		if err != nil {
+			t.Errorf("Update() returned an unexpected error %v", err)
+		}
+		done <- data
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	if err := r.Publish(ctx, map[dskey.Key][]byte{
+		dskey.MustKey("user/1/username"): []byte(`"Hubert"`),
+		dskey.MustKey("user/2/username"): nil,
+	}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	got := <-done
+	expect := map[dskey.Key][]byte{
+		dskey.MustKey("user/1/username"): []byte(`"Hubert"`),
+		dskey.MustKey("user/2/username"): nil,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Update() returned %v, expected %v", got, expect)
+	}
+}
+
+func TestForceResync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	resync := make(chan error, 1)
+	go r.Update(ctx, func(data map[dskey.Key][]byte, err error) {
+		if err != nil {
+			select {
+			case resync <- err:
+			default:
+			}
+		}
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	if err := r.ForceResync(ctx, "test"); err != nil {
+		t.Fatalf("ForceResync: %v", err)
+	}
+
+	select {
+	case err := <-resync:
+		if !errors.Is(err, flow.ErrResync) {
+			t.Errorf("got error %v, expected %v", err, flow.ErrResync)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Update did not return an error")
+	}
+}
+
+func TestResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	if err := r.SaveResume(ctx, "abc", []byte("state"), time.Minute); err != nil {
+		t.Fatalf("SaveResume: %v", err)
+	}
+
+	got, err := r.LoadResume(ctx, "abc")
+	if err != nil {
+		t.Fatalf("LoadResume: %v", err)
+	}
+
+	if string(got) != "state" {
+		t.Errorf("LoadResume returned %q, expected %q", got, "state")
+	}
+
+	got, err = r.LoadResume(ctx, "abc")
+	if err != nil {
+		t.Fatalf("second LoadResume: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("second LoadResume returned %q, expected nil", got)
+	}
+}
+
+func TestICC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tr := newTestRedis(t)
+	defer tr.Close()
+
+	r, err := redis.New(environment.ForTests(tr.Env))
+	if err != nil {
+		t.Fatalf("init redis: %v", err)
+	}
+	r.Wait(ctx)
+
+	if err := r.SendNotify(ctx, []byte("hello")); err != nil {
+		t.Fatalf("SendNotify: %v", err)
+	}
+
+	_, messages, err := r.ReceiveNotify(ctx, "0")
+	if err != nil {
+		t.Fatalf("ReceiveNotify: %v", err)
+	}
+
+	if len(messages) != 1 || string(messages[0]) != "hello" {
+		t.Errorf("ReceiveNotify returned %q, expected [hello]", messages)
+	}
+
+	now := time.Now()
+	if err := r.SendApplause(ctx, 1, 5, now); err != nil {
+		t.Fatalf("SendApplause: %v", err)
+	}
+
+	count, err := r.ApplauseSince(ctx, 1, now.Add(-time.Second))
+	if err != nil {
+		t.Fatalf("ApplauseSince: %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("ApplauseSince returned %d, expected 1", count)
+	}
+}
@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/clock"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/datastore/flow"
+)
+
+const (
+	// resyncTopic is the redis key name of the stream, that tells all
+	// instances to fetch the values of their cache again.
+	resyncTopic = "autoupdate_resync"
+
+	// resyncMaxLen is the number of messages, that are kept in the resync
+	// stream.
+	resyncMaxLen = 100
+
+	// replicaPositionName is the name of the redis metric with the position of
+	// each instance in the autoupdate stream.
+	replicaPositionName = "autoupdate_position"
+)
+
+// ForceResync tells all instances of the autoupdate service, that use this
+// redis, to fetch all values of their cache again. The reason is logged by
+// each instance.
+func (r *Redis) ForceResync(ctx context.Context, reason string) error {
+	if err := r.AddToStream(ctx, resyncTopic, resyncMaxLen, "reason", []byte(reason)); err != nil {
+		return fmt.Errorf("adding resync message: %w", err)
+	}
+	return nil
+}
+
+// resyncError returns the error for the resync messages or nil, if there are
+// none.
+func resyncError(reasons []string) error {
+	if len(reasons) == 0 {
+		return nil
+	}
+	return fmt.Errorf("forced resync: %s: %w", reasons[len(reasons)-1], flow.ErrResync)
+}
+
+// newestPosition combines the positions of the instances to the newest one.
+type newestPosition struct{}
+
+func (newestPosition) Combine(value string, acc streamID) (streamID, error) {
+	id, err := parseStreamID(value)
+	if err != nil {
+		return acc, fmt.Errorf("parsing position: %w", err)
+	}
+
+	if acc.less(id) {
+		return id, nil
+	}
+	return acc, nil
+}
+
+// shareReplicaPosition saves the position of this instance in redis and reads
+// the newest position of all instances.
+//
+// The instances do not coordinate with each other. Each one reads all
+// messages, so there is no leader. The newest position only tells, how far
+// this instance is behind the others.
+func (r *Redis) shareReplicaPosition(ctx context.Context) error {
+	own := r.Position().Consumed
+	if own == "" {
+		// The position was not checked yet.
+		return nil
+	}
+
+	consumed, err := parseStreamID(own)
+	if err != nil {
+		return fmt.Errorf("parsing own position: %w", err)
+	}
+
+	if err := r.replicas.Save(ctx, consumed.String()); err != nil {
+		return fmt.Errorf("saving position: %w", err)
+	}
+
+	newest, err := r.replicas.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("reading positions: %w", err)
+	}
+
+	r.updateReplicaLag(consumed, newest)
+	return nil
+}
+
+// updateReplicaLag sets the time, this instance is behind the newest instance.
+func (r *Redis) updateReplicaLag(consumed, newest streamID) {
+	var lag time.Duration
+	if consumed.less(newest) {
+		lag = time.Duration(newest.ms-consumed.ms) * time.Millisecond
+	}
+
+	r.position.mu.Lock()
+	defer r.position.mu.Unlock()
+
+	r.position.state.Newest = newest.String()
+	r.position.state.ReplicaLag = lag
+}
+
+// CheckReplicaLag returns an error, if this instance is more than
+// MESSAGE_BUS_MAX_REPLICA_LAG behind the newest instance. As readiness check,
+// the load balancer does not send clients to an instance with old data.
+func (r *Redis) CheckReplicaLag(context.Context) error {
+	if r.maxReplicaLag == 0 {
+		return nil
+	}
+
+	if lag := r.Position().ReplicaLag; lag > r.maxReplicaLag {
+		return fmt.Errorf("message bus is %s behind the newest instance", lag)
+	}
+	return nil
+}
+
+// replicaMetric is the metric for the positions of the instances. Positions,
+// that are not updated for three check intervals, are ignored.
+func replicaMetric(r *Redis, checkInterval time.Duration) Metric[streamID] {
+	return NewMetric[streamID](r, replicaPositionName, newestPosition{}, max(3*checkInterval, time.Minute), clock.Now)
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// resumeKeyPrefix is the prefix of the redis keys, that hold the state of
+// connections, that were handed off by an instance.
+const resumeKeyPrefix = "autoupdate_resume:"
+
+// SaveResume saves the state of a connection, so another instance can resume
+// it. The state is deleted after the ttl.
+func (r *Redis) SaveResume(ctx context.Context, id string, state []byte, ttl time.Duration) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	seconds := max(int64(ttl/time.Second), 1)
+	if _, err := redis.DoContext(conn, ctx, "SET", resumeKeyPrefix+id, state, "EX", seconds); err != nil {
+		return fmt.Errorf("redis `SET %s`: %w", resumeKeyPrefix+id, err)
+	}
+	return nil
+}
+
+// LoadResume returns the state of a connection, that was saved with
+// SaveResume. The state is deleted, so it can only be loaded once.
+//
+// Returns nil, if there is no state for the id or if it is too old.
+func (r *Redis) LoadResume(ctx context.Context, id string) ([]byte, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	state, err := redis.Bytes(redis.DoContext(conn, ctx, "GETDEL", resumeKeyPrefix+id))
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("redis `GETDEL %s`: %w", resumeKeyPrefix+id, err)
+	}
+	return state, nil
+}
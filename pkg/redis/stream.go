@@ -33,6 +33,11 @@ func parseStream(reply any, f func(k, v []byte)) (string, error) {
 
 		lastID = id
 
+		if idFields[1] == nil {
+			// A pending message of a consumer group, that was deleted.
+			continue
+		}
+
 		fieldList, ok := idFields[1].([]any)
 		if !ok || len(fieldList)%2 != 0 {
 			return "", fmt.Errorf("invalid field list value %d, got %v", i, idFields[i])
@@ -55,6 +60,10 @@ func parseStream(reply any, f func(k, v []byte)) (string, error) {
 	return lastID, nil
 }
 
+// errStreamNotFound is returned by onlyStream, when the reply has no messages
+// of the stream.
+var errStreamNotFound = errors.New("stream not found")
+
 // only Stream filters a xread request for one stream.
 func onlyStream(reply any, only string, f func(k, v []byte)) (string, error) {
 	streams, err := redis.Values(reply, nil)
@@ -85,7 +94,7 @@ func onlyStream(reply any, only string, f func(k, v []byte)) (string, error) {
 		return lastID, nil
 	}
 
-	return "", fmt.Errorf("stream not found")
+	return "", errStreamNotFound
 }
 
 func parseMessageBus(reply any) (string, map[dskey.Key][]byte, error) {
@@ -134,6 +143,25 @@ func logoutStream(reply any) (string, []string, error) {
 	return lastID, sessionIDs, nil
 }
 
+// resyncStream parses the reasons of the resync stream.
+func resyncStream(reply any) (string, []string, error) {
+	var reasons []string
+	databuilder := func(k, v []byte) {
+		if string(k) != "reason" {
+			return
+		}
+
+		reasons = append(reasons, string(v))
+	}
+
+	lastID, err := onlyStream(reply, resyncTopic, databuilder)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing resync stream: %w", err)
+	}
+
+	return lastID, reasons, nil
+}
+
 // toByte converts an interface with value string or []byte to []byte this is an
 // helper, because the test-code generates strings but the redis code generates
 // []bytes.
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -85,3 +86,32 @@ func TestStreamInvalidData(t *testing.T) {
 		})
 	}
 }
+
+func TestResyncStream(t *testing.T) {
+	reply := []any{
+		[]any{
+			[]byte("autoupdate_resync"),
+			[]any{
+				[]any{[]byte("12345-0"), []any{[]byte("reason"), []byte("first")}},
+				[]any{[]byte("12346-0"), []any{[]byte("reason"), []byte("second")}},
+			},
+		},
+	}
+
+	id, reasons, err := resyncStream(reply)
+	if err != nil {
+		t.Fatalf("resyncStream: %v", err)
+	}
+
+	if id != "12346-0" {
+		t.Errorf("got id %s, expected 12346-0", id)
+	}
+
+	if !reflect.DeepEqual(reasons, []string{"first", "second"}) {
+		t.Errorf("got reasons %v, expected [first second]", reasons)
+	}
+
+	if _, _, err := parseMessageBus(reply); !errors.Is(err, errStreamNotFound) {
+		t.Errorf("parseMessageBus returned %v, expected %v", err, errStreamNotFound)
+	}
+}
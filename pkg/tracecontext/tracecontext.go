@@ -0,0 +1,134 @@
+// Package tracecontext propagates the W3C trace context headers
+// (https://www.w3.org/TR/trace-context/) from incoming to outgoing requests.
+package tracecontext
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Header names of the trace context.
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// maxTraceStateLength is the maximum length of the tracestate header that is
+// propagated. Longer values are dropped.
+const maxTraceStateLength = 512
+
+// reTraceParent matches a traceparent header of version 00. Later versions
+// can have additional fields after the flags.
+var reTraceParent = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(-.*)?$`)
+
+// TraceContext is the trace context of a request.
+type TraceContext struct {
+	TraceParent string
+	TraceState  string
+}
+
+// TraceID returns the trace id part of the traceparent.
+func (tc TraceContext) TraceID() string {
+	m := reTraceParent.FindStringSubmatch(tc.TraceParent)
+	if m == nil {
+		return ""
+	}
+	return m[2]
+}
+
+// Parse reads the trace context from http headers.
+//
+// Returns false, if there is no valid traceparent header. In this case, the
+// tracestate header is also ignored.
+func Parse(header http.Header) (TraceContext, bool) {
+	parent := strings.TrimSpace(header.Get(HeaderTraceParent))
+	m := reTraceParent.FindStringSubmatch(parent)
+	if m == nil {
+		return TraceContext{}, false
+	}
+
+	version, traceID, parentID := m[1], m[2], m[3]
+	if version == "ff" || (version == "00" && m[5] != "") {
+		return TraceContext{}, false
+	}
+
+	if traceID == strings.Repeat("0", 32) || parentID == strings.Repeat("0", 16) {
+		return TraceContext{}, false
+	}
+
+	state := strings.Join(header.Values(HeaderTraceState), ",")
+	if len(state) > maxTraceStateLength {
+		state = ""
+	}
+
+	return TraceContext{TraceParent: parent, TraceState: state}, true
+}
+
+// Inject writes the trace context to the http headers.
+func (tc TraceContext) Inject(header http.Header) {
+	if tc.TraceParent == "" {
+		return
+	}
+
+	header.Set(HeaderTraceParent, tc.TraceParent)
+	if tc.TraceState != "" {
+		header.Set(HeaderTraceState, tc.TraceState)
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns a new context that carries the trace context.
+func NewContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, tc)
+}
+
+// FromContext returns the trace context from the context.
+func FromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(contextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// Middleware saves the trace context of incoming requests in the request
+// context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc, ok := Parse(r.Header)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), tc)))
+	})
+}
+
+// Transport is a http.RoundTripper that adds the trace context from the
+// request context to outgoing requests.
+//
+// Requests, that already have a traceparent header are not changed.
+type Transport struct {
+	// Base is the RoundTripper that sends the request. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	tc, ok := FromContext(req.Context())
+	if !ok || req.Header.Get(HeaderTraceParent) != "" {
+		return base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the given request.
+	req = req.Clone(req.Context())
+	tc.Inject(req.Header)
+	return base.RoundTrip(req)
+}
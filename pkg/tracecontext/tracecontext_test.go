@@ -0,0 +1,113 @@
+package tracecontext_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracecontext"
+)
+
+const validParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		parent      string
+		state       string
+		expectOK    bool
+		expectState string
+	}{
+		{"Valid", validParent, "congo=t61rcWkgMzE", true, "congo=t61rcWkgMzE"},
+		{"No header", "", "congo=t61rcWkgMzE", false, ""},
+		{"Uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", "", false, ""},
+		{"Invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false, ""},
+		{"Zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false, ""},
+		{"Zero parent id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", false, ""},
+		{"Version 00 with suffix", validParent + "-abc", "", false, ""},
+		{"Future version with suffix", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-abc", "", true, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			if tt.parent != "" {
+				header.Set("traceparent", tt.parent)
+			}
+			if tt.state != "" {
+				header.Set("tracestate", tt.state)
+			}
+
+			tc, ok := tracecontext.Parse(header)
+			if ok != tt.expectOK {
+				t.Fatalf("got ok %t, expected %t", ok, tt.expectOK)
+			}
+
+			if tc.TraceState != tt.expectState {
+				t.Errorf("got state %q, expected %q", tc.TraceState, tt.expectState)
+			}
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	tc := tracecontext.TraceContext{TraceParent: validParent}
+	if got := tc.TraceID(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: &tracecontext.Transport{}}
+
+	handler := tracecontext.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := http.NewRequestWithContext(r.Context(), "GET", downstream.URL, nil)
+		if err != nil {
+			t.Fatalf("creating request: %v", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+		resp.Body.Close()
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", validParent)
+	req.Header.Set("tracestate", "congo=t61rcWkgMzE")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if v := got.Get("traceparent"); v != validParent {
+		t.Errorf("got traceparent %q, expected %q", v, validParent)
+	}
+
+	if v := got.Get("tracestate"); v != "congo=t61rcWkgMzE" {
+		t.Errorf("got tracestate %q", v)
+	}
+}
+
+func TestTransportWithoutContext(t *testing.T) {
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: &tracecontext.Transport{}}
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", downstream.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+	resp.Body.Close()
+
+	if v := got.Get("traceparent"); v != "" {
+		t.Errorf("got traceparent %q, expected none", v)
+	}
+}
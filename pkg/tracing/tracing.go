@@ -0,0 +1,126 @@
+// Package tracing creates OpenTelemetry spans and exports them via OTLP.
+//
+// Without the environment variable OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, the
+// spans are not recorded. Starting a span is cheap in this case.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracecontext"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	envEndpoint    = environment.NewVariable("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "", "URL of an OTLP/HTTP collector for traces, for example `http://collector:4318/v1/traces`. Empty disables tracing.")
+	envServiceName = environment.NewVariable("OTEL_SERVICE_NAME", "autoupdate", "Name of the service in the exported spans.")
+	envSampleRatio = environment.NewVariable("OTEL_TRACES_SAMPLER_RATIO", "1", "Fraction of the requests without a sampled parent span, that are traced. Requests with a sampled parent are always traced.")
+)
+
+// instrumentation is the name of the tracer.
+const instrumentation = "github.com/OpenSlides/openslides-autoupdate-service"
+
+// New configures the global tracer provider from the environment.
+//
+// Returns a background task that exports the remaining spans, when the
+// context is done.
+func New(lookup environment.Environmenter) (func(context.Context, func(error)), error) {
+	endpoint := envEndpoint.Value(lookup)
+	serviceName := envServiceName.Value(lookup)
+	ratio, err := strconv.ParseFloat(envSampleRatio.Value(lookup), 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("invalid value for `%s`, expected a number between 0 and 1, got `%s`", envSampleRatio.Key, envSampleRatio.Value(lookup))
+	}
+
+	if endpoint == "" {
+		return func(context.Context, func(error)) {}, nil
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("creating otlp exporter: %w", err)
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	background := func(ctx context.Context, errorHandler func(error)) {
+		<-ctx.Done()
+		if err := provider.Shutdown(context.Background()); err != nil {
+			errorHandler(fmt.Errorf("shutdown tracer: %w", err))
+		}
+	}
+	return background, nil
+}
+
+// Start starts a new span as child of the span in the context.
+//
+// The span has to be ended with End.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span. If err is not nil, it is recorded on the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Middleware starts a span for each request.
+//
+// The parent of the span is read from the traceparent header. The trace
+// context of the new span is saved with tracecontext.NewContext, so outgoing
+// requests are children of the span.
+func Middleware(next http.Handler) http.Handler {
+	propagator := propagation.TraceContext{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(instrumentation).Start(
+			ctx,
+			r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if span.IsRecording() {
+			header := make(http.Header)
+			propagator.Inject(ctx, propagation.HeaderCarrier(header))
+			if tc, ok := tracecontext.Parse(header); ok {
+				ctx = tracecontext.NewContext(ctx, tc)
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
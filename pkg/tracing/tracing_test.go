@@ -0,0 +1,69 @@
+package tracing_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/environment"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracecontext"
+	"github.com/OpenSlides/openslides-autoupdate-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	var gotParent string
+	handler := tracing.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc, _ := tracecontext.FromContext(r.Context())
+		gotParent = tc.TraceParent
+
+		_, span := tracing.Start(r.Context(), "child")
+		tracing.End(span, errors.New("my error"))
+	}))
+
+	req := httptest.NewRequest("GET", "/system/autoupdate", nil)
+	req.Header.Set("traceparent", parent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, expected 2", len(spans))
+	}
+
+	child, server := spans[0], spans[1]
+	if got := server.Parent.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("server span has trace id %s", got)
+	}
+
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("child span is not a child of the server span")
+	}
+
+	if child.Status.Code != codes.Error {
+		t.Errorf("child span has status %v, expected error", child.Status.Code)
+	}
+
+	expectParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + server.SpanContext.SpanID().String() + "-01"
+	if gotParent != expectParent {
+		t.Errorf("got traceparent %s in context, expected %s", gotParent, expectParent)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	if _, err := tracing.New(environment.ForTests{}); err != nil {
+		t.Errorf("New without endpoint: %v", err)
+	}
+
+	if _, err := tracing.New(environment.ForTests{"OTEL_TRACES_SAMPLER_RATIO": "2"}); err == nil {
+		t.Errorf("New with invalid ratio did not return an error")
+	}
+}